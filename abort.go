@@ -0,0 +1,456 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// The annotations on a deploy's lock app that say what became of the deploy.
+const (
+	abortAnnotation     = "autopilot/abort-requested"
+	abandonedAnnotation = "autopilot/abandoned"
+)
+
+// abortPollInterval is how often a deploy checks whether it has been asked
+// to abort, and zero-downtime-abort whether the deploy has rolled back.
+var abortPollInterval = 5 * time.Second
+
+// LockState is what the deploy lock on an app says about the deploy that
+// took it.
+type LockState struct {
+	Found     bool
+	Holder    string
+	Taken     time.Time
+	Expires   time.Time
+	Abandoned bool
+
+	guid string
+}
+
+// Running says whether the deploy that took the lock is still going: it has
+// not been abandoned and the lock has not expired.
+func (state LockState) Running(now time.Time) bool {
+	return state.Found && !state.Abandoned && now.Before(state.Expires)
+}
+
+// Gone says whether the deploy that took the lock is known to have stopped:
+// it quit without rolling back, or has stopped renewing its lock. A lock
+// that does not say when it was taken was never renewed, so having expired
+// says nothing about its deploy, which may well still be running.
+func (state LockState) Gone(now time.Time) bool {
+	if !state.Found {
+		return false
+	}
+
+	return state.Abandoned || (!state.Taken.IsZero() && !now.Before(state.Expires))
+}
+
+// TakenAt is when the deploy took the lock. Locks that do not say were
+// taken by deploys that never renewed them, an hour before they expire.
+func (state LockState) TakenAt() time.Time {
+	if state.Taken.IsZero() {
+		return state.Expires.Add(-time.Hour)
+	}
+	return state.Taken
+}
+
+// LockState reads the deploy lock on appName.
+func (repo *ApplicationRepo) LockState(appName string) (LockState, error) {
+	lock, found, err := repo.findApp(lockAppName(appName))
+	if err != nil || !found {
+		return LockState{}, err
+	}
+
+	entity, err := repo.api.GetAppEntity(lock.Metadata.Guid)
+	if err != nil {
+		return LockState{}, err
+	}
+
+	annotations, err := repo.api.AppAnnotations(lock.Metadata.Guid)
+	if err != nil {
+		return LockState{}, err
+	}
+
+	env, _ := entity["environment_json"].(map[string]interface{})
+	holder, _ := env[lockHolderVar].(string)
+	takenAt, _ := env[lockTakenVar].(string)
+	expiresAt, _ := env[lockExpiresVar].(string)
+
+	// a lock that does not say when it expires is treated as expired, as
+	// breakExpiredLock does
+	taken, _ := time.Parse(time.RFC3339, takenAt)
+	expires, _ := time.Parse(time.RFC3339, expiresAt)
+
+	return LockState{
+		Found:     true,
+		Holder:    holder,
+		Taken:     taken,
+		Expires:   expires,
+		Abandoned: annotations[abandonedAnnotation] != "",
+		guid:      lock.Metadata.Guid,
+	}, nil
+}
+
+// RequestAbort asks the deploy holding the lock to roll back, by annotating
+// the lock app; the deploy watches for it.
+func (repo *ApplicationRepo) RequestAbort(state LockState, by string) error {
+	return repo.api.UpdateAppAnnotations(state.guid, map[string]string{abortAnnotation: by})
+}
+
+// markLockAbandoned notes on the lock that the deploy holding it has quit
+// without rolling back, so zero-downtime-abort does not wait for it.
+func (repo *ApplicationRepo) markLockAbandoned(appName string) error {
+	lock, found, err := repo.findApp(lockAppName(appName))
+	if err != nil || !found {
+		return err
+	}
+
+	return repo.api.UpdateAppAnnotations(lock.Metadata.Guid, map[string]string{abandonedAnnotation: "true"})
+}
+
+// AbortRequested returns who asked for the deploy to be aborted, or "" if
+// nobody has.
+func (lock *DeployLock) AbortRequested() (string, error) {
+	annotations, err := lock.repo.api.AppAnnotations(lock.guid)
+	if err != nil {
+		return "", err
+	}
+
+	return annotations[abortAnnotation], nil
+}
+
+// AbortWatch cancels a deploy's context when someone asks for the deploy to
+// be aborted, so that it finishes its current step and rolls back, as it does
+// on an interrupt.
+type AbortWatch struct {
+	ctx    context.Context
+	mutex  sync.Mutex
+	by     string
+	cancel func()
+}
+
+// WatchForAbort polls requested, which returns who asked for an abort, until
+// parent is done or someone has. Errors polling are ignored: the deploy
+// carries on as if nobody had asked.
+func WatchForAbort(parent context.Context, requested func() (string, error), interval time.Duration) *AbortWatch {
+	ctx, cancel := context.WithCancel(parent)
+	watch := &AbortWatch{ctx: ctx, cancel: cancel}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			by, err := requested()
+			if err == nil && by != "" {
+				watch.Abort(by)
+				return
+			}
+		}
+	}()
+
+	return watch
+}
+
+// Context is cancelled once the deploy is aborted.
+func (watch *AbortWatch) Context() context.Context {
+	return watch.ctx
+}
+
+// Abort rolls the deploy back on behalf of by. Only the first abort counts.
+func (watch *AbortWatch) Abort(by string) {
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+
+	if watch.by != "" {
+		return
+	}
+
+	watch.by = by
+	fmt.Printf("%s asked for the deploy to be aborted. Finishing the current step and rolling back.\n", by)
+	watch.cancel()
+}
+
+// AbortedBy returns who aborted the deploy, or "" if nobody did.
+func (watch *AbortWatch) AbortedBy() string {
+	if watch == nil {
+		return ""
+	}
+
+	watch.mutex.Lock()
+	defer watch.mutex.Unlock()
+
+	return watch.by
+}
+
+// AbortActions put the apps back the way they were before an unfinished
+// command on appName, which took the lock at since, started, going by the
+// apps it left: a rollback copy is left by a rollback, a scaled clone by a
+// scale, and the old version by a push. There are none if there is nothing
+// to undo.
+func (planner *DeploymentPlanner) AbortActions(appName string, since time.Time) ([]rewind.Action, error) {
+	naming := planner.Naming
+	actions := []rewind.Action{}
+
+	liveExists, err := planner.Repo.DoesAppExist(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, previous := range []string{naming.RollbackName(appName), naming.ScaledName(appName), naming.VenerableName(appName)} {
+		exists, err := planner.Repo.DoesAppExist(previous)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		ours, err := planner.madeSince(previous, since, liveExists)
+		if err != nil {
+			return nil, err
+		}
+		if !ours {
+			continue
+		}
+
+		switch previous {
+		case naming.RollbackName(appName):
+			// the rollback has moved the old version back under the app's
+			// name, or not yet
+			if liveExists {
+				actions = append(actions, planner.abortRename(appName, naming.VenerableName(appName)))
+			}
+			return planner.restoreActions(actions, previous, appName)
+
+		case naming.ScaledName(appName):
+			if liveExists {
+				return append(actions, planner.abortDelete(previous, "delete scaled clone")), nil
+			}
+			return append(actions, planner.abortRename(previous, appName)), nil
+
+		default:
+			if liveExists {
+				actions = append(actions, planner.abortDelete(appName, "delete new version"))
+			}
+			return planner.restoreActions(actions, previous, appName)
+		}
+	}
+
+	return actions, nil
+}
+
+// madeSince says whether the command that took the lock at since made
+// previous, rather than an earlier deploy, as with an old version kept by
+// --keep-existing-app: autopilot marks its copies when it makes them. A copy
+// with the app's name free is the command's too, as only it could have
+// renamed the app, even if it was stopped before marking the copy.
+func (planner *DeploymentPlanner) madeSince(previous string, since time.Time, liveExists bool) (bool, error) {
+	if !liveExists {
+		return true, nil
+	}
+
+	marker, managed, err := planner.Repo.ManagedMarker(previous)
+	if err != nil {
+		return false, err
+	}
+
+	return managed && !marker.CreatedAt.IsZero() && !marker.CreatedAt.Before(since), nil
+}
+
+// restoreActions move previous back under appName, and start it if the
+// deploy had stopped it.
+func (planner *DeploymentPlanner) restoreActions(actions []rewind.Action, previous, appName string) ([]rewind.Action, error) {
+	stopped, err := planner.Repo.IsAppStopped(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	actions = append(actions, planner.abortRename(previous, appName))
+	if stopped {
+		actions = append(actions, rewind.Action{
+			Name: "start previous version",
+			Forward: func() error {
+				return planner.Repo.StartApplication(appName)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Start %s, which the deploy had stopped.", appName),
+			},
+		})
+	}
+
+	return actions, nil
+}
+
+func (planner *DeploymentPlanner) abortRename(from, to string) rewind.Action {
+	return rewind.Action{
+		Name: "rename " + from,
+		Forward: func() error {
+			return planner.Repo.RenameApplication(from, to)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Rename %s to %s.", from, to),
+		},
+	}
+}
+
+func (planner *DeploymentPlanner) abortDelete(appName, name string) rewind.Action {
+	return rewind.Action{
+		Name: name,
+		Forward: func() error {
+			return planner.Repo.DeleteApplication(appName)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Delete %s.", appName),
+		},
+	}
+}
+
+// ParseAbortArgs takes zero-downtime-abort's app and flags.
+func ParseAbortArgs(args []string) (appName string, statusURL string, wait time.Duration, force bool, err error) {
+	statusURL, args = takeStringFlag(args, "status-url")
+	waitFor, args := takeStringFlag(args, "wait")
+	force, args = takeBoolFlag(args, "force")
+
+	if len(args) < 2 {
+		return "", "", 0, false, errors.New("zero-downtime-abort needs the name of an app")
+	}
+
+	wait = 10 * time.Minute
+	if waitFor != "" {
+		wait, err = time.ParseDuration(waitFor)
+		if err != nil || wait <= 0 {
+			return "", "", 0, false, fmt.Errorf("--wait takes a duration, e.g. 15m, not %q", waitFor)
+		}
+	}
+
+	return args[1], statusURL, wait, force, nil
+}
+
+// requestAbortAt asks the deploy serving its status at statusURL to abort.
+func requestAbortAt(statusURL string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	response, err := client.Post(strings.TrimSuffix(statusURL, "/")+"/abort", "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s answered %s", statusURL, response.Status)
+	}
+	return nil
+}
+
+// abort stops a deploy of the app. The apps a deploy known to have stopped
+// left behind are put back the way they were; any other is taken to be still
+// running, and is asked to roll back, and waited for.
+func abort(appRepo *ApplicationRepo, planner *DeploymentPlanner, args []string) error {
+	appName, statusURL, wait, force, err := ParseAbortArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if statusURL != "" {
+		err = requestAbortAt(statusURL)
+		if err != nil {
+			return fmt.Errorf("could not ask the deploy at %s to abort: %s", statusURL, err)
+		}
+	}
+
+	state, err := appRepo.LockState(appName)
+	if err != nil {
+		return err
+	}
+
+	if !state.Found {
+		return fmt.Errorf("No deploy of %s is running or was left unfinished, so there is nothing to abort. "+
+			"To go back to the previous version, run: cf zero-downtime-rollback %s", appName, appName)
+	}
+
+	if !state.Gone(time.Now()) {
+		return abortRunningDeploy(appRepo, appName, state, statusURL != "", wait)
+	}
+
+	fmt.Printf("The deploy of %s by %s did not finish.\n", appName, state.Holder)
+	actions, err := planner.AbortActions(appName, state.TakenAt())
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		fmt.Println("It left nothing to undo.")
+	} else {
+		fmt.Println("To put the apps back the way they were before it started:")
+		for i, action := range actions {
+			fmt.Printf("  %d. %s\n", i+1, action.Description.Forward)
+		}
+
+		if !force && !confirm("Go ahead?") {
+			return errors.New("Nothing was changed.")
+		}
+
+		err = (&rewind.Actions{
+			Actions:  actions,
+			Observer: StepPrinter{Colors: colors, Out: os.Stdout}.ObserveStep,
+		}).Execute()
+		if err != nil {
+			return fmt.Errorf("Could not finish putting the apps back: %s. Check them with: cf zero-downtime-status %s", err, appName)
+		}
+	}
+
+	fmt.Printf("Removing the deploy lock on %s\n", appName)
+	return (&DeployLock{repo: appRepo, guid: state.guid}).Release()
+}
+
+// abortRunningDeploy asks the deploy holding the lock to roll back, unless
+// it has been asked through its status port already, and waits for it to
+// release the lock.
+func abortRunningDeploy(appRepo *ApplicationRepo, appName string, state LockState, asked bool, wait time.Duration) error {
+	if !asked {
+		by, err := appRepo.lockHolder()
+		if err != nil {
+			return err
+		}
+
+		err = appRepo.RequestAbort(state, by)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Asked the deploy of %s by %s to abort. Waiting for it to roll back...\n", appName, state.Holder)
+
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		time.Sleep(abortPollInterval)
+
+		current, err := appRepo.LockState(appName)
+		if err != nil {
+			return err
+		}
+		if !current.Found {
+			fmt.Printf("The deploy of %s has rolled back.\n", appName)
+			return nil
+		}
+		if current.Gone(time.Now()) {
+			return fmt.Errorf("The deploy of %s quit without rolling back. Run cf zero-downtime-abort %s again to put the apps back.", appName, appName)
+		}
+	}
+
+	return fmt.Errorf("The deploy of %s has not finished rolling back after %s. Check on it with: cf zero-downtime-status %s", appName, wait, appName)
+}
@@ -0,0 +1,306 @@
+package autopilot_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Aborting a deploy", func() {
+	Describe("ParseAbortArgs", func() {
+		It("takes the app and the flags", func() {
+			appName, statusURL, wait, force, err := ParseAbortArgs([]string{"zero-downtime-abort", "app", "--status-url", "http://127.0.0.1:8123", "--wait", "2m", "--force"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(appName).To(Equal("app"))
+			Expect(statusURL).To(Equal("http://127.0.0.1:8123"))
+			Expect(wait).To(Equal(2 * time.Minute))
+			Expect(force).To(BeTrue())
+		})
+
+		It("waits ten minutes for a running deploy by default", func() {
+			_, _, wait, force, err := ParseAbortArgs([]string{"zero-downtime-abort", "app"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(wait).To(Equal(10 * time.Minute))
+			Expect(force).To(BeFalse())
+		})
+
+		It("needs an app and a duration to wait", func() {
+			_, _, _, _, err := ParseAbortArgs([]string{"zero-downtime-abort"})
+			Expect(err).To(MatchError("zero-downtime-abort needs the name of an app"))
+
+			_, _, _, _, err = ParseAbortArgs([]string{"zero-downtime-abort", "app", "--wait", "soon"})
+			Expect(err).To(MatchError(`--wait takes a duration, e.g. 15m, not "soon"`))
+		})
+	})
+
+	Describe("WatchForAbort", func() {
+		It("cancels the deploy once someone asks for an abort", func() {
+			answers := []string{"", "", "alice on ci-worker"}
+			watch := WatchForAbort(context.Background(), func() (string, error) {
+				answer := answers[0]
+				if len(answers) > 1 {
+					answers = answers[1:]
+				}
+				return answer, nil
+			}, time.Millisecond)
+
+			Eventually(watch.Context().Done()).Should(BeClosed())
+			Expect(watch.Context().Err()).To(Equal(context.Canceled))
+			Expect(watch.AbortedBy()).To(Equal("alice on ci-worker"))
+		})
+
+		It("stops watching when the deploy is over, without aborting it", func() {
+			parent, cancel := context.WithCancel(context.Background())
+			watch := WatchForAbort(parent, func() (string, error) { return "", nil }, time.Millisecond)
+			cancel()
+
+			Eventually(watch.Context().Done()).Should(BeClosed())
+			Expect(watch.AbortedBy()).To(BeEmpty())
+		})
+
+		It("counts only the first abort", func() {
+			watch := WatchForAbort(context.Background(), func() (string, error) { return "", nil }, time.Hour)
+			watch.Abort("the status endpoint")
+			watch.Abort("bob on laptop")
+
+			Expect(watch.AbortedBy()).To(Equal("the status endpoint"))
+		})
+	})
+
+	Describe("AbortActions", func() {
+		var (
+			repo    *recordingRepo
+			planner *DeploymentPlanner
+			lockAt  time.Time
+		)
+
+		// made notes that the deploy holding the lock made the copy
+		made := func(appName string) {
+			repo.existing[appName] = true
+			repo.markers[appName] = ManagedMarker{OriginalName: "app", CreatedAt: lockAt.Add(time.Minute)}
+		}
+
+		abort := func() []string {
+			actions, err := planner.AbortActions("app", lockAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			repo.calls = nil
+			Expect(rewind.Actions{Actions: actions}.Execute()).To(Succeed())
+			return repo.calls
+		}
+
+		BeforeEach(func() {
+			repo = newRecordingRepo()
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: discardLogger{},
+			}
+			lockAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("deletes the new version of an unfinished push and restarts the old one", func() {
+			repo.existing["app"] = true
+			made("app-venerable")
+			repo.stopped["app-venerable"] = true
+
+			Expect(abort()).To(Equal([]string{
+				"DeleteApplication app",
+				"RenameApplication app-venerable app",
+				"StartApplication app",
+			}))
+		})
+
+		It("moves the old version back when the push got no further than renaming it", func() {
+			repo.existing["app-venerable"] = true
+
+			Expect(abort()).To(Equal([]string{"RenameApplication app-venerable app"}))
+		})
+
+		It("swaps the versions back after an unfinished rollback", func() {
+			repo.existing["app"] = true
+			made("app-rollback")
+
+			Expect(abort()).To(Equal([]string{
+				"RenameApplication app app-venerable",
+				"RenameApplication app-rollback app",
+			}))
+		})
+
+		It("deletes the clone of an unfinished scale", func() {
+			repo.existing["app"] = true
+			made("app-scaled")
+
+			Expect(abort()).To(Equal([]string{"DeleteApplication app-scaled"}))
+		})
+
+		It("goes by the planner's naming", func() {
+			planner.Naming = prefixNaming{}
+			repo.existing["app"] = true
+			made("old-app")
+
+			Expect(abort()).To(Equal([]string{
+				"DeleteApplication app",
+				"RenameApplication old-app app",
+			}))
+		})
+
+		It("leaves a kept venerable app alone when the push was stopped before the rename", func() {
+			repo.existing["app"] = true
+			repo.existing["app-venerable"] = true
+			repo.markers["app-venerable"] = ManagedMarker{OriginalName: "app", CreatedAt: lockAt.Add(-24 * time.Hour)}
+
+			actions, err := planner.AbortActions("app", lockAt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actions).To(BeEmpty())
+		})
+
+		It("leaves alone a copy that does not say when it was made", func() {
+			repo.existing["app"] = true
+			repo.existing["app-venerable"] = true
+
+			actions, err := planner.AbortActions("app", lockAt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actions).To(BeEmpty())
+		})
+
+		It("does nothing when the deploy left nothing behind", func() {
+			repo.existing["app"] = true
+
+			actions, err := planner.AbortActions("app", lockAt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actions).To(BeEmpty())
+		})
+	})
+
+	Describe("the deploy lock", func() {
+		var server *fakecc.Server
+
+		BeforeEach(func() {
+			server = fakecc.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		lock := func(expires time.Time) {
+			server.AddApp("app-autopilot-lock")
+			cli := server.CLI()
+			_, err := cli.CliCommandWithoutTerminalOutput("set-env", "app-autopilot-lock", "AUTOPILOT_LOCK_HOLDER", "alice on ci-worker")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cli.CliCommandWithoutTerminalOutput("set-env", "app-autopilot-lock", "AUTOPILOT_LOCK_EXPIRES", expires.UTC().Format(time.RFC3339))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("says a deploy is running until its lock expires", func() {
+			lock(time.Now().Add(time.Hour))
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Found).To(BeTrue())
+			Expect(state.Holder).To(Equal("alice on ci-worker"))
+			Expect(state.Running(time.Now())).To(BeTrue())
+			Expect(state.Running(time.Now().Add(2 * time.Hour))).To(BeFalse())
+		})
+
+		It("reads when the deploy took the lock, however often it has renewed it", func() {
+			lock(time.Now().Add(time.Hour))
+			taken := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+			_, err := server.CLI().CliCommandWithoutTerminalOutput("set-env", "app-autopilot-lock", "AUTOPILOT_LOCK_TAKEN", taken.Format(time.RFC3339))
+			Expect(err).ToNot(HaveOccurred())
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.TakenAt()).To(BeTemporally("==", taken))
+		})
+
+		It("says a deploy that was abandoned is not running", func() {
+			lock(time.Now().Add(time.Hour))
+			server.Annotate("app-autopilot-lock", map[string]string{"autopilot/abandoned": "true"})
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Abandoned).To(BeTrue())
+			Expect(state.Running(time.Now())).To(BeFalse())
+			Expect(state.Gone(time.Now())).To(BeTrue())
+		})
+
+		It("says a deploy that stopped renewing its lock is gone", func() {
+			lock(time.Now().Add(-time.Minute))
+			_, err := server.CLI().CliCommandWithoutTerminalOutput("set-env", "app-autopilot-lock", "AUTOPILOT_LOCK_TAKEN", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+			Expect(err).ToNot(HaveOccurred())
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Running(time.Now())).To(BeFalse())
+			Expect(state.Gone(time.Now())).To(BeTrue())
+		})
+
+		It("does not take a deploy whose lock is not renewed to be gone once the lock expires", func() {
+			lock(time.Now().Add(-time.Minute))
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Running(time.Now())).To(BeFalse())
+			Expect(state.Gone(time.Now())).To(BeFalse())
+		})
+
+		It("does not take a running deploy to be gone", func() {
+			lock(time.Now().Add(time.Hour))
+			_, err := server.CLI().CliCommandWithoutTerminalOutput("set-env", "app-autopilot-lock", "AUTOPILOT_LOCK_TAKEN", time.Now().UTC().Format(time.RFC3339))
+			Expect(err).ToNot(HaveOccurred())
+
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Gone(time.Now())).To(BeFalse())
+		})
+
+		It("asks the deploy to abort on its lock", func() {
+			lock(time.Now().Add(time.Hour))
+			repo := NewApplicationRepo(server.CLI())
+
+			state, err := repo.LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.RequestAbort(state, "bob on laptop")).To(Succeed())
+
+			app, _ := server.App("app-autopilot-lock")
+			Expect(app.Annotations).To(HaveKeyWithValue("autopilot/abort-requested", "bob on laptop"))
+		})
+
+		It("finds no deploy when there is no lock", func() {
+			state, err := NewApplicationRepo(server.CLI()).LockState("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Found).To(BeFalse())
+			Expect(state.Running(time.Now())).To(BeFalse())
+		})
+	})
+
+	It("aborts the deploy on a POST to the status endpoint", func() {
+		progress := NewProgressServer("zero-downtime-push", "app", 3)
+		Expect(progress.Listen(0)).To(Succeed())
+		defer progress.Close()
+
+		response, err := http.Post("http://"+progress.Addr+"/abort", "application/json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+
+		aborted := ""
+		progress.SetAbort(func(by string) { aborted = by })
+
+		response, err = http.Post("http://"+progress.Addr+"/abort", "application/json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(aborted).To(HavePrefix("a request from 127.0.0.1:"))
+	})
+})
@@ -0,0 +1,92 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+type AppState = repository.AppState
+type InstanceStatus = repository.InstanceStatus
+
+// GetAppState looks up whether the app is started and how many of its
+// instances are running or have crashed.
+func (repo *ApplicationRepo) GetAppState(appName string) (AppState, error) {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return AppState{}, err
+	}
+
+	state := AppState{
+		Name:             appName,
+		Guid:             app.Guid,
+		State:            strings.ToUpper(app.State),
+		Instances:        app.InstanceCount,
+		RunningInstances: app.RunningInstances,
+	}
+	for _, instance := range app.Instances {
+		if strings.EqualFold(instance.State, "crashed") {
+			state.CrashedInstances++
+		}
+	}
+
+	return state, nil
+}
+
+// Instances lists the state of each of the app's instances, in order of
+// their index.
+func (repo *ApplicationRepo) Instances(appName string) ([]InstanceStatus, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("App %s not found", appName)
+	}
+
+	return repo.instancesByGuid(app.Metadata.Guid)
+}
+
+// instancesByGuid lists the instances of the app with the guid, which may be
+// in another space.
+func (repo *ApplicationRepo) instancesByGuid(appGuid string) ([]InstanceStatus, error) {
+	instances, err := repo.api.Instances(appGuid)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []InstanceStatus{}
+	for _, instance := range instances {
+		seconds := int64(instance.Since)
+		nanoseconds := int64((instance.Since - float64(seconds)) * float64(time.Second))
+		statuses = append(statuses, InstanceStatus{
+			Index:   instance.Index,
+			State:   instance.State,
+			Since:   time.Unix(seconds, nanoseconds).UTC(),
+			Details: instance.Details,
+		})
+	}
+
+	return statuses, nil
+}
+
+// describeInstances says what state each instance is in, and why when the
+// Cloud Controller knows, e.g. "#0 RUNNING, #1 CRASHED (out of memory)".
+func describeInstances(instances []InstanceStatus) string {
+	if len(instances) == 0 {
+		return "none are reported"
+	}
+
+	descriptions := []string{}
+	for _, instance := range instances {
+		description := fmt.Sprintf("#%d %s", instance.Index, instance.State)
+		if instance.Details != "" {
+			description += fmt.Sprintf(" (%s)", instance.Details)
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,78 @@
+package autopilot_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("App state", func() {
+	var (
+		api     *ghttp.Server
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("counts the running and crashed instances", func() {
+		cliConn.GetAppReturns(plugin_models.GetAppModel{
+			Guid:             "app-guid",
+			State:            "started",
+			InstanceCount:    3,
+			RunningInstances: 1,
+			Instances: []plugin_models.GetApp_AppInstanceFields{
+				{State: "running"}, {State: "crashed"}, {State: "starting"},
+			},
+		}, nil)
+
+		state, err := repo.GetAppState("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state).To(Equal(AppState{Name: "app", Guid: "app-guid", State: "STARTED", Instances: 3, RunningInstances: 1, CrashedInstances: 1}))
+	})
+
+	It("lists the instances in order, with why they are down", func() {
+		api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app"}}]}`))
+		api.RouteToHandler("GET", "/v2/apps/app-guid/instances", ghttp.RespondWith(http.StatusOK, `{
+			"1": {"state": "CRASHED", "since": 1717762320.5, "details": "out of memory"},
+			"0": {"state": "RUNNING", "since": 1717762320}
+		}`))
+
+		instances, err := repo.Instances("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(Equal([]InstanceStatus{
+			{Index: 0, State: "RUNNING", Since: time.Date(2024, 6, 7, 12, 12, 0, 0, time.UTC)},
+			{Index: 1, State: "CRASHED", Since: time.Date(2024, 6, 7, 12, 12, 0, int(500*time.Millisecond), time.UTC), Details: "out of memory"},
+		}))
+	})
+
+	It("fails for an app that does not exist", func() {
+		api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+		_, err := repo.Instances("app")
+		Expect(err).To(MatchError("App app not found"))
+	})
+})
@@ -0,0 +1,83 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+)
+
+// ParseShowApps takes the --show-apps flag, which every command accepts, out
+// of args. Arguments after -- are left alone.
+func ParseShowApps(args []string) (bool, []string) {
+	return takeBoolFlag(args, "show-apps")
+}
+
+// FormatAppSummary describes the app in a line: its state, running
+// instances and routes.
+func FormatAppSummary(app plugin_models.GetAppModel) string {
+	routes := []string{}
+	for _, route := range app.Routes {
+		url := route.Domain.Name
+		if route.Host != "" {
+			url = route.Host + "." + url
+		}
+		routes = append(routes, url)
+	}
+
+	routeList := "no routes"
+	if len(routes) > 0 {
+		routeList = strings.Join(routes, ", ")
+	}
+
+	return fmt.Sprintf("%s: %s, %d/%d instances running, %s",
+		app.Name, strings.ToLower(app.State), app.RunningInstances, app.InstanceCount, routeList)
+}
+
+// AppSummary describes each of the apps a deploy involved, a line each. An
+// app that does not exist, such as one just deleted, is said to be gone.
+func (repo *ApplicationRepo) AppSummary(appNames ...string) ([]string, error) {
+	lines := []string{}
+	for i, appName := range appNames {
+		exists, err := repo.DoesAppExist(appName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			// only the app deployed is worth saying is gone
+			if i == 0 {
+				lines = append(lines, fmt.Sprintf("%s: does not exist", appName))
+			}
+			continue
+		}
+
+		app, err := repo.conn.GetApp(appName)
+		if err != nil {
+			return nil, err
+		}
+		app.Name = appName
+		lines = append(lines, FormatAppSummary(app))
+	}
+
+	return lines, nil
+}
+
+// showApps prints the apps the deploy involved, or every app in the space
+// with --show-apps, as cf apps does.
+func showApps(appRepo *ApplicationRepo, all bool, appNames ...string) error {
+	if all {
+		return appRepo.ListApplications()
+	}
+
+	lines, err := appRepo.AppSummary(appNames...)
+	if err != nil {
+		warnf("could not describe the app: %s\n", err)
+		return nil
+	}
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Summarising the apps a deploy involved", func() {
+	It("takes the show apps flag out of the args", func() {
+		all, args := ParseShowApps([]string{"zero-downtime-push", "app", "--show-apps", "-f", "manifest.yml"})
+		Expect(all).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("describes an app's state, instances and routes in a line", func() {
+		app := plugin_models.GetAppModel{
+			Name:             "orders",
+			State:            "started",
+			InstanceCount:    3,
+			RunningInstances: 2,
+			Routes: []plugin_models.GetApp_RouteSummary{
+				{Host: "orders", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+				{Domain: plugin_models.GetApp_DomainFields{Name: "orders.example.org"}},
+			},
+		}
+		Expect(FormatAppSummary(app)).To(Equal("orders: started, 2/3 instances running, orders.example.com, orders.example.org"))
+
+		app = plugin_models.GetAppModel{Name: "orders-venerable", State: "STOPPED", InstanceCount: 3}
+		Expect(FormatAppSummary(app)).To(Equal("orders-venerable: stopped, 0/3 instances running, no routes"))
+	})
+
+	Describe("AppSummary", func() {
+		var (
+			api     *ghttp.Server
+			cliConn *pluginfakes.FakeCliConnection
+			repo    *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			api = ghttp.NewServer()
+
+			cliConn = &pluginfakes.FakeCliConnection{}
+			cliConn.ApiEndpointReturns(api.URL(), nil)
+			cliConn.AccessTokenReturns("bearer some-token", nil)
+			cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+				SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+			}, nil)
+			cliConn.GetAppReturns(plugin_models.GetAppModel{State: "started", InstanceCount: 1, RunningInstances: 1}, nil)
+
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		AfterEach(func() {
+			api.Close()
+		})
+
+		It("describes the apps that exist, without listing the whole space", func() {
+			api.RouteToHandler("GET", "/v2/apps", func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Query().Get("q") == "name:app" {
+					w.Write([]byte(`{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app"}}]}`))
+					return
+				}
+				w.Write([]byte(`{"resources":[]}`))
+			})
+
+			lines, err := repo.AppSummary("app", "app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"app: started, 1/1 instances running, no routes"}))
+			Expect(cliConn.GetAppArgsForCall(0)).To(Equal("app"))
+			Expect(cliConn.CliCommandCallCount()).To(Equal(0))
+		})
+
+		It("says when the app deployed is gone", func() {
+			api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			lines, err := repo.AppSummary("app", "app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"app: does not exist"}))
+		})
+	})
+})
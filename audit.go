@@ -0,0 +1,102 @@
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ReportEvent is a Cloud Controller audit event recorded against the app
+// while it was deployed, so the report shows who did what.
+type ReportEvent struct {
+	Type      string `json:"type"`
+	Actor     string `json:"actor"`
+	ActorType string `json:"actor_type"`
+	App       string `json:"app"`
+	AppGuid   string `json:"app_guid"`
+	Timestamp string `json:"timestamp"`
+}
+
+// auditAnnotation is set on the app by --audit-event. The Cloud Controller
+// has no way to create custom audit events, but it records the change to the
+// annotation as an audit.app.update event against the deploying user.
+const auditAnnotation = "autopilot/last-deploy"
+
+// ParseAuditEvent takes the --audit-event flag, which every command accepts,
+// out of args. Arguments after -- are left alone.
+func ParseAuditEvent(args []string) (bool, []string) {
+	return takeBoolFlag(args, "audit-event")
+}
+
+// RecordAuditEvent notes on the app that autopilot deployed it, when and for
+// whom.
+func (repo *ApplicationRepo) RecordAuditEvent(appName, command string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	user, err := repo.conn.Username()
+	if err != nil {
+		return err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("%s by %s at %s", command, user, time.Now().UTC().Format(time.RFC3339))
+	return api.UpdateAppAnnotations(app.Metadata.Guid, map[string]string{auditAnnotation: note})
+}
+
+// AppEvents lists the audit events recorded since the given time against any
+// of the apps, oldest first.
+func (repo *ApplicationRepo) AppEvents(since time.Time, guids ...string) ([]ReportEvent, error) {
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []ReportEvent{}
+	seen := map[string]bool{}
+	for _, guid := range guids {
+		if guid == "" || seen[guid] {
+			continue
+		}
+		seen[guid] = true
+
+		appEvents, err := api.Events(guid, since)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range appEvents {
+			events = append(events, ReportEvent{
+				Type:      event.Entity.Type,
+				Actor:     event.Entity.ActorName,
+				ActorType: event.Entity.ActorType,
+				App:       event.Entity.ActeeName,
+				AppGuid:   event.Entity.Actee,
+				Timestamp: event.Entity.Timestamp,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	return events, nil
+}
+
+// AddEvents records the audit events of the deploy in the report.
+func (report *DeploymentReport) AddEvents(events []ReportEvent) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.Events = append(report.Events, events...)
+}
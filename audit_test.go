@@ -0,0 +1,113 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("ParseAuditEvent", func() {
+	It("takes the audit event flag out of the args", func() {
+		audit, args := ParseAuditEvent([]string{"zero-downtime-push", "app", "--audit-event", "-f", "manifest.yml"})
+		Expect(audit).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+		audit, args = ParseAuditEvent([]string{"zero-downtime-push", "app", "--", "--audit-event"})
+		Expect(audit).To(BeFalse())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "--", "--audit-event"}))
+	})
+})
+
+var _ = Describe("Audit events", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.UsernameReturns("alice", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("notes the deploy in an annotation on the app", func() {
+		var annotations map[string]map[string]map[string]string
+		api.AppendHandlers(
+			ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app-name"}}]}`),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PATCH", "/v3/apps/app-guid"),
+				ghttp.VerifyContentType("application/json"),
+				func(w http.ResponseWriter, req *http.Request) {
+					Expect(json.NewDecoder(req.Body).Decode(&annotations)).To(Succeed())
+				},
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			),
+		)
+
+		Expect(repo.RecordAuditEvent("app-name", "zero-downtime-push")).To(Succeed())
+		Expect(annotations["metadata"]["annotations"]["autopilot/last-deploy"]).To(MatchRegexp(`^zero-downtime-push by alice at \d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`))
+	})
+
+	It("fails when the app does not exist", func() {
+		api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+		Expect(repo.RecordAuditEvent("app-name", "zero-downtime-push")).To(MatchError("App app-name not found"))
+	})
+
+	It("lists the events of both versions of the app in order", func() {
+		api.RouteToHandler("GET", "/v2/events", func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Query()["q"][0] {
+			case "actee:old-guid":
+				w.Write([]byte(`{"resources":[
+					{"entity":{"type":"audit.app.update","actor_name":"alice","actor_type":"user","actee":"old-guid","actee_name":"app-name-venerable","timestamp":"2026-03-01T10:00:02Z"}}
+				]}`))
+			case "actee:new-guid":
+				w.Write([]byte(`{"resources":[
+					{"entity":{"type":"audit.app.create","actor_name":"alice","actor_type":"user","actee":"new-guid","actee_name":"app-name","timestamp":"2026-03-01T10:00:05Z"}},
+					{"entity":{"type":"audit.app.map-route","actor_name":"alice","actor_type":"user","actee":"new-guid","actee_name":"app-name","timestamp":"2026-03-01T10:00:09Z"}}
+				]}`))
+			}
+		})
+
+		events, err := repo.AppEvents(time.Now(), "new-guid", "old-guid", "new-guid", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(api.ReceivedRequests()).To(HaveLen(2))
+		Expect(events).To(Equal([]ReportEvent{
+			{Type: "audit.app.update", Actor: "alice", ActorType: "user", App: "app-name-venerable", AppGuid: "old-guid", Timestamp: "2026-03-01T10:00:02Z"},
+			{Type: "audit.app.create", Actor: "alice", ActorType: "user", App: "app-name", AppGuid: "new-guid", Timestamp: "2026-03-01T10:00:05Z"},
+			{Type: "audit.app.map-route", Actor: "alice", ActorType: "user", App: "app-name", AppGuid: "new-guid", Timestamp: "2026-03-01T10:00:09Z"},
+		}))
+	})
+})
+
+var _ = Describe("DeploymentReport events", func() {
+	It("starts empty and collects the deploy's events", func() {
+		report := NewDeploymentReport("zero-downtime-push", "app", "old-guid", nil)
+		Expect(report.Events).To(BeEmpty())
+
+		report.AddEvents([]ReportEvent{{Type: "audit.app.create", AppGuid: "new-guid"}})
+		Expect(report.Events).To(Equal([]ReportEvent{{Type: "audit.app.create", AppGuid: "new-guid"}}))
+	})
+})
@@ -0,0 +1,65 @@
+package autopilot
+
+import (
+	"fmt"
+)
+
+// The variables that let a deploy log itself in, so a pipeline worker does
+// not need a cf login step first.
+const (
+	apiVar          = "AUTOPILOT_API"
+	clientIDVar     = "AUTOPILOT_CLIENT_ID"
+	clientSecretVar = "AUTOPILOT_CLIENT_SECRET"
+	orgVar          = "AUTOPILOT_ORG"
+	spaceVar        = "AUTOPILOT_SPACE"
+)
+
+// Authenticate logs the cf CLI session in with UAA client credentials when
+// they are given in the environment, then targets the org and space. The cf
+// commands autopilot runs, and its own API calls, all use that session.
+// Without credentials the existing session is used as is.
+func (repo *ApplicationRepo) Authenticate(getenv func(string) string) error {
+	clientID, clientSecret := getenv(clientIDVar), getenv(clientSecretVar)
+	if clientID == "" && clientSecret == "" {
+		return nil
+	}
+
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("Both %s and %s are needed to log in with client credentials.", clientIDVar, clientSecretVar)
+	}
+
+	if api := getenv(apiVar); api != "" {
+		args := []string{"api", api}
+		if repo.skipSSLValidation {
+			fmt.Println(sslWarning(api))
+			args = append(args, "--skip-ssl-validation")
+		}
+
+		_, err := repo.conn.CliCommandWithoutTerminalOutput(args...)
+		if err != nil {
+			return fmt.Errorf("Could not set the API to %s: %s", api, err)
+		}
+	}
+
+	_, err := repo.conn.CliCommandWithoutTerminalOutput("auth", clientID, clientSecret, "--client-credentials")
+	if err != nil {
+		return fmt.Errorf("Could not log in as client %s: %s", clientID, err)
+	}
+	// the session has a new token, whose expiry is not known yet
+	repo.tokenFetched = false
+
+	target := []string{"target"}
+	if org := getenv(orgVar); org != "" {
+		target = append(target, "-o", org)
+	}
+	if space := getenv(spaceVar); space != "" {
+		target = append(target, "-s", space)
+	}
+
+	if len(target) == 1 {
+		return nil
+	}
+
+	_, err = repo.conn.CliCommandWithoutTerminalOutput(target...)
+	return err
+}
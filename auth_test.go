@@ -0,0 +1,82 @@
+package autopilot_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Authenticate", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		env     map[string]string
+	)
+
+	getenv := func(name string) string {
+		return env[name]
+	}
+
+	commands := func() [][]string {
+		calls := [][]string{}
+		for i := 0; i < cliConn.CliCommandWithoutTerminalOutputCallCount(); i++ {
+			calls = append(calls, cliConn.CliCommandWithoutTerminalOutputArgsForCall(i))
+		}
+		return calls
+	}
+
+	BeforeEach(func() {
+		cliConn = &pluginfakes.FakeCliConnection{}
+		repo = NewApplicationRepo(cliConn)
+		env = map[string]string{}
+	})
+
+	It("uses the existing session without credentials", func() {
+		Expect(repo.Authenticate(getenv)).To(Succeed())
+		Expect(commands()).To(BeEmpty())
+	})
+
+	It("logs in with client credentials and targets the space", func() {
+		env["AUTOPILOT_API"] = "https://api.example.com"
+		env["AUTOPILOT_CLIENT_ID"] = "deployer"
+		env["AUTOPILOT_CLIENT_SECRET"] = "secret"
+		env["AUTOPILOT_ORG"] = "org"
+		env["AUTOPILOT_SPACE"] = "space"
+
+		Expect(repo.Authenticate(getenv)).To(Succeed())
+		Expect(commands()).To(Equal([][]string{
+			{"api", "https://api.example.com"},
+			{"auth", "deployer", "secret", "--client-credentials"},
+			{"target", "-o", "org", "-s", "space"},
+		}))
+	})
+
+	It("skips SSL validation when setting the API if asked to", func() {
+		env["AUTOPILOT_API"] = "https://api.lab.example.com"
+		env["AUTOPILOT_CLIENT_ID"] = "deployer"
+		env["AUTOPILOT_CLIENT_SECRET"] = "secret"
+		repo.SkipSSLValidation()
+
+		Expect(repo.Authenticate(getenv)).To(Succeed())
+		Expect(commands()[0]).To(Equal([]string{"api", "https://api.lab.example.com", "--skip-ssl-validation"}))
+	})
+
+	It("needs both the client id and secret", func() {
+		env["AUTOPILOT_CLIENT_ID"] = "deployer"
+
+		Expect(repo.Authenticate(getenv)).To(MatchError("Both AUTOPILOT_CLIENT_ID and AUTOPILOT_CLIENT_SECRET are needed to log in with client credentials."))
+	})
+
+	It("reports failed logins without the secret", func() {
+		env["AUTOPILOT_CLIENT_ID"] = "deployer"
+		env["AUTOPILOT_CLIENT_SECRET"] = "secret"
+		cliConn.CliCommandWithoutTerminalOutputReturns(nil, errors.New("Credentials were rejected"))
+
+		Expect(repo.Authenticate(getenv)).To(MatchError("Could not log in as client deployer: Credentials were rejected"))
+	})
+})
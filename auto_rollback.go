@@ -0,0 +1,220 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+var (
+	// defaultProbeWatchWindow is how long the routes are watched when
+	// --auto-rollback-window is not given.
+	defaultProbeWatchWindow = 5 * time.Minute
+	// probeWatchInterval is the pause between rounds of requests.
+	probeWatchInterval = 5 * time.Second
+	// probeWatchMinRequests is how many requests are sent before the error
+	// rate and latency are judged, so one early failure is not a breach.
+	probeWatchMinRequests = 10
+)
+
+// ProbeWatch rolls the deploy back when the production routes fail too
+// often or answer too slowly for a while after the cutover. The old version
+// is kept, stopped, until the window has passed, to roll back to.
+type ProbeWatch struct {
+	// Window is how long after the cutover the routes are watched.
+	Window time.Duration
+	// MaxErrorRate is the percentage of requests that may fail.
+	MaxErrorRate float64
+	// MaxLatency is the longest the requests may take on average, or 0 for
+	// no limit.
+	MaxLatency time.Duration
+}
+
+// ParseProbeWatch reads --auto-rollback-on-probe-failure and the thresholds
+// that go with it.
+func ParseProbeWatch(enabled bool, window time.Duration, maxErrorRate float64, maxLatency time.Duration) (ProbeWatch, error) {
+	if !enabled {
+		if window != 0 || maxLatency != 0 {
+			return ProbeWatch{}, errors.New("--auto-rollback-window and --max-latency need --auto-rollback-on-probe-failure")
+		}
+		return ProbeWatch{}, nil
+	}
+
+	if window < 0 {
+		return ProbeWatch{}, fmt.Errorf("--auto-rollback-window %s should not be negative", window)
+	}
+	if maxErrorRate < 0 || maxErrorRate > 100 {
+		return ProbeWatch{}, fmt.Errorf("--max-error-rate %g should be a percentage between 0 and 100", maxErrorRate)
+	}
+	if maxLatency < 0 {
+		return ProbeWatch{}, fmt.Errorf("--max-latency %s should not be negative", maxLatency)
+	}
+
+	if window == 0 {
+		window = defaultProbeWatchWindow
+	}
+
+	return ProbeWatch{Window: window, MaxErrorRate: maxErrorRate, MaxLatency: maxLatency}, nil
+}
+
+// Enabled is true when --auto-rollback-on-probe-failure was given.
+func (watch ProbeWatch) Enabled() bool {
+	return watch.Window > 0
+}
+
+// ProbeStats counts the requests sent to the production routes.
+type ProbeStats struct {
+	Sent    int
+	Failed  int
+	Elapsed time.Duration
+}
+
+// ErrorRate is the percentage of requests that failed.
+func (stats ProbeStats) ErrorRate() float64 {
+	if stats.Sent == 0 {
+		return 0
+	}
+	return float64(stats.Failed) * 100 / float64(stats.Sent)
+}
+
+// Latency is how long the requests took on average.
+func (stats ProbeStats) Latency() time.Duration {
+	if stats.Sent == 0 {
+		return 0
+	}
+	return stats.Elapsed / time.Duration(stats.Sent)
+}
+
+// Breach says how the requests so far exceed the thresholds, or nothing if
+// they do not, or too few were sent to tell.
+func (watch ProbeWatch) Breach(stats ProbeStats) string {
+	if stats.Sent < probeWatchMinRequests {
+		return ""
+	}
+
+	if stats.ErrorRate() > watch.MaxErrorRate {
+		return fmt.Sprintf("%d of %d requests failed, more than the %g%% --max-error-rate allows", stats.Failed, stats.Sent, watch.MaxErrorRate)
+	}
+	if watch.MaxLatency > 0 && stats.Latency() > watch.MaxLatency {
+		return fmt.Sprintf("requests took %s on average, more than the %s --max-latency allows", stats.Latency(), watch.MaxLatency)
+	}
+
+	return ""
+}
+
+// Watch requests each of the URLs in turn until the window has passed, and
+// fails as soon as the thresholds are breached, or ctx is done. A request
+// fails as a --probe-path request does.
+func (watch ProbeWatch) Watch(ctx context.Context, urls []string, prober Prober, clock Clock) (ProbeStats, error) {
+	stats := ProbeStats{}
+	deadline := clock.Now().Add(watch.Window)
+	for {
+		for _, url := range urls {
+			start := time.Now()
+			err := prober.check(url)
+			stats.Elapsed += time.Since(start)
+			stats.Sent++
+			if err != nil {
+				stats.Failed++
+			}
+		}
+
+		breach := watch.Breach(stats)
+		if breach != "" {
+			return stats, fmt.Errorf("the production routes are failing since the cutover: %s", breach)
+		}
+
+		if !clock.Now().Before(deadline) {
+			return stats, nil
+		}
+		err := sleepContext(ctx, clock, probeWatchInterval)
+		if err != nil {
+			return stats, err
+		}
+	}
+}
+
+// probeWatchAction stops the old version once the new one has the routes,
+// but keeps it until the routes have been watched for the window. Should
+// they fail, the old version is started and given the routes back before
+// the new one is deleted, as in any rollback.
+func (planner *DeploymentPlanner) probeWatchAction(appName string, options AutopilotOptions, unmapVenerable, remapVenerable func() error) rewind.Action {
+	venerable := planner.Naming.VenerableName(appName)
+	watch := options.ProbeWatch
+
+	// whether the old version was stopped, to start it again on a rollback
+	stopped := false
+
+	return rewind.Action{
+		Name: "watch production routes",
+		Forward: func() error {
+			stopped = false
+			if !watch.Enabled() {
+				return nil
+			}
+
+			if options.KeepRunning {
+				planner.Logger.Printf("Not watching the production routes, as the old version keeps its routes.\n")
+				return nil
+			}
+
+			routes, err := planner.Repo.AppRoutes(appName)
+			if err != nil {
+				return err
+			}
+			routes = checkableRoutes(options.Domains.Filter(routes))
+			if len(routes) == 0 {
+				planner.Logger.Printf("The new version has no routes to watch.\n")
+				return nil
+			}
+
+			err = unmapVenerable()
+			if err != nil {
+				return err
+			}
+
+			planner.Logger.Printf("Stopping %s, keeping it to roll back to until the production routes have been watched.\n", venerable)
+			err = planner.Repo.StopApplication(venerable)
+			if err != nil {
+				return err
+			}
+			stopped = true
+
+			urls := []string{}
+			for _, route := range routes {
+				urls = append(urls, "https://"+route+"/"+strings.TrimLeft(options.Probe.Path, "/"))
+			}
+
+			planner.Logger.Printf("Watching %s for %s\n", strings.Join(routes, ", "), watch.Window)
+			stats, err := watch.Watch(planner.context(), urls, options.Probe, planner.Clock)
+			if err != nil {
+				return err
+			}
+
+			planner.Logger.Printf("%d of %d requests failed within %s, taking %s on average.\n", stats.Failed, stats.Sent, watch.Window, stats.Latency())
+			return nil
+		},
+		ReversePrevious: func() error {
+			if stopped {
+				planner.Logger.Printf("Starting %s again.\n", venerable)
+				err := planner.Repo.StartApplication(venerable)
+				if err != nil {
+					return err
+				}
+			}
+
+			return remapVenerable()
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Unmap and stop %s, then request %s on each production route for %s, and roll the deploy back if more than %g%% of the requests fail%s.",
+				venerable, describeChoice(options.Probe.Path != "", options.Probe.Path, "/"), watch.Window, watch.MaxErrorRate,
+				describeIf(watch.MaxLatency > 0, fmt.Sprintf(" or they take longer than %s on average", watch.MaxLatency))),
+			When:            onlyWith("--auto-rollback-on-probe-failure", watch.Enabled() && !options.KeepRunning),
+			ReversePrevious: fmt.Sprintf("Start %s again and map the routes back to it.", venerable),
+		},
+	}
+}
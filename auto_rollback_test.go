@@ -0,0 +1,190 @@
+package autopilot_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Automatic rollback", func() {
+	Describe("ParseProbeWatch", func() {
+		It("watches for five minutes unless told otherwise", func() {
+			Expect(ParseProbeWatch(true, 0, 5, 0)).To(Equal(ProbeWatch{Window: 5 * time.Minute, MaxErrorRate: 5}))
+			Expect(ParseProbeWatch(true, 10*time.Minute, 1, time.Second)).To(Equal(ProbeWatch{Window: 10 * time.Minute, MaxErrorRate: 1, MaxLatency: time.Second}))
+			Expect(ParseProbeWatch(false, 0, 5, 0)).To(Equal(ProbeWatch{}))
+		})
+
+		It("rejects thresholds without the flag, or out of range", func() {
+			_, err := ParseProbeWatch(false, time.Minute, 5, 0)
+			Expect(err).To(MatchError("--auto-rollback-window and --max-latency need --auto-rollback-on-probe-failure"))
+
+			_, err = ParseProbeWatch(true, 0, 101, 0)
+			Expect(err).To(MatchError("--max-error-rate 101 should be a percentage between 0 and 100"))
+
+			_, err = ParseProbeWatch(true, 0, 5, -time.Second)
+			Expect(err).To(MatchError("--max-latency -1s should not be negative"))
+		})
+
+		It("is read from the command line", func() {
+			_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--auto-rollback-on-probe-failure", "--auto-rollback-window", "10m", "--max-error-rate", "2.5", "--max-latency", "500ms"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.ProbeWatch).To(Equal(ProbeWatch{Window: 10 * time.Minute, MaxErrorRate: 2.5, MaxLatency: 500 * time.Millisecond}))
+		})
+
+		It("cannot be given with --unmap-routes", func() {
+			_, _, _, _, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--auto-rollback-on-probe-failure", "--unmap-routes"})
+			Expect(err).To(MatchError(ContainSubstring("--auto-rollback-on-probe-failure stops the old version")))
+		})
+	})
+
+	Describe("Watch", func() {
+		var (
+			server *ghttp.Server
+			clock  *fakeClock
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			clock = &fakeClock{}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("requests the routes until the window has passed", func() {
+			server.RouteToHandler("GET", "/healthz", ghttp.RespondWith(http.StatusOK, "ok"))
+
+			watch := ProbeWatch{Window: 30 * time.Second, MaxErrorRate: 5}
+			stats, err := watch.Watch(context.Background(), []string{server.URL() + "/healthz"}, Prober{}, clock)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Sent).To(Equal(7))
+			Expect(stats.Failed).To(BeZero())
+			Expect(clock.slept).To(HaveLen(6))
+		})
+
+		It("tolerates failures within the error rate", func() {
+			requests := 0
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, req *http.Request) {
+				requests++
+				if requests == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+				}
+			})
+
+			watch := ProbeWatch{Window: time.Minute, MaxErrorRate: 10}
+			stats, err := watch.Watch(context.Background(), []string{server.URL() + "/"}, Prober{}, clock)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Failed).To(Equal(1))
+		})
+
+		It("fails once too many requests fail", func() {
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+			watch := ProbeWatch{Window: time.Hour, MaxErrorRate: 5}
+			stats, err := watch.Watch(context.Background(), []string{server.URL() + "/"}, Prober{}, clock)
+			Expect(err).To(MatchError("the production routes are failing since the cutover: 10 of 10 requests failed, more than the 5% --max-error-rate allows"))
+			Expect(stats.Sent).To(Equal(10))
+		})
+
+		It("fails when the requests are too slow", func() {
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, req *http.Request) {
+				time.Sleep(2 * time.Millisecond)
+			})
+
+			watch := ProbeWatch{Window: time.Hour, MaxErrorRate: 5, MaxLatency: time.Millisecond}
+			_, err := watch.Watch(context.Background(), []string{server.URL() + "/"}, Prober{}, clock)
+			Expect(err).To(MatchError(ContainSubstring("more than the 1ms --max-latency allows")))
+		})
+
+		It("stops watching as soon as the deploy is interrupted", func() {
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, "ok"))
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			watch := ProbeWatch{Window: time.Hour, MaxErrorRate: 5}
+			stats, err := watch.Watch(ctx, []string{server.URL() + "/"}, Prober{}, clock)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(stats.Sent).To(Equal(1))
+			Expect(clock.slept).To(BeEmpty())
+		})
+	})
+
+	Describe("during a push", func() {
+		var (
+			repo         *recordingRepo
+			planner      *DeploymentPlanner
+			manifestPath string
+			options      AutopilotOptions
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: discardLogger{},
+			}
+			options = AutopilotOptions{ProbeWatch: ProbeWatch{Window: time.Minute, MaxErrorRate: 5}}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("starts the old version and gives it the routes back before deleting the new one when the routes fail", func() {
+			repo.routes["app"] = []string{"localhost"}
+			repo.routes["app-venerable"] = []string{"localhost"}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError("the production routes are failing since the cutover: 10 of 10 requests failed, more than the 5% --max-error-rate allows"))
+
+			stopped := indexOf(repo.calls, "StopApplication app-venerable")
+			started := indexOf(repo.calls, "StartApplication app-venerable")
+			remapped := indexOf(repo.calls, "MapRoutes app-venerable [localhost]")
+			Expect(stopped).To(BeNumerically(">", indexOf(repo.calls, "UnmapRoutes app-venerable [localhost]")))
+			Expect(started).To(BeNumerically(">", stopped))
+			Expect(remapped).To(BeNumerically(">", started))
+			Expect(indexOf(repo.calls, "DeleteApplication app")).To(BeNumerically(">", remapped))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+		})
+
+		It("gives the routes back without starting the old version when it could not be stopped", func() {
+			repo.routes["app"] = []string{"localhost"}
+			repo.routes["app-venerable"] = []string{"localhost"}
+			repo.failures["StopApplication app-venerable"] = errors.New("stop failed")
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError("stop failed"))
+			Expect(repo.calls).To(ContainElement("MapRoutes app-venerable [localhost]"))
+			Expect(repo.calls).ToNot(ContainElement("StartApplication app-venerable"))
+		})
+
+		It("does nothing without the flag", func() {
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).ToNot(ContainElement("StopApplication app-venerable"))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app-venerable"))
+		})
+	})
+})
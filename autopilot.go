@@ -1,351 +1,234 @@
-package main
+package autopilot
 
 import (
-	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
-	"os"
-	"strings"
+	"time"
 
 	"github.com/cloudfoundry/cli/plugin"
-	"github.com/concourse/autopilot/rewind"
+	"github.com/concourse/autopilot/capi"
+	"github.com/concourse/autopilot/repository"
 )
 
-func fatalIf(err error) {
-	if err != nil {
-		fmt.Fprintln(os.Stdout, "error:", err)
-		os.Exit(1)
-	}
-}
-
-func main() {
-	plugin.Start(&AutopilotPlugin{})
-}
-
-type AutopilotPlugin struct{}
-
-type Route struct {
-	Host []string
-	Domain string
-}
+type Route = repository.Route
 
 func venerableAppName(appName string) string {
-	return fmt.Sprintf("%s-venerable", appName)
+	return SuffixNaming{}.VenerableName(appName)
 }
 
 func rollbackAppName(appName string) string {
-	return fmt.Sprintf("%s-rollback", appName)
+	return SuffixNaming{}.RollbackName(appName)
 }
 
-//Check to see if venerable app has routes. if it does not, go get the routes for the current app, and put them on the
-//venerable.
-
-//If the rollback has no routes, it is going to receive the routes of the most recent version of the app regardless of
-//what the original unmapped venerable had for routes.
-func getActionsForRollback(appName string, appRepo *ApplicationRepo, args []string) []rewind.Action {
-	return []rewind.Action{
-		//Rename live app
-		{
-			Forward: func() error {
-				return appRepo.RenameApplication(appName, rollbackAppName(appName))
-			},
-			ReversePrevious: func() error {
-				return appRepo.RenameApplication(rollbackAppName(appName), appName)
-			},
-		},
-
-		//See if venerable app has routes
-		{
-			Forward: func() error {
-				route, _ := appRepo.FindUrls(venerableAppName(appName))
-
-				if((len(route.Host)) < 1) {
-					newAppRoute, _ := appRepo.FindUrls(rollbackAppName(appName))
-
-					errMapRoutes := appRepo.MapRoutes(venerableAppName(appName), newAppRoute)
-					if (errMapRoutes != nil){
-						fmt.Println("error in apprepo.MapRoutes")
-						return errMapRoutes
-					}
-
-					errUnmapRoutes := appRepo.UnmapRoutes(rollbackAppName(appName), newAppRoute)
-					if (errUnmapRoutes != nil) {
-						fmt.Println("error in apprepo.Unmaproutes")
-						return errUnmapRoutes
-					}
-				}
-				return nil
-			},
-			ReversePrevious: func() error {
-				route, _ := appRepo.FindUrls(rollbackAppName(appName))
-
-				if((len(route.Host)) < 1) {
-					newAppRoute, _ := appRepo.FindUrls(venerableAppName(appName))
-
-					errMapRoutes := appRepo.MapRoutes(rollbackAppName(appName), newAppRoute)
-					if (errMapRoutes != nil) {
-						fmt.Println("Error in appRepo.MapRoutes")
-						return errMapRoutes
-					}
-
-					errUnmapRoutes := appRepo.UnmapRoutes(venerableAppName(appName), newAppRoute)
-					if (errUnmapRoutes != nil) {
-						fmt.Println("Error in appRepo.UnmapRoutes")
-						return errUnmapRoutes
-					}
-				}
-				return nil
-			},
-		},
-		//Rename venerable app
-		{
-			Forward: func() error {
-				return appRepo.RenameApplication(venerableAppName(appName), appName)
-			},
-			ReversePrevious: func() error {
-				appRepo.RenameApplication(venerableAppName(appName), appName)
-				return appRepo.RenameApplication(appName, venerableAppName(appName))
-			},
-		},
-		//Start rollback app
-		{
-			Forward: func() error {
-				return appRepo.StartApplication(appName)
-
-			},
-		},
-		//Delete rolled back app
-		{
-			Forward: func() error {
-				return appRepo.DeleteApplication(rollbackAppName(appName))
-			},
-		},
-	}
-}
+var ErrNoManifest = errors.New("a manifest is required to push this application")
 
-func getActionsForPush(appRepo *ApplicationRepo, args []string) []rewind.Action {
-	appName, manifestPath, appPath, options, err := ParseArgs(args)
-	fatalIf(err)
+type ApplicationRepo struct {
+	conn      plugin.CliConnection
+	api       *capi.Client
+	verbosity Verbosity
+	redactor  *Redactor
 
-	appExists, err := appRepo.DoesAppExist(appName)
-	fatalIf(err)
+	skipSSLValidation bool
 
-	if appExists {
-		return getActionsForExistingApp(appRepo, appName, manifestPath, appPath, options)
-	} else {
-		return getActionsForNewApp(appRepo, appName, manifestPath, appPath)
-	}
+	// tokenExpiry is when the session's access token expires, once
+	// freshenToken has fetched it, or zero if that can't be told.
+	tokenExpiry  time.Time
+	tokenFetched bool
 }
 
-func getActionsForExistingApp(appRepo *ApplicationRepo, appName, manifestPath, appPath string, options AutopilotOptions) []rewind.Action {
-	return []rewind.Action{
-		// delete old version if it still exists
-		{
-			Forward: func() error {
-				appExists, err := appRepo.DoesAppExist(venerableAppName(appName))
-				fatalIf(err)
-				if(appExists) {
-					fmt.Println("Found old version of app running, deleting.")
-					return appRepo.DeleteApplication(venerableAppName(appName))
-				} else {
-					return nil
-				}
-			},
-		},
-		// rename
-		{
-			Forward: func() error {
-				return appRepo.RenameApplication(appName, venerableAppName(appName))
-			},
-		},
-		// push
-		{
-			Forward: func() error {
-				return appRepo.PushApplication(appName, manifestPath, appPath)
-			},
-			ReversePrevious: func() error {
-				// If the app cannot start we'll have a lingering application
-				// We delete this application so that the rename can succeed
-				appRepo.DeleteApplication(appName)
-
-				return appRepo.RenameApplication(venerableAppName(appName), appName)
-			},
-		},
-		// delete/unmap
-
-		{
-			Forward: func() error {
-				if(options.KeepExisting){
-					fmt.Println("Stopping old version of app. Remove the --keep-existing-app flag to delete it automatically.")
-					return appRepo.StopApplication(venerableAppName(appName))
-				} else if (options.UnmapRoute){
-					fmt.Println("Unmapping routes for the venerable app. Remove the --unmap-routes flag to delete the old version.")
-					route, err := appRepo.FindUrls(venerableAppName(appName))
-
-					if(err != nil) {
-						fmt.Errorf("Error finding Urls")
-					}
-
-					fmt.Println("Unmapping old version of the app.")
-					return appRepo.UnmapRoutes(venerableAppName(appName), route)
-				} else {
-					fmt.Println("Deleting old version of app. Use the --keep-existing-app flag to preserve it.")
-					return appRepo.DeleteApplication(venerableAppName(appName))
-				}
-			},
-		},
-	}
+type AutopilotOptions struct {
+	KeepExisting bool
+	UnmapRoute bool
+	// KeepRunning leaves the old version running with its routes, as the
+	// keep disposition of the config file asks.
+	KeepRunning bool
+	ContinueOnRouteError bool
+	Env EnvVars
+	StrictRoutes bool
+	// RoutesFrom is RoutesFromManifest to take the routes from the manifest
+	// alone, or RoutesFromApp.
+	RoutesFrom string
+	CopyRouteServices bool
+	TestRoute string
+	// TestRouteService is bound to the test route while the new app is
+	// checked on it, and TestRouteHTTPSOnly checks plain http is not served.
+	TestRouteService string
+	TestRouteHTTPSOnly bool
+	PushArgs []string
+	DrainWait time.Duration
+	Domains DomainFilter
+	// CutoverOrder moves the production routes a domain at a time.
+	CutoverOrder CutoverOrder
+	Warmup time.Duration
+	WarmupRequests int
+	Probe Prober
+	// ReadyLog holds the cutover until the new app logs a matching line.
+	ReadyLog ReadyLog
+	// RouteCheck confirms the routes reach the new version after the cutover.
+	RouteCheck RouteCheck
+	// CrashWatch holds the cutover until the new version has settled
+	// without crashing too often.
+	CrashWatch CrashWatch
+	// ProbeWatch rolls back to the old version, kept stopped, should the
+	// production routes fail after the cutover.
+	ProbeWatch ProbeWatch
+	// VerifySSH is run inside the new app with cf ssh before the cutover.
+	VerifySSH string
+	// LogDrains are bound to the new app before it starts, as the live app
+	// forwards its logs to them and the manifest does not bind them.
+	LogDrains []string
+	// PremapRoutes maps the production routes to the new app before it
+	// starts, instead of once it is running.
+	PremapRoutes bool
+	// Premap are the routes mapped to the new app before it starts.
+	Premap []string
+	Diff bool
+	FailOnDrift []string
+	DeleteOrphanedRoutes bool
+	CheckSecurityGroups bool
+	AllowStoppedApp bool
+	// StagedStart is the percentage of instances to start at a time, or 0.
+	StagedStart int
+	Revision string
+	Vars EnvVars
+	RotateServiceKeys []string
+	Buildpacks []string
+	Lifecycle string
+	StartCommand string
+	ForceNameCollision bool
+	// Scale overrides the manifest's instances, memory and disk.
+	Scale ScaleOptions
+	// HealthCheck overrides the manifest's health check.
+	HealthCheck HealthCheck
+	// Timeouts are given to every command, not parsed with the push's flags.
+	Timeouts Timeouts
+	// Package zips the app path before pushing it.
+	Package bool
+	// SkipIfUnchanged skips a push of what the live app was last pushed with.
+	SkipIfUnchanged bool
+	// ResumeFrom is the step to resume the last, failed, deploy from.
+	ResumeFrom string
 }
 
-func getActionsForNewApp(appRepo *ApplicationRepo, appName, manifestPath, appPath string) []rewind.Action {
-	return []rewind.Action{
-		// push
-		{
-			Forward: func() error {
-				return appRepo.PushApplication(appName, manifestPath, appPath)
-			},
-		},
-	}
+type RollbackOptions struct {
+	ContinueOnRouteError bool
+	From string
+	Restage bool
+	StartFirst bool
+	Yes bool
+	Domains DomainFilter
+	ForceNameCollision bool
+	// Strategy is copy, or revisions to deploy an earlier revision in place.
+	Strategy string
+	// Revision is the version to roll back to with the revisions strategy,
+	// or 0 for the one before the app's current one.
+	Revision int
 }
 
-func (plugin AutopilotPlugin) Run(cliConnection plugin.CliConnection, args []string) {
-	appRepo := NewApplicationRepo(cliConnection)
-
-	appName := args[1]
-	var actionList []rewind.Action
-	var	successMessage string
+func NewApplicationRepo(conn plugin.CliConnection) *ApplicationRepo {
+	return &ApplicationRepo{
+		conn: conn,
+	}
+}
 
-	if(args[0] == "zero-downtime-push") {
-		actionList = getActionsForPush(appRepo, args)
-		successMessage = "A new version of your application has successfully been pushed!"
-	} else if (args[0] == "zero-downtime-rollback") {
-		appExists, err := appRepo.DoesAppExist(appName)
-		fatalIf(err)
-		venerableAppExists, err := appRepo.DoesAppExist(venerableAppName(appName))
-		fatalIf(err)
+// client builds the Cloud Controller client from the CLI session the first
+// time it is needed.
+func (repo *ApplicationRepo) client() (*capi.Client, error) {
+	if repo.api != nil {
+		return repo.api, nil
+	}
 
-		if(!appExists){
-			fatalIf(errors.New(fmt.Sprintf("Live version of app \"%s\" not found, cannot rollback.", appName)))
-		}
-		if(!venerableAppExists){
-			fatalIf(errors.New(fmt.Sprintf("Venerable version of \"%s\" not found, cannot rollback. Make sure you push with the " +
-			"--keep-existing-app flag to leave the venerable version behind.", appName)))
-		}
-		actionList = getActionsForRollback(appName, appRepo, args)
-		successMessage = "Your application has been successfully rolled back!"
+	endpoint, err := repo.conn.ApiEndpoint()
+	if err != nil {
+		return nil, err
 	}
 
-	actions := rewind.Actions{
-		Actions:              actionList,
-		RewindFailureMessage: "Oh no. Something's gone wrong. I've tried to roll back but you should check to see if everything is OK.",
+	sslDisabled, err := repo.conn.IsSSLDisabled()
+	if err != nil {
+		return nil, err
 	}
 
-	err := actions.Execute()
-	fatalIf(err)
+	sslDisabled = sslDisabled || repo.skipSSLValidation
+	if sslDisabled {
+		fmt.Println(colors.Warning(sslWarning(endpoint)))
+	}
 
-	fmt.Println()
-	fmt.Println(successMessage)
-	fmt.Println()
+	if proxy := proxyFor(endpoint); proxy != "" && repo.verbosity == VerboseVerbosity {
+		fmt.Printf("Sending API requests through the proxy at %s.\n", proxy)
+	}
 
-	err = appRepo.ListApplications()
-	fatalIf(err)
+	repo.api = capi.NewClient(endpoint, repo.conn.AccessToken, sslDisabled)
+	return repo.api, nil
 }
 
-func (AutopilotPlugin) GetMetadata() plugin.PluginMetadata {
-	return plugin.PluginMetadata{
-		Name: "autopilot",
-		Version: plugin.VersionType{
-			Major: 0,
-			Minor: 0,
-			Build: 3,
-		},
-		Commands: []plugin.Command{
-			{
-				Name:     "zero-downtime-push",
-				HelpText: "Perform a zero-downtime push of an application over the top of an old one",
-				UsageDetails: plugin.Usage{
-					Usage: "$ cf zero-downtime-push application-to-replace \\ \n \t-f path/to/new_manifest.yml \\ \n \t-p path/to/new/path",
-				},
-			},
-			{
-				Name:"zero-downtime-rollback",
-				HelpText: "Perform a zero-downtime rollback to the previous version of the application. Requires that the previous, 'venerable' version of the app still exists." +
-					"Use the --keep-existing-app flag when performing a zero-downtime-push to ensure this.",
-				UsageDetails:plugin.Usage{
-					Usage:"$cf zero-downtime-rollback application-to-revert",
-				},
-			},
-		},
+func (repo *ApplicationRepo) findApp(appName string) (capi.App, bool, error) {
+	api, err := repo.client()
+	if err != nil {
+		return capi.App{}, false, err
 	}
-}
-
-func ParseArgs(args []string) (string, string, string, AutopilotOptions, error) {
-	flags := flag.NewFlagSet("zero-downtime-push", flag.ContinueOnError)
-	manifestPath := flags.String("f", "", "path to an application manifest")
-	appPath := flags.String("p", "", "path to application files")
-	keepVenerable := flags.Bool("keep-existing-app", false, "keep existing app running")
-	unmapVenerableRoutes := flags.Bool("unmap-routes", false, "unmap routes for the venerable app")
 
-	err := flags.Parse(args[2:])
+	space, err := repo.conn.GetCurrentSpace()
 	if err != nil {
-		return "", "", "", AutopilotOptions{}, err
+		return capi.App{}, false, err
 	}
 
-	appName := args[1]
+	return api.FindApp(space.Guid, appName)
+}
 
-	if *manifestPath == "" {
-		return "", "", "", AutopilotOptions{}, ErrNoManifest
+func (repo *ApplicationRepo) RenameApplication(oldName, newName string) error {
+	app, found, err := repo.findApp(oldName)
+	if err != nil {
+		return err
 	}
 
-	options := AutopilotOptions{KeepExisting: *keepVenerable, UnmapRoute: *unmapVenerableRoutes}
-
-	return appName, *manifestPath, *appPath, options, nil
-}
-
-var ErrNoManifest = errors.New("a manifest is required to push this application")
+	if !found {
+		return fmt.Errorf("App %s not found", oldName)
+	}
 
-type ApplicationRepo struct {
-	conn plugin.CliConnection
+	fmt.Printf("Renaming app %s to %s\n", oldName, newName)
+	return repo.api.RenameApp(app.Metadata.Guid, newName)
 }
 
-type AutopilotOptions struct {
-	KeepExisting bool
-	UnmapRoute bool
-}
+func (repo *ApplicationRepo) PushApplication(appName, manifestPath, appPath string, extraArgs ...string) error {
+	args := []string{"push", appName, "-f", manifestPath}
 
-func NewApplicationRepo(conn plugin.CliConnection) *ApplicationRepo {
-	return &ApplicationRepo{
-		conn: conn,
+	if appPath != "" {
+		args = append(args, "-p", appPath)
 	}
-}
 
-func (repo *ApplicationRepo) RenameApplication(oldName, newName string) error {
-	_, err := repo.conn.CliCommand("rename", oldName, newName)
+	args = append(args, extraArgs...)
+
+	err := repo.cliCommand(args...)
 	return err
 }
 
-func (repo *ApplicationRepo) PushApplication(appName, manifestPath, appPath string) error {
-	args := []string{"push", appName, "-f", manifestPath}
-
-	if appPath != "" {
-		args = append(args, "-p", appPath)
+// SetEnv sets an environment variable without echoing its value, which may
+// well be a secret.
+func (repo *ApplicationRepo) SetEnv(appName, name, value string) error {
+	fmt.Printf("Setting env variable %s for app %s\n", name, appName)
+	err := repo.freshenToken("set-env")
+	if err != nil {
+		return err
 	}
 
-	_, err := repo.conn.CliCommand(args...)
+	_, err = repo.conn.CliCommandWithoutTerminalOutput("set-env", appName, name, value)
 	return err
 }
 
 func (repo *ApplicationRepo) DeleteApplication(appName string) error {
-	_, err := repo.conn.CliCommand("delete", appName, "-f")
+	err := repo.cliCommand("delete", appName, "-f")
 	return err
 }
 
 func (repo *ApplicationRepo) StartApplication(appName string) error {
-	_, err := repo.conn.CliCommand("start", appName)
+	err := repo.cliCommand("start", appName)
 	return err
 }
 
+func (repo *ApplicationRepo) RestageApplication(appName string) error {
+	return repo.cliCommand("restage", appName)
+}
+
 func (repo *ApplicationRepo) UnmapRoutes(appName string, route Route) error {
 	fmt.Println("Unmapping ", appName, " from ", route.Domain, route.Host)
 	return repo.UnmapRouteFromApp(appName, route)
@@ -355,103 +238,228 @@ func (repo *ApplicationRepo) MapRoutes(appName string, route Route) error {
 	return repo.MapRoutesToApp(appName, route)
 }
 
+type routeTarget struct {
+	spaceGuid  string
+	appGuid    string
+	domainGuid string
+}
+
+func (repo *ApplicationRepo) findRouteTarget(appName, domain string) (routeTarget, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return routeTarget{}, err
+	}
+
+	if !found {
+		return routeTarget{}, fmt.Errorf("App %s not found", appName)
+	}
+
+	domainGuid, found, err := repo.api.FindDomain(domain)
+	if err != nil {
+		return routeTarget{}, err
+	}
+
+	if !found {
+		return routeTarget{}, fmt.Errorf("Domain %s not found", domain)
+	}
+
+	return routeTarget{
+		spaceGuid:  app.Entity.SpaceGuid,
+		appGuid:    app.Metadata.Guid,
+		domainGuid: domainGuid,
+	}, nil
+}
+
 func (repo *ApplicationRepo) UnmapRouteFromApp(appName string, r Route) error {
-	count := len(r.Host)
-	if (count == 0) {
+	if len(routeHosts(r)) == 0 {
 		return fmt.Errorf("No routes in the app.")
 	}
-	for i := 0; i<len(r.Host); i++ {
-		repo.conn.CliCommand("unmap-route", appName, r.Domain, "--hostname", r.Host[i])
-		count = count -1
-		if(count == 0) {
-			fmt.Println("Unmapping complete for all routes in %s", appName)
-			return nil
+
+	target, err := repo.findRouteTarget(appName, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	err = forEachHost(routeHosts(r), func(job string) error {
+		host, domainGuid, path, err := repo.routeHostDomain(job, target)
+		if err != nil {
+			return err
+		}
+
+		route, found, err := repo.api.FindRouteWithPath(host, domainGuid, path)
+		if err != nil {
+			return err
 		}
+
+		if !found {
+			return fmt.Errorf("Route %s does not exist", hostURL(job, r.Domain))
+		}
+
+		return repo.api.UnmapRoute(route.Metadata.Guid, target.appGuid)
+	})
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("Route could not be unmapped")
+
+	fmt.Printf("Unmapping complete for all routes in %s\n", appName)
+	return nil
 }
 
 func (repo *ApplicationRepo) MapRoutesToApp(appName string, r Route) error {
-	count := len(r.Host)
-	if (count == 0) {
+	if len(routeHosts(r)) == 0 {
 		return fmt.Errorf("There are no routes to add.")
 	}
-	for i := 0; i<len(r.Host); i++ {
-		repo.conn.CliCommand("map-route", appName, r.Domain, "--hostname", r.Host[i])
-		count = count-1
-		if(count == 0) {
-			fmt.Println("Mapping routes to app: ", appName)
-			return nil
+
+	target, err := repo.findRouteTarget(appName, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	err = forEachHost(routeHosts(r), func(job string) error {
+		host, domainGuid, path, err := repo.routeHostDomain(job, target)
+		if err != nil {
+			return err
+		}
+
+		route, found, err := repo.api.FindRouteWithPath(host, domainGuid, path)
+		if err != nil {
+			return err
 		}
+
+		if !found {
+			route, err = repo.api.CreateRouteWithPath(host, domainGuid, target.spaceGuid, path)
+			if err != nil {
+				return err
+			}
+		}
+
+		return repo.api.MapRoute(route.Metadata.Guid, target.appGuid)
+	})
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("Error mapping routes to venerable app name")
+
+	fmt.Println("Mapping routes to app: ", appName)
+	return nil
 }
 
 func (repo *ApplicationRepo) StopApplication(appName string) error {
-	_, err := repo.conn.CliCommand("stop", appName)
+	err := repo.cliCommand("stop", appName)
 	return err
 }
 
 func (repo *ApplicationRepo) ListApplications() error {
-	_, err := repo.conn.CliCommand("apps")
+	err := repo.cliCommand("apps")
 	return err
 }
 
+// AppRoutes lists the app's routes as "host.domain/path".
+func (repo *ApplicationRepo) AppRoutes(appName string) ([]string, error) {
+	appRoutes, err := repo.listAppRoutes(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []string{}
+	for _, route := range appRoutes {
+		routes = append(routes, route.url())
+	}
+
+	return routes, nil
+}
+
+// FindUrls returns ErrNoRoutes for an app without routes, and a
+// *RouteLookupError when its routes could not be determined.
 func (repo *ApplicationRepo) FindUrls(appName string) (Route, error) {
-	route := Route{nil, "apps.foundry.mrll.com"}
+	route := Route{Domain: "apps.foundry.mrll.com"}
 
-	i, err := repo.conn.GetApp(appName)
+	appRoutes, err := repo.listAppRoutes(appName)
 
 	if(err != nil) {
-		return route, err
+		return route, &RouteLookupError{App: appName, Err: err}
 	}
 
-	appHosts := i.Routes
-
-	if(appHosts == nil) {
-		return route, fmt.Errorf("No routes for this app.")
+	if(len(appRoutes) == 0) {
+		return route, ErrNoRoutes
 	}
 
-	for _, element := range appHosts {
-		route.Host = append(route.Host, element.Host)
+	for _, element := range appRoutes {
+		// wildcard routes keep their own domain, rather than becoming the
+		// host * on the app's
+		if (element.host == wildcardHost) {
+			route.Wildcards = append(route.Wildcards, element.domain)
+			continue
+		}
+		// the other hosts keep their own domains too, which the route
+		// takes from the first of them
+		if (len(route.Host) == 0) {
+			route.Domain = element.domain
+		}
+		route.Host = append(route.Host, element.host)
+		route.Domains = append(route.Domains, element.domain)
+		route.Paths = append(route.Paths, element.path)
 	}
 
 	return route, nil
 }
 
-func (repo *ApplicationRepo) DoesAppExist(appName string) (bool, error) {
-	space, err := repo.conn.GetCurrentSpace()
+// CheckRollbackCopy makes sure a copy of the app chosen to roll back to is
+// stopped, so it is not serving some other purpose, and staged, so it can be
+// started.
+func (repo *ApplicationRepo) CheckRollbackCopy(copyName string) error {
+	app, found, err := repo.findApp(copyName)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	path := fmt.Sprintf(`v2/apps?q=name:%s&q=space_guid:%s`, appName, space.Guid)
-	result, err := repo.conn.CliCommandWithoutTerminalOutput("curl", path)
+	if !found {
+		return fmt.Errorf("App copy \"%s\" not found, cannot rollback.", copyName)
+	}
 
-	if err != nil {
-		return false, err
+	if app.Entity.State != "STOPPED" {
+		return fmt.Errorf("App copy \"%s\" is %s, only a stopped copy can be rolled back to.", copyName, app.Entity.State)
+	}
+
+	if app.Entity.PackageState != "STAGED" {
+		return fmt.Errorf("App copy \"%s\" has not been staged, cannot rollback.", copyName)
 	}
 
-	jsonResp := strings.Join(result, "")
+	return nil
+}
 
-	output := make(map[string]interface{})
-	err = json.Unmarshal([]byte(jsonResp), &output)
+func (repo *ApplicationRepo) DoesAppExist(appName string) (bool, error) {
+	_, found, err := repo.findApp(appName)
+	return found, err
+}
 
+// AppNames lists the apps in the current space.
+func (repo *ApplicationRepo) AppNames() ([]string, error) {
+	api, err := repo.client()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	totalResults, ok := output["total_results"]
-
-	if !ok {
-		return false, errors.New("Missing total_results from api response")
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return nil, err
 	}
 
-	count, ok := totalResults.(float64)
+	apps, err := api.ListApps(space.Guid)
+	if err != nil {
+		return nil, err
+	}
 
-	if !ok {
-		return false, fmt.Errorf("total_results didn't have a number %v", totalResults)
+	names := []string{}
+	for _, app := range apps {
+		names = append(names, app.Entity.Name)
 	}
 
-	return count == 1, nil
-}
\ No newline at end of file
+	return names, nil
+}
+
+// IsAppStopped reports whether the app exists and is stopped, so it is not
+// serving any traffic.
+func (repo *ApplicationRepo) IsAppStopped(appName string) (bool, error) {
+	app, found, err := repo.findApp(appName)
+	return found && app.Entity.State == "STOPPED", err
+}
@@ -1,11 +1,16 @@
-package main_test
+package autopilot_test
 
 import (
 	"errors"
+	"net/http"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 
 	. "github.com/MerrillCorporation/autopilot"
 
@@ -55,6 +60,380 @@ var _ = Describe("Flag Parsing", func() {
 		Expect(options.UnmapRoute).To(Equal(true))
 	})
 
+	It("adds the continue-on-route-error flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--continue-on-route-error",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.ContinueOnRouteError).To(BeTrue())
+	})
+
+	It("collects repeated env flags", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--env", "FOO=bar",
+				"--env", "URL=http://example.com/?a=b",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Env).To(Equal(EnvVars{"FOO": "bar", "URL": "http://example.com/?a=b"}))
+	})
+
+	It("rejects env flags without a value", func() {
+		_, _, _, _, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--env", "FOO",
+			},
+		)
+		Expect(err).To(MatchError(ContainSubstring(`"FOO" should be of the form KEY=VALUE`)))
+	})
+
+	It("does not resume a deploy that rotates service keys", func() {
+		_, _, _, _, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--resume-from", "push",
+				"--rotate-service-keys", "db",
+			},
+		)
+		Expect(err).To(MatchError(HavePrefix("--resume-from cannot resume a deploy with --rotate-service-keys")))
+	})
+
+	It("does not premap routes the new app is checked on a test route before getting", func() {
+		_, _, _, _, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--premap-routes",
+				"--probe-path", "/healthz",
+			},
+		)
+		Expect(err).To(MatchError(HavePrefix("--premap-routes gives the new app the production routes before it starts")))
+	})
+
+	It("rejects flags that contradict each other", func() {
+		_, _, _, _, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--keep-existing-app",
+				"--unmap-routes",
+			},
+		)
+		Expect(err).To(MatchError(ContainSubstring("Give only one of them.")))
+	})
+
+	It("adds the strict-routes flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--strict-routes",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.StrictRoutes).To(BeTrue())
+	})
+
+	It("adds the copy-route-services flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--copy-route-services",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.CopyRouteServices).To(BeTrue())
+	})
+
+	It("adds the test-route flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--test-route", "appname-test.example.com",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.TestRoute).To(Equal("appname-test.example.com"))
+	})
+
+	It("collects arguments to pass on to push", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--push-arg", "-t 180",
+				"--push-arg", "--health-check-type=http",
+				"--",
+				"-s", "cflinuxfs3",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.PushArgs).To(Equal([]string{"-t", "180", "--health-check-type=http", "-s", "cflinuxfs3"}))
+	})
+
+	It("parses domain filters", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--only-domains", "example.com,example.org",
+				"--exclude-domains", "internal.example.com",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Domains).To(Equal(DomainFilter{
+			Only:    []string{"example.com", "example.org"},
+			Exclude: []string{"internal.example.com"},
+		}))
+	})
+
+	It("warms up on the automatic test route unless one is given", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--warmup", "2m",
+				"--warmup-requests", "50",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Warmup).To(Equal(2 * time.Minute))
+		Expect(options.WarmupRequests).To(Equal(50))
+		Expect(options.TestRoute).To(Equal("auto"))
+	})
+
+	It("probes on the automatic test route unless one is given", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--probe-path", "/healthz",
+				"--probe-status", "200",
+				"--probe-count", "5",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Probe).To(Equal(Prober{Path: "/healthz", Status: 200, Count: 5}))
+		Expect(options.TestRoute).To(Equal("auto"))
+	})
+
+	It("adds the drain-wait flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--drain-wait", "45s",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.DrainWait).To(Equal(45 * time.Second))
+	})
+
+	It("adds the check-security-groups flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--check-security-groups",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.CheckSecurityGroups).To(BeTrue())
+	})
+
+	It("adds the staged-start flag", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--staged-start", "25%"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.StagedStart).To(Equal(25))
+
+		_, _, _, _, err = ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--staged-start", "150%"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("adds the allow-stopped-app flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--allow-stopped-app",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.AllowStoppedApp).To(BeTrue())
+	})
+
+	It("adds the revision and var flags for templates", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--revision", "abc1234",
+				"--var", "team=payments",
+				"--var", "stage=prod",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Revision).To(Equal("abc1234"))
+		Expect(options.Vars).To(Equal(EnvVars{"team": "payments", "stage": "prod"}))
+	})
+
+	It("adds the rotate-service-keys flag", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--rotate-service-keys", "orders-db,cache",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.RotateServiceKeys).To(Equal([]string{"orders-db", "cache"}))
+	})
+
+	It("adds the buildpack and lifecycle flags", func() {
+		_, _, _, options, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "manifest-path",
+				"--buildpack", "apm_buildpack",
+				"--buildpack", "go_buildpack",
+				"--lifecycle", "cnb",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Buildpacks).To(Equal([]string{"apm_buildpack", "go_buildpack"}))
+		Expect(options.Lifecycle).To(Equal("cnb"))
+	})
+
+	It("adds the start-command flag", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--start-command", "bundle exec rackup"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.StartCommand).To(Equal("bundle exec rackup"))
+	})
+
+	It("adds the instances, memory and disk overrides", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--instances", "6", "--memory", "2G", "--disk", "512M"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Scale).To(Equal(ScaleOptions{Instances: 6, Memory: 2048, DiskQuota: 512}))
+
+		_, _, _, _, err = ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--memory", "2"})
+		Expect(err).To(MatchError(`invalid size "2", use a unit of M or G`))
+	})
+
+	It("adds the health check flags", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--health-check-type", "process"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.HealthCheck).To(Equal(HealthCheck{Type: "process"}))
+
+		_, _, _, options, err = ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--health-check-http-endpoint", "/healthz"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.HealthCheck).To(Equal(HealthCheck{Type: "http", Endpoint: "/healthz"}))
+
+		_, _, _, _, err = ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--health-check-type", "tcp"})
+		Expect(err).To(MatchError(`--health-check-type should be one of port, process, http, not "tcp"`))
+	})
+
+	It("adds the skip-if-unchanged flag", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--skip-if-unchanged"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.SkipIfUnchanged).To(BeTrue())
+	})
+
+	It("adds the package flag, which needs an app path", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "-p", "app-path", "--package"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Package).To(BeTrue())
+
+		_, _, _, _, err = ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--package"})
+		Expect(err).To(MatchError(ErrNoAppPath))
+	})
+
+	It("adds the rollback strategy flags", func() {
+		options, err := ParseRollbackArgs([]string{"zero-downtime-rollback", "appname"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Strategy).To(Equal("copy"))
+
+		options, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--strategy", "revisions", "--to-revision", "7"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Strategy).To(Equal("revisions"))
+		Expect(options.Revision).To(Equal(7))
+
+		_, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--strategy", "in-place"})
+		Expect(err).To(MatchError(`--strategy should be copy or revisions, not "in-place"`))
+
+		_, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--to-revision", "7"})
+		Expect(err).To(MatchError("--to-revision needs --strategy revisions"))
+
+		_, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--strategy", "revisions", "--from", "appname-v41"})
+		Expect(err).To(MatchError(ContainSubstring("--from names a copy of the app")))
+	})
+
+	It("adds the force-name-collision flag", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest-path", "--force-name-collision"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.ForceNameCollision).To(BeTrue())
+
+		rollbackOptions, err := ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--force-name-collision"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rollbackOptions.ForceNameCollision).To(BeTrue())
+	})
+
+	It("parses rollback args", func() {
+		options, err := ParseRollbackArgs(
+			[]string{
+				"zero-downtime-rollback",
+				"appname",
+				"--continue-on-route-error",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.ContinueOnRouteError).To(BeTrue())
+
+		options, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.ContinueOnRouteError).To(BeFalse())
+		Expect(options.From).To(BeEmpty())
+
+		options, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--from", "appname-v41"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.From).To(Equal("appname-v41"))
+		Expect(options.Yes).To(BeFalse())
+
+		options, err = ParseRollbackArgs([]string{"zero-downtime-rollback", "appname", "--yes"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Yes).To(BeTrue())
+	})
+
 	It("requires a manifest", func() {
 		_, _, _, _, err := ParseArgs(
 			[]string{
@@ -65,6 +444,19 @@ var _ = Describe("Flag Parsing", func() {
 		)
 		Expect(err).To(MatchError(ErrNoManifest))
 	})
+
+	It("takes - as the manifest, to read it from stdin", func() {
+		_, manifestPath, _, _, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "-",
+				"-p", "app-path",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifestPath).To(Equal(StdinManifest))
+	})
 })
 
 var _ = Describe("Option defaults", func() {
@@ -93,114 +485,163 @@ var _ = Describe("ApplicationRepo", func() {
 		repo       *ApplicationRepo
 		route	   Route
 		blankRoute Route
-
+		api        *ghttp.Server
 	)
 
 	BeforeEach(func() {
+		api = ghttp.NewServer()
+
 		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(
+			plugin_models.Space{
+				SpaceFields: plugin_models.SpaceFields{
+					Guid: "4",
+				},
+			},
+			nil,
+		)
+
 		repo = NewApplicationRepo(cliConn)
 		route = Route{Domain: "test-domain.com", Host: []string{"host-app", "host-app-copy"}}
 		blankRoute = Route{}
 	})
 
+	AfterEach(func() {
+		api.Close()
+	})
+
+	findsApp := func(name, guid string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3A"+name+"&q=space_guid%3A4"),
+			ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+			ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"`+guid+`"},"entity":{"name":"`+name+`","space_guid":"4"}}]}`),
+		)
+	}
+
 	Describe("RenameApplication", func() {
 		It("renames the application", func() {
+			api.AppendHandlers(
+				findsApp("old-name", "app-guid"),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/v2/apps/app-guid"),
+					ghttp.VerifyJSON(`{"name":"new-name"}`),
+					ghttp.RespondWith(http.StatusCreated, `{}`),
+				),
+			)
+
 			err := repo.RenameApplication("old-name", "new-name")
 			Expect(err).ToNot(HaveOccurred())
+			Expect(api.ReceivedRequests()).To(HaveLen(2))
+		})
 
-			Expect(cliConn.CliCommandCallCount()).To(Equal(1))
-			args := cliConn.CliCommandArgsForCall(0)
-			Expect(args).To(Equal([]string{"rename", "old-name", "new-name"}))
+		It("returns an error if the app does not exist", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			err := repo.RenameApplication("old-name", "new-name")
+			Expect(err).To(MatchError("App old-name not found"))
 		})
 
 		It("returns an error if one occurs", func() {
-			cliConn.CliCommandReturns([]string{}, errors.New("no app"))
+			api.AppendHandlers(
+				findsApp("old-name", "app-guid"),
+				ghttp.RespondWith(http.StatusBadRequest, `{"description":"The app name is taken: new-name","error_code":"CF-AppNameTaken"}`),
+			)
 
 			err := repo.RenameApplication("old-name", "new-name")
-			Expect(err).To(MatchError("no app"))
+			Expect(err).To(MatchError("The app name is taken: new-name (400 CF-AppNameTaken)"))
 		})
 	})
 
-	Describe("DoesAppExist", func() {
+	Describe("CheckRollbackCopy", func() {
+		respondsWithCopy := func(state, packageState string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3Aapp-v41&q=space_guid%3A4"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"copy-guid"},"entity":{"name":"app-v41","state":"`+state+`","package_state":"`+packageState+`"}}]}`),
+			)
+		}
 
-		It("returns an error if the cli returns an error", func() {
-			cliConn.CliCommandWithoutTerminalOutputReturns([]string{}, errors.New("you shall not curl"))
-			_, err := repo.DoesAppExist("app-name")
+		It("accepts a stopped, staged copy", func() {
+			api.AppendHandlers(respondsWithCopy("STOPPED", "STAGED"))
+			Expect(repo.CheckRollbackCopy("app-v41")).To(Succeed())
+		})
 
-			Expect(err).To(MatchError("you shall not curl"))
+		It("rejects a running copy", func() {
+			api.AppendHandlers(respondsWithCopy("STARTED", "STAGED"))
+			Expect(repo.CheckRollbackCopy("app-v41")).To(MatchError(`App copy "app-v41" is STARTED, only a stopped copy can be rolled back to.`))
 		})
 
-		It("returns an error if the cli response is invalid JSON", func() {
-			response := []string{
-				"}notjson{",
-			}
+		It("rejects a copy that was never staged", func() {
+			api.AppendHandlers(respondsWithCopy("STOPPED", "PENDING"))
+			Expect(repo.CheckRollbackCopy("app-v41")).To(MatchError(`App copy "app-v41" has not been staged, cannot rollback.`))
+		})
+
+		It("rejects a copy that does not exist", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+			Expect(repo.CheckRollbackCopy("app-v41")).To(MatchError(`App copy "app-v41" not found, cannot rollback.`))
+		})
+	})
 
-			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
+	Describe("DoesAppExist", func() {
+		It("returns an error if the api endpoint cannot be found", func() {
+			cliConn.ApiEndpointReturns("", errors.New("not logged in"))
 			_, err := repo.DoesAppExist("app-name")
 
-			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError("not logged in"))
 		})
 
-		It("returns an error if the cli response doesn't contain total_results", func() {
-			response := []string{
-				`{"brutal_results":2}`,
-			}
-
-			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
+		It("returns an error if the api returns an error", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
 			_, err := repo.DoesAppExist("app-name")
 
-			Expect(err).To(MatchError("Missing total_results from api response"))
+			Expect(err).To(MatchError("Internal Server Error (500)"))
 		})
 
-		It("returns an error if the cli response contains a non-number total_results", func() {
-			response := []string{
-				`{"total_results":"sandwich"}`,
-			}
-
-			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
+		It("returns an error if the api response is invalid JSON", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, "}notjson{"))
 			_, err := repo.DoesAppExist("app-name")
 
-			Expect(err).To(MatchError("total_results didn't have a number sandwich"))
+			Expect(err).To(HaveOccurred())
 		})
 
 		It("returns true if the app exists", func() {
-			 response := []string{
-			 	`{"total_results":1}`,
-			 }
-			 spaceGUID := "4"
-
-			 cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
-			 cliConn.GetCurrentSpaceReturns(
-			 	plugin_models.Space{
-			 		SpaceFields: plugin_models.SpaceFields{
-			 			Guid: spaceGUID,
-			 		},
-			 	},
-			 	nil,
-			 )
+			api.AppendHandlers(findsApp("app-name", "app-guid"))
 
-			 result, err := repo.DoesAppExist("app-name")
-
-			 Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
-			 args := cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)
-			 Expect(args).To(Equal([]string{"curl", "v2/apps?q=name:app-name&q=space_guid:4"}))
+			result, err := repo.DoesAppExist("app-name")
 
-			 Expect(err).ToNot(HaveOccurred())
-			 Expect(result).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(BeTrue())
 		})
 
 		It("returns false if the app does not exist", func() {
-			response := []string{
-				`{"total_results":0}`,
-			}
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
 
-			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
 			result, err := repo.DoesAppExist("app-name")
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(result).To(BeFalse())
 		})
+	})
+
+	Describe("IsAppStopped", func() {
+		It("returns true for a stopped app", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app-name","state":"STOPPED"}}]}`))
 
+			stopped, err := repo.IsAppStopped("app-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopped).To(BeTrue())
+		})
+
+		It("returns false for a started or missing app", func() {
+			api.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app-name","state":"STARTED"}}]}`),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[]}`),
+			)
+
+			Expect(repo.IsAppStopped("app-name")).To(BeFalse())
+			Expect(repo.IsAppStopped("app-name")).To(BeFalse())
+		})
 	})
 
 	Describe("PushApplication", func() {
@@ -231,6 +672,19 @@ var _ = Describe("ApplicationRepo", func() {
 			}))
 		})
 
+		It("passes extra arguments on to push", func() {
+			err := repo.PushApplication("appName", "/path/to/a/manifest.yml", "", "--no-start")
+			Expect(err).ToNot(HaveOccurred())
+
+			args := cliConn.CliCommandArgsForCall(0)
+			Expect(args).To(Equal([]string{
+				"push",
+				"appName",
+				"-f", "/path/to/a/manifest.yml",
+				"--no-start",
+			}))
+		})
+
 		It("returns errors from the push", func() {
 			cliConn.CliCommandReturns([]string{}, errors.New("bad app"))
 
@@ -239,13 +693,31 @@ var _ = Describe("ApplicationRepo", func() {
 		})
 	})
 
+	Describe("SetEnv", func() {
+		It("sets the variable without printing its value", func() {
+			err := repo.SetEnv("app-name", "SECRET", "hunter2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cliConn.CliCommandCallCount()).To(Equal(0))
+			args := cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)
+			Expect(args).To(Equal([]string{"set-env", "app-name", "SECRET", "hunter2"}))
+		})
+
+		It("returns errors from set-env", func() {
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{}, errors.New("bad app"))
+
+			err := repo.SetEnv("app-name", "SECRET", "hunter2")
+			Expect(err).To(MatchError("bad app"))
+		})
+	})
+
 	Describe("DeleteApplication", func() {
 		It("deletes all trace of an application", func() {
 			err := repo.DeleteApplication("app-name")
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(cliConn.CliCommandCallCount()).To(Equal(1))
-			args := cliConn.CliCommandArgsForCall(0)
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			args := cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)
 			Expect(args).To(Equal([]string{
 				"delete", "app-name",
 				"-f",
@@ -253,7 +725,7 @@ var _ = Describe("ApplicationRepo", func() {
 		})
 
 		It("returns errors from the delete", func() {
-			cliConn.CliCommandReturns([]string{}, errors.New("bad app"))
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{}, errors.New("bad app"))
 
 			err := repo.DeleteApplication("app-name")
 			Expect(err).To(MatchError("bad app"))
@@ -265,15 +737,15 @@ var _ = Describe("ApplicationRepo", func() {
 			err := repo.StopApplication("app-name")
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(cliConn.CliCommandCallCount()).To(Equal(1))
-			args := cliConn.CliCommandArgsForCall(0)
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			args := cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)
 			Expect(args).To(Equal([]string{
 				"stop", "app-name",
 			}))
 		})
 
 		It("returns errors from the stop", func() {
-			cliConn.CliCommandReturns([]string{}, errors.New("bad app"))
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{}, errors.New("bad app"))
 
 			err := repo.StopApplication("app-name")
 			Expect(err).To(MatchError("bad app"))
@@ -295,7 +767,7 @@ var _ = Describe("ApplicationRepo", func() {
 		It("returns errors from the start", func() {
 			cliConn.CliCommandReturns([]string{}, errors.New("bad app"))
 
-			err := repo.DeleteApplication("app-name")
+			err := repo.StartApplication("app-name")
 			Expect(err).To(MatchError("bad app"))
 		})
 	})
@@ -318,64 +790,223 @@ var _ = Describe("ApplicationRepo", func() {
 		})
 	})
 
-	Describe("MapRoutes", func() {
-		It("feeds data to MapRoutesToApp fuction", func() {
-			err := repo.MapRoutes("app-name", route)
+	Describe("route mapping", func() {
+		var mappedRoutes, unmappedRoutes []string
+
+		BeforeEach(func() {
+			mappedRoutes = []string{}
+			unmappedRoutes = []string{}
+
+			api.RouteToHandler("GET", "/v2/apps", findsApp("app-name", "app-guid"))
+			api.RouteToHandler("GET", "/v2/shared_domains", ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/shared_domains", "q=name%3Atest-domain.com"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"domain-guid"}}]}`),
+			))
+			api.RouteToHandler("GET", "/v2/routes", func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.RawQuery {
+				case "q=host%3Ahost-app&q=domain_guid%3Adomain-guid":
+					w.Write([]byte(`{"resources":[{"metadata":{"guid":"route-guid"},"entity":{"host":"host-app"}}]}`))
+				default:
+					w.Write([]byte(`{"resources":[]}`))
+				}
+			})
+			api.RouteToHandler("POST", "/v2/routes", ghttp.CombineHandlers(
+				ghttp.VerifyJSON(`{"host":"host-app-copy","domain_guid":"domain-guid","space_guid":"4"}`),
+				ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"new-route-guid"}}`),
+			))
+			api.RouteToHandler("PUT", regexp.MustCompile(`^/v2/routes/.*/apps/app-guid$`), func(w http.ResponseWriter, req *http.Request) {
+				mappedRoutes = append(mappedRoutes, req.URL.Path)
+				w.WriteHeader(http.StatusCreated)
+			})
+			api.RouteToHandler("DELETE", regexp.MustCompile(`^/v2/routes/.*/apps/app-guid$`), func(w http.ResponseWriter, req *http.Request) {
+				unmappedRoutes = append(unmappedRoutes, req.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			})
+		})
 
-			Expect(err).ToNot(HaveOccurred())
+		Describe("MapRoutes", func() {
+			It("feeds data to MapRoutesToApp fuction", func() {
+				err := repo.MapRoutes("app-name", route)
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Describe("MapRoutesToApp", func() {
+			It("adds routes to a specified venerable app, creating the ones that don't exist", func() {
+				err := repo.MapRoutesToApp("app-name", route)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mappedRoutes).To(ConsistOf(
+					"/v2/routes/route-guid/apps/app-guid",
+					"/v2/routes/new-route-guid/apps/app-guid",
+				))
+			})
+
+			It("attempts every route and reports the ones that failed", func() {
+				api.RouteToHandler("POST", "/v2/routes", ghttp.RespondWith(http.StatusBadRequest, `{"description":"route taken"}`))
+
+				err := repo.MapRoutesToApp("app-name", route)
+				Expect(err).To(MatchError("1 of 2 routes failed (host-app-copy: route taken (400))"))
+				Expect(mappedRoutes).To(ConsistOf("/v2/routes/route-guid/apps/app-guid"))
+
+				routeErrs, ok := err.(*RouteErrors)
+				Expect(ok).To(BeTrue())
+				Expect(routeErrs.Total).To(Equal(2))
+				Expect(routeErrs.Hosts()).To(Equal([]string{"host-app-copy"}))
+			})
+
+			It("reports every host that failed", func() {
+				api.RouteToHandler("GET", "/v2/routes", ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+				err := repo.MapRoutesToApp("app-name", route)
+				Expect(err.(*RouteErrors).Hosts()).To(ConsistOf("host-app", "host-app-copy"))
+				Expect(err).To(MatchError("2 of 2 routes failed (host-app: Internal Server Error (500); host-app-copy: Internal Server Error (500))"))
+			})
+
+			It("returns an error if the domain does not exist", func() {
+				api.RouteToHandler("GET", "/v2/shared_domains", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+				api.RouteToHandler("GET", "/v2/private_domains", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+				err := repo.MapRoutesToApp("app-name", route)
+				Expect(err).To(MatchError("Domain test-domain.com not found"))
+			})
+
+			It("maps wildcard routes on their own domains", func() {
+				api.RouteToHandler("GET", "/v2/shared_domains", func(w http.ResponseWriter, req *http.Request) {
+					switch req.URL.RawQuery {
+					case "q=name%3Atest-domain.com":
+						w.Write([]byte(`{"resources":[{"metadata":{"guid":"domain-guid"}}]}`))
+					case "q=name%3Awild.example.com":
+						w.Write([]byte(`{"resources":[{"metadata":{"guid":"wild-domain-guid"}}]}`))
+					default:
+						w.Write([]byte(`{"resources":[]}`))
+					}
+				})
+				api.RouteToHandler("POST", "/v2/routes", ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"host":"*","domain_guid":"wild-domain-guid","space_guid":"4"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"wildcard-route-guid"}}`),
+				))
+
+				err := repo.MapRoutesToApp("app-name", Route{Domain: "test-domain.com", Host: []string{"host-app"}, Wildcards: []string{"wild.example.com"}})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mappedRoutes).To(ConsistOf(
+					"/v2/routes/route-guid/apps/app-guid",
+					"/v2/routes/wildcard-route-guid/apps/app-guid",
+				))
+			})
+
+			It("maps the route with the host's path, not the one without", func() {
+				api.RouteToHandler("POST", "/v2/routes", ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"host":"host-app","domain_guid":"domain-guid","space_guid":"4","path":"/api"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"api-route-guid"}}`),
+				))
+
+				err := repo.MapRoutesToApp("app-name", Route{Domain: "test-domain.com", Host: []string{"host-app"}, Paths: []string{"/api"}})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mappedRoutes).To(ConsistOf("/v2/routes/api-route-guid/apps/app-guid"))
+			})
+
+			It("returns an error from the MapRoutesToApp with blank route", func() {
+				err := repo.MapRoutesToApp("app-name", blankRoute)
+				Expect(err).To(MatchError("There are no routes to add."))
+			})
 		})
-	})
 
-	Describe("MapRoutesToApp", func() {
-		It("adds routes to a specified venerable app", func() {
-			err := repo.MapRoutesToApp("app-name", route)
-			hostLength := len(route.Host)
+		Describe("UnmapRoutes", func() {
+			It("feeds data to UnmapRoutesFromApp", func() {
+				err := repo.UnmapRoutes("app-name", Route{Domain: "test-domain.com", Host: []string{"host-app"}})
 
-			Expect(err).ToNot(HaveOccurred())
-			args := cliConn.CliCommandArgsForCall(0)
-			Expect(args).To(Equal([]string{"map-route", "app-name", "test-domain.com", "--hostname",
-				"host-app"}))
-			Expect(cliConn.CliCommandCallCount()).To(Equal(hostLength))
+				Expect(err).ToNot(HaveOccurred())
+			})
 		})
 
-		It("returns an error from the MapRoutesToApp with blank route", func() {
-			cliConn.CliCommandReturns([]string{}, errors.New("Error mapping routes to venerable app name"))
+		Describe("UnmapRoutesFromApp", func() {
+			It("unmaps routes from a specified application", func() {
+				err := repo.UnmapRouteFromApp("app-name", Route{Domain: "test-domain.com", Host: []string{"host-app"}})
 
-			err := repo.MapRoutesToApp("app-name", blankRoute)
-			Expect(err).To(MatchError("There are no routes to add."))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(unmappedRoutes).To(ConsistOf("/v2/routes/route-guid/apps/app-guid"))
+			})
+
+			It("reports routes that don't exist", func() {
+				err := repo.UnmapRouteFromApp("app-name", route)
+
+				Expect(err).To(MatchError("1 of 2 routes failed (host-app-copy: Route host-app-copy.test-domain.com does not exist)"))
+				Expect(unmappedRoutes).To(ConsistOf("/v2/routes/route-guid/apps/app-guid"))
+				Expect(err.(*RouteErrors).Hosts()).To(Equal([]string{"host-app-copy"}))
+			})
+
+			It("unmaps wildcard routes, and names the ones that don't exist in full", func() {
+				err := repo.UnmapRouteFromApp("app-name", Route{Domain: "test-domain.com", Wildcards: []string{"test-domain.com"}})
+
+				Expect(err).To(MatchError("1 of 1 routes failed (*.test-domain.com: Route *.test-domain.com does not exist)"))
+			})
+
+			It("returns an error from unmap routes from app when there is no defined route", func() {
+				err := repo.UnmapRouteFromApp("app-name", blankRoute)
+				Expect(err).To(MatchError("No routes in the app."))
+			})
 		})
 	})
 
-	Describe("UnmapRoutes", func() {
-		It("feeds data to UnmapRoutesFromApp", func() {
-			err := repo.UnmapRoutes("app-name", route)
+	// the route summaries leave out the paths, which are looked up by guid
+	routePaths := func(paths map[string]string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			guid := strings.TrimPrefix(req.URL.Path, "/v2/routes/")
+			w.Write([]byte(`{"metadata":{"guid":"` + guid + `"},"entity":{"path":"` + paths[guid] + `"}}`))
+		}
+	}
+
+	Describe("AppRoutes", func() {
+		BeforeEach(func() {
+			api.RouteToHandler("GET", regexp.MustCompile(`^/v2/routes/[^/]+$`), routePaths(map[string]string{"api-route": "/api"}))
+		})
+
+		It("lists the app's routes with their domains and paths", func() {
+			cliConn.GetAppReturns(
+				plugin_models.GetAppModel{
+					Routes: []plugin_models.GetApp_RouteSummary{
+						{Guid: "host-route", Host: "app-host", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+						{Guid: "apex-route", Host: "", Domain: plugin_models.GetApp_DomainFields{Name: "apex.example.com"}},
+						{Guid: "api-route", Host: "www", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+					},
+				},
+				nil,
+			)
 
+			routes, err := repo.AppRoutes("app-name")
 			Expect(err).ToNot(HaveOccurred())
+			Expect(routes).To(Equal([]string{"app-host.example.com", "apex.example.com", "www.example.com/api"}))
+			Expect(cliConn.GetAppArgsForCall(0)).To(Equal("app-name"))
 		})
-	})
 
-	Describe("UnmapRoutesFromApp", func() {
-		It("unmaps routes from a specified application", func() {
-			err := repo.UnmapRouteFromApp("app-name", route)
-			hostLength := len(route.Host)
+		It("keeps the paths of the routes the API lists", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{Guid: "app-guid"}, nil)
+			api.RouteToHandler("GET", "/v3/apps/app-guid/routes", ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":null},"resources":[
+				{"guid":"route-1","host":"www","path":"/api","url":"www.example.com/api"}
+			]}`))
 
-			Expect(cliConn.CliCommandCallCount()).To(Equal(hostLength))
-			args := cliConn.CliCommandArgsForCall(0)
-			Expect(args).To(Equal([]string{"unmap-route", "app-name", "test-domain.com", "--hostname",
-				"host-app"}))
+			routes, err := repo.AppRoutes("app-name")
 			Expect(err).ToNot(HaveOccurred())
-
+			Expect(routes).To(Equal([]string{"www.example.com/api"}))
 		})
 
-		It("returns an error from unmap routes from app when there is no defined route", func() {
-			cliConn.CliCommandReturns([]string{}, errors.New("Route could not be unmapped"))
+		It("returns errors from the cli", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{}, errors.New("no app"))
 
-			err := repo.UnmapRouteFromApp("app-name", blankRoute)
-			Expect(err).To(MatchError("No routes in the app."))
+			_, err := repo.AppRoutes("app-name")
+			Expect(err).To(MatchError("no app"))
 		})
 	})
 
 	Describe("FindUrls", func() {
+		BeforeEach(func() {
+			api.RouteToHandler("GET", regexp.MustCompile(`^/v2/routes/[^/]+$`), routePaths(map[string]string{"api-route": "/api"}))
+		})
+
 		It("generates the Urls attached to a specified application", func() {
 
 			appDomainFields := plugin_models.GetApp_DomainFields{
@@ -407,10 +1038,107 @@ var _ = Describe("ApplicationRepo", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("keeps wildcard routes with their own domains", func() {
+			cliConn.GetAppReturns(
+				plugin_models.GetAppModel{
+					Routes: []plugin_models.GetApp_RouteSummary{
+						{Guid: "host-route", Host: "app-host", Domain: plugin_models.GetApp_DomainFields{Name: "apps.foundry.mrll.com"}},
+						{Guid: "wildcard-route", Host: "*", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+					},
+				},
+				nil,
+			)
+
+			route, err := repo.FindUrls("app-name")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route.Host).To(Equal([]string{"app-host"}))
+			Expect(route.Wildcards).To(Equal([]string{"example.com"}))
+			Expect(route.URLs()).To(Equal([]string{"app-host.apps.foundry.mrll.com", "*.example.com"}))
+		})
+
+		It("keeps each host on its own domain", func() {
+			cliConn.GetAppReturns(
+				plugin_models.GetAppModel{
+					Routes: []plugin_models.GetApp_RouteSummary{
+						{Guid: "com-route", Host: "app-host", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+						{Guid: "org-route", Host: "app-host", Domain: plugin_models.GetApp_DomainFields{Name: "example.org"}},
+					},
+				},
+				nil,
+			)
+
+			route, err := repo.FindUrls("app-name")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route.Domain).To(Equal("example.com"))
+			Expect(route.URLs()).To(Equal([]string{"app-host.example.com", "app-host.example.org"}))
+		})
+
+		It("keeps the path of each host's route", func() {
+			cliConn.GetAppReturns(
+				plugin_models.GetAppModel{
+					Routes: []plugin_models.GetApp_RouteSummary{
+						{Guid: "host-route", Host: "www", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+						{Guid: "api-route", Host: "www", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+					},
+				},
+				nil,
+			)
+
+			route, err := repo.FindUrls("app-name")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route.Paths).To(Equal([]string{"", "/api"}))
+			Expect(route.URLs()).To(Equal([]string{"www.example.com", "www.example.com/api"}))
+		})
+
 		It("The app entered has no routes", func(){
+			cliConn.GetAppReturns(plugin_models.GetAppModel{Guid: "app-guid"}, nil)
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":null},"resources":[]}`))
+
 			_, err := repo.FindUrls("app-name-no-routes")
 
 			Expect(err).To(MatchError("No routes for this app."))
+			Expect(err == ErrNoRoutes).To(BeTrue())
+		})
+
+		It("asks the API for the routes when the app is missing their summaries", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{Guid: "app-guid"}, nil)
+			api.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v3/apps/app-guid/routes", "per_page=100"),
+				ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":null},"resources":[
+					{"guid":"route-1","host":"app-host","url":"app-host.apps.foundry.mrll.com"},
+					{"guid":"route-2","host":"*","url":"*.example.com"}
+				]}`),
+			))
+
+			route, err := repo.FindUrls("app-name")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route.URLs()).To(Equal([]string{"app-host.apps.foundry.mrll.com", "*.example.com"}))
+		})
+
+		It("tells an app whose routes could not be looked up from one with none", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{Guid: "app-guid"}, nil)
+			api.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, `{"errors":[{"detail":"try again"}]}`))
+
+			_, err := repo.FindUrls("app-name")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err == ErrNoRoutes).To(BeFalse())
+			lookupErr, ok := err.(*RouteLookupError)
+			Expect(ok).To(BeTrue())
+			Expect(lookupErr.App).To(Equal("app-name"))
+			Expect(err.Error()).To(HavePrefix("Could not determine the routes of app-name: "))
+		})
+
+		It("fails to look up the routes when the cli can't get the app", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{}, errors.New("timed out"))
+
+			_, err := repo.FindUrls("app-name")
+
+			Expect(err).To(MatchError("Could not determine the routes of app-name: timed out"))
 		})
 	})
 })
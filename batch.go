@@ -0,0 +1,489 @@
+package autopilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Batch is a fleet of apps released together, as listed in the file given to
+// zero-downtime-push-batch.
+type Batch struct {
+	// Parallel is how many apps are deployed at once; 0 is one at a time.
+	Parallel int        `yaml:"parallel"`
+	Apps     []BatchApp `yaml:"apps"`
+}
+
+// BatchApp is an app of a batch and how to push it.
+type BatchApp struct {
+	Name     string `yaml:"name"`
+	Manifest string `yaml:"manifest"`
+	Path     string `yaml:"path"`
+	// Args are further zero-downtime-push flags, e.g. --keep-existing-app.
+	Args []string `yaml:"args"`
+	// DependsOn are the apps that must be deployed before this one.
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// The outcomes of an app in a batch.
+const (
+	BatchSucceeded = "succeeded"
+	BatchFailed    = "failed"
+	BatchSkipped   = "skipped"
+)
+
+// BatchResult is how the deploy of one app of a batch went.
+type BatchResult struct {
+	App             string    `json:"app"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Report is the app's own deployment report.
+	Report json.RawMessage `json:"report,omitempty"`
+}
+
+// BatchReport is the consolidated report of a batch, written by --report.
+type BatchReport struct {
+	Command    string        `json:"command"`
+	Status     string        `json:"status"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Apps       []BatchResult `json:"apps"`
+}
+
+// ParseBatchArgs reads the arguments of zero-downtime-push-batch: the batch
+// file, and --parallel, which overrides the file's.
+func ParseBatchArgs(args []string) (string, int, error) {
+	flags := flag.NewFlagSet("zero-downtime-push-batch", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	batchPath := flags.String("f", "", "")
+	parallel := flags.Int("parallel", 0, "")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return "", 0, err
+	}
+
+	if *batchPath == "" {
+		return "", 0, ErrNoBatchFile
+	}
+
+	if *parallel < 0 {
+		return "", 0, fmt.Errorf("--parallel must be at least 1, not %d", *parallel)
+	}
+
+	return *batchPath, *parallel, nil
+}
+
+// ErrNoBatchFile is returned when zero-downtime-push-batch is not given -f.
+var ErrNoBatchFile = errors.New("a batch file listing the apps to deploy is needed (-f apps.yml)")
+
+// LoadBatch reads and checks a batch file. Manifests and paths are relative
+// to the file.
+func LoadBatch(path string) (Batch, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	var batch Batch
+	err = yaml.Unmarshal(contents, &batch)
+	if err != nil {
+		return Batch{}, fmt.Errorf("could not read the batch file %s: %s", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i, app := range batch.Apps {
+		if app.Manifest != "" && !filepath.IsAbs(app.Manifest) {
+			batch.Apps[i].Manifest = filepath.Join(dir, app.Manifest)
+		}
+		if app.Path != "" && !filepath.IsAbs(app.Path) {
+			batch.Apps[i].Path = filepath.Join(dir, app.Path)
+		}
+	}
+
+	return batch, batch.Validate()
+}
+
+// Validate checks every app is named once, has a manifest, and depends only
+// on apps of the batch, without a cycle.
+func (batch Batch) Validate() error {
+	if len(batch.Apps) == 0 {
+		return errors.New("the batch lists no apps")
+	}
+
+	apps := map[string]BatchApp{}
+	for _, app := range batch.Apps {
+		if app.Name == "" {
+			return errors.New("every app in the batch needs a name")
+		}
+		if _, seen := apps[app.Name]; seen {
+			return fmt.Errorf("%s is listed twice in the batch", app.Name)
+		}
+		if app.Manifest == "" {
+			return fmt.Errorf("%s needs a manifest", app.Name)
+		}
+		apps[app.Name] = app
+	}
+
+	for _, app := range batch.Apps {
+		for _, dependency := range app.DependsOn {
+			if _, known := apps[dependency]; !known {
+				return fmt.Errorf("%s depends on %s, which is not in the batch", app.Name, dependency)
+			}
+		}
+	}
+
+	// depth first, following dependencies; an app met again while its own
+	// dependencies are being followed is part of a cycle
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("the batch's dependencies go round in a circle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dependency := range apps[name].DependsOn {
+			err := visit(dependency, append(path, name))
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for _, app := range batch.Apps {
+		err := visit(app.Name, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PushArgs are the zero-downtime-push arguments the app is deployed with.
+// The global flags go before the app's own, which may end with arguments
+// for cf push after --.
+func (app BatchApp) PushArgs(globalArgs ...string) []string {
+	args := []string{"zero-downtime-push", app.Name, "-f", app.Manifest}
+	if app.Path != "" {
+		args = append(args, "-p", app.Path)
+	}
+	args = append(args, globalArgs...)
+	return append(args, app.Args...)
+}
+
+// RunBatch deploys the apps with deploy, each once every app it depends on
+// has been deployed, and up to parallel at a time. An app is skipped if an
+// app it depends on failed or was skipped. The results are in the batch's
+// order.
+func RunBatch(batch Batch, parallel int, deploy func(BatchApp) error) []BatchResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	type finished struct {
+		index  int
+		result BatchResult
+	}
+
+	results := make([]BatchResult, len(batch.Apps))
+	status := map[string]string{}
+	started := map[string]bool{}
+	done := make(chan finished)
+	running := 0
+
+	for len(status) < len(batch.Apps) {
+		for i, app := range batch.Apps {
+			if started[app.Name] || running >= parallel {
+				continue
+			}
+
+			ready := true
+			blocker := ""
+			for _, dependency := range app.DependsOn {
+				switch status[dependency] {
+				case BatchSucceeded:
+				case BatchFailed, BatchSkipped:
+					blocker = dependency
+				default:
+					ready = false
+				}
+			}
+
+			if blocker != "" {
+				started[app.Name] = true
+				status[app.Name] = BatchSkipped
+				results[i] = BatchResult{App: app.Name, Status: BatchSkipped, Error: fmt.Sprintf("%s was not deployed", blocker)}
+				continue
+			}
+			if !ready {
+				continue
+			}
+
+			started[app.Name] = true
+			running++
+			go func(i int, app BatchApp) {
+				start := time.Now()
+				err := deploy(app)
+
+				result := BatchResult{App: app.Name, Status: BatchSucceeded, StartedAt: start.UTC(), DurationSeconds: time.Since(start).Seconds()}
+				if err != nil {
+					result.Status = BatchFailed
+					result.Error = err.Error()
+				}
+				done <- finished{i, result}
+			}(i, app)
+		}
+
+		// only skips were made, which may lead to more, or end the batch
+		if running == 0 {
+			continue
+		}
+
+		next := <-done
+		running--
+		results[next.index] = next.result
+		status[next.result.App] = next.result.Status
+	}
+
+	return results
+}
+
+// BatchError sums up the apps of a batch that were not deployed, or is nil
+// if every app was.
+func BatchError(results []BatchResult) error {
+	failed, skipped := []string{}, []string{}
+	for _, result := range results {
+		switch result.Status {
+		case BatchFailed:
+			failed = append(failed, result.App)
+		case BatchSkipped:
+			skipped = append(skipped, result.App)
+		}
+	}
+
+	if len(failed) == 0 && len(skipped) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("%d of %d apps were not deployed.", len(failed)+len(skipped), len(results))
+	if len(failed) > 0 {
+		message += " Failed: " + strings.Join(failed, ", ") + "."
+	}
+	if len(skipped) > 0 {
+		message += " Skipped, as what they depend on was not deployed: " + strings.Join(skipped, ", ") + "."
+	}
+	return errors.New(message)
+}
+
+// DescribeBatch prints a line per app saying how its deploy went.
+func DescribeBatch(out io.Writer, colors Colors, results []BatchResult) {
+	for _, result := range results {
+		took := (time.Duration(result.DurationSeconds * float64(time.Second))).Round(100 * time.Millisecond)
+		switch result.Status {
+		case BatchSucceeded:
+			fmt.Fprintln(out, colors.Success(fmt.Sprintf("OK      %s (%s)", result.App, took)))
+		case BatchFailed:
+			fmt.Fprintln(out, colors.Failure(fmt.Sprintf("FAILED  %s (%s): %s", result.App, took, result.Error)))
+		default:
+			fmt.Fprintln(out, colors.Warning(fmt.Sprintf("SKIPPED %s, as %s", result.App, result.Error)))
+		}
+	}
+}
+
+// BatchCommand runs the push of one app of a batch in its own cf process,
+// with the global flags the batch was given. The batch has logged in
+// already, so the app's push must not log in again over it, all at once.
+func BatchCommand(app BatchApp, globalArgs []string, environ []string) *exec.Cmd {
+	command := exec.Command("cf", app.PushArgs(globalArgs...)...)
+	for _, pair := range environ {
+		name := strings.SplitN(pair, "=", 2)[0]
+		if name == clientIDVar || name == clientSecretVar {
+			continue
+		}
+		command.Env = append(command.Env, pair)
+	}
+
+	return command
+}
+
+// batchGlobalArgs passes the flags every command accepts, as the batch was
+// given them, on to the push of each app.
+func batchGlobalArgs(verbosity Verbosity, skipSSLValidation, overrideWindow, allApps, offline bool, naming string, maxDeployTime time.Duration) []string {
+	args := []string{}
+	switch verbosity {
+	case QuietVerbosity:
+		args = append(args, "--quiet")
+	case VerboseVerbosity:
+		args = append(args, "--verbose")
+	}
+	if skipSSLValidation {
+		args = append(args, "--skip-ssl-validation")
+	}
+	if overrideWindow {
+		args = append(args, "--override-window")
+	}
+	if allApps {
+		args = append(args, "--show-apps")
+	}
+	if offline {
+		args = append(args, "--offline")
+	}
+	if naming != "" {
+		args = append(args, "--naming", naming)
+	}
+	if maxDeployTime > 0 {
+		args = append(args, "--max-deploy-time", maxDeployTime.String())
+	}
+	return args
+}
+
+// prefixWriter writes each line it is given, once it is complete, to out
+// with the prefix before it, so the output of apps deployed at once can be
+// told apart.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (writer *prefixWriter) Write(p []byte) (int, error) {
+	writer.buffer.Write(p)
+	for {
+		line, err := writer.buffer.ReadString('\n')
+		if err != nil {
+			// keep the incomplete line for the next write
+			writer.buffer.Reset()
+			writer.buffer.WriteString(line)
+			return len(p), nil
+		}
+
+		writer.lock.Lock()
+		_, err = io.WriteString(writer.out, writer.prefix+line)
+		writer.lock.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes what is left of an incomplete last line.
+func (writer *prefixWriter) Flush() {
+	if writer.buffer.Len() == 0 {
+		return
+	}
+
+	writer.lock.Lock()
+	io.WriteString(writer.out, writer.prefix+writer.buffer.String()+"\n")
+	writer.lock.Unlock()
+	writer.buffer.Reset()
+}
+
+// runBatch deploys the apps of the batch file, each with its own cf
+// zero-downtime-push, and writes a consolidated report to reportPath.
+func runBatch(args []string, globalArgs []string, reportPath string) error {
+	batchPath, parallel, err := ParseBatchArgs(args)
+	if err != nil {
+		return err
+	}
+
+	batch, err := LoadBatch(batchPath)
+	if err != nil {
+		return err
+	}
+	if parallel == 0 {
+		parallel = batch.Parallel
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	reportDir, err := ioutil.TempDir("", "autopilot-batch")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(reportDir)
+
+	lock := &sync.Mutex{}
+	width := 0
+	for _, app := range batch.Apps {
+		if len(app.Name) > width {
+			width = len(app.Name)
+		}
+	}
+
+	report := BatchReport{Command: args[0], StartedAt: time.Now().UTC()}
+	fmt.Printf("Deploying %d apps, %d at a time.\n", len(batch.Apps), parallel)
+
+	report.Apps = RunBatch(batch, parallel, func(app BatchApp) error {
+		out := &prefixWriter{prefix: fmt.Sprintf("%-*s | ", width, app.Name), out: os.Stdout, lock: lock}
+		defer out.Flush()
+
+		appArgs := append([]string{"--report", filepath.Join(reportDir, app.Name+".json")}, globalArgs...)
+		command := BatchCommand(app, appArgs, os.Environ())
+		command.Stdout = out
+		command.Stderr = out
+		return command.Run()
+	})
+
+	for i, result := range report.Apps {
+		contents, readErr := ioutil.ReadFile(filepath.Join(reportDir, result.App+".json"))
+		if readErr == nil && json.Valid(contents) {
+			report.Apps[i].Report = contents
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	err = BatchError(report.Apps)
+	report.Status = ReportSucceeded
+	if err != nil {
+		report.Status = ReportFailed
+	}
+
+	fmt.Println()
+	DescribeBatch(os.Stdout, colors, report.Apps)
+
+	if reportPath != "" {
+		writeErr := report.Write(reportPath)
+		if writeErr != nil {
+			warnf("could not write the batch report: %s\n", writeErr)
+		}
+	}
+
+	return err
+}
+
+// Write writes the report as JSON.
+func (report BatchReport) Write(path string) error {
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(contents, '\n'), 0644)
+}
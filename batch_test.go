@@ -0,0 +1,195 @@
+package autopilot_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Batch", func() {
+	Describe("ParseBatchArgs", func() {
+		It("takes the batch file and the parallelism", func() {
+			path, parallel, err := ParseBatchArgs([]string{"zero-downtime-push-batch", "-f", "apps.yml", "--parallel", "3"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("apps.yml"))
+			Expect(parallel).To(Equal(3))
+		})
+
+		It("requires a batch file", func() {
+			_, _, err := ParseBatchArgs([]string{"zero-downtime-push-batch"})
+			Expect(err).To(MatchError(ErrNoBatchFile))
+		})
+	})
+
+	Describe("LoadBatch", func() {
+		var dir string
+
+		load := func(contents string) (Batch, error) {
+			path := filepath.Join(dir, "apps.yml")
+			Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+			return LoadBatch(path)
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "batch")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("reads the apps, with manifests and paths relative to the file", func() {
+			batch, err := load(`
+parallel: 2
+apps:
+- name: users
+  manifest: users/manifest.yml
+  path: users/build
+- name: orders
+  manifest: /deploy/orders.yml
+  args: [--keep-existing-app, --, -s, cflinuxfs4]
+  depends_on: [users]
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Parallel).To(Equal(2))
+			Expect(batch.Apps).To(Equal([]BatchApp{
+				{Name: "users", Manifest: filepath.Join(dir, "users/manifest.yml"), Path: filepath.Join(dir, "users/build")},
+				{Name: "orders", Manifest: "/deploy/orders.yml", Args: []string{"--keep-existing-app", "--", "-s", "cflinuxfs4"}, DependsOn: []string{"users"}},
+			}))
+		})
+
+		It("rejects apps listed twice, without a manifest, or depending on apps not in the batch", func() {
+			_, err := load("apps:\n- {name: users, manifest: m.yml}\n- {name: users, manifest: m.yml}\n")
+			Expect(err).To(MatchError("users is listed twice in the batch"))
+
+			_, err = load("apps:\n- {name: users}\n")
+			Expect(err).To(MatchError("users needs a manifest"))
+
+			_, err = load("apps:\n- {name: orders, manifest: m.yml, depends_on: [users]}\n")
+			Expect(err).To(MatchError("orders depends on users, which is not in the batch"))
+
+			_, err = load("apps: []\n")
+			Expect(err).To(MatchError("the batch lists no apps"))
+		})
+
+		It("rejects dependencies that go round in a circle", func() {
+			_, err := load(`
+apps:
+- {name: a, manifest: m.yml, depends_on: [b]}
+- {name: b, manifest: m.yml, depends_on: [c]}
+- {name: c, manifest: m.yml, depends_on: [a]}
+`)
+			Expect(err).To(MatchError("the batch's dependencies go round in a circle: a -> b -> c -> a"))
+		})
+	})
+
+	Describe("RunBatch", func() {
+		It("deploys each app after the apps it depends on", func() {
+			batch := Batch{Apps: []BatchApp{
+				{Name: "orders", DependsOn: []string{"users", "catalog"}},
+				{Name: "users"},
+				{Name: "catalog", DependsOn: []string{"users"}},
+			}}
+
+			order := []string{}
+			results := RunBatch(batch, 1, func(app BatchApp) error {
+				order = append(order, app.Name)
+				return nil
+			})
+
+			Expect(order).To(Equal([]string{"users", "catalog", "orders"}))
+			Expect(results).To(HaveLen(3))
+			Expect(results[0].App).To(Equal("orders"))
+			Expect(BatchError(results)).ToNot(HaveOccurred())
+		})
+
+		It("deploys up to the parallel limit at once", func() {
+			batch := Batch{Apps: []BatchApp{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}}
+
+			lock := sync.Mutex{}
+			running, most := 0, 0
+			release := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				for i := 0; i < 4; i++ {
+					release <- struct{}{}
+				}
+			}()
+
+			RunBatch(batch, 2, func(app BatchApp) error {
+				lock.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				lock.Unlock()
+
+				<-release
+
+				lock.Lock()
+				running--
+				lock.Unlock()
+				return nil
+			})
+
+			Expect(most).To(BeNumerically("<=", 2))
+		})
+
+		It("skips the apps that depend on a failed one, and deploys the rest", func() {
+			batch := Batch{Apps: []BatchApp{
+				{Name: "users"},
+				{Name: "orders", DependsOn: []string{"users"}},
+				{Name: "billing", DependsOn: []string{"orders"}},
+				{Name: "catalog"},
+			}}
+
+			deployed := []string{}
+			results := RunBatch(batch, 1, func(app BatchApp) error {
+				deployed = append(deployed, app.Name)
+				if app.Name == "users" {
+					return errors.New("exit status 1")
+				}
+				return nil
+			})
+
+			Expect(deployed).To(Equal([]string{"users", "catalog"}))
+			Expect([]string{results[0].Status, results[0].Error}).To(Equal([]string{BatchFailed, "exit status 1"}))
+			Expect([]string{results[1].Status, results[1].Error}).To(Equal([]string{BatchSkipped, "users was not deployed"}))
+			Expect([]string{results[2].Status, results[2].Error}).To(Equal([]string{BatchSkipped, "orders was not deployed"}))
+			Expect(results[3].Status).To(Equal(BatchSucceeded))
+
+			Expect(BatchError(results)).To(MatchError("3 of 4 apps were not deployed. Failed: users. Skipped, as what they depend on was not deployed: orders, billing."))
+		})
+	})
+
+	It("sums up how each app's deploy went", func() {
+		out := &bytes.Buffer{}
+		DescribeBatch(out, Colors{}, []BatchResult{
+			{App: "users", Status: BatchSucceeded, DurationSeconds: 62.34},
+			{App: "orders", Status: BatchFailed, Error: "exit status 1", DurationSeconds: 3},
+			{App: "billing", Status: BatchSkipped, Error: "orders was not deployed"},
+		})
+
+		Expect(out.String()).To(Equal("OK      users (1m2.3s)\n" +
+			"FAILED  orders (3s): exit status 1\n" +
+			"SKIPPED billing, as orders was not deployed\n"))
+	})
+
+	It("pushes each app in a cf process of its own, without logging in again", func() {
+		app := BatchApp{Name: "orders", Manifest: "orders.yml", Path: "build", Args: []string{"--keep-existing-app", "--", "-s", "cflinuxfs4"}}
+		command := BatchCommand(app, []string{"--quiet"}, []string{"HOME=/home/ci", "AUTOPILOT_CLIENT_ID=deployer", "AUTOPILOT_CLIENT_SECRET=secret"})
+
+		Expect(command.Args).To(Equal([]string{"cf", "zero-downtime-push", "orders", "-f", "orders.yml", "-p", "build", "--quiet", "--keep-existing-app", "--", "-s", "cflinuxfs4"}))
+		Expect(command.Env).To(Equal([]string{"HOME=/home/ci"}))
+	})
+})
@@ -0,0 +1,101 @@
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ParseMaxDeployTime takes the --max-deploy-time flag, which every command
+// accepts, out of args. Arguments after -- are left alone.
+func ParseMaxDeployTime(args []string) (time.Duration, []string, error) {
+	value, args := takeStringFlag(args, "max-deploy-time")
+	if value == "" {
+		return 0, args, nil
+	}
+
+	limit, err := time.ParseDuration(value)
+	if err != nil || limit <= 0 {
+		return 0, args, fmt.Errorf("--max-deploy-time must be a duration such as 20m, not %q", value)
+	}
+
+	return limit, args, nil
+}
+
+// DeployBudget is how long a whole command may take, so a stuck deploy
+// rolls itself back instead of holding a pipeline job until it is killed.
+// Once the budget is spent no further step is started; the step running
+// finishes, and the completed steps are undone.
+type DeployBudget struct {
+	Limit    time.Duration
+	Deadline time.Time
+
+	mutex sync.Mutex
+	// the step that was running when the deadline passed
+	step      string
+	stepStart time.Time
+	stepEnd   time.Time
+}
+
+// NewDeployBudget starts the budget of a command that started at now.
+func NewDeployBudget(limit time.Duration, now time.Time) *DeployBudget {
+	return &DeployBudget{Limit: limit, Deadline: now.Add(limit)}
+}
+
+// Context is done once the budget is spent. On a nil budget it is only done
+// with parent.
+func (budget *DeployBudget) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if budget == nil {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithDeadline(parent, budget.Deadline)
+}
+
+// ObserveStep notes the step that was running when the deadline passed. It is
+// a rewind.Observer, and does nothing on a nil budget.
+func (budget *DeployBudget) ObserveStep(name, phase string, start time.Time, err error) {
+	if budget == nil {
+		return
+	}
+
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	end := time.Now()
+	if budget.step == "" && !start.After(budget.Deadline) && end.After(budget.Deadline) {
+		budget.step, budget.stepStart, budget.stepEnd = name, start, end
+	}
+}
+
+// Exceeded is the error of a command that ran out of budget.
+func (budget *DeployBudget) Exceeded() error {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	return BudgetExceededError{
+		Limit:    budget.Limit,
+		Step:     budget.step,
+		StepTook: budget.stepEnd.Sub(budget.stepStart),
+	}
+}
+
+// BudgetExceededError says a command took longer than --max-deploy-time, and
+// which step it was in when the time ran out.
+type BudgetExceededError struct {
+	Limit time.Duration
+	// Step was running when the deadline passed, or is empty if the time
+	// ran out before the first step or between two.
+	Step     string
+	StepTook time.Duration
+}
+
+func (err BudgetExceededError) Error() string {
+	spentIn := "while no step was running"
+	if err.Step != "" {
+		spentIn = fmt.Sprintf("during %q, which took %s", err.Step, err.StepTook.Round(time.Second))
+	}
+
+	return fmt.Sprintf("The deploy took longer than the %s --max-deploy-time allows; the time ran out %s. The completed steps have been rolled back.", err.Limit, spentIn)
+}
@@ -0,0 +1,86 @@
+package autopilot_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Deploy budget", func() {
+	Describe("ParseMaxDeployTime", func() {
+		It("takes the flag out of the args", func() {
+			limit, args, err := ParseMaxDeployTime([]string{"zero-downtime-push", "app", "--max-deploy-time", "20m", "-f", "manifest.yml"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(Equal(20 * time.Minute))
+			Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+			limit, args, err = ParseMaxDeployTime([]string{"zero-downtime-scale", "app", "--", "--max-deploy-time", "x"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(BeZero())
+			Expect(args).To(Equal([]string{"zero-downtime-scale", "app", "--", "--max-deploy-time", "x"}))
+		})
+
+		It("rejects anything but a positive duration", func() {
+			_, _, err := ParseMaxDeployTime([]string{"zero-downtime-push", "app", "--max-deploy-time=soon"})
+			Expect(err).To(MatchError(`--max-deploy-time must be a duration such as 20m, not "soon"`))
+
+			_, _, err = ParseMaxDeployTime([]string{"zero-downtime-push", "app", "--max-deploy-time=0s"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("starts no step once the budget is spent, undoes the completed ones, and says which step used it up", func() {
+		budget := NewDeployBudget(20*time.Millisecond, time.Now())
+		ctx, cancel := budget.Context(context.Background())
+		defer cancel()
+
+		calls := []string{}
+		actions := rewind.Actions{
+			Observer: budget.ObserveStep,
+			Actions: []rewind.Action{
+				{
+					Name:    "push",
+					Forward: func() error { calls = append(calls, "push"); return nil },
+					Undo:    func() error { calls = append(calls, "undo push"); return nil },
+				},
+				{
+					Name:    "probe",
+					Forward: func() error { time.Sleep(40 * time.Millisecond); calls = append(calls, "probe"); return nil },
+				},
+				{
+					Name:    "retire old version",
+					Forward: func() error { calls = append(calls, "retire"); return nil },
+				},
+			},
+		}
+
+		err := actions.ExecuteContext(ctx)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(calls).To(Equal([]string{"push", "probe", "undo push"}))
+
+		exceeded := budget.Exceeded()
+		Expect(exceeded).To(BeAssignableToTypeOf(BudgetExceededError{}))
+		Expect(exceeded.(BudgetExceededError).Step).To(Equal("probe"))
+		Expect(exceeded.Error()).To(HavePrefix(`The deploy took longer than the 20ms --max-deploy-time allows; the time ran out during "probe", which took `))
+	})
+
+	It("says when the time ran out outside a step", func() {
+		err := BudgetExceededError{Limit: 20 * time.Minute}
+		Expect(err.Error()).To(Equal("The deploy took longer than the 20m0s --max-deploy-time allows; the time ran out while no step was running. The completed steps have been rolled back."))
+	})
+
+	It("never runs out without a limit", func() {
+		var budget *DeployBudget
+		ctx, cancel := budget.Context(context.Background())
+		defer cancel()
+
+		_, hasDeadline := ctx.Deadline()
+		Expect(hasDeadline).To(BeFalse())
+		budget.ObserveStep("push", "forward", time.Now(), nil)
+	})
+})
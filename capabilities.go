@@ -0,0 +1,177 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The oldest cf CLI and v3 API versions autopilot works with, or needs for
+// some of its features.
+const (
+	// minCLIVersion is the oldest cf CLI autopilot's push arguments and
+	// plugin calls are known to work with.
+	minCLIVersion = "6.40.0"
+	// annotationsV3Version is the v3 API that added app annotations, which
+	// mark old versions as autopilot's and hold deploy digests, audit
+	// markers and abort requests.
+	annotationsV3Version = "3.63.0"
+	// deploymentsV3Version is the v3 API with app revisions and the
+	// deployments that roll back to one.
+	deploymentsV3Version = "3.74.0"
+)
+
+// The optional features a command line relies on.
+const (
+	FeatureAnnotations = "annotations"
+	FeatureDeployments = "deployments"
+)
+
+// Capabilities are the versions of the cf CLI running autopilot and of the
+// APIs its Cloud Controller offers.
+type Capabilities struct {
+	CLIVersion string
+	API        string
+	V2Version  string
+	// V3Version is "" when the Cloud Controller does not offer the v3 API.
+	V3Version string
+}
+
+// Capabilities asks the cf CLI for its version and the Cloud Controller for
+// the APIs it offers.
+func (repo *ApplicationRepo) Capabilities() (Capabilities, error) {
+	output, err := repo.conn.CliCommandWithoutTerminalOutput("version")
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("could not run cf version: %s", err)
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	info, err := api.Info()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	endpoint, err := repo.conn.ApiEndpoint()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	return Capabilities{
+		CLIVersion: ParseCLIVersion(output),
+		API:        endpoint,
+		V2Version:  info.V2Version,
+		V3Version:  info.V3Version,
+	}, nil
+}
+
+// ParseCLIVersion finds the version in the output of cf version, e.g.
+// "6.53.0+8e2b70a4a.2020-10-01" from "cf version 6.53.0+8e2b70a4a.2020-10-01".
+func ParseCLIVersion(output []string) string {
+	for _, line := range output {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "cf" && fields[1] == "version" {
+			return fields[2]
+		}
+	}
+	return ""
+}
+
+// FeaturesFor lists the optional features the command line relies on, so a
+// foundation without them fails before anything is changed rather than half
+// way through the deploy.
+func FeaturesFor(args []string, auditEvent bool) []string {
+	features := []string{}
+
+	skipIfUnchanged, _ := takeBoolFlag(args, "skip-if-unchanged")
+	if auditEvent || args[0] == "zero-downtime-abort" || skipIfUnchanged {
+		features = append(features, FeatureAnnotations)
+	}
+
+	strategy, _ := takeStringFlag(args, "strategy")
+	if args[0] == "zero-downtime-rollback" && strategy == revisionsStrategy {
+		features = append(features, FeatureDeployments)
+	}
+
+	return features
+}
+
+// Check fails when the cf CLI is too old, the Cloud Controller does not
+// offer the v2 API autopilot is built on, or a feature the command line
+// relies on is missing, and says what to do about it.
+func (caps Capabilities) Check(features []string) error {
+	if caps.CLIVersion != "" && !atLeastVersion(caps.CLIVersion, minCLIVersion) {
+		return fmt.Errorf("autopilot needs cf CLI %s or later, and this is %s. "+
+			"Upgrade it from https://github.com/cloudfoundry/cli/releases", minCLIVersion, caps.CLIVersion)
+	}
+
+	if caps.V2Version == "" {
+		return fmt.Errorf("The Cloud Controller at %s no longer offers the v2 API, which autopilot is built on. "+
+			"Use cf push --strategy rolling with cf CLI 7 or later for zero-downtime pushes there", caps.API)
+	}
+
+	for _, feature := range features {
+		switch feature {
+		case FeatureAnnotations:
+			if !caps.hasV3(annotationsV3Version) {
+				return fmt.Errorf("%s. It is needed for --audit-event, --skip-if-unchanged and zero-downtime-abort; "+
+					"run without them, or on a newer foundation", caps.missing("app annotations", annotationsV3Version))
+			}
+		case FeatureDeployments:
+			if !caps.hasV3(deploymentsV3Version) {
+				return fmt.Errorf("%s. Roll back with --strategy copy instead", caps.missing("app revisions and deployments", deploymentsV3Version))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Warnings describe what works differently on this foundation.
+func (caps Capabilities) Warnings() []string {
+	if caps.hasV3(annotationsV3Version) {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s, so old versions cannot be marked as made by autopilot, "+
+		"and one left over from an earlier deploy is only replaced with --force-name-collision", caps.missing("app annotations", annotationsV3Version))}
+}
+
+func (caps Capabilities) hasV3(version string) bool {
+	return caps.V3Version != "" && atLeastVersion(caps.V3Version, version)
+}
+
+// missing says the Cloud Controller lacks a feature of the v3 API.
+func (caps Capabilities) missing(feature, version string) string {
+	if caps.V3Version == "" {
+		return fmt.Sprintf("The Cloud Controller at %s does not offer the v3 API, which has the %s autopilot uses", caps.API, feature)
+	}
+
+	return fmt.Sprintf("The Cloud Controller at %s offers v3 API %s, but %s need %s or later", caps.API, caps.V3Version, feature, version)
+}
+
+func atLeastVersion(version, minimum string) bool {
+	return !newerVersion(minimum, version)
+}
+
+// checkCapabilities fails fast on a cf CLI or foundation that cannot run the
+// command. Not being able to tell is only a warning.
+func checkCapabilities(appRepo *ApplicationRepo, features []string) error {
+	caps, err := appRepo.Capabilities()
+	if err != nil {
+		warnf("could not check the cf CLI and the Cloud Controller offer what autopilot needs: %s\n", err)
+		return nil
+	}
+
+	err = caps.Check(features)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range caps.Warnings() {
+		warnf("%s.\n", warning)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+)
+
+var _ = Describe("Capabilities", func() {
+	modern := Capabilities{CLIVersion: "6.53.0+8e2b70a4a.2020-10-01", API: "https://api.example.com", V2Version: "2.150.0", V3Version: "3.85.0"}
+
+	It("reads the cf CLI's version", func() {
+		Expect(ParseCLIVersion([]string{"cf version 6.53.0+8e2b70a4a.2020-10-01"})).To(Equal("6.53.0+8e2b70a4a.2020-10-01"))
+		Expect(ParseCLIVersion([]string{"", "cf version 7.2.0"})).To(Equal("7.2.0"))
+		Expect(ParseCLIVersion([]string{"something else"})).To(Equal(""))
+	})
+
+	It("lists the features the command line relies on", func() {
+		Expect(FeaturesFor([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}, false)).To(BeEmpty())
+		Expect(FeaturesFor([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}, true)).To(Equal([]string{FeatureAnnotations}))
+		Expect(FeaturesFor([]string{"zero-downtime-push", "app", "--skip-if-unchanged"}, false)).To(Equal([]string{FeatureAnnotations}))
+		Expect(FeaturesFor([]string{"zero-downtime-abort", "app"}, false)).To(Equal([]string{FeatureAnnotations}))
+		Expect(FeaturesFor([]string{"zero-downtime-rollback", "app", "--strategy", "revisions"}, false)).To(Equal([]string{FeatureDeployments}))
+		Expect(FeaturesFor([]string{"zero-downtime-rollback", "app", "--strategy=copy"}, false)).To(BeEmpty())
+	})
+
+	It("passes a cf CLI and foundation that have everything", func() {
+		Expect(modern.Check([]string{FeatureAnnotations, FeatureDeployments})).To(Succeed())
+		Expect(modern.Warnings()).To(BeEmpty())
+	})
+
+	It("fails on a cf CLI that is too old", func() {
+		old := modern
+		old.CLIVersion = "6.32.0+0191c33d9.2017-09-26"
+
+		Expect(old.Check(nil)).To(MatchError("autopilot needs cf CLI 6.40.0 or later, and this is 6.32.0+0191c33d9.2017-09-26. " +
+			"Upgrade it from https://github.com/cloudfoundry/cli/releases"))
+	})
+
+	It("fails on a foundation without the v2 API", func() {
+		v3Only := modern
+		v3Only.V2Version = ""
+
+		Expect(v3Only.Check(nil)).To(MatchError(ContainSubstring("The Cloud Controller at https://api.example.com no longer offers the v2 API")))
+	})
+
+	It("fails only the command lines that need what an old foundation lacks, and warns about the rest", func() {
+		old := modern
+		old.V3Version = "3.42.0"
+
+		Expect(old.Check(nil)).To(Succeed())
+		Expect(old.Check([]string{FeatureAnnotations})).To(MatchError(
+			"The Cloud Controller at https://api.example.com offers v3 API 3.42.0, but app annotations need 3.63.0 or later. " +
+				"It is needed for --audit-event, --skip-if-unchanged and zero-downtime-abort; run without them, or on a newer foundation"))
+		Expect(old.Check([]string{FeatureDeployments})).To(MatchError(ContainSubstring("Roll back with --strategy copy instead")))
+		Expect(old.Warnings()).To(HaveLen(1))
+
+		old.V3Version = ""
+		Expect(old.Check([]string{FeatureDeployments})).To(MatchError(
+			"The Cloud Controller at https://api.example.com does not offer the v3 API, which has the app revisions and deployments autopilot uses. " +
+				"Roll back with --strategy copy instead"))
+	})
+
+	It("asks the cf CLI and the Cloud Controller", func() {
+		server := fakecc.NewServer()
+		defer server.Close()
+		server.V3Version = "3.42.0"
+
+		caps, err := NewApplicationRepo(server.CLI()).Capabilities()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(caps).To(Equal(Capabilities{
+			CLIVersion: "6.53.0+8e2b70a4a.2020-10-01",
+			API:        server.URL,
+			V2Version:  "2.150.0",
+			V3Version:  "3.42.0",
+		}))
+	})
+})
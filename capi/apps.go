@@ -0,0 +1,152 @@
+package capi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type Metadata struct {
+	Guid string `json:"guid"`
+}
+
+type App struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		Name      string `json:"name"`
+		SpaceGuid string `json:"space_guid"`
+		State     string `json:"state"`
+		// PackageState is STAGED once the app has a droplet to run.
+		PackageState string `json:"package_state"`
+	} `json:"entity"`
+}
+
+type appList struct {
+	Resources []App  `json:"resources"`
+	NextURL   string `json:"next_url"`
+}
+
+// FindApp looks an app up by name in a space. The bool is false if there is
+// no such app.
+func (client *Client) FindApp(spaceGuid, name string) (App, bool, error) {
+	var apps appList
+	err := client.Get("v2/apps?"+Query("name:"+name, "space_guid:"+spaceGuid), &apps)
+	if err != nil {
+		return App{}, false, err
+	}
+
+	if len(apps.Resources) == 0 {
+		return App{}, false, nil
+	}
+
+	return apps.Resources[0], true, nil
+}
+
+// ListApps lists the apps in a space.
+func (client *Client) ListApps(spaceGuid string) ([]App, error) {
+	apps := []App{}
+	path := "v2/apps?" + Query("space_guid:"+spaceGuid)
+	for path != "" {
+		var page appList
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		apps = append(apps, page.Resources...)
+		path = page.NextURL
+	}
+
+	return apps, nil
+}
+
+func (client *Client) RenameApp(appGuid, newName string) error {
+	return client.Do("PUT", fmt.Sprintf("v2/apps/%s", appGuid), map[string]string{"name": newName}, nil)
+}
+
+// GetAppEntity fetches the app's full configuration, as the Cloud Controller
+// returns it.
+func (client *Client) GetAppEntity(appGuid string) (map[string]interface{}, error) {
+	var app struct {
+		Entity map[string]interface{} `json:"entity"`
+	}
+	err := client.Get(fmt.Sprintf("v2/apps/%s", appGuid), &app)
+	return app.Entity, err
+}
+
+// UpdateApp changes the given fields of the app's configuration.
+func (client *Client) UpdateApp(appGuid string, entity map[string]interface{}) error {
+	return client.Do("PUT", fmt.Sprintf("v2/apps/%s", appGuid), entity, nil)
+}
+
+func (client *Client) CreateApp(entity map[string]interface{}) (App, error) {
+	var app App
+	err := client.Do("POST", "v2/apps", entity, &app)
+	return app, err
+}
+
+func (client *Client) BindService(appGuid, serviceInstanceGuid string) error {
+	return client.Do("POST", "v2/service_bindings", map[string]string{
+		"app_guid":              appGuid,
+		"service_instance_guid": serviceInstanceGuid,
+	}, nil)
+}
+
+// StartApp starts the app, which needs a current droplet to run.
+func (client *Client) StartApp(appGuid string) error {
+	return client.Do("PUT", fmt.Sprintf("v2/apps/%s", appGuid), map[string]string{"state": "STARTED"}, nil)
+}
+
+func (client *Client) DeleteApp(appGuid string) error {
+	return client.Do("DELETE", fmt.Sprintf("v2/apps/%s", appGuid), nil, nil)
+}
+
+// Instance is one of an app's instances, as the v2 API reports it.
+type Instance struct {
+	Index int
+	// State is RUNNING, STARTING, CRASHED or DOWN.
+	State string `json:"state"`
+	// Since is when the instance entered the state, in seconds since the
+	// epoch.
+	Since   float64 `json:"since"`
+	Details string  `json:"details"`
+}
+
+// Instances lists the app's instances, in order of their index.
+func (client *Client) Instances(appGuid string) ([]Instance, error) {
+	var byIndex map[string]Instance
+	err := client.Get(fmt.Sprintf("v2/apps/%s/instances", appGuid), &byIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []Instance{}
+	for index, instance := range byIndex {
+		instance.Index, err = strconv.Atoi(index)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected instance index %q", index)
+		}
+		instances = append(instances, instance)
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Index < instances[j].Index
+	})
+	return instances, nil
+}
+
+// InstanceStates lists the state of each of the app's instances, such as
+// RUNNING, STARTING or CRASHED.
+func (client *Client) InstanceStates(appGuid string) ([]string, error) {
+	instances, err := client.Instances(appGuid)
+	if err != nil {
+		return nil, err
+	}
+
+	states := []string{}
+	for _, instance := range instances {
+		states = append(states, instance.State)
+	}
+
+	return states, nil
+}
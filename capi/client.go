@@ -0,0 +1,183 @@
+package capi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client talks to the Cloud Controller API directly, authenticating with the
+// access token of the cf CLI session, or one from a UAATokenSource.
+type Client struct {
+	endpoint    string
+	accessToken func() (string, error)
+	httpClient  *http.Client
+
+	tokenLock sync.Mutex
+	token     string
+}
+
+// Error is returned for any response outside of the 2xx range.
+type Error struct {
+	StatusCode  int
+	ErrorCode   string `json:"error_code"`
+	Description string `json:"description"`
+}
+
+func (err *Error) Error() string {
+	if err.ErrorCode == "" {
+		return fmt.Sprintf("%s (%d)", err.Description, err.StatusCode)
+	}
+
+	return fmt.Sprintf("%s (%d %s)", err.Description, err.StatusCode, err.ErrorCode)
+}
+
+// IsNotFound reports whether err is a 404 from the Cloud Controller.
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// HasErrorCode reports whether err is a Cloud Controller error with the
+// given code, such as "CF-AppNameTaken".
+func HasErrorCode(err error, code string) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.ErrorCode == code
+}
+
+func NewClient(endpoint string, accessToken func() (string, error), skipSSLValidation bool) *Client {
+	return &Client{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		accessToken: accessToken,
+		httpClient:  newHTTPClient(skipSSLValidation),
+	}
+}
+
+func (client *Client) Get(path string, result interface{}) error {
+	return client.Do("GET", path, nil, result)
+}
+
+// Do sends a request with body encoded as JSON, and decodes the response
+// into result. Either of body and result may be nil.
+func (client *Client) Do(method, path string, body interface{}, result interface{}) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		encoded, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	token, err := client.authorization()
+	if err != nil {
+		return err
+	}
+
+	responseBody, err := client.send(method, path, encoded, token)
+
+	// a long deploy can outlive the token, so a rejected one is replaced
+	// and the request sent again, once
+	if apiErr, ok := err.(*Error); ok && apiErr.StatusCode == http.StatusUnauthorized {
+		token, err = client.refreshAuthorization(token)
+		if err != nil {
+			return err
+		}
+		responseBody, err = client.send(method, path, encoded, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result == nil || len(responseBody) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(responseBody, result)
+}
+
+func (client *Client) send(method, path string, body []byte, token string) ([]byte, error) {
+	var requestBody io.Reader
+	if body != nil {
+		requestBody = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequest(method, client.endpoint+"/"+strings.TrimLeft(path, "/"), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiErr := &Error{StatusCode: response.StatusCode}
+		if json.Unmarshal(responseBody, apiErr) != nil || apiErr.Description == "" {
+			apiErr.Description = http.StatusText(response.StatusCode)
+		}
+		return nil, apiErr
+	}
+
+	return responseBody, nil
+}
+
+func (client *Client) authorization() (string, error) {
+	client.tokenLock.Lock()
+	defer client.tokenLock.Unlock()
+
+	if client.token == "" {
+		token, err := client.accessToken()
+		if err != nil {
+			return "", err
+		}
+		client.token = token
+	}
+
+	return client.token, nil
+}
+
+// refreshAuthorization fetches a new token in place of the rejected one,
+// unless a concurrent request already has.
+func (client *Client) refreshAuthorization(rejected string) (string, error) {
+	client.tokenLock.Lock()
+	defer client.tokenLock.Unlock()
+
+	if client.token == rejected {
+		token, err := client.accessToken()
+		if err != nil {
+			return "", err
+		}
+		client.token = token
+	}
+
+	return client.token, nil
+}
+
+// Query builds a v2 query string out of "field:value" filters.
+func Query(filters ...string) string {
+	params := []string{}
+	for _, filter := range filters {
+		params = append(params, "q="+url.QueryEscape(filter))
+	}
+
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,484 @@
+package capi_test
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/concourse/autopilot/capi"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server     *ghttp.Server
+		client     *capi.Client
+		tokenCalls int
+		tokenErr   error
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		tokenCalls = 0
+		tokenErr = nil
+
+		client = capi.NewClient(server.URL()+"/", func() (string, error) {
+			tokenCalls++
+			return "bearer some-token", tokenErr
+		}, false)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the session's access token and decodes the response", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/info"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWith(http.StatusOK, `{"name":"vcap"}`),
+			),
+			ghttp.RespondWith(http.StatusOK, `{"name":"vcap"}`),
+		)
+
+		var info struct {
+			Name string `json:"name"`
+		}
+		Expect(client.Get("v2/info", &info)).To(Succeed())
+		Expect(info.Name).To(Equal("vcap"))
+
+		Expect(client.Get("/v2/info", &info)).To(Succeed())
+		Expect(tokenCalls).To(Equal(1))
+	})
+
+	It("encodes request bodies as JSON", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("PUT", "/v2/apps/app-guid"),
+			ghttp.VerifyContentType("application/json"),
+			ghttp.VerifyJSON(`{"name":"new-name"}`),
+			ghttp.RespondWith(http.StatusCreated, ""),
+		))
+
+		Expect(client.RenameApp("app-guid", "new-name")).To(Succeed())
+	})
+
+	It("returns Cloud Controller errors", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, `{"code":100004,"description":"The app could not be found: app-guid","error_code":"CF-AppNotFound"}`))
+
+		err := client.RenameApp("app-guid", "new-name")
+		Expect(err).To(MatchError("The app could not be found: app-guid (404 CF-AppNotFound)"))
+		Expect(capi.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("returns errors from fetching the token", func() {
+		tokenErr = errors.New("not logged in")
+
+		Expect(client.Get("v2/info", nil)).To(MatchError("not logged in"))
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("fetches a new token and retries once when the token has expired", func() {
+		tokens := []string{"bearer expired-token", "bearer fresh-token"}
+		client = capi.NewClient(server.URL(), func() (string, error) {
+			token := tokens[0]
+			tokens = tokens[1:]
+			return token, nil
+		}, false)
+
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "bearer expired-token"),
+				ghttp.RespondWith(http.StatusUnauthorized, `{"description":"Invalid Auth Token","error_code":"CF-InvalidAuthToken"}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/v2/apps/app-guid"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer fresh-token"),
+				ghttp.VerifyJSON(`{"name":"new-name"}`),
+				ghttp.RespondWith(http.StatusCreated, ""),
+			),
+		)
+
+		Expect(client.RenameApp("app-guid", "new-name")).To(Succeed())
+		Expect(tokens).To(BeEmpty())
+	})
+
+	It("gives up when the new token is rejected too", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusUnauthorized, `{"description":"Invalid Auth Token","error_code":"CF-InvalidAuthToken"}`),
+			ghttp.RespondWith(http.StatusUnauthorized, `{"description":"Invalid Auth Token","error_code":"CF-InvalidAuthToken"}`),
+		)
+
+		Expect(client.Get("v2/info", nil)).To(MatchError("Invalid Auth Token (401 CF-InvalidAuthToken)"))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+		Expect(tokenCalls).To(Equal(2))
+	})
+
+	Describe("FindApp", func() {
+		It("finds an app by name and space", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3Aapp-name&q=space_guid%3Aspace-guid"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app-name","state":"STARTED"}}]}`),
+			))
+
+			app, found, err := client.FindApp("space-guid", "app-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(app.Metadata.Guid).To(Equal("app-guid"))
+			Expect(app.Entity.State).To(Equal("STARTED"))
+		})
+
+		It("reports missing apps", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			_, found, err := client.FindApp("space-guid", "app-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("routes", func() {
+		It("finds a domain among the private domains", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/shared_domains", "q=name%3Aexample.com"),
+					ghttp.RespondWith(http.StatusOK, `{"resources":[]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/private_domains", "q=name%3Aexample.com"),
+					ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"domain-guid"}}]}`),
+				),
+			)
+
+			guid, found, err := client.FindDomain("example.com")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(guid).To(Equal("domain-guid"))
+		})
+
+		It("finds the route without a path", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/routes", "q=host%3Aapp&q=domain_guid%3Adomain-guid"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[
+					{"metadata":{"guid":"path-route"},"entity":{"host":"app","path":"/path"}},
+					{"metadata":{"guid":"route-guid"},"entity":{"host":"app"}}
+				]}`),
+			))
+
+			route, found, err := client.FindRoute("app", "domain-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(route.Metadata.Guid).To(Equal("route-guid"))
+		})
+
+		It("creates, maps and unmaps routes", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/routes"),
+					ghttp.VerifyJSON(`{"host":"app","domain_guid":"domain-guid","space_guid":"space-guid"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"route-guid"}}`),
+				),
+				ghttp.VerifyRequest("PUT", "/v2/routes/route-guid/apps/app-guid"),
+				ghttp.VerifyRequest("DELETE", "/v2/routes/route-guid/apps/app-guid"),
+			)
+
+			route, err := client.CreateRoute("app", "domain-guid", "space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route.Metadata.Guid).To(Equal("route-guid"))
+
+			Expect(client.MapRoute("route-guid", "app-guid")).To(Succeed())
+			Expect(client.UnmapRoute("route-guid", "app-guid")).To(Succeed())
+		})
+
+		It("lists the app's routes with the v3 API, page by page", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/routes", "per_page=100"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":{"href":"`+server.URL()+`/v3/apps/app-guid/routes?page=2"}},"resources":[{"guid":"r1","host":"app","path":"/api","url":"app.example.com/api"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/routes", "page=2"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":null},"resources":[{"guid":"r2","host":"","path":"","url":"apex.example.com"},{"guid":"r3","host":"*","path":"","url":"*.example.org"},{"guid":"r4","host":"","path":"","port":1024,"url":"tcp.example.com:1024"}]}`),
+				),
+			)
+
+			routes, err := client.AppRoutes("app-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routes).To(HaveLen(4))
+			Expect(routes[0].Domain()).To(Equal("example.com"))
+			Expect(routes[1].Domain()).To(Equal("apex.example.com"))
+			Expect(routes[2].Host).To(Equal("*"))
+			Expect(routes[2].Domain()).To(Equal("example.org"))
+			Expect(routes[3].Port).To(Equal(1024))
+			Expect(routes[3].Domain()).To(Equal("tcp.example.com"))
+		})
+	})
+
+	Describe("events", func() {
+		It("pages through the app's events since a time", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/events", "q=actee%3Aapp-guid&q=timestamp%3E%3D2026-03-01T10%3A00%3A00Z&order-direction=asc&results-per-page=100"),
+					ghttp.RespondWith(http.StatusOK, `{"next_url":"/v2/events?page=2","resources":[{"entity":{"type":"audit.app.create","actor_name":"alice"}}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/events", "page=2"),
+					ghttp.RespondWith(http.StatusOK, `{"next_url":null,"resources":[{"entity":{"type":"audit.app.start","actor_name":"alice"}}]}`),
+				),
+			)
+
+			events, err := client.Events("app-guid", time.Date(2026, 3, 1, 11, 0, 0, 0, time.FixedZone("CET", 3600)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Entity.Type).To(Equal("audit.app.create"))
+			Expect(events[1].Entity.Type).To(Equal("audit.app.start"))
+		})
+
+		It("annotates apps with the v3 API", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PATCH", "/v3/apps/app-guid"),
+				ghttp.VerifyJSON(`{"metadata":{"annotations":{"autopilot/last-deploy":"zero-downtime-push"}}}`),
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			))
+
+			Expect(client.UpdateAppAnnotations("app-guid", map[string]string{"autopilot/last-deploy": "zero-downtime-push"})).To(Succeed())
+		})
+
+		It("lists the apps in a space, page by page", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/apps", "q=space_guid:space-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"next_url":"/v2/apps?page=2","resources":[{"metadata":{"guid":"guid-1"},"entity":{"name":"app"}}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/apps", "page=2"),
+					ghttp.RespondWith(http.StatusOK, `{"next_url":null,"resources":[{"metadata":{"guid":"guid-2"},"entity":{"name":"app-20240607T1212"}}]}`),
+				),
+			)
+
+			apps, err := client.ListApps("space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(apps).To(HaveLen(2))
+			Expect(apps[1].Entity.Name).To(Equal("app-20240607T1212"))
+		})
+
+		It("lists revisions page by page, and which are deployed", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/revisions", "order_by=created_at&per_page=100"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":{"href":"`+server.URL()+`/v3/apps/app-guid/revisions?page=2"}},"resources":[{"guid":"r1","version":1,"deployable":false}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/revisions", "page=2"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination":{"next":null},"resources":[{"guid":"r2","version":2,"deployable":true,"description":"New droplet deployed."}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/revisions/deployed"),
+					ghttp.RespondWith(http.StatusOK, `{"resources":[{"guid":"r2","version":2}]}`),
+				),
+			)
+
+			revisions, err := client.Revisions("app-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[1].Version).To(Equal(2))
+			Expect(revisions[1].Deployable).To(BeTrue())
+			Expect(revisions[1].Description).To(Equal("New droplet deployed."))
+
+			deployed, err := client.DeployedRevisions("app-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployed[0].Guid).To(Equal("r2"))
+		})
+
+		It("deploys a revision, follows the deployment and cancels it", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v3/deployments"),
+					ghttp.VerifyJSON(`{"revision":{"guid":"r1"},"relationships":{"app":{"data":{"guid":"app-guid"}}}}`),
+					ghttp.RespondWith(http.StatusCreated, `{"guid":"deployment-guid","state":"DEPLOYING","status":{"value":"ACTIVE","reason":"DEPLOYING"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/deployments/deployment-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"guid":"deployment-guid","state":"DEPLOYED","status":{"value":"FINALIZED","reason":"DEPLOYED"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v3/deployments/deployment-guid/actions/cancel"),
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+			)
+
+			deployment, err := client.CreateDeployment("app-guid", "r1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Guid).To(Equal("deployment-guid"))
+
+			deployment, err = client.GetDeployment("deployment-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Status.Reason).To(Equal("DEPLOYED"))
+
+			Expect(client.CancelDeployment("deployment-guid")).To(Succeed())
+		})
+
+		It("copies an app's droplet to another app and makes it current", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/droplets/current"),
+					ghttp.RespondWith(http.StatusOK, `{"guid":"droplet-guid","state":"STAGED"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v3/droplets", "source_guid=droplet-guid"),
+					ghttp.VerifyJSON(`{"relationships":{"app":{"data":{"guid":"copy-guid"}}}}`),
+					ghttp.RespondWith(http.StatusCreated, `{"guid":"copied-guid","state":"COPYING"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/droplets/copied-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"guid":"copied-guid","state":"STAGED"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/v3/apps/copy-guid/relationships/current_droplet"),
+					ghttp.VerifyJSON(`{"data":{"guid":"copied-guid"}}`),
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+			)
+
+			current, err := client.CurrentDroplet("app-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			copied, err := client.CopyDroplet(current.Guid, "copy-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(copied.State).To(Equal("COPYING"))
+
+			copied, err = client.GetDroplet(copied.Guid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(copied.State).To(Equal("STAGED"))
+
+			Expect(client.SetCurrentDroplet("copy-guid", copied.Guid)).To(Succeed())
+		})
+
+		It("reads whether an app records revisions", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v3/apps/app-guid/features/revisions"),
+				ghttp.RespondWith(http.StatusOK, `{"name":"revisions","enabled":true}`),
+			))
+
+			Expect(client.RevisionsEnabled("app-guid")).To(BeTrue())
+		})
+
+		It("reads app annotations with the v3 API", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"guid":"app-guid","metadata":{"annotations":{"autopilot/managed":"true"}}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/other-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"guid":"other-guid","metadata":{"annotations":null}}`),
+				),
+			)
+
+			Expect(client.AppAnnotations("app-guid")).To(Equal(map[string]string{"autopilot/managed": "true"}))
+			Expect(client.AppAnnotations("other-guid")).To(BeEmpty())
+		})
+	})
+
+	Describe("security groups", func() {
+		It("lists the platform wide and the space's running rules", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/config/running_security_groups"),
+					ghttp.RespondWith(http.StatusOK, `{"resources":[{"entity":{"name":"dns","rules":[{"protocol":"udp","destination":"0.0.0.0/0","ports":"53"}]}}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/spaces/space-guid/security_groups"),
+					ghttp.RespondWith(http.StatusOK, `{"resources":[{"entity":{"name":"db","rules":[{"protocol":"tcp","destination":"10.0.0.0/24","ports":"5432"}]}}]}`),
+				),
+			)
+
+			rules, complete, err := client.RunningSecurityGroupRules("space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(complete).To(BeTrue())
+			Expect(rules).To(Equal([]capi.SecurityGroupRule{
+				{Protocol: "udp", Destination: "0.0.0.0/0", Ports: "53"},
+				{Protocol: "tcp", Destination: "10.0.0.0/24", Ports: "5432"},
+			}))
+		})
+
+		It("leaves out the platform wide rules when it may not list them", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusForbidden, `{"error_code":"CF-NotAuthorized","description":"You are not authorized to perform the requested action"}`),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"entity":{"name":"db","rules":[{"protocol":"tcp","destination":"10.0.0.0/24","ports":"5432"}]}}]}`),
+			)
+
+			rules, complete, err := client.RunningSecurityGroupRules("space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(complete).To(BeFalse())
+			Expect(rules).To(HaveLen(1))
+		})
+	})
+
+	Describe("Info", func() {
+		It("reads the versions of the APIs the Cloud Controller offers", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/"),
+				ghttp.RespondWith(http.StatusOK, `{"links":{"cloud_controller_v2":{"href":"https://api.example.com/v2","meta":{"version":"2.150.0"}},"cloud_controller_v3":{"href":"https://api.example.com/v3","meta":{"version":"3.85.0"}}}}`),
+			))
+
+			info, err := client.Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info).To(Equal(capi.Info{V2Version: "2.150.0", V3Version: "3.85.0"}))
+		})
+
+		It("leaves out an API that is not offered", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"links":{"cloud_controller_v2":{"meta":{"version":"2.100.0"}}}}`))
+
+			info, err := client.Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info).To(Equal(capi.Info{V2Version: "2.100.0"}))
+		})
+	})
+
+	Describe("service keys", func() {
+		It("finds a service instance by name in the space", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/spaces/space-guid/service_instances", "q=name%3Aorders-db"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"instance-guid"}}]}`),
+			))
+
+			guid, found, err := client.FindServiceInstance("space-guid", "orders-db")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(guid).To(Equal("instance-guid"))
+		})
+
+		It("creates, lists and deletes keys", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/service_keys"),
+					ghttp.VerifyJSON(`{"service_instance_guid":"instance-guid","name":"app-key-1"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"key-guid"},"entity":{"name":"app-key-1","credentials":{"password":"secret"}}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/service_instances/instance-guid/service_keys"),
+					ghttp.RespondWith(http.StatusOK, `{"next_url":null,"resources":[{"metadata":{"guid":"key-guid"},"entity":{"name":"app-key-1"}}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/v2/service_keys/key-guid"),
+					ghttp.RespondWith(http.StatusNoContent, ``),
+				),
+			)
+
+			key, err := client.CreateServiceKey("instance-guid", "app-key-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key.Entity.Credentials).To(Equal(map[string]interface{}{"password": "secret"}))
+
+			keys, err := client.ServiceKeys("instance-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(HaveLen(1))
+			Expect(keys[0].Metadata.Guid).To(Equal("key-guid"))
+
+			Expect(client.DeleteServiceKey("key-guid")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package capi
+
+import (
+	"fmt"
+)
+
+// Droplet is a v3 droplet: a staged app, ready to run. A copied droplet is
+// COPYING until it is STAGED.
+type Droplet struct {
+	Guid  string `json:"guid"`
+	State string `json:"state"`
+}
+
+// CurrentDroplet fetches the droplet the app runs.
+func (client *Client) CurrentDroplet(appGuid string) (Droplet, error) {
+	var droplet Droplet
+	err := client.Get(fmt.Sprintf("v3/apps/%s/droplets/current", appGuid), &droplet)
+	return droplet, err
+}
+
+// CopyDroplet starts copying the droplet to another app, which may be in
+// another space.
+func (client *Client) CopyDroplet(dropletGuid, appGuid string) (Droplet, error) {
+	var droplet Droplet
+	err := client.Do("POST", "v3/droplets?source_guid="+dropletGuid, map[string]interface{}{
+		"relationships": map[string]interface{}{
+			"app": map[string]interface{}{"data": map[string]string{"guid": appGuid}},
+		},
+	}, &droplet)
+	return droplet, err
+}
+
+// GetDroplet fetches the droplet, to see whether a copy has finished.
+func (client *Client) GetDroplet(dropletGuid string) (Droplet, error) {
+	var droplet Droplet
+	err := client.Get(fmt.Sprintf("v3/droplets/%s", dropletGuid), &droplet)
+	return droplet, err
+}
+
+// SetCurrentDroplet makes the app run the droplet the next time it starts.
+func (client *Client) SetCurrentDroplet(appGuid, dropletGuid string) error {
+	return client.Do("PATCH", fmt.Sprintf("v3/apps/%s/relationships/current_droplet", appGuid), map[string]interface{}{
+		"data": map[string]string{"guid": dropletGuid},
+	}, nil)
+}
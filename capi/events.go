@@ -0,0 +1,76 @@
+package capi
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is an entry in the Cloud Controller's audit log, such as
+// audit.app.update.
+type Event struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		Type      string `json:"type"`
+		Actor     string `json:"actor"`
+		ActorType string `json:"actor_type"`
+		ActorName string `json:"actor_name"`
+		Actee     string `json:"actee"`
+		ActeeName string `json:"actee_name"`
+		Timestamp string `json:"timestamp"`
+	} `json:"entity"`
+}
+
+type eventList struct {
+	Resources []Event `json:"resources"`
+	NextURL   string  `json:"next_url"`
+}
+
+// Events lists the audit events recorded against the app since the given
+// time, oldest first.
+func (client *Client) Events(acteeGuid string, since time.Time) ([]Event, error) {
+	events := []Event{}
+	path := "v2/events?" + Query("actee:"+acteeGuid, "timestamp>="+since.UTC().Format(time.RFC3339)) +
+		"&order-direction=asc&results-per-page=100"
+
+	for path != "" {
+		var page eventList
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, page.Resources...)
+		path = page.NextURL
+	}
+
+	return events, nil
+}
+
+// UpdateAppAnnotations sets annotations on the app, leaving any others it has
+// alone. The Cloud Controller records the change as an audit.app.update event.
+func (client *Client) UpdateAppAnnotations(appGuid string, annotations map[string]string) error {
+	body := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	}
+
+	return client.Do("PATCH", fmt.Sprintf("v3/apps/%s", appGuid), body, nil)
+}
+
+// AppAnnotations fetches the annotations set on the app.
+func (client *Client) AppAnnotations(appGuid string) (map[string]string, error) {
+	var app struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	err := client.Get(fmt.Sprintf("v3/apps/%s", appGuid), &app)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.Metadata.Annotations == nil {
+		return map[string]string{}, nil
+	}
+
+	return app.Metadata.Annotations, nil
+}
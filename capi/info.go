@@ -0,0 +1,39 @@
+package capi
+
+// Info is what the Cloud Controller's root endpoint says about the APIs it
+// offers.
+type Info struct {
+	// V2Version is the version of the v2 API, e.g. "2.150.0".
+	V2Version string
+	// V3Version is the version of the v3 API, or "" if it is not offered.
+	V3Version string
+}
+
+// Info asks the root endpoint which APIs the Cloud Controller offers.
+func (client *Client) Info() (Info, error) {
+	type api struct {
+		Meta struct {
+			Version string `json:"version"`
+		} `json:"meta"`
+	}
+
+	var root struct {
+		Links struct {
+			V2 *api `json:"cloud_controller_v2"`
+			V3 *api `json:"cloud_controller_v3"`
+		} `json:"links"`
+	}
+	err := client.Get("/", &root)
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{}
+	if root.Links.V2 != nil {
+		info.V2Version = root.Links.V2.Meta.Version
+	}
+	if root.Links.V3 != nil {
+		info.V3Version = root.Links.V3.Meta.Version
+	}
+	return info, nil
+}
@@ -0,0 +1,102 @@
+package capi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Revision is a v3 app revision: a droplet with the environment and process
+// commands it ran with, which the app can be deployed back to.
+type Revision struct {
+	Guid        string `json:"guid"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Deployable  bool   `json:"deployable"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type v3Pagination struct {
+	Next *struct {
+		Href string `json:"href"`
+	} `json:"next"`
+}
+
+// Deployment is a v3 deployment, which replaces an app's instances with
+// those of a revision or droplet a few at a time.
+type Deployment struct {
+	Guid   string `json:"guid"`
+	State  string `json:"state"`
+	Status struct {
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	} `json:"status"`
+}
+
+// RevisionsEnabled reports whether the app records revisions.
+func (client *Client) RevisionsEnabled(appGuid string) (bool, error) {
+	var feature struct {
+		Enabled bool `json:"enabled"`
+	}
+	err := client.Get(fmt.Sprintf("v3/apps/%s/features/revisions", appGuid), &feature)
+	return feature.Enabled, err
+}
+
+// Revisions lists the app's revisions, oldest first.
+func (client *Client) Revisions(appGuid string) ([]Revision, error) {
+	revisions := []Revision{}
+	path := fmt.Sprintf("v3/apps/%s/revisions?order_by=created_at&per_page=100", appGuid)
+	for path != "" {
+		var page struct {
+			Pagination v3Pagination `json:"pagination"`
+			Resources  []Revision   `json:"resources"`
+		}
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, page.Resources...)
+		path = ""
+		if page.Pagination.Next != nil {
+			// v3 links are absolute
+			path = strings.TrimPrefix(page.Pagination.Next.Href, client.endpoint)
+		}
+	}
+
+	return revisions, nil
+}
+
+// DeployedRevisions lists the revisions the app's running instances are on,
+// more than one while a deployment is under way.
+func (client *Client) DeployedRevisions(appGuid string) ([]Revision, error) {
+	var deployed struct {
+		Resources []Revision `json:"resources"`
+	}
+	err := client.Get(fmt.Sprintf("v3/apps/%s/revisions/deployed", appGuid), &deployed)
+	return deployed.Resources, err
+}
+
+// CreateDeployment starts a rolling deployment of the app to the revision.
+func (client *Client) CreateDeployment(appGuid, revisionGuid string) (Deployment, error) {
+	var deployment Deployment
+	err := client.Do("POST", "v3/deployments", map[string]interface{}{
+		"revision": map[string]string{"guid": revisionGuid},
+		"relationships": map[string]interface{}{
+			"app": map[string]interface{}{"data": map[string]string{"guid": appGuid}},
+		},
+	}, &deployment)
+	return deployment, err
+}
+
+// GetDeployment fetches the deployment's progress.
+func (client *Client) GetDeployment(deploymentGuid string) (Deployment, error) {
+	var deployment Deployment
+	err := client.Get(fmt.Sprintf("v3/deployments/%s", deploymentGuid), &deployment)
+	return deployment, err
+}
+
+// CancelDeployment stops a deployment, which scales the app back to what it
+// was running before.
+func (client *Client) CancelDeployment(deploymentGuid string) error {
+	return client.Do("POST", fmt.Sprintf("v3/deployments/%s/actions/cancel", deploymentGuid), nil, nil)
+}
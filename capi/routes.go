@@ -0,0 +1,185 @@
+package capi
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Route struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		Host                string `json:"host"`
+		Path                string `json:"path"`
+		Port                int    `json:"port"`
+		DomainGuid          string `json:"domain_guid"`
+		SpaceGuid           string `json:"space_guid"`
+		ServiceInstanceGuid string `json:"service_instance_guid"`
+	} `json:"entity"`
+}
+
+type routeList struct {
+	Resources []Route `json:"resources"`
+}
+
+type domainList struct {
+	Resources []struct {
+		Metadata Metadata `json:"metadata"`
+	} `json:"resources"`
+}
+
+// FindDomain looks up a shared or private domain by name. The bool is false
+// if there is no such domain.
+func (client *Client) FindDomain(name string) (string, bool, error) {
+	for _, endpoint := range []string{"v2/shared_domains", "v2/private_domains"} {
+		var domains domainList
+		err := client.Get(endpoint+"?"+Query("name:"+name), &domains)
+		if err != nil {
+			return "", false, err
+		}
+
+		if len(domains.Resources) > 0 {
+			return domains.Resources[0].Metadata.Guid, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// AppRoute is a route of an app, as the v3 API lists them.
+type AppRoute struct {
+	Guid string `json:"guid"`
+	Host string `json:"host"`
+	Path string `json:"path"`
+	// Port is set for a TCP route, whose URL is "domain:port".
+	Port int `json:"port"`
+	// URL is the route as "host.domain/path".
+	URL string `json:"url"`
+}
+
+// Domain is the name of the route's domain.
+func (route AppRoute) Domain() string {
+	hostAndDomain := strings.TrimSuffix(route.URL, route.Path)
+	if route.Port != 0 {
+		hostAndDomain = strings.TrimSuffix(hostAndDomain, fmt.Sprintf(":%d", route.Port))
+	}
+	if route.Host == "" {
+		return hostAndDomain
+	}
+
+	return strings.TrimPrefix(hostAndDomain, route.Host+".")
+}
+
+// AppRoutes lists the routes mapped to the app.
+func (client *Client) AppRoutes(appGuid string) ([]AppRoute, error) {
+	routes := []AppRoute{}
+	path := fmt.Sprintf("v3/apps/%s/routes?per_page=100", appGuid)
+	for path != "" {
+		var page struct {
+			Pagination v3Pagination `json:"pagination"`
+			Resources  []AppRoute   `json:"resources"`
+		}
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, page.Resources...)
+		path = ""
+		if page.Pagination.Next != nil {
+			// v3 links are absolute
+			path = strings.TrimPrefix(page.Pagination.Next.Href, client.endpoint)
+		}
+	}
+
+	return routes, nil
+}
+
+// FindRoute looks up a route by host and domain. The bool is false if there
+// is no such route visible to the current user.
+func (client *Client) FindRoute(host, domainGuid string) (Route, bool, error) {
+	return client.FindRouteWithPath(host, domainGuid, "")
+}
+
+// FindRouteWithPath looks up a route by host, domain and path, "" for the
+// route without one.
+func (client *Client) FindRouteWithPath(host, domainGuid, path string) (Route, bool, error) {
+	filters := []string{"host:" + host, "domain_guid:" + domainGuid}
+	if path != "" {
+		filters = append(filters, "path:"+path)
+	}
+
+	var routes routeList
+	err := client.Get("v2/routes?"+Query(filters...), &routes)
+	if err != nil {
+		return Route{}, false, err
+	}
+
+	for _, route := range routes.Resources {
+		if route.Entity.Path == path {
+			return route, true, nil
+		}
+	}
+
+	return Route{}, false, nil
+}
+
+func (client *Client) GetRoute(routeGuid string) (Route, error) {
+	var route Route
+	err := client.Get(fmt.Sprintf("v2/routes/%s", routeGuid), &route)
+	return route, err
+}
+
+func (client *Client) CreateRoute(host, domainGuid, spaceGuid string) (Route, error) {
+	return client.CreateRouteWithPath(host, domainGuid, spaceGuid, "")
+}
+
+func (client *Client) CreateRouteWithPath(host, domainGuid, spaceGuid, path string) (Route, error) {
+	body := map[string]string{
+		"host":        host,
+		"domain_guid": domainGuid,
+		"space_guid":  spaceGuid,
+	}
+	if path != "" {
+		body["path"] = path
+	}
+
+	var route Route
+	err := client.Do("POST", "v2/routes", body, &route)
+	return route, err
+}
+
+// CreateTCPRoute creates the TCP route on the port of the domain.
+func (client *Client) CreateTCPRoute(domainGuid, spaceGuid string, port int) (Route, error) {
+	body := map[string]interface{}{
+		"domain_guid": domainGuid,
+		"space_guid":  spaceGuid,
+		"port":        port,
+	}
+
+	var route Route
+	err := client.Do("POST", "v2/routes", body, &route)
+	return route, err
+}
+
+func (client *Client) MapRoute(routeGuid, appGuid string) error {
+	return client.Do("PUT", fmt.Sprintf("v2/routes/%s/apps/%s", routeGuid, appGuid), nil, nil)
+}
+
+func (client *Client) UnmapRoute(routeGuid, appGuid string) error {
+	return client.Do("DELETE", fmt.Sprintf("v2/routes/%s/apps/%s", routeGuid, appGuid), nil, nil)
+}
+
+// BindRouteService sends the route's traffic through a route service.
+func (client *Client) BindRouteService(serviceInstanceGuid, routeGuid string) error {
+	return client.Do("PUT", fmt.Sprintf("v2/service_instances/%s/routes/%s", serviceInstanceGuid, routeGuid), nil, nil)
+}
+
+// UnbindRouteService stops sending the route's traffic through a route
+// service.
+func (client *Client) UnbindRouteService(serviceInstanceGuid, routeGuid string) error {
+	return client.Do("DELETE", fmt.Sprintf("v2/service_instances/%s/routes/%s", serviceInstanceGuid, routeGuid), nil, nil)
+}
+
+func (client *Client) DeleteRoute(routeGuid string) error {
+	return client.Do("DELETE", fmt.Sprintf("v2/routes/%s", routeGuid), nil, nil)
+}
@@ -0,0 +1,66 @@
+package capi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SecurityGroupRule is one egress rule of an application security group.
+// Destination is an address, a CIDR block or a range such as
+// "10.0.0.1-10.0.0.9", and Ports a list such as "80,443" or a range such as
+// "8000-9000".
+type SecurityGroupRule struct {
+	Protocol    string `json:"protocol"`
+	Destination string `json:"destination"`
+	Ports       string `json:"ports"`
+}
+
+type securityGroupList struct {
+	Resources []struct {
+		Entity struct {
+			Name  string              `json:"name"`
+			Rules []SecurityGroupRule `json:"rules"`
+		} `json:"entity"`
+	} `json:"resources"`
+	NextURL string `json:"next_url"`
+}
+
+// RunningSecurityGroupRules lists the egress rules that apply to running apps
+// in the space, from the platform wide groups and the space's own. Only
+// admins may list the platform wide groups; for anyone else they are left out
+// and the bool is false.
+func (client *Client) RunningSecurityGroupRules(spaceGuid string) ([]SecurityGroupRule, bool, error) {
+	complete := true
+
+	global, err := client.securityGroupRules("v2/config/running_security_groups")
+	if apiErr, ok := err.(*Error); ok && apiErr.StatusCode == http.StatusForbidden {
+		complete = false
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	space, err := client.securityGroupRules(fmt.Sprintf("v2/spaces/%s/security_groups", spaceGuid))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return append(global, space...), complete, nil
+}
+
+func (client *Client) securityGroupRules(path string) ([]SecurityGroupRule, error) {
+	rules := []SecurityGroupRule{}
+	for path != "" {
+		var page securityGroupList
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range page.Resources {
+			rules = append(rules, group.Entity.Rules...)
+		}
+		path = page.NextURL
+	}
+
+	return rules, nil
+}
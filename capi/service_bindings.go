@@ -0,0 +1,38 @@
+package capi
+
+import (
+	"fmt"
+)
+
+// ServiceBinding binds a service instance to an app. SyslogDrainURL is set
+// when the instance forwards the app's logs.
+type ServiceBinding struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		ServiceInstanceGuid string `json:"service_instance_guid"`
+		SyslogDrainURL      string `json:"syslog_drain_url"`
+	} `json:"entity"`
+}
+
+type serviceBindingList struct {
+	Resources []ServiceBinding `json:"resources"`
+	NextURL   string           `json:"next_url"`
+}
+
+// AppServiceBindings lists the service bindings of an app.
+func (client *Client) AppServiceBindings(appGuid string) ([]ServiceBinding, error) {
+	bindings := []ServiceBinding{}
+	path := fmt.Sprintf("v2/apps/%s/service_bindings", appGuid)
+	for path != "" {
+		var page serviceBindingList
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		bindings = append(bindings, page.Resources...)
+		path = page.NextURL
+	}
+
+	return bindings, nil
+}
@@ -0,0 +1,124 @@
+package capi
+
+import (
+	"fmt"
+)
+
+// ServiceKey is a set of credentials for a service instance.
+type ServiceKey struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		Name        string                 `json:"name"`
+		Credentials map[string]interface{} `json:"credentials"`
+	} `json:"entity"`
+}
+
+type serviceKeyList struct {
+	Resources []ServiceKey `json:"resources"`
+	NextURL   string       `json:"next_url"`
+}
+
+// FindServiceInstance looks up a managed service instance by name in a
+// space. The bool is false if there is no such instance.
+func (client *Client) FindServiceInstance(spaceGuid, name string) (string, bool, error) {
+	var instances struct {
+		Resources []struct {
+			Metadata Metadata `json:"metadata"`
+		} `json:"resources"`
+	}
+	err := client.Get(fmt.Sprintf("v2/spaces/%s/service_instances?", spaceGuid)+Query("name:"+name), &instances)
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(instances.Resources) == 0 {
+		return "", false, nil
+	}
+
+	return instances.Resources[0].Metadata.Guid, true, nil
+}
+
+// ServiceInstance is a managed or user-provided service instance, with the
+// last operation the broker ran on it.
+type ServiceInstance struct {
+	Metadata Metadata `json:"metadata"`
+	Entity   struct {
+		Name          string `json:"name"`
+		LastOperation *struct {
+			Type        string `json:"type"`
+			State       string `json:"state"`
+			Description string `json:"description"`
+		} `json:"last_operation"`
+	} `json:"entity"`
+}
+
+// Bindable says whether an app can be bound to the instance: no operation
+// is in progress on it, and it was not left half created or half deleted.
+// The reason says why not.
+func (instance ServiceInstance) Bindable() (bool, string) {
+	operation := instance.Entity.LastOperation
+	if operation == nil {
+		return true, ""
+	}
+
+	switch {
+	case operation.State == "in progress":
+		return false, fmt.Sprintf("%s in progress", operation.Type)
+	case operation.State == "failed" && (operation.Type == "create" || operation.Type == "delete"):
+		return false, fmt.Sprintf("%s failed", operation.Type)
+	}
+	return true, ""
+}
+
+// FindAnyServiceInstance looks up a managed or user-provided service
+// instance by name in a space. The bool is false if there is no such
+// instance.
+func (client *Client) FindAnyServiceInstance(spaceGuid, name string) (ServiceInstance, bool, error) {
+	var instances struct {
+		Resources []ServiceInstance `json:"resources"`
+	}
+	err := client.Get(fmt.Sprintf("v2/spaces/%s/service_instances?return_user_provided_service_instances=true&", spaceGuid)+Query("name:"+name), &instances)
+	if err != nil {
+		return ServiceInstance{}, false, err
+	}
+
+	if len(instances.Resources) == 0 {
+		return ServiceInstance{}, false, nil
+	}
+
+	return instances.Resources[0], true, nil
+}
+
+// ServiceKeys lists the keys of a service instance.
+func (client *Client) ServiceKeys(serviceInstanceGuid string) ([]ServiceKey, error) {
+	keys := []ServiceKey{}
+	path := fmt.Sprintf("v2/service_instances/%s/service_keys", serviceInstanceGuid)
+	for path != "" {
+		var page serviceKeyList
+		err := client.Get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, page.Resources...)
+		path = page.NextURL
+	}
+
+	return keys, nil
+}
+
+// CreateServiceKey creates a key for the service instance, and returns it
+// with its credentials.
+func (client *Client) CreateServiceKey(serviceInstanceGuid, name string) (ServiceKey, error) {
+	var key ServiceKey
+	err := client.Do("POST", "v2/service_keys", map[string]string{
+		"service_instance_guid": serviceInstanceGuid,
+		"name":                  name,
+	}, &key)
+	return key, err
+}
+
+// DeleteServiceKey deletes a service key.
+func (client *Client) DeleteServiceKey(serviceKeyGuid string) error {
+	return client.Do("DELETE", fmt.Sprintf("v2/service_keys/%s", serviceKeyGuid), nil, nil)
+}
@@ -0,0 +1,25 @@
+package capi
+
+import (
+	"fmt"
+)
+
+// FindSpace looks a space up by name in an org. The bool is false if there
+// is no such space.
+func (client *Client) FindSpace(orgGuid, name string) (string, bool, error) {
+	var spaces struct {
+		Resources []struct {
+			Metadata Metadata `json:"metadata"`
+		} `json:"resources"`
+	}
+	err := client.Get(fmt.Sprintf("v2/organizations/%s/spaces?", orgGuid)+Query("name:"+name), &spaces)
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(spaces.Resources) == 0 {
+		return "", false, nil
+	}
+
+	return spaces.Resources[0].Metadata.Guid, true, nil
+}
@@ -0,0 +1,132 @@
+package capi
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// UAATokenSource gets access tokens straight from UAA, with client
+// credentials or a refresh token, so the client can be used without a cf CLI
+// session. Its Token method can be given to NewClient.
+type UAATokenSource struct {
+	// TokenEndpoint is UAA's URL, as listed in the API's v2/info.
+	TokenEndpoint string
+	ClientID      string
+	ClientSecret  string
+	// RefreshToken, if set, is exchanged for access tokens instead of the
+	// client credentials. The cf CLI's own client is "cf", with no secret.
+	RefreshToken string
+
+	httpClient *http.Client
+	lock       sync.Mutex
+}
+
+func NewUAATokenSource(tokenEndpoint, clientID, clientSecret, refreshToken string, skipSSLValidation bool) *UAATokenSource {
+	return &UAATokenSource{
+		TokenEndpoint: strings.TrimRight(tokenEndpoint, "/"),
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		RefreshToken:  refreshToken,
+		httpClient:    newHTTPClient(skipSSLValidation),
+	}
+}
+
+// DiscoverTokenEndpoint asks the API which UAA issues its tokens.
+func DiscoverTokenEndpoint(apiEndpoint string, skipSSLValidation bool) (string, error) {
+	response, err := newHTTPClient(skipSSLValidation).Get(strings.TrimRight(apiEndpoint, "/") + "/v2/info")
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", &Error{StatusCode: response.StatusCode, Description: http.StatusText(response.StatusCode)}
+	}
+
+	var info struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil {
+		return "", err
+	}
+
+	if info.TokenEndpoint == "" {
+		return "", fmt.Errorf("%s does not list a token endpoint", apiEndpoint)
+	}
+
+	return info.TokenEndpoint, nil
+}
+
+// Token fetches a new access token, as "bearer <token>". When UAA hands out
+// a new refresh token, it is used next time.
+func (source *UAATokenSource) Token() (string, error) {
+	source.lock.Lock()
+	defer source.lock.Unlock()
+
+	form := url.Values{}
+	if source.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", source.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	request, err := http.NewRequest("POST", source.TokenEndpoint+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.SetBasicAuth(source.ClientID, source.ClientSecret)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := source.httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	json.Unmarshal(body, &token)
+
+	if response.StatusCode != http.StatusOK || token.AccessToken == "" {
+		description := token.ErrorDescription
+		if description == "" {
+			description = http.StatusText(response.StatusCode)
+		}
+		return "", &Error{StatusCode: response.StatusCode, ErrorCode: token.Error, Description: "UAA did not issue a token: " + description}
+	}
+
+	if token.RefreshToken != "" && source.RefreshToken != "" {
+		source.RefreshToken = token.RefreshToken
+	}
+
+	return "bearer " + token.AccessToken, nil
+}
+
+func newHTTPClient(skipSSLValidation bool) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: skipSSLValidation,
+			},
+		},
+	}
+}
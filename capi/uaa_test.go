@@ -0,0 +1,91 @@
+package capi_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/concourse/autopilot/capi"
+)
+
+// verifyForm checks the given fields of a form encoded request body.
+func verifyForm(fields map[string][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Expect(r.ParseForm()).To(Succeed())
+		for name, values := range fields {
+			Expect(r.PostForm[name]).To(Equal(values))
+		}
+	}
+}
+
+var _ = Describe("UAATokenSource", func() {
+	var uaa *ghttp.Server
+
+	BeforeEach(func() {
+		uaa = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		uaa.Close()
+	})
+
+	It("gets tokens with client credentials", func() {
+		uaa.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/oauth/token"),
+			ghttp.VerifyBasicAuth("deployer", "secret"),
+			verifyForm(map[string][]string{"grant_type": {"client_credentials"}}),
+			ghttp.RespondWith(http.StatusOK, `{"access_token":"token-1","token_type":"bearer"}`),
+		))
+
+		source := capi.NewUAATokenSource(uaa.URL()+"/", "deployer", "secret", "", false)
+		Expect(source.Token()).To(Equal("bearer token-1"))
+	})
+
+	It("exchanges the refresh token, keeping the new one", func() {
+		uaa.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("cf", ""),
+				verifyForm(map[string][]string{"grant_type": {"refresh_token"}, "refresh_token": {"refresh-1"}}),
+				ghttp.RespondWith(http.StatusOK, `{"access_token":"token-1","refresh_token":"refresh-2"}`),
+			),
+			ghttp.CombineHandlers(
+				verifyForm(map[string][]string{"refresh_token": {"refresh-2"}}),
+				ghttp.RespondWith(http.StatusOK, `{"access_token":"token-2"}`),
+			),
+		)
+
+		source := capi.NewUAATokenSource(uaa.URL(), "cf", "", "refresh-1", false)
+		Expect(source.Token()).To(Equal("bearer token-1"))
+		Expect(source.Token()).To(Equal("bearer token-2"))
+	})
+
+	It("reports rejected credentials", func() {
+		uaa.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, `{"error":"unauthorized","error_description":"Bad credentials"}`))
+
+		_, err := capi.NewUAATokenSource(uaa.URL(), "deployer", "wrong", "", false).Token()
+		Expect(err).To(MatchError("UAA did not issue a token: Bad credentials (401 unauthorized)"))
+	})
+
+	It("can be used by the client", func() {
+		uaa.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/info"),
+				ghttp.RespondWith(http.StatusOK, `{"token_endpoint":"`+uaa.URL()+`"}`),
+			),
+			ghttp.RespondWith(http.StatusOK, `{"access_token":"token-1"}`),
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "bearer token-1"),
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			),
+		)
+
+		tokenEndpoint, err := capi.DiscoverTokenEndpoint(uaa.URL(), false)
+		Expect(err).ToNot(HaveOccurred())
+
+		source := capi.NewUAATokenSource(tokenEndpoint, "deployer", "secret", "", false)
+		client := capi.NewClient(uaa.URL(), source.Token, false)
+		Expect(client.Get("v2/apps/app-guid", nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,83 @@
+package autopilot
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ParseCFHome takes the --cf-home flag, which every command accepts, out of
+// args. Arguments after -- are left alone.
+func ParseCFHome(args []string) (string, []string) {
+	return takeStringFlag(args, "cf-home")
+}
+
+// CFHomeCommand runs the same autopilot command in a cf CLI that uses the
+// config in cfHome. The cf CLI that started the plugin has already read its
+// own config, so a separate cf process is the only way to act as another
+// login. The plugin itself is still found where it was installed.
+func CFHomeCommand(cfHome string, args []string, environ []string) (*exec.Cmd, error) {
+	cfHome, err := filepath.Abs(cfHome)
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	names := []string{}
+	for _, pair := range environ {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if _, seen := env[parts[0]]; !seen {
+			names = append(names, parts[0])
+		}
+		env[parts[0]] = parts[1]
+	}
+
+	if env["CF_PLUGIN_HOME"] == "" {
+		pluginHome := env["CF_HOME"]
+		if pluginHome == "" {
+			pluginHome = env["HOME"]
+		}
+		// the cf CLI's home on Windows
+		if pluginHome == "" {
+			pluginHome = env["USERPROFILE"]
+		}
+		if pluginHome != "" {
+			env["CF_PLUGIN_HOME"] = pluginHome
+			names = append(names, "CF_PLUGIN_HOME")
+		}
+	}
+
+	if _, seen := env["CF_HOME"]; !seen {
+		names = append(names, "CF_HOME")
+	}
+	env["CF_HOME"] = cfHome
+
+	command := exec.Command("cf", args...)
+	for _, name := range names {
+		command.Env = append(command.Env, name+"="+env[name])
+	}
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	return command, nil
+}
+
+// runInCFHome runs the command against the other config and exits with its
+// status.
+func runInCFHome(cfHome string, args []string) {
+	command, err := CFHomeCommand(cfHome, args, os.Environ())
+	fatalIf(err)
+
+	err = command.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	}
+	fatalIf(err)
+
+	os.Exit(0)
+}
@@ -0,0 +1,44 @@
+package autopilot_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ParseCFHome", func() {
+	It("takes the cf-home flag out of the args", func() {
+		cfHome, args := ParseCFHome([]string{"zero-downtime-push", "app", "--cf-home", "/tmp/prod", "-f", "manifest.yml"})
+		Expect(cfHome).To(Equal("/tmp/prod"))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+		cfHome, _ = ParseCFHome([]string{"zero-downtime-push", "app", "--cf-home=/tmp/staging"})
+		Expect(cfHome).To(Equal("/tmp/staging"))
+	})
+})
+
+var _ = Describe("CFHomeCommand", func() {
+	It("runs the command with the other config, still finding the plugin", func() {
+		command, err := CFHomeCommand("prod", []string{"zero-downtime-push", "app"}, []string{"HOME=/home/ci", "PATH=/bin"})
+		Expect(err).ToNot(HaveOccurred())
+
+		cfHome, _ := filepath.Abs("prod")
+		Expect(command.Args).To(Equal([]string{"cf", "zero-downtime-push", "app"}))
+		Expect(command.Env).To(Equal([]string{"HOME=/home/ci", "PATH=/bin", "CF_PLUGIN_HOME=/home/ci", "CF_HOME=" + cfHome}))
+	})
+
+	It("keeps the plugin home of the current config", func() {
+		command, err := CFHomeCommand("/tmp/prod", []string{"zero-downtime-push", "app"}, []string{"CF_HOME=/tmp/default", "HOME=/home/ci"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(command.Env).To(Equal([]string{"CF_HOME=/tmp/prod", "HOME=/home/ci", "CF_PLUGIN_HOME=/tmp/default"}))
+	})
+
+	It("finds the plugin in the user's profile on Windows", func() {
+		command, err := CFHomeCommand("prod", []string{"zero-downtime-push", "app"}, []string{`USERPROFILE=C:\Users\ci`})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(command.Env).To(ContainElement(`CF_PLUGIN_HOME=C:\Users\ci`))
+	})
+})
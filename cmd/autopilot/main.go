@@ -0,0 +1,13 @@
+// Command autopilot is the cf CLI plugin. All it does is start the plugin,
+// which is in package autopilot along with the planner and ApplicationRepo
+// it deploys with, so that other tools can import them.
+package main
+
+import (
+	"github.com/cloudfoundry/cli/plugin"
+	"github.com/concourse/autopilot"
+)
+
+func main() {
+	plugin.Start(&autopilot.AutopilotPlugin{})
+}
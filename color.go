@@ -0,0 +1,123 @@
+package autopilot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// Colors colors output meant for a terminal: green for what succeeded,
+// yellow for warnings and what was undone, red for failures. The zero value
+// leaves text as it is.
+type Colors struct {
+	Enabled bool
+}
+
+const (
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	colorReset  = "\x1b[0m"
+)
+
+// colors is how the plugin's own output is colored, set once the flags are
+// parsed.
+var colors Colors
+
+// ParseColor takes the --no-color flag, which every command accepts, out of
+// args. Output is colored only on a terminal, and never when NO_COLOR is set
+// or TERM is dumb.
+func ParseColor(args []string, getenv func(string) string, terminal bool) (Colors, []string) {
+	noColor, args := takeBoolFlag(args, "no-color")
+	enabled := terminal && !noColor && getenv("NO_COLOR") == "" && getenv("TERM") != "dumb"
+	return Colors{Enabled: enabled}, args
+}
+
+// stdoutIsTerminal says whether output goes to a terminal rather than a file
+// or a CI log.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (colors Colors) paint(color, text string) string {
+	if !colors.Enabled {
+		return text
+	}
+
+	// the newline is left uncolored, so a cut off line does not bleed
+	body := strings.TrimRight(text, "\n")
+	return color + body + colorReset + text[len(body):]
+}
+
+// Success colors text green.
+func (colors Colors) Success(text string) string { return colors.paint(colorGreen, text) }
+
+// Warning colors text yellow.
+func (colors Colors) Warning(text string) string { return colors.paint(colorYellow, text) }
+
+// Failure colors text red.
+func (colors Colors) Failure(text string) string { return colors.paint(colorRed, text) }
+
+// warnf prints a warning.
+func warnf(format string, args ...interface{}) {
+	fmt.Print(colors.Warning(fmt.Sprintf("Warning: "+format, args...)))
+}
+
+// Logger returns a Logger that colors the warnings passed on to logger.
+func (colors Colors) Logger(logger Logger) Logger {
+	if !colors.Enabled {
+		return logger
+	}
+
+	return colorLogger{colors: colors, next: logger}
+}
+
+type colorLogger struct {
+	colors Colors
+	next   Logger
+}
+
+func (logger colorLogger) Printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if strings.HasPrefix(message, "Warning:") {
+		message = logger.colors.Warning(message)
+	}
+	logger.next.Printf("%s", message)
+}
+
+// StepPrinter prints the status of each step of a deploy as it finishes. Its
+// ObserveStep is a rewind.Observer.
+type StepPrinter struct {
+	Colors Colors
+	Out    io.Writer
+	// Now is the clock steps are timed with; nil is time.Now.
+	Now func() time.Time
+}
+
+// ObserveStep prints whether the step succeeded, failed or was undone.
+func (printer StepPrinter) ObserveStep(name, phase string, start time.Time, err error) {
+	now := time.Now
+	if printer.Now != nil {
+		now = printer.Now
+	}
+	took := now().Sub(start).Round(100 * time.Millisecond)
+
+	var line string
+	switch {
+	case err != nil && phase == rewind.PhaseForward:
+		line = printer.Colors.Failure(fmt.Sprintf("FAILED  %s (%s)", name, took))
+	case err != nil:
+		line = printer.Colors.Failure(fmt.Sprintf("FAILED  undoing %s (%s)", name, took))
+	case phase == rewind.PhaseForward:
+		line = printer.Colors.Success(fmt.Sprintf("OK      %s (%s)", name, took))
+	default:
+		line = printer.Colors.Warning(fmt.Sprintf("UNDONE  %s (%s)", name, took))
+	}
+
+	fmt.Fprintln(printer.Out, line)
+}
@@ -0,0 +1,101 @@
+package autopilot_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("ParseColor", func() {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+
+	It("colors output on a terminal", func() {
+		colors, args := ParseColor([]string{"zero-downtime-push", "app"}, env(nil), true)
+		Expect(colors.Enabled).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app"}))
+	})
+
+	It("does not color output that is not going to a terminal", func() {
+		colors, _ := ParseColor([]string{"zero-downtime-push", "app"}, env(nil), false)
+		Expect(colors.Enabled).To(BeFalse())
+	})
+
+	It("takes the no-color flag out of the args", func() {
+		colors, args := ParseColor([]string{"zero-downtime-push", "app", "--no-color", "-f", "manifest.yml"}, env(nil), true)
+		Expect(colors.Enabled).To(BeFalse())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("does not color output when NO_COLOR is set or the terminal is dumb", func() {
+		colors, _ := ParseColor([]string{"zero-downtime-push", "app"}, env(map[string]string{"NO_COLOR": "1"}), true)
+		Expect(colors.Enabled).To(BeFalse())
+
+		colors, _ = ParseColor([]string{"zero-downtime-push", "app"}, env(map[string]string{"TERM": "dumb"}), true)
+		Expect(colors.Enabled).To(BeFalse())
+	})
+})
+
+var _ = Describe("Colors", func() {
+	It("colors text, leaving its newline alone", func() {
+		colors := Colors{Enabled: true}
+		Expect(colors.Success("done\n")).To(Equal("\x1b[32mdone\x1b[0m\n"))
+		Expect(colors.Warning("careful")).To(Equal("\x1b[33mcareful\x1b[0m"))
+		Expect(colors.Failure("broken")).To(Equal("\x1b[31mbroken\x1b[0m"))
+	})
+
+	It("leaves text alone when disabled", func() {
+		Expect(Colors{}.Failure("broken\n")).To(Equal("broken\n"))
+	})
+
+	It("colors the warnings a logger is given", func() {
+		logger := &recordingLogger{}
+		colored := Colors{Enabled: true}.Logger(logger)
+		colored.Printf("Warning: %s\n", "careful")
+		colored.Printf("Pushing %s.\n", "app")
+
+		Expect(logger.messages).To(Equal([]string{"\x1b[33mWarning: careful\x1b[0m\n", "Pushing app.\n"}))
+		Expect(Colors{}.Logger(logger) == Logger(logger)).To(BeTrue())
+	})
+})
+
+var _ = Describe("StepPrinter", func() {
+	var (
+		out     bytes.Buffer
+		printer StepPrinter
+		start   time.Time
+	)
+
+	BeforeEach(func() {
+		out.Reset()
+		start = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		printer = StepPrinter{Out: &out, Now: func() time.Time { return start.Add(2340 * time.Millisecond) }}
+	})
+
+	It("prints how each step ended", func() {
+		printer.ObserveStep("push", rewind.PhaseForward, start, nil)
+		printer.ObserveStep("verify routes", rewind.PhaseForward, start, errors.New("missing"))
+		printer.ObserveStep("rename live app", rewind.PhaseUndo, start, nil)
+		printer.ObserveStep("push", rewind.PhaseUndo, start, errors.New("forbidden"))
+
+		Expect(out.String()).To(Equal("OK      push (2.3s)\n" +
+			"FAILED  verify routes (2.3s)\n" +
+			"UNDONE  rename live app (2.3s)\n" +
+			"FAILED  undoing push (2.3s)\n"))
+	})
+
+	It("colors the statuses", func() {
+		printer.Colors = Colors{Enabled: true}
+		printer.ObserveStep("push", rewind.PhaseForward, start, nil)
+		printer.ObserveStep("push", rewind.PhaseUndo, start, nil)
+
+		Expect(out.String()).To(Equal("\x1b[32mOK      push (2.3s)\x1b[0m\n\x1b[33mUNDONE  push (2.3s)\x1b[0m\n"))
+	})
+})
@@ -0,0 +1,60 @@
+package autopilot
+
+import (
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configVar names the config file, which is autopilot.yml in the working
+// directory by default.
+const (
+	configVar         = "AUTOPILOT_CONFIG"
+	defaultConfigPath = "autopilot.yml"
+)
+
+// Config holds settings that apply to every deploy from a repository or
+// pipeline, so they do not have to be repeated as flags. Flags win over the
+// config file.
+type Config struct {
+	// DeployWindow is the --deploy-window setting.
+	DeployWindow string `yaml:"deploy_window"`
+
+	// Redact lists patterns for the names of environment variables whose
+	// values are masked in output, instead of DefaultRedactPatterns.
+	Redact []string `yaml:"redact"`
+
+	// Venerable sets what becomes of the old version of apps when neither
+	// --keep-existing-app nor --unmap-routes is given.
+	Venerable VenerablePolicy `yaml:"venerable"`
+
+	// Policy is consulted before the destructive steps of deploys to each
+	// space.
+	Policy PolicyHooks `yaml:"policy"`
+}
+
+// LoadConfig reads the config file named by AUTOPILOT_CONFIG, or
+// autopilot.yml if it exists. Without either the config is empty.
+func LoadConfig(getenv func(string) string) (Config, error) {
+	path := getenv(configVar)
+	if path == "" {
+		path = defaultConfigPath
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return Config{}, nil
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	err = yaml.UnmarshalStrict(contents, &config)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, config.Venerable.Check()
+}
@@ -0,0 +1,87 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("LoadConfig", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "config")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	getenv := func(path string) func(string) string {
+		return func(name string) string {
+			if name == "AUTOPILOT_CONFIG" {
+				return path
+			}
+			return ""
+		}
+	}
+
+	It("reads the file named by AUTOPILOT_CONFIG", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte(`deploy_window: "Mon-Fri 09:00-17:00 Europe/Berlin"`), 0644)).To(Succeed())
+
+		config, err := LoadConfig(getenv(path))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.DeployWindow).To(Equal("Mon-Fri 09:00-17:00 Europe/Berlin"))
+	})
+
+	It("reads the redact patterns", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte("redact: [PASSWORD, ^PRIVATE_]"), 0644)).To(Succeed())
+
+		config, err := LoadConfig(getenv(path))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Redact).To(Equal([]string{"PASSWORD", "^PRIVATE_"}))
+	})
+
+	It("reads the venerable disposition policy", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte("venerable:\n  default: delete\n  spaces:\n    prod: stop\n  apps:\n    orders: unmap\n"), 0644)).To(Succeed())
+
+		config, err := LoadConfig(getenv(path))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Venerable).To(Equal(VenerablePolicy{
+			Default: "delete",
+			Spaces:  map[string]string{"prod": "stop"},
+			Apps:    map[string]string{"orders": "unmap"},
+		}))
+	})
+
+	It("rejects dispositions there are not", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte("venerable:\n  spaces:\n    prod: archive\n"), 0644)).To(Succeed())
+
+		_, err := LoadConfig(getenv(path))
+		Expect(err).To(MatchError(`venerable.spaces.prod should be delete, stop, unmap or keep, not "archive"`))
+	})
+
+	It("rejects unknown settings", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte(`deploy_windw: "Mon-Fri 09:00-17:00"`), 0644)).To(Succeed())
+
+		_, err := LoadConfig(getenv(path))
+		Expect(err).To(MatchError(ContainSubstring("deploy_windw")))
+	})
+
+	It("fails when the named file is missing", func() {
+		_, err := LoadConfig(getenv(filepath.Join(dir, "missing.yml")))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,360 @@
+package autopilot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+var (
+	dropletCopyAttempts = 60
+	dropletCopyInterval = 5 * time.Second
+)
+
+// CopyOptions control where zero-downtime-copy puts the copy of an app.
+type CopyOptions struct {
+	// ToSpace is the space of the current org the copy is made in.
+	ToSpace string
+	// Name is the copy's name, the app's own by default.
+	Name string
+	// Instances is how many instances the copy runs.
+	Instances int
+	// NoStart leaves the copy stopped.
+	NoStart bool
+}
+
+// ErrNoCopySpace is returned when zero-downtime-copy is not told where to
+// copy the app to.
+var ErrNoCopySpace = errors.New("give the space to copy the app to with --to-space")
+
+func ParseCopyArgs(args []string) (CopyOptions, error) {
+	flags := flag.NewFlagSet("zero-downtime-copy", flag.ContinueOnError)
+	toSpace := flags.String("to-space", "", "the space of the current org to copy the app to")
+	name := flags.String("name", "", "name the copy this instead of the app's name")
+	instances := flags.Int("i", 1, "how many instances the copy runs")
+	noStart := flags.Bool("no-start", false, "leave the copy stopped")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return CopyOptions{}, err
+	}
+
+	if *toSpace == "" {
+		return CopyOptions{}, ErrNoCopySpace
+	}
+
+	if *instances < 1 {
+		return CopyOptions{}, fmt.Errorf("invalid number of instances %d", *instances)
+	}
+
+	options := CopyOptions{
+		ToSpace:   *toSpace,
+		Name:      *name,
+		Instances: *instances,
+		NoStart:   *noStart,
+	}
+	if options.Name == "" {
+		options.Name = args[1]
+	}
+
+	return options, nil
+}
+
+// findSpace looks up the guid of a space of the current org.
+func (repo *ApplicationRepo) findSpace(spaceName string) (string, error) {
+	api, err := repo.client()
+	if err != nil {
+		return "", err
+	}
+
+	org, err := repo.conn.GetCurrentOrg()
+	if err != nil {
+		return "", err
+	}
+
+	spaceGuid, found, err := api.FindSpace(org.Guid, spaceName)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("Space %s not found in org %s", spaceName, org.Name)
+	}
+
+	return spaceGuid, nil
+}
+
+// CheckCopyTarget makes sure the app can be copied into the space: the
+// space exists, the copy's name is free there, and every service the app
+// is bound to has an instance of the same name there to bind the copy to.
+func (repo *ApplicationRepo) CheckCopyTarget(appName, spaceName, copyName string) error {
+	spaceGuid, err := repo.findSpace(spaceName)
+	if err != nil {
+		return err
+	}
+
+	_, taken, err := repo.api.FindApp(spaceGuid, copyName)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("App %s already exists in space %s", copyName, spaceName)
+	}
+
+	model, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, service := range model.Services {
+		_, found, err := repo.api.FindAnyServiceInstance(spaceGuid, service.Name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			missing = append(missing, service.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is bound to services space %s does not have: %s. Create them there, and copy again.",
+			appName, spaceName, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// CopyApplicationToSpace creates a stopped copy of the app in the space,
+// with the same configuration and environment, running the given number of
+// instances. It returns the copy's guid.
+func (repo *ApplicationRepo) CopyApplicationToSpace(appName, spaceName, copyName string, instances int) (string, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("App %s not found", appName)
+	}
+
+	spaceGuid, err := repo.findSpace(spaceName)
+	if err != nil {
+		return "", err
+	}
+
+	entity, err := repo.api.GetAppEntity(app.Metadata.Guid)
+	if err != nil {
+		return "", err
+	}
+
+	copied := map[string]interface{}{
+		"name":       copyName,
+		"space_guid": spaceGuid,
+		"state":      "STOPPED",
+	}
+	for _, setting := range clonedAppSettings {
+		if value, ok := entity[setting]; ok && value != nil {
+			copied[setting] = value
+		}
+	}
+	copied["instances"] = instances
+
+	fmt.Printf("Creating app %s in space %s as a copy of %s\n", copyName, spaceName, appName)
+	created, err := repo.api.CreateApp(copied)
+	if err != nil {
+		return "", err
+	}
+
+	return created.Metadata.Guid, nil
+}
+
+// BindServicesToCopy binds the copy to the instances of its space with the
+// names of the services the app is bound to.
+func (repo *ApplicationRepo) BindServicesToCopy(appName, copyGuid string) error {
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	entity, err := api.GetAppEntity(copyGuid)
+	if err != nil {
+		return err
+	}
+	spaceGuid, _ := entity["space_guid"].(string)
+
+	model, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range model.Services {
+		instance, found, err := api.FindAnyServiceInstance(spaceGuid, service.Name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("Service %s not found in the copy's space", service.Name)
+		}
+
+		fmt.Printf("Binding service %s to the copy\n", service.Name)
+		err = api.BindService(copyGuid, instance.Metadata.Guid)
+		if err != nil {
+			return fmt.Errorf("Could not bind service %s to the copy: %s", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CopyDroplet gives the copy the droplet the app runs, so it runs the same
+// bits without staging them again.
+func (repo *ApplicationRepo) CopyDroplet(appName, copyGuid string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	current, err := repo.api.CurrentDroplet(app.Metadata.Guid)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Copying the droplet of %s\n", appName)
+	droplet, err := repo.api.CopyDroplet(current.Guid, copyGuid)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; droplet.State != "STAGED"; i++ {
+		if droplet.State == "FAILED" || droplet.State == "EXPIRED" {
+			return fmt.Errorf("Could not copy the droplet of %s, it is %s", appName, droplet.State)
+		}
+		if i >= dropletCopyAttempts {
+			return fmt.Errorf("The droplet of %s was not copied in time, it is still %s", appName, droplet.State)
+		}
+
+		time.Sleep(dropletCopyInterval)
+		droplet, err = repo.api.GetDroplet(droplet.Guid)
+		if err != nil {
+			return err
+		}
+	}
+
+	return repo.api.SetCurrentDroplet(copyGuid, droplet.Guid)
+}
+
+// StartAppByGuid starts the app with the guid, which may be in another
+// space, and waits until its instances are running.
+func (repo *ApplicationRepo) StartAppByGuid(appGuid string) error {
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	err = api.StartApp(appGuid)
+	if err != nil {
+		return err
+	}
+
+	return repo.waitUntilRunning("the copy", appGuid)
+}
+
+// DeleteAppByGuid deletes the app with the guid, which may be in another
+// space.
+func (repo *ApplicationRepo) DeleteAppByGuid(appGuid string) error {
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	return api.DeleteApp(appGuid)
+}
+
+// CopyActions copy the app into another space, with the same bits,
+// configuration and services, running fewer instances, as a production-like
+// environment to verify changes in. The copy has no routes.
+func (planner *DeploymentPlanner) CopyActions(appName string, options CopyOptions) []rewind.Action {
+	appRepo := planner.Repo
+
+	// the guid of the copy, once it is created
+	var copyGuid string
+
+	deleteCopy := func() error {
+		if copyGuid == "" {
+			return nil
+		}
+
+		planner.Logger.Printf("Deleting the copy %s from space %s.\n", options.Name, options.ToSpace)
+		return appRepo.DeleteAppByGuid(copyGuid)
+	}
+
+	return []rewind.Action{
+		// make sure there is somewhere to put the copy
+		{
+			Name: "check target space",
+			Forward: func() error {
+				return appRepo.CheckCopyTarget(appName, options.ToSpace, options.Name)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Check space %s exists, has no app named %s, and has the services %s is bound to.", options.ToSpace, options.Name, appName),
+			},
+		},
+		// the app, without its bits
+		{
+			Name: "create copy",
+			Forward: func() error {
+				var err error
+				copyGuid, err = appRepo.CopyApplicationToSpace(appName, options.ToSpace, options.Name, options.Instances)
+				return err
+			},
+			ReversePrevious: deleteCopy,
+			Undo:            deleteCopy,
+			Description: rewind.Description{
+				Forward:         fmt.Sprintf("Create %s in space %s, stopped, with the configuration of %s and %d instances.", options.Name, options.ToSpace, appName, options.Instances),
+				ReversePrevious: "Delete the copy, if it was created.",
+				Undo:            "Delete the copy.",
+			},
+		},
+		// its services, by name
+		{
+			Name: "bind services",
+			Forward: func() error {
+				return appRepo.BindServicesToCopy(appName, copyGuid)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Bind the copy to the services of space %s with the names of those %s is bound to.", options.ToSpace, appName),
+			},
+		},
+		// and its bits
+		{
+			Name: "copy droplet",
+			Forward: func() error {
+				return appRepo.CopyDroplet(appName, copyGuid)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Copy the droplet %s runs to the copy, so it runs the same bits without staging.", appName),
+			},
+		},
+		// start the copy
+		{
+			Name: "start copy",
+			Forward: func() error {
+				if options.NoStart {
+					return nil
+				}
+
+				return appRepo.StartAppByGuid(copyGuid)
+			},
+			Description: rewind.Description{
+				Forward: "Start the copy and wait for its instances to be running.",
+				When:    describeIf(options.NoStart, "not with --no-start, which was given, so it does nothing"),
+			},
+		},
+	}
+}
@@ -0,0 +1,129 @@
+package autopilot_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("zero-downtime-copy", func() {
+	It("parses its flags", func() {
+		options, err := ParseCopyArgs([]string{"zero-downtime-copy", "app", "--to-space", "staging"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(CopyOptions{ToSpace: "staging", Name: "app", Instances: 1}))
+
+		options, err = ParseCopyArgs([]string{"zero-downtime-copy", "app", "--to-space", "staging", "--name", "app-verify", "-i", "2", "--no-start"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(CopyOptions{ToSpace: "staging", Name: "app-verify", Instances: 2, NoStart: true}))
+	})
+
+	It("needs a space to copy to", func() {
+		_, err := ParseCopyArgs([]string{"zero-downtime-copy", "app"})
+		Expect(err).To(MatchError(ErrNoCopySpace))
+
+		_, err = ParseCopyArgs([]string{"zero-downtime-copy", "app", "--to-space", "staging", "-i", "0"})
+		Expect(err).To(MatchError("invalid number of instances 0"))
+	})
+
+	Describe("CopyActions", func() {
+		var (
+			repo    *recordingRepo
+			planner *DeploymentPlanner
+		)
+
+		BeforeEach(func() {
+			repo = newRecordingRepo()
+			planner = &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: &fakeClock{}, Logger: discardLogger{}}
+		})
+
+		execute := func(actions []rewind.Action) error {
+			return rewind.Actions{Actions: actions}.Execute()
+		}
+
+		It("creates the copy, binds its services and gives it the app's droplet before starting it", func() {
+			Expect(execute(planner.CopyActions("app", CopyOptions{ToSpace: "staging", Name: "app", Instances: 1}))).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"CheckCopyTarget app staging app",
+				"CopyApplicationToSpace app staging app 1",
+				"BindServicesToCopy app copy-guid",
+				"CopyDroplet app copy-guid",
+				"StartAppByGuid copy-guid",
+			}))
+		})
+
+		It("deletes the copy when it cannot be completed", func() {
+			repo.failures["CopyDroplet app copy-guid"] = errors.New("droplet expired")
+
+			err := execute(planner.CopyActions("app", CopyOptions{ToSpace: "staging", Name: "app", Instances: 1}))
+			Expect(err).To(MatchError("droplet expired"))
+			Expect(repo.calls[len(repo.calls)-1]).To(Equal("DeleteAppByGuid copy-guid"))
+		})
+
+		It("changes nothing when the space cannot take the copy", func() {
+			repo.failures["CheckCopyTarget app staging app"] = errors.New("Space staging not found in org org")
+
+			err := execute(planner.CopyActions("app", CopyOptions{ToSpace: "staging", Name: "app", Instances: 1}))
+			Expect(err).To(MatchError("Space staging not found in org org"))
+			Expect(repo.calls).To(Equal([]string{"CheckCopyTarget app staging app"}))
+		})
+	})
+
+	Describe("CheckCopyTarget", func() {
+		var (
+			api     *ghttp.Server
+			cliConn *pluginfakes.FakeCliConnection
+			repo    *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			api = ghttp.NewServer()
+
+			cliConn = &pluginfakes.FakeCliConnection{}
+			cliConn.ApiEndpointReturns(api.URL(), nil)
+			cliConn.AccessTokenReturns("bearer some-token", nil)
+			cliConn.GetCurrentOrgReturns(plugin_models.Organization{
+				OrganizationFields: plugin_models.OrganizationFields{Guid: "org-guid", Name: "org"},
+			}, nil)
+			cliConn.GetAppReturns(plugin_models.GetAppModel{
+				Services: []plugin_models.GetApp_ServiceSummary{{Name: "db"}, {Name: "cache"}},
+			}, nil)
+
+			api.RouteToHandler("GET", "/v2/organizations/org-guid/spaces", ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"staging-guid"}}]}`))
+			api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		AfterEach(func() {
+			api.Close()
+		})
+
+		It("names the services the space is missing", func() {
+			api.RouteToHandler("GET", "/v2/spaces/staging-guid/service_instances", func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Query().Get("q") == "name:db" {
+					w.Write([]byte(`{"resources":[{"metadata":{"guid":"db-guid"},"entity":{"name":"db"}}]}`))
+					return
+				}
+				w.Write([]byte(`{"resources":[]}`))
+			})
+
+			err := repo.CheckCopyTarget("app", "staging", "app")
+			Expect(err).To(MatchError("app is bound to services space staging does not have: cache. Create them there, and copy again."))
+		})
+
+		It("fails for a space the org does not have", func() {
+			api.RouteToHandler("GET", "/v2/organizations/org-guid/spaces", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			Expect(repo.CheckCopyTarget("app", "stagin", "app")).To(MatchError("Space stagin not found in org org"))
+		})
+	})
+})
@@ -0,0 +1,131 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// crashWatchInterval is how often the app's events are read again.
+const crashWatchInterval = 10 * time.Second
+
+// crashEventTypes are the audit events the Cloud Controller records when an
+// instance crashes, by the v2 and v3 APIs.
+var crashEventTypes = []string{"app.crash", "audit.app.process.crash"}
+
+// CrashWatch fails a deploy whose new version crashes too often while it
+// settles, though its instances may report running in between the crashes.
+type CrashWatch struct {
+	// Window is how long after the push the new version is watched.
+	Window time.Duration
+	// MaxCrashes is how many crashes are tolerated within the window.
+	MaxCrashes int
+}
+
+// ParseCrashWatch reads --stabilization-window and --max-crashes.
+func ParseCrashWatch(window time.Duration, maxCrashes int) (CrashWatch, error) {
+	if window < 0 {
+		return CrashWatch{}, fmt.Errorf("--stabilization-window %s should not be negative", window)
+	}
+	if maxCrashes < 0 {
+		return CrashWatch{}, fmt.Errorf("--max-crashes %d should not be negative", maxCrashes)
+	}
+	if window == 0 && maxCrashes > 0 {
+		return CrashWatch{}, errors.New("--max-crashes needs --stabilization-window")
+	}
+
+	return CrashWatch{Window: window, MaxCrashes: maxCrashes}, nil
+}
+
+// Enabled is true when a stabilization window was given.
+func (watch CrashWatch) Enabled() bool {
+	return watch.Window > 0
+}
+
+// CrashCount counts the crashes of the app's instances since the given time,
+// from its audit events.
+func (repo *ApplicationRepo) CrashCount(appName string, since time.Time) (int, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("App %s not found", appName)
+	}
+
+	events, err := repo.api.Events(app.Metadata.Guid, since)
+	if err != nil {
+		return 0, err
+	}
+
+	crashes := 0
+	for _, event := range events {
+		if containsString(crashEventTypes, event.Entity.Type) {
+			crashes++
+		}
+	}
+
+	return crashes, nil
+}
+
+// watchForCrashes counts the app's crashes since it was pushed until the
+// window has passed, and fails as soon as there are more than allowed.
+func (planner *DeploymentPlanner) watchForCrashes(appName string, watch CrashWatch, since time.Time) error {
+	planner.Logger.Printf("Watching %s for crashes until %s after it was pushed\n", appName, watch.Window)
+	deadline := since.Add(watch.Window)
+	for {
+		crashes, err := planner.Repo.CrashCount(appName, since)
+		if err != nil {
+			return err
+		}
+
+		if crashes > watch.MaxCrashes {
+			err = fmt.Errorf("%s crashed %d times since it was pushed, more than the %d --max-crashes allows", appName, crashes, watch.MaxCrashes)
+
+			// what the instances are doing now may say why
+			instances, instancesErr := planner.Repo.Instances(appName)
+			if instancesErr == nil && len(instances) > 0 {
+				err = fmt.Errorf("%s. Its instances are %s", err, describeInstances(instances))
+			}
+			return err
+		}
+
+		if !planner.Clock.Now().Before(deadline) {
+			planner.Logger.Printf("%s crashed %d times within %s.\n", appName, crashes, watch.Window)
+			return nil
+		}
+		err = planner.sleep(crashWatchInterval)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// crashWatchAction holds the cutover until the new version has run for the
+// stabilization window without crashing too often. pushed is when the push
+// began, so crashes while it started count too.
+func (planner *DeploymentPlanner) crashWatchAction(appName string, watch CrashWatch, pushed *time.Time) rewind.Action {
+	return rewind.Action{
+		Name: "watch for crashes",
+		Forward: func() error {
+			if !watch.Enabled() {
+				return nil
+			}
+
+			// a resumed deploy may not have pushed it itself
+			since := *pushed
+			if since.IsZero() {
+				since = planner.Clock.Now()
+			}
+
+			return planner.watchForCrashes(appName, watch, since)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Watch the new version of %s for crashes%s, and fail the deploy if it crashes more than %d times.", appName,
+				describeIf(watch.Enabled(), fmt.Sprintf(" until %s after it was pushed", watch.Window)), watch.MaxCrashes),
+			When: onlyWith("--stabilization-window", watch.Enabled()),
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package autopilot_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Watching for crashes", func() {
+	Describe("ParseCrashWatch", func() {
+		It("tolerates no crashes unless told otherwise", func() {
+			Expect(ParseCrashWatch(2*time.Minute, 0)).To(Equal(CrashWatch{Window: 2 * time.Minute}))
+			Expect(ParseCrashWatch(2*time.Minute, 3)).To(Equal(CrashWatch{Window: 2 * time.Minute, MaxCrashes: 3}))
+			Expect(ParseCrashWatch(0, 0)).To(Equal(CrashWatch{}))
+		})
+
+		It("rejects a crash limit without a window, or negative values", func() {
+			_, err := ParseCrashWatch(0, 2)
+			Expect(err).To(MatchError("--max-crashes needs --stabilization-window"))
+
+			_, err = ParseCrashWatch(-time.Minute, 0)
+			Expect(err).To(MatchError("--stabilization-window -1m0s should not be negative"))
+
+			_, err = ParseCrashWatch(time.Minute, -1)
+			Expect(err).To(MatchError("--max-crashes -1 should not be negative"))
+		})
+
+		It("is read from the command line", func() {
+			_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--stabilization-window", "2m", "--max-crashes", "1"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.CrashWatch).To(Equal(CrashWatch{Window: 2 * time.Minute, MaxCrashes: 1}))
+		})
+	})
+
+	It("counts the crash events of the app", func() {
+		api := ghttp.NewServer()
+		defer api.Close()
+
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+		}, nil)
+
+		api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app"}}]}`))
+		api.RouteToHandler("GET", "/v2/events", func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.URL.Query()["q"]).To(Equal([]string{"actee:app-guid", "timestamp>=2024-06-07T12:12:00Z"}))
+			w.Write([]byte(`{"resources":[
+				{"entity":{"type":"audit.app.start"}},
+				{"entity":{"type":"app.crash"}},
+				{"entity":{"type":"audit.app.process.crash"}}
+			]}`))
+		})
+
+		crashes, err := NewApplicationRepo(cliConn).CrashCount("app", time.Date(2024, 6, 7, 12, 12, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(crashes).To(Equal(2))
+	})
+
+	Describe("before the cutover", func() {
+		var (
+			repo         *recordingRepo
+			clock        *fakeClock
+			planner      *DeploymentPlanner
+			options      AutopilotOptions
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			repo.routes["app"] = []string{"app.example.com"}
+			clock = &fakeClock{}
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  clock,
+				Logger: discardLogger{},
+			}
+			options = AutopilotOptions{CrashWatch: CrashWatch{Window: 30 * time.Second, MaxCrashes: 1}}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("retires the old version once the new one has settled", func() {
+			repo.crashes = []int{0, 1}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clock.slept).To(Equal([]time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second}))
+			Expect(indexOf(repo.calls, "CrashCount app")).To(BeNumerically("<", indexOf(repo.calls, "DeleteApplication app-venerable")))
+		})
+
+		It("rolls the deploy back when the new version crashes too often", func() {
+			repo.crashes = []int{1, 2}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError("app crashed 2 times since it was pushed, more than the 1 --max-crashes allows"))
+			Expect(clock.slept).To(HaveLen(1))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+		})
+
+		It("stops watching and rolls back as soon as the deploy is interrupted", func() {
+			repo.crashes = []int{0}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			planner.Context = ctx
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(Equal(context.Canceled))
+			Expect(clock.slept).To(BeEmpty())
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+	})
+})
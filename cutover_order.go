@@ -0,0 +1,63 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/routes"
+)
+
+// CutoverOrder moves the production routes to the new version a domain at a
+// time, as internal domains before public ones, so a risky release meets
+// the riskiest traffic last.
+type CutoverOrder struct {
+	// Domains are moved in this order. Routes on other domains are moved
+	// last.
+	Domains []string
+	// Pause is how long to wait between domains.
+	Pause time.Duration
+}
+
+// ParseCutoverOrder reads --cutover-order and --cutover-pause.
+func ParseCutoverOrder(domains []string, pause time.Duration) (CutoverOrder, error) {
+	if pause < 0 {
+		return CutoverOrder{}, fmt.Errorf("--cutover-pause %s should not be negative", pause)
+	}
+	if pause > 0 && len(domains) == 0 {
+		return CutoverOrder{}, errors.New("--cutover-pause needs --cutover-order")
+	}
+
+	return CutoverOrder{Domains: domains, Pause: pause}, nil
+}
+
+// Enabled is true when a domain order was given.
+func (order CutoverOrder) Enabled() bool {
+	return len(order.Domains) > 0
+}
+
+// mapInOrder maps the production routes to the new version a domain at a
+// time, pausing between them. Without an order they are mapped at once.
+func (planner *DeploymentPlanner) mapInOrder(appName string, urls []string, options AutopilotOptions) error {
+	groups := routes.InDomainOrder(urls, options.CutoverOrder.Domains)
+	for i, group := range groups {
+		if i > 0 && options.CutoverOrder.Pause > 0 {
+			planner.Logger.Printf("Waiting %s before moving the next domain.\n", options.CutoverOrder.Pause)
+			err := planner.sleep(options.CutoverOrder.Pause)
+			if err != nil {
+				return err
+			}
+		}
+
+		if options.CutoverOrder.Enabled() {
+			planner.Logger.Printf("Mapping %s to the new version of %s\n", strings.Join(group, ", "), appName)
+		}
+		err := tolerateRouteErrors(planner.Repo.MapRouteURLs(appName, group), options.ContinueOnRouteError)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
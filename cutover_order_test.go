@@ -0,0 +1,114 @@
+package autopilot_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Cutover order", func() {
+	Describe("ParseCutoverOrder", func() {
+		It("pauses between domains only when told to", func() {
+			Expect(ParseCutoverOrder([]string{"internal.example.com", "example.com"}, 0)).To(Equal(CutoverOrder{Domains: []string{"internal.example.com", "example.com"}}))
+			Expect(ParseCutoverOrder([]string{"example.com"}, time.Minute)).To(Equal(CutoverOrder{Domains: []string{"example.com"}, Pause: time.Minute}))
+		})
+
+		It("rejects a pause without an order, or a negative one", func() {
+			_, err := ParseCutoverOrder(nil, time.Minute)
+			Expect(err).To(MatchError("--cutover-pause needs --cutover-order"))
+
+			_, err = ParseCutoverOrder([]string{"example.com"}, -time.Minute)
+			Expect(err).To(MatchError("--cutover-pause -1m0s should not be negative"))
+		})
+
+		It("is read from the command line", func() {
+			_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--cutover-order", "Internal.example.com,example.com", "--cutover-pause", "30s"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.CutoverOrder).To(Equal(CutoverOrder{Domains: []string{"internal.example.com", "example.com"}, Pause: 30 * time.Second}))
+		})
+
+		It("cannot be given with --premap-routes", func() {
+			_, _, _, _, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--cutover-order", "example.com", "--premap-routes"})
+			Expect(err).To(MatchError(ContainSubstring("--premap-routes maps every production route")))
+		})
+	})
+
+	Describe("during a push", func() {
+		var (
+			repo         *recordingRepo
+			clock        *fakeClock
+			planner      *DeploymentPlanner
+			manifestPath string
+			options      AutopilotOptions
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n  routes:\n  - route: app.example.com\n  - route: app.internal.example.com\n  - route: app.example.org\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			repo.routes["app"] = []string{"app.example.com", "app.internal.example.com", "app.example.org"}
+			clock = &fakeClock{}
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  clock,
+				Logger: discardLogger{},
+			}
+			options = AutopilotOptions{CutoverOrder: CutoverOrder{Domains: []string{"internal.example.com", "example.com"}, Pause: time.Minute}}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("pushes without routes, then maps them a domain at a time, pausing between domains", func() {
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-route]"))
+
+			internal := indexOf(repo.calls, "MapRouteURLs app [app.internal.example.com]")
+			public := indexOf(repo.calls, "MapRouteURLs app [app.example.com]")
+			rest := indexOf(repo.calls, "MapRouteURLs app [app.example.org]")
+			Expect(internal).To(BeNumerically(">=", 0))
+			Expect(public).To(BeNumerically(">", internal))
+			Expect(rest).To(BeNumerically(">", public))
+			Expect(clock.slept).To(Equal([]time.Duration{time.Minute, time.Minute}))
+		})
+
+		It("rolls back without moving the later domains when a domain cannot be mapped", func() {
+			repo.failures["MapRouteURLs app [app.example.com]"] = errors.New("route taken")
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError("route taken"))
+			Expect(repo.calls).ToNot(ContainElement("MapRouteURLs app [app.example.org]"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+
+		It("moves no more domains once the deploy is interrupted during a pause", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			planner.Context = ctx
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(Equal(context.Canceled))
+			Expect(clock.slept).To(BeEmpty())
+			Expect(repo.calls).To(ContainElement("MapRouteURLs app [app.internal.example.com]"))
+			Expect(repo.calls).ToNot(ContainElement("MapRouteURLs app [app.example.com]"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+	})
+})
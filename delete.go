@@ -0,0 +1,130 @@
+package autopilot
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// DeleteOptions control how an app is retired by zero-downtime-delete.
+type DeleteOptions struct {
+	// Drain is how long the app keeps running once its routes are unmapped.
+	Drain time.Duration
+	// DeleteRoutes deletes the app's routes once no other app uses them.
+	DeleteRoutes bool
+}
+
+func ParseDeleteArgs(args []string) (DeleteOptions, error) {
+	flags := flag.NewFlagSet("zero-downtime-delete", flag.ContinueOnError)
+	drain := flags.Duration("drain", 0, "wait this long (e.g. 5m) after unmapping the app's routes before stopping it")
+	deleteRoutes := flags.Bool("delete-routes", false, "delete the app's routes once no other app is mapped to them")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return DeleteOptions{}, err
+	}
+
+	return DeleteOptions{
+		Drain:        *drain,
+		DeleteRoutes: *deleteRoutes,
+	}, nil
+}
+
+// DeleteActions retire an app gracefully: its routes are unmapped first, so
+// no new requests reach it, then it is given time to finish the ones in
+// flight before it is stopped and deleted.
+func (planner *DeploymentPlanner) DeleteActions(appName string, options DeleteOptions) []rewind.Action {
+	appRepo := planner.Repo
+
+	// the app's routes, remembered so they can be mapped back
+	var routes []string
+
+	return []rewind.Action{
+		// remember the routes
+		{
+			Name: "remember routes",
+			Forward: func() error {
+				var err error
+				routes, err = appRepo.AppRoutes(appName)
+				return err
+			},
+		},
+		// unmap the routes
+		{
+			Name: "unmap routes",
+			Forward: func() error {
+				if len(routes) == 0 {
+					return nil
+				}
+
+				planner.Logger.Printf("Unmapping %s from %s.\n", strings.Join(routes, ", "), appName)
+				return appRepo.UnmapRouteURLs(appName, routes)
+			},
+			ReversePrevious: func() error {
+				if len(routes) == 0 {
+					return nil
+				}
+
+				return appRepo.MapRouteURLs(appName, routes)
+			},
+			Undo: func() error {
+				if len(routes) == 0 {
+					return nil
+				}
+
+				return appRepo.MapRouteURLs(appName, routes)
+			},
+		},
+		// let the app finish its in-flight requests
+		{
+			Name: "drain",
+			Forward: func() error {
+				if options.Drain == 0 {
+					return nil
+				}
+
+				planner.Logger.Printf("Waiting %s for %s to drain.\n", options.Drain, appName)
+				return planner.sleep(options.Drain)
+			},
+		},
+		// stop
+		{
+			Name: "stop",
+			Forward: func() error {
+				return appRepo.StopApplication(appName)
+			},
+			Undo: func() error {
+				return appRepo.StartApplication(appName)
+			},
+		},
+		// delete, which removes its service bindings too
+		{
+			Name: "delete",
+			Forward: func() error {
+				return appRepo.DeleteApplication(appName)
+			},
+		},
+		// delete the routes no other app uses
+		{
+			Name: "delete orphaned routes",
+			Forward: func() error {
+				if !options.DeleteRoutes || len(routes) == 0 {
+					return nil
+				}
+
+				deleted, err := appRepo.DeleteOrphanedRoutes(routes)
+				if len(deleted) > 0 {
+					planner.Logger.Printf("Deleted routes %s.\n", strings.Join(deleted, ", "))
+				}
+
+				// the app is gone, so there is nothing to roll back to
+				if err != nil {
+					planner.Logger.Printf("Warning: could not delete every orphaned route: %s\n", err)
+				}
+				return nil
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package autopilot_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("zero-downtime-delete", func() {
+	It("parses its flags", func() {
+		options, err := ParseDeleteArgs([]string{"zero-downtime-delete", "app", "--drain", "5m", "--delete-routes"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(DeleteOptions{Drain: 5 * time.Minute, DeleteRoutes: true}))
+	})
+
+	Describe("DeleteActions", func() {
+		var (
+			repo    *recordingRepo
+			clock   *fakeClock
+			planner *DeploymentPlanner
+		)
+
+		BeforeEach(func() {
+			repo = newRecordingRepo()
+			repo.routes["app"] = []string{"app.example.com"}
+			clock = &fakeClock{}
+			planner = &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: clock, Logger: discardLogger{}}
+		})
+
+		execute := func(actions []rewind.Action) error {
+			return rewind.Actions{Actions: actions}.Execute()
+		}
+
+		It("unmaps the routes and drains the app before deleting it", func() {
+			Expect(execute(planner.DeleteActions("app", DeleteOptions{Drain: 5 * time.Minute, DeleteRoutes: true}))).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"AppRoutes app",
+				"UnmapRouteURLs app [app.example.com]",
+				"StopApplication app",
+				"DeleteApplication app",
+				"DeleteOrphanedRoutes [app.example.com]",
+			}))
+			Expect(clock.slept).To(Equal([]time.Duration{5 * time.Minute}))
+		})
+
+		It("keeps the routes unless asked to delete them", func() {
+			Expect(execute(planner.DeleteActions("app", DeleteOptions{}))).To(Succeed())
+			Expect(repo.calls).ToNot(ContainElement(ContainSubstring("DeleteOrphanedRoutes")))
+		})
+
+		It("puts the app back when it cannot be deleted", func() {
+			repo.failures["DeleteApplication app"] = errors.New("api down")
+
+			Expect(execute(planner.DeleteActions("app", DeleteOptions{}))).To(MatchError("api down"))
+			Expect(repo.calls[len(repo.calls)-2:]).To(Equal([]string{
+				"StartApplication app",
+				"MapRouteURLs app [app.example.com]",
+			}))
+		})
+	})
+})
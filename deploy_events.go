@@ -0,0 +1,171 @@
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// The annotations --audit-event sets on the app for each point of the deploy.
+// Their values are JSON shaped like the Cloud Controller's v3 audit events,
+// so tools that read those can read these too.
+const (
+	deployStartAnnotation    = "autopilot/deploy-start"
+	deployCutoverAnnotation  = "autopilot/deploy-cutover"
+	deployCompleteAnnotation = "autopilot/deploy-complete"
+)
+
+// cutoverSteps are the steps after which the version being deployed serves
+// the app's routes. A push over a live app passes "push" before "check test
+// route", so the last one to complete is the cutover.
+var cutoverSteps = map[string]bool{
+	"push":                            true,
+	"check test route":                true,
+	"move routes to previous version": true,
+	"map routes to clone":             true,
+	"deploy revision":                 true,
+}
+
+// DeployEvent is one point of a deploy, in the shape of a v3 audit event.
+type DeployEvent struct {
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"created_at"`
+	Actor     EventActor      `json:"actor"`
+	Target    EventTarget     `json:"target"`
+	Data      DeployEventData `json:"data"`
+}
+
+// EventActor is who ran the deploy.
+type EventActor struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// EventTarget is the app deployed.
+type EventTarget struct {
+	Type string `json:"type"`
+	Guid string `json:"guid"`
+	Name string `json:"name"`
+}
+
+// DeployEventData ties the events of one deploy together.
+type DeployEventData struct {
+	Deployment string `json:"deployment"`
+	Command    string `json:"command"`
+	// Outcome is "succeeded" or "failed", on the complete event only.
+	Outcome string `json:"outcome,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DeployTimeline notes when the deploy started and when it cut over to the
+// new version, to record on the app once it is done.
+type DeployTimeline struct {
+	// Deployment identifies the deploy in each of its events, as an
+	// annotation left from an earlier deploy is not cleared.
+	Deployment string
+	Command    string
+	App        string
+	StartedAt  time.Time
+	CutoverAt  time.Time
+
+	mutex sync.Mutex
+}
+
+// NewDeployTimeline starts the timeline of a deploy of the app.
+func NewDeployTimeline(command, appName string) *DeployTimeline {
+	return &DeployTimeline{
+		Deployment: randomID(8),
+		Command:    command,
+		App:        appName,
+		StartedAt:  time.Now().UTC(),
+	}
+}
+
+// ObserveStep is a rewind.Observer.
+func (timeline *DeployTimeline) ObserveStep(name, phase string, start time.Time, err error) {
+	if timeline == nil || phase != rewind.PhaseForward || err != nil || !cutoverSteps[name] {
+		return
+	}
+
+	timeline.mutex.Lock()
+	defer timeline.mutex.Unlock()
+	timeline.CutoverAt = time.Now().UTC()
+}
+
+// Annotations are the deploy's events, as annotations of the app, once it
+// has ended with deployErr. There is no cutover event if it never got that
+// far.
+func (timeline *DeployTimeline) Annotations(actor string, target EventTarget, deployErr error, now time.Time) (map[string]string, error) {
+	timeline.mutex.Lock()
+	defer timeline.mutex.Unlock()
+
+	event := func(kind string, at time.Time) DeployEvent {
+		return DeployEvent{
+			Type:      "autopilot.deploy." + kind,
+			CreatedAt: at.UTC().Format(time.RFC3339),
+			Actor:     EventActor{Type: "user", Name: actor},
+			Target:    target,
+			Data:      DeployEventData{Deployment: timeline.Deployment, Command: timeline.Command},
+		}
+	}
+
+	events := map[string]DeployEvent{deployStartAnnotation: event("start", timeline.StartedAt)}
+	if !timeline.CutoverAt.IsZero() {
+		events[deployCutoverAnnotation] = event("cutover", timeline.CutoverAt)
+	}
+
+	complete := event("complete", now)
+	complete.Data.Outcome = "succeeded"
+	if deployErr != nil {
+		complete.Data.Outcome = "failed"
+		complete.Data.Error = deployErr.Error()
+	}
+	events[deployCompleteAnnotation] = complete
+
+	annotations := map[string]string{}
+	for key, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		annotations[key] = string(value)
+	}
+
+	return annotations, nil
+}
+
+// RecordDeployTimeline sets the deploy's events on the app, whichever version
+// of it is live once the deploy has ended. A failed deploy of a new app
+// leaves no app to record them on.
+func (repo *ApplicationRepo) RecordDeployTimeline(timeline *DeployTimeline, deployErr error) error {
+	app, found, err := repo.findApp(timeline.App)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if deployErr != nil {
+			return nil
+		}
+		return fmt.Errorf("App %s not found", timeline.App)
+	}
+
+	user, err := repo.conn.Username()
+	if err != nil {
+		return err
+	}
+
+	annotations, err := timeline.Annotations(user, EventTarget{Type: "app", Guid: app.Metadata.Guid, Name: timeline.App}, deployErr, time.Now())
+	if err != nil {
+		return err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	return api.UpdateAppAnnotations(app.Metadata.Guid, annotations)
+}
@@ -0,0 +1,134 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Deploy events", func() {
+	target := EventTarget{Type: "app", Guid: "app-guid", Name: "app"}
+
+	decode := func(value string) DeployEvent {
+		event := DeployEvent{}
+		Expect(json.Unmarshal([]byte(value), &event)).To(Succeed())
+		return event
+	}
+
+	It("describes the start, cutover and end of the deploy like audit events", func() {
+		timeline := &DeployTimeline{
+			Deployment: "d1",
+			Command:    "zero-downtime-push",
+			App:        "app",
+			StartedAt:  time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC),
+			CutoverAt:  time.Date(2024, 6, 7, 12, 3, 0, 0, time.UTC),
+		}
+
+		annotations, err := timeline.Annotations("alice", target, nil, time.Date(2024, 6, 7, 12, 5, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).To(HaveLen(3))
+
+		Expect(decode(annotations["autopilot/deploy-start"])).To(Equal(DeployEvent{
+			Type:      "autopilot.deploy.start",
+			CreatedAt: "2024-06-07T12:00:00Z",
+			Actor:     EventActor{Type: "user", Name: "alice"},
+			Target:    target,
+			Data:      DeployEventData{Deployment: "d1", Command: "zero-downtime-push"},
+		}))
+		Expect(decode(annotations["autopilot/deploy-cutover"]).CreatedAt).To(Equal("2024-06-07T12:03:00Z"))
+
+		complete := decode(annotations["autopilot/deploy-complete"])
+		Expect(complete.CreatedAt).To(Equal("2024-06-07T12:05:00Z"))
+		Expect(complete.Data).To(Equal(DeployEventData{Deployment: "d1", Command: "zero-downtime-push", Outcome: "succeeded"}))
+	})
+
+	It("has no cutover when the deploy failed before it", func() {
+		timeline := NewDeployTimeline("zero-downtime-push", "app")
+
+		annotations, err := timeline.Annotations("alice", target, errors.New("push failed"), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).ToNot(HaveKey("autopilot/deploy-cutover"))
+		Expect(decode(annotations["autopilot/deploy-complete"]).Data.Outcome).To(Equal("failed"))
+		Expect(decode(annotations["autopilot/deploy-complete"]).Data.Error).To(Equal("push failed"))
+	})
+
+	It("takes the cutover from the last step that gave the new version the routes", func() {
+		timeline := NewDeployTimeline("zero-downtime-push", "app")
+
+		timeline.ObserveStep("rename live app", rewind.PhaseForward, time.Now(), nil)
+		Expect(timeline.CutoverAt.IsZero()).To(BeTrue())
+
+		timeline.ObserveStep("check test route", rewind.PhaseForward, time.Now(), errors.New("probe failed"))
+		timeline.ObserveStep("push", rewind.PhaseUndo, time.Now(), nil)
+		Expect(timeline.CutoverAt.IsZero()).To(BeTrue())
+
+		timeline.ObserveStep("check test route", rewind.PhaseForward, time.Now(), nil)
+		Expect(timeline.CutoverAt.IsZero()).To(BeFalse())
+
+		var none *DeployTimeline
+		none.ObserveStep("push", rewind.PhaseForward, time.Now(), nil)
+	})
+
+	Describe("RecordDeployTimeline", func() {
+		var (
+			repo *ApplicationRepo
+			api  *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			api = ghttp.NewServer()
+
+			cliConn := &pluginfakes.FakeCliConnection{}
+			cliConn.ApiEndpointReturns(api.URL(), nil)
+			cliConn.AccessTokenReturns("bearer some-token", nil)
+			cliConn.UsernameReturns("alice", nil)
+			cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+				SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+			}, nil)
+
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		AfterEach(func() {
+			api.Close()
+		})
+
+		It("sets the events on the live app", func() {
+			var body map[string]map[string]map[string]string
+			api.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app"}}]}`),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/v3/apps/app-guid"),
+					func(w http.ResponseWriter, req *http.Request) {
+						Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+					},
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+			)
+
+			Expect(repo.RecordDeployTimeline(NewDeployTimeline("zero-downtime-push", "app"), nil)).To(Succeed())
+
+			start := decode(body["metadata"]["annotations"]["autopilot/deploy-start"])
+			Expect(start.Actor.Name).To(Equal("alice"))
+			Expect(start.Target).To(Equal(target))
+		})
+
+		It("records nothing when a failed deploy left no app", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			Expect(repo.RecordDeployTimeline(NewDeployTimeline("zero-downtime-push", "app"), errors.New("push failed"))).To(Succeed())
+			Expect(api.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,112 @@
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The dispositions of the old version of an app once the new one is live.
+const (
+	// DispositionDelete deletes it, as zero-downtime-push does by default.
+	DispositionDelete = "delete"
+	// DispositionStop stops it and keeps it for a rollback, as with
+	// --keep-existing-app.
+	DispositionStop = "stop"
+	// DispositionUnmap unmaps its routes and leaves it running, as with
+	// --unmap-routes.
+	DispositionUnmap = "unmap"
+	// DispositionKeep leaves it running with its routes, serving beside the
+	// new version until it is retired by hand.
+	DispositionKeep = "keep"
+)
+
+// VenerablePolicy sets the disposition of the old version of apps from the
+// config file, so platform teams can, say, never delete old versions in
+// production straight away without every pipeline passing the flag. The
+// setting for the app wins over the one for its space, which wins over the
+// default.
+type VenerablePolicy struct {
+	Default string            `yaml:"default"`
+	Spaces  map[string]string `yaml:"spaces"`
+	Apps    map[string]string `yaml:"apps"`
+}
+
+// Check makes sure every disposition in the policy is one there is.
+func (policy VenerablePolicy) Check() error {
+	settings := map[string]string{"venerable.default": policy.Default}
+	for space, disposition := range policy.Spaces {
+		settings["venerable.spaces."+space] = disposition
+	}
+	for app, disposition := range policy.Apps {
+		settings["venerable.apps."+app] = disposition
+	}
+
+	names := []string{}
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch settings[name] {
+		case "", DispositionDelete, DispositionStop, DispositionUnmap, DispositionKeep:
+		default:
+			return fmt.Errorf("%s should be delete, stop, unmap or keep, not %q", name, settings[name])
+		}
+	}
+
+	return nil
+}
+
+// For returns the disposition for the app in the space, or "" if the policy
+// does not set one.
+func (policy VenerablePolicy) For(appName, spaceName string) string {
+	if disposition := policy.Apps[appName]; disposition != "" {
+		return disposition
+	}
+	if disposition := policy.Spaces[spaceName]; disposition != "" {
+		return disposition
+	}
+
+	return policy.Default
+}
+
+// dispositionFor returns the config file's disposition for the app in the
+// current space. The space is only looked up if the policy names any.
+func dispositionFor(policy VenerablePolicy, repo *ApplicationRepo, appName string) (string, error) {
+	spaceName := ""
+	if len(policy.Spaces) > 0 {
+		space, err := repo.conn.GetCurrentSpace()
+		if err != nil {
+			return "", err
+		}
+		spaceName = space.Name
+	}
+
+	return policy.For(appName, spaceName), nil
+}
+
+// ApplyDisposition sets the options for the disposition, unless
+// --keep-existing-app or --unmap-routes already chose one.
+func ApplyDisposition(options AutopilotOptions, disposition string) AutopilotOptions {
+	if options.KeepExisting || options.UnmapRoute {
+		return options
+	}
+
+	switch disposition {
+	case DispositionStop:
+		options.KeepExisting = true
+	case DispositionUnmap:
+		options.UnmapRoute = true
+	case DispositionKeep:
+		options.KeepRunning = true
+	}
+
+	return options
+}
+
+// keepsVenerable says whether the old version is still there once the deploy
+// is done, and so may need its routes and service keys.
+func (options AutopilotOptions) keepsVenerable() bool {
+	return options.KeepExisting || options.UnmapRoute || options.KeepRunning
+}
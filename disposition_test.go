@@ -0,0 +1,39 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("VenerablePolicy", func() {
+	policy := VenerablePolicy{
+		Default: DispositionDelete,
+		Spaces:  map[string]string{"prod": DispositionStop},
+		Apps:    map[string]string{"orders": DispositionUnmap},
+	}
+
+	It("prefers the app's setting, then its space's, then the default", func() {
+		Expect(policy.For("orders", "prod")).To(Equal(DispositionUnmap))
+		Expect(policy.For("billing", "prod")).To(Equal(DispositionStop))
+		Expect(policy.For("billing", "dev")).To(Equal(DispositionDelete))
+		Expect(VenerablePolicy{}.For("billing", "dev")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ApplyDisposition", func() {
+	It("sets the options for the disposition", func() {
+		Expect(ApplyDisposition(AutopilotOptions{}, DispositionStop).KeepExisting).To(BeTrue())
+		Expect(ApplyDisposition(AutopilotOptions{}, DispositionUnmap).UnmapRoute).To(BeTrue())
+		Expect(ApplyDisposition(AutopilotOptions{}, DispositionKeep).KeepRunning).To(BeTrue())
+		Expect(ApplyDisposition(AutopilotOptions{}, DispositionDelete)).To(Equal(AutopilotOptions{}))
+		Expect(ApplyDisposition(AutopilotOptions{}, "")).To(Equal(AutopilotOptions{}))
+	})
+
+	It("leaves a disposition given with a flag alone", func() {
+		options := ApplyDisposition(AutopilotOptions{UnmapRoute: true}, DispositionStop)
+		Expect(options.UnmapRoute).To(BeTrue())
+		Expect(options.KeepExisting).To(BeFalse())
+	})
+})
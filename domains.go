@@ -0,0 +1,18 @@
+package autopilot
+
+import "github.com/concourse/autopilot/routes"
+
+// DomainFilter limits which routes a cutover moves, by domain. Routes on
+// other domains are left where they are, so internal routes can stay pinned
+// to one version while public traffic is swapped.
+type DomainFilter = routes.DomainFilter
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,199 @@
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+type AppConfig = repository.AppConfig
+
+// The kinds of configuration compared by --diff.
+var driftCategories = []string{"memory", "instances", "env", "services", "routes"}
+
+// Drift is a difference between the manifest and the live app.
+type Drift struct {
+	Category string
+	Detail   string
+}
+
+func (drift Drift) String() string {
+	return fmt.Sprintf("%s: %s", drift.Category, drift.Detail)
+}
+
+// AppConfig reads the live app's configuration.
+func (repo *ApplicationRepo) AppConfig(appName string) (AppConfig, error) {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return AppConfig{}, err
+	}
+
+	config := AppConfig{
+		Memory:    app.Memory,
+		Instances: app.InstanceCount,
+		Env:       map[string]string{},
+		Services:  []string{},
+	}
+
+	for name, value := range app.EnvironmentVars {
+		config.Env[name] = fmt.Sprint(value)
+	}
+
+	for _, service := range app.Services {
+		config.Services = append(config.Services, service.Name)
+	}
+
+	config.Routes, err = repo.AppRoutes(appName)
+	return config, err
+}
+
+// CompareConfig lists how the live app differs from its manifest entry. Only
+// what the manifest sets is compared. Environment values are not shown, as
+// they are often secrets.
+func CompareConfig(app ManifestApplication, live AppConfig) []Drift {
+	drifts := []Drift{}
+
+	if app.Memory != "" {
+		memory, err := parseMegabytes(app.Memory)
+		if err == nil && memory != live.Memory {
+			drifts = append(drifts, Drift{"memory", fmt.Sprintf("live app has %dM, manifest has %dM", live.Memory, memory)})
+		}
+	}
+
+	if app.Instances != nil && *app.Instances != live.Instances {
+		drifts = append(drifts, Drift{"instances", fmt.Sprintf("live app has %d, manifest has %d", live.Instances, *app.Instances)})
+	}
+
+	manifestEnv := map[string]string{}
+	for name, value := range app.Env {
+		manifestEnv[name] = fmt.Sprint(value)
+	}
+	for _, name := range sortedKeys(manifestEnv) {
+		value, set := live.Env[name]
+		if !set {
+			drifts = append(drifts, Drift{"env", name + " is in the manifest but not set on the live app"})
+		} else if value != manifestEnv[name] {
+			drifts = append(drifts, Drift{"env", name + " has a different value on the live app"})
+		}
+	}
+	for _, name := range sortedKeys(live.Env) {
+		if _, declared := manifestEnv[name]; !declared {
+			drifts = append(drifts, Drift{"env", name + " is set on the live app but not in the manifest"})
+		}
+	}
+
+	for _, service := range MissingRoutes(app.Services, live.Services) {
+		drifts = append(drifts, Drift{"services", service + " is in the manifest but not bound to the live app"})
+	}
+	for _, service := range MissingRoutes(live.Services, app.Services) {
+		drifts = append(drifts, Drift{"services", service + " is bound to the live app but not in the manifest"})
+	}
+
+	if intended, known := app.IntendedRoutes(); known {
+		for _, route := range MissingRoutes(intended, live.Routes) {
+			drifts = append(drifts, Drift{"routes", route + " is in the manifest but not mapped to the live app"})
+		}
+		for _, route := range MissingRoutes(live.Routes, intended) {
+			drifts = append(drifts, Drift{"routes", route + " is mapped to the live app but not in the manifest"})
+		}
+	}
+
+	return drifts
+}
+
+// ParseDriftCategories reads the --fail-on-drift list, where "all" stands
+// for every category.
+func ParseDriftCategories(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	categories := []string{}
+	for _, category := range strings.Split(value, ",") {
+		category = strings.TrimSpace(category)
+		if category == "all" {
+			return driftCategories, nil
+		}
+
+		if !containsString(driftCategories, category) {
+			return nil, fmt.Errorf("unknown drift category %q, use one of %s or all", category, strings.Join(driftCategories, ", "))
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+// ConfigDrift compares the manifest entry for the app with the live app.
+func (planner *DeploymentPlanner) ConfigDrift(appName, manifestPath string) ([]Drift, error) {
+	manifest, err := ParseManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	app, found := manifest.Application(appName)
+	if !found {
+		return nil, fmt.Errorf("The manifest has no entry for %s to compare with", appName)
+	}
+
+	live, err := planner.Repo.AppConfig(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareConfig(app, live), nil
+}
+
+// showDrift prints the drift of a live app from its manifest, then fails if
+// there is drift in any of the failOn categories. New apps have no drift.
+func showDrift(planner *DeploymentPlanner, appName, manifestPath string, failOn []string) error {
+	appExists, err := planner.Repo.DoesAppExist(appName)
+	if err != nil || !appExists {
+		return err
+	}
+
+	drifts, err := planner.ConfigDrift(appName, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if len(drifts) == 0 {
+		fmt.Println("The live app matches the manifest.")
+		return nil
+	}
+
+	fmt.Println("The live app differs from the manifest:")
+	for _, drift := range drifts {
+		fmt.Printf("  %s\n", drift)
+	}
+
+	return CheckDrift(drifts, failOn)
+}
+
+// CheckDrift fails when there is drift in any of the categories.
+func CheckDrift(drifts []Drift, failOn []string) error {
+	failed := []string{}
+	for _, drift := range drifts {
+		if containsString(failOn, drift.Category) && !containsString(failed, drift.Category) {
+			failed = append(failed, drift.Category)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("The live app has drifted from the manifest (%s). Update the manifest, or remove them from --fail-on-drift to deploy anyway.", strings.Join(failed, ", "))
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,94 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Configuration drift", func() {
+	It("reads the live app's configuration", func() {
+		api := ghttp.NewServer()
+		defer api.Close()
+		api.RouteToHandler("GET", "/v2/routes/app-route", ghttp.RespondWith(http.StatusOK, `{"entity":{"host":"app","path":"/api"}}`))
+
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetAppReturns(plugin_models.GetAppModel{
+			Memory:          512,
+			InstanceCount:   2,
+			EnvironmentVars: map[string]interface{}{"DEBUG": true},
+			Services:        []plugin_models.GetApp_ServiceSummary{{Name: "database"}},
+			Routes: []plugin_models.GetApp_RouteSummary{
+				{Guid: "app-route", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+			},
+		}, nil)
+
+		config, err := NewApplicationRepo(cliConn).AppConfig("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(AppConfig{
+			Memory:    512,
+			Instances: 2,
+			Env:       map[string]string{"DEBUG": "true"},
+			Services:  []string{"database"},
+			Routes:    []string{"app.example.com/api"},
+		}))
+	})
+
+	It("lists the differences in what the manifest sets", func() {
+		instances := 3
+		app := ManifestApplication{
+			Name:      "app",
+			Domain:    "example.com",
+			Memory:    "1G",
+			Instances: &instances,
+			Env:       map[string]interface{}{"DEBUG": false, "REGION": "eu", "SAME": 1},
+			Services:  ManifestServices{"database", "cache"},
+		}
+		live := AppConfig{
+			Memory:    512,
+			Instances: 3,
+			Env:       map[string]string{"DEBUG": "true", "SAME": "1", "HOTFIX": "on"},
+			Services:  []string{"database", "queue"},
+			Routes:    []string{"app.example.com", "old.example.com"},
+		}
+
+		Expect(CompareConfig(app, live)).To(Equal([]Drift{
+			{"memory", "live app has 512M, manifest has 1024M"},
+			{"env", "DEBUG has a different value on the live app"},
+			{"env", "REGION is in the manifest but not set on the live app"},
+			{"env", "HOTFIX is set on the live app but not in the manifest"},
+			{"services", "cache is in the manifest but not bound to the live app"},
+			{"services", "queue is bound to the live app but not in the manifest"},
+			{"routes", "old.example.com is mapped to the live app but not in the manifest"},
+		}))
+	})
+
+	It("does not compare what the manifest leaves out", func() {
+		Expect(CompareConfig(ManifestApplication{Name: "app"}, AppConfig{Memory: 512, Instances: 2, Routes: []string{"app.example.com"}})).To(BeEmpty())
+	})
+
+	It("fails on drift in the chosen categories", func() {
+		drifts := []Drift{{"env", "DEBUG has a different value on the live app"}, {"routes", "x"}}
+
+		Expect(CheckDrift(drifts, []string{"memory"})).To(Succeed())
+		Expect(CheckDrift(drifts, []string{"env", "routes"})).To(MatchError(
+			"The live app has drifted from the manifest (env, routes). Update the manifest, or remove them from --fail-on-drift to deploy anyway."))
+	})
+
+	It("parses the categories to fail on", func() {
+		Expect(ParseDriftCategories("env, services")).To(Equal([]string{"env", "services"}))
+		Expect(ParseDriftCategories("all")).To(HaveLen(5))
+
+		_, err := ParseDriftCategories("colour")
+		Expect(err).To(MatchError(`unknown drift category "colour", use one of memory, instances, env, services, routes or all`))
+	})
+})
@@ -0,0 +1,30 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// ParseFailAt takes the --fail-at flag, which every command that deploys
+// accepts, out of args. It is left out of the help on purpose: it makes the
+// deploy fail at the named action, so that what is rolled back can be
+// rehearsed on a sandbox foundation.
+func ParseFailAt(args []string) (string, []string) {
+	return takeStringFlag(args, "fail-at")
+}
+
+// CheckFailAt makes sure --fail-at names one of the actions, so a typo does
+// not let the deploy run to the end.
+func CheckFailAt(name string, actions []rewind.Action) error {
+	names := []string{}
+	for _, action := range actions {
+		if action.Name == name {
+			return nil
+		}
+		names = append(names, action.Name)
+	}
+
+	return fmt.Errorf("--fail-at %q does not name an action, which are: %s", name, strings.Join(names, ", "))
+}
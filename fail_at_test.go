@@ -0,0 +1,29 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("ParseFailAt", func() {
+	It("takes the fail-at flag out of the args", func() {
+		name, args := ParseFailAt([]string{"zero-downtime-push", "app", "--fail-at", "verify routes", "-f", "manifest.yml"})
+		Expect(name).To(Equal("verify routes"))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+})
+
+var _ = Describe("CheckFailAt", func() {
+	actions := []rewind.Action{{Name: "rename"}, {Name: "push"}}
+
+	It("accepts the name of an action", func() {
+		Expect(CheckFailAt("push", actions)).To(Succeed())
+	})
+
+	It("lists the actions when the name is not one of them", func() {
+		Expect(CheckFailAt("pish", actions)).To(MatchError(`--fail-at "pish" does not name an action, which are: rename, push`))
+	})
+})
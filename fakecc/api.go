@@ -0,0 +1,399 @@
+package fakecc
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// handle answers the v2 API requests the plugin makes, and the v3 ones for
+// app annotations and routes. It is called with the lock held.
+func (server *Server) handle(r *http.Request) (interface{}, int, *apiError) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method == "GET" && r.URL.Path == "/" {
+		return server.root(), http.StatusOK, nil
+	}
+	if len(parts) == 3 && parts[0] == "v3" && parts[1] == "apps" {
+		return server.handleV3App(r, parts[2])
+	}
+	if r.Method == "GET" && len(parts) == 4 && parts[0] == "v3" && parts[1] == "apps" && parts[3] == "routes" {
+		return server.listV3AppRoutes(parts[2])
+	}
+	if len(parts) < 2 || parts[0] != "v2" {
+		return nil, 0, notFound("Endpoint " + r.URL.Path)
+	}
+	filters := queryFilters(r)
+
+	switch {
+	case r.Method == "GET" && len(parts) == 2 && parts[1] == "apps":
+		return server.listApps(filters), http.StatusOK, nil
+	case len(parts) >= 3 && parts[1] == "apps":
+		app, found := server.apps[parts[2]]
+		if !found {
+			return nil, 0, notFound("App")
+		}
+		return server.handleApp(r, app, parts[3:])
+
+	case r.Method == "GET" && len(parts) == 2 && parts[1] == "shared_domains":
+		return server.listDomains(filters), http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 2 && parts[1] == "private_domains":
+		return list(nil), http.StatusOK, nil
+
+	case len(parts) == 6 && parts[1] == "routes" && parts[2] == "reserved":
+		query := r.URL.Query()
+		if server.findRoute(query.Get("host"), parts[4], query.Get("path")) == nil {
+			return nil, 0, notFound("Route")
+		}
+		return nil, http.StatusNoContent, nil
+	case r.Method == "GET" && len(parts) == 2 && parts[1] == "routes":
+		return server.listRoutes(filters), http.StatusOK, nil
+	case r.Method == "POST" && len(parts) == 2 && parts[1] == "routes":
+		return server.postRoute(r)
+	case len(parts) >= 3 && parts[1] == "routes":
+		route, found := server.routes[parts[2]]
+		if !found {
+			return nil, 0, notFound("Route")
+		}
+		return server.handleRoute(r, route, parts[3:])
+
+	case r.Method == "GET" && len(parts) == 3 && parts[1] == "spaces" && parts[2] == server.SpaceGuid:
+		return resource(server.SpaceGuid, map[string]interface{}{
+			"name":                        server.SpaceName,
+			"organization_guid":           server.OrgGuid,
+			"space_quota_definition_guid": nil,
+		}), http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 4 && parts[1] == "spaces" && parts[3] == "summary":
+		return server.spaceSummary(), http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 3 && parts[1] == "organizations" && parts[2] == server.OrgGuid:
+		return resource(server.OrgGuid, map[string]interface{}{
+			"name":                  server.OrgName,
+			"quota_definition_guid": "quota-guid",
+		}), http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 4 && parts[1] == "organizations" && parts[3] == "memory_usage":
+		return map[string]int64{"memory_usage_in_mb": server.usage().Memory}, http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 4 && parts[1] == "organizations" && parts[3] == "instance_usage":
+		return map[string]int64{"instance_usage": server.usage().Instances}, http.StatusOK, nil
+	case r.Method == "GET" && len(parts) == 3 && parts[1] == "quota_definitions":
+		return resource(parts[2], map[string]interface{}{
+			"name":               "default",
+			"memory_limit":       -1,
+			"app_instance_limit": -1,
+		}), http.StatusOK, nil
+	}
+
+	return nil, 0, notFound("Endpoint " + r.Method + " " + r.URL.Path)
+}
+
+func (server *Server) handleApp(r *http.Request, app *App, rest []string) (interface{}, int, *apiError) {
+	switch {
+	case r.Method == "GET" && len(rest) == 0:
+		return server.appResource(app), http.StatusOK, nil
+	case r.Method == "PUT" && len(rest) == 0:
+		var update struct {
+			Name *string           `json:"name"`
+			Env  map[string]string `json:"environment_json"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		if update.Name != nil && *update.Name != app.Name {
+			if server.findApp(*update.Name) != nil {
+				return nil, 0, &apiError{status: http.StatusBadRequest, ErrorCode: "CF-AppNameTaken", Description: "The app name is taken: " + *update.Name}
+			}
+			app.Name = *update.Name
+		}
+		if update.Env != nil {
+			app.Env = update.Env
+		}
+		return server.appResource(app), http.StatusCreated, nil
+	case r.Method == "DELETE" && len(rest) == 0:
+		server.deleteApp(app)
+		return nil, http.StatusNoContent, nil
+	case r.Method == "GET" && len(rest) == 1 && rest[0] == "instances":
+		if app.State != "STARTED" {
+			return nil, 0, &apiError{status: http.StatusBadRequest, ErrorCode: "CF-AppStoppedStatsError", Description: "Could not fetch stats for stopped app: " + app.Name}
+		}
+		instances := map[string]interface{}{}
+		for i := 0; i < app.Instances; i++ {
+			instances[strconv.Itoa(i)] = map[string]string{"state": "RUNNING"}
+		}
+		return instances, http.StatusOK, nil
+	case r.Method == "GET" && len(rest) == 1 && rest[0] == "service_bindings":
+		// apps have no services here
+		return list(nil), http.StatusOK, nil
+	}
+
+	return nil, 0, notFound("Endpoint " + r.Method + " " + r.URL.Path)
+}
+
+// handleV3App reads and patches the app's annotations, the only part of
+// the v3 app the plugin uses.
+func (server *Server) handleV3App(r *http.Request, guid string) (interface{}, int, *apiError) {
+	app, found := server.apps[guid]
+	if !found {
+		return nil, 0, notFound("App")
+	}
+
+	switch r.Method {
+	case "GET":
+	case "PATCH":
+		var update struct {
+			Metadata struct {
+				Annotations map[string]*string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		for key, value := range update.Metadata.Annotations {
+			if value == nil {
+				delete(app.Annotations, key)
+			} else {
+				app.Annotations[key] = *value
+			}
+		}
+	default:
+		return nil, 0, notFound("Endpoint " + r.Method + " " + r.URL.Path)
+	}
+
+	return map[string]interface{}{
+		"guid":     app.Guid,
+		"name":     app.Name,
+		"metadata": map[string]interface{}{"annotations": app.Annotations},
+	}, http.StatusOK, nil
+}
+
+// listV3AppRoutes lists the app's routes in a single page.
+func (server *Server) listV3AppRoutes(guid string) (interface{}, int, *apiError) {
+	app, found := server.apps[guid]
+	if !found {
+		return nil, 0, notFound("App")
+	}
+
+	routes := []interface{}{}
+	for _, routeGuid := range server.sortedRouteGuids() {
+		route := server.routes[routeGuid]
+		if !containsString(route.appGuids, app.Guid) {
+			continue
+		}
+		routes = append(routes, map[string]interface{}{
+			"guid": route.guid,
+			"host": route.host,
+			"path": route.path,
+			"url":  server.url(route),
+		})
+	}
+
+	return map[string]interface{}{
+		"pagination": map[string]interface{}{"next": nil},
+		"resources":  routes,
+	}, http.StatusOK, nil
+}
+
+func (server *Server) handleRoute(r *http.Request, route *route, rest []string) (interface{}, int, *apiError) {
+	switch {
+	case r.Method == "GET" && len(rest) == 0:
+		return server.routeResource(route), http.StatusOK, nil
+	case r.Method == "DELETE" && len(rest) == 0:
+		delete(server.routes, route.guid)
+		return nil, http.StatusNoContent, nil
+	case r.Method == "GET" && len(rest) == 1 && rest[0] == "apps":
+		apps := []interface{}{}
+		for _, guid := range route.appGuids {
+			apps = append(apps, server.appResource(server.apps[guid]))
+		}
+		return list(apps), http.StatusOK, nil
+	case len(rest) == 2 && rest[0] == "apps":
+		if _, found := server.apps[rest[1]]; !found {
+			return nil, 0, notFound("App")
+		}
+		if r.Method == "PUT" {
+			if !containsString(route.appGuids, rest[1]) {
+				route.appGuids = append(route.appGuids, rest[1])
+			}
+			return server.routeResource(route), http.StatusCreated, nil
+		}
+		if r.Method == "DELETE" {
+			route.appGuids = removeString(route.appGuids, rest[1])
+			return nil, http.StatusNoContent, nil
+		}
+	}
+
+	return nil, 0, notFound("Endpoint " + r.Method + " " + r.URL.Path)
+}
+
+func (server *Server) postRoute(r *http.Request) (interface{}, int, *apiError) {
+	var body struct {
+		Host       string `json:"host"`
+		DomainGuid string `json:"domain_guid"`
+		SpaceGuid  string `json:"space_guid"`
+		Path       string `json:"path"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	if server.domainName(body.DomainGuid) == "" {
+		return nil, 0, &apiError{status: http.StatusBadRequest, ErrorCode: "CF-DomainInvalid", Description: "The domain is invalid"}
+	}
+	if server.findRoute(body.Host, body.DomainGuid, body.Path) != nil {
+		return nil, 0, &apiError{status: http.StatusBadRequest, ErrorCode: "CF-RouteHostTaken", Description: "The host is taken: " + body.Host}
+	}
+
+	return server.routeResource(server.createRoute(body.Host, body.DomainGuid, body.Path)), http.StatusCreated, nil
+}
+
+// root lists the APIs the server offers, with their versions.
+func (server *Server) root() interface{} {
+	links := map[string]interface{}{}
+	if server.V2Version != "" {
+		links["cloud_controller_v2"] = map[string]interface{}{"meta": map[string]string{"version": server.V2Version}}
+	}
+	if server.V3Version != "" {
+		links["cloud_controller_v3"] = map[string]interface{}{"meta": map[string]string{"version": server.V3Version}}
+	}
+	return map[string]interface{}{"links": links}
+}
+
+func (server *Server) listApps(filters map[string]string) interface{} {
+	apps := []interface{}{}
+	for _, guid := range server.sortedAppGuids() {
+		app := server.apps[guid]
+		if name, given := filters["name"]; given && app.Name != name {
+			continue
+		}
+		if space, given := filters["space_guid"]; given && space != server.SpaceGuid {
+			continue
+		}
+		apps = append(apps, server.appResource(app))
+	}
+	return list(apps)
+}
+
+func (server *Server) listDomains(filters map[string]string) interface{} {
+	domains := []interface{}{}
+	for _, domain := range server.domains {
+		if name, given := filters["name"]; given && domain.name != name {
+			continue
+		}
+		domains = append(domains, resource(domain.guid, map[string]interface{}{"name": domain.name}))
+	}
+	return list(domains)
+}
+
+func (server *Server) listRoutes(filters map[string]string) interface{} {
+	routes := []interface{}{}
+	for _, guid := range server.sortedRouteGuids() {
+		route := server.routes[guid]
+		if host, given := filters["host"]; given && route.host != host {
+			continue
+		}
+		if domainGuid, given := filters["domain_guid"]; given && route.domainGuid != domainGuid {
+			continue
+		}
+		if path, given := filters["path"]; given && route.path != path {
+			continue
+		}
+		routes = append(routes, server.routeResource(route))
+	}
+	return list(routes)
+}
+
+type quotaUsage struct {
+	Memory    int64
+	Instances int64
+}
+
+// usage is what the started apps take up of the quota.
+func (server *Server) usage() quotaUsage {
+	used := quotaUsage{}
+	for _, app := range server.apps {
+		if app.State == "STARTED" {
+			used.Memory += app.Memory * int64(app.Instances)
+			used.Instances += int64(app.Instances)
+		}
+	}
+	return used
+}
+
+func (server *Server) spaceSummary() interface{} {
+	apps := []interface{}{}
+	for _, guid := range server.sortedAppGuids() {
+		app := server.apps[guid]
+		apps = append(apps, map[string]interface{}{
+			"guid":      app.Guid,
+			"name":      app.Name,
+			"memory":    app.Memory,
+			"instances": app.Instances,
+			"state":     app.State,
+		})
+	}
+	return map[string]interface{}{"guid": server.SpaceGuid, "name": server.SpaceName, "apps": apps}
+}
+
+func (server *Server) sortedAppGuids() []string {
+	guids := []string{}
+	for guid := range server.apps {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	return guids
+}
+
+func (server *Server) sortedRouteGuids() []string {
+	guids := []string{}
+	for guid := range server.routes {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	return guids
+}
+
+func (server *Server) appResource(app *App) interface{} {
+	return resource(app.Guid, map[string]interface{}{
+		"name":             app.Name,
+		"space_guid":       server.SpaceGuid,
+		"state":            app.State,
+		"package_state":    "STAGED",
+		"instances":        app.Instances,
+		"memory":           app.Memory,
+		"environment_json": app.Env,
+	})
+}
+
+func (server *Server) routeResource(route *route) interface{} {
+	return resource(route.guid, map[string]interface{}{
+		"host":                  route.host,
+		"path":                  route.path,
+		"domain_guid":           route.domainGuid,
+		"space_guid":            server.SpaceGuid,
+		"service_instance_guid": nil,
+	})
+}
+
+func resource(guid string, entity map[string]interface{}) interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]string{"guid": guid},
+		"entity":   entity,
+	}
+}
+
+func list(resources []interface{}) interface{} {
+	if resources == nil {
+		resources = []interface{}{}
+	}
+
+	return map[string]interface{}{
+		"total_results": len(resources),
+		"next_url":      nil,
+		"resources":     resources,
+	}
+}
+
+// queryFilters reads the "q=field:value" filters of a v2 request.
+func queryFilters(r *http.Request) map[string]string {
+	filters := map[string]string{}
+	for _, filter := range r.URL.Query()["q"] {
+		parts := strings.SplitN(filter, ":", 2)
+		if len(parts) == 2 {
+			filters[parts[0]] = parts[1]
+		}
+	}
+	return filters
+}
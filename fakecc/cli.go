@@ -0,0 +1,310 @@
+package fakecc
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+	"gopkg.in/yaml.v2"
+)
+
+// CLI returns a cf CLI session logged in to the server and targeting its
+// space. The cf commands the plugin runs through it change the server's
+// state the way the real commands would.
+func (server *Server) CLI() *pluginfakes.FakeCliConnection {
+	conn := &pluginfakes.FakeCliConnection{}
+	conn.ApiEndpointReturns(server.URL, nil)
+	conn.IsSSLDisabledReturns(false, nil)
+	conn.IsLoggedInReturns(true, nil)
+	conn.AccessTokenReturns("bearer fake-token", nil)
+	conn.UsernameReturns("deployer", nil)
+	conn.GetCurrentOrgReturns(plugin_models.Organization{
+		OrganizationFields: plugin_models.OrganizationFields{Guid: server.OrgGuid, Name: server.OrgName},
+	}, nil)
+	conn.GetCurrentSpaceReturns(plugin_models.Space{
+		SpaceFields: plugin_models.SpaceFields{Guid: server.SpaceGuid, Name: server.SpaceName},
+	}, nil)
+	conn.GetAppStub = server.getApp
+	conn.CliCommandStub = server.run
+	conn.CliCommandWithoutTerminalOutputStub = server.run
+	return conn
+}
+
+// getApp is what the CLI's GetApp returns for the app.
+func (server *Server) getApp(name string) (plugin_models.GetAppModel, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	app := server.findApp(name)
+	if app == nil {
+		return plugin_models.GetAppModel{}, fmt.Errorf("App %s not found", name)
+	}
+
+	model := plugin_models.GetAppModel{
+		Guid:            app.Guid,
+		Name:            app.Name,
+		State:           strings.ToLower(app.State),
+		InstanceCount:   app.Instances,
+		Memory:          app.Memory,
+		SpaceGuid:       server.SpaceGuid,
+		PackageState:    "STAGED",
+		EnvironmentVars: map[string]interface{}{},
+		Routes:          []plugin_models.GetApp_RouteSummary{},
+	}
+	if app.State == "STARTED" {
+		model.RunningInstances = app.Instances
+	}
+	for key, value := range app.Env {
+		model.EnvironmentVars[key] = value
+	}
+	for _, guid := range server.sortedRouteGuids() {
+		route := server.routes[guid]
+		if !containsString(route.appGuids, app.Guid) {
+			continue
+		}
+		model.Routes = append(model.Routes, plugin_models.GetApp_RouteSummary{
+			Guid: route.guid,
+			Host: route.host,
+			Domain: plugin_models.GetApp_DomainFields{
+				Guid: route.domainGuid,
+				Name: server.domainName(route.domainGuid),
+			},
+		})
+	}
+
+	return model, nil
+}
+
+// run simulates a cf command. Commands the plugin does not use are an
+// error, so a test notices when it starts to.
+func (server *Server) run(args ...string) ([]string, error) {
+	if len(args) > 0 && args[0] == "curl" {
+		return server.curl(args[1:]...)
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	command := strings.Join(args, " ")
+	server.commands = append(server.commands, command)
+	for prefix, err := range server.failures {
+		if command == prefix || strings.HasPrefix(command, prefix+" ") {
+			return []string{"FAILED"}, err
+		}
+	}
+
+	if len(args) < 2 {
+		if len(args) == 1 && args[0] == "apps" {
+			return []string{"OK"}, nil
+		}
+		if len(args) == 1 && args[0] == "version" {
+			return []string{"cf version " + server.CLIVersion}, nil
+		}
+		return nil, fmt.Errorf("fakecc does not simulate cf %s", command)
+	}
+
+	if args[0] == "push" {
+		return server.push(args[1], args[2:])
+	}
+
+	app := server.findApp(args[1])
+	if app == nil {
+		if args[0] == "delete" {
+			return []string{"OK"}, nil
+		}
+		return []string{"FAILED"}, fmt.Errorf("App %s not found", args[1])
+	}
+
+	switch args[0] {
+	case "start", "restage":
+		app.State = "STARTED"
+	case "stop":
+		app.State = "STOPPED"
+	case "delete":
+		server.deleteApp(app)
+	case "set-env":
+		if len(args) != 4 {
+			return nil, fmt.Errorf("fakecc: cf %s takes an app, a name and a value", args[0])
+		}
+		app.Env[args[2]] = args[3]
+	case "scale":
+		instances, err := instancesFlag(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		if instances > 0 {
+			app.Instances = instances
+		}
+		memory, err := memoryFlag(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		if memory > 0 {
+			app.Memory = memory
+		}
+	default:
+		return nil, fmt.Errorf("fakecc does not simulate cf %s", args[0])
+	}
+
+	return []string{"OK"}, nil
+}
+
+// curl sends a GET to the server, as cf curl does, returning the body even
+// for an error status.
+func (server *Server) curl(args ...string) ([]string, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("fakecc only simulates cf curl PATH, not %s", strings.Join(args, " "))
+	}
+
+	request, err := http.NewRequest("GET", server.URL+"/"+strings.TrimLeft(args[0], "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "bearer fake-token")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{string(body)}, nil
+}
+
+type manifest struct {
+	Applications []struct {
+		Name      string            `yaml:"name"`
+		Instances int               `yaml:"instances"`
+		Env       map[string]string `yaml:"env"`
+		NoRoute   bool              `yaml:"no-route"`
+		Routes    []struct {
+			Route string `yaml:"route"`
+		} `yaml:"routes"`
+	} `yaml:"applications"`
+}
+
+// push creates or updates the app from the manifest entry of the same name.
+// Its routes are mapped unless --no-route is given; an app with none gets a
+// route named after it. It is started unless --no-start is given.
+func (server *Server) push(name string, args []string) ([]string, error) {
+	var manifestPath string
+	noRoute, noStart := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-f":
+			if i+1 < len(args) {
+				manifestPath = args[i+1]
+				i++
+			}
+		case "--no-route":
+			noRoute = true
+		case "--no-start":
+			noStart = true
+		}
+	}
+
+	instances, err := instancesFlag(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifestPath == "" {
+		return nil, errors.New("fakecc only simulates cf push with a manifest")
+	}
+
+	contents, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return []string{"FAILED"}, err
+	}
+
+	var parsed manifest
+	err = yaml.Unmarshal(contents, &parsed)
+	if err != nil {
+		return []string{"FAILED"}, err
+	}
+
+	app := server.findApp(name)
+	if app == nil {
+		app = server.createApp(name)
+	}
+
+	for _, entry := range parsed.Applications {
+		if entry.Name != name {
+			continue
+		}
+
+		if entry.Instances > 0 {
+			app.Instances = entry.Instances
+		}
+		for key, value := range entry.Env {
+			app.Env[key] = value
+		}
+
+		noRoute = noRoute || entry.NoRoute
+		if !noRoute {
+			for _, route := range entry.Routes {
+				err = server.mapURL(app, route.Route)
+				if err != nil {
+					return []string{"FAILED"}, err
+				}
+			}
+		}
+	}
+
+	if !noRoute && len(server.appRoutes(app)) == 0 {
+		err = server.mapURL(app, name+"."+server.domains[0].name)
+		if err != nil {
+			return []string{"FAILED"}, err
+		}
+	}
+
+	if instances > 0 {
+		app.Instances = instances
+	}
+	if !noStart {
+		app.State = "STARTED"
+	}
+
+	return []string{"OK"}, nil
+}
+
+func (server *Server) appRoutes(app *App) []*route {
+	routes := []*route{}
+	for _, route := range server.routes {
+		if containsString(route.appGuids, app.Guid) {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// memoryFlag reads -m in megabytes, given as e.g. 512M, or 0 if it is not
+// given.
+func memoryFlag(args []string) (int64, error) {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-m" {
+			return strconv.ParseInt(strings.TrimSuffix(args[i+1], "M"), 10, 64)
+		}
+	}
+	return 0, nil
+}
+
+// instancesFlag reads -i, or 0 if it is not given.
+func instancesFlag(args []string) (int, error) {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-i" {
+			return strconv.Atoi(args[i+1])
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,13 @@
+package fakecc_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFakecc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fakecc Suite")
+}
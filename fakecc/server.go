@@ -0,0 +1,366 @@
+// Package fakecc simulates just enough of a Cloud Controller, and of the cf
+// CLI session a plugin runs in, to drive whole deploys in tests: apps, routes,
+// domains and the space they live in. It keeps its state in memory and
+// records the cf commands it is given, so tests can check both the order of
+// what was done and where the apps and routes ended up.
+package fakecc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultDomain is the shared domain routes are created on, the one the
+// plugin assumes for routes it only knows the hosts of.
+const DefaultDomain = "apps.foundry.mrll.com"
+
+// App is the state of a simulated app.
+type App struct {
+	Guid      string
+	Name      string
+	State     string
+	Instances int
+	Memory    int64
+	Env       map[string]string
+	// Annotations are the app's v3 metadata annotations.
+	Annotations map[string]string
+}
+
+type route struct {
+	guid       string
+	host       string
+	domainGuid string
+	path       string
+	appGuids   []string
+}
+
+type domain struct {
+	guid string
+	name string
+}
+
+// Server is a fake Cloud Controller with a single org and space.
+type Server struct {
+	*httptest.Server
+
+	OrgGuid   string
+	OrgName   string
+	SpaceGuid string
+	SpaceName string
+
+	// CLIVersion is what cf version reports, and V2Version and V3Version
+	// are the API versions the root endpoint lists; "" leaves one out.
+	CLIVersion string
+	V2Version  string
+	V3Version  string
+
+	lock     sync.Mutex
+	nextGuid int
+	apps     map[string]*App
+	routes   map[string]*route
+	domains  []domain
+	commands []string
+	failures map[string]error
+}
+
+// NewServer starts a fake Cloud Controller with DefaultDomain shared.
+// Close it when done.
+func NewServer() *Server {
+	server := &Server{
+		OrgGuid:   "org-guid",
+		OrgName:   "org",
+		SpaceGuid: "space-guid",
+		SpaceName: "space",
+
+		CLIVersion: "6.53.0+8e2b70a4a.2020-10-01",
+		V2Version:  "2.150.0",
+		V3Version:  "3.85.0",
+
+		apps:     map[string]*App{},
+		routes:   map[string]*route{},
+		failures: map[string]error{},
+	}
+	server.AddDomain(DefaultDomain)
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+func (server *Server) guid(kind string) string {
+	server.nextGuid++
+	return fmt.Sprintf("%s-guid-%d", kind, server.nextGuid)
+}
+
+// AddDomain adds a shared domain.
+func (server *Server) AddDomain(name string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.domains = append(server.domains, domain{guid: server.guid("domain"), name: name})
+}
+
+// AddApp adds a started app with one instance, mapped to the routes, given
+// as "host.domain/path".
+func (server *Server) AddApp(name string, routes ...string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	app := server.createApp(name)
+	app.State = "STARTED"
+	for _, url := range routes {
+		server.mapURL(app, url)
+	}
+}
+
+// Annotate sets annotations on the app, as the plugin or anyone else could
+// with the v3 API.
+func (server *Server) Annotate(appName string, annotations map[string]string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	app := server.findApp(appName)
+	if app == nil {
+		return
+	}
+	for key, value := range annotations {
+		app.Annotations[key] = value
+	}
+}
+
+// FailCommand makes the cf commands starting with command, such as
+// "start app", fail with err.
+func (server *Server) FailCommand(command string, err error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.failures[command] = err
+}
+
+// App returns a copy of the app's state. The bool is false if there is no
+// such app.
+func (server *Server) App(name string) (App, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	app := server.findApp(name)
+	if app == nil {
+		return App{}, false
+	}
+
+	copied := *app
+	copied.Env = map[string]string{}
+	for key, value := range app.Env {
+		copied.Env[key] = value
+	}
+	copied.Annotations = map[string]string{}
+	for key, value := range app.Annotations {
+		copied.Annotations[key] = value
+	}
+	return copied, true
+}
+
+// AppNames lists the apps in the space, sorted.
+func (server *Server) AppNames() []string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	names := []string{}
+	for _, app := range server.apps {
+		names = append(names, app.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Routes lists the app's routes as "host.domain/path", sorted.
+func (server *Server) Routes(appName string) []string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	app := server.findApp(appName)
+	if app == nil {
+		return nil
+	}
+
+	urls := []string{}
+	for _, route := range server.routes {
+		if containsString(route.appGuids, app.Guid) {
+			urls = append(urls, server.url(route))
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// Commands lists the cf commands run so far, with their arguments.
+func (server *Server) Commands() []string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return append([]string{}, server.commands...)
+}
+
+func (server *Server) findApp(name string) *App {
+	for _, app := range server.apps {
+		if app.Name == name {
+			return app
+		}
+	}
+	return nil
+}
+
+func (server *Server) createApp(name string) *App {
+	app := &App{
+		Guid:        server.guid("app"),
+		Name:        name,
+		State:       "STOPPED",
+		Instances:   1,
+		Memory:      256,
+		Env:         map[string]string{},
+		Annotations: map[string]string{},
+	}
+	server.apps[app.Guid] = app
+	return app
+}
+
+func (server *Server) deleteApp(app *App) {
+	for _, route := range server.routes {
+		route.appGuids = removeString(route.appGuids, app.Guid)
+	}
+	delete(server.apps, app.Guid)
+}
+
+func (server *Server) findDomain(name string) (domain, bool) {
+	for _, domain := range server.domains {
+		if domain.name == name {
+			return domain, true
+		}
+	}
+	return domain{}, false
+}
+
+func (server *Server) domainName(guid string) string {
+	for _, domain := range server.domains {
+		if domain.guid == guid {
+			return domain.name
+		}
+	}
+	return ""
+}
+
+func (server *Server) url(route *route) string {
+	url := server.domainName(route.domainGuid)
+	if route.host != "" {
+		url = route.host + "." + url
+	}
+	return url + route.path
+}
+
+func (server *Server) findRoute(host, domainGuid, path string) *route {
+	for _, route := range server.routes {
+		if route.host == host && route.domainGuid == domainGuid && route.path == path {
+			return route
+		}
+	}
+	return nil
+}
+
+func (server *Server) createRoute(host, domainGuid, path string) *route {
+	route := &route{guid: server.guid("route"), host: host, domainGuid: domainGuid, path: path}
+	server.routes[route.guid] = route
+	return route
+}
+
+// mapURL maps a "host.domain/path" route to the app the way cf push does,
+// creating it if need be.
+func (server *Server) mapURL(app *App, url string) error {
+	hostAndDomain, path := url, ""
+	if i := strings.Index(url, "/"); i != -1 {
+		hostAndDomain, path = url[:i], url[i:]
+	}
+
+	host := ""
+	domain, found := server.findDomain(hostAndDomain)
+	if !found {
+		parts := strings.SplitN(hostAndDomain, ".", 2)
+		if len(parts) == 2 {
+			host = parts[0]
+			domain, found = server.findDomain(parts[1])
+		}
+	}
+	if !found {
+		return fmt.Errorf("The route %s did not match any existing domains.", url)
+	}
+
+	route := server.findRoute(host, domain.guid, path)
+	if route == nil {
+		route = server.createRoute(host, domain.guid, path)
+	}
+	if !containsString(route.appGuids, app.Guid) {
+		route.appGuids = append(route.appGuids, app.Guid)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, value string) []string {
+	kept := []string{}
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// apiError is the body the Cloud Controller sends with an error status.
+type apiError struct {
+	status      int
+	ErrorCode   string `json:"error_code"`
+	Description string `json:"description"`
+}
+
+func notFound(what string) *apiError {
+	return &apiError{status: http.StatusNotFound, ErrorCode: "CF-NotFound", Description: what + " could not be found"}
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, apiError{ErrorCode: "CF-NotAuthenticated", Description: "Authentication error"})
+		return
+	}
+
+	server.lock.Lock()
+	result, status, err := server.handle(r)
+	server.lock.Unlock()
+
+	if err != nil {
+		writeJSON(w, err.status, err)
+		return
+	}
+
+	if result == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
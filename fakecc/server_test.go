@@ -0,0 +1,97 @@
+package fakecc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/autopilot/capi"
+	"github.com/concourse/autopilot/fakecc"
+)
+
+var _ = Describe("Server", func() {
+	var server *fakecc.Server
+
+	BeforeEach(func() {
+		server = fakecc.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("answers the API for the apps and routes it holds", func() {
+		server.AddApp("app", "app."+fakecc.DefaultDomain)
+		client := capi.NewClient(server.URL, func() (string, error) { return "bearer token", nil }, false)
+
+		app, found, err := client.FindApp(server.SpaceGuid, "app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(app.Entity.State).To(Equal("STARTED"))
+
+		Expect(client.RenameApp(app.Metadata.Guid, "app-venerable")).To(Succeed())
+		Expect(server.AppNames()).To(Equal([]string{"app-venerable"}))
+		Expect(server.Routes("app-venerable")).To(Equal([]string{"app." + fakecc.DefaultDomain}))
+
+		domainGuid, found, err := client.FindDomain(fakecc.DefaultDomain)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		route, found, err := client.FindRoute("app", domainGuid)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		routes, err := client.AppRoutes(app.Metadata.Guid)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].Host).To(Equal("app"))
+		Expect(routes[0].Domain()).To(Equal(fakecc.DefaultDomain))
+
+		Expect(client.UnmapRoute(route.Metadata.Guid, app.Metadata.Guid)).To(Succeed())
+		Expect(server.Routes("app-venerable")).To(BeEmpty())
+	})
+
+	It("runs cf commands against its state", func() {
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(manifest.Name())
+		manifest.WriteString("applications:\n- name: app\n  instances: 2\n  routes:\n  - route: www." + fakecc.DefaultDomain + "\n")
+		manifest.Close()
+
+		cli := server.CLI()
+		_, err = cli.CliCommand("push", "app", "-f", manifest.Name(), "--no-start")
+		Expect(err).ToNot(HaveOccurred())
+
+		app, found := server.App("app")
+		Expect(found).To(BeTrue())
+		Expect(app.State).To(Equal("STOPPED"))
+		Expect(app.Instances).To(Equal(2))
+		Expect(server.Routes("app")).To(Equal([]string{"www." + fakecc.DefaultDomain}))
+
+		model, err := cli.GetApp("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(model.Routes).To(HaveLen(1))
+		Expect(model.Routes[0].Host).To(Equal("www"))
+
+		server.FailCommand("start app", errors.New("crashing"))
+		_, err = cli.CliCommand("start", "app")
+		Expect(err).To(MatchError("crashing"))
+
+		_, err = cli.CliCommandWithoutTerminalOutput("rename", "app", "other")
+		Expect(err).To(MatchError("fakecc does not simulate cf rename"))
+
+		Expect(server.Commands()).To(Equal([]string{
+			"push app -f " + manifest.Name() + " --no-start",
+			"start app",
+			"rename app other",
+		}))
+	})
+
+	It("passes cf curl on to the API", func() {
+		output, err := server.CLI().CliCommandWithoutTerminalOutput("curl", "v2/shared_domains?q=name:"+fakecc.DefaultDomain)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output[0]).To(ContainSubstring(`"name":"` + fakecc.DefaultDomain + `"`))
+	})
+})
@@ -0,0 +1,168 @@
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnvVars collects repeated KEY=VALUE flags.
+type EnvVars map[string]string
+
+func (vars EnvVars) String() string {
+	pairs := []string{}
+	for _, name := range vars.Names() {
+		pairs = append(pairs, name+"="+vars[name])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (vars EnvVars) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("%q should be of the form KEY=VALUE", value)
+	}
+
+	vars[parts[0]] = parts[1]
+	return nil
+}
+
+// Names lists the variable names in a stable order.
+func (vars EnvVars) Names() []string {
+	names := []string{}
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// PushArgs collects repeated --push-arg flags. Each value is split into
+// arguments as a shell would, so a flag and its value can be given together,
+// as in "-t 180", and a value with spaces quoted, as in "-c 'bin/run web'".
+type PushArgs []string
+
+func (args *PushArgs) String() string {
+	return strings.Join(*args, " ")
+}
+
+func (args *PushArgs) Set(value string) error {
+	words, err := splitShellWords(value)
+	if err != nil {
+		return fmt.Errorf("--push-arg %s: %s", value, err)
+	}
+
+	*args = append(*args, words...)
+	return nil
+}
+
+// splitShellWords splits the value on unquoted whitespace. Single quotes keep
+// everything up to the next one; double quotes and backslashes keep what
+// they quote, as in sh.
+func splitShellWords(value string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes) && (quote == 0 || strings.ContainsRune("\"\\$`", runes[i+1])):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// DomainList collects comma separated domains from repeated flags.
+type DomainList []string
+
+func (domains *DomainList) String() string {
+	return strings.Join(*domains, ",")
+}
+
+func (domains *DomainList) Set(value string) error {
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			return fmt.Errorf("%q should be a comma separated list of domains", value)
+		}
+		*domains = append(*domains, domain)
+	}
+
+	return nil
+}
+
+// BuildpackList collects repeated --buildpack flags, in order.
+type BuildpackList []string
+
+func (buildpacks *BuildpackList) String() string {
+	return strings.Join(*buildpacks, ",")
+}
+
+func (buildpacks *BuildpackList) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("--buildpack needs a buildpack name or URL")
+	}
+
+	*buildpacks = append(*buildpacks, value)
+	return nil
+}
+
+// ServiceList collects comma separated service names from repeated flags.
+type ServiceList []string
+
+func (services *ServiceList) String() string {
+	return strings.Join(*services, ",")
+}
+
+func (services *ServiceList) Set(value string) error {
+	for _, service := range strings.Split(value, ",") {
+		service = strings.TrimSpace(service)
+		if service == "" {
+			return fmt.Errorf("%q should be a comma separated list of services", value)
+		}
+		*services = append(*services, service)
+	}
+
+	return nil
+}
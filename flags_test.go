@@ -0,0 +1,63 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("EnvVars", func() {
+	It("keeps everything after the first equals sign as the value", func() {
+		vars := EnvVars{}
+		Expect(vars.Set("B=1=2")).To(Succeed())
+		Expect(vars.Set("A=")).To(Succeed())
+
+		Expect(vars).To(Equal(EnvVars{"A": "", "B": "1=2"}))
+		Expect(vars.Names()).To(Equal([]string{"A", "B"}))
+		Expect(vars.String()).To(Equal("A=,B=1=2"))
+	})
+
+	It("rejects a missing name", func() {
+		Expect(EnvVars{}.Set("=value")).To(MatchError(`"=value" should be of the form KEY=VALUE`))
+	})
+})
+
+var _ = Describe("PushArgs", func() {
+	It("splits each value into separate arguments", func() {
+		args := PushArgs{}
+		Expect(args.Set("-t 180")).To(Succeed())
+		Expect(args.Set("--no-manifest")).To(Succeed())
+
+		Expect(args).To(Equal(PushArgs{"-t", "180", "--no-manifest"}))
+		Expect(args.String()).To(Equal("-t 180 --no-manifest"))
+	})
+
+	It("keeps a quoted value with spaces as one argument", func() {
+		args := PushArgs{}
+		Expect(args.Set(`-c 'bundle exec rackup -p $PORT'`)).To(Succeed())
+		Expect(args.Set(`--var "greeting=hello world" --var path=a\ b`)).To(Succeed())
+		Expect(args.Set(`--health-check-http-endpoint ""`)).To(Succeed())
+
+		Expect(args).To(Equal(PushArgs{"-c", "bundle exec rackup -p $PORT", "--var", "greeting=hello world", "--var", "path=a b", "--health-check-http-endpoint", ""}))
+	})
+
+	It("rejects an unterminated quote", func() {
+		args := PushArgs{}
+		Expect(args.Set(`-c 'bin/run web`)).To(MatchError(`--push-arg -c 'bin/run web: unterminated ' quote`))
+	})
+})
+
+var _ = Describe("DomainList", func() {
+	It("collects comma separated domains", func() {
+		domains := DomainList{}
+		Expect(domains.Set("Example.com, example.org")).To(Succeed())
+		Expect(domains.Set("internal.example.com")).To(Succeed())
+
+		Expect(domains).To(Equal(DomainList{"example.com", "example.org", "internal.example.com"}))
+	})
+
+	It("rejects empty domains", func() {
+		Expect((&DomainList{}).Set("example.com,")).To(MatchError(`"example.com," should be a comma separated list of domains`))
+	})
+})
@@ -0,0 +1,55 @@
+package autopilot
+
+import (
+	"strings"
+)
+
+// takeStringFlag takes a flag every command accepts, given as "--name value"
+// or "--name=value", out of args. Arguments after -- are left alone. The
+// last value given wins.
+func takeStringFlag(args []string, name string) (string, []string) {
+	value := ""
+	rest := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		switch {
+		case (arg == "--"+name || arg == "-"+name) && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"+name+"="):
+			value = strings.TrimPrefix(arg, "--"+name+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return value, rest
+}
+
+// takeBoolFlag takes a flag every command accepts, given as "--name", out of
+// args. Arguments after -- are left alone.
+func takeBoolFlag(args []string, name string) (bool, []string) {
+	found := false
+	rest := []string{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+
+	return found, rest
+}
@@ -0,0 +1,121 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	healthCheckAttempts = 20
+	healthCheckInterval = 3 * time.Second
+)
+
+// CheckAppHealthy waits for every instance of the app to be running, and
+// fails if they are not all running in time.
+func (repo *ApplicationRepo) CheckAppHealthy(appName string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	return repo.waitUntilRunning(appName, app.Metadata.Guid)
+}
+
+// waitUntilRunning polls the instances of the app with the guid, which may
+// be in another space, until they are all running.
+func (repo *ApplicationRepo) waitUntilRunning(appName, appGuid string) error {
+	var instances []InstanceStatus
+	var err error
+	for i := 0; i < healthCheckAttempts; i++ {
+		if i > 0 {
+			time.Sleep(healthCheckInterval)
+		}
+
+		instances, err = repo.instancesByGuid(appGuid)
+		if err != nil {
+			return err
+		}
+
+		if allRunning(instances) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("App %s is not healthy, its instances are %s", appName, describeInstances(instances))
+}
+
+func allRunning(instances []InstanceStatus) bool {
+	if len(instances) == 0 {
+		return false
+	}
+
+	for _, instance := range instances {
+		if instance.State != "RUNNING" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// The health check types the Cloud Controller knows.
+var healthCheckTypes = []string{"port", "process", "http"}
+
+// HealthCheck is how the Cloud Controller checks the new app's instances,
+// overriding the manifest. Zero values leave the manifest's.
+type HealthCheck struct {
+	Type string
+	// Endpoint is the path an http health check requests.
+	Endpoint string
+}
+
+// ParseHealthCheck checks the --health-check-type and
+// --health-check-http-endpoint flags. An endpoint on its own asks for an
+// http health check.
+func ParseHealthCheck(checkType, endpoint string) (HealthCheck, error) {
+	if checkType != "" && !containsString(healthCheckTypes, checkType) {
+		return HealthCheck{}, fmt.Errorf("--health-check-type should be one of %s, not %q", strings.Join(healthCheckTypes, ", "), checkType)
+	}
+
+	if endpoint == "" {
+		return HealthCheck{Type: checkType}, nil
+	}
+
+	if !strings.HasPrefix(endpoint, "/") {
+		return HealthCheck{}, fmt.Errorf("--health-check-http-endpoint should be a path starting with /, not %q", endpoint)
+	}
+	if checkType != "" && checkType != "http" {
+		return HealthCheck{}, fmt.Errorf("--health-check-http-endpoint only applies to --health-check-type http, not %s", checkType)
+	}
+
+	return HealthCheck{Type: "http", Endpoint: endpoint}, nil
+}
+
+// pushArgs passes the health check on to cf push, so it is in place before
+// the new app starts.
+func (check HealthCheck) pushArgs() []string {
+	args := []string{}
+	if check.Type != "" {
+		args = append(args, "-u", check.Type)
+	}
+	if check.Endpoint != "" {
+		args = append(args, "--endpoint", check.Endpoint)
+	}
+	return args
+}
+
+// describe says what the health check is, for the plan.
+func (check HealthCheck) describe() string {
+	switch {
+	case check.Endpoint != "":
+		return fmt.Sprintf(", with an http health check on %s", check.Endpoint)
+	case check.Type != "":
+		return fmt.Sprintf(", with a %s health check", check.Type)
+	}
+	return ""
+}
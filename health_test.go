@@ -0,0 +1,93 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("CheckAppHealthy", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "4"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+
+		api.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3Aapp-name&q=space_guid%3A4"),
+			ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"}}]}`),
+		))
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("succeeds when every instance is running", func() {
+		api.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/apps/app-guid/instances"),
+			ghttp.RespondWith(http.StatusOK, `{"0":{"state":"RUNNING"},"1":{"state":"RUNNING"}}`),
+		))
+
+		Expect(repo.CheckAppHealthy("app-name")).To(Succeed())
+	})
+
+	It("returns errors from the API", func() {
+		api.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, `{"description":"Instances error: app is stopped","error_code":"CF-InstancesError"}`))
+
+		Expect(repo.CheckAppHealthy("app-name")).To(MatchError("Instances error: app is stopped (400 CF-InstancesError)"))
+	})
+})
+
+var _ = Describe("ParseHealthCheck", func() {
+	It("leaves the manifest's health check alone without flags", func() {
+		check, err := ParseHealthCheck("", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check).To(Equal(HealthCheck{}))
+	})
+
+	It("takes the health check type", func() {
+		for _, checkType := range []string{"port", "process", "http"} {
+			check, err := ParseHealthCheck(checkType, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(check).To(Equal(HealthCheck{Type: checkType}))
+		}
+	})
+
+	It("asks for an http health check when given an endpoint", func() {
+		check, err := ParseHealthCheck("", "/healthz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check).To(Equal(HealthCheck{Type: "http", Endpoint: "/healthz"}))
+
+		check, err = ParseHealthCheck("http", "/ready")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check).To(Equal(HealthCheck{Type: "http", Endpoint: "/ready"}))
+	})
+
+	It("rejects endpoints that are not paths, or for other types", func() {
+		_, err := ParseHealthCheck("", "healthz")
+		Expect(err).To(MatchError(`--health-check-http-endpoint should be a path starting with /, not "healthz"`))
+
+		_, err = ParseHealthCheck("port", "/healthz")
+		Expect(err).To(MatchError("--health-check-http-endpoint only applies to --health-check-type http, not port"))
+	})
+})
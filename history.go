@@ -0,0 +1,313 @@
+package autopilot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// The outcomes of a deploy, as the history records them.
+const (
+	OutcomeSucceeded      = "succeeded"
+	OutcomeRolledBack     = "rolled back"
+	OutcomeRollbackFailed = "rollback failed"
+	// OutcomeLeaked is a deploy that went through, but left the old version
+	// holding on to routes, bindings or instances.
+	OutcomeLeaked = "succeeded with leaks"
+)
+
+// HistoryEntry is one deploy of an app, as the history file on the machine
+// that ran it records it. It complements what the Cloud Controller keeps,
+// which forgets deploys that were rolled back.
+type HistoryEntry struct {
+	Command string `json:"command"`
+	App     string `json:"app"`
+	// Revision is the --revision, or the git commit, that was pushed.
+	Revision   string    `json:"revision,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	// FailedAt is the step that failed, or empty if the deploy succeeded
+	// or was interrupted.
+	FailedAt string `json:"failed_at,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Duration is how long the deploy took.
+func (entry HistoryEntry) Duration() time.Duration {
+	return entry.FinishedAt.Sub(entry.StartedAt)
+}
+
+// DeployHistory notes how a deploy went, for its entry in the history.
+type DeployHistory struct {
+	// Redactor masks secrets in the deploy's error.
+	Redactor *Redactor
+
+	entry          HistoryEntry
+	rollbackFailed bool
+	leaked         bool
+}
+
+// NewDeployHistory starts the entry of a deploy that started at now.
+func NewDeployHistory(command, appName string, now time.Time) *DeployHistory {
+	return &DeployHistory{entry: HistoryEntry{Command: command, App: appName, StartedAt: now.UTC()}}
+}
+
+// ObserveStep notes the step that failed, and whether undoing the steps
+// before it failed too. It does nothing on a nil history.
+func (history *DeployHistory) ObserveStep(name, phase string, start time.Time, err error) {
+	if history == nil || err == nil {
+		return
+	}
+
+	if phase == rewind.PhaseForward {
+		// a wait cut short by an interrupt is not a failure of its step
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			return
+		}
+		if history.entry.FailedAt == "" {
+			history.entry.FailedAt = name
+		}
+	} else {
+		history.rollbackFailed = true
+	}
+}
+
+// NoteLeaks notes what the old version still holds on to once the deploy
+// went through, which its error then reports. It does nothing on a nil
+// history.
+func (history *DeployHistory) NoteLeaks(leaks []string) {
+	if history == nil {
+		return
+	}
+
+	history.leaked = len(leaks) > 0
+}
+
+// Finish completes the entry of the deploy of the revision, which ended at
+// now with deployErr.
+func (history *DeployHistory) Finish(revision string, deployErr error, now time.Time) HistoryEntry {
+	entry := history.entry
+	entry.Revision = revision
+	entry.FinishedAt = now.UTC()
+
+	switch {
+	case deployErr == nil:
+		entry.Outcome = OutcomeSucceeded
+		entry.FailedAt = ""
+	case history.leaked:
+		entry.Outcome = OutcomeLeaked
+		entry.Error = history.Redactor.Redact(deployErr.Error())
+	case history.rollbackFailed:
+		entry.Outcome = OutcomeRollbackFailed
+		entry.Error = history.Redactor.Redact(deployErr.Error())
+	default:
+		entry.Outcome = OutcomeRolledBack
+		entry.Error = history.Redactor.Redact(deployErr.Error())
+	}
+
+	return entry
+}
+
+// HistoryDir is where the history files are kept.
+func HistoryDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".autopilot", "history"), nil
+}
+
+// HistoryPath is the history file of the app in the org and space the cf
+// CLI targets, on the foundation it is logged in to, as apps of the same
+// name elsewhere are other apps.
+func (repo *ApplicationRepo) HistoryPath(appName string) (string, error) {
+	dir, err := HistoryDir()
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := repo.conn.ApiEndpoint()
+	if err != nil {
+		return "", err
+	}
+
+	org, err := repo.conn.GetCurrentOrg()
+	if err != nil {
+		return "", err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, historyPathPart(endpoint), historyPathPart(org.Name), historyPathPart(space.Name), historyPathPart(appName)+".jsonl"), nil
+}
+
+// historyPathPart makes a name safe as a single part of a path: an API
+// endpoint keeps its host and port, and separators become dashes.
+func historyPathPart(name string) string {
+	if i := strings.Index(name, "://"); i != -1 {
+		name = name[i+3:]
+	}
+	name = strings.TrimRight(name, "/")
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':':
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+// AppendHistory adds the entry to the history file, readable only by the
+// user.
+func AppendHistory(path string, entry HistoryEntry) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(line, '\n'))
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// LoadHistory reads the history file, oldest deploy first. Without the file
+// the history is empty.
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry HistoryEntry
+		err = json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s is not a deploy: %s", number, path, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// FormatHistory lists the entries newest first, at most limit of them, or
+// all of them for a limit of 0.
+func FormatHistory(entries []HistoryEntry, limit int) []string {
+	if len(entries) == 0 {
+		return []string{"No deploys recorded."}
+	}
+
+	lines := []string{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(lines) == limit {
+			break
+		}
+
+		entry := entries[i]
+		line := fmt.Sprintf("%s  %s  %s, took %s", entry.StartedAt.UTC().Format("2006-01-02 15:04 MST"), entry.Command,
+			describeChoice(entry.Revision != "", "revision "+entry.Revision, "no revision"), entry.Duration().Round(time.Second))
+		line += ": " + entry.Outcome
+		if entry.FailedAt != "" {
+			line += fmt.Sprintf(" after %q failed", entry.FailedAt)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// ParseHistoryArgs reads the history command's --limit and --json flags,
+// leaving the app's name.
+func ParseHistoryArgs(args []string) (string, int, bool, error) {
+	asJSON, args := takeBoolFlag(args, "json")
+	limitValue, args := takeStringFlag(args, "limit")
+
+	limit := 0
+	if limitValue != "" {
+		var err error
+		limit, err = strconv.Atoi(limitValue)
+		if err != nil || limit < 0 {
+			return "", 0, false, fmt.Errorf("--limit should be a number of deploys, not %q", limitValue)
+		}
+	}
+
+	if len(args) < 2 {
+		return "", 0, false, errors.New("zero-downtime-history needs the name of an app")
+	}
+
+	return args[1], limit, asJSON, nil
+}
+
+// showHistory prints the past deploys of the app from this machine.
+func showHistory(repo *ApplicationRepo, args []string) error {
+	appName, limit, asJSON, err := ParseHistoryArgs(args)
+	if err != nil {
+		return err
+	}
+
+	path, err := repo.HistoryPath(appName)
+	if err != nil {
+		return err
+	}
+
+	entries, err := LoadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		contents, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(contents))
+		return nil
+	}
+
+	for _, line := range FormatHistory(entries, limit) {
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package autopilot_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Deploy history", func() {
+	var (
+		dir     string
+		started time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "history")
+		Expect(err).ToNot(HaveOccurred())
+		started = time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("DeployHistory", func() {
+		It("records a deploy that went through", func() {
+			history := NewDeployHistory("zero-downtime-push", "app", started)
+			history.ObserveStep("push", rewind.PhaseForward, started, nil)
+
+			entry := history.Finish("abc123", nil, started.Add(3*time.Minute))
+			Expect(entry).To(Equal(HistoryEntry{
+				Command:    "zero-downtime-push",
+				App:        "app",
+				Revision:   "abc123",
+				StartedAt:  started,
+				FinishedAt: started.Add(3 * time.Minute),
+				Outcome:    OutcomeSucceeded,
+			}))
+			Expect(entry.Duration()).To(Equal(3 * time.Minute))
+		})
+
+		It("records the step that failed, and whether it was rolled back", func() {
+			history := NewDeployHistory("zero-downtime-push", "app", started)
+			history.ObserveStep("check test route", rewind.PhaseForward, started, errors.New("404"))
+			history.ObserveStep("push", rewind.PhaseUndo, started, nil)
+
+			entry := history.Finish("", errors.New("404"), started.Add(time.Minute))
+			Expect(entry.Outcome).To(Equal(OutcomeRolledBack))
+			Expect(entry.FailedAt).To(Equal("check test route"))
+			Expect(entry.Error).To(Equal("404"))
+
+			history.ObserveStep("rename live app", rewind.PhaseUndo, started, errors.New("name taken"))
+			Expect(history.Finish("", errors.New("404"), started.Add(time.Minute)).Outcome).To(Equal(OutcomeRollbackFailed))
+		})
+
+		It("records no failed step for a deploy interrupted during a wait", func() {
+			history := NewDeployHistory("zero-downtime-push", "app", started)
+			history.ObserveStep("drain", rewind.PhaseForward, started, context.Canceled)
+
+			entry := history.Finish("", context.Canceled, started.Add(time.Minute))
+			Expect(entry.Outcome).To(Equal(OutcomeRolledBack))
+			Expect(entry.FailedAt).To(BeEmpty())
+		})
+
+		It("records a deploy that went through but leaked as such, not as rolled back", func() {
+			history := NewDeployHistory("zero-downtime-push", "app", started)
+			history.NoteLeaks([]string{"app-venerable still has 2 instances"})
+
+			entry := history.Finish("abc123", errors.New("app-venerable still has 2 instances"), started.Add(time.Minute))
+			Expect(entry.Outcome).To(Equal(OutcomeLeaked))
+			Expect(entry.FailedAt).To(BeEmpty())
+			Expect(entry.Error).To(Equal("app-venerable still has 2 instances"))
+		})
+
+		It("masks secrets in the error", func() {
+			redactor, err := NewRedactor(nil)
+			Expect(err).ToNot(HaveOccurred())
+			redactor.Add("DB_PASSWORD", "hunter2")
+
+			history := NewDeployHistory("zero-downtime-push", "app", started)
+			history.Redactor = redactor
+			history.ObserveStep("push", rewind.PhaseForward, started, errors.New("failed"))
+
+			entry := history.Finish("", errors.New("could not connect with hunter2"), started.Add(time.Minute))
+			Expect(entry.Error).To(Equal("could not connect with " + Redacted))
+		})
+	})
+
+	It("keeps the history of each app in its own file per foundation, org and space", func() {
+		home := os.Getenv("HOME")
+		os.Setenv("HOME", dir)
+		defer os.Setenv("HOME", home)
+
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns("https://api.sys.example.com", nil)
+		cliConn.GetCurrentOrgReturns(plugin_models.Organization{OrganizationFields: plugin_models.OrganizationFields{Name: "payments"}}, nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Name: "prod"}}, nil)
+
+		path, err := NewApplicationRepo(cliConn).HistoryPath("orders")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(dir, ".autopilot", "history", "api.sys.example.com", "payments", "prod", "orders.jsonl")))
+	})
+
+	It("appends each deploy to the file, and reads them back oldest first", func() {
+		path := filepath.Join(dir, "history", "app.jsonl")
+		Expect(LoadHistory(path)).To(BeEmpty())
+
+		first := HistoryEntry{Command: "zero-downtime-push", App: "app", Revision: "abc123", StartedAt: started, FinishedAt: started.Add(2 * time.Minute), Outcome: OutcomeSucceeded}
+		second := HistoryEntry{Command: "zero-downtime-push", App: "app", StartedAt: started.Add(time.Hour), FinishedAt: started.Add(time.Hour + 90*time.Second), Outcome: OutcomeRolledBack, FailedAt: "push", Error: "staging failed"}
+		Expect(AppendHistory(path, first)).To(Succeed())
+		Expect(AppendHistory(path, second)).To(Succeed())
+
+		Expect(LoadHistory(path)).To(Equal([]HistoryEntry{first, second}))
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("says which line of the file it cannot read", func() {
+		path := filepath.Join(dir, "app.jsonl")
+		Expect(ioutil.WriteFile(path, []byte("{\"command\":\"zero-downtime-push\"}\nnot json\n"), 0600)).To(Succeed())
+
+		_, err := LoadHistory(path)
+		Expect(err).To(MatchError(HavePrefix("line 2 of " + path + " is not a deploy")))
+	})
+
+	It("lists the deploys newest first", func() {
+		entries := []HistoryEntry{
+			{Command: "zero-downtime-push", Revision: "abc123", StartedAt: started, FinishedAt: started.Add(2 * time.Minute), Outcome: OutcomeSucceeded},
+			{Command: "zero-downtime-push", StartedAt: started.Add(time.Hour), FinishedAt: started.Add(time.Hour + 90*time.Second), Outcome: OutcomeRolledBack, FailedAt: "push"},
+			{Command: "zero-downtime-scale", StartedAt: started.Add(2 * time.Hour), FinishedAt: started.Add(2*time.Hour + 10*time.Second), Outcome: OutcomeSucceeded},
+		}
+
+		Expect(FormatHistory(entries, 0)).To(Equal([]string{
+			"2026-10-16 11:30 UTC  zero-downtime-scale  no revision, took 10s: succeeded",
+			`2026-10-16 10:30 UTC  zero-downtime-push  no revision, took 1m30s: rolled back after "push" failed`,
+			"2026-10-16 09:30 UTC  zero-downtime-push  revision abc123, took 2m0s: succeeded",
+		}))
+		Expect(FormatHistory(entries, 1)).To(HaveLen(1))
+		Expect(FormatHistory(nil, 0)).To(Equal([]string{"No deploys recorded."}))
+	})
+
+	It("reads the command's arguments", func() {
+		appName, limit, asJSON, err := ParseHistoryArgs([]string{"zero-downtime-history", "app", "--limit", "5", "--json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appName).To(Equal("app"))
+		Expect(limit).To(Equal(5))
+		Expect(asJSON).To(BeTrue())
+
+		_, _, _, err = ParseHistoryArgs([]string{"zero-downtime-history", "app", "--limit", "many"})
+		Expect(err).To(MatchError(`--limit should be a number of deploys, not "many"`))
+
+		_, _, _, err = ParseHistoryArgs([]string{"zero-downtime-history"})
+		Expect(err).To(MatchError("zero-downtime-history needs the name of an app"))
+	})
+})
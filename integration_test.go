@@ -0,0 +1,228 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// These drive whole deploys through the real ApplicationRepo, against a
+// simulated Cloud Controller, and check where the apps and routes end up.
+var _ = Describe("Deploys against a Cloud Controller", func() {
+	var (
+		server       *fakecc.Server
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	route := "app." + fakecc.DefaultDomain
+
+	BeforeEach(func() {
+		server = fakecc.NewServer()
+		planner = NewDeploymentPlanner(NewApplicationRepo(server.CLI()))
+
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manifest.WriteString("applications:\n- name: app\n  routes:\n  - route: " + route + "\n")
+		Expect(err).ToNot(HaveOccurred())
+		manifest.Close()
+		manifestPath = manifest.Name()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Remove(manifestPath)
+	})
+
+	push := func(options AutopilotOptions) error {
+		actions, err := planner.PushActions("app", manifestPath, "", options)
+		if err != nil {
+			return err
+		}
+		return rewind.Actions{Actions: actions}.Execute()
+	}
+
+	rollback := func(options RollbackOptions) error {
+		return rewind.Actions{Actions: planner.RollbackActions("app", options)}.Execute()
+	}
+
+	It("pushes a new app", func() {
+		Expect(push(AutopilotOptions{})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{"push app -f " + manifestPath}))
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+		Expect(server.Routes("app")).To(Equal([]string{route}))
+	})
+
+	It("replaces a live app, deleting the old version once the new one has its routes", func() {
+		server.AddApp("app", route)
+		old, _ := server.App("app")
+
+		Expect(push(AutopilotOptions{})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{
+			"push app -f " + manifestPath,
+			"delete app-venerable -f",
+		}))
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+		live, _ := server.App("app")
+		Expect(live.Guid).ToNot(Equal(old.Guid))
+		Expect(live.State).To(Equal("STARTED"))
+		Expect(server.Routes("app")).To(Equal([]string{route}))
+	})
+
+	It("sets the environment before starting the new app", func() {
+		server.AddApp("app", route)
+
+		Expect(push(AutopilotOptions{Env: EnvVars{"FEATURE": "on"}})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{
+			"push app -f " + manifestPath + " --no-start",
+			"set-env app FEATURE on",
+			"start app",
+			"delete app-venerable -f",
+		}))
+		live, _ := server.App("app")
+		Expect(live.Env).To(HaveKeyWithValue("FEATURE", "on"))
+	})
+
+	It("scales the new app with the overrides before starting it", func() {
+		server.AddApp("app", route)
+
+		Expect(push(AutopilotOptions{Scale: ScaleOptions{Instances: 3, Memory: 1024}})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{
+			"push app -f " + manifestPath + " --no-start",
+			"scale app -i 3 -m 1024M -f",
+			"start app",
+			"delete app-venerable -f",
+		}))
+		live, _ := server.App("app")
+		Expect(live.Instances).To(Equal(3))
+		Expect(live.Memory).To(Equal(int64(1024)))
+	})
+
+	It("passes the start command on to cf push", func() {
+		Expect(push(AutopilotOptions{StartCommand: "bundle exec rackup -p $PORT"})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{"push app -f " + manifestPath + " -c bundle exec rackup -p $PORT"}))
+	})
+
+	It("marks the old version as made by autopilot, and refuses apps that are not", func() {
+		server.AddApp("app", route)
+
+		Expect(push(AutopilotOptions{KeepExisting: true})).To(Succeed())
+		venerable, _ := server.App("app-venerable")
+		Expect(venerable.Annotations).To(HaveKeyWithValue("autopilot/managed", "true"))
+
+		// a kept old version is deleted by the next push
+		Expect(push(AutopilotOptions{})).To(Succeed())
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+
+		server.AddApp("app-venerable")
+		Expect(push(AutopilotOptions{})).To(MatchError(ContainSubstring("App app-venerable already exists and was not made by autopilot")))
+		Expect(server.AppNames()).To(Equal([]string{"app", "app-venerable"}))
+	})
+
+	It("keeps timestamped old versions side by side, and rolls back to the newest", func() {
+		server.AddApp("app", route)
+		first, _ := server.App("app")
+
+		planner.Naming = TimestampNaming{At: time.Date(2024, 6, 7, 12, 12, 0, 0, time.UTC)}
+		Expect(push(AutopilotOptions{KeepExisting: true})).To(Succeed())
+		second, _ := server.App("app")
+
+		// the kept version still holds the route, which is not in the way
+		planner.Naming = TimestampNaming{At: time.Date(2024, 6, 8, 9, 0, 0, 0, time.UTC)}
+		Expect(push(AutopilotOptions{KeepExisting: true})).To(Succeed())
+		Expect(server.AppNames()).To(Equal([]string{"app", "app-20240607T1212", "app-20240608T0900"}))
+
+		latest, err := planner.LatestCopy("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(latest).To(Equal("app-20240608T0900"))
+
+		Expect(rollback(RollbackOptions{From: latest})).To(Succeed())
+		live, _ := server.App("app")
+		Expect(live.Guid).To(Equal(second.Guid))
+		oldest, _ := server.App("app-20240607T1212")
+		Expect(oldest.Guid).To(Equal(first.Guid))
+	})
+
+	It("puts the live app back when the new version fails to start", func() {
+		server.AddApp("app", route)
+		old, _ := server.App("app")
+		server.FailCommand("push app", errors.New("staging failed"))
+
+		Expect(push(AutopilotOptions{})).To(MatchError("staging failed"))
+
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+		live, _ := server.App("app")
+		Expect(live.Guid).To(Equal(old.Guid))
+		Expect(server.Routes("app")).To(Equal([]string{route}))
+	})
+
+	It("rolls back to the kept old version", func() {
+		server.AddApp("app", route)
+		old, _ := server.App("app")
+
+		Expect(push(AutopilotOptions{KeepExisting: true})).To(Succeed())
+		venerable, _ := server.App("app-venerable")
+		Expect(venerable.State).To(Equal("STOPPED"))
+
+		Expect(rollback(RollbackOptions{})).To(Succeed())
+
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+		live, _ := server.App("app")
+		Expect(live.Guid).To(Equal(old.Guid))
+		Expect(live.State).To(Equal("STARTED"))
+		Expect(server.Routes("app")).To(Equal([]string{route}))
+	})
+
+	It("moves the routes back when rolling back to an unmapped old version", func() {
+		server.AddApp("app", route)
+		old, _ := server.App("app")
+
+		Expect(push(AutopilotOptions{UnmapRoute: true})).To(Succeed())
+		Expect(server.Routes("app-venerable")).To(BeEmpty())
+
+		Expect(rollback(RollbackOptions{})).To(Succeed())
+
+		Expect(server.AppNames()).To(Equal([]string{"app"}))
+		live, _ := server.App("app")
+		Expect(live.Guid).To(Equal(old.Guid))
+		Expect(server.Routes("app")).To(Equal([]string{route}))
+	})
+
+	It("moves wildcard routes on other domains along with the app's hosts", func() {
+		server.AddDomain("example.com")
+		wildcard := "*.example.com"
+		server.AddApp("app", route, wildcard)
+		Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: app\n  routes:\n  - route: "+route+"\n  - route: \""+wildcard+"\"\n"), 0644)).To(Succeed())
+
+		Expect(push(AutopilotOptions{UnmapRoute: true})).To(Succeed())
+		Expect(server.Routes("app")).To(Equal([]string{wildcard, route}))
+		Expect(server.Routes("app-venerable")).To(BeEmpty())
+
+		Expect(rollback(RollbackOptions{})).To(Succeed())
+		Expect(server.Routes("app")).To(Equal([]string{wildcard, route}))
+	})
+
+	It("premaps the live app's exact routes, paths included, when the manifest lists none", func() {
+		api := "www." + fakecc.DefaultDomain + "/api"
+		server.AddApp("app", route, api)
+		Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: app\n"), 0644)).To(Succeed())
+
+		Expect(push(AutopilotOptions{PremapRoutes: true})).To(Succeed())
+
+		Expect(server.Commands()).To(ContainElement("push app -f " + manifestPath + " --no-route --no-start"))
+		Expect(server.Routes("app")).To(Equal([]string{route, api}))
+	})
+})
@@ -0,0 +1,116 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/autopilot/repository"
+	"github.com/concourse/autopilot/rewind"
+)
+
+type AppResources = repository.AppResources
+
+// AppResources looks up what the app still holds. An app that does not exist
+// holds nothing.
+func (repo *ApplicationRepo) AppResources(appName string) (AppResources, error) {
+	_, found, err := repo.findApp(appName)
+	if err != nil || !found {
+		return AppResources{}, err
+	}
+
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return AppResources{}, err
+	}
+
+	resources := AppResources{
+		Exists:           true,
+		Services:         []string{},
+		RunningInstances: app.RunningInstances,
+	}
+	for _, service := range app.Services {
+		resources.Services = append(resources.Services, service.Name)
+	}
+
+	resources.Routes, err = repo.AppRoutes(appName)
+	return resources, err
+}
+
+// Leaks lists what the old version of the app was left holding after it was
+// deleted, as found by the last deploy the planner ran.
+func (planner *DeploymentPlanner) Leaks() []string {
+	return planner.leaks
+}
+
+// LeakError is returned by a deploy that went through, but left the old
+// version holding routes, service bindings or instances it should have let
+// go of.
+type LeakError struct {
+	App   string
+	Leaks []string
+}
+
+func (err LeakError) Error() string {
+	return fmt.Sprintf("The new version of %s is live, but the old version was not cleaned up:\n  %s\nRemove what is left by hand.",
+		err.App, strings.Join(err.Leaks, "\n  "))
+}
+
+// leakError is the error for the leaks found, or nil if there were none.
+func leakError(appName string, leaks []string) error {
+	if len(leaks) == 0 {
+		return nil
+	}
+
+	return LeakError{App: appName, Leaks: leaks}
+}
+
+// describeLeaks says what a deleted app still holds.
+func describeLeaks(appName string, resources AppResources) []string {
+	if !resources.Exists {
+		return nil
+	}
+
+	leaks := []string{fmt.Sprintf("%s still exists after it was deleted", appName)}
+	if len(resources.Routes) > 0 {
+		leaks = append(leaks, fmt.Sprintf("%s is still mapped to %s", appName, strings.Join(resources.Routes, ", ")))
+	}
+	if len(resources.Services) > 0 {
+		leaks = append(leaks, fmt.Sprintf("%s is still bound to %s", appName, strings.Join(resources.Services, ", ")))
+	}
+	if resources.RunningInstances > 0 {
+		leaks = append(leaks, fmt.Sprintf("%s still has %d running instances", appName, resources.RunningInstances))
+	}
+
+	return leaks
+}
+
+// verifyRetiredAction checks a deleted old version is really gone. The new
+// version is live by then, so what is left is reported rather than rolled
+// back.
+func (planner *DeploymentPlanner) verifyRetiredAction(venerable string, keepsVenerable bool) rewind.Action {
+	return rewind.Action{
+		Name: "verify old version retired",
+		Forward: func() error {
+			planner.leaks = nil
+			if keepsVenerable {
+				return nil
+			}
+
+			resources, err := planner.Repo.AppResources(venerable)
+			if err != nil {
+				planner.Logger.Printf("Warning: could not check %s was cleaned up: %s\n", venerable, err)
+				return nil
+			}
+
+			planner.leaks = describeLeaks(venerable, resources)
+			for _, leak := range planner.leaks {
+				planner.Logger.Printf("Warning: %s.\n", leak)
+			}
+			return nil
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Check %s is gone, leaving no routes, service bindings or running instances behind, and fail the deploy if not.", venerable),
+			When:    describeIf(keepsVenerable, "only when the old version is deleted, which it is not, so it does nothing"),
+		},
+	}
+}
@@ -0,0 +1,130 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Verifying the old version was retired", func() {
+	var (
+		repo         *recordingRepo
+		logger       *recordingLogger
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	execute := func(options AutopilotOptions) error {
+		return rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+	}
+
+	BeforeEach(func() {
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manifest.WriteString("applications:\n- name: app\n")
+		Expect(err).ToNot(HaveOccurred())
+		manifest.Close()
+		manifestPath = manifest.Name()
+
+		repo = newRecordingRepo()
+		repo.routes["app"] = []string{"app.example.com"}
+		logger = &recordingLogger{}
+		planner = &DeploymentPlanner{
+			Repo:   repo,
+			Naming: SuffixNaming{},
+			Clock:  &fakeClock{},
+			Logger: logger,
+		}
+	})
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	It("finds nothing left of a deleted old version", func() {
+		Expect(execute(AutopilotOptions{})).To(Succeed())
+		Expect(repo.calls[len(repo.calls)-1]).To(Equal("AppResources app-venerable"))
+		Expect(planner.Leaks()).To(BeEmpty())
+	})
+
+	It("reports what a deleted old version still holds, without rolling back the new one", func() {
+		repo.resources["app-venerable"] = AppResources{
+			Exists:           true,
+			Routes:           []string{"app.example.com"},
+			Services:         []string{"orders-db", "logs"},
+			RunningInstances: 2,
+		}
+
+		Expect(execute(AutopilotOptions{})).To(Succeed())
+		Expect(planner.Leaks()).To(Equal([]string{
+			"app-venerable still exists after it was deleted",
+			"app-venerable is still mapped to app.example.com",
+			"app-venerable is still bound to orders-db, logs",
+			"app-venerable still has 2 running instances",
+		}))
+		Expect(logger.messages).To(ContainElement("Warning: app-venerable is still bound to orders-db, logs.\n"))
+		Expect(repo.calls).ToNot(ContainElement("RenameApplication app-venerable app"))
+	})
+
+	It("leaves a kept old version alone", func() {
+		repo.resources["app-venerable"] = AppResources{Exists: true, RunningInstances: 1}
+
+		Expect(execute(AutopilotOptions{KeepRunning: true})).To(Succeed())
+		Expect(repo.calls).ToNot(ContainElement("AppResources app-venerable"))
+		Expect(planner.Leaks()).To(BeEmpty())
+	})
+
+	It("warns when the old version can't be checked", func() {
+		repo.failures["AppResources app-venerable"] = errors.New("timed out")
+
+		Expect(execute(AutopilotOptions{})).To(Succeed())
+		Expect(planner.Leaks()).To(BeEmpty())
+		Expect(logger.messages).To(ContainElement("Warning: could not check app-venerable was cleaned up: timed out\n"))
+	})
+
+	It("lists the leaks in the error that fails the deploy", func() {
+		err := LeakError{App: "app", Leaks: []string{"app-venerable still exists after it was deleted", "app-venerable still has 2 running instances"}}
+		Expect(err.Error()).To(Equal("The new version of app is live, but the old version was not cleaned up:\n" +
+			"  app-venerable still exists after it was deleted\n" +
+			"  app-venerable still has 2 running instances\n" +
+			"Remove what is left by hand."))
+	})
+
+	Describe("against a Cloud Controller", func() {
+		var server *fakecc.Server
+
+		BeforeEach(func() {
+			server = fakecc.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("finds an app's routes and running instances", func() {
+			server.AddApp("app-venerable", "app."+fakecc.DefaultDomain)
+
+			resources, err := NewApplicationRepo(server.CLI()).AppResources("app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(Equal(AppResources{
+				Exists:           true,
+				Routes:           []string{"app." + fakecc.DefaultDomain},
+				Services:         []string{},
+				RunningInstances: 1,
+			}))
+		})
+
+		It("finds nothing held by an app that is gone", func() {
+			resources, err := NewApplicationRepo(server.CLI()).AppResources("app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources.Exists).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The lifecycles an app can be staged and run with, as the v3 manifest
+// schema names them.
+var lifecycles = []string{"buildpack", "docker", "cnb"}
+
+// ManifestDocker is the image a docker app runs.
+type ManifestDocker struct {
+	Image    string `yaml:"image"`
+	Username string `yaml:"username"`
+}
+
+// Lifecycle is how the new version will be staged, from the flags or else
+// the manifest.
+type Lifecycle struct {
+	Type        string
+	Buildpacks  []string
+	DockerImage string
+}
+
+// PushLifecycle works out the lifecycle of the app's push. Flags override
+// the manifest; without either an app with a docker image is a docker app
+// and any other a buildpack app.
+func PushLifecycle(app ManifestApplication, options AutopilotOptions) (Lifecycle, error) {
+	if app.Buildpack != "" && len(app.Buildpacks) > 0 {
+		return Lifecycle{}, fmt.Errorf("The manifest of %s sets both buildpack and buildpacks, use only buildpacks.", app.Name)
+	}
+
+	lifecycle := Lifecycle{Type: app.Lifecycle, Buildpacks: app.Buildpacks}
+	if app.Buildpack != "" {
+		lifecycle.Buildpacks = []string{app.Buildpack}
+	}
+	if app.Docker != nil {
+		lifecycle.DockerImage = app.Docker.Image
+	}
+	if image := pushArgValue(options.PushArgs, "--docker-image", "-o"); image != "" {
+		lifecycle.DockerImage = image
+	}
+
+	if len(options.Buildpacks) > 0 {
+		lifecycle.Buildpacks = options.Buildpacks
+	}
+	if options.Lifecycle != "" {
+		lifecycle.Type = options.Lifecycle
+	}
+	if lifecycle.Type == "" {
+		lifecycle.Type = "buildpack"
+		if lifecycle.DockerImage != "" {
+			lifecycle.Type = "docker"
+		}
+	}
+
+	return lifecycle, lifecycle.validate()
+}
+
+func (lifecycle Lifecycle) validate() error {
+	if !containsString(lifecycles, lifecycle.Type) {
+		return fmt.Errorf("Unknown lifecycle %q, use one of %s.", lifecycle.Type, strings.Join(lifecycles, ", "))
+	}
+
+	if lifecycle.Type == "docker" {
+		if len(lifecycle.Buildpacks) > 0 {
+			return fmt.Errorf("Buildpacks (%s) cannot be used with the docker lifecycle.", strings.Join(lifecycle.Buildpacks, ", "))
+		}
+		if lifecycle.DockerImage == "" {
+			return fmt.Errorf("The docker lifecycle needs an image, from the manifest's docker section or --push-arg \"--docker-image IMAGE\".")
+		}
+		return nil
+	}
+
+	if lifecycle.DockerImage != "" {
+		return fmt.Errorf("The docker image %s cannot be used with the %s lifecycle.", lifecycle.DockerImage, lifecycle.Type)
+	}
+
+	if lifecycle.Type == "cnb" && len(lifecycle.Buildpacks) == 0 {
+		return fmt.Errorf("The cnb lifecycle needs at least one buildpack, from the manifest or --buildpack.")
+	}
+
+	return nil
+}
+
+// lifecycleArgs passes the --buildpack and --lifecycle flags on to cf push.
+// The manifest's own settings are left for cf push to read.
+func lifecycleArgs(options AutopilotOptions) []string {
+	args := []string{}
+	for _, buildpack := range options.Buildpacks {
+		args = append(args, "-b", buildpack)
+	}
+
+	if options.Lifecycle != "" {
+		args = append(args, "--lifecycle", options.Lifecycle)
+	}
+
+	return args
+}
+
+// describeLifecycle says which buildpacks, lifecycle, start command, health
+// check or sizes the flags set, for the plan.
+func describeLifecycle(options AutopilotOptions) string {
+	description := ""
+	if options.Lifecycle != "" {
+		description += fmt.Sprintf(", with the %s lifecycle", options.Lifecycle)
+	}
+	if len(options.Buildpacks) > 0 {
+		description += ", with buildpacks " + strings.Join(options.Buildpacks, ", ")
+	}
+	if options.StartCommand != "" {
+		description += fmt.Sprintf(", started with %q", options.StartCommand)
+	}
+	description += options.HealthCheck.describe()
+	if options.Scale.Instances > 0 {
+		description += fmt.Sprintf(", with %d instances", options.Scale.Instances)
+	}
+	if options.Scale.Memory > 0 {
+		description += fmt.Sprintf(", with %dM of memory", options.Scale.Memory)
+	}
+	if options.Scale.DiskQuota > 0 {
+		description += fmt.Sprintf(", with %dM of disk", options.Scale.DiskQuota)
+	}
+
+	return description
+}
+
+// checkLifecycle makes sure the manifest and flags ask for a lifecycle cf
+// push can stage, before anything is changed.
+func (planner *DeploymentPlanner) checkLifecycle(appName, manifestPath string, options AutopilotOptions) error {
+	manifest, err := ParseManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	app, _ := manifest.Application(appName)
+	_, err = PushLifecycle(app, options)
+	return err
+}
+
+// pushArgValue finds the value of a cf push flag passed on with --push-arg.
+func pushArgValue(args []string, names ...string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if containsString(names, args[i]) {
+			return args[i+1]
+		}
+	}
+
+	return ""
+}
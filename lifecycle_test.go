@@ -0,0 +1,55 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Lifecycles", func() {
+	It("takes the buildpacks from the manifest unless flags override them", func() {
+		app := ManifestApplication{Name: "app", Buildpacks: []string{"nodejs_buildpack"}}
+
+		lifecycle, err := PushLifecycle(app, AutopilotOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lifecycle).To(Equal(Lifecycle{Type: "buildpack", Buildpacks: []string{"nodejs_buildpack"}}))
+
+		lifecycle, err = PushLifecycle(app, AutopilotOptions{Buildpacks: []string{"apm_buildpack", "go_buildpack"}, Lifecycle: "cnb"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lifecycle).To(Equal(Lifecycle{Type: "cnb", Buildpacks: []string{"apm_buildpack", "go_buildpack"}}))
+	})
+
+	It("treats an app with a docker image as a docker app", func() {
+		app := ManifestApplication{Name: "app", Docker: &ManifestDocker{Image: "registry/app:1"}}
+
+		lifecycle, err := PushLifecycle(app, AutopilotOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lifecycle.Type).To(Equal("docker"))
+
+		lifecycle, err = PushLifecycle(ManifestApplication{}, AutopilotOptions{Lifecycle: "docker", PushArgs: []string{"--docker-image", "registry/app:2"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lifecycle.DockerImage).To(Equal("registry/app:2"))
+	})
+
+	It("rejects combinations cf push cannot stage", func() {
+		_, err := PushLifecycle(ManifestApplication{Name: "app", Buildpack: "ruby_buildpack", Buildpacks: []string{"go_buildpack"}}, AutopilotOptions{})
+		Expect(err).To(MatchError("The manifest of app sets both buildpack and buildpacks, use only buildpacks."))
+
+		_, err = PushLifecycle(ManifestApplication{Docker: &ManifestDocker{Image: "registry/app:1"}}, AutopilotOptions{Buildpacks: []string{"go_buildpack"}})
+		Expect(err).To(MatchError("Buildpacks (go_buildpack) cannot be used with the docker lifecycle."))
+
+		_, err = PushLifecycle(ManifestApplication{}, AutopilotOptions{Lifecycle: "docker"})
+		Expect(err).To(MatchError(ContainSubstring("The docker lifecycle needs an image")))
+
+		_, err = PushLifecycle(ManifestApplication{Docker: &ManifestDocker{Image: "registry/app:1"}}, AutopilotOptions{Lifecycle: "cnb", Buildpacks: []string{"go_buildpack"}})
+		Expect(err).To(MatchError("The docker image registry/app:1 cannot be used with the cnb lifecycle."))
+
+		_, err = PushLifecycle(ManifestApplication{}, AutopilotOptions{Lifecycle: "cnb"})
+		Expect(err).To(MatchError("The cnb lifecycle needs at least one buildpack, from the manifest or --buildpack."))
+
+		_, err = PushLifecycle(ManifestApplication{}, AutopilotOptions{Lifecycle: "kpack"})
+		Expect(err).To(MatchError(`Unknown lifecycle "kpack", use one of buildpack, docker, cnb.`))
+	})
+
+})
@@ -0,0 +1,212 @@
+package autopilot
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/concourse/autopilot/capi"
+)
+
+// lockExpiry is how long a deploy lock is honoured after it was last
+// renewed, so that a deploy that died without releasing it does not block the
+// app for long. A running deploy renews its lock every lockRenewal, however
+// long it takes.
+const (
+	lockExpiry  = 10 * time.Minute
+	lockRenewal = time.Minute
+)
+
+const (
+	lockHolderVar  = "AUTOPILOT_LOCK_HOLDER"
+	lockTakenVar   = "AUTOPILOT_LOCK_TAKEN"
+	lockExpiresVar = "AUTOPILOT_LOCK_EXPIRES"
+)
+
+func lockAppName(appName string) string {
+	return fmt.Sprintf("%s-autopilot-lock", appName)
+}
+
+// DeployLock is held for the length of a deploy by creating a stopped,
+// zero-instance marker app. App names are unique within a space, so only one
+// deploy of an app can create it.
+type DeployLock struct {
+	repo   *ApplicationRepo
+	holder string
+	taken  time.Time
+
+	mutex sync.Mutex
+	guid  string
+	stop  chan struct{}
+}
+
+// AcquireLock takes the deploy lock for appName, replacing an expired one.
+// It fails straight away if another deploy holds the lock.
+func (repo *ApplicationRepo) AcquireLock(appName string) (*DeployLock, error) {
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return nil, err
+	}
+
+	holder, err := repo.lockHolder()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		lock := &DeployLock{repo: repo, holder: holder, taken: time.Now()}
+		app, err := api.CreateApp(map[string]interface{}{
+			"name":             lockAppName(appName),
+			"space_guid":       space.Guid,
+			"state":            "STOPPED",
+			"instances":        0,
+			"environment_json": lock.env(lock.taken),
+		})
+		if err == nil {
+			lock.guid = app.Metadata.Guid
+			return lock, nil
+		}
+
+		if !capi.HasErrorCode(err, "CF-AppNameTaken") {
+			return nil, err
+		}
+
+		err = repo.breakExpiredLock(appName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("Could not lock %s for the deploy", appName)
+}
+
+// breakExpiredLock deletes the lock on appName if it has expired, and
+// otherwise explains who holds it.
+func (repo *ApplicationRepo) breakExpiredLock(appName string) error {
+	lock, found, err := repo.findApp(lockAppName(appName))
+	if err != nil || !found {
+		// released since we tried to take it
+		return err
+	}
+
+	entity, err := repo.api.GetAppEntity(lock.Metadata.Guid)
+	if err != nil {
+		return err
+	}
+
+	env, _ := entity["environment_json"].(map[string]interface{})
+	holder, _ := env[lockHolderVar].(string)
+	expiresAt, _ := env[lockExpiresVar].(string)
+
+	expires, err := time.Parse(time.RFC3339, expiresAt)
+	if err == nil && time.Now().Before(expires) {
+		return fmt.Errorf("%s is already being deployed by %s (the lock expires at %s). "+
+			"If that deploy is no longer running, delete the lock with: cf delete %s -f",
+			appName, holder, expiresAt, lockAppName(appName))
+	}
+
+	fmt.Printf("Removing an expired deploy lock on %s held by %s\n", appName, holder)
+	return repo.api.DeleteApp(lock.Metadata.Guid)
+}
+
+// lockHolder describes this deploy for anyone who finds the app locked.
+func (repo *ApplicationRepo) lockHolder() (string, error) {
+	user, err := repo.conn.Username()
+	if err != nil {
+		return "", err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+
+	return fmt.Sprintf("%s on %s", user, host), nil
+}
+
+// env is what the lock app says about the lock when renewed at now.
+func (lock *DeployLock) env(now time.Time) map[string]string {
+	return map[string]string{
+		lockHolderVar:  lock.holder,
+		lockTakenVar:   lock.taken.UTC().Format(time.RFC3339),
+		lockExpiresVar: now.Add(lockExpiry).UTC().Format(time.RFC3339),
+	}
+}
+
+// Renew puts the lock's expiry back to lockExpiry from now.
+func (lock *DeployLock) Renew() error {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+
+	if lock.guid == "" {
+		return nil
+	}
+
+	return lock.repo.api.UpdateApp(lock.guid, map[string]interface{}{"environment_json": lock.env(time.Now())})
+}
+
+// Keep renews the lock every interval until it is released, so that it
+// outlasts the deploy, and its rollback, however long they take. A renewal
+// that fails is tried again at the next interval.
+func (lock *DeployLock) Keep(interval time.Duration) {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+
+	if lock.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	lock.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			err := lock.Renew()
+			if err != nil {
+				warnf("could not renew the deploy lock: %s\n", err)
+			}
+		}
+	}()
+}
+
+// Release stops renewing the lock and removes it. It is safe to call more
+// than once.
+func (lock *DeployLock) Release() error {
+	if lock == nil {
+		return nil
+	}
+
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+
+	if lock.stop != nil {
+		close(lock.stop)
+		lock.stop = nil
+	}
+
+	if lock.guid == "" {
+		return nil
+	}
+
+	err := lock.repo.api.DeleteApp(lock.guid)
+	if err != nil && !capi.IsNotFound(err) {
+		return err
+	}
+
+	lock.guid = ""
+	return nil
+}
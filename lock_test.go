@@ -0,0 +1,161 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("DeployLock", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.UsernameReturns("deployer", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "4"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	createsLock := func(guid string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v2/apps"),
+			func(w http.ResponseWriter, req *http.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				var app map[string]interface{}
+				Expect(json.Unmarshal(body, &app)).To(Succeed())
+				Expect(app).To(HaveKeyWithValue("name", "app-name-autopilot-lock"))
+				Expect(app).To(HaveKeyWithValue("state", "STOPPED"))
+				Expect(app).To(HaveKeyWithValue("instances", BeNumerically("==", 0)))
+				Expect(app["environment_json"]).To(HaveKeyWithValue("AUTOPILOT_LOCK_HOLDER", ContainSubstring("deployer on ")))
+			},
+			ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"`+guid+`"}}`),
+		)
+	}
+
+	nameTaken := ghttp.RespondWith(http.StatusBadRequest, `{"description":"The app name is taken: app-name-autopilot-lock","error_code":"CF-AppNameTaken"}`)
+
+	findsLock := ghttp.CombineHandlers(
+		ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3Aapp-name-autopilot-lock&q=space_guid%3A4"),
+		ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"old-lock"}}]}`),
+	)
+
+	lockEntity := func(expires string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/apps/old-lock"),
+			ghttp.RespondWith(http.StatusOK, `{"entity":{"environment_json":{"AUTOPILOT_LOCK_HOLDER":"someone on ci","AUTOPILOT_LOCK_EXPIRES":"`+expires+`"}}}`),
+		)
+	}
+
+	It("takes the lock and releases it", func() {
+		api.AppendHandlers(
+			createsLock("lock-guid"),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/v2/apps/lock-guid"),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			),
+		)
+
+		lock, err := repo.AcquireLock("app-name")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Release()).To(Succeed())
+		Expect(lock.Release()).To(Succeed())
+		Expect(api.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("fails fast while another deploy holds the lock", func() {
+		api.AppendHandlers(
+			nameTaken,
+			findsLock,
+			lockEntity("2999-01-01T00:00:00Z"),
+		)
+
+		_, err := repo.AcquireLock("app-name")
+		Expect(err).To(MatchError("app-name is already being deployed by someone on ci (the lock expires at 2999-01-01T00:00:00Z). " +
+			"If that deploy is no longer running, delete the lock with: cf delete app-name-autopilot-lock -f"))
+	})
+
+	It("replaces an expired lock", func() {
+		api.AppendHandlers(
+			nameTaken,
+			findsLock,
+			lockEntity("2000-01-01T00:00:00Z"),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/v2/apps/old-lock"),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			),
+			createsLock("lock-guid"),
+		)
+
+		_, err := repo.AcquireLock("app-name")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("renews the lock until it is released", func() {
+		renewed := make(chan map[string]interface{}, 10)
+		api.AppendHandlers(createsLock("lock-guid"))
+		api.RouteToHandler("PUT", "/v2/apps/lock-guid", func(w http.ResponseWriter, req *http.Request) {
+			var app map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&app)).To(Succeed())
+			renewed <- app["environment_json"].(map[string]interface{})
+			w.WriteHeader(http.StatusCreated)
+		})
+		api.RouteToHandler("DELETE", "/v2/apps/lock-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+
+		lock, err := repo.AcquireLock("app-name")
+		Expect(err).ToNot(HaveOccurred())
+		lock.Keep(time.Millisecond)
+
+		var env map[string]interface{}
+		Eventually(renewed).Should(Receive(&env))
+		Expect(env).To(HaveKeyWithValue("AUTOPILOT_LOCK_HOLDER", ContainSubstring("deployer on ")))
+		Expect(env).To(HaveKey("AUTOPILOT_LOCK_TAKEN"))
+		expires, err := time.Parse(time.RFC3339, env["AUTOPILOT_LOCK_EXPIRES"].(string))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expires).To(BeTemporally("~", time.Now().Add(10*time.Minute), time.Minute))
+
+		Expect(lock.Release()).To(Succeed())
+		for len(renewed) > 0 {
+			<-renewed
+		}
+		Consistently(renewed, 20*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("does not mind a lock that was already removed", func() {
+		api.AppendHandlers(
+			createsLock("lock-guid"),
+			ghttp.RespondWith(http.StatusNotFound, `{"description":"The app could not be found","error_code":"CF-AppNotFound"}`),
+		)
+
+		lock, err := repo.AcquireLock("app-name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lock.Release()).To(Succeed())
+	})
+})
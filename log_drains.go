@@ -0,0 +1,87 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogDrains lists the services bound to the app that drain its logs, such as
+// user-provided services created with cf cups -l.
+func (repo *ApplicationRepo) LogDrains(appName string) ([]string, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := api.AppServiceBindings(app.Metadata.Guid)
+	if err != nil {
+		return nil, err
+	}
+
+	drainGuids := map[string]bool{}
+	for _, binding := range bindings {
+		if binding.Entity.SyslogDrainURL != "" {
+			drainGuids[binding.Entity.ServiceInstanceGuid] = true
+		}
+	}
+	if len(drainGuids) == 0 {
+		return nil, nil
+	}
+
+	summary, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	drains := []string{}
+	for _, service := range summary.Services {
+		if drainGuids[service.Guid] {
+			drains = append(drains, service.Name)
+		}
+	}
+	return drains, nil
+}
+
+// BindService binds the service to the app, as cf bind-service does.
+func (repo *ApplicationRepo) BindService(appName, serviceName string) error {
+	return repo.cliCommand("bind-service", appName, serviceName)
+}
+
+// unboundLogDrains are the log drains of the live app the manifest does not
+// bind, which the new app would otherwise start without.
+func unboundLogDrains(drains []string, manifestApp ManifestApplication) []string {
+	bound := map[string]bool{}
+	for _, name := range manifestApp.Services {
+		bound[name] = true
+	}
+
+	unbound := []string{}
+	for _, name := range drains {
+		if !bound[name] {
+			unbound = append(unbound, name)
+		}
+	}
+	return unbound
+}
+
+// bindLogDrains binds the log drains to the app before it starts, so that
+// no log line goes unforwarded.
+func (planner *DeploymentPlanner) bindLogDrains(appName string, drains []string) error {
+	if len(drains) == 0 {
+		return nil
+	}
+
+	planner.Logger.Printf("Binding log drains %s to the new version of the app\n", strings.Join(drains, ", "))
+	for _, name := range drains {
+		err := planner.Repo.BindService(appName, name)
+		if err != nil {
+			return fmt.Errorf("Could not bind log drain %s to %s, so its logs would no longer be forwarded: %s. Add it to the manifest's services, or fix the service, and deploy again.", name, appName, err)
+		}
+	}
+	return nil
+}
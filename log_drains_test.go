@@ -0,0 +1,70 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("LogDrains", func() {
+	var (
+		api     *ghttp.Server
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+		}, nil)
+		cliConn.GetAppReturns(plugin_models.GetAppModel{
+			Services: []plugin_models.GetApp_ServiceSummary{
+				{Guid: "papertrail-guid", Name: "papertrail"},
+				{Guid: "db-guid", Name: "db"},
+			},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("lists the services bound to the app that drain its logs", func() {
+		api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app"}}]}`))
+		api.RouteToHandler("GET", "/v2/apps/app-guid/service_bindings", ghttp.RespondWith(http.StatusOK, `{"resources":[
+			{"entity":{"service_instance_guid":"db-guid","syslog_drain_url":null}},
+			{"entity":{"service_instance_guid":"papertrail-guid","syslog_drain_url":"syslog-tls://logs.example.com:6514"}}
+		]}`))
+
+		drains, err := repo.LogDrains("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drains).To(Equal([]string{"papertrail"}))
+	})
+
+	It("finds none for an app that does not exist", func() {
+		api.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+		drains, err := repo.LogDrains("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drains).To(BeEmpty())
+	})
+
+	It("binds services with cf bind-service", func() {
+		Expect(repo.BindService("app", "papertrail")).To(Succeed())
+		Expect(cliConn.CliCommandArgsForCall(0)).To(Equal([]string{"bind-service", "app", "papertrail"}))
+	})
+})
@@ -0,0 +1,127 @@
+package autopilot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+// ManagedMarker is what autopilot notes on the copies of an app it makes.
+type ManagedMarker = repository.ManagedMarker
+
+// The annotations that mark the copies autopilot makes under the names it
+// derives, such as app-venerable.
+const (
+	managedAnnotation          = "autopilot/managed"
+	managedOriginalAnnotation  = "autopilot/original-name"
+	managedCreatedAtAnnotation = "autopilot/managed-at"
+)
+
+// MarkManaged notes on the app that autopilot made it, from which app and
+// when.
+func (repo *ApplicationRepo) MarkManaged(appName string, marker ManagedMarker) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	return api.UpdateAppAnnotations(app.Metadata.Guid, map[string]string{
+		managedAnnotation:          "true",
+		managedOriginalAnnotation:  marker.OriginalName,
+		managedCreatedAtAnnotation: marker.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// ManagedMarker reads what autopilot noted on the app when it made it. The
+// bool is false if autopilot did not make it.
+func (repo *ApplicationRepo) ManagedMarker(appName string) (ManagedMarker, bool, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil || !found {
+		return ManagedMarker{}, false, err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return ManagedMarker{}, false, err
+	}
+
+	annotations, err := api.AppAnnotations(app.Metadata.Guid)
+	if err != nil {
+		return ManagedMarker{}, false, err
+	}
+
+	if annotations[managedAnnotation] != "true" {
+		return ManagedMarker{}, false, nil
+	}
+
+	// a missing or garbled time is no reason to distrust the marker
+	createdAt, _ := time.Parse(time.RFC3339, annotations[managedCreatedAtAnnotation])
+	return ManagedMarker{OriginalName: annotations[managedOriginalAnnotation], CreatedAt: createdAt}, true, nil
+}
+
+// CheckNameCollisions refuses to go on when an app has one of the names
+// autopilot derives for the copies of appName, but autopilot did not make
+// it, as the deploy would rename or delete it. With force it only warns, for
+// copies left by versions of autopilot that did not mark them.
+func (planner *DeploymentPlanner) CheckNameCollisions(appName string, force bool, names ...string) error {
+	for _, name := range names {
+		exists, err := planner.Repo.DoesAppExist(name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		err = planner.verifyManaged(appName, name, force)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyManaged checks, before it is renamed over the live app or deleted,
+// that autopilot made copyName as a copy of appName.
+func (planner *DeploymentPlanner) verifyManaged(appName, copyName string, force bool) error {
+	marker, managed, err := planner.Repo.ManagedMarker(copyName)
+	if err != nil {
+		return err
+	}
+
+	var problem string
+	if !managed {
+		problem = fmt.Sprintf("App %s already exists and was not made by autopilot", copyName)
+	} else if marker.OriginalName != "" && marker.OriginalName != appName {
+		problem = fmt.Sprintf("App %s was made by autopilot as a copy of %s, not of %s", copyName, marker.OriginalName, appName)
+	} else {
+		return nil
+	}
+
+	if force {
+		planner.Logger.Printf("Warning: %s, but is treated as a copy of %s because of --force-name-collision.\n", problem, appName)
+		return nil
+	}
+
+	return fmt.Errorf("%s, which would rename or delete it. Rename that app, or use --force-name-collision if it is a copy left by an earlier deploy.", problem)
+}
+
+// markManaged marks a copy of appName autopilot has just made. Failing to is
+// only a warning, since the deploy itself is unaffected; the next one will
+// ask for --force-name-collision.
+func (planner *DeploymentPlanner) markManaged(copyName, appName string) {
+	err := planner.Repo.MarkManaged(copyName, ManagedMarker{OriginalName: appName, CreatedAt: planner.Clock.Now().UTC()})
+	if err != nil {
+		planner.Logger.Printf("Warning: could not mark %s as made by autopilot: %s\n", copyName, err)
+	}
+}
@@ -0,0 +1,176 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Name collisions", func() {
+	var (
+		repo         *recordingRepo
+		logger       *recordingLogger
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	BeforeEach(func() {
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manifest.WriteString("applications:\n- name: app\n")
+		Expect(err).ToNot(HaveOccurred())
+		manifest.Close()
+		manifestPath = manifest.Name()
+
+		repo = newRecordingRepo()
+		logger = &recordingLogger{}
+		planner = &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: &fakeClock{}, Logger: logger}
+	})
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	execute := func(actions []rewind.Action) error {
+		return rewind.Actions{Actions: actions}.Execute()
+	}
+
+	It("ignores names no app has", func() {
+		Expect(planner.CheckNameCollisions("app", false, "app-venerable")).To(Succeed())
+		Expect(repo.calls).ToNot(ContainElement("ManagedMarker app-venerable"))
+	})
+
+	It("accepts copies autopilot made", func() {
+		repo.existing["app-venerable"] = true
+
+		Expect(planner.CheckNameCollisions("app", false, "app-venerable")).To(Succeed())
+	})
+
+	It("refuses apps autopilot did not make", func() {
+		repo.existing["app-venerable"] = true
+		repo.unmanaged["app-venerable"] = true
+
+		err := planner.CheckNameCollisions("app", false, "app-venerable")
+		Expect(err).To(MatchError(ContainSubstring("App app-venerable already exists and was not made by autopilot")))
+		Expect(err).To(MatchError(ContainSubstring("--force-name-collision")))
+	})
+
+	It("only warns about them when forced", func() {
+		repo.existing["app-venerable"] = true
+		repo.unmanaged["app-venerable"] = true
+
+		Expect(planner.CheckNameCollisions("app", true, "app-venerable")).To(Succeed())
+		Expect(logger.messages).To(ContainElement(ContainSubstring("Warning: App app-venerable already exists and was not made by autopilot, but is treated as a copy of app")))
+	})
+
+	It("refuses copies autopilot made of another app", func() {
+		repo.existing["app-venerable"] = true
+		repo.markers["app-venerable"] = ManagedMarker{OriginalName: "other"}
+
+		err := planner.CheckNameCollisions("app", false, "app-venerable")
+		Expect(err).To(MatchError(ContainSubstring("App app-venerable was made by autopilot as a copy of other, not of app")))
+	})
+
+	It("checks the marker again before deleting a leftover venerable app", func() {
+		repo.existing["app-venerable"] = true
+		repo.unmanaged["app-venerable"] = true
+
+		err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))
+		Expect(err).To(MatchError(ContainSubstring("App app-venerable already exists and was not made by autopilot")))
+		Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+		Expect(repo.calls).ToNot(ContainElement(ContainSubstring("RenameApplication")))
+	})
+
+	It("checks the marker before rolling back to the venerable app", func() {
+		repo.unmanaged["app-venerable"] = true
+
+		err := execute(planner.RollbackActions("app", RollbackOptions{}))
+		Expect(err).To(MatchError(ContainSubstring("App app-venerable already exists and was not made by autopilot")))
+		Expect(repo.calls).ToNot(ContainElement(ContainSubstring("RenameApplication")))
+
+		repo.calls = nil
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{ForceNameCollision: true}))).To(Succeed())
+		Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+	})
+
+	It("marks copies with the app they were made of", func() {
+		Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))).To(Succeed())
+		Expect(repo.calls).To(ContainElement("MarkManaged app-venerable app"))
+	})
+
+	It("refuses to push over an app that has the venerable name", func() {
+		repo.existing["app"] = true
+		repo.existing["app-venerable"] = true
+		repo.unmanaged["app-venerable"] = true
+
+		_, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{})
+		Expect(err).To(MatchError(ContainSubstring("App app-venerable already exists")))
+
+		_, err = planner.PushActions("app", manifestPath, "", AutopilotOptions{ForceNameCollision: true})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("only warns when the venerable app cannot be marked", func() {
+		repo.failures["MarkManaged app-venerable app"] = errors.New("forbidden")
+
+		Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))).To(Succeed())
+		Expect(logger.messages).To(ContainElement(ContainSubstring("Warning: could not mark app-venerable as made by autopilot: forbidden")))
+	})
+
+	Describe("against a Cloud Controller", func() {
+		var (
+			server  *fakecc.Server
+			appRepo *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			server = fakecc.NewServer()
+			appRepo = NewApplicationRepo(server.CLI())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("marks apps with annotations", func() {
+			server.AddApp("app-venerable")
+			createdAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+			_, managed, err := appRepo.ManagedMarker("app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managed).To(BeFalse())
+
+			Expect(appRepo.MarkManaged("app-venerable", ManagedMarker{OriginalName: "app", CreatedAt: createdAt})).To(Succeed())
+
+			marker, managed, err := appRepo.ManagedMarker("app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managed).To(BeTrue())
+			Expect(marker).To(Equal(ManagedMarker{OriginalName: "app", CreatedAt: createdAt}))
+
+			app, _ := server.App("app-venerable")
+			Expect(app.Annotations).To(Equal(map[string]string{
+				"autopilot/managed":       "true",
+				"autopilot/original-name": "app",
+				"autopilot/managed-at":    "2026-03-01T09:30:00Z",
+			}))
+		})
+
+		It("trusts markers without an original name or time", func() {
+			server.AddApp("app-venerable")
+			server.Annotate("app-venerable", map[string]string{"autopilot/managed": "true"})
+
+			marker, managed, err := appRepo.ManagedMarker("app-venerable")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managed).To(BeTrue())
+			Expect(marker.OriginalName).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,217 @@
+package autopilot
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Manifest struct {
+	Applications []ManifestApplication `yaml:"applications"`
+}
+
+type ManifestApplication struct {
+	Name        string          `yaml:"name"`
+	Host        string          `yaml:"host"`
+	Hosts       []string        `yaml:"hosts"`
+	Domain      string          `yaml:"domain"`
+	Domains     []string        `yaml:"domains"`
+	Routes      []ManifestRoute `yaml:"routes"`
+	NoRoute     bool            `yaml:"no-route"`
+	RandomRoute bool            `yaml:"random-route"`
+
+	Memory    string                 `yaml:"memory"`
+	Instances *int                   `yaml:"instances"`
+	Env       map[string]interface{} `yaml:"env"`
+	Services  ManifestServices       `yaml:"services"`
+
+	Buildpack  string          `yaml:"buildpack"`
+	Buildpacks []string        `yaml:"buildpacks"`
+	Docker     *ManifestDocker `yaml:"docker"`
+	Lifecycle  string          `yaml:"lifecycle"`
+
+	// Path is the app's files, relative to the manifest, when -p is not given.
+	Path string `yaml:"path"`
+}
+
+type ManifestRoute struct {
+	Route string `yaml:"route"`
+}
+
+// ManifestServices lists the names of the services to bind, which manifests
+// give either as plain names or as maps with a name and parameters.
+type ManifestServices []string
+
+func (services *ManifestServices) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var entries []interface{}
+	err := unmarshal(&entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case string:
+			*services = append(*services, entry)
+		case map[interface{}]interface{}:
+			if name, ok := entry["name"].(string); ok {
+				*services = append(*services, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// StdinManifest is the -f path that reads the manifest from stdin, for
+// pipelines that template manifests in memory.
+const StdinManifest = "-"
+
+// WriteStdinManifest copies a manifest piped in on stdin to a temporary file
+// only the user can read, since cf push needs a path. Secrets in it are then
+// only on disk for as long as the push takes: the returned func removes it.
+func WriteStdinManifest(stdin io.Reader) (string, func(), error) {
+	contents, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(strings.TrimSpace(string(contents))) == 0 {
+		return "", nil, errors.New("-f - reads the manifest from stdin, but nothing was piped in")
+	}
+
+	file, err := ioutil.TempFile("", "autopilot-manifest-*.yml")
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() { os.Remove(file.Name()) }
+
+	_, err = file.Write(contents)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+
+	return file.Name(), remove, nil
+}
+
+// manifestPathFor is the manifest to push with for the -f path. A manifest
+// from stdin is written to a temporary file, which is removed on exit.
+func manifestPathFor(path string) (string, error) {
+	if path != StdinManifest {
+		return path, nil
+	}
+
+	tempPath, remove, err := WriteStdinManifest(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+
+	atExit(remove)
+	return tempPath, nil
+}
+
+func ParseManifest(manifestPath string) (Manifest, error) {
+	contents, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	var manifest Manifest
+	err = yaml.Unmarshal(contents, &manifest)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// Application finds the manifest entry for appName. A manifest with a single
+// application is assumed to describe the app being pushed, as cf push does
+// when given an app name on the command line.
+func (manifest Manifest) Application(appName string) (ManifestApplication, bool) {
+	for _, app := range manifest.Applications {
+		if app.Name == appName {
+			return app, true
+		}
+	}
+
+	if len(manifest.Applications) == 1 {
+		return manifest.Applications[0], true
+	}
+
+	return ManifestApplication{}, false
+}
+
+// RouteURLs lists the routes the manifest declares, as "host.domain/path".
+// Domains declared without hosts get the app's name as their host, and hosts
+// declared without a domain depend on the foundation's default domain and are
+// left out.
+func (app ManifestApplication) RouteURLs() []string {
+	if app.NoRoute {
+		return nil
+	}
+
+	urls := []string{}
+	for _, route := range app.Routes {
+		urls = append(urls, route.Route)
+	}
+
+	hosts := app.Hosts
+	if app.Host != "" {
+		hosts = append([]string{app.Host}, hosts...)
+	}
+
+	domains := app.Domains
+	if app.Domain != "" {
+		domains = append([]string{app.Domain}, domains...)
+	}
+
+	if len(hosts) == 0 && app.Name != "" {
+		hosts = []string{app.Name}
+	}
+
+	for _, domain := range domains {
+		for _, host := range hosts {
+			urls = append(urls, host+"."+domain)
+		}
+	}
+
+	return urls
+}
+
+// IntendedRoutes lists the routes the app should end up with once pushed, as
+// "host.domain/path" like AppRoutes reports them. The bool is false when the
+// manifest leaves the routes to the foundation, through its default domain or
+// a random route, so they cannot be known in advance.
+func (app ManifestApplication) IntendedRoutes() ([]string, bool) {
+	if app.NoRoute {
+		return []string{}, true
+	}
+
+	if app.RandomRoute {
+		return nil, false
+	}
+
+	if len(app.Routes) == 0 && app.Domain == "" && len(app.Domains) == 0 {
+		return nil, false
+	}
+
+	routes := []string{}
+	seen := map[string]bool{}
+	for _, route := range app.RouteURLs() {
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, true
+}
@@ -0,0 +1,175 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Manifest", func() {
+	var manifestPath string
+
+	writeManifest := func(contents string) {
+		file, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+
+		manifestPath = file.Name()
+	}
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	It("reads the v3 lifecycle settings", func() {
+		writeManifest(`---
+applications:
+- name: app-name
+  lifecycle: cnb
+  buildpacks:
+  - paketo-buildpacks/java
+  docker:
+    image: registry/app:1
+`)
+
+		manifest, err := ParseManifest(manifestPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Applications[0].Lifecycle).To(Equal("cnb"))
+		Expect(manifest.Applications[0].Buildpacks).To(Equal([]string{"paketo-buildpacks/java"}))
+		Expect(manifest.Applications[0].Docker.Image).To(Equal("registry/app:1"))
+	})
+
+	It("finds the application by name", func() {
+		writeManifest(`---
+applications:
+- name: other-app
+- name: app-name
+  routes:
+  - route: app.example.com
+  - route: app.example.com/path
+`)
+
+		manifest, err := ParseManifest(manifestPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		app, found := manifest.Application("app-name")
+		Expect(found).To(BeTrue())
+		Expect(app.RouteURLs()).To(Equal([]string{"app.example.com", "app.example.com/path"}))
+	})
+
+	It("reads the app's configuration, with services as names or maps", func() {
+		writeManifest(`---
+applications:
+- name: app-name
+  memory: 1G
+  instances: 3
+  env:
+    DEBUG: false
+  services:
+  - database
+  - name: cache
+    parameters:
+      size: small
+`)
+
+		manifest, err := ParseManifest(manifestPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		app, _ := manifest.Application("app-name")
+		Expect(app.Memory).To(Equal("1G"))
+		Expect(*app.Instances).To(Equal(3))
+		Expect(app.Env).To(HaveKeyWithValue("DEBUG", false))
+		Expect(app.Services).To(Equal(ManifestServices{"database", "cache"}))
+	})
+
+	It("assumes a single application is the one being pushed", func() {
+		writeManifest(`---
+applications:
+- name: renamed-app
+  host: app
+  domains:
+  - example.com
+  - example.org
+`)
+
+		manifest, err := ParseManifest(manifestPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		app, found := manifest.Application("app-name")
+		Expect(found).To(BeTrue())
+		Expect(app.RouteURLs()).To(Equal([]string{"app.example.com", "app.example.org"}))
+	})
+
+	It("uses the app name as the host for domains without hosts", func() {
+		app := ManifestApplication{Name: "app-name", Domain: "example.com"}
+		Expect(app.RouteURLs()).To(Equal([]string{"app-name.example.com"}))
+	})
+
+	It("has no routes when no-route is set", func() {
+		app := ManifestApplication{NoRoute: true, Routes: []ManifestRoute{{Route: "app.example.com"}}}
+		Expect(app.RouteURLs()).To(BeEmpty())
+	})
+
+	Describe("IntendedRoutes", func() {
+		It("lists the declared routes with their paths, once each", func() {
+			app := ManifestApplication{Routes: []ManifestRoute{{Route: "app.example.com"}, {Route: "app.example.com/path"}, {Route: "www.example.org"}, {Route: "app.example.com"}}}
+
+			routes, known := app.IntendedRoutes()
+			Expect(known).To(BeTrue())
+			Expect(routes).To(Equal([]string{"app.example.com", "app.example.com/path", "www.example.org"}))
+		})
+
+		It("is empty when no-route is set", func() {
+			routes, known := ManifestApplication{NoRoute: true}.IntendedRoutes()
+			Expect(known).To(BeTrue())
+			Expect(routes).To(BeEmpty())
+		})
+
+		It("is unknown when the routes are left to the foundation", func() {
+			_, known := ManifestApplication{Name: "app-name", Host: "app"}.IntendedRoutes()
+			Expect(known).To(BeFalse())
+
+			_, known = ManifestApplication{Name: "app-name", RandomRoute: true, Domain: "example.com"}.IntendedRoutes()
+			Expect(known).To(BeFalse())
+		})
+	})
+
+	It("returns an error for an invalid manifest", func() {
+		writeManifest("applications: [")
+
+		_, err := ParseManifest(manifestPath)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("WriteStdinManifest", func() {
+	It("copies the manifest to a file only the user can read, until it is removed", func() {
+		path, remove, err := WriteStdinManifest(strings.NewReader("applications:\n- name: app\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("applications:\n- name: app\n"))
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		remove()
+		_, err = os.Stat(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("fails when nothing was piped in", func() {
+		_, _, err := WriteStdinManifest(strings.NewReader("\n"))
+		Expect(err).To(MatchError("-f - reads the manifest from stdin, but nothing was piped in"))
+	})
+})
@@ -0,0 +1,104 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/concourse/autopilot/naming"
+)
+
+// NamingStrategy names the copies of an app made while it is deployed, and
+// its test route. The built in ones are below; embedders may give the
+// planner their own.
+type NamingStrategy = naming.Strategy
+
+// The built in naming strategies, picked with --naming.
+type (
+	SuffixNaming    = naming.Suffix
+	TimestampNaming = naming.Timestamp
+	GitSHANaming    = naming.GitSHA
+)
+
+var (
+	timestampSuffix = regexp.MustCompile(`-\d{8}T\d{4}$`)
+	gitSHASuffix    = regexp.MustCompile(`-before-[0-9a-f]{7,40}$`)
+)
+
+// ParseNaming picks the naming strategy given with --naming: suffix, the
+// default, timestamp or git-sha. The commit of a git-sha naming is only
+// filled in by namingFor, once the revision being pushed is known.
+func ParseNaming(name string, now time.Time) (NamingStrategy, error) {
+	switch name {
+	case "", "suffix":
+		return SuffixNaming{}, nil
+	case "timestamp":
+		return TimestampNaming{At: now}, nil
+	case "git-sha":
+		return GitSHANaming{}, nil
+	}
+
+	return nil, fmt.Errorf("--naming should be suffix, timestamp or git-sha, not %q", name)
+}
+
+// namingFor gives a git-sha naming the revision being pushed.
+func namingFor(strategy NamingStrategy, revision string) (NamingStrategy, error) {
+	if _, gitSHA := strategy.(GitSHANaming); !gitSHA {
+		return strategy, nil
+	}
+
+	if revision == "" {
+		return nil, errors.New("--naming git-sha needs the commit being pushed: push from a git checkout, or give --revision")
+	}
+
+	return GitSHANaming{SHA: revision}, nil
+}
+
+// isCopyName reports whether name is one autopilot gives an old version of
+// appName, with any of the built in naming strategies.
+func isCopyName(appName, name string) bool {
+	if name == venerableAppName(appName) {
+		return true
+	}
+
+	return hasCopySuffix(appName, name, timestampSuffix) || hasCopySuffix(appName, name, gitSHASuffix)
+}
+
+func hasCopySuffix(appName, name string, suffixPattern *regexp.Regexp) bool {
+	suffix := suffixPattern.FindString(name)
+	return suffix != "" && name[:len(name)-len(suffix)] == appName
+}
+
+// LatestCopy finds the most recent old version of the app kept with
+// timestamp naming, for a rollback to go back to. Only copies autopilot marked
+// as made from the app are considered.
+func (planner *DeploymentPlanner) LatestCopy(appName string) (string, error) {
+	names, err := planner.Repo.AppNames()
+	if err != nil {
+		return "", err
+	}
+
+	copies := []string{}
+	for _, name := range names {
+		if hasCopySuffix(appName, name, timestampSuffix) {
+			copies = append(copies, name)
+		}
+	}
+	// the timestamps sort by time, so the newest is last
+	sort.Strings(copies)
+
+	for i := len(copies) - 1; i >= 0; i-- {
+		marker, managed, err := planner.Repo.ManagedMarker(copies[i])
+		if err != nil {
+			return "", err
+		}
+		if managed && (marker.OriginalName == "" || marker.OriginalName == appName) {
+			return copies[i], nil
+		}
+	}
+
+	return "", fmt.Errorf("No earlier version of %s named %s-<time> was found, cannot rollback. Make sure you push with the "+
+		"--keep-existing-app flag to leave the old version behind.", appName, appName)
+}
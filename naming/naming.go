@@ -0,0 +1,62 @@
+// Package naming names the copies of an app autopilot makes while deploying
+// it, and the temporary route it checks a new version on. Tools that embed
+// the planner can give it their own Strategy to follow an organisation's
+// naming policy.
+package naming
+
+import (
+	"fmt"
+	"time"
+)
+
+// Strategy names the copies of an app made while it is deployed.
+type Strategy interface {
+	// VenerableName is the old version of the app, once the new one is live.
+	VenerableName(appName string) string
+	// RollbackName is the live app while it is being rolled back.
+	RollbackName(appName string) string
+	// ScaledName is the clone a scale swaps in for the app.
+	ScaledName(appName string) string
+	// TempRouteName is the host of the test route picked for a new version
+	// of the app.
+	TempRouteName(appName string) string
+}
+
+// Suffix adds -venerable, -rollback and -scaled to the app's name, and checks
+// new versions on <app>-verify.
+type Suffix struct{}
+
+func (Suffix) VenerableName(appName string) string { return appName + "-venerable" }
+func (Suffix) RollbackName(appName string) string  { return appName + "-rollback" }
+func (Suffix) ScaledName(appName string) string    { return appName + "-scaled" }
+func (Suffix) TempRouteName(appName string) string { return appName + "-verify" }
+
+// TimestampFormat is the time in a Timestamp copy's name, to the minute, e.g.
+// app-20240607T1212.
+const TimestampFormat = "20060102T1504"
+
+// Timestamp names the old version of an app after the time it was replaced,
+// so several can be kept side by side and cf apps shows when each was live
+// until. The rest are named as with Suffix.
+type Timestamp struct {
+	Suffix
+	// At is the time of the deploy, fixed so every action agrees on the name.
+	At time.Time
+}
+
+func (naming Timestamp) VenerableName(appName string) string {
+	return appName + "-" + naming.At.UTC().Format(TimestampFormat)
+}
+
+// GitSHA names the old version of an app after the git commit that replaced
+// it, e.g. app-before-1a2b3c4, so a kept copy can be matched to the deploy
+// that retired it. The rest are named as with Suffix.
+type GitSHA struct {
+	Suffix
+	// SHA is the commit being pushed.
+	SHA string
+}
+
+func (naming GitSHA) VenerableName(appName string) string {
+	return fmt.Sprintf("%s-before-%s", appName, naming.SHA)
+}
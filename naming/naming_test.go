@@ -0,0 +1,47 @@
+package naming_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/autopilot/naming"
+)
+
+var _ = Describe("Strategies", func() {
+	It("adds suffixes to the app's name", func() {
+		strategy := naming.Suffix{}
+		Expect(strategy.VenerableName("app")).To(Equal("app-venerable"))
+		Expect(strategy.RollbackName("app")).To(Equal("app-rollback"))
+		Expect(strategy.ScaledName("app")).To(Equal("app-scaled"))
+		Expect(strategy.TempRouteName("app")).To(Equal("app-verify"))
+	})
+
+	It("names old versions after the time they were replaced, in UTC", func() {
+		at := time.Date(2024, 6, 7, 14, 12, 30, 0, time.FixedZone("CEST", 2*3600))
+		strategy := naming.Timestamp{At: at}
+		Expect(strategy.VenerableName("app")).To(Equal("app-20240607T1212"))
+		Expect(strategy.RollbackName("app")).To(Equal("app-rollback"))
+		Expect(strategy.TempRouteName("app")).To(Equal("app-verify"))
+	})
+
+	It("names old versions after the commit that replaced them", func() {
+		strategy := naming.GitSHA{SHA: "1a2b3c4"}
+		Expect(strategy.VenerableName("app")).To(Equal("app-before-1a2b3c4"))
+		Expect(strategy.ScaledName("app")).To(Equal("app-scaled"))
+	})
+
+	It("can be replaced by an embedder's own", func() {
+		var strategy naming.Strategy = prefixNaming{}
+		Expect(strategy.VenerableName("app")).To(Equal("old-app"))
+		Expect(strategy.TempRouteName("app")).To(Equal("verify-app"))
+	})
+})
+
+type prefixNaming struct{}
+
+func (prefixNaming) VenerableName(appName string) string { return "old-" + appName }
+func (prefixNaming) RollbackName(appName string) string  { return "rollback-" + appName }
+func (prefixNaming) ScaledName(appName string) string    { return "scaled-" + appName }
+func (prefixNaming) TempRouteName(appName string) string { return "verify-" + appName }
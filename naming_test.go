@@ -0,0 +1,60 @@
+package autopilot_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Naming", func() {
+	at := time.Date(2024, 6, 7, 12, 12, 30, 0, time.UTC)
+
+	It("parses the --naming strategies", func() {
+		Expect(ParseNaming("", at)).To(Equal(SuffixNaming{}))
+		Expect(ParseNaming("suffix", at)).To(Equal(SuffixNaming{}))
+		Expect(ParseNaming("timestamp", at)).To(Equal(TimestampNaming{At: at}))
+		Expect(ParseNaming("git-sha", at)).To(Equal(GitSHANaming{}))
+
+		_, err := ParseNaming("random", at)
+		Expect(err).To(MatchError(`--naming should be suffix, timestamp or git-sha, not "random"`))
+	})
+
+	It("names old versions after the time they were replaced", func() {
+		naming := TimestampNaming{At: at.In(time.FixedZone("CEST", 2*3600))}
+		Expect(naming.VenerableName("app")).To(Equal("app-20240607T1212"))
+		Expect(naming.RollbackName("app")).To(Equal("app-rollback"))
+		Expect(naming.ScaledName("app")).To(Equal("app-scaled"))
+	})
+
+	Describe("LatestCopy", func() {
+		var (
+			repo    *recordingRepo
+			planner *DeploymentPlanner
+		)
+
+		BeforeEach(func() {
+			repo = newRecordingRepo()
+			planner = &DeploymentPlanner{Repo: repo, Naming: TimestampNaming{At: at}, Clock: &fakeClock{}, Logger: discardLogger{}}
+		})
+
+		It("finds the newest copy autopilot made of the app", func() {
+			for _, name := range []string{"app", "app-20240501T0900", "app-20240607T1000", "app-20240607T1100", "app-venerable", "app-before-1a2b3c4", "app-2-20240701T0900", "other-20240801T0900"} {
+				repo.existing[name] = true
+			}
+			repo.unmanaged["app-20240607T1100"] = true
+
+			Expect(planner.LatestCopy("app")).To(Equal("app-20240607T1000"))
+		})
+
+		It("skips copies made of another app", func() {
+			repo.existing["app-20240607T1000"] = true
+			repo.markers["app-20240607T1000"] = ManagedMarker{OriginalName: "other"}
+
+			_, err := planner.LatestCopy("app")
+			Expect(err).To(MatchError(ContainSubstring("No earlier version of app named app-<time> was found")))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+// Package network says where autopilot may send requests besides the Cloud
+// Controller the cf CLI targets and its UAA. Each other destination serves a
+// feature a deploy can do without, so a Policy for an air-gapped foundation
+// can rule them all out before a deploy starts.
+package network
+
+import "fmt"
+
+// Destination is somewhere autopilot may send requests besides the Cloud
+// Controller and its UAA.
+type Destination string
+
+// The destinations outside the Cloud Controller autopilot may reach.
+const (
+	// UpdateCheck asks GitHub for the latest release, with --check-update.
+	UpdateCheck Destination = "GitHub, to check for a newer release"
+	// Telemetry exports the deploy's spans and metrics to
+	// OTEL_EXPORTER_OTLP_ENDPOINT.
+	Telemetry Destination = "the OTLP endpoint, to export telemetry"
+	// AppRoutes requests the app's routes, to check, probe or warm up the
+	// new version.
+	AppRoutes Destination = "the app's routes, to check the new version"
+	// StatusURL asks a deploy to abort through its status endpoint.
+	StatusURL Destination = "a deploy's status endpoint, to abort it"
+	// SSHProxy runs a command in the new version through the foundation's
+	// SSH proxy, with --verify-ssh.
+	SSHProxy Destination = "the SSH proxy, to run a command in the new version"
+	// PolicyEndpoint asks the config file's deploy policy whether a deploy
+	// may go ahead.
+	PolicyEndpoint Destination = "the deploy policy endpoint, to ask whether the deploy may go ahead"
+)
+
+// Need is a flag and the destination it needs requests sent to.
+type Need struct {
+	Flag        string
+	Destination Destination
+}
+
+// Policy says where autopilot may send requests. The zero value allows every
+// destination; an offline policy allows none of them, only the Cloud
+// Controller and its UAA.
+type Policy struct {
+	Offline bool
+}
+
+// Allows says whether requests may be sent to the destination.
+func (policy Policy) Allows(destination Destination) bool {
+	return !policy.Offline
+}
+
+// Check fails when a flag needs a destination the policy does not allow.
+func (policy Policy) Check(needs []Need) error {
+	for _, need := range needs {
+		if !policy.Allows(need.Destination) {
+			return fmt.Errorf("--%s needs requests to %s, which --offline does not allow", need.Flag, need.Destination)
+		}
+	}
+
+	return nil
+}
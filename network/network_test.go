@@ -0,0 +1,26 @@
+package network_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/autopilot/network"
+)
+
+var _ = Describe("Policy", func() {
+	needs := []network.Need{{Flag: "probe-path", Destination: network.AppRoutes}}
+
+	It("allows every destination by default", func() {
+		policy := network.Policy{}
+		Expect(policy.Allows(network.Telemetry)).To(BeTrue())
+		Expect(policy.Check(needs)).To(Succeed())
+	})
+
+	It("allows nothing but the Cloud Controller offline", func() {
+		policy := network.Policy{Offline: true}
+		for _, destination := range []network.Destination{network.UpdateCheck, network.Telemetry, network.AppRoutes, network.StatusURL, network.SSHProxy, network.PolicyEndpoint} {
+			Expect(policy.Allows(destination)).To(BeFalse())
+		}
+		Expect(policy.Check(needs)).To(MatchError("--probe-path needs requests to the app's routes, to check the new version, which --offline does not allow"))
+	})
+})
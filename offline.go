@@ -0,0 +1,67 @@
+package autopilot
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/concourse/autopilot/network"
+)
+
+// NetworkPolicy says where autopilot may send requests besides the Cloud
+// Controller. With --offline, nowhere.
+type NetworkPolicy = network.Policy
+
+// ParseOffline takes the --offline flag, which every command accepts, out of
+// args. Arguments after -- are left alone.
+func ParseOffline(args []string) (NetworkPolicy, []string) {
+	offline, args := takeBoolFlag(args, "offline")
+	return NetworkPolicy{Offline: offline}, args
+}
+
+// NetworkNeeds lists the flags of the command line that need requests sent
+// somewhere other than the Cloud Controller.
+func NetworkNeeds(args []string) []network.Need {
+	needs := []network.Need{}
+
+	if checkUpdate, _ := takeBoolFlag(args, "check-update"); checkUpdate {
+		needs = append(needs, network.Need{Flag: "check-update", Destination: network.UpdateCheck})
+	}
+
+	if statusURL, _ := takeStringFlag(args, "status-url"); args[0] == "zero-downtime-abort" && statusURL != "" {
+		needs = append(needs, network.Need{Flag: "status-url", Destination: network.StatusURL})
+	}
+
+	// a test route is requested even without a probe, to check the new
+	// version answers on it
+	if args[0] == "zero-downtime-push" {
+		if verifySSH, _ := takeStringFlag(args, "verify-ssh"); verifySSH != "" {
+			needs = append(needs, network.Need{Flag: "verify-ssh", Destination: network.SSHProxy})
+		}
+		if autoRollback, _ := takeBoolFlag(args, "auto-rollback-on-probe-failure"); autoRollback {
+			needs = append(needs, network.Need{Flag: "auto-rollback-on-probe-failure", Destination: network.AppRoutes})
+		}
+		if httpsOnly, _ := takeBoolFlag(args, "test-route-https-only"); httpsOnly {
+			needs = append(needs, network.Need{Flag: "test-route-https-only", Destination: network.AppRoutes})
+		}
+		for _, flag := range []string{"test-route", "probe-path", "warmup", "warmup-requests", "route-check-header", "route-check-body"} {
+			if value, _ := takeStringFlag(args, flag); value != "" {
+				needs = append(needs, network.Need{Flag: flag, Destination: network.AppRoutes})
+			}
+		}
+	}
+
+	return needs
+}
+
+// telemetryFor exports the deploy's telemetry when OTEL_EXPORTER_OTLP_ENDPOINT
+// is set and the policy allows it.
+func telemetryFor(policy NetworkPolicy, command, appName string) *Telemetry {
+	if !policy.Allows(network.Telemetry) {
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+			fmt.Println("Not exporting telemetry, as --offline was given.")
+		}
+		return nil
+	}
+
+	return NewTelemetryFromEnv(command, appName)
+}
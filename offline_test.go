@@ -0,0 +1,47 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/network"
+)
+
+var _ = Describe("Offline", func() {
+	It("takes the offline flag out of the args", func() {
+		policy, args := ParseOffline([]string{"zero-downtime-push", "app", "--offline", "-f", "manifest.yml"})
+		Expect(policy.Offline).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("lists the flags that send requests outside the Cloud Controller", func() {
+		needs := NetworkNeeds([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--probe-path", "/healthz", "--route-check-header=X-Revision"})
+		Expect(needs).To(Equal([]network.Need{
+			{Flag: "probe-path", Destination: network.AppRoutes},
+			{Flag: "route-check-header", Destination: network.AppRoutes},
+		}))
+
+		Expect(NetworkNeeds([]string{"zero-downtime-abort", "app", "--status-url", "http://127.0.0.1:8123"})).To(Equal([]network.Need{
+			{Flag: "status-url", Destination: network.StatusURL},
+		}))
+		Expect(NetworkNeeds([]string{"zero-downtime-push", "app", "--version", "--check-update"})).To(Equal([]network.Need{
+			{Flag: "check-update", Destination: network.UpdateCheck},
+		}))
+		Expect(NetworkNeeds([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--auto-rollback-on-probe-failure"})).To(Equal([]network.Need{
+			{Flag: "auto-rollback-on-probe-failure", Destination: network.AppRoutes},
+		}))
+		Expect(NetworkNeeds([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--verify-ssh", "true"})).To(Equal([]network.Need{
+			{Flag: "verify-ssh", Destination: network.SSHProxy},
+		}))
+		Expect(NetworkNeeds([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--test-route-https-only"})).To(Equal([]network.Need{
+			{Flag: "test-route-https-only", Destination: network.AppRoutes},
+		}))
+	})
+
+	It("needs nothing outside the Cloud Controller for a plain push", func() {
+		needs := NetworkNeeds([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--", "--test-route", "ignored"})
+		Expect(needs).To(BeEmpty())
+		Expect(NetworkPolicy{Offline: true}.Check(needs)).To(Succeed())
+	})
+})
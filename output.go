@@ -0,0 +1,105 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verbosity controls how much of the cf CLI's own output is shown.
+type Verbosity int
+
+const (
+	// QuietVerbosity hides the output of every cf command, unless it fails.
+	QuietVerbosity Verbosity = iota - 1
+	// NormalVerbosity shows the output of the steps users care about, such
+	// as the push, and hides bookkeeping.
+	NormalVerbosity
+	// VerboseVerbosity shows the output of every cf command.
+	VerboseVerbosity
+)
+
+// OutputPolicy says whether a cf command's output is worth showing.
+type OutputPolicy int
+
+const (
+	ShowOutput OutputPolicy = iota
+	HideOutput
+)
+
+// commandOutput lists the cf commands run as bookkeeping, whose output only
+// gets in the way at normal verbosity. Anything else is shown. set-env is
+// never run through here, since its output would show secret values.
+var commandOutput = map[string]OutputPolicy{
+	"copy-source": HideOutput,
+	"delete":      HideOutput,
+	"scale":       HideOutput,
+	"stop":        HideOutput,
+}
+
+// ParseVerbosity takes the --quiet and --verbose flags, which every command
+// accepts, out of args. Arguments after -- are left alone.
+func ParseVerbosity(args []string) (Verbosity, []string) {
+	verbosity := NormalVerbosity
+	rest := []string{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		switch arg {
+		case "--quiet", "-quiet":
+			verbosity = QuietVerbosity
+		case "--verbose", "-verbose":
+			verbosity = VerboseVerbosity
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return verbosity, rest
+}
+
+func (repo *ApplicationRepo) SetVerbosity(verbosity Verbosity) {
+	repo.verbosity = verbosity
+}
+
+// cliCommand runs a cf command, showing its output according to the
+// command's policy and the verbosity. Hidden output is still printed if the
+// command fails, so the reason is not lost. The access token is refreshed
+// first if it would expire before the command could finish.
+func (repo *ApplicationRepo) cliCommand(args ...string) error {
+	err := repo.freshenToken(args[0])
+	if err != nil {
+		return err
+	}
+
+	show := commandOutput[args[0]] == ShowOutput
+	switch repo.verbosity {
+	case QuietVerbosity:
+		show = false
+	case VerboseVerbosity:
+		show = true
+	}
+
+	// verbose output is printed once the command is done, so that the
+	// sensitive values in it can be masked first
+	if show && repo.verbosity != VerboseVerbosity {
+		_, err := repo.conn.CliCommand(args...)
+		return err
+	}
+
+	output, err := repo.conn.CliCommandWithoutTerminalOutput(args...)
+	if (show || err != nil) && len(output) > 0 {
+		fmt.Println(strings.Join(repo.redactor.RedactAll(output), "\n"))
+	}
+
+	return err
+}
+
+// SetRedactor masks sensitive values in the cf commands' output printed by
+// autopilot.
+func (repo *ApplicationRepo) SetRedactor(redactor *Redactor) {
+	repo.redactor = redactor
+}
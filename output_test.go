@@ -0,0 +1,117 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Output", func() {
+	Describe("ParseVerbosity", func() {
+		It("takes the verbosity flags out of the args", func() {
+			verbosity, args := ParseVerbosity([]string{"zero-downtime-push", "app", "--quiet", "-f", "manifest.yml"})
+			Expect(verbosity).To(Equal(QuietVerbosity))
+			Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+			verbosity, _ = ParseVerbosity([]string{"zero-downtime-push", "app", "--verbose"})
+			Expect(verbosity).To(Equal(VerboseVerbosity))
+		})
+
+		It("leaves arguments for cf push alone", func() {
+			verbosity, args := ParseVerbosity([]string{"zero-downtime-push", "app", "--", "--verbose"})
+			Expect(verbosity).To(Equal(NormalVerbosity))
+			Expect(args).To(Equal([]string{"zero-downtime-push", "app", "--", "--verbose"}))
+		})
+	})
+
+	Describe("cf commands", func() {
+		var (
+			cliConn *pluginfakes.FakeCliConnection
+			repo    *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			cliConn = &pluginfakes.FakeCliConnection{}
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		It("shows the push but hides bookkeeping by default", func() {
+			Expect(repo.PushApplication("app", "manifest.yml", "")).To(Succeed())
+			Expect(repo.DeleteApplication("app-venerable")).To(Succeed())
+
+			Expect(cliConn.CliCommandCallCount()).To(Equal(1))
+			Expect(cliConn.CliCommandArgsForCall(0)[0]).To(Equal("push"))
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)[0]).To(Equal("delete"))
+		})
+
+		It("hides everything when quiet", func() {
+			repo.SetVerbosity(QuietVerbosity)
+			Expect(repo.PushApplication("app", "manifest.yml", "")).To(Succeed())
+
+			Expect(cliConn.CliCommandCallCount()).To(Equal(0))
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+		})
+
+		It("still returns errors from hidden commands", func() {
+			repo.SetVerbosity(QuietVerbosity)
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{"FAILED"}, errors.New("push failed"))
+
+			Expect(repo.PushApplication("app", "manifest.yml", "")).To(MatchError("push failed"))
+		})
+
+		It("shows everything when verbose, with sensitive values masked", func() {
+			redactor, err := NewRedactor(nil)
+			Expect(err).ToNot(HaveOccurred())
+			redactor.Add("DB_PASSWORD", "hunter22")
+			repo.SetRedactor(redactor)
+			repo.SetVerbosity(VerboseVerbosity)
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{"Stopping app", "connecting with hunter22", "API_TOKEN: abc123"}, nil)
+
+			output := captureStdout(func() {
+				Expect(repo.StopApplication("app")).To(Succeed())
+			})
+
+			Expect(cliConn.CliCommandCallCount()).To(Equal(0))
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			Expect(output).To(Equal("Stopping app\nconnecting with [REDACTED]\nAPI_TOKEN: [REDACTED]\n"))
+		})
+
+		It("masks sensitive values in the output of failed commands", func() {
+			redactor, err := NewRedactor(nil)
+			Expect(err).ToNot(HaveOccurred())
+			repo.SetRedactor(redactor)
+			cliConn.CliCommandWithoutTerminalOutputReturns([]string{"FAILED", "SECRET_KEY=s3cr3t"}, errors.New("delete failed"))
+
+			output := captureStdout(func() {
+				Expect(repo.DeleteApplication("app")).To(MatchError("delete failed"))
+			})
+
+			Expect(output).To(Equal("FAILED\nSECRET_KEY=[REDACTED]\n"))
+		})
+	})
+})
+
+// captureStdout returns what f prints.
+func captureStdout(f func()) string {
+	reader, writer, err := os.Pipe()
+	Expect(err).ToNot(HaveOccurred())
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	Expect(err).ToNot(HaveOccurred())
+	return string(output)
+}
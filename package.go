@@ -0,0 +1,364 @@
+package autopilot
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// defaultIgnored are left out of every package, as cf push leaves them out.
+var defaultIgnored = []string{".cfignore", "_darcs", ".DS_Store", ".git", ".gitignore", ".hg", "/manifest.yml", ".svn"}
+
+// AppPackage is an app's files zipped up ahead of cf push.
+type AppPackage struct {
+	// Path is the archive, which is pushed instead of the directory.
+	Path string
+	Size int64
+	// Digest is the SHA-256 of the packaged files' names, modes and contents.
+	Digest string
+	// Cached is true if an archive of the same files was already built.
+	Cached bool
+}
+
+// ErrNoAppPath is returned when --package is given without -p.
+var ErrNoAppPath = errors.New("--package needs the app's directory, given with -p")
+
+// PackageApp zips the files in dir that its .cfignore does not exclude, so
+// that cf push only has to upload one archive. Archives are kept in cacheDir
+// by the digest of the files, so an unchanged app is not zipped again.
+func PackageApp(dir, cacheDir string) (AppPackage, error) {
+	ignore, err := loadCFIgnore(dir)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	files, err := packageFiles(dir, ignore)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	digest, err := digestFiles(dir, files)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	archive := filepath.Join(cacheDir, digest+".zip")
+	if info, err := os.Stat(archive); err == nil {
+		return AppPackage{Path: archive, Size: info.Size(), Digest: digest, Cached: true}, nil
+	}
+
+	err = os.MkdirAll(cacheDir, 0700)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	// written aside and renamed, so an interrupted build is never reused
+	partial, err := ioutil.TempFile(cacheDir, digest+"-*.partial")
+	if err != nil {
+		return AppPackage{}, err
+	}
+	defer os.Remove(partial.Name())
+
+	err = writeZip(partial, dir, files)
+	closeErr := partial.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return AppPackage{}, fmt.Errorf("could not package %s: %s", dir, err)
+	}
+
+	err = os.Rename(partial.Name(), archive)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	info, err := os.Stat(archive)
+	if err != nil {
+		return AppPackage{}, err
+	}
+
+	return AppPackage{Path: archive, Size: info.Size(), Digest: digest}, nil
+}
+
+// PackageCacheDir is where packaged apps are kept between deploys.
+func PackageCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "autopilot", "packages"), nil
+}
+
+// Describe says how big the package is, for the deploy log.
+func (pkg AppPackage) Describe() string {
+	reuse := ""
+	if pkg.Cached {
+		reuse = ", unchanged since it was last packaged"
+	}
+
+	return fmt.Sprintf("Packaged the app into %s (%s)%s.", formatSize(pkg.Size), pkg.Digest[:12], reuse)
+}
+
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d bytes", bytes)
+}
+
+// packageFiles lists the files and symlinks under dir to package, relative to
+// it with forward slashes, sorted so the archive and digest are stable.
+func packageFiles(dir string, ignore cfIgnore) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.Ignored(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+
+	sort.Strings(files)
+	return files, err
+}
+
+func digestFiles(dir string, files []string) (string, error) {
+	hash := sha256.New()
+	for _, rel := range files {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Lstat(file)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%o\x00", rel, info.Mode())
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(file)
+			if err != nil {
+				return "", err
+			}
+			io.WriteString(hash, target)
+		} else {
+			err = copyFile(hash, file)
+			if err != nil {
+				return "", err
+			}
+		}
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeZip(w io.Writer, dir string, files []string) error {
+	archive := zip.NewWriter(w)
+	for _, rel := range files {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Lstat(file)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		header.Method = zip.Deflate
+		header.SetMode(archiveMode(runtime.GOOS, info.Mode()))
+
+		entry, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(entry, target)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = copyFile(entry, file)
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// archiveMode is the mode a file is packaged with. Windows has no executable
+// bit, so, as cf push does there, files are made executable by their owner,
+// or start scripts would not run.
+func archiveMode(goos string, mode os.FileMode) os.FileMode {
+	if goos == "windows" && mode.IsRegular() {
+		return mode | 0744
+	}
+	return mode
+}
+
+func copyFile(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// cfIgnore holds the patterns of a .cfignore, which follow .gitignore: a
+// pattern with a slash is matched against the path from the app's directory,
+// one without against each name in it, and ! brings back what an earlier
+// pattern excluded. An excluded directory excludes everything in it.
+type cfIgnore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob     string
+	anchored bool
+	dirOnly  bool
+	negated  bool
+}
+
+func loadCFIgnore(dir string) (cfIgnore, error) {
+	lines := append([]string{}, defaultIgnored...)
+
+	f, err := os.Open(filepath.Join(dir, ".cfignore"))
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		err = scanner.Err()
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return cfIgnore{}, err
+	}
+
+	return parseCFIgnore(lines), nil
+}
+
+func parseCFIgnore(lines []string) cfIgnore {
+	ignore := cfIgnore{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negated = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			pattern.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		pattern.glob = line
+		ignore.patterns = append(ignore.patterns, pattern)
+	}
+
+	return ignore
+}
+
+// Ignored says whether the file or directory at rel, relative to the app's
+// directory with forward slashes, is left out of the package. Directories are
+// walked before what is in them, so only the path itself is checked.
+func (ignore cfIgnore) Ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range ignore.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if pattern.matches(rel) {
+			ignored = !pattern.negated
+		}
+	}
+	return ignored
+}
+
+func (pattern ignorePattern) matches(rel string) bool {
+	if pattern.anchored {
+		// a/**/b matches a/b, a/x/b and so on
+		if strings.Contains(pattern.glob, "**/") {
+			for _, glob := range []string{strings.Replace(pattern.glob, "**/", "", 1), strings.Replace(pattern.glob, "**/", "*/", 1)} {
+				if (ignorePattern{glob: glob, anchored: true}).matches(rel) {
+					return true
+				}
+			}
+			return false
+		}
+		matched, _ := path.Match(pattern.glob, rel)
+		return matched
+	}
+
+	matched, _ := path.Match(pattern.glob, path.Base(rel))
+	return matched
+}
+
+// packageAppPath packages the app at appPath into the cache and returns the
+// archive to push instead.
+func packageAppPath(appPath string) (string, error) {
+	cacheDir, err := PackageCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	pkg, err := PackageApp(appPath, cacheDir)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Println(pkg.Describe())
+	return pkg.Path, nil
+}
@@ -0,0 +1,144 @@
+package autopilot_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("PackageApp", func() {
+	var (
+		appDir   string
+		cacheDir string
+	)
+
+	write := func(rel, content string) {
+		file := filepath.Join(appDir, filepath.FromSlash(rel))
+		Expect(os.MkdirAll(filepath.Dir(file), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).To(Succeed())
+	}
+
+	entries := func(pkg AppPackage) map[string]string {
+		archive, err := zip.OpenReader(pkg.Path)
+		Expect(err).ToNot(HaveOccurred())
+		defer archive.Close()
+
+		contents := map[string]string{}
+		for _, file := range archive.File {
+			f, err := file.Open()
+			Expect(err).ToNot(HaveOccurred())
+			content, err := ioutil.ReadAll(f)
+			f.Close()
+			Expect(err).ToNot(HaveOccurred())
+			contents[file.Name] = string(content)
+		}
+		return contents
+	}
+
+	BeforeEach(func() {
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		Expect(err).ToNot(HaveOccurred())
+		cacheDir, err = ioutil.TempDir("", "packages")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(appDir)
+		os.RemoveAll(cacheDir)
+	})
+
+	It("zips the app, leaving out what .cfignore and cf push do", func() {
+		write("app.rb", "puts 'hi'")
+		write("lib/helper.rb", "helper")
+		write("manifest.yml", "applications: []")
+		write("config/manifest.yml", "nested")
+		write(".git/HEAD", "ref")
+		write("log/development.log", "noise")
+		write("tmp", "a file, not a directory")
+		write("spec/a_spec.rb", "spec")
+		write("spec/fixtures/keep.rb", "fixture")
+		write("notes.swp", "swap")
+		write(".cfignore", "# local only\nlog/\ntmp/\n*.swp\n/spec/**/*_spec.rb\n")
+
+		pkg, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pkg.Cached).To(BeFalse())
+		Expect(filepath.Dir(pkg.Path)).To(Equal(cacheDir))
+
+		Expect(entries(pkg)).To(Equal(map[string]string{
+			"app.rb":                "puts 'hi'",
+			"lib/helper.rb":         "helper",
+			"config/manifest.yml":   "nested",
+			"tmp":                   "a file, not a directory",
+			"spec/fixtures/keep.rb": "fixture",
+		}))
+
+		info, err := os.Stat(pkg.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pkg.Size).To(Equal(info.Size()))
+	})
+
+	It("brings back files a negated pattern names", func() {
+		write("log/keep.log", "kept")
+		write("log/other.log", "dropped")
+		write(".cfignore", "*.log\n!keep.log\n")
+
+		pkg, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries(pkg)).To(Equal(map[string]string{"log/keep.log": "kept"}))
+	})
+
+	It("keeps symlinks as links", func() {
+		write("real/config.yml", "config")
+		Expect(os.Symlink("real/config.yml", filepath.Join(appDir, "config.yml"))).To(Succeed())
+
+		pkg, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		archive, err := zip.OpenReader(pkg.Path)
+		Expect(err).ToNot(HaveOccurred())
+		defer archive.Close()
+		Expect(archive.File[0].Name).To(Equal("config.yml"))
+		Expect(archive.File[0].Mode() & os.ModeSymlink).ToNot(BeZero())
+		Expect(entries(pkg)["config.yml"]).To(Equal("real/config.yml"))
+	})
+
+	It("reuses the zip while the files are unchanged", func() {
+		write("app.rb", "v1")
+
+		first, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		again, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again.Cached).To(BeTrue())
+		Expect(again.Path).To(Equal(first.Path))
+		Expect(again.Digest).To(Equal(first.Digest))
+
+		write("app.rb", "v2")
+		changed, err := PackageApp(appDir, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed.Cached).To(BeFalse())
+		Expect(changed.Digest).ToNot(Equal(first.Digest))
+
+		files, err := ioutil.ReadDir(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+	})
+
+	It("describes the package's size", func() {
+		pkg := AppPackage{Size: 3 << 20, Digest: "0123456789abcdef"}
+		Expect(pkg.Describe()).To(Equal("Packaged the app into 3.0 MB (0123456789ab)."))
+
+		pkg.Cached = true
+		pkg.Size = 512
+		Expect(pkg.Describe()).To(Equal("Packaged the app into 512 bytes (0123456789ab), unchanged since it was last packaged."))
+	})
+})
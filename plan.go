@@ -0,0 +1,86 @@
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/concourse/autopilot/plan"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// A Plan lists the actions a push would run, one PlanStep each.
+type (
+	Plan     = plan.Plan
+	PlanStep = plan.Step
+)
+
+// ParsePlanArgs takes the --json flag out of the plan command's args, leaving
+// the same arguments zero-downtime-push takes.
+func ParsePlanArgs(args []string) (bool, []string) {
+	return takeBoolFlag(args, "json")
+}
+
+// NewPlan lists the actions, which run command on the app.
+func NewPlan(command, appName string, replacesLiveApp bool, actions []rewind.Action) Plan {
+	return plan.New(command, appName, replacesLiveApp, actions)
+}
+
+// showPlan prints the actions zero-downtime-push would run with these
+// arguments, and the config file's disposition for the old version, without
+// running them.
+func showPlan(planner *DeploymentPlanner, args []string, dispositionFor func(appName string) (string, error)) error {
+	asJSON, args := ParsePlanArgs(args)
+
+	appName, manifestPath, appPath, options, err := ParseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	disposition, err := dispositionFor(appName)
+	if err != nil {
+		return err
+	}
+	options = ApplyDisposition(options, disposition)
+
+	manifestPath, err = manifestPathFor(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	err = CheckDeployPaths(manifestPath, appPath)
+	if err != nil {
+		return err
+	}
+
+	revision := options.Revision
+	if revision == "" {
+		revision = GitRevision(appPath)
+	}
+	planner.Naming, err = namingFor(planner.Naming, revision)
+	if err != nil {
+		return err
+	}
+
+	appExists, err := planner.Repo.DoesAppExist(appName)
+	if err != nil {
+		return err
+	}
+
+	actions, err := planner.PushActions(appName, manifestPath, appPath, options)
+	if err != nil {
+		return err
+	}
+
+	plan := NewPlan("zero-downtime-push", appName, appExists, actions)
+	if !asJSON {
+		fmt.Print(plan.Text())
+		return nil
+	}
+
+	contents, err := plan.JSON()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(contents)
+	return nil
+}
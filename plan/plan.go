@@ -0,0 +1,93 @@
+// Package plan lays out the actions of a deploy for review before they are
+// run: what each does, when it is skipped, and what undoes it.
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// Plan is the list of actions a push would run, for review before it is run
+// for real.
+type Plan struct {
+	Command         string `json:"command"`
+	App             string `json:"app"`
+	ReplacesLiveApp bool   `json:"replaces_live_app"`
+	Steps           []Step `json:"steps"`
+}
+
+// Step is one action of the plan. IfItFails runs when the action itself
+// fails, and Undo when a later action does.
+type Step struct {
+	Step      int    `json:"step"`
+	Name      string `json:"name"`
+	Does      string `json:"does"`
+	When      string `json:"when,omitempty"`
+	IfItFails string `json:"if_it_fails,omitempty"`
+	Undo      string `json:"undo,omitempty"`
+}
+
+// New lists the actions, which run command on the app, as the steps of a
+// plan.
+func New(command, appName string, replacesLiveApp bool, actions []rewind.Action) Plan {
+	plan := Plan{
+		Command:         command,
+		App:             appName,
+		ReplacesLiveApp: replacesLiveApp,
+		Steps:           []Step{},
+	}
+
+	for i, action := range actions {
+		plan.Steps = append(plan.Steps, Step{
+			Step:      i + 1,
+			Name:      action.Name,
+			Does:      action.Description.Forward,
+			When:      action.Description.When,
+			IfItFails: action.Description.ReversePrevious,
+			Undo:      action.Description.Undo,
+		})
+	}
+
+	return plan
+}
+
+// Text lays the plan out for reading in a terminal or a change request.
+func (plan Plan) Text() string {
+	var text bytes.Buffer
+
+	target := "as a new app"
+	if plan.ReplacesLiveApp {
+		target = "replacing the live app"
+	}
+	fmt.Fprintf(&text, "Plan for %s of %s, %s:\n", plan.Command, plan.App, target)
+
+	for _, step := range plan.Steps {
+		fmt.Fprintf(&text, "\n%d. %s\n", step.Step, step.Name)
+		fmt.Fprintf(&text, "   %s\n", step.Does)
+		if step.When != "" {
+			fmt.Fprintf(&text, "   When: %s.\n", step.When)
+		}
+		if step.IfItFails != "" {
+			fmt.Fprintf(&text, "   If it fails: %s\n", step.IfItFails)
+		}
+		if step.Undo != "" {
+			fmt.Fprintf(&text, "   Undo: %s\n", step.Undo)
+		}
+	}
+
+	fmt.Fprintf(&text, "\nIf a step fails, its \"if it fails\" step runs, then the undo steps of the steps before it, most recent first.\n")
+	return text.String()
+}
+
+// JSON encodes the plan for tools.
+func (plan Plan) JSON() (string, error) {
+	contents, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents) + "\n", nil
+}
@@ -0,0 +1,63 @@
+package plan_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/concourse/autopilot/plan"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Plan", func() {
+	actions := []rewind.Action{
+		{
+			Name:        "rename",
+			Description: rewind.Description{Forward: "Rename app to app-old.", Undo: "Rename app-old back to app."},
+		},
+		{
+			Name:        "warm up",
+			Description: rewind.Description{Forward: "Warm the new app up.", When: "only with --warmup, which was not given, so it does nothing", ReversePrevious: "Delete the new app."},
+		},
+	}
+
+	It("numbers the actions as steps", func() {
+		plan := New("zero-downtime-push", "app", true, actions)
+
+		Expect(plan.Steps).To(Equal([]Step{
+			{Step: 1, Name: "rename", Does: "Rename app to app-old.", Undo: "Rename app-old back to app."},
+			{Step: 2, Name: "warm up", Does: "Warm the new app up.", When: "only with --warmup, which was not given, so it does nothing", IfItFails: "Delete the new app."},
+		}))
+	})
+
+	It("lays the plan out as text", func() {
+		Expect(New("zero-downtime-push", "app", true, actions).Text()).To(Equal(`Plan for zero-downtime-push of app, replacing the live app:
+
+1. rename
+   Rename app to app-old.
+   Undo: Rename app-old back to app.
+
+2. warm up
+   Warm the new app up.
+   When: only with --warmup, which was not given, so it does nothing.
+   If it fails: Delete the new app.
+
+If a step fails, its "if it fails" step runs, then the undo steps of the steps before it, most recent first.
+`))
+	})
+
+	It("encodes the plan as JSON", func() {
+		contents, err := New("zero-downtime-push", "app", false, nil).JSON()
+		Expect(err).ToNot(HaveOccurred())
+
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal([]byte(contents), &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(map[string]interface{}{
+			"command":           "zero-downtime-push",
+			"app":               "app",
+			"replaces_live_app": false,
+			"steps":             []interface{}{},
+		}))
+	})
+})
@@ -0,0 +1,101 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/repository/repositoryfakes"
+)
+
+var _ = Describe("Plan", func() {
+	var planner *DeploymentPlanner
+
+	BeforeEach(func() {
+		planner = NewDeploymentPlanner(&repositoryfakes.FakeApplicationRepository{})
+	})
+
+	It("lists every action of a push over a live app, with its undo and conditions", func() {
+		actions := planner.ExistingAppActions("app", "manifest.yml", "", AutopilotOptions{KeepExisting: true})
+		plan := NewPlan("zero-downtime-push", "app", true, actions)
+
+		Expect(plan.Steps).To(HaveLen(len(actions)))
+		for i, step := range plan.Steps {
+			Expect(step.Step).To(Equal(i + 1))
+			Expect(step.Name).To(Equal(actions[i].Name))
+			Expect(step.Does).ToNot(BeEmpty(), step.Name)
+		}
+
+		Expect(plan.Steps[4].Name).To(Equal("consult policy before cutover"))
+		Expect(plan.Steps[4].When).To(Equal("only when the config file sets a policy for the space, which it does not, so it does nothing"))
+		Expect(plan.Steps[7]).To(Equal(PlanStep{
+			Step: 8,
+			Name: "rename live app",
+			Does: "Rename app to app-venerable.",
+			Undo: "Rename app-venerable back to app.",
+		}))
+		Expect(plan.Steps[13].When).To(Equal("only with --test-route, --only-domains, --exclude-domains, --cutover-order, --ready-log-pattern or --verify-ssh, which was not given, so it does nothing, unless the manifest asks for a random route"))
+		Expect(plan.Steps[20].When).To(Equal("not when the old version is kept, so it does nothing"))
+		Expect(plan.Steps[21].Does).To(Equal("Stop app-venerable, keeping it for a rollback."))
+		Expect(plan.Steps[21].IfItFails).To(Equal("Map the routes back to app-venerable."))
+	})
+
+	It("describes the steps the given options change", func() {
+		actions := planner.ExistingAppActions("app", "manifest.yml", "", AutopilotOptions{TestRoute: "auto", Probe: Prober{Path: "/healthz"}, StrictRoutes: true})
+		plan := NewPlan("zero-downtime-push", "app", true, actions)
+
+		Expect(plan.Steps[8].Does).To(Equal("Push the new version of app with manifest.yml, without routes."))
+		Expect(plan.Steps[13].Does).To(Equal("Map the new version to the test route and check it answers on /healthz, unmap the test route, then map the production routes."))
+		Expect(plan.Steps[13].When).To(Equal("only with --test-route, --only-domains, --exclude-domains, --cutover-order, --ready-log-pattern or --verify-ssh, which was given"))
+		Expect(plan.Steps[15].Does).To(HaveSuffix("and fail if not."))
+		Expect(plan.Steps[21].Does).To(Equal("Delete app-venerable."))
+	})
+
+	It("lays the plan out as text", func() {
+		plan := NewPlan("zero-downtime-push", "app", false, planner.NewAppActions("app", "manifest.yml", "", AutopilotOptions{}))
+
+		Expect(plan.Text()).To(Equal(`Plan for zero-downtime-push of app, as a new app:
+
+1. create service keys
+   Create a new key for each of the --rotate-service-keys services, for the new version's environment.
+   When: only with --rotate-service-keys, which was not given, so it does nothing.
+   If it fails: Delete the keys that were created.
+   Undo: Delete the new keys.
+
+2. push
+   Push app with manifest.yml.
+
+3. probe
+   Check app is ready by requesting the --probe-path on its first route.
+   When: only with --probe-path, which was not given, so it does nothing.
+
+4. delete old service keys
+   Delete the keys of the --rotate-service-keys services that earlier versions of app were given.
+   When: only with --rotate-service-keys, which was not given, so it does nothing.
+
+If a step fails, its "if it fails" step runs, then the undo steps of the steps before it, most recent first.
+`))
+	})
+
+	It("encodes the plan as JSON", func() {
+		plan := NewPlan("zero-downtime-push", "app", true, planner.ExistingAppActions("app", "manifest.yml", "", AutopilotOptions{}))
+
+		contents, err := plan.JSON()
+		Expect(err).ToNot(HaveOccurred())
+
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal([]byte(contents), &decoded)).To(Succeed())
+		Expect(decoded["app"]).To(Equal("app"))
+		Expect(decoded["replaces_live_app"]).To(BeTrue())
+		Expect(decoded["steps"]).To(HaveLen(25))
+		Expect(decoded["steps"].([]interface{})[7]).To(HaveKeyWithValue("undo", "Rename app-venerable back to app."))
+	})
+
+	It("takes the json flag out of the args", func() {
+		asJSON, args := ParsePlanArgs([]string{"zero-downtime-plan", "app", "-f", "manifest.yml", "--json"})
+		Expect(asJSON).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-plan", "app", "-f", "manifest.yml"}))
+	})
+})
@@ -0,0 +1,1209 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/repository"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// ApplicationRepository is everything the planner needs to change apps and
+// routes. ApplicationRepo implements it against a cf CLI session.
+type ApplicationRepository = repository.ApplicationRepository
+
+var _ ApplicationRepository = &ApplicationRepo{}
+
+// Clock lets tests control time, such as the wait for an app to drain.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// sleepContext sleeps for d on the clock, but returns ctx's error as soon as
+// ctx is done, so an interrupt need not wait out a long pause.
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	slept := make(chan struct{})
+	go func() {
+		clock.Sleep(d)
+		close(slept)
+	}()
+
+	select {
+	case <-slept:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Logger receives the planner's progress messages.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type stdoutLogger struct{}
+
+func (stdoutLogger) Printf(format string, args ...interface{}) { fmt.Printf(format, args...) }
+
+// DeploymentPlanner turns a command into the actions that carry it out. The
+// actions only touch the outside world through the planner's dependencies,
+// and report every failure as an error for the rewind engine to act on.
+type DeploymentPlanner struct {
+	Repo   ApplicationRepository
+	Naming NamingStrategy
+	Clock  Clock
+	Logger Logger
+
+	// ResumeFrom is the step a resumed deploy starts from, so that the
+	// lookups before it know what the failed deploy already did.
+	ResumeFrom string
+
+	// CompareVersions compares the configuration of the new version with
+	// the old one's once it is pushed, for the deployment report.
+	CompareVersions bool
+
+	// Policy is consulted before the cutover and before the old version is
+	// deleted, and can stop the deploy.
+	Policy PolicyHook
+
+	// Context is the deploy's, done once it is interrupted, aborted or out
+	// of time. The long waits within a step end as soon as it is done.
+	Context context.Context
+
+	// how the new version's configuration differs from the old one's
+	configChanges []ConfigChange
+	// what the deleted old version was found still holding
+	leaks []string
+}
+
+// NewDeploymentPlanner plans with the default naming, the real clock and
+// progress on stdout.
+func NewDeploymentPlanner(repo ApplicationRepository) *DeploymentPlanner {
+	return &DeploymentPlanner{
+		Repo:   repo,
+		Naming: SuffixNaming{},
+		Clock:  realClock{},
+		Logger: stdoutLogger{},
+	}
+}
+
+// context is the deploy's context, or the background one if none is set.
+func (planner *DeploymentPlanner) context() context.Context {
+	if planner.Context == nil {
+		return context.Background()
+	}
+	return planner.Context
+}
+
+// sleep waits for d, or until the deploy's context is done.
+func (planner *DeploymentPlanner) sleep(d time.Duration) error {
+	return sleepContext(planner.context(), planner.Clock, d)
+}
+
+//Check to see if venerable app has routes. if it does not, go get the routes for the current app, and put them on the
+//venerable.
+
+// If the rollback has no routes, it is going to receive the routes of the most recent version of the app regardless of
+// what the original unmapped venerable had for routes.
+func (planner *DeploymentPlanner) RollbackActions(appName string, options RollbackOptions) []rewind.Action {
+	appRepo := planner.Repo
+
+	// the copy being rolled back to
+	previous := planner.Naming.VenerableName(appName)
+	if options.From != "" {
+		previous = options.From
+	}
+
+	// the live app's routes moved to the copy, so exactly those can be
+	// moved back, even when the live app keeps routes on other domains
+	var movedRoute Route
+
+	// Moves the routes back from the venerable app to the rollback app, if
+	// they had been moved over.
+	moveRoutesBack := func() error {
+		if len(movedRoute.URLs()) == 0 {
+			return nil
+		}
+
+		errMapRoutes := tolerateRouteErrors(appRepo.MapRoutes(planner.Naming.RollbackName(appName), movedRoute), options.ContinueOnRouteError)
+		if errMapRoutes != nil {
+			planner.Logger.Printf("Error in appRepo.MapRoutes\n")
+			return errMapRoutes
+		}
+
+		errUnmapRoutes := tolerateRouteErrors(appRepo.UnmapRoutes(previous, movedRoute), options.ContinueOnRouteError)
+		if errUnmapRoutes != nil {
+			planner.Logger.Printf("Error in appRepo.UnmapRoutes\n")
+			return errUnmapRoutes
+		}
+		return nil
+	}
+
+	// whether the copy is running and healthy before the live app is touched
+	startedFirst := options.Restage || options.StartFirst
+
+	stopPrevious := func() error {
+		if !startedFirst {
+			return nil
+		}
+
+		return appRepo.StopApplication(previous)
+	}
+
+	return []rewind.Action{
+		// start (or restage, e.g. to pick up a fixed buildpack) the copy
+		// being rolled back to while the live app still serves
+		{
+			Name: "start copy before rollback",
+			Forward: func() error {
+				var err error
+				if options.Restage {
+					planner.Logger.Printf("Restaging %s before rolling back to it.\n", previous)
+					err = appRepo.RestageApplication(previous)
+				} else if options.StartFirst {
+					planner.Logger.Printf("Starting %s before rolling back to it.\n", previous)
+					err = appRepo.StartApplication(previous)
+				} else {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				return appRepo.CheckAppHealthy(previous)
+			},
+			ReversePrevious: stopPrevious,
+			Undo:            stopPrevious,
+		},
+		//Rename live app
+		{
+			Name: "rename live app",
+			Forward: func() error {
+				// the venerable app is about to take the live app's place
+				if options.From == "" {
+					err := planner.verifyManaged(appName, previous, options.ForceNameCollision)
+					if err != nil {
+						return err
+					}
+				}
+
+				err := appRepo.RenameApplication(appName, planner.Naming.RollbackName(appName))
+				if err != nil {
+					return err
+				}
+
+				planner.markManaged(planner.Naming.RollbackName(appName), appName)
+				return nil
+			},
+			// the rename may have been made before it failed
+			ReversePrevious: func() error {
+				return planner.undoFailedRename(appName, planner.Naming.RollbackName(appName))
+			},
+			Undo: func() error {
+				return appRepo.RenameApplication(planner.Naming.RollbackName(appName), appName)
+			},
+		},
+
+		//See if venerable app has routes
+		{
+			Name: "move routes to previous version",
+			Forward: func() error {
+				// an app whose routes can't be looked up is not taken to
+				// have none, which would leave the rollback without routes
+				route, err := routesOf(appRepo, previous)
+				if err != nil {
+					return err
+				}
+
+				if (len(route.URLs())) < 1 {
+					newAppRoute, err := routesOf(appRepo, planner.Naming.RollbackName(appName))
+					if err != nil {
+						return err
+					}
+					newAppRoute = options.Domains.FilterRoute(newAppRoute)
+					if len(newAppRoute.URLs()) == 0 {
+						// neither version has routes, as with no-route apps,
+						// or they are on a domain left where it is
+						return nil
+					}
+
+					movedRoute = newAppRoute
+					errMapRoutes := tolerateRouteErrors(appRepo.MapRoutes(previous, newAppRoute), options.ContinueOnRouteError)
+					if errMapRoutes != nil {
+						planner.Logger.Printf("error in apprepo.MapRoutes\n")
+						return errMapRoutes
+					}
+
+					errUnmapRoutes := tolerateRouteErrors(appRepo.UnmapRoutes(planner.Naming.RollbackName(appName), newAppRoute), options.ContinueOnRouteError)
+					if errUnmapRoutes != nil {
+						planner.Logger.Printf("error in apprepo.Unmaproutes\n")
+						return errUnmapRoutes
+					}
+				}
+				return nil
+			},
+			ReversePrevious: moveRoutesBack,
+			Undo:            moveRoutesBack,
+		},
+		//Rename venerable app
+		{
+			Name: "rename previous version",
+			Forward: func() error {
+				return appRepo.RenameApplication(previous, appName)
+			},
+			ReversePrevious: func() error {
+				return planner.undoFailedRename(previous, appName)
+			},
+			Undo: func() error {
+				return appRepo.RenameApplication(appName, previous)
+			},
+		},
+		//Start rollback app
+		{
+			Name: "start rolled back app",
+			Forward: func() error {
+				if startedFirst {
+					return nil
+				}
+
+				return appRepo.StartApplication(appName)
+
+			},
+			// the undo steps move the routes back to the app that was live
+			// and give it its name back, so it serves again. The copy that
+			// could not start is stopped first, rather than left crashing.
+			ReversePrevious: func() error {
+				planner.Logger.Printf("%s could not start, so it is stopped, and %s, the version that was live, gets the name %s back.\n",
+					appName, planner.Naming.RollbackName(appName), appName)
+				err := appRepo.StopApplication(appName)
+				if err != nil {
+					planner.Logger.Printf("Warning: could not stop %s: %s\n", appName, err)
+				}
+				return nil
+			},
+		},
+		//Delete rolled back app
+		{
+			Name: "delete rolled back app",
+			Forward: func() error {
+				// the rollback is done by now, and undoing it because the
+				// app it replaced could not be deleted would only put the
+				// broken version back
+				err := appRepo.DeleteApplication(planner.Naming.RollbackName(appName))
+				if err != nil {
+					planner.Logger.Printf("Warning: could not delete %s, delete it by hand: %s\n", planner.Naming.RollbackName(appName), err)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// undoFailedRename names the app back to oldName after a rename to newName
+// failed. The Cloud Controller may have made the rename before the failure,
+// e.g. when the cf CLI timed out waiting for it, so the app is looked for
+// under its new name rather than renamed back blindly, which would fail or,
+// worse, take a name another app needs.
+func (planner *DeploymentPlanner) undoFailedRename(oldName, newName string) error {
+	renamed, err := planner.Repo.DoesAppExist(newName)
+	if err != nil {
+		return err
+	}
+	if !renamed {
+		return nil
+	}
+
+	stillThere, err := planner.Repo.DoesAppExist(oldName)
+	if err != nil {
+		return err
+	}
+	if stillThere {
+		// newName was taken by another app, so the rename never happened
+		return nil
+	}
+
+	return planner.Repo.RenameApplication(newName, oldName)
+}
+
+// PushActions plans a push, over the top of the live app if there is one.
+func (planner *DeploymentPlanner) PushActions(appName, manifestPath, appPath string, options AutopilotOptions) ([]rewind.Action, error) {
+	// a push cf cannot stage would only fail once the live app is renamed
+	err := planner.checkLifecycle(appName, manifestPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	appExists, err := planner.Repo.DoesAppExist(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !appExists {
+		return planner.NewAppActions(appName, manifestPath, appPath, options), nil
+	}
+
+	// the venerable name is deleted and then taken for the live app
+	err = planner.CheckNameCollisions(appName, options.ForceNameCollision, planner.Naming.VenerableName(appName))
+	if err != nil {
+		return nil, err
+	}
+
+	// a stopped app serves no traffic, so there is no downtime to avoid
+	appStopped, err := planner.Repo.IsAppStopped(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	if appStopped {
+		if !options.AllowStoppedApp {
+			return nil, fmt.Errorf("App %s is stopped, so there is no traffic to move to the new version. Use --allow-stopped-app to replace it by pushing, verifying the new version and then deleting the old one.", appName)
+		}
+
+		planner.Logger.Printf("App %s is stopped, replacing it without moving any traffic.\n", appName)
+		return planner.StoppedAppActions(appName, manifestPath, appPath, options), nil
+	}
+
+	return planner.ExistingAppActions(appName, manifestPath, appPath, options), nil
+}
+
+func (planner *DeploymentPlanner) ExistingAppActions(appName, manifestPath, appPath string, options AutopilotOptions) []rewind.Action {
+	appRepo := planner.Repo
+
+	// the venerable app's routes, remembered so an unmap can be undone
+	var venerableRoutes Route
+	// the same, when only the routes on some domains are unmapped
+	var venerableURLs []string
+	// set once the venerable app's routes are unmapped, so that unmapping
+	// again does not forget them
+	var venerableUnmapped bool
+	// the live app's routes, which the new app must take over
+	var liveRoutes []string
+	// the app's entry in the manifest
+	var manifestApp ManifestApplication
+	// randomRoute is set when the manifest asks for a random route, which
+	// is replaced by the live app's routes
+	var randomRoute bool
+	// holdsRoutes says whether the production routes wait until after the
+	// push, whatever the manifest asks for
+	holdsRoutes := options.TestRoute != "" || options.Domains.Active() || options.CutoverOrder.Enabled() || options.ReadyLog.Enabled() || options.VerifySSH != ""
+	// the temporary route the new app is verified on before it gets the
+	// production routes
+	var testRoute string
+	// the route services bound to those routes
+	var routeServiceBindings []RouteServiceBinding
+	// the live app's log drains the manifest does not bind
+	var logDrains []string
+	// with --staged-start, the live app's instance count and the counts the
+	// new app is scaled through
+	var oldInstances int
+	var stagedCounts []int
+	// when the push began, for the crashes since
+	var pushed time.Time
+
+	// the production routes the new app takes over: the manifest's, or the
+	// live app's when the manifest leaves them to the foundation
+	productionRoutes := func() []string {
+		routes := liveRoutes
+		if _, known := manifestApp.IntendedRoutes(); known {
+			routes = manifestApp.RouteURLs()
+		}
+		return options.Domains.Filter(routes)
+	}
+
+	unmapVenerable := func() error {
+		if venerableUnmapped {
+			return nil
+		}
+
+		if len(liveRoutes) == 0 {
+			planner.Logger.Printf("The old version of the app has no routes to unmap.\n")
+			return nil
+		}
+
+		if options.Domains.Active() {
+			urls, err := appRepo.AppRoutes(planner.Naming.VenerableName(appName))
+			if err != nil {
+				return err
+			}
+			venerableURLs = options.Domains.Filter(urls)
+			if len(venerableURLs) == 0 {
+				return nil
+			}
+
+			planner.Logger.Printf("Unmapping old version of the app from %s.\n", strings.Join(venerableURLs, ", "))
+			venerableUnmapped = true
+			return tolerateRouteErrors(appRepo.UnmapRouteURLs(planner.Naming.VenerableName(appName), venerableURLs), options.ContinueOnRouteError)
+		}
+
+		route, err := routesOf(appRepo, planner.Naming.VenerableName(appName))
+
+		if err != nil {
+			return fmt.Errorf("Error finding Urls: %s", err)
+		}
+		venerableRoutes = route
+
+		planner.Logger.Printf("Unmapping old version of the app.\n")
+		venerableUnmapped = true
+		return tolerateRouteErrors(appRepo.UnmapRoutes(planner.Naming.VenerableName(appName), route), options.ContinueOnRouteError)
+	}
+
+	// gives back any routes that were unmapped before a failure, since the
+	// venerable app is about to go live again
+	remapVenerable := func() error {
+		venerableUnmapped = false
+		if len(venerableURLs) > 0 {
+			return appRepo.MapRouteURLs(planner.Naming.VenerableName(appName), venerableURLs)
+		}
+
+		if len(venerableRoutes.URLs()) == 0 {
+			return nil
+		}
+
+		return appRepo.MapRoutes(planner.Naming.VenerableName(appName), venerableRoutes)
+	}
+
+	venerable := planner.Naming.VenerableName(appName)
+	rotation := planner.newServiceKeyRotation(appName, options.RotateServiceKeys)
+
+	// scales the old version back up after a staged start that went wrong
+	restoreOldInstances := func() error {
+		if len(stagedCounts) == 0 {
+			return nil
+		}
+
+		return appRepo.ScaleInstances(venerable, oldInstances)
+	}
+
+	// the lookups of a resumed deploy need to know which steps it skips
+	var actions []rewind.Action
+	actions = []rewind.Action{
+		// make sure both versions fit in the quota before touching anything
+		{
+			Name: "check quota",
+			Forward: func() error {
+				// a staged start never runs both versions in full
+				if options.StagedStart > 0 {
+					return nil
+				}
+
+				needs, err := pushQuotaNeeds(appName, manifestPath, options)
+				if err != nil {
+					return err
+				}
+
+				return appRepo.CheckQuota(appName, needs)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Check the space's quota has room for a second copy of %s, sized and routed as %s says, and for its new routes.", appName, manifestPath),
+				When:    describeIf(options.StagedStart > 0, "not with --staged-start, which was given, so it does nothing"),
+			},
+		},
+		// make sure no other app holds the manifest's routes
+		{
+			Name: "check routes available",
+			Forward: func() error {
+				manifest, err := ParseManifest(manifestPath)
+				if err != nil {
+					return err
+				}
+
+				manifestApp, _ = manifest.Application(appName)
+				err = checkRoutesFrom(manifestApp, manifestPath, options)
+				if err != nil {
+					return err
+				}
+
+				return appRepo.CheckRoutesAvailable(appName, manifestApp.RouteURLs())
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Check no other app holds the routes declared in %s.", manifestPath),
+			},
+		},
+		// and that the services it binds are there to bind
+		planner.checkServicesAction(appName, manifestPath),
+		// remember the live app's routes
+		{
+			Name:   "remember routes",
+			Lookup: true,
+			Forward: func() error {
+				liveName := appName
+				if planner.ResumeFrom != "" {
+					// the manifest is read when the routes are checked, which
+					// a resumed deploy may skip
+					manifest, err := ParseManifest(manifestPath)
+					if err != nil {
+						return err
+					}
+					manifestApp, _ = manifest.Application(appName)
+
+					// and the live app may have been renamed already
+					if planner.resumesAfter(actions, "rename live app") {
+						liveName = venerable
+					}
+				}
+
+				var err error
+				liveRoutes, err = appRepo.AppRoutes(liveName)
+				if err != nil {
+					return err
+				}
+
+				randomRoute = len(liveRoutes) > 0 && asksForRandomRoute(manifestApp, options.PushArgs)
+				if randomRoute {
+					planner.Logger.Printf("Warning: %s\n", randomRouteWarning(liveRoutes))
+				}
+
+				if options.TestRoute != "" {
+					testRoute, err = ExpandTemplate("test route", options.TestRoute, options.TemplateData(appName, manifestApp))
+					if err != nil {
+						return err
+					}
+
+					testRoute, err = appRepo.ResolveTestRoute(appName, testRoute, planner.Naming.TempRouteName(appName))
+					if err != nil {
+						return err
+					}
+				}
+
+				if options.StagedStart > 0 {
+					config, err := appRepo.AppConfig(liveName)
+					if err != nil {
+						return err
+					}
+
+					oldInstances = config.Instances
+					target := oldInstances
+					if manifestApp.Instances != nil {
+						target = *manifestApp.Instances
+					}
+					if options.Scale.Instances > 0 {
+						target = options.Scale.Instances
+					}
+					if target > 1 {
+						stagedCounts = StagedInstances(target, options.StagedStart)
+					}
+				}
+
+				drains, err := appRepo.LogDrains(liveName)
+				if err != nil {
+					return err
+				}
+				logDrains = unboundLogDrains(drains, manifestApp)
+
+				if !options.CopyRouteServices {
+					return nil
+				}
+
+				routeServiceBindings, err = appRepo.RouteServiceBindings(liveName)
+				return err
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Remember the routes and log drains of %s%s%s%s.", appName,
+					describeIf(options.TestRoute != "", ", and pick the test route"),
+					describeIf(options.StagedStart > 0, ", and how many instances it runs"),
+					describeIf(options.CopyRouteServices, ", and the route services bound to them")),
+			},
+		},
+		// the org's guardrails have their say before anything changes
+		planner.policyAction(PolicyCutover, appName, options, &actions, true, nil),
+		// delete old version if it still exists
+		{
+			Name: "delete old venerable app",
+			Forward: func() error {
+				appExists, err := appRepo.DoesAppExist(planner.Naming.VenerableName(appName))
+				if err != nil {
+					return err
+				}
+				if appExists {
+					err = planner.verifyManaged(appName, venerable, options.ForceNameCollision)
+					if err != nil {
+						return err
+					}
+
+					// an old version kept by an earlier deploy is deleted
+					// here, so the policy has its say on it as well
+					err = planner.consultPolicy(PolicyDelete, appName, options, actions)
+					if err != nil {
+						return err
+					}
+
+					planner.Logger.Printf("Found old version of app running, deleting.\n")
+					return appRepo.DeleteApplication(planner.Naming.VenerableName(appName))
+				} else {
+					return nil
+				}
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Delete %s%s.", venerable, describeIf(planner.Policy.Enabled(), ", once the deploy policy allows it")),
+				When:    "only if it is left over from an earlier deploy, and marked as made by autopilot",
+			},
+		},
+		// fresh credentials for the new version
+		rotation.createAction(),
+		// rename
+		{
+			Name: "rename live app",
+			Forward: func() error {
+				err := appRepo.RenameApplication(appName, planner.Naming.VenerableName(appName))
+				if err != nil {
+					return err
+				}
+
+				planner.markManaged(planner.Naming.VenerableName(appName), appName)
+				return nil
+			},
+			Undo: func() error {
+				return appRepo.RenameApplication(planner.Naming.VenerableName(appName), appName)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Rename %s to %s.", appName, venerable),
+				Undo:    fmt.Sprintf("Rename %s back to %s.", venerable, appName),
+			},
+		},
+		// push
+		{
+			Name: "push",
+			Forward: func() error {
+				extraArgs := []string{}
+				if holdsRoutes || randomRoute || options.PremapRoutes {
+					// the production routes are mapped once the test route
+					// has been checked, the domains filtered or ordered, or
+					// the app has logged that it is ready
+					extraArgs = append(extraArgs, "--no-route")
+				}
+				pushOptions := rotation.pushOptions(options)
+				if randomRoute {
+					pushOptions.PushArgs = withoutRandomRoute(pushOptions.PushArgs)
+				}
+				pushOptions.LogDrains = logDrains
+				if options.PremapRoutes {
+					pushOptions.Premap = productionRoutes()
+				}
+				if len(stagedCounts) > 0 {
+					extraArgs = append(extraArgs, "-i", strconv.Itoa(stagedCounts[0]))
+					// the staged start scales up to --instances itself
+					pushOptions.Scale.Instances = 0
+				}
+
+				pushed = planner.Clock.Now()
+				return planner.push(appName, manifestPath, appPath, pushOptions, extraArgs...)
+			},
+			ReversePrevious: func() error {
+				// If the app cannot start we'll have a lingering application
+				// We delete this application so that the rename can be undone
+				appRepo.DeleteApplication(appName)
+				return nil
+			},
+			Undo: func() error {
+				return appRepo.DeleteApplication(appName)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Push the new version of %s with %s%s%s%s%s%s.", appName, manifestPath,
+					describeLifecycle(options),
+					describeIf(holdsRoutes && !options.PremapRoutes, ", without routes"),
+					describeIf(options.PremapRoutes, ", mapping the production routes to it before it starts"),
+					describeIf(options.StagedStart > 0, fmt.Sprintf(", starting with %d%% of its instances", options.StagedStart)),
+					describeIf(len(options.Env) > 0, ", setting "+strings.Join(options.Env.Names(), ", ")+" before starting it")),
+				ReversePrevious: "Delete the new version, if it was created.",
+				Undo:            "Delete the new version.",
+			},
+		},
+		// what the new version lost on the way
+		planner.compareVersionsAction(appName, venerable),
+		// wait for the new app to log that it is ready
+		planner.readyLogAction(appName, options.ReadyLog),
+		// and for it to settle without crashing
+		planner.crashWatchAction(appName, options.CrashWatch, &pushed),
+		// and answer for what only it can reach
+		planner.verifySSHAction(appName, options.VerifySSH),
+		// check the new app on the test route, then give it the production
+		// routes on the domains being moved
+		{
+			Name: "check test route",
+			Forward: func() error {
+				if !holdsRoutes && !randomRoute {
+					return nil
+				}
+
+				// the push mapped them already
+				if options.PremapRoutes {
+					return nil
+				}
+
+				if testRoute != "" {
+					err := planner.checkTestRoute(appName, testRoute, options)
+					if err != nil {
+						return err
+					}
+				}
+
+				routes := productionRoutes()
+				if len(routes) == 0 {
+					return nil
+				}
+
+				return planner.mapInOrder(appName, routes, options)
+			},
+			Description: rewind.Description{
+				Forward: "Map the new version to the test route" +
+					describeIf(options.TestRouteService != "", ", bind the "+options.TestRouteService+" route service to it,") +
+					" and check it answers" +
+					describeIf(options.Probe.Enabled(), " on "+options.Probe.Path) +
+					describeIf(options.TestRouteHTTPSOnly, " and refuses plain http") +
+					describeIf(options.Warmup > 0 || options.WarmupRequests > 0, ", warm it up") +
+					", unmap the test route, then map the production routes" +
+					describeIf(options.Domains.Active(), " on the domains being moved") +
+					describeIf(options.CutoverOrder.Enabled(), " a domain at a time, in --cutover-order") +
+					describeIf(options.CutoverOrder.Pause > 0, fmt.Sprintf(", waiting %s between them", options.CutoverOrder.Pause)) + ".",
+				When: describeChoice(options.PremapRoutes, "not with --premap-routes, which was given, so it does nothing",
+					onlyWith("--test-route, --only-domains, --exclude-domains, --cutover-order, --ready-log-pattern or --verify-ssh", holdsRoutes)+
+						describeIf(!holdsRoutes, ", unless the manifest asks for a random route")),
+			},
+		},
+		// bind route services back to routes that lost them
+		{
+			Name: "restore route services",
+			Forward: func() error {
+				return appRepo.RestoreRouteServiceBindings(routeServiceBindings)
+			},
+			Description: rewind.Description{
+				Forward: "Bind the old version's route services to the routes that lost them.",
+				When:    onlyWith("--copy-route-services", options.CopyRouteServices),
+			},
+		},
+		// make sure the new app took over every route
+		{
+			Name: "verify routes",
+			Forward: func() error {
+				return planner.verifyRoutes(appName, liveRoutes, manifestApp, options)
+			},
+			Description: rewind.Description{
+				Forward: describeVerifyRoutes(options),
+			},
+		},
+		// scale the new version up and the old one down in steps
+		{
+			Name: "staged start",
+			Forward: func() error {
+				if len(stagedCounts) == 0 {
+					return nil
+				}
+
+				return planner.stagedStart(appName, venerable, oldInstances, stagedCounts)
+			},
+			ReversePrevious: restoreOldInstances,
+			Undo:            restoreOldInstances,
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Scale the new version up %d%% of its instances at a time, checking it is healthy after each step, and scale %s down to match.",
+					options.StagedStart, venerable),
+				When:            onlyWith("--staged-start", options.StagedStart > 0),
+				ReversePrevious: fmt.Sprintf("Scale %s back up.", venerable),
+				Undo:            fmt.Sprintf("Scale %s back up.", venerable),
+			},
+		},
+		// let the old version finish its in-flight requests
+		{
+			Name: "drain",
+			Forward: func() error {
+				if options.DrainWait == 0 || (options.UnmapRoute && !options.KeepExisting) || options.KeepRunning {
+					return nil
+				}
+
+				err := unmapVenerable()
+				if err != nil {
+					return err
+				}
+
+				planner.Logger.Printf("Waiting %s for the old version of the app to drain.\n", options.DrainWait)
+				return planner.sleep(options.DrainWait)
+			},
+			ReversePrevious: remapVenerable,
+			Description: rewind.Description{
+				Forward:         fmt.Sprintf("Unmap %s and wait %s for it to finish its requests.", venerable, options.DrainWait),
+				When:            onlyWith("--drain-wait", options.DrainWait > 0 && !(options.UnmapRoute && !options.KeepExisting) && !options.KeepRunning),
+				ReversePrevious: fmt.Sprintf("Map the routes back to %s.", venerable),
+			},
+		},
+		// make sure the routes reach the new version now
+		planner.routeCheckAction(appName, &manifestApp, options, unmapVenerable, remapVenerable),
+		// and keep serving them, with the old version to fall back to
+		planner.probeWatchAction(appName, options, unmapVenerable, remapVenerable),
+		// and again before the old version is gone for good
+		planner.policyAction(PolicyDelete, appName, options, &actions, !options.keepsVenerable(), remapVenerable),
+		// delete/unmap
+
+		{
+			Name: "retire old version",
+			Forward: func() error {
+				if options.KeepExisting {
+					planner.Logger.Printf("Stopping old version of app. Remove the --keep-existing-app flag to delete it automatically.\n")
+					return appRepo.StopApplication(planner.Naming.VenerableName(appName))
+				} else if options.UnmapRoute {
+					planner.Logger.Printf("Unmapping routes for the venerable app. Remove the --unmap-routes flag to delete the old version.\n")
+					return unmapVenerable()
+				} else if options.KeepRunning {
+					planner.Logger.Printf("Leaving the old version of the app running with its routes, as the config file asks.\n")
+					return nil
+				} else {
+					if options.Domains.Active() {
+						planner.Logger.Printf("Warning: routes on the domains that were not moved are left unmapped when the old version is deleted. Use --unmap-routes to keep it serving them.\n")
+					}
+					planner.Logger.Printf("Deleting old version of app. Use the --keep-existing-app flag to preserve it.\n")
+					return appRepo.DeleteApplication(planner.Naming.VenerableName(appName))
+				}
+			},
+			ReversePrevious: remapVenerable,
+			Description: rewind.Description{
+				Forward:         describeRetirement(venerable, options),
+				ReversePrevious: fmt.Sprintf("Map the routes back to %s.", venerable),
+			},
+		},
+		// delete the old version's routes the new one did not take over
+		{
+			Name: "delete orphaned routes",
+			Forward: func() error {
+				// a kept venerable app may need its routes for a rollback
+				if !options.DeleteOrphanedRoutes || options.keepsVenerable() || len(liveRoutes) == 0 {
+					return nil
+				}
+
+				deleted, err := appRepo.DeleteOrphanedRoutes(liveRoutes)
+				if len(deleted) > 0 {
+					planner.Logger.Printf("Deleted orphaned routes %s.\n", strings.Join(deleted, ", "))
+				}
+
+				// the old version is gone, so there is nothing to roll back to
+				if err != nil {
+					planner.Logger.Printf("Warning: could not delete every orphaned route: %s\n", err)
+				}
+				return nil
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Delete the routes %s had that no app is mapped to any more.", venerable),
+				When:    onlyWith("--delete-orphaned-routes", options.DeleteOrphanedRoutes && !options.keepsVenerable()),
+			},
+		},
+		// the old version is gone, so its credentials can go too
+		rotation.deleteOldAction(options.keepsVenerable()),
+		// and it should hold nothing any more
+		planner.verifyRetiredAction(venerable, options.keepsVenerable()),
+	}
+	return actions
+}
+
+// resumesAfter is true when the deploy resumes from a step after the named
+// one, which the failed deploy already carried out.
+func (planner *DeploymentPlanner) resumesAfter(actions []rewind.Action, step string) bool {
+	if planner.ResumeFrom == "" {
+		return false
+	}
+
+	names := []string{}
+	for _, action := range actions {
+		names = append(names, action.Name)
+	}
+	return stepIndex(names, planner.ResumeFrom) > stepIndex(names, step)
+}
+
+// verifyRoutes checks the new app took over every route the manifest
+// declares, or the old version had when the manifest leaves them to the
+// foundation. Missing routes only fail the deploy with --strict-routes. With
+// --routes-from manifest, routes the manifest does not declare are unmapped.
+func (planner *DeploymentPlanner) verifyRoutes(appName string, liveRoutes []string, manifestApp ManifestApplication, options AutopilotOptions) error {
+	appRepo := planner.Repo
+
+	newRoutes, err := appRepo.AppRoutes(appName)
+	if err != nil {
+		return err
+	}
+
+	// when the manifest says which routes the app should have,
+	// those are the ones to check, not the old version's
+	expected := liveRoutes
+	description := "the old version had"
+	if intended, known := manifestApp.IntendedRoutes(); known {
+		dropped := MissingRoutes(liveRoutes, intended)
+		if len(dropped) > 0 {
+			planner.Logger.Printf("The manifest no longer declares these routes of the old version: %s\n", strings.Join(dropped, ", "))
+		}
+
+		expected = intended
+		description = "the manifest declares"
+	}
+
+	if options.RoutesFrom == RoutesFromManifest {
+		err = planner.unmapUndeclaredRoutes(appName, newRoutes, expected, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	missing := MissingRoutes(options.Domains.Filter(expected), newRoutes)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("The new version of the app is missing routes %s: %s", description, strings.Join(missing, ", "))
+	if options.StrictRoutes {
+		return errors.New(message + ".")
+	}
+
+	planner.Logger.Printf("Warning: %s. Use --strict-routes to fail the deploy instead.\n", message)
+	return nil
+}
+
+// describeRetirement says what happens to the old version once the new one
+// has taken over.
+func describeRetirement(venerable string, options AutopilotOptions) string {
+	if options.KeepExisting {
+		return fmt.Sprintf("Stop %s, keeping it for a rollback.", venerable)
+	} else if options.UnmapRoute {
+		return fmt.Sprintf("Unmap the routes of %s, leaving it running.", venerable)
+	} else if options.KeepRunning {
+		return fmt.Sprintf("Leave %s running with its routes.", venerable)
+	}
+
+	return fmt.Sprintf("Delete %s.", venerable)
+}
+
+func (planner *DeploymentPlanner) NewAppActions(appName, manifestPath, appPath string, options AutopilotOptions) []rewind.Action {
+	rotation := planner.newServiceKeyRotation(appName, options.RotateServiceKeys)
+
+	return []rewind.Action{
+		// fresh credentials for the app
+		rotation.createAction(),
+		// push
+		{
+			Name: "push",
+			Forward: func() error {
+				return planner.push(appName, manifestPath, appPath, rotation.pushOptions(options))
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Push %s with %s%s.", appName, manifestPath, describeLifecycle(options)),
+			},
+		},
+		// a new app has its routes to itself, so they can be probed directly
+		{
+			Name: "probe",
+			Forward: func() error {
+				if !options.Probe.Enabled() {
+					return nil
+				}
+
+				routes, err := planner.Repo.AppRoutes(appName)
+				if err != nil {
+					return err
+				}
+
+				if len(routes) == 0 {
+					return fmt.Errorf("App %s has no route to probe", appName)
+				}
+
+				return planner.probe("https://"+routes[0], options)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Check %s is ready by requesting %s on its first route.", appName,
+					describeChoice(options.Probe.Enabled(), options.Probe.Path, "the --probe-path")),
+				When: onlyWith("--probe-path", options.Probe.Enabled()),
+			},
+		},
+		// keys left over from an earlier app of the same name
+		rotation.deleteOldAction(false),
+	}
+}
+
+// probe checks the app answers on url, with the --probe options if given.
+func (planner *DeploymentPlanner) probe(url string, options AutopilotOptions) error {
+	if !options.Probe.Enabled() {
+		return ProbeURL(url, testRouteAttempts, testRouteInterval)
+	}
+
+	planner.Logger.Printf("Probing %s%s\n", url, options.Probe.Path)
+	return options.Probe.Probe(url, planner.Clock)
+}
+
+// push pushes the app, applying the overrides given on the command line
+// before it is started.
+func (planner *DeploymentPlanner) push(appName, manifestPath, appPath string, options AutopilotOptions, extraArgs ...string) error {
+	appRepo := planner.Repo
+	extraArgs = append(append(append([]string{}, options.PushArgs...), lifecycleArgs(options)...), extraArgs...)
+	if options.StartCommand != "" {
+		extraArgs = append(extraArgs, "-c", options.StartCommand)
+	}
+	extraArgs = append(extraArgs, options.HealthCheck.pushArgs()...)
+	extraArgs = append(extraArgs, options.Timeouts.pushArgs()...)
+
+	if len(options.Env) == 0 && options.Scale == (ScaleOptions{}) && len(options.LogDrains) == 0 && len(options.Premap) == 0 {
+		return appRepo.PushApplication(appName, manifestPath, appPath, extraArgs...)
+	}
+
+	err := appRepo.PushApplication(appName, manifestPath, appPath, append(extraArgs, "--no-start")...)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range options.Env.Names() {
+		err = appRepo.SetEnv(appName, name, options.Env[name])
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Scale != (ScaleOptions{}) {
+		err = appRepo.ScaleApplication(appName, options.Scale)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = planner.bindLogDrains(appName, options.LogDrains)
+	if err != nil {
+		return err
+	}
+
+	// the routers only send requests to instances once they are running, so
+	// the routes can be mapped while the app stages
+	if len(options.Premap) > 0 {
+		planner.Logger.Printf("Mapping %s to the new version before it starts.\n", strings.Join(options.Premap, ", "))
+		err = tolerateRouteErrors(appRepo.MapRouteURLs(appName, options.Premap), options.ContinueOnRouteError)
+		if err != nil {
+			return err
+		}
+	}
+
+	return appRepo.StartApplication(appName)
+}
+
+// ScaleActions replaces the app with a scaled clone, swapping the routes
+// over once the clone is running, so no instance of the original is restarted.
+func (planner *DeploymentPlanner) ScaleActions(appName string, options ScaleOptions) []rewind.Action {
+	appRepo := planner.Repo
+	cloneName := planner.Naming.ScaledName(appName)
+
+	return []rewind.Action{
+		// clone
+		{
+			Name: "clone",
+			Forward: func() error {
+				err := appRepo.CloneApplication(appName, cloneName, options)
+				if err != nil {
+					return err
+				}
+
+				planner.markManaged(cloneName, appName)
+				return nil
+			},
+			ReversePrevious: func() error {
+				appRepo.DeleteApplication(cloneName)
+				return nil
+			},
+			Undo: func() error {
+				return appRepo.DeleteApplication(cloneName)
+			},
+		},
+		// start
+		{
+			Name: "start clone",
+			Forward: func() error {
+				return appRepo.StartApplication(cloneName)
+			},
+		},
+		// map the routes to the clone
+		{
+			Name: "map routes to clone",
+			Forward: func() error {
+				return appRepo.CopyRoutes(appName, cloneName)
+			},
+			Undo: func() error {
+				return appRepo.RemoveRoutes(cloneName)
+			},
+		},
+		// unmap the routes from the original
+		{
+			Name: "unmap routes from original",
+			Forward: func() error {
+				return appRepo.RemoveRoutes(appName)
+			},
+			ReversePrevious: func() error {
+				return appRepo.CopyRoutes(cloneName, appName)
+			},
+			Undo: func() error {
+				return appRepo.CopyRoutes(cloneName, appName)
+			},
+		},
+		// delete original
+		{
+			Name: "delete original",
+			Forward: func() error {
+				return appRepo.DeleteApplication(appName)
+			},
+		},
+		// rename
+		{
+			Name: "rename clone",
+			Forward: func() error {
+				err := appRepo.RenameApplication(cloneName, appName)
+				if err != nil {
+					planner.Logger.Printf("Could not rename %s to %s, trying again: %s\n", cloneName, appName, err)
+					planner.Clock.Sleep(scaleRenameRetryWait)
+					err = appRepo.RenameApplication(cloneName, appName)
+				}
+				return err
+			},
+			// the original is gone, so the steps before cannot be undone:
+			// the clone, which serves the routes, only lacks the app's name
+			ReversePrevious: func() error {
+				return fmt.Errorf("%s has replaced %s, which is deleted, but could not be given its name. "+
+					"Finish the scale with: cf rename %s %s", cloneName, appName, cloneName, appName)
+			},
+			Description: rewind.Description{
+				Forward:         fmt.Sprintf("Rename %s to %s, trying again once if that fails.", cloneName, appName),
+				ReversePrevious: fmt.Sprintf("Stop, leaving %s serving the routes, with the command that renames it to %s.", cloneName, appName),
+			},
+		},
+	}
+}
+
+// scaleRenameRetryWait is how long a scale waits before trying again to
+// give the clone the app's name.
+const scaleRenameRetryWait = 5 * time.Second
+
+// onlyWith describes when an action that depends on a flag does anything.
+func onlyWith(flags string, given bool) string {
+	if given {
+		return "only with " + flags + ", which was given"
+	}
+
+	return "only with " + flags + ", which was not given, so it does nothing"
+}
+
+func describeIf(condition bool, text string) string {
+	if condition {
+		return text
+	}
+
+	return ""
+}
+
+func describeChoice(condition bool, ifTrue, ifFalse string) string {
+	if condition {
+		return ifTrue
+	}
+
+	return ifFalse
+}
@@ -0,0 +1,1049 @@
+package autopilot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/repository/repositoryfakes"
+	"github.com/concourse/autopilot/rewind"
+	"github.com/concourse/autopilot/routes"
+)
+
+// recordingRepo records the calls the planner makes, as "Method arg arg".
+// A call listed in failures returns that error.
+type recordingRepo struct {
+	calls     []string
+	failures  map[string]error
+	existing  map[string]bool
+	stopped   map[string]bool
+	instances map[string]int
+	routes    map[string][]string
+	keys      map[string][]string
+	// apps that were not made by autopilot; the rest are
+	unmanaged map[string]bool
+	markers   map[string]ManagedMarker
+	revisions map[string][]Revision
+	// what apps still hold; the rest do not exist by the time it is asked
+	resources map[string]AppResources
+	// the hosts ResolveTestRoute was given for --test-route auto
+	autoHosts []string
+	// what CheckQuota was last asked to make room for
+	quotaNeeds QuotaNeeds
+	// the states DeploymentState reports in turn, then DEPLOYED
+	deploymentStates []string
+	// the recent logs RecentLogs reports in turn, then the last again
+	logs [][]string
+	// the crash counts CrashCount reports in turn, then the last again
+	crashes []int
+	// the log drains bound to each app
+	drains map[string][]string
+	// what RunSSH prints
+	sshOutput []string
+	// the environment variables and services of each app
+	configs map[string]AppConfig
+}
+
+func newRecordingRepo() *recordingRepo {
+	return &recordingRepo{
+		failures:  map[string]error{},
+		existing:  map[string]bool{},
+		stopped:   map[string]bool{},
+		instances: map[string]int{},
+		routes:    map[string][]string{},
+		keys:      map[string][]string{},
+		unmanaged: map[string]bool{},
+		markers:   map[string]ManagedMarker{},
+		revisions: map[string][]Revision{},
+		resources: map[string]AppResources{},
+		drains:    map[string][]string{},
+		configs:   map[string]AppConfig{},
+	}
+}
+
+func (repo *recordingRepo) record(method string, args ...interface{}) error {
+	call := strings.TrimSpace(fmt.Sprintln(append([]interface{}{method}, args...)...))
+	repo.calls = append(repo.calls, call)
+	return repo.failures[call]
+}
+
+func (repo *recordingRepo) DoesAppExist(appName string) (bool, error) {
+	return repo.existing[appName], repo.record("DoesAppExist", appName)
+}
+func (repo *recordingRepo) AppNames() ([]string, error) {
+	names := []string{}
+	for name, exists := range repo.existing {
+		if exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, repo.record("AppNames")
+}
+func (repo *recordingRepo) IsAppStopped(appName string) (bool, error) {
+	return repo.stopped[appName], repo.record("IsAppStopped", appName)
+}
+func (repo *recordingRepo) RenameApplication(oldName, newName string) error {
+	return repo.record("RenameApplication", oldName, newName)
+}
+func (repo *recordingRepo) PushApplication(appName, manifestPath, appPath string, extraArgs ...string) error {
+	return repo.record("PushApplication", appName, manifestPath, appPath, extraArgs)
+}
+func (repo *recordingRepo) SetEnv(appName, name, value string) error {
+	return repo.record("SetEnv", appName, name, value)
+}
+func (repo *recordingRepo) StartApplication(appName string) error {
+	return repo.record("StartApplication", appName)
+}
+func (repo *recordingRepo) RestageApplication(appName string) error {
+	return repo.record("RestageApplication", appName)
+}
+func (repo *recordingRepo) CheckAppHealthy(appName string) error {
+	return repo.record("CheckAppHealthy", appName)
+}
+func (repo *recordingRepo) CrashCount(appName string, since time.Time) (int, error) {
+	err := repo.record("CrashCount", appName)
+	if len(repo.crashes) == 0 {
+		return 0, err
+	}
+
+	count := repo.crashes[0]
+	if len(repo.crashes) > 1 {
+		repo.crashes = repo.crashes[1:]
+	}
+	return count, err
+}
+func (repo *recordingRepo) GetAppState(appName string) (AppState, error) {
+	return AppState{Name: appName, State: "STARTED", Instances: repo.instances[appName], RunningInstances: repo.instances[appName]}, repo.record("GetAppState", appName)
+}
+func (repo *recordingRepo) Instances(appName string) ([]InstanceStatus, error) {
+	statuses := []InstanceStatus{}
+	for i := 0; i < repo.instances[appName]; i++ {
+		statuses = append(statuses, InstanceStatus{Index: i, State: "RUNNING"})
+	}
+	return statuses, repo.record("Instances", appName)
+}
+func (repo *recordingRepo) RecentLogs(appName string) ([]string, error) {
+	err := repo.record("RecentLogs", appName)
+	if len(repo.logs) == 0 {
+		return nil, err
+	}
+	lines := repo.logs[0]
+	if len(repo.logs) > 1 {
+		repo.logs = repo.logs[1:]
+	}
+	return lines, err
+}
+func (repo *recordingRepo) RunSSH(appName, command string) ([]string, error) {
+	return repo.sshOutput, repo.record("RunSSH", appName, command)
+}
+func (repo *recordingRepo) StopApplication(appName string) error {
+	return repo.record("StopApplication", appName)
+}
+func (repo *recordingRepo) ScaleInstances(appName string, instances int) error {
+	return repo.record("ScaleInstances", appName, instances)
+}
+func (repo *recordingRepo) ScaleApplication(appName string, options ScaleOptions) error {
+	return repo.record("ScaleApplication", appName, options.Instances, options.Memory, options.DiskQuota)
+}
+func (repo *recordingRepo) DeleteApplication(appName string) error {
+	return repo.record("DeleteApplication", appName)
+}
+func (repo *recordingRepo) CloneApplication(appName, cloneName string, options ScaleOptions) error {
+	return repo.record("CloneApplication", appName, cloneName)
+}
+func (repo *recordingRepo) AppConfig(appName string) (AppConfig, error) {
+	config := repo.configs[appName]
+	return AppConfig{Routes: repo.routes[appName], Instances: repo.instances[appName], Env: config.Env, Services: config.Services}, repo.record("AppConfig", appName)
+}
+func (repo *recordingRepo) AppResources(appName string) (AppResources, error) {
+	return repo.resources[appName], repo.record("AppResources", appName)
+}
+func (repo *recordingRepo) CheckQuota(appName string, needs QuotaNeeds) error {
+	repo.quotaNeeds = needs
+	return repo.record("CheckQuota", appName)
+}
+func (repo *recordingRepo) CheckRoutesAvailable(appName string, urls []string) error {
+	return repo.record("CheckRoutesAvailable", appName, urls)
+}
+func (repo *recordingRepo) CheckServicesBindable(serviceNames []string) error {
+	return repo.record("CheckServicesBindable", serviceNames)
+}
+func (repo *recordingRepo) ResolveTestRoute(appName, testRoute, autoHost string) (string, error) {
+	repo.autoHosts = append(repo.autoHosts, autoHost)
+	return testRoute, repo.record("ResolveTestRoute", appName, testRoute)
+}
+func (repo *recordingRepo) AppRoutes(appName string) ([]string, error) {
+	return repo.routes[appName], repo.record("AppRoutes", appName)
+}
+func (repo *recordingRepo) FindUrls(appName string) (Route, error) {
+	route := Route{Domain: "example.com"}
+	for i, url := range repo.routes[appName] {
+		parts := append(strings.SplitN(url, ".", 2), route.Domain)
+		if i == 0 {
+			route.Domain = parts[1]
+		}
+		route.Host = append(route.Host, parts[0])
+		route.Domains = append(route.Domains, parts[1])
+	}
+	return route, repo.record("FindUrls", appName)
+}
+func (repo *recordingRepo) MapRoutes(appName string, route Route) error {
+	return repo.record("MapRoutes", appName, routes.Hosts(route))
+}
+func (repo *recordingRepo) UnmapRoutes(appName string, route Route) error {
+	return repo.record("UnmapRoutes", appName, routes.Hosts(route))
+}
+func (repo *recordingRepo) MapRouteURLs(appName string, urls []string) error {
+	return repo.record("MapRouteURLs", appName, urls)
+}
+func (repo *recordingRepo) UnmapRouteURLs(appName string, urls []string) error {
+	return repo.record("UnmapRouteURLs", appName, urls)
+}
+func (repo *recordingRepo) CopyRoutes(fromApp, toApp string) error {
+	return repo.record("CopyRoutes", fromApp, toApp)
+}
+func (repo *recordingRepo) RemoveRoutes(appName string) error {
+	return repo.record("RemoveRoutes", appName)
+}
+func (repo *recordingRepo) DeleteOrphanedRoutes(urls []string) ([]string, error) {
+	return urls, repo.record("DeleteOrphanedRoutes", urls)
+}
+func (repo *recordingRepo) RevisionsEnabled(appName string) (bool, error) {
+	return len(repo.revisions[appName]) > 0, repo.record("RevisionsEnabled", appName)
+}
+func (repo *recordingRepo) Revisions(appName string) ([]Revision, error) {
+	return repo.revisions[appName], repo.record("Revisions", appName)
+}
+func (repo *recordingRepo) DeployRevision(appName, revisionGuid string) (string, error) {
+	return "deployment-guid", repo.record("DeployRevision", appName, revisionGuid)
+}
+func (repo *recordingRepo) DeploymentState(deploymentGuid string) (string, error) {
+	state := "DEPLOYED"
+	if len(repo.deploymentStates) > 0 {
+		state, repo.deploymentStates = repo.deploymentStates[0], repo.deploymentStates[1:]
+	}
+	return state, repo.record("DeploymentState", deploymentGuid)
+}
+func (repo *recordingRepo) CancelDeployment(deploymentGuid string) error {
+	return repo.record("CancelDeployment", deploymentGuid)
+}
+func (repo *recordingRepo) RouteServiceBindings(appName string) ([]RouteServiceBinding, error) {
+	return nil, repo.record("RouteServiceBindings", appName)
+}
+func (repo *recordingRepo) RestoreRouteServiceBindings(bindings []RouteServiceBinding) error {
+	return repo.record("RestoreRouteServiceBindings")
+}
+func (repo *recordingRepo) BindRouteServiceToURL(url, serviceName string) error {
+	return repo.record("BindRouteServiceToURL", url, serviceName)
+}
+func (repo *recordingRepo) UnbindRouteServiceFromURL(url, serviceName string) error {
+	return repo.record("UnbindRouteServiceFromURL", url, serviceName)
+}
+func (repo *recordingRepo) LogDrains(appName string) ([]string, error) {
+	return repo.drains[appName], repo.record("LogDrains", appName)
+}
+func (repo *recordingRepo) BindService(appName, serviceName string) error {
+	return repo.record("BindService", appName, serviceName)
+}
+func (repo *recordingRepo) CheckCopyTarget(appName, spaceName, copyName string) error {
+	return repo.record("CheckCopyTarget", appName, spaceName, copyName)
+}
+func (repo *recordingRepo) CopyApplicationToSpace(appName, spaceName, copyName string, instances int) (string, error) {
+	return "copy-guid", repo.record("CopyApplicationToSpace", appName, spaceName, copyName, instances)
+}
+func (repo *recordingRepo) BindServicesToCopy(appName, copyGuid string) error {
+	return repo.record("BindServicesToCopy", appName, copyGuid)
+}
+func (repo *recordingRepo) CopyDroplet(appName, copyGuid string) error {
+	return repo.record("CopyDroplet", appName, copyGuid)
+}
+func (repo *recordingRepo) StartAppByGuid(appGuid string) error {
+	return repo.record("StartAppByGuid", appGuid)
+}
+func (repo *recordingRepo) DeleteAppByGuid(appGuid string) error {
+	return repo.record("DeleteAppByGuid", appGuid)
+}
+func (repo *recordingRepo) MarkManaged(appName string, marker ManagedMarker) error {
+	return repo.record("MarkManaged", appName, marker.OriginalName)
+}
+func (repo *recordingRepo) ManagedMarker(appName string) (ManagedMarker, bool, error) {
+	return repo.markers[appName], !repo.unmanaged[appName], repo.record("ManagedMarker", appName)
+}
+func (repo *recordingRepo) CreateServiceKey(serviceName, keyName string) (string, error) {
+	return fmt.Sprintf(`{"key":%q}`, keyName), repo.record("CreateServiceKey", serviceName, keyName)
+}
+func (repo *recordingRepo) ServiceKeys(serviceName string) ([]string, error) {
+	return repo.keys[serviceName], repo.record("ServiceKeys", serviceName)
+}
+func (repo *recordingRepo) DeleteServiceKey(serviceName, keyName string) error {
+	return repo.record("DeleteServiceKey", serviceName, keyName)
+}
+
+func indexOf(calls []string, call string) int {
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+type fakeClock struct {
+	slept []time.Duration
+}
+
+// Now moves on by the time slept.
+func (clock *fakeClock) Now() time.Time {
+	now := time.Date(2024, 6, 7, 12, 12, 0, 0, time.UTC)
+	for _, d := range clock.slept {
+		now = now.Add(d)
+	}
+	return now
+}
+
+func (clock *fakeClock) Sleep(d time.Duration) {
+	clock.slept = append(clock.slept, d)
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (logger *recordingLogger) Printf(format string, args ...interface{}) {
+	logger.messages = append(logger.messages, fmt.Sprintf(format, args...))
+}
+
+// prefixNaming is a naming an embedder might give the planner.
+type prefixNaming struct{}
+
+func (prefixNaming) VenerableName(appName string) string { return "old-" + appName }
+func (prefixNaming) RollbackName(appName string) string  { return "rollback-" + appName }
+func (prefixNaming) ScaledName(appName string) string    { return "scaled-" + appName }
+func (prefixNaming) TempRouteName(appName string) string { return "verify-" + appName }
+
+var _ = Describe("DeploymentPlanner", func() {
+	var (
+		repo         *recordingRepo
+		clock        *fakeClock
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	BeforeEach(func() {
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manifest.WriteString("applications:\n- name: app\n")
+		Expect(err).ToNot(HaveOccurred())
+		manifest.Close()
+		manifestPath = manifest.Name()
+
+		repo = newRecordingRepo()
+		clock = &fakeClock{}
+		planner = &DeploymentPlanner{
+			Repo:   repo,
+			Naming: SuffixNaming{},
+			Clock:  clock,
+			Logger: discardLogger{},
+		}
+	})
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	execute := func(actions []rewind.Action) error {
+		return rewind.Actions{Actions: actions}.Execute()
+	}
+
+	Describe("PushActions", func() {
+		It("just pushes an app that does not exist yet", func() {
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(execute(actions)).To(Succeed())
+
+			Expect(repo.calls).To(Equal([]string{
+				"DoesAppExist app",
+				"PushApplication app " + manifestPath + "  []",
+			}))
+		})
+
+		It("passes the buildpacks and lifecycle on to cf push", func() {
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{Buildpacks: []string{"apm_buildpack", "go_buildpack"}, Lifecycle: "cnb"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(execute(actions)).To(Succeed())
+
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-b apm_buildpack -b go_buildpack --lifecycle cnb]"))
+		})
+
+		It("passes the start command on to cf push", func() {
+			repo.existing["app"] = true
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{StartCommand: "bundle exec puma -C config/puma.rb"}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-c bundle exec puma -C config/puma.rb]"))
+		})
+
+		It("passes the start timeout on to cf push", func() {
+			err := execute(planner.NewAppActions("app", manifestPath, "", AutopilotOptions{Timeouts: Timeouts{Staging: 30 * time.Minute, Startup: 90 * time.Second}}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-t 90]"))
+		})
+
+		It("passes the health check on to cf push", func() {
+			repo.existing["app"] = true
+			options := AutopilotOptions{HealthCheck: HealthCheck{Type: "http", Endpoint: "/healthz"}}
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", options))).To(Succeed())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-u http --endpoint /healthz]"))
+
+			actions := planner.ExistingAppActions("app", manifestPath, "", options)
+			Expect(actions[8].Description.Forward).To(ContainSubstring(", with an http health check on /healthz"))
+		})
+
+		It("scales the new app with the overrides before starting it", func() {
+			repo.existing["app"] = true
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{Scale: ScaleOptions{Instances: 6, Memory: 2048}}))
+			Expect(err).ToNot(HaveOccurred())
+
+			push := indexOf(repo.calls, "PushApplication app "+manifestPath+"  [--no-start]")
+			scale := indexOf(repo.calls, "ScaleApplication app 6 2048 0")
+			start := indexOf(repo.calls, "StartApplication app")
+			Expect(push).To(BeNumerically(">=", 0))
+			Expect(scale).To(BeNumerically(">", push))
+			Expect(start).To(BeNumerically(">", scale))
+		})
+
+		It("rejects a lifecycle cf push cannot stage before touching the live app", func() {
+			repo.existing["app"] = true
+
+			_, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{Lifecycle: "cnb"})
+			Expect(err).To(MatchError(ContainSubstring("The cnb lifecycle needs at least one buildpack")))
+			Expect(repo.calls).To(BeEmpty())
+		})
+
+		It("replaces an existing app", func() {
+			repo.existing["app"] = true
+			repo.routes["app"] = []string{"app.example.com"}
+
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			repo.calls = nil
+			Expect(execute(actions)).To(Succeed())
+			Expect(repo.calls).To(ContainElement("RenameApplication app app-venerable"))
+			Expect(repo.calls[len(repo.calls)-2:]).To(Equal([]string{"DeleteApplication app-venerable", "AppResources app-venerable"}))
+		})
+
+		It("refuses to replace a stopped app unless allowed", func() {
+			repo.existing["app"] = true
+			repo.stopped["app"] = true
+
+			_, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{})
+			Expect(err).To(MatchError(ContainSubstring("App app is stopped, so there is no traffic to move to the new version. Use --allow-stopped-app")))
+		})
+
+		It("replaces a stopped app by pushing, verifying and deleting it", func() {
+			repo.existing["app"] = true
+			repo.stopped["app"] = true
+			repo.routes["app"] = []string{"app.example.com"}
+
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{AllowStoppedApp: true, TestRoute: "auto", DrainWait: time.Minute})
+			Expect(err).ToNot(HaveOccurred())
+
+			repo.calls = nil
+			Expect(execute(actions)).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"CheckRoutesAvailable app []",
+				"AppRoutes app",
+				"DoesAppExist app-venerable",
+				"RenameApplication app app-venerable",
+				"MarkManaged app-venerable app",
+				"PushApplication app " + manifestPath + "  []",
+				"AppRoutes app",
+				"DeleteApplication app-venerable",
+				"AppResources app-venerable",
+			}))
+			Expect(clock.slept).To(BeEmpty())
+		})
+
+		It("renames a stopped app back when its replacement fails to push", func() {
+			repo.existing["app"] = true
+			repo.stopped["app"] = true
+			repo.failures["PushApplication app "+manifestPath+"  []"] = errors.New("staging failed")
+
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{AllowStoppedApp: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(execute(actions)).To(MatchError("staging failed"))
+			Expect(repo.calls[len(repo.calls)-2:]).To(Equal([]string{
+				"DeleteApplication app",
+				"RenameApplication app-venerable app",
+			}))
+		})
+
+		It("probes a new app on its own route", func() {
+			repo.failures["AppRoutes app"] = errors.New("no routes api")
+
+			actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{Probe: Prober{Path: "/healthz"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(execute(actions)).To(MatchError("no routes api"))
+		})
+
+		It("returns errors from finding the app", func() {
+			repo.failures["DoesAppExist app"] = errors.New("no api")
+
+			_, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{})
+			Expect(err).To(MatchError("no api"))
+		})
+	})
+
+	It("plans with the generated fake repository", func() {
+		fake := &repositoryfakes.FakeApplicationRepository{}
+		fake.DoesAppExistReturns(false, nil)
+		fake.PushApplicationReturns(errors.New("push failed"))
+		planner.Repo = fake
+
+		actions, err := planner.PushActions("app", manifestPath, "", AutopilotOptions{PushArgs: []string{"-t", "180"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(execute(actions)).To(MatchError("push failed"))
+
+		Expect(fake.PushApplicationCallCount()).To(Equal(1))
+		appName, _, _, extraArgs := fake.PushApplicationArgsForCall(0)
+		Expect(appName).To(Equal("app"))
+		Expect(extraArgs).To(Equal([]string{"-t", "180"}))
+	})
+
+	It("replaces a live app through the generated fake with the planner's defaults, as tools embedding it do", func() {
+		fake := &repositoryfakes.FakeApplicationRepository{}
+		fake.DoesAppExistStub = func(appName string) (bool, error) {
+			return appName == "app", nil
+		}
+
+		actions, err := NewDeploymentPlanner(fake).PushActions("app", manifestPath, "", AutopilotOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		captureStdout(func() {
+			err = execute(actions)
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fake.RenameApplicationCallCount()).To(Equal(1))
+		from, to := fake.RenameApplicationArgsForCall(0)
+		Expect([]string{from, to}).To(Equal([]string{"app", "app-venerable"}))
+		Expect(fake.PushApplicationCallCount()).To(Equal(1))
+		Expect(fake.DeleteApplicationCallCount()).To(Equal(1))
+		Expect(fake.DeleteApplicationArgsForCall(0)).To(Equal("app-venerable"))
+	})
+
+	Describe("ExistingAppActions", func() {
+		It("rolls back through the rewind engine when the old venerable app cannot be checked", func() {
+			repo.failures["DoesAppExist app-venerable"] = errors.New("api down")
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))
+			Expect(err).To(MatchError("api down"))
+			Expect(repo.calls).ToNot(ContainElement("RenameApplication app app-venerable"))
+		})
+
+		It("undoes the rename when the push fails", func() {
+			repo.failures["PushApplication app "+manifestPath+"  []"] = errors.New("staging failed")
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))
+			Expect(err).To(MatchError("staging failed"))
+			Expect(repo.calls[len(repo.calls)-2:]).To(Equal([]string{
+				"DeleteApplication app",
+				"RenameApplication app-venerable app",
+			}))
+		})
+
+		It("waits on the clock for the old app to drain", func() {
+			repo.routes["app"] = []string{"app.example.com"}
+			repo.routes["app-venerable"] = []string{"app.example.com"}
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{DrainWait: time.Minute}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clock.slept).To(Equal([]time.Duration{time.Minute}))
+			Expect(repo.calls).To(ContainElement("UnmapRoutes app-venerable [app]"))
+		})
+
+		It("stops draining as soon as the deploy is interrupted", func() {
+			repo.routes["app"] = []string{"app.example.com"}
+			repo.routes["app-venerable"] = []string{"app.example.com"}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			planner.Context = ctx
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{DrainWait: time.Minute}))
+			Expect(err).To(Equal(context.Canceled))
+			Expect(clock.slept).To(BeEmpty())
+			Expect(repo.calls).To(ContainElement("MapRoutes app-venerable [app]"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+
+		It("gives the old version its routes back when they do not reach the new one", func() {
+			repo.routes["app"] = []string{"localhost"}
+			repo.routes["app-venerable"] = []string{"localhost"}
+
+			options := AutopilotOptions{DrainWait: time.Minute, RouteCheck: RouteCheck{Body: "version 2"}}
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", options))
+			Expect(err).To(MatchError(HavePrefix("https://localhost is still served by the old version after 10 requests")))
+
+			unmaps := []string{}
+			for _, call := range repo.calls {
+				if strings.Contains(call, "app-venerable [") {
+					unmaps = append(unmaps, call)
+				}
+			}
+			Expect(unmaps).To(Equal([]string{
+				"UnmapRoutes app-venerable [localhost]",
+				"MapRoutes app-venerable [localhost]",
+			}))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+		})
+
+		It("does not check the routes while the old version keeps them", func() {
+			repo.routes["app"] = []string{"localhost"}
+
+			options := AutopilotOptions{KeepRunning: true, RouteCheck: RouteCheck{Body: "version 2"}}
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", options))).To(Succeed())
+			Expect(repo.calls).ToNot(ContainElement(HavePrefix("UnmapRoutes")))
+		})
+
+		It("binds the live app's log drains the manifest does not to the new version before it starts", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: app\n  services: [splunk]\n"), 0600)).To(Succeed())
+			repo.drains["app"] = []string{"papertrail", "splunk"}
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))).To(Succeed())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-start]"))
+			Expect(repo.calls).ToNot(ContainElement("BindService app splunk"))
+
+			binding := indexOf(repo.calls, "BindService app papertrail")
+			Expect(binding).To(BeNumerically(">", indexOf(repo.calls, "PushApplication app "+manifestPath+"  [--no-start]")))
+			Expect(binding).To(BeNumerically("<", indexOf(repo.calls, "StartApplication app")))
+		})
+
+		It("fails the deploy when a log drain cannot be bound", func() {
+			repo.drains["app"] = []string{"papertrail"}
+			repo.failures["BindService app papertrail"] = errors.New("service instance is being deleted")
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))
+			Expect(err).To(MatchError(HavePrefix("Could not bind log drain papertrail to app, so its logs would no longer be forwarded: service instance is being deleted.")))
+			Expect(repo.calls).ToNot(ContainElement("StartApplication app"))
+			Expect(repo.calls[len(repo.calls)-1]).To(Equal("RenameApplication app-venerable app"))
+		})
+
+		It("maps the production routes to the new version before it starts with --premap-routes", func() {
+			repo.routes["app"] = []string{"app.example.com", "www.example.com"}
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{PremapRoutes: true}))).To(Succeed())
+
+			push := indexOf(repo.calls, "PushApplication app "+manifestPath+"  [--no-route --no-start]")
+			premap := indexOf(repo.calls, "MapRouteURLs app [app.example.com www.example.com]")
+			Expect(push).To(BeNumerically(">=", 0))
+			Expect(premap).To(BeNumerically(">", push))
+			Expect(premap).To(BeNumerically("<", indexOf(repo.calls, "StartApplication app")))
+			Expect(indexOf(repo.calls, "DeleteApplication app-venerable")).To(BeNumerically(">", indexOf(repo.calls, "StartApplication app")))
+
+			// and not again once it is running
+			Expect(repo.calls[premap+1:]).ToNot(ContainElement(HavePrefix("MapRouteURLs app ")))
+		})
+
+		It("premaps the live app's routes with their paths and ports", func() {
+			repo.routes["app"] = []string{"app.example.com", "www.example.com/api", "tcp.example.com:1024"}
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{PremapRoutes: true}))).To(Succeed())
+			Expect(repo.calls).To(ContainElement("MapRouteURLs app [app.example.com www.example.com/api tcp.example.com:1024]"))
+		})
+
+		It("resumes after the rename, remembering the routes of the renamed app", func() {
+			repo.routes["app-venerable"] = []string{"app.example.com"}
+			state := DeployState{App: "app", Steps: []string{}}
+			for _, action := range planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}) {
+				state.Steps = append(state.Steps, action.Name)
+			}
+
+			actions, err := planner.ResumeActions("app", manifestPath, "", AutopilotOptions{ResumeFrom: "push"}, state)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rewind.Actions{Actions: actions, ResumeFrom: "push"}.Execute()).To(Succeed())
+
+			Expect(repo.calls).To(ContainElement("AppRoutes app-venerable"))
+			Expect(repo.calls).ToNot(ContainElement("RenameApplication app app-venerable"))
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  []"))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app-venerable"))
+		})
+
+		It("does not resume a deploy planned with other options", func() {
+			state := DeployState{App: "app", Steps: []string{"push", "probe"}}
+			_, err := planner.ResumeActions("app", manifestPath, "", AutopilotOptions{ResumeFrom: "probe", KeepExisting: true}, state)
+			Expect(err).To(MatchError(HavePrefix("The steps of this deploy differ from those of the failed one.")))
+		})
+
+		It("deletes the routes left without an app", func() {
+			repo.routes["app"] = []string{"app.example.com", "old.example.com"}
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{DeleteOrphanedRoutes: true}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls[len(repo.calls)-3:]).To(Equal([]string{
+				"DeleteApplication app-venerable",
+				"DeleteOrphanedRoutes [app.example.com old.example.com]",
+				"AppResources app-venerable",
+			}))
+		})
+
+		It("fills in the test route template", func() {
+			repo.failures["ResolveTestRoute app app-r42-test.example.com"] = errors.New("stop here")
+
+			options := AutopilotOptions{TestRoute: "{{.AppName}}-{{.Revision}}-{{.Vars.stage}}.example.com", Revision: "r42", Vars: EnvVars{"stage": "test"}}
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", options))
+			Expect(err).To(MatchError("stop here"))
+		})
+
+		It("names the test route it picks with the planner's naming", func() {
+			planner.Naming = prefixNaming{}
+			repo.existing["app"] = true
+			repo.failures["ResolveTestRoute app auto"] = errors.New("stop here")
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{TestRoute: "auto"}))).To(MatchError("stop here"))
+			Expect(repo.autoHosts).To(Equal([]string{"verify-app"}))
+		})
+
+		It("fails on test route templates referring to missing vars", func() {
+			options := AutopilotOptions{TestRoute: "{{.Vars.stage}}.example.com"}
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", options))
+			Expect(err).To(MatchError(ContainSubstring("could not fill in the test route template")))
+			Expect(repo.calls).ToNot(ContainElement(ContainSubstring("ResolveTestRoute")))
+		})
+
+		It("sizes the quota check from the manifest, or the flags that override it", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: app\n  memory: 1G\n  instances: 3\n  routes:\n  - route: app.example.com/api\n  - route: tcp.example.com:1234\n"), 0600)).To(Succeed())
+
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{}))).To(Succeed())
+			Expect(repo.quotaNeeds).To(Equal(QuotaNeeds{Memory: 1024, Instances: 3, Routes: []string{"app.example.com/api", "tcp.example.com:1234"}}))
+
+			repo.calls = nil
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{Scale: ScaleOptions{Memory: 512}}))).To(Succeed())
+			Expect(repo.quotaNeeds.Memory).To(Equal(int64(512)))
+			Expect(repo.quotaNeeds.Instances).To(Equal(3))
+		})
+
+		It("scales the new version up and the old one down in stages", func() {
+			repo.instances["app"] = 4
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{StagedStart: 50}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).ToNot(ContainElement("CheckQuota app"))
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-i 2]"))
+
+			scaling := []string{}
+			for _, call := range repo.calls {
+				if strings.HasPrefix(call, "ScaleInstances") || strings.HasPrefix(call, "CheckAppHealthy") {
+					scaling = append(scaling, call)
+				}
+			}
+			Expect(scaling).To(Equal([]string{
+				"ScaleInstances app-venerable 2",
+				"ScaleInstances app 4",
+				"CheckAppHealthy app",
+				"ScaleInstances app-venerable 1",
+			}))
+		})
+
+		It("scales up in stages to the instances override", func() {
+			repo.instances["app"] = 2
+
+			options := AutopilotOptions{StagedStart: 50, Scale: ScaleOptions{Instances: 4, Memory: 1024}}
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", options))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-i 2 --no-start]"))
+			Expect(repo.calls).To(ContainElement("ScaleApplication app 0 1024 0"))
+			Expect(repo.calls).To(ContainElement("ScaleInstances app 4"))
+		})
+
+		It("scales the old version back up when a stage fails", func() {
+			repo.instances["app"] = 4
+			repo.failures["CheckAppHealthy app"] = errors.New("crashing")
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{StagedStart: 50}))
+			Expect(err).To(MatchError("crashing"))
+			Expect(repo.calls).To(ContainElement("ScaleInstances app-venerable 4"))
+			Expect(repo.calls[len(repo.calls)-1]).To(Equal("RenameApplication app-venerable app"))
+		})
+
+		It("gives the new version fresh service keys and deletes the old ones once it is live", func() {
+			repo.keys["orders-db"] = []string{"app-key-20240501-090000", "app-key-20240607-121200", "reporting"}
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{RotateServiceKeys: []string{"orders-db"}}))
+			Expect(err).ToNot(HaveOccurred())
+			created := indexOf(repo.calls, "CreateServiceKey orders-db app-key-20240607-121200")
+			Expect(created).ToNot(Equal(-1))
+			Expect(repo.calls[created+1 : created+4]).To(Equal([]string{
+				"RenameApplication app app-venerable",
+				"MarkManaged app-venerable app",
+				"PushApplication app " + manifestPath + "  [--no-start]",
+			}))
+			Expect(repo.calls).To(ContainElement(`SetEnv app ORDERS_DB_CREDENTIALS {"key":"app-key-20240607-121200"}`))
+			Expect(repo.calls[len(repo.calls)-3:]).To(Equal([]string{
+				"ServiceKeys orders-db",
+				"DeleteServiceKey orders-db app-key-20240501-090000",
+				"AppResources app-venerable",
+			}))
+		})
+
+		It("deletes the new service keys when the new version fails", func() {
+			repo.failures["StartApplication app"] = errors.New("crashing")
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{RotateServiceKeys: []string{"orders-db"}}))
+			Expect(err).To(MatchError("crashing"))
+			Expect(repo.calls[len(repo.calls)-1]).To(Equal("DeleteServiceKey orders-db app-key-20240607-121200"))
+		})
+
+		It("keeps the old service keys while the old version is kept running", func() {
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{RotateServiceKeys: []string{"orders-db"}, UnmapRoute: true}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).ToNot(ContainElement("ServiceKeys orders-db"))
+		})
+
+		It("leaves the routes of a kept venerable app alone", func() {
+			repo.routes["app"] = []string{"app.example.com"}
+
+			err := execute(planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{DeleteOrphanedRoutes: true, KeepExisting: true}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).ToNot(ContainElement(ContainSubstring("DeleteOrphanedRoutes")))
+		})
+
+		It("leaves the old version running with its routes when kept running", func() {
+			repo.routes["app"] = []string{"app.example.com"}
+			repo.routes["app-venerable"] = repo.routes["app"]
+
+			options := AutopilotOptions{KeepRunning: true, DrainWait: time.Minute, DeleteOrphanedRoutes: true, RotateServiceKeys: []string{"orders-db"}}
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", options))).To(Succeed())
+			Expect(repo.calls).ToNot(ContainElement(HavePrefix("Unmap")))
+			Expect(repo.calls).ToNot(ContainElement(HavePrefix("StopApplication")))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+			Expect(repo.calls).ToNot(ContainElement(ContainSubstring("DeleteOrphanedRoutes")))
+			Expect(repo.calls).ToNot(ContainElement("ServiceKeys orders-db"))
+			Expect(clock.slept).To(BeEmpty())
+		})
+
+		It("only moves routes on the chosen domains", func() {
+			repo.routes["app"] = []string{"app.example.com", "app.internal.example.com"}
+			repo.routes["app-venerable"] = repo.routes["app"]
+
+			options := AutopilotOptions{
+				UnmapRoute: true,
+				Domains:    DomainFilter{Exclude: []string{"internal.example.com"}},
+			}
+			Expect(execute(planner.ExistingAppActions("app", manifestPath, "", options))).To(Succeed())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-route]"))
+			Expect(repo.calls).To(ContainElement("MapRouteURLs app [app.example.com]"))
+			Expect(repo.calls).To(ContainElement("UnmapRouteURLs app-venerable [app.example.com]"))
+		})
+	})
+
+	Describe("RollbackActions", func() {
+		It("swaps the venerable app back in", func() {
+			repo.routes["app-venerable"] = []string{"app.example.com"}
+
+			Expect(execute(planner.RollbackActions("app", RollbackOptions{}))).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"ManagedMarker app-venerable",
+				"RenameApplication app app-rollback",
+				"MarkManaged app-rollback app",
+				"FindUrls app-venerable",
+				"RenameApplication app-venerable app",
+				"StartApplication app",
+				"DeleteApplication app-rollback",
+			}))
+		})
+	})
+
+	It("restages and checks the venerable app before the live app is touched", func() {
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{Restage: true}))).To(Succeed())
+		Expect(repo.calls[:4]).To(Equal([]string{
+			"RestageApplication app-venerable",
+			"CheckAppHealthy app-venerable",
+			"ManagedMarker app-venerable",
+			"RenameApplication app app-rollback",
+		}))
+	})
+
+	It("stops the restaged app again when the restage is unhealthy", func() {
+		repo.failures["CheckAppHealthy app-venerable"] = errors.New("crashed")
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{Restage: true}))).To(MatchError("crashed"))
+		Expect(repo.calls).To(Equal([]string{
+			"RestageApplication app-venerable",
+			"CheckAppHealthy app-venerable",
+			"StopApplication app-venerable",
+		}))
+	})
+
+	It("starts and checks the venerable app before swapping", func() {
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{StartFirst: true}))).To(Succeed())
+		Expect(repo.calls).To(Equal([]string{
+			"StartApplication app-venerable",
+			"CheckAppHealthy app-venerable",
+			"ManagedMarker app-venerable",
+			"RenameApplication app app-rollback",
+			"MarkManaged app-rollback app",
+			"FindUrls app-venerable",
+			"FindUrls app-rollback",
+			"RenameApplication app-venerable app",
+			"DeleteApplication app-rollback",
+		}))
+	})
+
+	It("names the copy that could not start, and the version that gets the name back", func() {
+		logger := &recordingLogger{}
+		planner.Logger = logger
+		repo.failures["StartApplication app"] = errors.New("crashing")
+		repo.failures["StopApplication app"] = errors.New("timed out")
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{}))).To(MatchError("crashing"))
+		Expect(logger.messages).To(ContainElement("app could not start, so it is stopped, and app-rollback, the version that was live, gets the name app back.\n"))
+		Expect(logger.messages).To(ContainElement("Warning: could not stop app: timed out\n"))
+	})
+
+	It("fails rather than treat an app whose routes can't be looked up as having none", func() {
+		lookupErr := &RouteLookupError{App: "app-venerable", Err: errors.New("timed out")}
+		repo.failures["FindUrls app-venerable"] = lookupErr
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{}))).To(MatchError(lookupErr))
+		Expect(repo.calls).ToNot(ContainElement(HavePrefix("MapRoutes")))
+		Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-rollback"))
+	})
+
+	It("puts the live app back when the rolled back app cannot start", func() {
+		repo.routes["app-rollback"] = []string{"app.example.com"}
+		repo.failures["StartApplication app"] = errors.New("crashing")
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{}))).To(MatchError("crashing"))
+		Expect(repo.calls).To(Equal([]string{
+			"ManagedMarker app-venerable",
+			"RenameApplication app app-rollback",
+			"MarkManaged app-rollback app",
+			"FindUrls app-venerable",
+			"FindUrls app-rollback",
+			"MapRoutes app-venerable [app]",
+			"UnmapRoutes app-rollback [app]",
+			"RenameApplication app-venerable app",
+			"StartApplication app",
+			"StopApplication app",
+			"RenameApplication app app-venerable",
+			"MapRoutes app-rollback [app]",
+			"UnmapRoutes app-venerable [app]",
+			"RenameApplication app-rollback app",
+		}))
+	})
+
+	It("only moves the routes on the chosen domains, each on its own domain", func() {
+		repo.routes["app-rollback"] = []string{"app.one.example", "app.two.example", "api.two.example"}
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{Domains: DomainFilter{Only: []string{"two.example"}}}))).To(Succeed())
+		Expect(repo.calls).To(ContainElement("MapRoutes app-venerable [app.two.example api.two.example]"))
+		Expect(repo.calls).To(ContainElement("UnmapRoutes app-rollback [app.two.example api.two.example]"))
+	})
+
+	It("rolls back to a chosen copy", func() {
+		repo.routes["app-v41"] = []string{"app.example.com"}
+
+		Expect(execute(planner.RollbackActions("app", RollbackOptions{From: "app-v41"}))).To(Succeed())
+		Expect(repo.calls).To(ContainElement("RenameApplication app-v41 app"))
+		Expect(repo.calls).ToNot(ContainElement(ContainSubstring("venerable")))
+	})
+
+	Describe("RollbackRouteReport", func() {
+		It("lists the routes that will move to the venerable app", func() {
+			repo.routes["app"] = []string{"app.example.com", "www.example.com"}
+
+			report, err := planner.RollbackRouteReport("app", RollbackOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report).To(Equal([]string{
+				"Routes on app: app.example.com, www.example.com",
+				"Routes on app-venerable: none",
+				"Route changes:",
+				"  map app.example.com to app-venerable",
+				"  map www.example.com to app-venerable",
+				"  unmap app.example.com from app-rollback",
+				"  unmap www.example.com from app-rollback",
+			}))
+		})
+
+		It("fails when the live app's routes can't be looked up", func() {
+			repo.failures["FindUrls app"] = &RouteLookupError{App: "app", Err: errors.New("timed out")}
+
+			_, err := planner.RollbackRouteReport("app", RollbackOptions{})
+			Expect(err).To(MatchError("Could not determine the routes of app: timed out"))
+		})
+
+		It("only lists the routes on the chosen domains", func() {
+			repo.routes["app"] = []string{"app.one.example", "app.two.example"}
+
+			report, err := planner.RollbackRouteReport("app", RollbackOptions{Domains: DomainFilter{Exclude: []string{"one.example"}}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report[3:]).To(Equal([]string{
+				"  map app.two.example to app-venerable",
+				"  unmap app.two.example from app-rollback",
+			}))
+
+			report, err = planner.RollbackRouteReport("app", RollbackOptions{Domains: DomainFilter{Only: []string{"three.example"}}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report[3:]).To(Equal([]string{"  none, routes on one.example, two.example are left where they are"}))
+		})
+
+		It("reports no changes when the venerable app kept its routes", func() {
+			repo.routes["app"] = []string{"app.example.com", "new.example.com"}
+			repo.routes["app-v41"] = []string{"app.example.com"}
+
+			report, err := planner.RollbackRouteReport("app", RollbackOptions{From: "app-v41"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report).To(Equal([]string{
+				"Routes on app: app.example.com, new.example.com",
+				"Routes on app-v41: app.example.com",
+				"Route changes:",
+				"  none, app-v41 keeps its routes",
+				"  new.example.com stop serving when app is deleted",
+			}))
+		})
+	})
+
+	Describe("ScaleActions", func() {
+		It("swaps in a scaled clone", func() {
+			Expect(execute(planner.ScaleActions("app", ScaleOptions{Instances: 3}))).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"CloneApplication app app-scaled",
+				"MarkManaged app-scaled app",
+				"StartApplication app-scaled",
+				"CopyRoutes app app-scaled",
+				"RemoveRoutes app",
+				"DeleteApplication app",
+				"RenameApplication app-scaled app",
+			}))
+		})
+
+		It("gives the command that finishes the scale when the clone cannot be renamed", func() {
+			repo.failures["RenameApplication app-scaled app"] = errors.New("name taken")
+
+			err := execute(planner.ScaleActions("app", ScaleOptions{Instances: 3}))
+			Expect(err).To(MatchError(ContainSubstring("Finish the scale with: cf rename app-scaled app")))
+			Expect(repo.calls[len(repo.calls)-2:]).To(Equal([]string{
+				"RenameApplication app-scaled app",
+				"RenameApplication app-scaled app",
+			}))
+			Expect(repo.calls).ToNot(ContainElement("CopyRoutes app-scaled app"))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-scaled"))
+		})
+	})
+})
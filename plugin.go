@@ -0,0 +1,1074 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/cli/plugin"
+	"github.com/concourse/autopilot/network"
+	"github.com/concourse/autopilot/rewind"
+)
+
+func fatalIf(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stdout, colors.Failure(fmt.Sprint("error: ", err)))
+		cleanUp()
+		os.Exit(1)
+	}
+}
+
+// cleanups are run before the plugin exits, whether or not it fails, e.g.
+// to remove a manifest read from stdin.
+var cleanups []func()
+
+func atExit(cleanup func()) {
+	cleanups = append(cleanups, cleanup)
+}
+
+func cleanUp() {
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+	cleanups = nil
+}
+
+// AutopilotPlugin is the cf CLI plugin: its commands, and the flags they take.
+type AutopilotPlugin struct{}
+
+func (plugin AutopilotPlugin) Run(cliConnection plugin.CliConnection, args []string) {
+	cfHome, args := ParseCFHome(args)
+	if (cfHome != "") {
+		runInCFHome(cfHome, args)
+	}
+
+	// an app given as org/space/app is deployed by a cf CLI targeting
+	// that org and space
+	target, args, err := ParseTarget(args)
+	fatalIf(err)
+	if (target != Target{}) {
+		runInTarget(target, args)
+	}
+
+	// the cf CLI reads its timeouts when it starts, so a cf with them set
+	// runs the command instead
+	timeouts, rest, err := ParseTimeouts(args)
+	fatalIf(err)
+	if (!timeouts.InEffect(os.Getenv)) {
+		runWithTimeouts(timeouts, args)
+	}
+	args = rest
+
+	// each space of a promotion is pushed to by a cf process of its own,
+	// with the rest of the arguments
+	promotion, args, err := ParsePromotion(args)
+	fatalIf(err)
+	if (len(promotion.Spaces) > 0) {
+		fatalIf(promote(promotion, args))
+		return
+	}
+
+	appRepo := NewApplicationRepo(cliConnection)
+	defer cleanUp()
+
+	verbosity, args := ParseVerbosity(args)
+	appRepo.SetVerbosity(verbosity)
+	colors, args = ParseColor(args, os.Getenv, stdoutIsTerminal())
+
+	// air-gapped foundations reach nothing but the Cloud Controller
+	networkPolicy, args := ParseOffline(args)
+	fatalIf(networkPolicy.Check(NetworkNeeds(args)))
+
+	// which build this is, without logging in or touching any app
+	version, args := takeBoolFlag(args, "version")
+	checkUpdate, args := takeBoolFlag(args, "check-update")
+	if (version) {
+		showVersion(checkUpdate)
+		return
+	}
+
+	reportPath, args := ParseReportPath(args)
+	allApps, args := ParseShowApps(args)
+	statusPort, args, err := ParseStatusPort(args)
+	fatalIf(err)
+	auditEvent, args := ParseAuditEvent(args)
+	failAt, args := ParseFailAt(args)
+
+	// the whole command, setup included, counts against --max-deploy-time
+	maxDeployTime, args, err := ParseMaxDeployTime(args)
+	fatalIf(err)
+	var budget *DeployBudget
+	if (maxDeployTime > 0) {
+		budget = NewDeployBudget(maxDeployTime, time.Now())
+	}
+
+	skipSSLValidation, args := ParseSkipSSLValidation(args)
+	if (skipSSLValidation) {
+		appRepo.SkipSSLValidation()
+	}
+
+	fatalIf(appRepo.Authenticate(os.Getenv))
+
+	// an old cf CLI or foundation fails here, not half way through a deploy
+	fatalIf(checkCapabilities(appRepo, FeaturesFor(args, auditEvent)))
+
+	config, err := LoadConfig(os.Getenv)
+	fatalIf(err)
+
+	// sensitive values are masked in verbose output, reports and the status
+	redactor, err := NewRedactor(config.Redact)
+	fatalIf(err)
+	appRepo.SetRedactor(redactor)
+
+	windowSpec, args := takeStringFlag(args, "deploy-window")
+	overrideWindow, args := takeBoolFlag(args, "override-window")
+	if (windowSpec == "") {
+		windowSpec = config.DeployWindow
+	}
+	if (windowSpec != "" && args[0] != "zero-downtime-plan" && args[0] != "zero-downtime-status" && args[0] != "zero-downtime-history" && args[0] != "zero-downtime-abort") {
+		deployWindow, err := ParseDeployWindow(windowSpec)
+		fatalIf(err)
+		fatalIf(CheckDeployWindow(deployWindow, time.Now(), overrideWindow))
+	}
+
+	planner := NewDeploymentPlanner(appRepo)
+	planner.Logger = colors.Logger(planner.Logger)
+	planner.CompareVersions = reportPath != ""
+
+	// the org's guardrails for the space, which an offline deploy cannot
+	// reach if they are a URL
+	planner.Policy, err = policyFor(config.Policy, appRepo)
+	fatalIf(err)
+	if (planner.Policy.IsEndpoint() && !networkPolicy.Allows(network.PolicyEndpoint)) {
+		fatalIf(fmt.Errorf("the config file's deploy policy for space %s needs requests to %s, which --offline does not allow", planner.Policy.Space, network.PolicyEndpoint))
+	}
+
+	naming, args := takeStringFlag(args, "naming")
+	planner.Naming, err = ParseNaming(naming, time.Now())
+	fatalIf(err)
+
+	// each app of a batch is pushed by a cf zero-downtime-push of its own
+	if (args[0] == "zero-downtime-push-batch") {
+		fatalIf(runBatch(args, batchGlobalArgs(verbosity, skipSSLValidation, overrideWindow, allApps, networkPolicy.Offline, naming, maxDeployTime), reportPath))
+		return
+	}
+
+	// plans and the status only look, so nothing is locked or changed
+	if (args[0] == "zero-downtime-plan") {
+		fatalIf(showPlan(planner, args, func(appName string) (string, error) {
+			return dispositionFor(config.Venerable, appRepo, appName)
+		}))
+		return
+	}
+
+	if (args[0] == "zero-downtime-status") {
+		fatalIf(showStatus(planner, args))
+		return
+	}
+
+	if (args[0] == "zero-downtime-history") {
+		fatalIf(showHistory(appRepo, args))
+		return
+	}
+
+	// an abort stops another deploy, so it takes no lock of its own
+	if (args[0] == "zero-downtime-abort") {
+		fatalIf(abort(appRepo, planner, args))
+		return
+	}
+
+	appName := args[1]
+	addAppSecrets(redactor, appRepo, appName, "", nil)
+	var actionList []rewind.Action
+	var	successMessage string
+	var deployDigest string
+	// the revision pushed, for the app's history
+	var revision string
+	// the copy a rollback goes back to, if not the venerable app
+	var rollbackFrom string
+	// what a push that fails leaves behind for --resume-from
+	var deployState *DeployState
+	var statePath string
+
+	if(args[0] == "zero-downtime-push") {
+		appName, manifestPath, appPath, options, err := ParseArgs(args)
+		fatalIf(err)
+		options.Timeouts = timeouts
+
+		disposition, err := dispositionFor(config.Venerable, appRepo, appName)
+		fatalIf(err)
+		options = ApplyDisposition(options, disposition)
+
+		manifestPath, err = manifestPathFor(manifestPath)
+		fatalIf(err)
+		fatalIf(CheckDeployPaths(manifestPath, appPath))
+		addAppSecrets(redactor, appRepo, appName, manifestPath, options.Env)
+
+		if (options.Revision == "") {
+			options.Revision = GitRevision(appPath)
+		}
+		revision = options.Revision
+		planner.Naming, err = namingFor(planner.Naming, options.Revision)
+		fatalIf(err)
+
+		// a repeat of the last deploy has nothing to do
+		if (options.SkipIfUnchanged) {
+			deployDigest, err = DeployDigest(appName, manifestPath, appPath, options)
+			fatalIf(err)
+			deployedDigest, err := appRepo.DeployedDigest(appName)
+			fatalIf(err)
+			if (deployedDigest == deployDigest) {
+				fmt.Printf("%s is already up to date, so nothing was pushed.\n", appName)
+				return
+			}
+		}
+
+		if (options.Package) {
+			appPath, err = packageAppPath(appPath)
+			fatalIf(err)
+		}
+
+		if (options.Diff) {
+			fatalIf(showDrift(planner, appName, manifestPath, options.FailOnDrift))
+		}
+
+		if (options.CheckSecurityGroups) {
+			fatalIf(checkSecurityGroups(appRepo, appName, manifestPath))
+		}
+
+		statePath, err = appRepo.DeployStatePath(appName)
+		if (err != nil) {
+			warnf("could not find where to keep the deploy's state, so it cannot be resumed if it fails: %s\n", err)
+		}
+
+		if (options.ResumeFrom != "") {
+			state, found, err := LoadDeployState(statePath)
+			fatalIf(err)
+			if (!found) {
+				fatalIf(fmt.Errorf("there is no failed deploy of %s to resume", appName))
+			}
+			fatalIf(CheckResume(state, options.ResumeFrom))
+
+			actionList, err = planner.ResumeActions(appName, manifestPath, appPath, options, state)
+			fatalIf(err)
+			fmt.Printf("Resuming the deploy of %s that failed at %q, from %q.\n", appName, state.FailedAt, options.ResumeFrom)
+		} else {
+			actionList, err = planner.PushActions(appName, manifestPath, appPath, options)
+			fatalIf(err)
+		}
+
+		if (statePath != "") {
+			deployState = NewDeployState(appName, actionList)
+		}
+		successMessage = "A new version of your application has successfully been pushed!"
+	} else if (args[0] == "zero-downtime-rollback") {
+		options, err := ParseRollbackArgs(args)
+		fatalIf(err)
+
+		appExists, err := appRepo.DoesAppExist(appName)
+		fatalIf(err)
+
+		if(!appExists){
+			fatalIf(errors.New(fmt.Sprintf("Live version of app \"%s\" not found, cannot rollback.", appName)))
+		}
+
+		// a revision is deployed in place, so there is no copy to check
+		if (options.Strategy == revisionsStrategy) {
+			enabled, err := appRepo.RevisionsEnabled(appName)
+			fatalIf(err)
+			if (!enabled) {
+				fatalIf(fmt.Errorf("Revisions are not enabled for app \"%s\", cannot rollback with --strategy revisions. " +
+				"Enable them with cf enable-revisions, or roll back to a kept copy instead.", appName))
+			}
+
+			if (!options.Yes && !confirm("Roll back to an earlier revision?")) {
+				fatalIf(errors.New("Rollback cancelled."))
+			}
+
+			actionList = planner.RevisionRollbackActions(appName, options)
+		} else {
+			// timestamped old versions are rolled back to by name, newest first
+			if _, timestamped := planner.Naming.(TimestampNaming); (timestamped && options.From == "") {
+				options.From, err = planner.LatestCopy(appName)
+				fatalIf(err)
+				fmt.Printf("Rolling back to %s, the most recent earlier version.\n", options.From)
+			}
+
+			// the commit that replaced the copy can't be guessed
+			if _, gitSHA := planner.Naming.(GitSHANaming); (gitSHA && options.From == "") {
+				fatalIf(errors.New("With --naming git-sha, give the earlier version to roll back to with --from, e.g. --from " + appName + "-before-<commit>"))
+			}
+
+			rollbackFrom = options.From
+			if (options.From != "") {
+				fatalIf(appRepo.CheckRollbackCopy(options.From))
+			} else {
+				venerableAppExists, err := appRepo.DoesAppExist(venerableAppName(appName))
+				fatalIf(err)
+
+				if(!venerableAppExists){
+					fatalIf(errors.New(fmt.Sprintf("Venerable version of \"%s\" not found, cannot rollback. Make sure you push with the " +
+					"--keep-existing-app flag to leave the venerable version behind.", appName)))
+				}
+
+				fatalIf(planner.CheckNameCollisions(appName, options.ForceNameCollision, venerableAppName(appName)))
+			}
+
+			// the live app is renamed to the rollback name, and then deleted
+			fatalIf(planner.CheckNameCollisions(appName, options.ForceNameCollision, planner.Naming.RollbackName(appName)))
+
+			report, err := planner.RollbackRouteReport(appName, options)
+			fatalIf(err)
+			for _, line := range report {
+				fmt.Println(line)
+			}
+
+			if (!options.Yes && !confirm("Roll back?")) {
+				fatalIf(errors.New("Rollback cancelled."))
+			}
+
+			actionList = planner.RollbackActions(appName, options)
+		}
+		successMessage = "Your application has been successfully rolled back!"
+	} else if (args[0] == "zero-downtime-scale") {
+		forceNameCollision, args := takeBoolFlag(args, "force-name-collision")
+		_, options, err := ParseScaleArgs(args)
+		fatalIf(err)
+
+		appExists, err := appRepo.DoesAppExist(appName)
+		fatalIf(err)
+		if(!appExists){
+			fatalIf(errors.New(fmt.Sprintf("App \"%s\" not found, cannot scale.", appName)))
+		}
+		fatalIf(planner.CheckNameCollisions(appName, forceNameCollision, planner.Naming.ScaledName(appName)))
+
+		actionList = planner.ScaleActions(appName, options)
+		successMessage = "Your application has been successfully scaled!"
+	} else if (args[0] == "zero-downtime-copy") {
+		options, err := ParseCopyArgs(args)
+		fatalIf(err)
+
+		appExists, err := appRepo.DoesAppExist(appName)
+		fatalIf(err)
+		if(!appExists){
+			fatalIf(errors.New(fmt.Sprintf("App \"%s\" not found, cannot copy.", appName)))
+		}
+
+		actionList = planner.CopyActions(appName, options)
+		successMessage = fmt.Sprintf("Your application has been successfully copied to space %s!", options.ToSpace)
+	} else if (args[0] == "zero-downtime-delete") {
+		options, err := ParseDeleteArgs(args)
+		fatalIf(err)
+
+		appExists, err := appRepo.DoesAppExist(appName)
+		fatalIf(err)
+		if(!appExists){
+			fatalIf(errors.New(fmt.Sprintf("App \"%s\" not found, cannot delete.", appName)))
+		}
+
+		actionList = planner.DeleteActions(appName, options)
+		successMessage = "Your application has been successfully deleted!"
+	}
+
+	// traces and metrics for the deploy, if an OTLP collector is configured
+	telemetry := telemetryFor(networkPolicy, args[0], appName)
+	if (telemetry != nil) {
+		telemetry.Redactor = redactor
+	}
+
+	// the deploy's start, cutover and end, noted on the app with --audit-event
+	var timeline *DeployTimeline
+	if (auditEvent && args[0] != "zero-downtime-delete") {
+		timeline = NewDeployTimeline(args[0], appName)
+	}
+
+	var report *DeploymentReport
+	if (reportPath != "") {
+		guid, routes := appRepo.AppState(appName)
+		report = NewDeploymentReport(args[0], appName, guid, routes)
+		report.Redactor = redactor
+	}
+
+	// deploy progress for dashboards to poll, if asked for
+	var progress *ProgressServer
+	if (statusPort != 0) {
+		progress = NewProgressServer(args[0], appName, len(actionList))
+		progress.Redactor = redactor
+		fatalIf(progress.Listen(statusPort))
+		defer progress.Close()
+		planner.Logger = progress.Logger(planner.Logger)
+		fmt.Printf("Serving the deploy status on http://%s/\n", progress.Addr)
+	}
+
+	// the deploy's entry in this machine's history of the app
+	history := NewDeployHistory(args[0], appName, time.Now())
+	history.Redactor = redactor
+
+	// how far the deploy got, should it be abandoned
+	tracker := NewStepTracker(actionList, planner.ResumeFrom)
+
+	// a line per step, so a long deploy log shows where it got to
+	steps := StepPrinter{Colors: colors, Out: os.Stdout}
+	if (verbosity == QuietVerbosity) {
+		steps.Out = ioutil.Discard
+	}
+
+	actions := rewind.Actions{
+		Actions:              actionList,
+		RewindFailureMessage: "Oh no. Something's gone wrong. I've tried to roll back but you should check to see if everything is OK.",
+		Observer:             rewind.Observers(steps.ObserveStep, telemetry.ObserveStep, report.ObserveStep, progress.ObserveStep, timeline.ObserveStep, deployState.ObserveStep, budget.ObserveStep, history.ObserveStep, tracker.ObserveStep),
+		Starting: func(name, phase string) {
+			progress.StepStarting(name, phase)
+			tracker.StepStarting(name, phase)
+		},
+		ResumeFrom:           planner.ResumeFrom,
+	}
+
+	// a rehearsal of what is rolled back when the deploy fails
+	if (failAt != "") {
+		fatalIf(CheckFailAt(failAt, actionList))
+		actions.Inject = rewind.FailAt(failAt)
+		fmt.Printf("This deploy will fail at %q, as --fail-at asks.\n", failAt)
+	}
+
+	// fail fast if another deploy of the app is in progress
+	lock, err := appRepo.AcquireLock(appName)
+	fatalIf(err)
+	lock.Keep(lockRenewal)
+
+	ctx, stopInterrupts := interruptContext(appRepo, planner.Naming, args[0], appName, rollbackFrom, tracker)
+	defer stopInterrupts()
+
+	// running out of time rolls it back as well
+	ctx, stopBudget := budget.Context(ctx)
+	defer stopBudget()
+
+	// zero-downtime-abort rolls the deploy back as an interrupt does
+	aborts := WatchForAbort(ctx, lock.AbortRequested, abortPollInterval)
+	progress.SetAbort(aborts.Abort)
+
+	// so the long waits within a step end at once too
+	planner.Context = aborts.Context()
+
+	err = actions.ExecuteContext(aborts.Context())
+	if (err == context.DeadlineExceeded) {
+		err = budget.Exceeded()
+	}
+	progress.Finish(err)
+
+	// only a deploy that could not be rolled back in full can be resumed
+	if (deployState != nil) {
+		var stateErr error
+		if (err != nil) {
+			deployState.Finish(err, time.Now())
+			stateErr = SaveDeployState(statePath, deployState)
+		} else {
+			stateErr = os.Remove(statePath)
+			if (os.IsNotExist(stateErr)) {
+				stateErr = nil
+			}
+		}
+		if (stateErr != nil) {
+			warnf("could not keep the deploy's state for --resume-from: %s\n", stateErr)
+		}
+	}
+
+	if (err == nil && deployDigest != "") {
+		digestErr := appRepo.RecordDeployDigest(appName, deployDigest)
+		if (digestErr != nil) {
+			warnf("could not note what was deployed, so the next push will not be skipped: %s\n", digestErr)
+		}
+	}
+
+	if (err == nil && auditEvent && args[0] != "zero-downtime-delete") {
+		auditErr := appRepo.RecordAuditEvent(appName, args[0])
+		if (auditErr != nil) {
+			warnf("could not record the audit event: %s\n", auditErr)
+		}
+	}
+
+	if (timeline != nil) {
+		timelineErr := appRepo.RecordDeployTimeline(timeline, err)
+		if (timelineErr != nil) {
+			warnf("could not record the deploy's events on the app: %s\n", timelineErr)
+		}
+	}
+
+	// the deploy went through, but must not claim success while the old
+	// version still holds on to routes, bindings or instances
+	if (err == nil) {
+		leaks := planner.Leaks()
+		history.NoteLeaks(leaks)
+		err = leakError(appName, leaks)
+	}
+
+	if (report != nil) {
+		guid, routes := appRepo.AppState(appName)
+		report.Finish(err, guid, routes)
+		report.AddConfigChanges(planner.ConfigChanges())
+		events, eventsErr := appRepo.AppEvents(report.StartedAt, report.GuidBefore, report.GuidAfter)
+		if (eventsErr != nil) {
+			warnf("could not fetch the app's events for the deployment report: %s\n", eventsErr)
+		}
+		report.AddEvents(events)
+		writeErr := report.Write(reportPath)
+		if (writeErr != nil) {
+			warnf("could not write the deployment report: %s\n", writeErr)
+		}
+	}
+
+	if (err == context.Canceled && aborts.AbortedBy() != "") {
+		err = fmt.Errorf("Aborted by %s. The completed steps have been rolled back.", aborts.AbortedBy())
+	} else if err == context.Canceled {
+		err = errors.New("Interrupted. The completed steps have been rolled back.")
+	}
+
+	historyPath, historyErr := appRepo.HistoryPath(appName)
+	if (historyErr == nil) {
+		historyErr = AppendHistory(historyPath, history.Finish(revision, err, time.Now()))
+	}
+	if (historyErr != nil) {
+		warnf("could not record the deploy in the app's history: %s\n", historyErr)
+	}
+
+	releaseErr := lock.Release()
+
+	exportErr := telemetry.Export(err)
+	if (exportErr != nil) {
+		warnf("could not export telemetry: %s\n", exportErr)
+	}
+
+	fatalIf(err)
+	fatalIf(releaseErr)
+
+	fmt.Println()
+	fmt.Println(colors.Success(successMessage))
+	fmt.Println()
+
+	err = showApps(appRepo, allApps, appName, planner.Naming.VenerableName(appName))
+	fatalIf(err)
+}
+
+func (AutopilotPlugin) GetMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{
+		Name: "autopilot",
+		Version: ParsePluginVersion(Version),
+		Commands: []plugin.Command{
+			{
+				Name:     "zero-downtime-push",
+				HelpText: "Perform a zero-downtime push of an application over the top of an old one",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-push [ORG/SPACE/]application-to-replace \\ \n \t-f path/to/new_manifest.yml \\ \n \t-p path/to/new/path [-- cf push arguments]",
+					Options: map[string]string{
+						"f":                              "path to an application manifest, or - to read it from stdin",
+						"p":                              "path to application files",
+						"workdir":                        "resolve relative -f and -p paths from this directory",
+						"keep-existing-app":              "stop the existing app instead of deleting it",
+						"unmap-routes":                   "unmap the existing app's routes instead of deleting it",
+						"continue-on-route-error":        "warn instead of failing when some routes cannot be mapped or unmapped",
+						"report":                         "write a JSON report of the deploy to this path",
+						"max-deploy-time":                "roll the deploy back if it takes longer than this (e.g. 20m) in all",
+						"status-port":                    "serve the deploy's progress as JSON on this local port, e.g. 8123",
+						"show-apps":                      "list every app in the space after the deploy, as cf apps does, instead of only the apps it involved",
+						"audit-event":                    "note the deploy on the app, which the Cloud Controller records as an audit event",
+						"skip-ssl-validation":            "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":                        "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":                        "run with the cf CLI config in this directory instead of the current one",
+						"staging-timeout":                "wait this long (e.g. 30m) for the new app to stage, instead of CF_STAGING_TIMEOUT",
+						"app-start-timeout":              "wait this long (e.g. 5m) for the new app's instances to start, as cf push -t and CF_STARTUP_TIMEOUT",
+						"deploy-window":                  "only run within these times, e.g. \"Mon-Fri 09:00-17:00 Europe/Berlin\"",
+						"naming":                         "name the old version app-venerable (suffix, the default), after the time it was replaced, e.g. app-20240607T1212 (timestamp), or after the commit that replaced it, e.g. app-before-1a2b3c4 (git-sha)",
+						"override-window":                "run even outside the deploy window",
+						"quiet":                          "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":                        "show the output of every cf command autopilot runs",
+						"no-color":                       "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+						"version":                        "print the version of autopilot and exit",
+						"check-update":                   "with --version, also check GitHub for a newer release",
+						"env":                            "set an environment variable on the new app, as KEY=VALUE (repeatable)",
+						"strict-routes":                  "fail the deploy if the new app is missing routes the old one had",
+						"routes-from":                    "manifest: map and verify only the manifest's routes, unmapping any others; app (default): the old version's when the manifest has none",
+						"spaces":                         "push to each of these comma separated spaces of the current org in turn, e.g. dev,staging, packaging -p only once",
+						"pause-between":                  "with --spaces, wait this long (e.g. 30m) before promoting to the next space, or confirm to ask first",
+						"copy-route-services":            "bind route services on the old app's routes to the new app's routes",
+						"drain-wait":                     "unmap the old app's routes and wait this long (e.g. 30s) before stopping or deleting it",
+						"push-arg":                       "pass extra arguments on to cf push (repeatable)",
+						"warmup":                         "send requests to the new app on its test route for this long (e.g. 2m) before giving it the production routes",
+						"warmup-requests":                "warm the new app up until this many requests on its test route have succeeded",
+						"probe-path":                     "check the new app is ready by requesting this path (e.g. /healthz) on its test route",
+						"probe-status":                   "the status the probe must get (default: any success or redirect)",
+						"probe-count":                    "how many probe requests in a row must succeed (default 1)",
+						"ready-log-pattern":              "wait until the new app logs a line matching this regular expression (e.g. \"Server started on\") before giving it the production routes",
+						"ready-log-timeout":              "how long to wait for the --ready-log-pattern line (default 5m)",
+						"stabilization-window":           "watch the new app for crashes for this long (e.g. 2m) after it is pushed, before the old one is retired",
+						"max-crashes":                    "fail the deploy if the new app crashes more than this many times within --stabilization-window (default 0)",
+						"verify-ssh":                     "run this command in the new app's first instance with cf ssh before it gets the production routes, and roll back if it fails",
+						"auto-rollback-on-probe-failure": "after the cutover, keep the old app stopped and roll back to it if the production routes fail too often or answer too slowly",
+						"auto-rollback-window":           "how long (e.g. 10m) --auto-rollback-on-probe-failure watches the production routes (default 5m)",
+						"max-error-rate":                 "the percentage of requests to the production routes that may fail before --auto-rollback-on-probe-failure rolls back",
+						"max-latency":                    "roll back with --auto-rollback-on-probe-failure if the production routes take longer than this (e.g. 500ms) on average",
+						"route-check-header":             "once the new app has the routes, check each one answers with this header, as NAME=VALUE or NAME to expect the revision",
+						"route-check-body":               "once the new app has the routes, check each one answers with this text in the body",
+						"premap-routes":                  "map the production routes to the new app while it stages, before it starts, instead of once it is running",
+						"diff":                           "show how the live app's configuration differs from the manifest before pushing",
+						"delete-orphaned-routes":         "delete the old app's routes that no app is mapped to once it has been deleted",
+						"check-security-groups":          "warn if the space's security groups block services the manifest's environment points at",
+						"staged-start":                   "scale the new app up, and the old one down, this percentage of the instances at a time (e.g. 25%)",
+						"allow-stopped-app":              "replace a stopped app by pushing, verifying and deleting it, since there is no traffic to move",
+						"revision":                       "the revision being pushed, for {{.Revision}} in templates (default: the app path's git commit)",
+						"var":                            "set a value for templates, as KEY=VALUE, used as {{.Vars.KEY}} (repeatable)",
+						"buildpack":                      "stage the new app with this buildpack, overriding the manifest (repeatable, in order)",
+						"lifecycle":                      "stage the new app with this lifecycle: buildpack, docker or cnb",
+						"start-command":                  "start the new app with this command instead of the manifest's or the buildpack's",
+						"health-check-type":              "check the new app's instances with this health check: port, process or http, overriding the manifest",
+						"health-check-http-endpoint":     "the path the new app's http health check requests (e.g. /healthz), overriding the manifest",
+						"force-name-collision":           "treat an app with the venerable name as a copy autopilot left, even though it is not marked as one",
+						"instances":                      "run the new app with this many instances, overriding the manifest",
+						"memory":                         "give the new app this memory limit (e.g. 1G), overriding the manifest",
+						"disk":                           "give the new app this disk limit (e.g. 2G), overriding the manifest",
+						"skip-if-unchanged":              "do nothing if the app's files, the manifest and these flags are the same as the live app was last pushed with",
+						"package":                        "zip the -p directory locally, respecting .cfignore, and push the zip, reusing it while the files are unchanged",
+						"resume-from":                    "resume the last deploy of the app, which failed and could not be rolled back, from this step",
+						"rotate-service-keys":            "give the new app fresh keys for these comma separated services, in SERVICE_CREDENTIALS variables, and delete the old ones once it is live",
+						"fail-on-drift":                  "fail if the live app has drifted from the manifest in these comma separated categories (memory, instances, env, services, routes, or all)",
+						"only-domains":                   "only move routes on these comma separated domains to the new app",
+						"exclude-domains":                "leave routes on these comma separated domains on the old app",
+						"cutover-order":                  "move routes to the new app a domain at a time, in this comma separated order, with routes on other domains last",
+						"cutover-pause":                  "wait this long (e.g. 1m) between the domains of --cutover-order",
+						"test-route":                     "check the new app on this temporary route (host.domain, or auto) before giving it the production routes",
+						"test-route-service":             "bind this route service to the test route while the new app is checked on it",
+						"test-route-https-only":          "fail the check on the test route if it answers plain http rather than redirecting or refusing it",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-plan",
+				HelpText: "List the actions a zero-downtime-push would run, what undoes each one, and when each applies, without running them",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-plan application-to-replace \\ \n \t-f path/to/new_manifest.yml [--json] [zero-downtime-push options]",
+					Options: map[string]string{
+						"f":       "path to an application manifest, or - to read it from stdin",
+						"p":       "path to application files",
+						"workdir": "resolve relative -f and -p paths from this directory",
+						"json":    "print the plan as JSON",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-status",
+				HelpText: "Show an application's state, the old versions kept beside it and the revisions it can be rolled back to",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-status application",
+					Options: map[string]string{
+						"skip-ssl-validation": "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":             "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":             "run with the cf CLI config in this directory instead of the current one",
+						"no-color":            "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-history",
+				HelpText: "List the past deploys of an application from this machine, with how long they took, their revisions and outcomes",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-history application [--limit 10] [--json]",
+					Options: map[string]string{
+						"limit":    "list only this many of the most recent deploys",
+						"json":     "print the deploys as JSON, oldest first",
+						"cf-home":  "run with the cf CLI config in this directory instead of the current one",
+						"no-color": "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-abort",
+				HelpText: "Stop a deploy of an application: a running deploy is rolled back, and the apps an unfinished one left are put back the way they were",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-abort application [--force] [--wait 10m] [--status-url URL]",
+					Options: map[string]string{
+						"force":               "put the apps an unfinished deploy left back without asking first",
+						"wait":                "how long to wait for a running deploy to roll back (default 10m)",
+						"status-url":          "ask the deploy serving its status here to abort, e.g. http://127.0.0.1:8123",
+						"skip-ssl-validation": "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":             "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":             "run with the cf CLI config in this directory instead of the current one",
+						"quiet":               "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":             "show the output of every cf command autopilot runs",
+						"no-color":            "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:"zero-downtime-rollback",
+				HelpText: "Perform a zero-downtime rollback to the previous version of the application. Requires that the previous, 'venerable' version of the app still exists." +
+					"Use the --keep-existing-app flag when performing a zero-downtime-push to ensure this.",
+				UsageDetails:plugin.Usage{
+					Usage:"$cf zero-downtime-rollback application-to-revert",
+					Options: map[string]string{
+						"continue-on-route-error": "warn instead of failing when some routes cannot be mapped or unmapped",
+						"from":                    "roll back to this stopped copy of the app instead of the venerable one",
+						"strategy":                "copy, to rename a kept copy over the app (the default), or revisions, to deploy an earlier revision in place",
+						"to-revision":             "with --strategy revisions, the revision to roll back to (default: the one before the app's current one)",
+						"restage-on-rollback":     "restage the copy being rolled back to, and check it is healthy, before it gets any traffic",
+						"start-first":             "start the copy being rolled back to, and check it is healthy, before the live app is touched",
+						"yes":                     "roll back without asking for confirmation of the route changes",
+						"force-name-collision":    "treat apps with the venerable or rollback name as copies autopilot left, even though they are not marked as ones",
+						"only-domains":            "only move routes on these comma separated domains",
+						"exclude-domains":         "leave routes on these comma separated domains where they are",
+						"report":                  "write a JSON report of the deploy to this path",
+						"max-deploy-time":         "roll the deploy back if it takes longer than this (e.g. 20m) in all",
+						"status-port":             "serve the deploy's progress as JSON on this local port, e.g. 8123",
+						"show-apps":               "list every app in the space after the deploy, as cf apps does, instead of only the apps it involved",
+						"audit-event":             "note the deploy on the app, which the Cloud Controller records as an audit event",
+						"skip-ssl-validation":     "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":                 "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":                 "run with the cf CLI config in this directory instead of the current one",
+						"deploy-window":           "only run within these times, e.g. \"Mon-Fri 09:00-17:00 Europe/Berlin\"",
+						"naming":                  "find the old version to roll back to by this naming: suffix (the default), or timestamp for the newest app-<time> copy",
+						"override-window":         "run even outside the deploy window",
+						"quiet":                   "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":                 "show the output of every cf command autopilot runs",
+						"no-color":                "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-scale",
+				HelpText: "Scale an application without restarting it, by replacing it with a scaled copy",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-scale application-to-scale [-i INSTANCES] [-m MEMORY] [-k DISK]",
+					Options: map[string]string{
+						"i":                    "number of instances",
+						"m":                    "memory limit (e.g. 256M, 1024M, 1G)",
+						"k":                    "disk limit (e.g. 256M, 1024M, 1G)",
+						"force-name-collision": "treat an app with the scaled name as a copy autopilot left, even though it is not marked as one",
+						"report":               "write a JSON report of the deploy to this path",
+						"max-deploy-time":      "roll the deploy back if it takes longer than this (e.g. 20m) in all",
+						"status-port":          "serve the deploy's progress as JSON on this local port, e.g. 8123",
+						"show-apps":            "list every app in the space after the deploy, as cf apps does, instead of only the apps it involved",
+						"audit-event":          "note the deploy on the app, which the Cloud Controller records as an audit event",
+						"skip-ssl-validation":  "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":              "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":              "run with the cf CLI config in this directory instead of the current one",
+						"deploy-window":        "only run within these times, e.g. \"Mon-Fri 09:00-17:00 Europe/Berlin\"",
+						"override-window":      "run even outside the deploy window",
+						"quiet":                "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":              "show the output of every cf command autopilot runs",
+						"no-color":             "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-copy",
+				HelpText: "Copy an application into another space with the same bits, configuration and services, as an environment to verify changes in",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-copy application-to-copy --to-space SPACE [--name NAME] [-i INSTANCES] [--no-start]",
+					Options: map[string]string{
+						"to-space":            "the space of the current org to copy the app to",
+						"name":                "name the copy this instead of the app's name",
+						"i":                   "how many instances the copy runs (default 1)",
+						"no-start":            "leave the copy stopped",
+						"report":              "write a JSON report of the deploy to this path",
+						"max-deploy-time":     "roll the deploy back if it takes longer than this (e.g. 20m) in all",
+						"status-port":         "serve the deploy's progress as JSON on this local port, e.g. 8123",
+						"show-apps":           "list every app in the space after the deploy, as cf apps does, instead of only the apps it involved",
+						"skip-ssl-validation": "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":             "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":             "run with the cf CLI config in this directory instead of the current one",
+						"deploy-window":       "only run within these times, e.g. \"Mon-Fri 09:00-17:00 Europe/Berlin\"",
+						"override-window":     "run even outside the deploy window",
+						"quiet":               "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":             "show the output of every cf command autopilot runs",
+						"no-color":            "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-delete",
+				HelpText: "Retire an application gracefully, unmapping its routes and letting it drain before deleting it",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-delete application-to-delete [--drain DURATION] [--delete-routes]",
+					Options: map[string]string{
+						"drain":               "wait this long (e.g. 5m) after unmapping the app's routes before stopping it",
+						"delete-routes":       "delete the app's routes once no other app is mapped to them",
+						"report":              "write a JSON report of the deploy to this path",
+						"max-deploy-time":     "roll the deploy back if it takes longer than this (e.g. 20m) in all",
+						"status-port":         "serve the deploy's progress as JSON on this local port, e.g. 8123",
+						"show-apps":           "list every app in the space after the deploy, as cf apps does, instead of only the apps it involved",
+						"skip-ssl-validation": "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":             "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":             "run with the cf CLI config in this directory instead of the current one",
+						"deploy-window":       "only run within these times, e.g. \"Mon-Fri 09:00-17:00 Europe/Berlin\"",
+						"override-window":     "run even outside the deploy window",
+						"quiet":               "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":             "show the output of every cf command autopilot runs",
+						"no-color":            "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+			{
+				Name:     "zero-downtime-push-batch",
+				HelpText: "Perform a zero-downtime push of each app a batch file lists, after the apps it depends on, several at a time",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf zero-downtime-push-batch -f apps.yml [--parallel N]",
+					Options: map[string]string{
+						"f":                   "path to the batch file listing each app's name, manifest, path, args and depends_on",
+						"parallel":            "deploy this many apps at once, overriding the batch file's parallel (default 1)",
+						"report":              "write a JSON report of every app's deploy to this path",
+						"max-deploy-time":     "give each app's deploy at most this long (e.g. 20m), rolling it back if it takes longer",
+						"show-apps":           "list every app in the space after each deploy, as cf apps does, instead of only the apps it involved",
+						"skip-ssl-validation": "do not check the API's certificate, for lab foundations with self-signed ones",
+						"offline":             "only send requests to the Cloud Controller and its UAA: no update checks, telemetry or requests to the app's routes",
+						"cf-home":             "run with the cf CLI config in this directory instead of the current one",
+						"naming":              "name each app's old version as zero-downtime-push does",
+						"override-window":     "run even outside the deploy window",
+						"quiet":               "hide the output of the cf commands autopilot runs, unless they fail",
+						"verbose":             "show the output of every cf command autopilot runs",
+						"no-color":            "do not color the output, as when NO_COLOR is set or it is not going to a terminal",
+					},
+				},
+			},
+		},
+	}
+}
+
+func ParseArgs(args []string) (string, string, string, AutopilotOptions, error) {
+	flags := flag.NewFlagSet("zero-downtime-push", flag.ContinueOnError)
+	manifestPath := flags.String("f", "", "path to an application manifest, or - to read it from stdin")
+	appPath := flags.String("p", "", "path to application files")
+	workdir := flags.String("workdir", "", "resolve relative -f and -p paths from this directory")
+	keepVenerable := flags.Bool("keep-existing-app", false, "keep existing app running")
+	unmapVenerableRoutes := flags.Bool("unmap-routes", false, "unmap routes for the venerable app")
+	continueOnRouteError := flags.Bool("continue-on-route-error", false, "warn instead of failing when some routes cannot be mapped or unmapped")
+	strictRoutes := flags.Bool("strict-routes", false, "fail the deploy if the new app is missing routes the old one had")
+	routesFrom := flags.String("routes-from", RoutesFromApp, "where the routes to map and verify come from: manifest, to take only the manifest's and unmap any others, or app")
+	copyRouteServices := flags.Bool("copy-route-services", false, "bind route services on the old app's routes to the new app's routes")
+	testRoute := flags.String("test-route", "", "check the new app on this temporary route (host.domain, or auto) before giving it the production routes")
+	testRouteService := flags.String("test-route-service", "", "bind this route service to the test route while the new app is checked on it")
+	testRouteHTTPSOnly := flags.Bool("test-route-https-only", false, "fail the check on the test route if it answers plain http rather than redirecting or refusing it")
+	env := EnvVars{}
+	warmup := flags.Duration("warmup", 0, "send requests to the new app on its test route for this long (e.g. 2m) before giving it the production routes")
+	warmupRequests := flags.Int("warmup-requests", 0, "warm the new app up until this many requests on its test route have succeeded")
+	probePath := flags.String("probe-path", "", "check the new app is ready by requesting this path (e.g. /healthz) on its test route")
+	probeStatus := flags.Int("probe-status", 0, "the status the probe must get (default: any success or redirect)")
+	probeCount := flags.Int("probe-count", 0, "how many probe requests in a row must succeed (default 1)")
+	readyLogPattern := flags.String("ready-log-pattern", "", "wait until the new app logs a line matching this regular expression (e.g. \"Server started on\") before giving it the production routes")
+	readyLogTimeout := flags.Duration("ready-log-timeout", 0, "how long to wait for the --ready-log-pattern line (default 5m)")
+	stabilizationWindow := flags.Duration("stabilization-window", 0, "watch the new app for crashes for this long (e.g. 2m) after it is pushed, before the old one is retired")
+	maxCrashes := flags.Int("max-crashes", 0, "fail the deploy if the new app crashes more than this many times within --stabilization-window (default 0)")
+	verifySSH := flags.String("verify-ssh", "", "run this command in the new app's first instance with cf ssh before it gets the production routes, and roll back if it fails")
+	autoRollback := flags.Bool("auto-rollback-on-probe-failure", false, "after the cutover, keep the old app stopped and roll back to it if the production routes fail too often or answer too slowly")
+	autoRollbackWindow := flags.Duration("auto-rollback-window", 0, "how long (e.g. 10m) --auto-rollback-on-probe-failure watches the production routes (default 5m)")
+	maxErrorRate := flags.Float64("max-error-rate", 5, "the percentage of requests to the production routes that may fail before --auto-rollback-on-probe-failure rolls back")
+	maxLatency := flags.Duration("max-latency", 0, "roll back with --auto-rollback-on-probe-failure if the production routes take longer than this (e.g. 500ms) on average")
+	routeCheckHeader := flags.String("route-check-header", "", "once the new app has the routes, check each one answers with this header, as NAME=VALUE or NAME to expect the revision")
+	routeCheckBody := flags.String("route-check-body", "", "once the new app has the routes, check each one answers with this text in the body")
+	premapRoutes := flags.Bool("premap-routes", false, "map the production routes to the new app while it stages, before it starts, instead of once it is running")
+	diff := flags.Bool("diff", false, "show how the live app's configuration differs from the manifest before pushing")
+	failOnDrift := flags.String("fail-on-drift", "", "fail if the live app has drifted from the manifest in these comma separated categories (memory, instances, env, services, routes, or all)")
+	deleteOrphanedRoutes := flags.Bool("delete-orphaned-routes", false, "delete the old app's routes that no app is mapped to once it has been deleted")
+	checkSecurityGroups := flags.Bool("check-security-groups", false, "warn if the space's security groups block services the manifest's environment points at")
+	stagedStart := flags.String("staged-start", "", "scale the new app up, and the old one down, this percentage of the instances at a time (e.g. 25%)")
+	allowStoppedApp := flags.Bool("allow-stopped-app", false, "replace a stopped app by pushing, verifying and deleting it, since there is no traffic to move")
+	revision := flags.String("revision", "", "the revision being pushed, for {{.Revision}} in templates (default: the app path's git commit)")
+	vars := EnvVars{}
+	flags.Var(vars, "var", "set a value for templates, as KEY=VALUE, used as {{.Vars.KEY}} (repeatable)")
+	buildpacks := BuildpackList{}
+	flags.Var(&buildpacks, "buildpack", "stage the new app with this buildpack, overriding the manifest (repeatable, in order)")
+	lifecycle := flags.String("lifecycle", "", "stage the new app with this lifecycle: buildpack, docker or cnb")
+	startCommand := flags.String("start-command", "", "start the new app with this command instead of the manifest's or the buildpack's")
+	healthCheckType := flags.String("health-check-type", "", "check the new app's instances with this health check: port, process or http, overriding the manifest")
+	healthCheckEndpoint := flags.String("health-check-http-endpoint", "", "the path the new app's http health check requests (e.g. /healthz), overriding the manifest")
+	forceNameCollision := flags.Bool("force-name-collision", false, "treat an app with the venerable name as a copy autopilot left, even though it is not marked as one")
+	instances := flags.Int("instances", 0, "run the new app with this many instances, overriding the manifest")
+	memory := flags.String("memory", "", "give the new app this memory limit (e.g. 1G), overriding the manifest")
+	diskQuota := flags.String("disk", "", "give the new app this disk limit (e.g. 2G), overriding the manifest")
+	skipIfUnchanged := flags.Bool("skip-if-unchanged", false, "do nothing if the app's files, the manifest and these flags are the same as the live app was last pushed with")
+	packageApp := flags.Bool("package", false, "zip the -p directory locally, respecting .cfignore, and push the zip, reusing it while the files are unchanged")
+	rotateServiceKeys := ServiceList{}
+	flags.Var(&rotateServiceKeys, "rotate-service-keys", "give the new app fresh keys for these comma separated services, in SERVICE_CREDENTIALS variables, and delete the old ones once it is live")
+	resumeFrom := flags.String("resume-from", "", "resume the last deploy of the app, which failed and could not be rolled back, from this step")
+	drainWait := flags.Duration("drain-wait", 0, "unmap the old app's routes and wait this long (e.g. 30s) before stopping or deleting it")
+	pushArgs := PushArgs{}
+	flags.Var(&pushArgs, "push-arg", "pass extra arguments on to cf push (repeatable)")
+	flags.Var(env, "env", "set an environment variable on the new app, as KEY=VALUE (repeatable)")
+	onlyDomains := DomainList{}
+	flags.Var(&onlyDomains, "only-domains", "only move routes on these comma separated domains to the new app")
+	excludeDomains := DomainList{}
+	flags.Var(&excludeDomains, "exclude-domains", "leave routes on these comma separated domains on the old app")
+	cutoverDomains := DomainList{}
+	flags.Var(&cutoverDomains, "cutover-order", "move routes to the new app a domain at a time, in this comma separated order, with routes on other domains last")
+	cutoverPause := flags.Duration("cutover-pause", 0, "wait this long (e.g. 1m) between the domains of --cutover-order")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	appName := args[1]
+	*manifestPath, *appPath = ResolveWorkdir(*workdir, *manifestPath, *appPath)
+
+	if *manifestPath == "" {
+		return "", "", "", AutopilotOptions{}, ErrNoManifest
+	}
+
+	if *packageApp && *appPath == "" {
+		return "", "", "", AutopilotOptions{}, ErrNoAppPath
+	}
+
+	failOnDriftCategories, err := ParseDriftCategories(*failOnDrift)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	stagedStartPercent, err := ParseStagedStart(*stagedStart)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	scale, err := parseScaleOptions(*instances, *memory, *diskQuota)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	healthCheck, err := ParseHealthCheck(*healthCheckType, *healthCheckEndpoint)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	routesFromSource, err := ParseRoutesFrom(*routesFrom)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	readyLog, err := ParseReadyLog(*readyLogPattern, *readyLogTimeout)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	routeCheck, err := ParseRouteCheck(*routeCheckHeader, *routeCheckBody)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	crashWatch, err := ParseCrashWatch(*stabilizationWindow, *maxCrashes)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	cutoverOrder, err := ParseCutoverOrder(cutoverDomains, *cutoverPause)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	probeWatch, err := ParseProbeWatch(*autoRollback, *autoRollbackWindow, *maxErrorRate, *maxLatency)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	probe := Prober{Path: *probePath, Status: *probeStatus, Count: *probeCount}
+
+	// warming up, probing and the test route's own options need a test
+	// route, so pick one if none was given
+	if ((*warmup > 0 || *warmupRequests > 0 || probe.Enabled() || *testRouteService != "" || *testRouteHTTPSOnly) && *testRoute == "") {
+		*testRoute = autoTestRoute
+	}
+
+	options := AutopilotOptions{
+		KeepExisting:         *keepVenerable,
+		UnmapRoute:           *unmapVenerableRoutes,
+		ContinueOnRouteError: *continueOnRouteError,
+		Env:                  env,
+		StrictRoutes:         *strictRoutes,
+		RoutesFrom:           routesFromSource,
+		CopyRouteServices:    *copyRouteServices,
+		TestRoute:            *testRoute,
+		TestRouteService:     *testRouteService,
+		TestRouteHTTPSOnly:   *testRouteHTTPSOnly,
+		// anything after -- is passed on to cf push as well
+		PushArgs:             append(pushArgs, flags.Args()...),
+		DrainWait:            *drainWait,
+		Domains:              DomainFilter{Only: onlyDomains, Exclude: excludeDomains},
+		CutoverOrder:         cutoverOrder,
+		Warmup:               *warmup,
+		WarmupRequests:       *warmupRequests,
+		Probe:                probe,
+		ReadyLog:             readyLog,
+		RouteCheck:           routeCheck,
+		CrashWatch:           crashWatch,
+		ProbeWatch:           probeWatch,
+		VerifySSH:            *verifySSH,
+		PremapRoutes:         *premapRoutes,
+		Diff:                 *diff || len(failOnDriftCategories) > 0,
+		FailOnDrift:          failOnDriftCategories,
+		DeleteOrphanedRoutes: *deleteOrphanedRoutes,
+		CheckSecurityGroups:  *checkSecurityGroups,
+		AllowStoppedApp:      *allowStoppedApp,
+		StagedStart:          stagedStartPercent,
+		Revision:             *revision,
+		Vars:                 vars,
+		RotateServiceKeys:    rotateServiceKeys,
+		Buildpacks:           buildpacks,
+		Lifecycle:            *lifecycle,
+		StartCommand:         *startCommand,
+		ForceNameCollision:   *forceNameCollision,
+		Scale:                scale,
+		HealthCheck:          healthCheck,
+		Package:              *packageApp,
+		SkipIfUnchanged:      *skipIfUnchanged,
+		ResumeFrom:           *resumeFrom,
+	}
+
+	err = ValidatePushOptions(options, *appPath)
+	if err != nil {
+		return "", "", "", AutopilotOptions{}, err
+	}
+
+	return appName, *manifestPath, *appPath, options, nil
+}
+
+func ParseRollbackArgs(args []string) (RollbackOptions, error) {
+	flags := flag.NewFlagSet("zero-downtime-rollback", flag.ContinueOnError)
+	continueOnRouteError := flags.Bool("continue-on-route-error", false, "warn instead of failing when some routes cannot be mapped or unmapped")
+	from := flags.String("from", "", "roll back to this stopped copy of the app instead of the venerable one")
+	restage := flags.Bool("restage-on-rollback", false, "restage the copy being rolled back to, and check it is healthy, before it gets any traffic")
+	startFirst := flags.Bool("start-first", false, "start the copy being rolled back to, and check it is healthy, before the live app is touched")
+	yes := flags.Bool("yes", false, "roll back without asking for confirmation of the route changes")
+	forceNameCollision := flags.Bool("force-name-collision", false, "treat apps with the venerable or rollback name as copies autopilot left, even though they are not marked as ones")
+	strategy := flags.String("strategy", copyStrategy, "how to roll back: copy, to rename a kept copy over the app, or revisions, to deploy an earlier revision in place")
+	toRevision := flags.Int("to-revision", 0, "with --strategy revisions, the revision to roll back to (default: the one before the app's current one)")
+	onlyDomains := DomainList{}
+	flags.Var(&onlyDomains, "only-domains", "only move routes on these comma separated domains")
+	excludeDomains := DomainList{}
+	flags.Var(&excludeDomains, "exclude-domains", "leave routes on these comma separated domains where they are")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return RollbackOptions{}, err
+	}
+
+	if *strategy != copyStrategy && *strategy != revisionsStrategy {
+		return RollbackOptions{}, fmt.Errorf("--strategy should be %s or %s, not %q", copyStrategy, revisionsStrategy, *strategy)
+	}
+	if *toRevision != 0 && *strategy != revisionsStrategy {
+		return RollbackOptions{}, errors.New("--to-revision needs --strategy revisions")
+	}
+	if *strategy == revisionsStrategy && *from != "" {
+		return RollbackOptions{}, errors.New("--from names a copy of the app, which --strategy revisions does not use")
+	}
+
+	return RollbackOptions{
+		ContinueOnRouteError: *continueOnRouteError,
+		From:                 *from,
+		Restage:              *restage,
+		StartFirst:           *startFirst,
+		Yes:                  *yes,
+		Domains:              DomainFilter{Only: onlyDomains, Exclude: excludeDomains},
+		ForceNameCollision:   *forceNameCollision,
+		Strategy:             *strategy,
+		Revision:             *toRevision,
+	}, nil
+}
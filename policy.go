@@ -0,0 +1,224 @@
+package autopilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// The stages of a deploy at which the policy is consulted.
+const (
+	// PolicyCutover is before the live app is renamed and the new version
+	// pushed to take over its routes.
+	PolicyCutover = "cutover"
+	// PolicyDelete is before the old version is deleted.
+	PolicyDelete = "delete"
+)
+
+// policyClient posts requests to a policy endpoint.
+var policyClient = &http.Client{Timeout: 30 * time.Second}
+
+// PolicyHooks sets, in the config file, the deploy policy consulted before
+// the destructive steps of a deploy, so an org can put guardrails on its
+// spaces without every pipeline opting in. Each is a command run through the
+// shell, or an http or https URL. The setting for the space wins over the
+// default.
+type PolicyHooks struct {
+	Default string            `yaml:"default"`
+	Spaces  map[string]string `yaml:"spaces"`
+}
+
+// For returns the policy for the space, or "" if there is none.
+func (hooks PolicyHooks) For(spaceName string) string {
+	if hook := hooks.Spaces[spaceName]; hook != "" {
+		return hook
+	}
+
+	return hooks.Default
+}
+
+// policyFor returns the config file's policy for the current space. The
+// space is only looked up if the config names any.
+func policyFor(hooks PolicyHooks, repo *ApplicationRepo) (PolicyHook, error) {
+	if hooks.Default == "" && len(hooks.Spaces) == 0 {
+		return PolicyHook{}, nil
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return PolicyHook{}, err
+	}
+
+	return PolicyHook{Hook: hooks.For(space.Name), Space: space.Name}, nil
+}
+
+// PolicyHook is the policy consulted for deploys to a space.
+type PolicyHook struct {
+	// Hook is the command or URL.
+	Hook  string
+	Space string
+}
+
+// Enabled is true when the space has a policy.
+func (hook PolicyHook) Enabled() bool {
+	return hook.Hook != ""
+}
+
+// IsEndpoint is true when the policy is a URL rather than a command.
+func (hook PolicyHook) IsEndpoint() bool {
+	return strings.HasPrefix(hook.Hook, "http://") || strings.HasPrefix(hook.Hook, "https://")
+}
+
+// PolicyRequest is what the policy is given to decide on, as JSON: on the
+// command's stdin, or as the body of a POST to the endpoint.
+type PolicyRequest struct {
+	// Stage is PolicyCutover or PolicyDelete.
+	Stage string `json:"stage"`
+	App   string `json:"app"`
+	Space string `json:"space"`
+	// Vars are the --var values, e.g. a change ticket.
+	Vars map[string]string `json:"vars"`
+	Plan Plan              `json:"plan"`
+}
+
+// PolicyDecision is the policy's answer. A command allows the step by exiting
+// zero, and denies it otherwise, giving its output as the reason; an endpoint
+// answers with the decision as JSON.
+type PolicyDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason"`
+}
+
+// Consult asks the policy whether the deploy may go on. An error means the
+// policy could not be asked, which stops the deploy as a denial does.
+func (hook PolicyHook) Consult(request PolicyRequest) (PolicyDecision, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+
+	if hook.IsEndpoint() {
+		return hook.post(body)
+	}
+	return hook.run(body)
+}
+
+func (hook PolicyHook) run(body []byte) (PolicyDecision, error) {
+	var output bytes.Buffer
+	cmd := ShellCommand(hook.Hook)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &output
+
+	err := cmd.Run()
+	reason := strings.TrimSpace(output.String())
+	if _, exited := err.(*exec.ExitError); exited {
+		if reason == "" {
+			reason = err.Error()
+		}
+		return PolicyDecision{Allow: false, Reason: reason}, nil
+	}
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("could not run the deploy policy %q: %s", hook.Hook, err)
+	}
+
+	return PolicyDecision{Allow: true, Reason: reason}, nil
+}
+
+func (hook PolicyHook) post(body []byte) (PolicyDecision, error) {
+	resp, err := policyClient.Post(hook.Hook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("could not ask the deploy policy at %s: %s", hook.Hook, err)
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	if resp.StatusCode >= 300 {
+		return PolicyDecision{}, fmt.Errorf("the deploy policy at %s answered with status %d", hook.Hook, resp.StatusCode)
+	}
+
+	var decision PolicyDecision
+	err = json.Unmarshal(contents, &decision)
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("the deploy policy at %s did not answer with a decision: %s", hook.Hook, err)
+	}
+
+	return decision, nil
+}
+
+// PolicyDeniedError says the deploy policy stopped a deploy, and why.
+type PolicyDeniedError struct {
+	Stage  string
+	App    string
+	Reason string
+}
+
+func (err PolicyDeniedError) Error() string {
+	reason := describeIf(err.Reason != "", ": "+err.Reason)
+	return fmt.Sprintf("the deploy policy does not allow the %s of %s%s", err.Stage, err.App, reason)
+}
+
+// consultPolicy asks the policy, if the space has one, whether the stage of
+// the push with these actions may go ahead.
+func (planner *DeploymentPlanner) consultPolicy(stage, appName string, options AutopilotOptions, actions []rewind.Action) error {
+	if !planner.Policy.Enabled() {
+		return nil
+	}
+
+	planner.Logger.Printf("Asking the deploy policy whether the %s of %s may go ahead\n", stage, appName)
+	vars := map[string]string{}
+	for name, value := range options.Vars {
+		vars[name] = value
+	}
+	decision, err := planner.Policy.Consult(PolicyRequest{
+		Stage: stage,
+		App:   appName,
+		Space: planner.Policy.Space,
+		Vars:  vars,
+		Plan:  NewPlan("zero-downtime-push", appName, true, actions),
+	})
+	if err != nil {
+		return err
+	}
+	if !decision.Allow {
+		return PolicyDeniedError{Stage: stage, App: appName, Reason: decision.Reason}
+	}
+
+	return nil
+}
+
+// policyAction consults the policy before the stage of the deploy, with the
+// whole plan of it. actions is read once the plan is complete. reverse, if
+// any, puts back what the actions before it changed should it deny.
+func (planner *DeploymentPlanner) policyAction(stage, appName string, options AutopilotOptions, actions *[]rewind.Action, applies bool, reverse func() error) rewind.Action {
+	venerable := planner.Naming.VenerableName(appName)
+
+	return rewind.Action{
+		Name: "consult policy before " + stage,
+		Forward: func() error {
+			if !applies {
+				return nil
+			}
+
+			return planner.consultPolicy(stage, appName, options, *actions)
+		},
+		ReversePrevious: reverse,
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Ask the deploy policy%s whether the %s of %s may go ahead, with this plan, and stop the deploy if not.",
+				describeIf(planner.Policy.Enabled(), " for space "+planner.Policy.Space), stage, appName),
+			When: describeChoice(!applies, "not when the old version is kept, so it does nothing",
+				describeChoice(planner.Policy.Enabled(), "only when the config file sets a policy for the space, which it does",
+					"only when the config file sets a policy for the space, which it does not, so it does nothing")),
+			ReversePrevious: describeIf(reverse != nil, fmt.Sprintf("Map the routes back to %s.", venerable)),
+		},
+	}
+}
@@ -0,0 +1,171 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Deploy policy", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "policy")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("prefers the space's policy to the default", func() {
+		hooks := PolicyHooks{Default: "./check.sh", Spaces: map[string]string{"prod": "https://policy.example.com"}}
+		Expect(hooks.For("prod")).To(Equal("https://policy.example.com"))
+		Expect(hooks.For("dev")).To(Equal("./check.sh"))
+		Expect(PolicyHooks{}.For("prod")).To(BeEmpty())
+	})
+
+	It("is read from the config file", func() {
+		path := filepath.Join(dir, "autopilot.yml")
+		Expect(ioutil.WriteFile(path, []byte("policy:\n  spaces:\n    prod: ./check.sh\n"), 0644)).To(Succeed())
+
+		config, err := LoadConfig(func(name string) string {
+			if name == "AUTOPILOT_CONFIG" {
+				return path
+			}
+			return ""
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Policy).To(Equal(PolicyHooks{Spaces: map[string]string{"prod": "./check.sh"}}))
+	})
+
+	Describe("a command", func() {
+		It("is given the request on stdin, and allows the step by exiting zero", func() {
+			requestPath := filepath.Join(dir, "request.json")
+			hook := PolicyHook{Hook: "cat > " + requestPath, Space: "prod"}
+
+			decision, err := hook.Consult(PolicyRequest{Stage: PolicyDelete, App: "app", Space: "prod", Vars: map[string]string{"ticket": "CHG-1"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decision.Allow).To(BeTrue())
+
+			contents, err := ioutil.ReadFile(requestPath)
+			Expect(err).ToNot(HaveOccurred())
+			var request PolicyRequest
+			Expect(json.Unmarshal(contents, &request)).To(Succeed())
+			Expect(request.Stage).To(Equal("delete"))
+			Expect(request.Vars).To(Equal(map[string]string{"ticket": "CHG-1"}))
+		})
+
+		It("denies the step by exiting non-zero, giving its output as the reason", func() {
+			hook := PolicyHook{Hook: "echo 'no prod deletes without a change ticket'; exit 1"}
+
+			decision, err := hook.Consult(PolicyRequest{Stage: PolicyDelete, App: "app"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decision).To(Equal(PolicyDecision{Allow: false, Reason: "no prod deletes without a change ticket"}))
+		})
+	})
+
+	Describe("an endpoint", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("is posted the request, and answers with the decision", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/"),
+				ghttp.VerifyContentType("application/json"),
+				ghttp.RespondWith(http.StatusOK, `{"allow":false,"reason":"frozen"}`),
+			))
+
+			hook := PolicyHook{Hook: server.URL() + "/"}
+			Expect(hook.IsEndpoint()).To(BeTrue())
+
+			decision, err := hook.Consult(PolicyRequest{Stage: PolicyCutover, App: "app"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decision).To(Equal(PolicyDecision{Allow: false, Reason: "frozen"}))
+		})
+
+		It("fails when it cannot decide", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+			hook := PolicyHook{Hook: server.URL() + "/"}
+			_, err := hook.Consult(PolicyRequest{Stage: PolicyCutover, App: "app"})
+			Expect(err).To(MatchError("the deploy policy at " + server.URL() + "/ answered with status 500"))
+		})
+	})
+
+	Describe("during a push", func() {
+		var (
+			repo         *recordingRepo
+			planner      *DeploymentPlanner
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifestPath = filepath.Join(dir, "manifest.yml")
+			Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: app\n"), 0644)).To(Succeed())
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: discardLogger{},
+			}
+		})
+
+		It("changes nothing when the cutover is denied", func() {
+			planner.Policy = PolicyHook{Hook: "echo 'change freeze'; exit 1", Space: "prod"}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).To(MatchError("the deploy policy does not allow the cutover of app: change freeze"))
+			Expect(repo.calls).ToNot(ContainElement("RenameApplication app app-venerable"))
+		})
+
+		It("rolls back when the delete of the old version is denied", func() {
+			planner.Policy = PolicyHook{Hook: `grep -q '"stage":"delete"' && { echo 'no prod deletes without a change ticket'; exit 1; }; exit 0`, Space: "prod"}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).To(MatchError("the deploy policy does not allow the delete of app: no prod deletes without a change ticket"))
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  []"))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+
+		It("is asked before an old version kept by an earlier deploy is deleted", func() {
+			repo.existing["app-venerable"] = true
+			planner.Policy = PolicyHook{Hook: `grep -q '"stage":"delete"' && { echo 'app-venerable is the fallback'; exit 1; }; exit 0`, Space: "prod"}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{KeepExisting: true})}.Execute()
+			Expect(err).To(MatchError("the deploy policy does not allow the delete of app: app-venerable is the fallback"))
+			Expect(repo.calls).ToNot(ContainElement("DeleteApplication app-venerable"))
+			Expect(repo.calls).ToNot(ContainElement("RenameApplication app app-venerable"))
+		})
+
+		It("is not asked about the delete when the old version is kept", func() {
+			planner.Policy = PolicyHook{Hook: `grep -q '"stage":"delete"' && exit 1; exit 0`, Space: "prod"}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{KeepExisting: true})}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("StopApplication app-venerable"))
+		})
+	})
+})
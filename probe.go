@@ -0,0 +1,74 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prober checks an app is ready by requesting one of its endpoints until it
+// answers as expected several times in a row, independently of the platform's
+// own health check.
+type Prober struct {
+	// Path is requested on the app's route, e.g. "/healthz".
+	Path string
+	// Status is the status to expect, or 0 for any success or redirect.
+	Status int
+	// Count is how many consecutive requests must succeed.
+	Count int
+}
+
+// Enabled is true when any probe option was given.
+func (prober Prober) Enabled() bool {
+	return prober.Path != "" || prober.Status != 0 || prober.Count != 0
+}
+
+// Probe requests the path on baseURL until Count requests in a row get the
+// expected status. A failed request starts the count again, and the probe
+// gives up after testRouteAttempts failures.
+func (prober Prober) Probe(baseURL string, clock Clock) error {
+	url := strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(prober.Path, "/")
+
+	count := prober.Count
+	if count < 1 {
+		count = 1
+	}
+
+	consecutive, failures := 0, 0
+	var lastErr error
+	for {
+		err := prober.check(url)
+		if err == nil {
+			consecutive++
+			if consecutive >= count {
+				return nil
+			}
+		} else {
+			consecutive = 0
+			failures++
+			lastErr = err
+			if failures >= testRouteAttempts {
+				return fmt.Errorf("%s did not answer %d times in a row, giving up after %d failures: %s", url, count, failures, lastErr)
+			}
+		}
+
+		clock.Sleep(testRouteInterval)
+	}
+}
+
+func (prober Prober) check(url string) error {
+	resp, err := probeClient.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if prober.Status != 0 && resp.StatusCode != prober.Status {
+		return fmt.Errorf("status %d, expected %d", resp.StatusCode, prober.Status)
+	}
+
+	if prober.Status == 0 && resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return nil
+}
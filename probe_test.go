@@ -0,0 +1,58 @@
+package autopilot_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Prober", func() {
+	var (
+		server *ghttp.Server
+		clock  *fakeClock
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		clock = &fakeClock{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("is only enabled by probe options", func() {
+		Expect(Prober{}.Enabled()).To(BeFalse())
+		Expect(Prober{Path: "/healthz"}.Enabled()).To(BeTrue())
+	})
+
+	It("needs consecutive successes on the path", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(ghttp.VerifyRequest("GET", "/healthz"), ghttp.RespondWith(http.StatusOK, "")),
+			ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+			ghttp.RespondWith(http.StatusOK, ""),
+			ghttp.RespondWith(http.StatusOK, ""),
+		)
+
+		prober := Prober{Path: "/healthz", Status: http.StatusOK, Count: 2}
+		Expect(prober.Probe(server.URL(), clock)).To(Succeed())
+		Expect(server.ReceivedRequests()).To(HaveLen(4))
+		Expect(clock.slept).To(HaveLen(3))
+	})
+
+	It("expects the given status exactly", func() {
+		server.AllowUnhandledRequests = true
+		server.UnhandledRequestStatusCode = http.StatusFound
+
+		prober := Prober{Path: "healthz", Status: http.StatusOK}
+		err := prober.Probe(server.URL()+"/", clock)
+		Expect(err).To(MatchError(server.URL() + "/healthz did not answer 1 times in a row, giving up after 10 failures: status 302, expected 200"))
+		Expect(clock.slept).To(HaveLen(9))
+		Expect(clock.slept[0]).To(Equal(3 * time.Second))
+	})
+})
@@ -0,0 +1,241 @@
+package autopilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// progressLogLines is how many of the most recent log lines the status
+// endpoint shows.
+const progressLogLines = 20
+
+// ProgressServer serves the progress of a running deploy as JSON on a local
+// port, for dashboards and wrapper tools to poll during long pushes.
+type ProgressServer struct {
+	// Addr is the address the server listens on, once it is listening.
+	Addr string
+	// Redactor masks sensitive values in the error and the log tail.
+	Redactor *Redactor
+
+	mutex    sync.Mutex
+	status   DeployProgress
+	started  time.Time
+	listener net.Listener
+	abort    func(by string)
+}
+
+// DeployProgress is what the status endpoint answers with.
+type DeployProgress struct {
+	Command        string    `json:"command"`
+	App            string    `json:"app"`
+	State          string    `json:"state"`
+	Error          string    `json:"error,omitempty"`
+	Step           string    `json:"current_step"`
+	Phase          string    `json:"phase"`
+	StepsCompleted int       `json:"steps_completed"`
+	StepsTotal     int       `json:"steps_total"`
+	StartedAt      time.Time `json:"started_at"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	LogTail        []string  `json:"log_tail"`
+}
+
+// ProgressRunning is the state of a deploy that has not finished yet; the
+// states it finishes with are those of the report.
+const ProgressRunning = "running"
+
+// ParseStatusPort takes the --status-port flag, which every command that
+// deploys accepts, out of args. The port is 0 if the flag is not given.
+func ParseStatusPort(args []string) (int, []string, error) {
+	value, args := takeStringFlag(args, "status-port")
+	if value == "" {
+		return 0, args, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, args, fmt.Errorf("--status-port must be a port number, not %q", value)
+	}
+
+	return port, args, nil
+}
+
+// NewProgressServer starts tracking a deploy of the app, which runs steps
+// actions. It does not serve anything until Listen is called.
+func NewProgressServer(command, appName string, steps int) *ProgressServer {
+	started := time.Now()
+	return &ProgressServer{
+		started: started,
+		status: DeployProgress{
+			Command:    command,
+			App:        appName,
+			State:      ProgressRunning,
+			StepsTotal: steps,
+			StartedAt:  started.UTC(),
+			LogTail:    []string{},
+		},
+	}
+}
+
+// Listen serves the progress on the port of the loopback interface, in the
+// background, until Close is called. Port 0 picks a free port.
+func (progress *ProgressServer) Listen(port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return fmt.Errorf("could not serve the deploy status on port %d: %s", port, err)
+	}
+
+	progress.listener = listener
+	progress.Addr = listener.Addr().String()
+	go http.Serve(listener, http.HandlerFunc(progress.serveHTTP))
+	return nil
+}
+
+// Close stops serving the progress.
+func (progress *ProgressServer) Close() error {
+	if progress == nil || progress.listener == nil {
+		return nil
+	}
+
+	return progress.listener.Close()
+}
+
+// Status returns the progress so far.
+func (progress *ProgressServer) Status() DeployProgress {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	status := progress.status
+	status.ElapsedSeconds = time.Since(progress.started).Seconds()
+	status.Error = progress.Redactor.Redact(status.Error)
+	status.LogTail = progress.Redactor.RedactAll(progress.status.LogTail)
+	return status
+}
+
+func (progress *ProgressServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" && r.URL.Path == "/abort" {
+		progress.mutex.Lock()
+		abort := progress.abort
+		progress.mutex.Unlock()
+
+		if abort == nil {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		abort(fmt.Sprintf("a request from %s to the status endpoint", r.RemoteAddr))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress.Status())
+}
+
+// SetAbort makes a POST to /abort, as zero-downtime-abort --status-url
+// makes, call abort with who asked. It is a no-op on a nil server.
+func (progress *ProgressServer) SetAbort(abort func(by string)) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	progress.abort = abort
+}
+
+// StepStarting records the step that is running. It is a no-op on a nil
+// server, so it can be passed to rewind.Actions unconditionally.
+func (progress *ProgressServer) StepStarting(name, phase string) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	progress.status.Step = name
+	progress.status.Phase = phase
+}
+
+// ObserveStep counts the steps that have completed. It is a rewind.Observer,
+// and a no-op on a nil server.
+func (progress *ProgressServer) ObserveStep(name, phase string, start time.Time, err error) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	if phase == rewind.PhaseForward && err == nil {
+		progress.status.StepsCompleted++
+	}
+}
+
+// Finish records how the deploy ended.
+func (progress *ProgressServer) Finish(deployErr error) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	progress.status.Step = ""
+	progress.status.Phase = ""
+	switch deployErr {
+	case nil:
+		progress.status.State = ReportSucceeded
+	case context.Canceled:
+		progress.status.State = ReportInterrupted
+	default:
+		progress.status.State = ReportFailed
+		progress.status.Error = deployErr.Error()
+	}
+}
+
+// Logger returns a Logger that passes messages on to logger, keeping the
+// last lines of them for the status endpoint.
+func (progress *ProgressServer) Logger(logger Logger) Logger {
+	if progress == nil {
+		return logger
+	}
+
+	return progressLogger{progress: progress, next: logger}
+}
+
+type progressLogger struct {
+	progress *ProgressServer
+	next     Logger
+}
+
+func (logger progressLogger) Printf(format string, args ...interface{}) {
+	logger.next.Printf(format, args...)
+
+	logger.progress.mutex.Lock()
+	defer logger.progress.mutex.Unlock()
+
+	tail := logger.progress.status.LogTail
+	for _, line := range strings.Split(strings.TrimRight(fmt.Sprintf(format, args...), "\n"), "\n") {
+		tail = append(tail, line)
+	}
+	if len(tail) > progressLogLines {
+		tail = tail[len(tail)-progressLogLines:]
+	}
+	logger.progress.status.LogTail = tail
+}
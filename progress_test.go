@@ -0,0 +1,151 @@
+package autopilot_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("ParseStatusPort", func() {
+	It("takes the status port flag out of the args", func() {
+		port, args, err := ParseStatusPort([]string{"zero-downtime-push", "app", "--status-port", "8123", "-f", "manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal(8123))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("is 0 when the flag is not given", func() {
+		port, args, err := ParseStatusPort([]string{"zero-downtime-scale", "app"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal(0))
+		Expect(args).To(Equal([]string{"zero-downtime-scale", "app"}))
+	})
+
+	It("rejects anything but a port number", func() {
+		for _, value := range []string{"http", "0", "70000"} {
+			_, _, err := ParseStatusPort([]string{"zero-downtime-push", "app", "--status-port", value})
+			Expect(err).To(MatchError(fmt.Sprintf("--status-port must be a port number, not %q", value)))
+		}
+	})
+})
+
+var _ = Describe("ProgressServer", func() {
+	var progress *ProgressServer
+
+	BeforeEach(func() {
+		progress = NewProgressServer("zero-downtime-push", "app", 3)
+	})
+
+	AfterEach(func() {
+		progress.Close()
+	})
+
+	It("tracks the step running and how many have completed", func() {
+		progress.StepStarting("push", rewind.PhaseForward)
+		progress.ObserveStep("push", rewind.PhaseForward, time.Now(), nil)
+		progress.StepStarting("verify routes", rewind.PhaseForward)
+
+		status := progress.Status()
+		Expect(status.Command).To(Equal("zero-downtime-push"))
+		Expect(status.App).To(Equal("app"))
+		Expect(status.State).To(Equal(ProgressRunning))
+		Expect(status.Step).To(Equal("verify routes"))
+		Expect(status.Phase).To(Equal(rewind.PhaseForward))
+		Expect(status.StepsCompleted).To(Equal(1))
+		Expect(status.StepsTotal).To(Equal(3))
+		Expect(status.ElapsedSeconds).To(BeNumerically(">=", 0))
+	})
+
+	It("does not count steps that fail or undo others as completed", func() {
+		progress.ObserveStep("push", rewind.PhaseForward, time.Now(), nil)
+		progress.ObserveStep("verify routes", rewind.PhaseForward, time.Now(), errors.New("missing routes"))
+		progress.ObserveStep("push", rewind.PhaseUndo, time.Now(), nil)
+
+		Expect(progress.Status().StepsCompleted).To(Equal(1))
+	})
+
+	It("records how the deploy finished", func() {
+		progress.StepStarting("push", rewind.PhaseForward)
+		progress.Finish(errors.New("push failed"))
+		status := progress.Status()
+		Expect(status.State).To(Equal(ReportFailed))
+		Expect(status.Error).To(Equal("push failed"))
+		Expect(status.Step).To(BeEmpty())
+
+		progress.Finish(context.Canceled)
+		Expect(progress.Status().State).To(Equal(ReportInterrupted))
+
+		progress.Finish(nil)
+		Expect(progress.Status().State).To(Equal(ReportSucceeded))
+	})
+
+	It("keeps the tail of the log while passing it on", func() {
+		logger := &recordingLogger{}
+		wrapped := progress.Logger(logger)
+		for i := 1; i <= 25; i++ {
+			wrapped.Printf("line %d\n", i)
+		}
+		wrapped.Printf("two\nlines\n")
+
+		Expect(logger.messages).To(HaveLen(26))
+		tail := progress.Status().LogTail
+		Expect(tail).To(HaveLen(20))
+		Expect(tail[0]).To(Equal("line 8"))
+		Expect(tail[18:]).To(Equal([]string{"two", "lines"}))
+	})
+
+	It("masks sensitive values in the error and the log tail", func() {
+		redactor, err := NewRedactor(nil)
+		Expect(err).ToNot(HaveOccurred())
+		redactor.Add("API_TOKEN", "t0ken-value")
+		progress.Redactor = redactor
+
+		progress.Logger(discardLogger{}).Printf("Using t0ken-value.\n")
+		progress.Finish(errors.New("rejected t0ken-value"))
+
+		status := progress.Status()
+		Expect(status.LogTail).To(Equal([]string{"Using [REDACTED]."}))
+		Expect(status.Error).To(Equal("rejected [REDACTED]"))
+	})
+
+	It("serves the progress as JSON", func() {
+		Expect(progress.Listen(0)).To(Succeed())
+		progress.StepStarting("push", rewind.PhaseForward)
+		progress.Logger(discardLogger{}).Printf("Pushing app.\n")
+
+		response, err := http.Get("http://" + progress.Addr + "/")
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+		Expect(response.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var body map[string]interface{}
+		Expect(json.NewDecoder(response.Body).Decode(&body)).To(Succeed())
+		Expect(body["app"]).To(Equal("app"))
+		Expect(body["state"]).To(Equal("running"))
+		Expect(body["current_step"]).To(Equal("push"))
+		Expect(body["steps_total"]).To(BeNumerically("==", 3))
+		Expect(body).To(HaveKey("elapsed_seconds"))
+		Expect(body["log_tail"]).To(Equal([]interface{}{"Pushing app."}))
+	})
+
+	It("does nothing when there is no server", func() {
+		var none *ProgressServer
+		none.StepStarting("push", rewind.PhaseForward)
+		none.ObserveStep("push", rewind.PhaseForward, time.Now(), nil)
+		none.Finish(nil)
+		Expect(none.Close()).To(Succeed())
+
+		logger := &recordingLogger{}
+		Expect(none.Logger(logger) == Logger(logger)).To(BeTrue())
+	})
+})
@@ -0,0 +1,296 @@
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+// QuotaNeeds is what the new version of an app takes from the quotas.
+type QuotaNeeds = repository.QuotaNeeds
+
+type quotaDefinition struct {
+	Entity struct {
+		Name                    string `json:"name"`
+		MemoryLimit             int64  `json:"memory_limit"`
+		AppInstanceLimit        int    `json:"app_instance_limit"`
+		TotalRoutes             int    `json:"total_routes"`
+		TotalReservedRoutePorts int    `json:"total_reserved_route_ports"`
+	} `json:"entity"`
+}
+
+type quotaUsage struct {
+	Memory     int64
+	Instances  int
+	Routes     int
+	RoutePorts int
+}
+
+// CheckQuota makes sure there is room in the space and org quotas for a
+// second copy of the application, sized as needs says. During a
+// zero-downtime push the venerable app and the new app run side by side, so
+// without this check a push can run out of quota after the live app has
+// already been renamed.
+//
+// The new app is bound to the routes the live app already has, so only the
+// routes it adds, and the ports of those that are TCP routes, need route
+// quota.
+func (repo *ApplicationRepo) CheckQuota(appName string, needs QuotaNeeds) error {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return err
+	}
+
+	memory, instances := needs.Memory, needs.Instances
+	if memory == 0 {
+		memory = app.Memory
+	}
+	if instances == 0 {
+		instances = app.InstanceCount
+	}
+
+	needed := quotaUsage{
+		Memory:    memory * int64(instances),
+		Instances: instances,
+	}
+
+	if len(needs.Routes) > 0 {
+		liveRoutes, err := repo.AppRoutes(appName)
+		if err != nil {
+			return err
+		}
+		needed.Routes, needed.RoutePorts = newRoutes(needs.Routes, liveRoutes)
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return err
+	}
+
+	org, err := repo.conn.GetCurrentOrg()
+	if err != nil {
+		return err
+	}
+
+	var spaceResponse struct {
+		Entity struct {
+			SpaceQuotaDefinitionGuid string `json:"space_quota_definition_guid"`
+		} `json:"entity"`
+	}
+	err = repo.curl("v2/spaces/"+space.Guid, &spaceResponse)
+	if err != nil {
+		return err
+	}
+
+	if spaceResponse.Entity.SpaceQuotaDefinitionGuid != "" {
+		var quota quotaDefinition
+		err = repo.curl("v2/space_quota_definitions/"+spaceResponse.Entity.SpaceQuotaDefinitionGuid, &quota)
+		if err != nil {
+			return err
+		}
+
+		used, err := repo.spaceUsage(space.Guid, needed.Routes > 0)
+		if err != nil {
+			return err
+		}
+
+		err = checkQuota(fmt.Sprintf("space %q", space.Name), quota, used, needed)
+		if err != nil {
+			return err
+		}
+	}
+
+	var orgResponse struct {
+		Entity struct {
+			QuotaDefinitionGuid string `json:"quota_definition_guid"`
+		} `json:"entity"`
+	}
+	err = repo.curl("v2/organizations/"+org.Guid, &orgResponse)
+	if err != nil {
+		return err
+	}
+
+	var quota quotaDefinition
+	err = repo.curl("v2/quota_definitions/"+orgResponse.Entity.QuotaDefinitionGuid, &quota)
+	if err != nil {
+		return err
+	}
+
+	used, err := repo.orgUsage(org.Guid, needed.Routes > 0)
+	if err != nil {
+		return err
+	}
+
+	return checkQuota(fmt.Sprintf("org %q", org.Name), quota, used, needed)
+}
+
+// pushQuotaNeeds sizes the new version of appName as the push will: by
+// --instances and --memory, or else by the manifest. What neither sets is the
+// live app's. Its routes are the manifest's, when they can be known.
+func pushQuotaNeeds(appName, manifestPath string, options AutopilotOptions) (QuotaNeeds, error) {
+	needs := QuotaNeeds{Memory: options.Scale.Memory, Instances: options.Scale.Instances}
+
+	manifest, err := ParseManifest(manifestPath)
+	if err != nil {
+		return QuotaNeeds{}, err
+	}
+	app, _ := manifest.Application(appName)
+
+	if needs.Memory == 0 && app.Memory != "" {
+		needs.Memory, err = parseMegabytes(app.Memory)
+		if err != nil {
+			return QuotaNeeds{}, fmt.Errorf("the memory of %s in %s: %s", appName, manifestPath, err)
+		}
+	}
+
+	if needs.Instances == 0 && app.Instances != nil {
+		needs.Instances = *app.Instances
+	}
+
+	if routes, known := app.IntendedRoutes(); known {
+		needs.Routes = routes
+	}
+
+	return needs, nil
+}
+
+// newRoutes counts the routes the live app does not have yet, and how many
+// of them are TCP routes, which reserve a port.
+func newRoutes(routes, liveRoutes []string) (int, int) {
+	count, ports := 0, 0
+	for _, route := range routes {
+		if containsString(liveRoutes, route) {
+			continue
+		}
+
+		count++
+		if strings.Contains(route, ":") {
+			ports++
+		}
+	}
+
+	return count, ports
+}
+
+// routeUsage adds to used how many routes the query lists, and how many of
+// them reserve a port. Only the count is read, so only one route is fetched.
+func (repo *ApplicationRepo) routeUsage(query string, used quotaUsage) (quotaUsage, error) {
+	var routes struct {
+		TotalResults int `json:"total_results"`
+	}
+	err := repo.curl(query+"results-per-page=1", &routes)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	var ports struct {
+		TotalResults int `json:"total_results"`
+	}
+	err = repo.curl(query+"q=port%3E0&results-per-page=1", &ports)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	used.Routes, used.RoutePorts = routes.TotalResults, ports.TotalResults
+	return used, nil
+}
+
+func (repo *ApplicationRepo) spaceUsage(spaceGuid string, withRoutes bool) (quotaUsage, error) {
+	var summary struct {
+		Apps []struct {
+			Memory    int64  `json:"memory"`
+			Instances int    `json:"instances"`
+			State     string `json:"state"`
+		} `json:"apps"`
+	}
+
+	err := repo.curl("v2/spaces/"+spaceGuid+"/summary", &summary)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	used := quotaUsage{}
+	for _, app := range summary.Apps {
+		if app.State != "STARTED" {
+			continue
+		}
+
+		used.Memory += app.Memory * int64(app.Instances)
+		used.Instances += app.Instances
+	}
+
+	if !withRoutes {
+		return used, nil
+	}
+	return repo.routeUsage("v2/spaces/"+spaceGuid+"/routes?", used)
+}
+
+func (repo *ApplicationRepo) orgUsage(orgGuid string, withRoutes bool) (quotaUsage, error) {
+	var memory struct {
+		MemoryUsageInMB int64 `json:"memory_usage_in_mb"`
+	}
+	err := repo.curl("v2/organizations/"+orgGuid+"/memory_usage", &memory)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	var instances struct {
+		InstanceUsage int `json:"instance_usage"`
+	}
+	err = repo.curl("v2/organizations/"+orgGuid+"/instance_usage", &instances)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	used := quotaUsage{Memory: memory.MemoryUsageInMB, Instances: instances.InstanceUsage}
+	if !withRoutes {
+		return used, nil
+	}
+	return repo.routeUsage("v2/routes?q=organization_guid:"+orgGuid+"&", used)
+}
+
+// Negative limits mean the quota is unlimited.
+func checkQuota(owner string, quota quotaDefinition, used, needed quotaUsage) error {
+	limit := quota.Entity
+
+	if limit.MemoryLimit >= 0 && used.Memory+needed.Memory > limit.MemoryLimit {
+		return fmt.Errorf(
+			"Not enough memory in %s (quota %q) for a zero-downtime push: %dM needed, %dM of %dM already in use.",
+			owner, limit.Name, needed.Memory, used.Memory, limit.MemoryLimit,
+		)
+	}
+
+	if limit.AppInstanceLimit >= 0 && used.Instances+needed.Instances > limit.AppInstanceLimit {
+		return fmt.Errorf(
+			"Not enough app instances in %s (quota %q) for a zero-downtime push: %d needed, %d of %d already in use.",
+			owner, limit.Name, needed.Instances, used.Instances, limit.AppInstanceLimit,
+		)
+	}
+
+	if limit.TotalRoutes >= 0 && needed.Routes > 0 && used.Routes+needed.Routes > limit.TotalRoutes {
+		return fmt.Errorf(
+			"Not enough routes in %s (quota %q) for a zero-downtime push: %d new routes needed, %d of %d already in use.",
+			owner, limit.Name, needed.Routes, used.Routes, limit.TotalRoutes,
+		)
+	}
+
+	if limit.TotalReservedRoutePorts >= 0 && needed.RoutePorts > 0 && used.RoutePorts+needed.RoutePorts > limit.TotalReservedRoutePorts {
+		return fmt.Errorf(
+			"Not enough reserved route ports in %s (quota %q) for a zero-downtime push: %d needed, %d of %d already in use.",
+			owner, limit.Name, needed.RoutePorts, used.RoutePorts, limit.TotalReservedRoutePorts,
+		)
+	}
+
+	return nil
+}
+
+func (repo *ApplicationRepo) curl(path string, result interface{}) error {
+	output, err := repo.conn.CliCommandWithoutTerminalOutput("curl", path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(strings.Join(output, "")), result)
+}
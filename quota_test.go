@@ -0,0 +1,120 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("CheckQuota", func() {
+	var (
+		cliConn   *pluginfakes.FakeCliConnection
+		repo      *ApplicationRepo
+		responses map[string]string
+	)
+
+	BeforeEach(func() {
+		cliConn = &pluginfakes.FakeCliConnection{}
+		repo = NewApplicationRepo(cliConn)
+
+		cliConn.GetAppReturns(plugin_models.GetAppModel{Memory: 256, InstanceCount: 2}, nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid", Name: "dev"},
+		}, nil)
+		cliConn.GetCurrentOrgReturns(plugin_models.Organization{
+			OrganizationFields: plugin_models.OrganizationFields{Guid: "org-guid", Name: "org"},
+		}, nil)
+
+		responses = map[string]string{
+			"v2/spaces/space-guid":                        `{"entity":{"space_quota_definition_guid":"space-quota-guid"}}`,
+			"v2/space_quota_definitions/space-quota-guid": `{"entity":{"name":"small","memory_limit":2048,"app_instance_limit":-1}}`,
+			"v2/spaces/space-guid/summary":                `{"apps":[{"memory":256,"instances":2,"state":"STARTED"},{"memory":1024,"instances":4,"state":"STOPPED"}]}`,
+			"v2/organizations/org-guid":                   `{"entity":{"quota_definition_guid":"org-quota-guid"}}`,
+			"v2/quota_definitions/org-quota-guid":         `{"entity":{"name":"default","memory_limit":10240,"app_instance_limit":10}}`,
+			"v2/organizations/org-guid/memory_usage":      `{"memory_usage_in_mb":512}`,
+			"v2/organizations/org-guid/instance_usage":    `{"instance_usage":2}`,
+		}
+
+		cliConn.CliCommandWithoutTerminalOutputStub = func(args ...string) ([]string, error) {
+			return []string{responses[args[1]]}, nil
+		}
+	})
+
+	It("succeeds when both copies of the app fit in the quotas", func() {
+		Expect(repo.CheckQuota("app-name", QuotaNeeds{})).To(Succeed())
+	})
+
+	It("fails when the space memory quota would be exceeded", func() {
+		responses["v2/space_quota_definitions/space-quota-guid"] = `{"entity":{"name":"small","memory_limit":768,"app_instance_limit":-1}}`
+
+		err := repo.CheckQuota("app-name", QuotaNeeds{})
+		Expect(err).To(MatchError(`Not enough memory in space "dev" (quota "small") for a zero-downtime push: 512M needed, 512M of 768M already in use.`))
+	})
+
+	It("skips the space check when the space has no quota", func() {
+		responses["v2/spaces/space-guid"] = `{"entity":{"space_quota_definition_guid":null}}`
+		responses["v2/quota_definitions/org-quota-guid"] = `{"entity":{"name":"default","memory_limit":10240,"app_instance_limit":3}}`
+
+		err := repo.CheckQuota("app-name", QuotaNeeds{})
+		Expect(err).To(MatchError(`Not enough app instances in org "org" (quota "default") for a zero-downtime push: 2 needed, 2 of 3 already in use.`))
+	})
+
+	It("returns errors from the api", func() {
+		responses["v2/organizations/org-guid/memory_usage"] = "}notjson{"
+
+		Expect(repo.CheckQuota("app-name", QuotaNeeds{})).ToNot(Succeed())
+	})
+
+	It("sizes the new version as it will be pushed, not as the live app is", func() {
+		err := repo.CheckQuota("app-name", QuotaNeeds{Memory: 1024, Instances: 2})
+		Expect(err).To(MatchError(`Not enough memory in space "dev" (quota "small") for a zero-downtime push: 2048M needed, 512M of 2048M already in use.`))
+	})
+
+	Context("with routes the live app does not have", func() {
+		var api *ghttp.Server
+
+		BeforeEach(func() {
+			api = ghttp.NewServer()
+			api.RouteToHandler("GET", "/v2/routes/app-route", ghttp.RespondWith(http.StatusOK, `{"entity":{"host":"app","path":""}}`))
+			cliConn.ApiEndpointReturns(api.URL(), nil)
+			cliConn.AccessTokenReturns("bearer some-token", nil)
+
+			cliConn.GetAppReturns(plugin_models.GetAppModel{Memory: 256, InstanceCount: 2, Routes: []plugin_models.GetApp_RouteSummary{
+				{Guid: "app-route", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+			}}, nil)
+			responses["v2/space_quota_definitions/space-quota-guid"] = `{"entity":{"name":"small","memory_limit":-1,"app_instance_limit":-1,"total_routes":5,"total_reserved_route_ports":1}}`
+			responses["v2/quota_definitions/org-quota-guid"] = `{"entity":{"name":"default","memory_limit":-1,"app_instance_limit":-1,"total_routes":-1,"total_reserved_route_ports":-1}}`
+			responses["v2/spaces/space-guid/routes?results-per-page=1"] = `{"total_results":4}`
+			responses["v2/spaces/space-guid/routes?q=port%3E0&results-per-page=1"] = `{"total_results":0}`
+			responses["v2/routes?q=organization_guid:org-guid&results-per-page=1"] = `{"total_results":9}`
+			responses["v2/routes?q=organization_guid:org-guid&q=port%3E0&results-per-page=1"] = `{"total_results":1}`
+		})
+
+		AfterEach(func() {
+			api.Close()
+		})
+
+		It("needs no route quota for the routes the live app has", func() {
+			Expect(repo.CheckQuota("app-name", QuotaNeeds{Routes: []string{"app.example.com"}})).To(Succeed())
+		})
+
+		It("fails when the space route quota would be exceeded", func() {
+			err := repo.CheckQuota("app-name", QuotaNeeds{Routes: []string{"app.example.com", "api.example.com", "www.example.com"}})
+			Expect(err).To(MatchError(`Not enough routes in space "dev" (quota "small") for a zero-downtime push: 2 new routes needed, 4 of 5 already in use.`))
+		})
+
+		It("fails when the space has no port left for a new TCP route", func() {
+			responses["v2/spaces/space-guid/routes?q=port%3E0&results-per-page=1"] = `{"total_results":1}`
+
+			err := repo.CheckQuota("app-name", QuotaNeeds{Routes: []string{"tcp.example.com:1234"}})
+			Expect(err).To(MatchError(`Not enough reserved route ports in space "dev" (quota "small") for a zero-downtime push: 1 needed, 1 of 1 already in use.`))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package autopilot
+
+import (
+	"strings"
+)
+
+// asksForRandomRoute says whether the push would give the new version a
+// random route, through the manifest or --random-route. Its hostname is
+// generated, so it never matches the live app's, and pushing with it would
+// leave the live app's routes behind on the old version.
+func asksForRandomRoute(manifestApp ManifestApplication, pushArgs []string) bool {
+	if manifestApp.RandomRoute && len(manifestApp.Routes) == 0 {
+		return true
+	}
+
+	for _, arg := range pushArgs {
+		if arg == "--random-route" {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutRandomRoute drops --random-route from the push arguments, as the
+// cf CLI will not push with both it and --no-route.
+func withoutRandomRoute(pushArgs []string) []string {
+	kept := []string{}
+	for _, arg := range pushArgs {
+		if arg != "--random-route" {
+			kept = append(kept, arg)
+		}
+	}
+	return kept
+}
+
+// randomRouteWarning explains why the random route is not used.
+func randomRouteWarning(liveRoutes []string) string {
+	return "the manifest asks for a random route, which would leave the live app's routes on the old version. " +
+		"Pushing the new version without routes and giving it " + strings.Join(liveRoutes, ", ") + " instead; " +
+		"remove random-route from the manifest to stop seeing this."
+}
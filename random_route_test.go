@@ -0,0 +1,65 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("A manifest asking for a random route", func() {
+	var (
+		repo         *recordingRepo
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	BeforeEach(func() {
+		manifest, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manifest.WriteString("applications:\n- name: app\n  random-route: true\n")
+		Expect(err).ToNot(HaveOccurred())
+		manifest.Close()
+		manifestPath = manifest.Name()
+
+		repo = newRecordingRepo()
+		planner = &DeploymentPlanner{
+			Repo:   repo,
+			Naming: SuffixNaming{},
+			Clock:  &fakeClock{},
+			Logger: discardLogger{},
+		}
+	})
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	It("pushes without routes and gives the new version the live app's routes", func() {
+		repo.routes["app"] = []string{"app.example.com", "www.example.com"}
+
+		err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-route]"))
+		Expect(repo.calls).To(ContainElement("MapRouteURLs app [app.example.com www.example.com]"))
+	})
+
+	It("drops --random-route from the push arguments", func() {
+		repo.routes["app"] = []string{"app.example.com"}
+
+		err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{PushArgs: []string{"--random-route", "-s", "cflinuxfs4"}})}.Execute()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [-s cflinuxfs4 --no-route]"))
+	})
+
+	It("leaves the random route alone when the live app has no routes to keep", func() {
+		err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  []"))
+		Expect(repo.calls).ToNot(ContainElement(HavePrefix("MapRouteURLs")))
+	})
+})
@@ -0,0 +1,111 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// defaultReadyLogTimeout is how long to wait for the ready log line when
+// --ready-log-timeout is not given.
+const defaultReadyLogTimeout = 5 * time.Minute
+
+// readyLogInterval is how often the recent logs are read again.
+const readyLogInterval = 5 * time.Second
+
+// ReadyLog waits for the new app to log a line saying it is ready, for apps
+// whose port is up before they can serve.
+type ReadyLog struct {
+	// Pattern is a regular expression the line must match.
+	Pattern string
+	Timeout time.Duration
+}
+
+// ParseReadyLog reads --ready-log-pattern and --ready-log-timeout. The
+// ReadyLog is not enabled if no pattern is given.
+func ParseReadyLog(pattern string, timeout time.Duration) (ReadyLog, error) {
+	if pattern == "" {
+		if timeout != 0 {
+			return ReadyLog{}, errors.New("--ready-log-timeout needs --ready-log-pattern")
+		}
+		return ReadyLog{}, nil
+	}
+
+	_, err := regexp.Compile(pattern)
+	if err != nil {
+		return ReadyLog{}, fmt.Errorf("--ready-log-pattern %q is not a regular expression: %s", pattern, err)
+	}
+
+	if timeout < 0 {
+		return ReadyLog{}, fmt.Errorf("--ready-log-timeout %s should not be negative", timeout)
+	}
+	if timeout == 0 {
+		timeout = defaultReadyLogTimeout
+	}
+
+	return ReadyLog{Pattern: pattern, Timeout: timeout}, nil
+}
+
+// Enabled is true when a pattern was given.
+func (readyLog ReadyLog) Enabled() bool {
+	return readyLog.Pattern != ""
+}
+
+// RecentLogs reads the app's recent logs, as cf logs --recent shows them.
+func (repo *ApplicationRepo) RecentLogs(appName string) ([]string, error) {
+	return repo.conn.CliCommandWithoutTerminalOutput("logs", appName, "--recent")
+}
+
+// waitForReadyLog reads the app's recent logs until a line matches the
+// pattern, and fails once the timeout has passed without one.
+func (planner *DeploymentPlanner) waitForReadyLog(appName string, readyLog ReadyLog) error {
+	pattern, err := regexp.Compile(readyLog.Pattern)
+	if err != nil {
+		return err
+	}
+
+	planner.Logger.Printf("Waiting for %s to log a line matching %q\n", appName, readyLog.Pattern)
+	deadline := planner.Clock.Now().Add(readyLog.Timeout)
+	for {
+		lines, err := planner.Repo.RecentLogs(appName)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			if pattern.MatchString(line) {
+				planner.Logger.Printf("%s is ready: %s\n", appName, line)
+				return nil
+			}
+		}
+
+		if !planner.Clock.Now().Before(deadline) {
+			return fmt.Errorf("%s did not log a line matching %q within %s", appName, readyLog.Pattern, readyLog.Timeout)
+		}
+		planner.Clock.Sleep(readyLogInterval)
+	}
+}
+
+// readyLogAction holds the cutover until the new version logs that it is
+// ready.
+func (planner *DeploymentPlanner) readyLogAction(appName string, readyLog ReadyLog) rewind.Action {
+	return rewind.Action{
+		Name: "wait for ready log",
+		Forward: func() error {
+			if !readyLog.Enabled() {
+				return nil
+			}
+
+			return planner.waitForReadyLog(appName, readyLog)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Wait until the new version of %s logs a line matching %s%s.", appName,
+				describeChoice(readyLog.Enabled(), fmt.Sprintf("%q", readyLog.Pattern), "the --ready-log-pattern"),
+				describeIf(readyLog.Enabled(), fmt.Sprintf(", for up to %s", readyLog.Timeout))),
+			When: onlyWith("--ready-log-pattern", readyLog.Enabled()),
+		},
+	}
+}
@@ -0,0 +1,129 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Waiting for the ready log line", func() {
+	Describe("ParseReadyLog", func() {
+		It("waits five minutes unless told otherwise", func() {
+			Expect(ParseReadyLog("Server started on", 0)).To(Equal(ReadyLog{Pattern: "Server started on", Timeout: 5 * time.Minute}))
+			Expect(ParseReadyLog("Server started on", time.Minute)).To(Equal(ReadyLog{Pattern: "Server started on", Timeout: time.Minute}))
+			Expect(ParseReadyLog("", 0)).To(Equal(ReadyLog{}))
+		})
+
+		It("rejects a pattern it cannot compile, or a timeout without a pattern", func() {
+			_, err := ParseReadyLog("started (on", 0)
+			Expect(err).To(MatchError(HavePrefix(`--ready-log-pattern "started (on" is not a regular expression`)))
+
+			_, err = ParseReadyLog("", time.Minute)
+			Expect(err).To(MatchError("--ready-log-timeout needs --ready-log-pattern"))
+		})
+
+		It("is read from the command line", func() {
+			_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--ready-log-pattern", "Server started on", "--ready-log-timeout", "2m"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.ReadyLog).To(Equal(ReadyLog{Pattern: "Server started on", Timeout: 2 * time.Minute}))
+		})
+	})
+
+	It("reads the app's recent logs", func() {
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{}, nil)
+		cliConn.CliCommandWithoutTerminalOutputReturns([]string{"2024-06-07T12:12:00.00+0000 [APP/PROC/WEB/0] OUT Server started on 8080"}, nil)
+
+		lines, err := NewApplicationRepo(cliConn).RecentLogs("app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(HaveLen(1))
+		Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"logs", "app", "--recent"}))
+	})
+
+	Describe("before the cutover", func() {
+		var (
+			repo         *recordingRepo
+			clock        *fakeClock
+			planner      *DeploymentPlanner
+			options      AutopilotOptions
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			repo.routes["app"] = []string{"app.example.com"}
+			clock = &fakeClock{}
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  clock,
+				Logger: discardLogger{},
+			}
+			options = AutopilotOptions{ReadyLog: ReadyLog{Pattern: `Server started on \d+`, Timeout: 20 * time.Second}}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("moves the routes once the new version logs that it is ready", func() {
+			repo.logs = [][]string{
+				{"[APP/PROC/WEB/0] OUT Starting"},
+				{"[APP/PROC/WEB/0] OUT Starting", "[APP/PROC/WEB/0] OUT Server started on 8080"},
+			}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clock.slept).To(Equal([]time.Duration{5 * time.Second}))
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-route]"))
+
+			var waited, moved int
+			for i, call := range repo.calls {
+				switch call {
+				case "RecentLogs app":
+					waited = i
+				case "CopyRoutes app-venerable app", "MapRouteURLs app [app.example.com]":
+					if moved == 0 {
+						moved = i
+					}
+				}
+			}
+			Expect(waited).To(BeNumerically("<", moved))
+		})
+
+		It("rolls the deploy back when the line is not logged in time", func() {
+			repo.logs = [][]string{{"[APP/PROC/WEB/0] OUT Starting"}}
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError(`app did not log a line matching "Server started on \\d+" within 20s`))
+			Expect(clock.slept).To(HaveLen(4))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+
+		It("fails when the logs cannot be read", func() {
+			repo.failures["RecentLogs app"] = errors.New("no logs")
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError("no logs"))
+		})
+	})
+})
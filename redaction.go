@@ -0,0 +1,158 @@
+package autopilot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultRedactPatterns match the names of environment variables whose
+// values are masked in output, unless the config file's redact setting
+// gives others. CREDENTIALS covers the variables rotated service keys are
+// passed in.
+var DefaultRedactPatterns = []string{"PASSWORD", "TOKEN", "KEY", "SECRET", "CREDENTIALS"}
+
+// Redacted replaces a sensitive value.
+const Redacted = "[REDACTED]"
+
+// minRedactedLength is the shortest value masked wherever it appears. Masking
+// every "1" or "on" would make output unreadable and hide nothing.
+const minRedactedLength = 4
+
+// Redactor masks the values of sensitive environment variables in the output
+// the cf CLI shows at --verbose, in deployment reports and in the status
+// endpoint, so that CI logs do not leak credentials. A variable is sensitive
+// when its name matches one of the patterns, ignoring case.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	// assignment matches "NAME: value" and "NAME=value" for sensitive names,
+	// as in cf push's manifest diff, whether or not the value is known.
+	assignment *regexp.Regexp
+
+	mutex  sync.Mutex
+	values []string
+}
+
+// NewRedactor masks variables whose names match the patterns, which are
+// regular expressions. With no patterns, DefaultRedactPatterns are used.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultRedactPatterns
+	}
+
+	redactor := &Redactor{}
+	alternatives := []string{}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %s", pattern, err)
+		}
+		redactor.patterns = append(redactor.patterns, compiled)
+		alternatives = append(alternatives, "(?:"+pattern+")")
+	}
+
+	redactor.assignment = regexp.MustCompile(`(?i)(?P<name>[A-Za-z0-9_.-]*(?:` + strings.Join(alternatives, "|") + `)[A-Za-z0-9_.-]*"?\s*[:=]\s*)(?:"[^"]*"|'[^']*'|\S+)`)
+	return redactor, nil
+}
+
+// Sensitive says whether the variable's value is masked.
+func (redactor *Redactor) Sensitive(name string) bool {
+	for _, pattern := range redactor.patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add remembers the variable's value, if it is sensitive, so that it is
+// masked wherever it appears.
+func (redactor *Redactor) Add(name string, value interface{}) {
+	if redactor == nil || !redactor.Sensitive(name) {
+		return
+	}
+
+	text := fmt.Sprint(value)
+	if len(text) < minRedactedLength {
+		return
+	}
+
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	for _, known := range redactor.values {
+		if known == text {
+			return
+		}
+	}
+	redactor.values = append(redactor.values, text)
+	// longest first, so a value containing another is masked whole
+	sort.Slice(redactor.values, func(i, j int) bool { return len(redactor.values[i]) > len(redactor.values[j]) })
+}
+
+// Redact masks the sensitive values in text. It returns text unchanged on a
+// nil Redactor.
+func (redactor *Redactor) Redact(text string) string {
+	if redactor == nil {
+		return text
+	}
+
+	redactor.mutex.Lock()
+	values := append([]string{}, redactor.values...)
+	redactor.mutex.Unlock()
+
+	for _, value := range values {
+		text = strings.Replace(text, value, Redacted, -1)
+	}
+
+	return redactor.assignment.ReplaceAllString(text, "${name}"+Redacted)
+}
+
+// RedactValue masks the variable's whole value if it is sensitive, and the
+// sensitive values within it otherwise. An empty value is left empty.
+func (redactor *Redactor) RedactValue(name, value string) string {
+	if redactor == nil || value == "" {
+		return value
+	}
+	if redactor.Sensitive(name) {
+		return Redacted
+	}
+
+	return redactor.Redact(value)
+}
+
+// RedactAll masks the sensitive values in each of the lines.
+func (redactor *Redactor) RedactAll(lines []string) []string {
+	redacted := []string{}
+	for _, line := range lines {
+		redacted = append(redacted, redactor.Redact(line))
+	}
+	return redacted
+}
+
+// addAppSecrets remembers the sensitive values a push of the app may show:
+// those set on the live app, in the manifest and with --env.
+func addAppSecrets(redactor *Redactor, repo *ApplicationRepo, appName, manifestPath string, env EnvVars) {
+	app, err := repo.conn.GetApp(appName)
+	if err == nil {
+		for name, value := range app.EnvironmentVars {
+			redactor.Add(name, value)
+		}
+	}
+
+	if manifestPath != "" {
+		manifest, err := ParseManifest(manifestPath)
+		if err == nil {
+			entry, _ := manifest.Application(appName)
+			for name, value := range entry.Env {
+				redactor.Add(name, value)
+			}
+		}
+	}
+
+	for name, value := range env {
+		redactor.Add(name, value)
+	}
+}
@@ -0,0 +1,72 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Redactor", func() {
+	var redactor *Redactor
+
+	BeforeEach(func() {
+		var err error
+		redactor, err = NewRedactor(nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("treats variables named like passwords, tokens and keys as sensitive", func() {
+		Expect(redactor.Sensitive("DB_PASSWORD")).To(BeTrue())
+		Expect(redactor.Sensitive("github_token")).To(BeTrue())
+		Expect(redactor.Sensitive("API_KEY")).To(BeTrue())
+		Expect(redactor.Sensitive("ORDERS_DB_CREDENTIALS")).To(BeTrue())
+		Expect(redactor.Sensitive("LOG_LEVEL")).To(BeFalse())
+	})
+
+	It("masks the values of sensitive variables wherever they appear", func() {
+		redactor.Add("DB_PASSWORD", "hunter22")
+		redactor.Add("LOG_LEVEL", "debug")
+
+		Expect(redactor.Redact("jdbc://admin:hunter22@db/orders at debug")).To(Equal("jdbc://admin:[REDACTED]@db/orders at debug"))
+	})
+
+	It("masks the whole of a value that contains another", func() {
+		redactor.Add("SHORT_TOKEN", "abcd")
+		redactor.Add("LONG_TOKEN", "abcdefgh")
+
+		Expect(redactor.Redact("abcdefgh")).To(Equal("[REDACTED]"))
+	})
+
+	It("leaves values too short to mask safely", func() {
+		redactor.Add("DEBUG_KEY", "on")
+
+		Expect(redactor.Redact("logging on")).To(Equal("logging on"))
+	})
+
+	It("masks assignments to sensitive names even when the value is not known", func() {
+		Expect(redactor.Redact("+   DB_PASSWORD: hunter22")).To(Equal("+   DB_PASSWORD: [REDACTED]"))
+		Expect(redactor.Redact(`"api_key": "abc 123",`)).To(Equal(`"api_key": [REDACTED],`))
+		Expect(redactor.Redact("SECRET=xyz other")).To(Equal("SECRET=[REDACTED] other"))
+		Expect(redactor.Redact("memory: 1G")).To(Equal("memory: 1G"))
+	})
+
+	It("uses the patterns it is given instead of the defaults", func() {
+		custom, err := NewRedactor([]string{"^PRIVATE_"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(custom.Sensitive("PRIVATE_URL")).To(BeTrue())
+		Expect(custom.Sensitive("DB_PASSWORD")).To(BeFalse())
+	})
+
+	It("rejects invalid patterns", func() {
+		_, err := NewRedactor([]string{"PASS("})
+		Expect(err).To(MatchError(ContainSubstring(`invalid redact pattern "PASS("`)))
+	})
+
+	It("leaves text alone when there is no redactor", func() {
+		var none *Redactor
+		none.Add("DB_PASSWORD", "hunter22")
+		Expect(none.Redact("hunter22")).To(Equal("hunter22"))
+	})
+})
@@ -0,0 +1,182 @@
+package autopilot
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// DeploymentReport is the record of a deploy written by --report, for
+// archiving as a CI artifact and for audit review.
+type DeploymentReport struct {
+	Command         string        `json:"command"`
+	App             string        `json:"app"`
+	Status          string        `json:"status"`
+	Error           string        `json:"error,omitempty"`
+	StartedAt       time.Time     `json:"started_at"`
+	FinishedAt      time.Time     `json:"finished_at"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	GuidBefore      string        `json:"guid_before"`
+	GuidAfter       string        `json:"guid_after"`
+	RoutesBefore    []string      `json:"routes_before"`
+	RoutesAfter     []string      `json:"routes_after"`
+	RoutesMoved     []string      `json:"routes_moved"`
+	Steps           []StepReport  `json:"steps"`
+	Events          []ReportEvent `json:"events"`
+	// ConfigChanges are how the new version's environment and services
+	// differ from the old version's.
+	ConfigChanges []ConfigChange `json:"config_changes"`
+
+	// Redactor masks sensitive values in the errors written.
+	Redactor *Redactor `json:"-"`
+
+	mutex sync.Mutex
+}
+
+// StepReport is one step run during the deploy.
+type StepReport struct {
+	Name            string  `json:"name"`
+	Phase           string  `json:"phase"`
+	Outcome         string  `json:"outcome"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// The statuses a deploy can finish with.
+const (
+	ReportSucceeded   = "succeeded"
+	ReportFailed      = "failed"
+	ReportInterrupted = "interrupted"
+)
+
+// ParseReportPath takes the --report flag, which every command accepts, out
+// of args. Arguments after -- are left alone.
+func ParseReportPath(args []string) (string, []string) {
+	return takeStringFlag(args, "report")
+}
+
+// NewDeploymentReport starts the report of a deploy of the app, recording its
+// GUID and routes before anything changes.
+func NewDeploymentReport(command, appName string, guid string, routes []string) *DeploymentReport {
+	return &DeploymentReport{
+		Command:       command,
+		App:           appName,
+		StartedAt:     time.Now().UTC(),
+		GuidBefore:    guid,
+		RoutesBefore:  nonNil(routes),
+		RoutesAfter:   []string{},
+		RoutesMoved:   []string{},
+		Steps:         []StepReport{},
+		Events:        []ReportEvent{},
+		ConfigChanges: []ConfigChange{},
+	}
+}
+
+// ObserveStep is a rewind.Observer.
+func (report *DeploymentReport) ObserveStep(name, phase string, start time.Time, err error) {
+	if report == nil {
+		return
+	}
+
+	step := StepReport{
+		Name:            name,
+		Phase:           phase,
+		Outcome:         "success",
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		step.Outcome = "failure"
+		step.Error = err.Error()
+	}
+
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+	report.Steps = append(report.Steps, step)
+}
+
+// Finish records how the deploy ended and the app's GUID and routes
+// afterwards. A route counts as moved when the app was replaced and the new
+// version took it over.
+func (report *DeploymentReport) Finish(deployErr error, guid string, routes []string) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.FinishedAt = time.Now().UTC()
+	report.DurationSeconds = report.FinishedAt.Sub(report.StartedAt).Seconds()
+	report.GuidAfter = guid
+	report.RoutesAfter = nonNil(routes)
+
+	switch deployErr {
+	case nil:
+		report.Status = ReportSucceeded
+	case context.Canceled:
+		report.Status = ReportInterrupted
+		report.Error = deployErr.Error()
+	default:
+		report.Status = ReportFailed
+		report.Error = deployErr.Error()
+	}
+
+	report.RoutesMoved = []string{}
+	if report.GuidAfter != report.GuidBefore {
+		dropped := MissingRoutes(report.RoutesBefore, report.RoutesAfter)
+		report.RoutesMoved = MissingRoutes(report.RoutesBefore, dropped)
+	}
+}
+
+// Write saves the report as indented JSON.
+func (report *DeploymentReport) Write(path string) error {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.Error = report.Redactor.Redact(report.Error)
+	for i := range report.Steps {
+		report.Steps[i].Error = report.Redactor.Redact(report.Steps[i].Error)
+	}
+	for i, change := range report.ConfigChanges {
+		report.ConfigChanges[i].Old = report.Redactor.RedactValue(change.Name, change.Old)
+		report.ConfigChanges[i].New = report.Redactor.RedactValue(change.Name, change.New)
+	}
+
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+// AddConfigChanges records how the new version's configuration differs from
+// the old version's.
+func (report *DeploymentReport) AddConfigChanges(changes []ConfigChange) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.ConfigChanges = append(report.ConfigChanges, changes...)
+}
+
+// AppState returns the app's GUID and routes, or nothing if it cannot be
+// found, for reporting.
+func (repo *ApplicationRepo) AppState(appName string) (string, []string) {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return "", []string{}
+	}
+
+	routes, err := repo.AppRoutes(appName)
+	if err != nil {
+		return app.Guid, []string{}
+	}
+
+	return app.Guid, routes
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
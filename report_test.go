@@ -0,0 +1,99 @@
+package autopilot_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ParseReportPath", func() {
+	It("takes the report flag out of the args", func() {
+		path, args := ParseReportPath([]string{"zero-downtime-push", "app", "--report", "out.json", "-f", "manifest.yml"})
+		Expect(path).To(Equal("out.json"))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+		path, args = ParseReportPath([]string{"zero-downtime-rollback", "app", "--report=out.json"})
+		Expect(path).To(Equal("out.json"))
+		Expect(args).To(Equal([]string{"zero-downtime-rollback", "app"}))
+	})
+
+	It("leaves arguments after -- alone", func() {
+		path, args := ParseReportPath([]string{"zero-downtime-push", "app", "--", "--report", "x"})
+		Expect(path).To(BeEmpty())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "--", "--report", "x"}))
+	})
+})
+
+var _ = Describe("DeploymentReport", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "report")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("records the steps, the routes moved and the outcome", func() {
+		report := NewDeploymentReport("zero-downtime-push", "app", "old-guid", []string{"app.example.com", "old.example.com"})
+		report.ObserveStep("push", "forward", time.Now(), nil)
+		report.ObserveStep("verify routes", "forward", time.Now(), errors.New("missing routes"))
+		report.Finish(errors.New("missing routes"), "new-guid", []string{"app.example.com"})
+
+		path := filepath.Join(dir, "deployment-report.json")
+		Expect(report.Write(path)).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		var written map[string]interface{}
+		Expect(json.Unmarshal(contents, &written)).To(Succeed())
+		Expect(written["app"]).To(Equal("app"))
+		Expect(written["status"]).To(Equal("failed"))
+		Expect(written["error"]).To(Equal("missing routes"))
+		Expect(written["guid_before"]).To(Equal("old-guid"))
+		Expect(written["guid_after"]).To(Equal("new-guid"))
+		Expect(written["routes_moved"]).To(Equal([]interface{}{"app.example.com"}))
+		Expect(written["steps"]).To(HaveLen(2))
+		Expect(written["steps"].([]interface{})[1]).To(HaveKeyWithValue("outcome", "failure"))
+	})
+
+	It("moves no routes when the app was not replaced", func() {
+		report := NewDeploymentReport("zero-downtime-push", "app", "guid", []string{"app.example.com"})
+		report.Finish(context.Canceled, "guid", []string{"app.example.com"})
+
+		Expect(report.Status).To(Equal(ReportInterrupted))
+		Expect(report.RoutesMoved).To(BeEmpty())
+	})
+
+	It("masks sensitive values in the errors it writes", func() {
+		redactor, err := NewRedactor(nil)
+		Expect(err).ToNot(HaveOccurred())
+		redactor.Add("DB_PASSWORD", "hunter22")
+
+		report := NewDeploymentReport("zero-downtime-push", "app", "guid", []string{})
+		report.Redactor = redactor
+		report.ObserveStep("push", "forward", time.Now(), errors.New("could not connect with hunter22"))
+		report.Finish(errors.New("could not connect with hunter22"), "guid", []string{})
+
+		path := filepath.Join(dir, "deployment-report.json")
+		Expect(report.Write(path)).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).ToNot(ContainSubstring("hunter22"))
+		Expect(string(contents)).To(ContainSubstring("could not connect with [REDACTED]"))
+	})
+})
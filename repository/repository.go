@@ -0,0 +1,215 @@
+// Package repository describes the operations autopilot's deployment planner
+// performs on apps and routes, so tools that embed the planner can swap in
+// their own implementation or the fake in repositoryfakes.
+package repository
+
+import "time"
+
+// Route is a set of hosts, and the app's wildcard routes, which answer for
+// every host on their domains.
+type Route struct {
+	Host   []string
+	Domain string
+	// Domains are the domains of the hosts, one for each, for an app whose
+	// routes are on more than one. Without them every host is on Domain.
+	Domains []string
+	// Paths are the paths of the hosts' routes, one for each, as "/path".
+	// Without them, or for an empty one, the host's route has no path.
+	Paths []string
+	// Wildcards are the domains of the app's wildcard routes, *.domain.
+	Wildcards []string
+}
+
+// HostDomain is the domain of the i-th host.
+func (route Route) HostDomain(i int) string {
+	if i < len(route.Domains) && route.Domains[i] != "" {
+		return route.Domains[i]
+	}
+	return route.Domain
+}
+
+// HostPath is the path of the i-th host's route, or "" if it has none.
+func (route Route) HostPath(i int) string {
+	if i < len(route.Paths) {
+		return route.Paths[i]
+	}
+	return ""
+}
+
+// URLs lists the routes as "host.domain/path", and wildcard routes as
+// "*.domain".
+func (route Route) URLs() []string {
+	urls := []string{}
+	for i, host := range route.Host {
+		urls = append(urls, host+"."+route.HostDomain(i)+route.HostPath(i))
+	}
+	for _, domain := range route.Wildcards {
+		urls = append(urls, "*."+domain)
+	}
+	return urls
+}
+
+// RouteServiceBinding records that a route's traffic goes through a route
+// service.
+type RouteServiceBinding struct {
+	Route               string
+	RouteGuid           string
+	ServiceInstanceGuid string
+}
+
+// ScaleOptions are the new sizes of a scaled app. Zero values are left as
+// they were.
+type ScaleOptions struct {
+	Instances int
+	Memory    int64
+	DiskQuota int64
+}
+
+// QuotaNeeds is what the new version of an app takes from the quotas while it
+// runs beside the live one. Zero sizes are the live app's.
+type QuotaNeeds struct {
+	// Memory is per instance, in megabytes.
+	Memory    int64
+	Instances int
+	// Routes are the new version's routes, as "host.domain" or
+	// "domain:port"; those the live app has already take no more quota.
+	Routes []string
+}
+
+// AppConfig is the configuration of a live app that a manifest can set.
+type AppConfig struct {
+	// Memory is in megabytes.
+	Memory    int64
+	Instances int
+	Env       map[string]string
+	Services  []string
+	Routes    []string
+}
+
+// AppResources is what an app still holds on to: the routes mapped to it,
+// the services bound to it and its running instances.
+type AppResources struct {
+	// Exists is false once the app is deleted, when it holds nothing.
+	Exists           bool
+	Routes           []string
+	Services         []string
+	RunningInstances int
+}
+
+// AppState is how an app is doing: whether it is started, and how many of
+// its instances are running or have crashed.
+type AppState struct {
+	Name string
+	Guid string
+	// State is STARTED or STOPPED.
+	State string
+	// Instances is how many instances the app asks for.
+	Instances        int
+	RunningInstances int
+	CrashedInstances int
+}
+
+// InstanceStatus is the state of one of an app's instances.
+type InstanceStatus struct {
+	Index int
+	// State is RUNNING, STARTING, CRASHED or DOWN.
+	State string
+	// Since is when the instance entered the state.
+	Since time.Time
+	// Details says why, such as why it crashed, when the Cloud Controller
+	// knows.
+	Details string
+}
+
+// Revision is a version of an app the Cloud Controller recorded, which the
+// app can be deployed back to in place.
+type Revision struct {
+	Guid        string
+	Version     int
+	Description string
+	// Deployable is false once the revision's droplet has been deleted.
+	Deployable bool
+	// Deployed is true for the revision the app is running.
+	Deployed  bool
+	CreatedAt string
+}
+
+// ManagedMarker is what autopilot notes on the copies of an app it makes, so
+// they can be told apart from apps that merely have the same names.
+type ManagedMarker struct {
+	// OriginalName is the app the copy was made of. It is empty on copies
+	// marked before it was recorded.
+	OriginalName string
+	CreatedAt    time.Time
+}
+
+//go:generate counterfeiter . ApplicationRepository
+
+// ApplicationRepository is everything the planner needs to change apps and
+// routes.
+type ApplicationRepository interface {
+	DoesAppExist(appName string) (bool, error)
+	AppNames() ([]string, error)
+	IsAppStopped(appName string) (bool, error)
+	RenameApplication(oldName, newName string) error
+	PushApplication(appName, manifestPath, appPath string, extraArgs ...string) error
+	SetEnv(appName, name, value string) error
+	StartApplication(appName string) error
+	RestageApplication(appName string) error
+	CheckAppHealthy(appName string) error
+	RecentLogs(appName string) ([]string, error)
+	RunSSH(appName, command string) ([]string, error)
+	CrashCount(appName string, since time.Time) (int, error)
+	GetAppState(appName string) (AppState, error)
+	Instances(appName string) ([]InstanceStatus, error)
+	StopApplication(appName string) error
+	ScaleInstances(appName string, instances int) error
+	ScaleApplication(appName string, options ScaleOptions) error
+	DeleteApplication(appName string) error
+	CloneApplication(appName, cloneName string, options ScaleOptions) error
+	AppConfig(appName string) (AppConfig, error)
+	AppResources(appName string) (AppResources, error)
+
+	CheckQuota(appName string, needs QuotaNeeds) error
+	CheckRoutesAvailable(appName string, urls []string) error
+	CheckServicesBindable(serviceNames []string) error
+	ResolveTestRoute(appName, testRoute, autoHost string) (string, error)
+
+	AppRoutes(appName string) ([]string, error)
+	FindUrls(appName string) (Route, error)
+	MapRoutes(appName string, route Route) error
+	UnmapRoutes(appName string, route Route) error
+	MapRouteURLs(appName string, urls []string) error
+	UnmapRouteURLs(appName string, urls []string) error
+	CopyRoutes(fromApp, toApp string) error
+	RemoveRoutes(appName string) error
+	DeleteOrphanedRoutes(urls []string) ([]string, error)
+
+	MarkManaged(appName string, marker ManagedMarker) error
+	ManagedMarker(appName string) (ManagedMarker, bool, error)
+
+	CreateServiceKey(serviceName, keyName string) (string, error)
+	ServiceKeys(serviceName string) ([]string, error)
+	DeleteServiceKey(serviceName, keyName string) error
+
+	RevisionsEnabled(appName string) (bool, error)
+	Revisions(appName string) ([]Revision, error)
+	DeployRevision(appName, revisionGuid string) (string, error)
+	DeploymentState(deploymentGuid string) (string, error)
+	CancelDeployment(deploymentGuid string) error
+
+	RouteServiceBindings(appName string) ([]RouteServiceBinding, error)
+	RestoreRouteServiceBindings(bindings []RouteServiceBinding) error
+	BindRouteServiceToURL(url, serviceName string) error
+	UnbindRouteServiceFromURL(url, serviceName string) error
+
+	LogDrains(appName string) ([]string, error)
+	BindService(appName, serviceName string) error
+
+	CheckCopyTarget(appName, spaceName, copyName string) error
+	CopyApplicationToSpace(appName, spaceName, copyName string, instances int) (string, error)
+	BindServicesToCopy(appName, copyGuid string) error
+	CopyDroplet(appName, copyGuid string) error
+	StartAppByGuid(appGuid string) error
+	DeleteAppByGuid(appGuid string) error
+}
@@ -0,0 +1,266 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/capi"
+	"github.com/concourse/autopilot/repository"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// Revision is a version of an app the Cloud Controller recorded.
+type Revision = repository.Revision
+
+// The rollback strategies: renaming a kept copy of the app over it, or
+// deploying one of its revisions in place.
+const (
+	copyStrategy      = "copy"
+	revisionsStrategy = "revisions"
+)
+
+// how long a revision deployment may take, checked every interval
+const (
+	deploymentTimeout  = 10 * time.Minute
+	deploymentInterval = 5 * time.Second
+)
+
+// appClient finds the app's GUID in the current space.
+func (repo *ApplicationRepo) appClient(appName string) (*capi.Client, string, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		return nil, "", fmt.Errorf("App %s not found", appName)
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return api, app.Metadata.Guid, nil
+}
+
+// RevisionsEnabled reports whether the Cloud Controller records revisions of
+// the app.
+func (repo *ApplicationRepo) RevisionsEnabled(appName string) (bool, error) {
+	api, guid, err := repo.appClient(appName)
+	if err != nil {
+		return false, err
+	}
+
+	enabled, err := api.RevisionsEnabled(guid)
+	if capi.IsNotFound(err) {
+		// foundations without revisions do not know the feature
+		return false, nil
+	}
+	return enabled, err
+}
+
+// Revisions lists the app's revisions, oldest first, noting the one it runs.
+func (repo *ApplicationRepo) Revisions(appName string) ([]Revision, error) {
+	api, guid, err := repo.appClient(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := api.Revisions(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := api.DeployedRevisions(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	running := map[string]bool{}
+	for _, revision := range deployed {
+		running[revision.Guid] = true
+	}
+
+	result := []Revision{}
+	for _, revision := range revisions {
+		result = append(result, Revision{
+			Guid:        revision.Guid,
+			Version:     revision.Version,
+			Description: revision.Description,
+			Deployable:  revision.Deployable,
+			Deployed:    running[revision.Guid],
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// DeployRevision starts a rolling deployment of the app to the revision, and
+// returns the deployment's GUID.
+func (repo *ApplicationRepo) DeployRevision(appName, revisionGuid string) (string, error) {
+	api, guid, err := repo.appClient(appName)
+	if err != nil {
+		return "", err
+	}
+
+	deployment, err := api.CreateDeployment(guid, revisionGuid)
+	return deployment.Guid, err
+}
+
+// DeploymentState is DEPLOYING while the deployment is under way, and
+// DEPLOYED, CANCELED or SUPERSEDED once it is over.
+func (repo *ApplicationRepo) DeploymentState(deploymentGuid string) (string, error) {
+	api, err := repo.client()
+	if err != nil {
+		return "", err
+	}
+
+	deployment, err := api.GetDeployment(deploymentGuid)
+	if err != nil {
+		return "", err
+	}
+
+	// older Cloud Controllers only have the state
+	switch deployment.Status.Value {
+	case "FINALIZED":
+		return deployment.Status.Reason, nil
+	case "ACTIVE":
+		return "DEPLOYING", nil
+	}
+	return deployment.State, nil
+}
+
+// CancelDeployment stops the deployment, putting the app back on the
+// revision it ran before.
+func (repo *ApplicationRepo) CancelDeployment(deploymentGuid string) error {
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	return api.CancelDeployment(deploymentGuid)
+}
+
+// PreviousRevision picks the revision to roll back to: the given version, or
+// else the newest deployable one older than the revision the app runs.
+func PreviousRevision(revisions []Revision, version int) (Revision, error) {
+	current := 0
+	for _, revision := range revisions {
+		if revision.Deployed && revision.Version > current {
+			current = revision.Version
+		}
+	}
+
+	var previous *Revision
+	for i, revision := range revisions {
+		if version != 0 {
+			if revision.Version != version {
+				continue
+			}
+			if !revision.Deployable {
+				return Revision{}, fmt.Errorf("Revision %d can no longer be deployed, its droplet has been deleted.", version)
+			}
+			if revision.Deployed {
+				return Revision{}, fmt.Errorf("Revision %d is the one the app is running.", version)
+			}
+			return revision, nil
+		}
+
+		if revision.Deployable && !revision.Deployed && revision.Version < current &&
+			(previous == nil || revision.Version > previous.Version) {
+			previous = &revisions[i]
+		}
+	}
+
+	if version != 0 {
+		return Revision{}, fmt.Errorf("Revision %d not found.", version)
+	}
+	if previous == nil {
+		return Revision{}, fmt.Errorf("No earlier revision to roll back to, the app has none that can still be deployed.")
+	}
+	return *previous, nil
+}
+
+// RevisionRollbackActions roll the app back by deploying an earlier revision
+// in place, so no copy of the app is needed. The Cloud Controller replaces
+// the instances a few at a time; if the deployment fails it is cancelled,
+// which puts the app back on the revision it ran.
+func (planner *DeploymentPlanner) RevisionRollbackActions(appName string, options RollbackOptions) []rewind.Action {
+	appRepo := planner.Repo
+
+	var target Revision
+	var deploymentGuid string
+
+	cancel := func() error {
+		if deploymentGuid == "" {
+			return nil
+		}
+
+		planner.Logger.Printf("Cancelling the deployment of revision %d.\n", target.Version)
+		return appRepo.CancelDeployment(deploymentGuid)
+	}
+
+	return []rewind.Action{
+		// pick the revision
+		{
+			Name: "find previous revision",
+			Forward: func() error {
+				revisions, err := appRepo.Revisions(appName)
+				if err != nil {
+					return err
+				}
+
+				target, err = PreviousRevision(revisions, options.Revision)
+				return err
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Find the revision of %s to roll back to: %s.", appName,
+					describeChoice(options.Revision != 0, fmt.Sprintf("revision %d", options.Revision), "the newest one before the one it runs")),
+			},
+		},
+		// deploy it in place and wait for every instance to be replaced
+		{
+			Name: "deploy revision",
+			Forward: func() error {
+				planner.Logger.Printf("Deploying revision %d of %s.\n", target.Version, appName)
+				var err error
+				deploymentGuid, err = appRepo.DeployRevision(appName, target.Guid)
+				if err != nil {
+					return err
+				}
+
+				return planner.waitForDeployment(deploymentGuid)
+			},
+			ReversePrevious: cancel,
+			Description: rewind.Description{
+				Forward:         fmt.Sprintf("Deploy that revision to %s, replacing its instances a few at a time, and wait for it to finish.", appName),
+				ReversePrevious: "Cancel the deployment, which puts the app back on the revision it ran.",
+			},
+		},
+	}
+}
+
+// waitForDeployment polls the deployment until it is over.
+func (planner *DeploymentPlanner) waitForDeployment(deploymentGuid string) error {
+	deadline := planner.Clock.Now().Add(deploymentTimeout)
+	for {
+		state, err := planner.Repo.DeploymentState(deploymentGuid)
+		if err != nil {
+			return err
+		}
+
+		switch state {
+		case "DEPLOYED":
+			return nil
+		case "CANCELED", "CANCELING", "SUPERSEDED":
+			return fmt.Errorf("The deployment was %s before it finished.", strings.ToLower(state))
+		}
+
+		if !planner.Clock.Now().Before(deadline) {
+			return fmt.Errorf("The deployment did not finish within %s.", deploymentTimeout)
+		}
+		planner.Clock.Sleep(deploymentInterval)
+	}
+}
@@ -0,0 +1,102 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Revisions", func() {
+	revisions := []Revision{
+		{Guid: "r1", Version: 1, Deployable: false},
+		{Guid: "r2", Version: 2, Deployable: true},
+		{Guid: "r3", Version: 3, Deployable: true},
+		{Guid: "r4", Version: 4, Deployable: true, Deployed: true},
+		{Guid: "r5", Version: 5, Deployable: true},
+	}
+
+	Describe("PreviousRevision", func() {
+		It("picks the newest deployable revision before the deployed one", func() {
+			Expect(PreviousRevision(revisions, 0)).To(Equal(revisions[2]))
+		})
+
+		It("picks the revision asked for", func() {
+			Expect(PreviousRevision(revisions, 2)).To(Equal(revisions[1]))
+			Expect(PreviousRevision(revisions, 5)).To(Equal(revisions[4]))
+		})
+
+		It("refuses revisions that cannot be rolled back to", func() {
+			_, err := PreviousRevision(revisions, 1)
+			Expect(err).To(MatchError("Revision 1 can no longer be deployed, its droplet has been deleted."))
+
+			_, err = PreviousRevision(revisions, 4)
+			Expect(err).To(MatchError("Revision 4 is the one the app is running."))
+
+			_, err = PreviousRevision(revisions, 9)
+			Expect(err).To(MatchError("Revision 9 not found."))
+
+			_, err = PreviousRevision(revisions[:1], 0)
+			Expect(err).To(MatchError(ContainSubstring("No earlier revision to roll back to")))
+		})
+	})
+
+	Describe("RevisionRollbackActions", func() {
+		var (
+			repo    *recordingRepo
+			clock   *fakeClock
+			planner *DeploymentPlanner
+		)
+
+		BeforeEach(func() {
+			repo = newRecordingRepo()
+			repo.revisions["app"] = revisions
+			clock = &fakeClock{}
+			planner = &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: clock, Logger: discardLogger{}}
+		})
+
+		execute := func(actions []rewind.Action) error {
+			return rewind.Actions{Actions: actions}.Execute()
+		}
+
+		It("deploys the previous revision in place and waits for it", func() {
+			repo.deploymentStates = []string{"DEPLOYING", "DEPLOYING"}
+
+			Expect(execute(planner.RevisionRollbackActions("app", RollbackOptions{}))).To(Succeed())
+			Expect(repo.calls).To(Equal([]string{
+				"Revisions app",
+				"DeployRevision app r3",
+				"DeploymentState deployment-guid",
+				"DeploymentState deployment-guid",
+				"DeploymentState deployment-guid",
+			}))
+			Expect(clock.slept).To(HaveLen(2))
+		})
+
+		It("cancels a deployment that does not finish", func() {
+			for i := 0; i < 200; i++ {
+				repo.deploymentStates = append(repo.deploymentStates, "DEPLOYING")
+			}
+
+			err := execute(planner.RevisionRollbackActions("app", RollbackOptions{Revision: 2}))
+			Expect(err).To(MatchError("The deployment did not finish within 10m0s."))
+			Expect(repo.calls).To(ContainElement("DeployRevision app r2"))
+			Expect(repo.calls[len(repo.calls)-1]).To(Equal("CancelDeployment deployment-guid"))
+		})
+
+		It("fails when the deployment is cancelled by someone else", func() {
+			repo.deploymentStates = []string{"CANCELED"}
+
+			err := execute(planner.RevisionRollbackActions("app", RollbackOptions{}))
+			Expect(err).To(MatchError("The deployment was canceled before it finished."))
+		})
+
+		It("changes nothing when there is no revision to go back to", func() {
+			repo.revisions["app"] = revisions[:1]
+
+			Expect(execute(planner.RevisionRollbackActions("app", RollbackOptions{}))).ToNot(Succeed())
+			Expect(repo.calls).To(Equal([]string{"Revisions app"}))
+		})
+	})
+})
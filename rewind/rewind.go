@@ -1,30 +1,100 @@
 package rewind
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 type Actions struct {
 	Actions []Action
 
 	RewindFailureMessage string
+
+	// Observer, if set, is told about every step that is run.
+	Observer Observer
+
+	// Starting, if set, is told the action's name and the phase before each
+	// step is run.
+	Starting func(name, phase string)
+
+	// Inject, if set, is asked before each step whether it should fail. A
+	// step it returns an error for is not run, and fails with that error, so
+	// that rewinding can be rehearsed.
+	Inject func(name, phase string) error
+
+	// ResumeFrom, if set, names the action to start from, as when resuming
+	// a deploy that stopped there. The actions before it are taken as done:
+	// only those marked Lookup are run, but the Undo of each is run should
+	// a later action fail.
+	ResumeFrom string
+}
+
+// The phases an action's steps are run in.
+const (
+	PhaseForward         = "forward"
+	PhaseReversePrevious = "reverse-previous"
+	PhaseUndo            = "undo"
+)
+
+// Observer is called after each step with the action's name, the phase, when
+// the step started and how it ended.
+type Observer func(name, phase string, start time.Time, err error)
+
+// Observers combines observers into one that calls each in turn.
+func Observers(observers ...Observer) Observer {
+	return func(name, phase string, start time.Time, err error) {
+		for _, observer := range observers {
+			observer(name, phase, start, err)
+		}
+	}
 }
 
+// Execute runs the actions in order. When one fails, its ReversePrevious is
+// run, followed by the Undo of every action that had already completed, most
+// recent first, so that a late failure unwinds the whole sequence.
 func (actions Actions) Execute() error {
-	for _, action := range actions.Actions {
-		err := action.Forward()
-		if err != nil {
-			if action.ReversePrevious == nil {
+	return actions.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is Execute, but stops before the next action once ctx is
+// done, undoing the completed actions and returning ctx.Err(). The action in
+// progress is always allowed to finish, so nothing is left half done.
+func (actions Actions) ExecuteContext(ctx context.Context) error {
+	resumeAt, err := actions.resumeIndex()
+	if err != nil {
+		return err
+	}
+
+	for i, action := range actions.Actions {
+		if i < resumeAt && !action.Lookup {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			err := actions.undo(i)
+			if err != nil {
 				return err
 			}
+			return ctx.Err()
+		default:
+		}
 
-			reverseError := action.ReversePrevious()
-			if reverseError != nil {
-				if actions.RewindFailureMessage != "" {
-					return fmt.Errorf("%s: %s", actions.RewindFailureMessage, reverseError)
-				} else {
-					return reverseError
+		err := actions.run(action.Name, PhaseForward, action.Forward)
+		if err != nil {
+			if action.ReversePrevious != nil {
+				reverseError := actions.run(action.Name, PhaseReversePrevious, action.ReversePrevious)
+				if reverseError != nil {
+					return actions.rewindFailure(reverseError)
 				}
 			}
 
+			undoError := actions.undo(i)
+			if undoError != nil {
+				return undoError
+			}
+
 			return err
 		}
 	}
@@ -32,7 +102,111 @@ func (actions Actions) Execute() error {
 	return nil
 }
 
+// resumeIndex is the index of the ResumeFrom action, or 0.
+func (actions Actions) resumeIndex() (int, error) {
+	if actions.ResumeFrom == "" {
+		return 0, nil
+	}
+
+	for i, action := range actions.Actions {
+		if action.Name == actions.ResumeFrom {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("there is no action named %q to resume from", actions.ResumeFrom)
+}
+
+// undo runs the Undo of every action before the nth, most recent first.
+func (actions Actions) undo(n int) error {
+	for i := n - 1; i >= 0; i-- {
+		undo := actions.Actions[i].Undo
+		if undo == nil {
+			continue
+		}
+
+		err := actions.run(actions.Actions[i].Name, PhaseUndo, undo)
+		if err != nil {
+			return actions.rewindFailure(err)
+		}
+	}
+
+	return nil
+}
+
+func (actions Actions) run(name, phase string, step func() error) error {
+	if actions.Starting != nil {
+		actions.Starting(name, phase)
+	}
+
+	start := time.Now()
+	var err error
+	if actions.Inject != nil {
+		err = actions.Inject(name, phase)
+	}
+	if err == nil {
+		err = step()
+	}
+	if actions.Observer != nil {
+		actions.Observer(name, phase, start, err)
+	}
+
+	return err
+}
+
+// InjectedFailure is the error of a step failed by FailAt.
+type InjectedFailure struct {
+	Action string
+}
+
+func (failure InjectedFailure) Error() string {
+	return fmt.Sprintf("failure injected at %s", failure.Action)
+}
+
+// FailAt is an Inject that fails the forward step of the named action, which
+// rewinds the actions completed before it.
+func FailAt(action string) func(name, phase string) error {
+	return func(name, phase string) error {
+		if name == action && phase == PhaseForward {
+			return InjectedFailure{Action: action}
+		}
+		return nil
+	}
+}
+
+func (actions Actions) rewindFailure(err error) error {
+	if actions.RewindFailureMessage != "" {
+		return fmt.Errorf("%s: %s", actions.RewindFailureMessage, err)
+	}
+
+	return err
+}
+
 type Action struct {
+	// Name describes the action, e.g. in traces.
+	Name string
+
 	Forward         func() error
 	ReversePrevious func() error
+
+	// Undo reverses the action after it has completed successfully. It is
+	// run when a later action fails.
+	Undo func() error
+
+	// Lookup marks an action that changes nothing, but finds out what later
+	// actions need, so it is run even when resuming past it.
+	Lookup bool
+
+	// Description documents the action, so it can be reviewed before it is
+	// run.
+	Description Description
+}
+
+// Description says in words what an action's steps do, and when the action
+// does anything at all. Empty fields say nothing.
+type Description struct {
+	Forward         string
+	When            string
+	ReversePrevious string
+	Undo            string
 }
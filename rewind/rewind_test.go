@@ -1,7 +1,10 @@
 package rewind_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -164,4 +167,283 @@ var _ = Describe("Rewind", func() {
 		Expect(secondReverseRun).To(BeTrue())
 		Expect(thirdRun).To(BeFalse())
 	})
+
+	Describe("undoing completed actions", func() {
+		var (
+			calls  []string
+			record func(call string, err error) func() error
+		)
+
+		BeforeEach(func() {
+			calls = []string{}
+			record = func(call string, err error) func() error {
+				return func() error {
+					calls = append(calls, call)
+					return err
+				}
+			}
+		})
+
+		It("undoes every completed action in reverse order after a failure", func() {
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{
+						Forward: record("first", nil),
+						Undo:    record("undo first", nil),
+					},
+					{
+						Forward: record("second", nil),
+					},
+					{
+						Forward: record("third", nil),
+						Undo:    record("undo third", nil),
+					},
+					{
+						Forward:         record("fourth", errors.New("disaster")),
+						ReversePrevious: record("reverse fourth", nil),
+						Undo:            record("undo fourth", nil),
+					},
+					{
+						Forward: record("fifth", nil),
+						Undo:    record("undo fifth", nil),
+					},
+				},
+			}
+
+			err := actions.Execute()
+			Expect(err).To(MatchError("disaster"))
+
+			Expect(calls).To(Equal([]string{
+				"first",
+				"second",
+				"third",
+				"fourth",
+				"reverse fourth",
+				"undo third",
+				"undo first",
+			}))
+		})
+
+		It("does not undo anything when all actions succeed", func() {
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{
+						Forward: record("first", nil),
+						Undo:    record("undo first", nil),
+					},
+				},
+			}
+
+			Expect(actions.Execute()).To(Succeed())
+			Expect(calls).To(Equal([]string{"first"}))
+		})
+
+		It("gives up if an undo fails", func() {
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{
+						Forward: record("first", nil),
+						Undo:    record("undo first", nil),
+					},
+					{
+						Forward: record("second", nil),
+						Undo:    record("undo second", errors.New("another disaster")),
+					},
+					{
+						Forward: record("third", errors.New("disaster")),
+					},
+				},
+				RewindFailureMessage: "uh oh",
+			}
+
+			err := actions.Execute()
+			Expect(err).To(MatchError("uh oh: another disaster"))
+
+			Expect(calls).To(Equal([]string{"first", "second", "third", "undo second"}))
+		})
+	})
+
+	Describe("cancellation", func() {
+		It("stops before the next action and undoes the completed ones", func() {
+			calls := []string{}
+			ctx, cancel := context.WithCancel(context.Background())
+
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{
+						Forward: func() error {
+							calls = append(calls, "first")
+							return nil
+						},
+						Undo: func() error {
+							calls = append(calls, "undo first")
+							return nil
+						},
+					},
+					{
+						Forward: func() error {
+							calls = append(calls, "second")
+							cancel()
+							return nil
+						},
+						Undo: func() error {
+							calls = append(calls, "undo second")
+							return nil
+						},
+					},
+					{
+						Forward: func() error {
+							calls = append(calls, "third")
+							return nil
+						},
+					},
+				},
+			}
+
+			err := actions.ExecuteContext(ctx)
+			Expect(err).To(Equal(context.Canceled))
+
+			Expect(calls).To(Equal([]string{"first", "second", "undo second", "undo first"}))
+		})
+
+		It("does not start anything when already cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			firstRun := false
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{
+						Forward: func() error {
+							firstRun = true
+							return nil
+						},
+					},
+				},
+			}
+
+			Expect(actions.ExecuteContext(ctx)).To(Equal(context.Canceled))
+			Expect(firstRun).To(BeFalse())
+		})
+	})
+
+	It("tells the observer about every step", func() {
+		observed := []string{}
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Name:    "first",
+					Forward: func() error { return nil },
+					Undo:    func() error { return nil },
+				},
+				{
+					Name:            "second",
+					Forward:         func() error { return errors.New("failed") },
+					ReversePrevious: func() error { return nil },
+				},
+			},
+			Observer: func(name, phase string, start time.Time, err error) {
+				observed = append(observed, fmt.Sprintf("%s %s %v", name, phase, err))
+			},
+		}
+
+		Expect(actions.Execute()).To(MatchError("failed"))
+		Expect(observed).To(Equal([]string{
+			"first forward <nil>",
+			"second forward failed",
+			"second reverse-previous <nil>",
+			"first undo <nil>",
+		}))
+	})
+
+	It("tells Starting about every step before it is run", func() {
+		started := []string{}
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Name: "first",
+					Forward: func() error {
+						started = append(started, "ran first")
+						return nil
+					},
+				},
+				{
+					Name:    "second",
+					Forward: func() error { return errors.New("failed") },
+				},
+			},
+			Starting: func(name, phase string) {
+				started = append(started, name+" "+phase)
+			},
+		}
+
+		Expect(actions.Execute()).To(MatchError("failed"))
+		Expect(started).To(Equal([]string{
+			"first forward",
+			"ran first",
+			"second forward",
+		}))
+	})
+
+	It("fails the step FailAt names without running it, rewinding the ones before", func() {
+		ran := []string{}
+		step := func(name string) func() error {
+			return func() error {
+				ran = append(ran, name)
+				return nil
+			}
+		}
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{Name: "first", Forward: step("first"), Undo: step("undo first")},
+				{Name: "second", Forward: step("second"), ReversePrevious: step("reverse second")},
+				{Name: "third", Forward: step("third")},
+			},
+			Inject: rewind.FailAt("second"),
+		}
+
+		err := actions.Execute()
+		Expect(err).To(Equal(rewind.InjectedFailure{Action: "second"}))
+		Expect(err).To(MatchError("failure injected at second"))
+		Expect(ran).To(Equal([]string{"first", "reverse second", "undo first"}))
+	})
+
+	Describe("resuming", func() {
+		var ran []string
+
+		step := func(name string, err error) func() error {
+			return func() error {
+				ran = append(ran, name)
+				return err
+			}
+		}
+
+		BeforeEach(func() {
+			ran = []string{}
+		})
+
+		It("skips the actions before the one it resumes from, except lookups, and still undoes them", func() {
+			actions := rewind.Actions{
+				Actions: []rewind.Action{
+					{Name: "look up", Forward: step("look up", nil), Lookup: true},
+					{Name: "rename", Forward: step("rename", nil), Undo: step("undo rename", nil)},
+					{Name: "push", Forward: step("push", errors.New("disaster")), ReversePrevious: step("reverse push", nil)},
+				},
+				ResumeFrom: "push",
+			}
+
+			Expect(actions.Execute()).To(MatchError("disaster"))
+			Expect(ran).To(Equal([]string{"look up", "push", "reverse push", "undo rename"}))
+		})
+
+		It("runs nothing when the action to resume from does not exist", func() {
+			actions := rewind.Actions{
+				Actions:    []rewind.Action{{Name: "push", Forward: step("push", nil)}},
+				ResumeFrom: "pusj",
+			}
+
+			Expect(actions.Execute()).To(MatchError(`there is no action named "pusj" to resume from`))
+			Expect(ran).To(BeEmpty())
+		})
+	})
 })
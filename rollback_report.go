@@ -0,0 +1,98 @@
+package autopilot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RollbackRouteReport describes the routes of the live app and the copy being
+// rolled back to, and the route changes the rollback will make, so the
+// traffic plan can be checked before anything happens.
+func (planner *DeploymentPlanner) RollbackRouteReport(appName string, options RollbackOptions) ([]string, error) {
+	previous := planner.Naming.VenerableName(appName)
+	if options.From != "" {
+		previous = options.From
+	}
+
+	liveRoutes, err := planner.Repo.AppRoutes(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRoutes, err := planner.Repo.AppRoutes(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []string{
+		fmt.Sprintf("Routes on %s: %s", appName, routeList(liveRoutes)),
+		fmt.Sprintf("Routes on %s: %s", previous, routeList(previousRoutes)),
+		"Route changes:",
+	}
+
+	if len(previousRoutes) > 0 {
+		report = append(report, fmt.Sprintf("  none, %s keeps its routes", previous))
+
+		dropped := MissingRoutes(liveRoutes, previousRoutes)
+		if len(dropped) > 0 {
+			report = append(report, fmt.Sprintf("  %s stop serving when %s is deleted", strings.Join(dropped, ", "), appName))
+		}
+
+		return report, nil
+	}
+
+	// the rollback moves the live app's hosts, as FindUrls reports them
+	route, err := routesOf(planner.Repo, appName)
+	if err != nil {
+		return nil, err
+	}
+	if len(route.URLs()) == 0 {
+		report = append(report, "  none, neither app has routes")
+		return report, nil
+	}
+
+	moved := options.Domains.FilterRoute(route)
+	if len(moved.URLs()) == 0 {
+		domains := []string{}
+		for i := range route.Host {
+			if !containsString(domains, route.HostDomain(i)) {
+				domains = append(domains, route.HostDomain(i))
+			}
+		}
+		domains = append(domains, route.Wildcards...)
+		report = append(report, fmt.Sprintf("  none, routes on %s are left where they are", strings.Join(domains, ", ")))
+		return report, nil
+	}
+
+	for _, url := range moved.URLs() {
+		report = append(report, fmt.Sprintf("  map %s to %s", url, previous))
+	}
+	for _, url := range moved.URLs() {
+		report = append(report, fmt.Sprintf("  unmap %s from %s", url, planner.Naming.RollbackName(appName)))
+	}
+
+	return report, nil
+}
+
+func routeList(routes []string) string {
+	if len(routes) == 0 {
+		return "none"
+	}
+
+	return strings.Join(routes, ", ")
+}
+
+// confirm asks a yes or no question. Without a terminal to ask on, the
+// answer is yes, so pipelines are not left waiting.
+func confirm(question string) bool {
+	if !isTerminal() {
+		return true
+	}
+
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
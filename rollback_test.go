@@ -0,0 +1,248 @@
+package autopilot_test
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// simulatedApp is an app in a simulatedSpace.
+type simulatedApp struct {
+	started bool
+	hosts   map[string]bool
+}
+
+// simulatedSpace keeps the apps a rollback changes, so that what it leaves
+// behind can be checked, and fails the nth change it is asked to make.
+type simulatedSpace struct {
+	*recordingRepo
+	apps map[string]*simulatedApp
+
+	failAt int
+	// the changes asked for, in order
+	changes []string
+	// takesEffect makes the failing change anyway, as when the cf CLI times
+	// out after the Cloud Controller made it
+	takesEffect bool
+}
+
+func newSimulatedSpace() *simulatedSpace {
+	return &simulatedSpace{recordingRepo: newRecordingRepo(), apps: map[string]*simulatedApp{}}
+}
+
+func (space *simulatedSpace) addApp(name string, started bool, hosts ...string) {
+	app := &simulatedApp{started: started, hosts: map[string]bool{}}
+	for _, host := range hosts {
+		app.hosts[host] = true
+	}
+	space.apps[name] = app
+}
+
+func (space *simulatedSpace) change(call string, apply func() error) error {
+	space.calls = append(space.calls, call)
+	space.changes = append(space.changes, call)
+	if len(space.changes) != space.failAt {
+		return apply()
+	}
+
+	if space.takesEffect {
+		apply()
+	}
+	return fmt.Errorf("%s failed", call)
+}
+
+func (space *simulatedSpace) app(name string) (*simulatedApp, error) {
+	app, found := space.apps[name]
+	if !found {
+		return nil, fmt.Errorf("App %s not found", name)
+	}
+	return app, nil
+}
+
+func (space *simulatedSpace) DoesAppExist(appName string) (bool, error) {
+	_, found := space.apps[appName]
+	return found, nil
+}
+
+func (space *simulatedSpace) RenameApplication(oldName, newName string) error {
+	return space.change("RenameApplication "+oldName+" "+newName, func() error {
+		app, err := space.app(oldName)
+		if err != nil {
+			return err
+		}
+		if _, taken := space.apps[newName]; taken {
+			return fmt.Errorf("App %s already exists", newName)
+		}
+		delete(space.apps, oldName)
+		space.apps[newName] = app
+		return nil
+	})
+}
+
+func (space *simulatedSpace) setStarted(call, appName string, started bool) error {
+	return space.change(call+" "+appName, func() error {
+		app, err := space.app(appName)
+		if err != nil {
+			return err
+		}
+		app.started = started
+		return nil
+	})
+}
+
+func (space *simulatedSpace) StartApplication(appName string) error {
+	return space.setStarted("StartApplication", appName, true)
+}
+
+func (space *simulatedSpace) RestageApplication(appName string) error {
+	return space.setStarted("RestageApplication", appName, true)
+}
+
+func (space *simulatedSpace) StopApplication(appName string) error {
+	return space.setStarted("StopApplication", appName, false)
+}
+
+func (space *simulatedSpace) DeleteApplication(appName string) error {
+	return space.change("DeleteApplication "+appName, func() error {
+		_, err := space.app(appName)
+		if err != nil {
+			return err
+		}
+		delete(space.apps, appName)
+		return nil
+	})
+}
+
+func (space *simulatedSpace) FindUrls(appName string) (Route, error) {
+	app, err := space.app(appName)
+	if err != nil {
+		return Route{}, err
+	}
+
+	route := Route{Domain: "example.com"}
+	for host := range app.hosts {
+		route.Host = append(route.Host, host)
+	}
+	sort.Strings(route.Host)
+	return route, nil
+}
+
+func (space *simulatedSpace) setRoutes(call, appName string, route Route, mapped bool) error {
+	return space.change(fmt.Sprint(call, " ", appName, " ", route.Host), func() error {
+		app, err := space.app(appName)
+		if err != nil {
+			return err
+		}
+		for _, host := range route.Host {
+			if mapped {
+				app.hosts[host] = true
+			} else {
+				delete(app.hosts, host)
+			}
+		}
+		return nil
+	})
+}
+
+func (space *simulatedSpace) MapRoutes(appName string, route Route) error {
+	return space.setRoutes("MapRoutes", appName, route, true)
+}
+
+func (space *simulatedSpace) UnmapRoutes(appName string, route Route) error {
+	return space.setRoutes("UnmapRoutes", appName, route, false)
+}
+
+// names lists the apps in the space.
+func (space *simulatedSpace) names() []string {
+	names := []string{}
+	for name := range space.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ = Describe("Rolling back when a step fails", func() {
+	type scenario struct {
+		description string
+		options     RollbackOptions
+		// whether the kept copy still has the routes
+		copyHasRoutes bool
+	}
+
+	scenarios := []scenario{
+		{description: "a copy kept without its routes"},
+		{description: "a copy kept with its routes", copyHasRoutes: true},
+		{description: "a copy started first", options: RollbackOptions{StartFirst: true}},
+		{description: "a copy restaged first", options: RollbackOptions{Restage: true}},
+	}
+
+	setUp := func(s scenario) (*simulatedSpace, *DeploymentPlanner) {
+		space := newSimulatedSpace()
+		space.addApp("app", true, "app", "www")
+		if s.copyHasRoutes {
+			space.addApp("app-venerable", false, "app", "www")
+		} else {
+			space.addApp("app-venerable", false)
+		}
+
+		planner := &DeploymentPlanner{
+			Repo:   space,
+			Naming: SuffixNaming{},
+			Clock:  &fakeClock{},
+			Logger: discardLogger{},
+		}
+		return space, planner
+	}
+
+	// serving is what every rollback must leave behind, whether it worked
+	// or not: a running app with the app's name and all of its routes.
+	serving := func(space *simulatedSpace) {
+		app, found := space.apps["app"]
+		Expect(found).To(BeTrue(), "no app is named app: %v", space.names())
+		Expect(app.started).To(BeTrue(), "app is not running")
+		Expect(app.hosts).To(Equal(map[string]bool{"app": true, "www": true}))
+	}
+
+	for _, s := range scenarios {
+		s := s
+
+		Describe("to "+s.description, func() {
+			It("leaves only the rolled back version once it succeeds", func() {
+				space, planner := setUp(s)
+
+				Expect(rewind.Actions{Actions: planner.RollbackActions("app", s.options)}.Execute()).To(Succeed())
+				serving(space)
+				Expect(space.names()).To(Equal([]string{"app"}))
+			})
+
+			It("puts everything back after any one change fails, made or not", func() {
+				space, planner := setUp(s)
+				Expect(rewind.Actions{Actions: planner.RollbackActions("app", s.options)}.Execute()).To(Succeed())
+				changes := len(space.changes)
+
+				for failAt := 1; failAt <= changes; failAt++ {
+					for _, takesEffect := range []bool{false, true} {
+						space, planner := setUp(s)
+						space.failAt = failAt
+						space.takesEffect = takesEffect
+
+						err := rewind.Actions{Actions: planner.RollbackActions("app", s.options)}.Execute()
+						By(fmt.Sprintf("failing change %d (%s), made: %t, with %v", failAt, space.changes[failAt-1], takesEffect, err))
+
+						serving(space)
+						if err != nil {
+							Expect(space.names()).To(Equal([]string{"app", "app-venerable"}))
+							Expect(space.apps["app-venerable"].started).To(BeFalse())
+						}
+					}
+				}
+			})
+		})
+	}
+})
@@ -0,0 +1,184 @@
+package autopilot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// routeCheckBodyLimit is how much of a response is searched for the body
+// marker.
+const routeCheckBodyLimit = 1 << 20
+
+// RouteCheck confirms the production routes reach the new version once it
+// has them, by a header or a marker in the body only the new version sends.
+// Header values and body markers may be templates, e.g. {{.Revision}}.
+type RouteCheck struct {
+	// Header is the name of the header to check, e.g. X-App-Revision.
+	Header string
+	// Value is what the header must be.
+	Value string
+	// Body is text the response body must contain.
+	Body string
+}
+
+// ParseRouteCheck reads --route-check-header, as NAME=VALUE or just NAME
+// to expect the revision being pushed, and --route-check-body.
+func ParseRouteCheck(header, body string) (RouteCheck, error) {
+	check := RouteCheck{Body: body}
+	if header == "" {
+		return check, nil
+	}
+
+	name, value := header, "{{.Revision}}"
+	if i := strings.Index(header, "="); i >= 0 {
+		name, value = header[:i], header[i+1:]
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || value == "" {
+		return RouteCheck{}, fmt.Errorf("--route-check-header %q should be NAME=VALUE, or NAME to expect the revision", header)
+	}
+
+	check.Header, check.Value = name, value
+	return check, nil
+}
+
+// Enabled is true when a header or a body marker was given.
+func (check RouteCheck) Enabled() bool {
+	return check.Header != "" || check.Body != ""
+}
+
+// Expand fills in the templates in the header value and the body marker.
+func (check RouteCheck) Expand(data TemplateData) (RouteCheck, error) {
+	value, err := ExpandTemplate("--route-check-header", check.Value, data)
+	if err != nil {
+		return RouteCheck{}, err
+	}
+	body, err := ExpandTemplate("--route-check-body", check.Body, data)
+	if err != nil {
+		return RouteCheck{}, err
+	}
+
+	if check.Header != "" && value == "" {
+		return RouteCheck{}, fmt.Errorf("there is no value to expect in the %s header; give one as NAME=VALUE, or a --revision", check.Header)
+	}
+
+	return RouteCheck{Header: check.Header, Value: value, Body: body}, nil
+}
+
+// Check requests url until the response comes from the new version, as the
+// routers may take a moment to pick up the change, and gives up after
+// testRouteAttempts requests.
+func (check RouteCheck) Check(url string, clock Clock) error {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		lastErr = check.request(url)
+		if lastErr == nil {
+			return nil
+		}
+
+		if attempt >= testRouteAttempts {
+			return fmt.Errorf("%s is still served by the old version after %d requests: %s", url, attempt, lastErr)
+		}
+		clock.Sleep(testRouteInterval)
+	}
+}
+
+func (check RouteCheck) request(url string) error {
+	resp, err := probeClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if check.Header != "" {
+		got := resp.Header.Get(check.Header)
+		if got != check.Value {
+			return fmt.Errorf("the %s header is %q, expected %q", check.Header, got, check.Value)
+		}
+	}
+
+	if check.Body != "" {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, routeCheckBodyLimit))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), check.Body) {
+			return fmt.Errorf("the response does not contain %q", check.Body)
+		}
+	}
+
+	return nil
+}
+
+// checkableRoutes leaves out the routes a request cannot be sent to, wildcard
+// hosts and TCP routes.
+func checkableRoutes(routes []string) []string {
+	checkable := []string{}
+	for _, route := range routes {
+		if strings.HasPrefix(route, "*.") || strings.Contains(route, ":") {
+			continue
+		}
+		checkable = append(checkable, route)
+	}
+	return checkable
+}
+
+// routeCheckAction confirms every production route is served by the new
+// version, with the old version unmapped first so it cannot answer instead.
+func (planner *DeploymentPlanner) routeCheckAction(appName string, manifestApp *ManifestApplication, options AutopilotOptions, unmapVenerable, remapVenerable func() error) rewind.Action {
+	return rewind.Action{
+		Name: "check routes serve new version",
+		Forward: func() error {
+			if !options.RouteCheck.Enabled() {
+				return nil
+			}
+
+			if options.KeepRunning {
+				planner.Logger.Printf("Not checking which version the routes reach, as the old version keeps its routes.\n")
+				return nil
+			}
+
+			check, err := options.RouteCheck.Expand(options.TemplateData(appName, *manifestApp))
+			if err != nil {
+				return err
+			}
+
+			err = unmapVenerable()
+			if err != nil {
+				return err
+			}
+
+			routes, err := planner.Repo.AppRoutes(appName)
+			if err != nil {
+				return err
+			}
+			routes = checkableRoutes(options.Domains.Filter(routes))
+			if len(routes) == 0 {
+				planner.Logger.Printf("The new version has no routes to check.\n")
+				return nil
+			}
+
+			for _, route := range routes {
+				planner.Logger.Printf("Checking %s is served by the new version\n", route)
+				err = check.Check("https://"+route, planner.Clock)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ReversePrevious: remapVenerable,
+		Description: rewind.Description{
+			Forward: "Unmap the old version and check each production route is served by the new one" +
+				describeIf(options.RouteCheck.Header != "", fmt.Sprintf(", by its %s header", options.RouteCheck.Header)) +
+				describeIf(options.RouteCheck.Body != "", fmt.Sprintf(", by %q in the body", options.RouteCheck.Body)) + ".",
+			When:            onlyWith("--route-check-header or --route-check-body", options.RouteCheck.Enabled() && !options.KeepRunning),
+			ReversePrevious: fmt.Sprintf("Map the routes back to %s.", planner.Naming.VenerableName(appName)),
+		},
+	}
+}
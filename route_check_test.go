@@ -0,0 +1,75 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("RouteCheck", func() {
+	var (
+		server *ghttp.Server
+		clock  *fakeClock
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		clock = &fakeClock{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("ParseRouteCheck", func() {
+		It("expects the revision in a header given by name alone", func() {
+			Expect(ParseRouteCheck("X-App-Revision", "")).To(Equal(RouteCheck{Header: "X-App-Revision", Value: "{{.Revision}}"}))
+			Expect(ParseRouteCheck("X-App-Revision=v2", "")).To(Equal(RouteCheck{Header: "X-App-Revision", Value: "v2"}))
+			Expect(ParseRouteCheck("", "build 42")).To(Equal(RouteCheck{Body: "build 42"}))
+			Expect(RouteCheck{}.Enabled()).To(BeFalse())
+		})
+
+		It("rejects a header without a name or a value", func() {
+			_, err := ParseRouteCheck("=v2", "")
+			Expect(err).To(MatchError(`--route-check-header "=v2" should be NAME=VALUE, or NAME to expect the revision`))
+
+			_, err = ParseRouteCheck("X-App-Revision=", "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("fills in the revision, and needs one", func() {
+		check, err := RouteCheck{Header: "X-App-Revision", Value: "{{.Revision}}"}.Expand(TemplateData{Revision: "abc123"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Value).To(Equal("abc123"))
+
+		_, err = RouteCheck{Header: "X-App-Revision", Value: "{{.Revision}}"}.Expand(TemplateData{})
+		Expect(err).To(MatchError("there is no value to expect in the X-App-Revision header; give one as NAME=VALUE, or a --revision"))
+	})
+
+	It("asks again until the new version answers", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusOK, "version 1", http.Header{"X-App-Revision": []string{"v1"}}),
+			ghttp.RespondWith(http.StatusOK, "version 2", http.Header{"X-App-Revision": []string{"v2"}}),
+		)
+
+		check := RouteCheck{Header: "X-App-Revision", Value: "v2", Body: "version 2"}
+		Expect(check.Check(server.URL(), clock)).To(Succeed())
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+		Expect(clock.slept).To(HaveLen(1))
+	})
+
+	It("fails while the old version still answers", func() {
+		server.AllowUnhandledRequests = true
+		server.UnhandledRequestStatusCode = http.StatusOK
+
+		check := RouteCheck{Body: "version 2"}
+		err := check.Check(server.URL(), clock)
+		Expect(err).To(MatchError(server.URL() + ` is still served by the old version after 10 requests: the response does not contain "version 2"`))
+		Expect(clock.slept).To(HaveLen(9))
+	})
+})
@@ -0,0 +1,143 @@
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+type RouteServiceBinding = repository.RouteServiceBinding
+
+// RouteServiceBindings lists the route services bound to the app's routes.
+func (repo *ApplicationRepo) RouteServiceBindings(appName string) ([]RouteServiceBinding, error) {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := []RouteServiceBinding{}
+	for _, summary := range app.Routes {
+		route, err := api.GetRoute(summary.Guid)
+		if err != nil {
+			return nil, err
+		}
+
+		if route.Entity.ServiceInstanceGuid == "" {
+			continue
+		}
+
+		url := summary.Domain.Name
+		if summary.Host != "" {
+			url = summary.Host + "." + url
+		}
+		url += route.Entity.Path
+
+		bindings = append(bindings, RouteServiceBinding{
+			Route:               url,
+			RouteGuid:           summary.Guid,
+			ServiceInstanceGuid: route.Entity.ServiceInstanceGuid,
+		})
+	}
+
+	return bindings, nil
+}
+
+// RestoreRouteServiceBindings binds the route services again to any of the
+// routes that lost them.
+func (repo *ApplicationRepo) RestoreRouteServiceBindings(bindings []RouteServiceBinding) error {
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		route, err := api.GetRoute(binding.RouteGuid)
+		if err != nil {
+			return err
+		}
+
+		if route.Entity.ServiceInstanceGuid == binding.ServiceInstanceGuid {
+			continue
+		}
+
+		fmt.Printf("Binding route service back to route %s\n", binding.Route)
+		err = api.BindRouteService(binding.ServiceInstanceGuid, binding.RouteGuid)
+		if err != nil {
+			return fmt.Errorf("Could not bind route service to route %s: %s", binding.Route, err)
+		}
+	}
+
+	return nil
+}
+
+// BindRouteServiceToURL sends the traffic of a route given as
+// "host.domain/path" through the named route service in the current space.
+func (repo *ApplicationRepo) BindRouteServiceToURL(url, serviceName string) error {
+	routeGuid, serviceInstanceGuid, err := repo.routeServiceGuids(url, serviceName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Binding route service %s to route %s\n", serviceName, url)
+	err = repo.api.BindRouteService(serviceInstanceGuid, routeGuid)
+	if err != nil {
+		return fmt.Errorf("Could not bind route service %s to route %s: %s", serviceName, url, err)
+	}
+	return nil
+}
+
+// UnbindRouteServiceFromURL undoes BindRouteServiceToURL.
+func (repo *ApplicationRepo) UnbindRouteServiceFromURL(url, serviceName string) error {
+	routeGuid, serviceInstanceGuid, err := repo.routeServiceGuids(url, serviceName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unbinding route service %s from route %s\n", serviceName, url)
+	err = repo.api.UnbindRouteService(serviceInstanceGuid, routeGuid)
+	if err != nil {
+		return fmt.Errorf("Could not unbind route service %s from route %s: %s", serviceName, url, err)
+	}
+	return nil
+}
+
+// routeServiceGuids finds the route and the service instance to bind to it.
+func (repo *ApplicationRepo) routeServiceGuids(url, serviceName string) (string, string, error) {
+	api, err := repo.client()
+	if err != nil {
+		return "", "", err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return "", "", err
+	}
+
+	serviceInstanceGuid, found, err := api.FindServiceInstance(space.Guid, serviceName)
+	if err != nil {
+		return "", "", err
+	}
+	if !found {
+		return "", "", fmt.Errorf("Route service %s not found in space %s", serviceName, space.Name)
+	}
+
+	key, err := repo.parseRouteURL(url)
+	if err != nil {
+		return "", "", err
+	}
+
+	routeGuid, err := repo.findRouteGuid(key)
+	if err != nil {
+		return "", "", err
+	}
+	if routeGuid == "" {
+		return "", "", fmt.Errorf("Route %s not found", url)
+	}
+
+	return routeGuid, serviceInstanceGuid, nil
+}
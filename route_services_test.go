@@ -0,0 +1,137 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Route services", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("finds the route services bound to the app's routes", func() {
+		cliConn.GetAppReturns(plugin_models.GetAppModel{
+			Routes: []plugin_models.GetApp_RouteSummary{
+				{Guid: "bound-route", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+				{Guid: "plain-route", Host: "www", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+			},
+		}, nil)
+
+		api.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/routes/bound-route"),
+				ghttp.RespondWith(http.StatusOK, `{"entity":{"service_instance_guid":"limiter-guid"}}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/routes/plain-route"),
+				ghttp.RespondWith(http.StatusOK, `{"entity":{"service_instance_guid":null}}`),
+			),
+		)
+
+		bindings, err := repo.RouteServiceBindings("app-name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bindings).To(Equal([]RouteServiceBinding{
+			{Route: "app.example.com", RouteGuid: "bound-route", ServiceInstanceGuid: "limiter-guid"},
+		}))
+	})
+
+	It("binds route services back to the routes that lost them", func() {
+		api.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/routes/bound-route"),
+				ghttp.RespondWith(http.StatusOK, `{"entity":{"service_instance_guid":"limiter-guid"}}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/routes/lost-route"),
+				ghttp.RespondWith(http.StatusOK, `{"entity":{}}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/v2/service_instances/limiter-guid/routes/lost-route"),
+				ghttp.RespondWith(http.StatusCreated, `{}`),
+			),
+		)
+
+		err := repo.RestoreRouteServiceBindings([]RouteServiceBinding{
+			{Route: "app.example.com", RouteGuid: "bound-route", ServiceInstanceGuid: "limiter-guid"},
+			{Route: "www.example.com", RouteGuid: "lost-route", ServiceInstanceGuid: "limiter-guid"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(api.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("says which route could not be bound", func() {
+		api.AppendHandlers(
+			ghttp.RespondWith(http.StatusOK, `{"entity":{}}`),
+			ghttp.RespondWith(http.StatusBadRequest, `{"description":"nope"}`),
+		)
+
+		err := repo.RestoreRouteServiceBindings([]RouteServiceBinding{
+			{Route: "www.example.com", RouteGuid: "lost-route", ServiceInstanceGuid: "limiter-guid"},
+		})
+		Expect(err).To(MatchError("Could not bind route service to route www.example.com: nope (400)"))
+	})
+
+	Describe("on a test route", func() {
+		BeforeEach(func() {
+			cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+				SpaceFields: plugin_models.SpaceFields{Guid: "space-guid", Name: "dev"},
+			}, nil)
+
+			responses := map[string]string{
+				"v2/shared_domains?q=name:example.com":                  `{"resources":[{"metadata":{"guid":"domain-guid"}}]}`,
+				"v2/routes?q=host:app-verify&q=domain_guid:domain-guid": `{"resources":[{"metadata":{"guid":"test-route-guid"},"entity":{"host":"app-verify"}}]}`,
+			}
+			cliConn.CliCommandWithoutTerminalOutputStub = func(args ...string) ([]string, error) {
+				if response, ok := responses[args[1]]; ok {
+					return []string{response}, nil
+				}
+				return []string{`{"resources":[]}`}, nil
+			}
+		})
+
+		It("binds the named route service and unbinds it again", func() {
+			api.RouteToHandler("GET", "/v2/spaces/space-guid/service_instances", ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/spaces/space-guid/service_instances", "q=name:limiter"),
+				ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"limiter-guid"}}]}`),
+			))
+			api.RouteToHandler("PUT", "/v2/service_instances/limiter-guid/routes/test-route-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+			api.RouteToHandler("DELETE", "/v2/service_instances/limiter-guid/routes/test-route-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+
+			Expect(repo.BindRouteServiceToURL("app-verify.example.com", "limiter")).To(Succeed())
+			Expect(repo.UnbindRouteServiceFromURL("app-verify.example.com", "limiter")).To(Succeed())
+			Expect(api.ReceivedRequests()).To(HaveLen(4))
+		})
+
+		It("fails when there is no such route service", func() {
+			api.RouteToHandler("GET", "/v2/spaces/space-guid/service_instances", ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			err := repo.BindRouteServiceToURL("app-verify.example.com", "limiter")
+			Expect(err).To(MatchError("Route service limiter not found in space dev"))
+		})
+	})
+})
@@ -0,0 +1,510 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/autopilot/capi"
+	"github.com/concourse/autopilot/routes"
+)
+
+type resourceList struct {
+	Resources []struct {
+		Metadata struct {
+			Guid string `json:"guid"`
+		} `json:"metadata"`
+		Entity struct {
+			Name      string `json:"name"`
+			SpaceGuid string `json:"space_guid"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+// CheckRoutesAvailable makes sure none of the given routes are mapped to an
+// app other than appName (or its old versions), or reserved in another
+// space. Otherwise the push would fail after the live app has been renamed.
+func (repo *ApplicationRepo) CheckRoutesAvailable(appName string, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		key, err := repo.parseRouteURL(url)
+		if err != nil {
+			return err
+		}
+
+		route, found, err := repo.findRoute(key)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			reserved, err := repo.isRouteReserved(key)
+			if err != nil {
+				return err
+			}
+
+			if reserved {
+				return fmt.Errorf("Route %s is reserved in another space.", url)
+			}
+
+			continue
+		}
+
+		if route.Entity.SpaceGuid != space.Guid {
+			return fmt.Errorf("Route %s is reserved in another space.", url)
+		}
+
+		var apps resourceList
+		err = repo.curl(fmt.Sprintf("v2/routes/%s/apps", route.Metadata.Guid), &apps)
+		if err != nil {
+			return err
+		}
+
+		for _, app := range apps.Resources {
+			if app.Entity.Name != appName && !isCopyName(appName, app.Entity.Name) {
+				return fmt.Errorf("Route %s is already mapped to app %q.", url, app.Entity.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ErrNoRoutes is returned by FindUrls for an app that has no routes.
+var ErrNoRoutes = errors.New("No routes for this app.")
+
+// RouteLookupError is returned by FindUrls when the app's routes could not be
+// determined, which does not mean it has none.
+type RouteLookupError struct {
+	App string
+	Err error
+}
+
+func (err *RouteLookupError) Error() string {
+	return fmt.Sprintf("Could not determine the routes of %s: %s", err.App, err.Err)
+}
+
+// routesOf is FindUrls, with an app that has no routes given as an empty
+// Route rather than an error. Failing to find the routes is still an error,
+// so that it is not mistaken for an app without any.
+func routesOf(repo ApplicationRepository, appName string) (Route, error) {
+	route, err := repo.FindUrls(appName)
+	if err == ErrNoRoutes {
+		return Route{Domain: route.Domain}, nil
+	}
+
+	return route, err
+}
+
+type appRoute struct {
+	guid   string
+	host   string
+	domain string
+	path   string
+	port   int
+}
+
+// url is the route as "host.domain/path", or "domain:port" for a TCP route.
+func (route appRoute) url() string {
+	url := route.domain
+	if route.host != "" {
+		url = route.host + "." + url
+	}
+	if route.port != 0 {
+		url += ":" + strconv.Itoa(route.port)
+	}
+
+	return url + route.path
+}
+
+// listAppRoutes lists the guid, host, domain, path and port of each of the
+// app's routes.
+// GetApp leaves out the route summaries of some large apps, so when it gives
+// none the API is asked for them directly. The summaries have no paths or
+// ports, so each of their routes is looked up for its own.
+func (repo *ApplicationRepo) listAppRoutes(appName string) ([]appRoute, error) {
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []appRoute{}
+	for _, summary := range app.Routes {
+		route, err := api.GetRoute(summary.Guid)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, appRoute{guid: summary.Guid, host: summary.Host, domain: summary.Domain.Name, path: route.Entity.Path, port: route.Entity.Port})
+	}
+	if len(routes) > 0 {
+		return routes, nil
+	}
+
+	listed, err := api.AppRoutes(app.Guid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, route := range listed {
+		routes = append(routes, appRoute{guid: route.Guid, host: route.Host, domain: route.Domain(), path: route.Path, port: route.Port})
+	}
+
+	return routes, nil
+}
+
+// wildcardHost is the host of a wildcard route, which answers for every host
+// on its domain that has no route of its own.
+const wildcardHost = routes.WildcardHost
+
+// IsWildcardRoute says whether a "host.domain/path" route is a wildcard
+// route, *.domain.
+func IsWildcardRoute(url string) bool {
+	return routes.IsWildcard(url)
+}
+
+// routeHosts lists what moving the route maps or unmaps: its hosts, and its
+// wildcard routes as *.domain, since their domains differ.
+func routeHosts(r Route) []string {
+	return routes.Hosts(r)
+}
+
+// routeHostDomain is the host, domain GUID and path of one of routeHosts.
+// Hosts are on the route's domain unless given as host.domain; a wildcard
+// route has the host * on its own.
+func (repo *ApplicationRepo) routeHostDomain(job string, target routeTarget) (string, string, string, error) {
+	host, path := routes.SplitPath(job)
+	if !strings.Contains(host, ".") {
+		return host, target.domainGuid, path, nil
+	}
+
+	parts := strings.SplitN(host, ".", 2)
+	host, domain := parts[0], parts[1]
+	domainGuid, found, err := repo.api.FindDomain(domain)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if !found {
+		return "", "", "", fmt.Errorf("Domain %s not found", domain)
+	}
+
+	return host, domainGuid, path, nil
+}
+
+// hostURL is one of routeHosts as "host.domain".
+func hostURL(host, domain string) string {
+	return routes.HostURL(host, domain)
+}
+
+// routeKey is what tells a route from the others on the foundation.
+type routeKey struct {
+	host       string
+	domainGuid string
+	path       string
+	// port is that of a TCP route, which has no host or path
+	port int
+}
+
+// parseRouteURL splits a "host.domain/path" route, or a "domain:port" TCP
+// route, into its parts, finding the domain the same way cf push does: the
+// whole name is tried as a domain first, then everything after the first
+// dot.
+func (repo *ApplicationRepo) parseRouteURL(url string) (routeKey, error) {
+	hostAndDomain, path := routes.SplitPath(url)
+
+	port := 0
+	if i := strings.LastIndex(hostAndDomain, ":"); i != -1 {
+		var err error
+		port, err = strconv.Atoi(hostAndDomain[i+1:])
+		if err != nil || port <= 0 {
+			return routeKey{}, fmt.Errorf("Route %s has an invalid port.", url)
+		}
+		hostAndDomain = hostAndDomain[:i]
+	}
+
+	// a wildcard route's domain is everything after the *, and "*" is no
+	// part of any domain name
+	if IsWildcardRoute(hostAndDomain) {
+		domain := strings.TrimPrefix(hostAndDomain, wildcardHost+".")
+		domainGuid, err := repo.findDomainGuid(domain)
+		if err != nil {
+			return routeKey{}, err
+		}
+		if domainGuid == "" {
+			return routeKey{}, fmt.Errorf("Could not find a domain for route %s.", url)
+		}
+
+		return routeKey{host: wildcardHost, domainGuid: domainGuid, path: path}, nil
+	}
+
+	domainGuid, err := repo.findDomainGuid(hostAndDomain)
+	if err != nil {
+		return routeKey{}, err
+	}
+
+	if domainGuid != "" {
+		return routeKey{domainGuid: domainGuid, path: path, port: port}, nil
+	}
+
+	// a TCP route is on its domain alone
+	parts := strings.SplitN(hostAndDomain, ".", 2)
+	if len(parts) == 2 && port == 0 {
+		domainGuid, err = repo.findDomainGuid(parts[1])
+		if err != nil {
+			return routeKey{}, err
+		}
+
+		if domainGuid != "" {
+			return routeKey{host: parts[0], domainGuid: domainGuid, path: path}, nil
+		}
+	}
+
+	return routeKey{}, fmt.Errorf("Could not find a domain for route %s.", url)
+}
+
+func (repo *ApplicationRepo) findDomainGuid(name string) (string, error) {
+	for _, endpoint := range []string{"v2/shared_domains", "v2/private_domains"} {
+		var domains resourceList
+		err := repo.curl(fmt.Sprintf("%s?q=name:%s", endpoint, name), &domains)
+		if err != nil {
+			return "", err
+		}
+
+		if len(domains.Resources) > 0 {
+			return domains.Resources[0].Metadata.Guid, nil
+		}
+	}
+
+	return "", nil
+}
+
+// The reserved route endpoint answers with an empty 204 when the route
+// exists anywhere on the foundation, and an error document otherwise.
+func (repo *ApplicationRepo) isRouteReserved(key routeKey) (bool, error) {
+	endpoint := fmt.Sprintf("v2/routes/reserved/domain/%s?host=%s", key.domainGuid, key.host)
+	if key.path != "" {
+		endpoint += "&path=" + key.path
+	}
+	if key.port != 0 {
+		endpoint = fmt.Sprintf("v2/routes/reserved/domain/%s?port=%d", key.domainGuid, key.port)
+	}
+
+	output, err := repo.conn.CliCommandWithoutTerminalOutput("curl", endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(strings.Join(output, "")) == "", nil
+}
+
+// MapRouteURLs maps routes given as "host.domain/path" to the app, creating
+// the ones that don't exist yet.
+func (repo *ApplicationRepo) MapRouteURLs(appName string, urls []string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	return forEachHost(urls, func(url string) error {
+		key, err := repo.parseRouteURL(url)
+		if err != nil {
+			return err
+		}
+
+		routeGuid, err := repo.findRouteGuid(key)
+		if err != nil {
+			return err
+		}
+
+		if routeGuid == "" {
+			var route capi.Route
+			if key.port != 0 {
+				route, err = repo.api.CreateTCPRoute(key.domainGuid, app.Entity.SpaceGuid, key.port)
+			} else {
+				route, err = repo.api.CreateRouteWithPath(key.host, key.domainGuid, app.Entity.SpaceGuid, key.path)
+			}
+			if err != nil {
+				return err
+			}
+			routeGuid = route.Metadata.Guid
+		}
+
+		return repo.api.MapRoute(routeGuid, app.Metadata.Guid)
+	})
+}
+
+// UnmapRouteURLs unmaps routes given as "host.domain/path" from the app.
+func (repo *ApplicationRepo) UnmapRouteURLs(appName string, urls []string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	return forEachHost(urls, func(url string) error {
+		key, err := repo.parseRouteURL(url)
+		if err != nil {
+			return err
+		}
+
+		routeGuid, err := repo.findRouteGuid(key)
+		if err != nil {
+			return err
+		}
+
+		if routeGuid == "" {
+			return fmt.Errorf("Route %s does not exist", url)
+		}
+
+		return repo.api.UnmapRoute(routeGuid, app.Metadata.Guid)
+	})
+}
+
+// DeleteOrphanedRoutes deletes those of the routes, given as
+// "host.domain/path", that are no longer mapped to any app, and lists them.
+// Routes still in use, or already gone, are left alone.
+func (repo *ApplicationRepo) DeleteOrphanedRoutes(urls []string) ([]string, error) {
+	api, err := repo.client()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, url := range urls {
+		key, err := repo.parseRouteURL(url)
+		if err != nil {
+			return deleted, err
+		}
+
+		routeGuid, err := repo.findRouteGuid(key)
+		if err != nil {
+			return deleted, err
+		}
+
+		if routeGuid == "" {
+			continue
+		}
+
+		var apps resourceList
+		err = repo.curl(fmt.Sprintf("v2/routes/%s/apps", routeGuid), &apps)
+		if err != nil {
+			return deleted, err
+		}
+
+		if len(apps.Resources) > 0 {
+			continue
+		}
+
+		err = api.DeleteRoute(routeGuid)
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, url)
+	}
+
+	return deleted, nil
+}
+
+// findRouteGuid returns "" if there is no such route.
+func (repo *ApplicationRepo) findRouteGuid(key routeKey) (string, error) {
+	route, found, err := repo.findRoute(key)
+	if err != nil || !found {
+		return "", err
+	}
+
+	return route.Metadata.Guid, nil
+}
+
+type routeResource struct {
+	Metadata struct {
+		Guid string `json:"guid"`
+	} `json:"metadata"`
+	Entity struct {
+		Host      string `json:"host"`
+		Path      string `json:"path"`
+		Port      int    `json:"port"`
+		SpaceGuid string `json:"space_guid"`
+	} `json:"entity"`
+}
+
+// findRoute looks up the route with the key's very host, path and port. The
+// API's filters leave out what is not given, so a route without a path would
+// otherwise match the routes with one on the same host.
+func (repo *ApplicationRepo) findRoute(key routeKey) (routeResource, bool, error) {
+	query := fmt.Sprintf("q=host:%s&q=domain_guid:%s", key.host, key.domainGuid)
+	if key.path != "" {
+		query += "&q=path:" + key.path
+	}
+	if key.port != 0 {
+		query = fmt.Sprintf("q=domain_guid:%s&q=port:%d", key.domainGuid, key.port)
+	}
+
+	var routes struct {
+		Resources []routeResource `json:"resources"`
+	}
+	err := repo.curl("v2/routes?"+query, &routes)
+	if err != nil {
+		return routeResource{}, false, err
+	}
+
+	for _, route := range routes.Resources {
+		if route.Entity.Host == key.host && route.Entity.Path == key.path && route.Entity.Port == key.port {
+			return route, true, nil
+		}
+	}
+
+	return routeResource{}, false, nil
+}
+
+// RouteError is the failure of a route operation for a single hostname, and
+// RouteErrors every failure of one.
+type (
+	RouteError  = routes.HostError
+	RouteErrors = routes.Errors
+)
+
+// forEachHost runs operation for every host on a bounded pool of workers,
+// returning the failures together as RouteErrors.
+func forEachHost(hosts []string, operation func(host string) error) error {
+	return routes.ForEachHost(hosts, operation)
+}
+
+// tolerateRouteErrors downgrades failed hostnames to a warning when the user
+// has asked to carry on regardless. Any other error is returned as is.
+func tolerateRouteErrors(err error, continueOnRouteError bool) error {
+	routeErrs, ok := err.(*RouteErrors)
+	if !ok || !continueOnRouteError {
+		return err
+	}
+
+	warnf("%s. Continuing because of --continue-on-route-error; check routes for %s.\n",
+		routeErrs, strings.Join(routeErrs.Hosts(), ", "))
+	return nil
+}
+
+// MissingRoutes lists the routes in oldRoutes that are not in newRoutes.
+func MissingRoutes(oldRoutes, newRoutes []string) []string {
+	return routes.Missing(oldRoutes, newRoutes)
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"strings"
+)
+
+// DomainFilter limits which routes a cutover moves, by domain. Routes on
+// other domains are left where they are, so internal routes can stay pinned
+// to one version while public traffic is swapped.
+type DomainFilter struct {
+	Only    []string
+	Exclude []string
+}
+
+// Active is true when some domains are filtered out.
+func (filter DomainFilter) Active() bool {
+	return len(filter.Only) > 0 || len(filter.Exclude) > 0
+}
+
+// AllowsDomain says whether routes on the domain may be moved.
+func (filter DomainFilter) AllowsDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+
+	if len(filter.Only) > 0 && !contains(filter.Only, domain) {
+		return false
+	}
+
+	return !contains(filter.Exclude, domain)
+}
+
+// Allows says whether a "host.domain/path" route may be moved.
+func (filter DomainFilter) Allows(url string) bool {
+	matches := func(domains []string) bool {
+		for _, domain := range domains {
+			if OnDomain(url, domain) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(filter.Only) > 0 && !matches(filter.Only) {
+		return false
+	}
+
+	return !matches(filter.Exclude)
+}
+
+// OnDomain says whether a "host.domain/path" route, or a "domain:port" TCP
+// route, is on the domain: the domain itself or a host on it.
+func OnDomain(url, domain string) bool {
+	hostAndDomain := strings.ToLower(strings.SplitN(url, "/", 2)[0])
+	if i := strings.LastIndex(hostAndDomain, ":"); i != -1 {
+		hostAndDomain = hostAndDomain[:i]
+	}
+	return hostAndDomain == domain || strings.HasSuffix(hostAndDomain, "."+domain)
+}
+
+// Filter keeps the routes that may be moved.
+func (filter DomainFilter) Filter(urls []string) []string {
+	allowed := []string{}
+	for _, url := range urls {
+		if filter.Allows(url) {
+			allowed = append(allowed, url)
+		}
+	}
+
+	return allowed
+}
+
+// FilterRoute keeps the hosts and the wildcard routes of the route that are
+// on domains that may be moved.
+func (filter DomainFilter) FilterRoute(route Route) Route {
+	filtered := Route{Domain: route.Domain}
+	for i, host := range route.Host {
+		if !filter.AllowsDomain(route.HostDomain(i)) {
+			continue
+		}
+		filtered.Host = append(filtered.Host, host)
+		if len(route.Domains) > 0 {
+			filtered.Domains = append(filtered.Domains, route.HostDomain(i))
+		}
+		if len(route.Paths) > 0 {
+			filtered.Paths = append(filtered.Paths, route.HostPath(i))
+		}
+	}
+	for _, domain := range route.Wildcards {
+		if filter.AllowsDomain(domain) {
+			filtered.Wildcards = append(filtered.Wildcards, domain)
+		}
+	}
+
+	return filtered
+}
+
+func contains(domains []string, domain string) bool {
+	for _, d := range domains {
+		if d == domain {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,54 @@
+package routes_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/concourse/autopilot/routes"
+)
+
+var _ = Describe("DomainFilter", func() {
+	It("allows every route when no domains are given", func() {
+		filter := DomainFilter{}
+		Expect(filter.Active()).To(BeFalse())
+		Expect(filter.Allows("app.example.com")).To(BeTrue())
+	})
+
+	It("only allows routes on the listed domains", func() {
+		filter := DomainFilter{Only: []string{"example.com"}}
+		Expect(filter.Filter([]string{"app.example.com/path", "example.com", "app.example.org", "app.notexample.com"})).
+			To(Equal([]string{"app.example.com/path", "example.com"}))
+		Expect(filter.AllowsDomain("Example.com")).To(BeTrue())
+		Expect(filter.AllowsDomain("example.org")).To(BeFalse())
+	})
+
+	It("finds the domain of a TCP route without its port", func() {
+		filter := DomainFilter{Only: []string{"tcp.example.com"}}
+		Expect(filter.Filter([]string{"tcp.example.com:1024", "tcp.example.org:1024"})).To(Equal([]string{"tcp.example.com:1024"}))
+	})
+
+	It("leaves out the excluded domains", func() {
+		filter := DomainFilter{Exclude: []string{"internal.example.com"}}
+		Expect(filter.Filter([]string{"app.example.com", "app.internal.example.com"})).To(Equal([]string{"app.example.com"}))
+		Expect(filter.AllowsDomain("internal.example.com")).To(BeFalse())
+	})
+
+	It("filters the hosts and the wildcard routes of a route by their domains", func() {
+		route := Route{Domain: "example.com", Host: []string{"app"}, Wildcards: []string{"example.org", "internal.example.com"}}
+
+		filter := DomainFilter{Exclude: []string{"internal.example.com"}}
+		Expect(filter.FilterRoute(route).URLs()).To(Equal([]string{"app.example.com", "*.example.org"}))
+
+		filter = DomainFilter{Only: []string{"internal.example.com"}}
+		Expect(filter.FilterRoute(route).URLs()).To(Equal([]string{"*.internal.example.com"}))
+	})
+
+	It("filters each host of a route by its own domain", func() {
+		route := Route{Domain: "example.com", Host: []string{"app", "app", "api"}, Domains: []string{"example.com", "example.org", "example.org"}}
+		filter := DomainFilter{Only: []string{"example.org"}}
+
+		filtered := filter.FilterRoute(route)
+		Expect(filtered.URLs()).To(Equal([]string{"app.example.org", "api.example.org"}))
+		Expect(Hosts(filtered)).To(Equal([]string{"app.example.org", "api.example.org"}))
+	})
+})
@@ -0,0 +1,28 @@
+package routes
+
+// InDomainOrder groups the routes by the domains they are on, in the order
+// the domains are given, so a cutover can move them a group at a time. A
+// route on several of the domains, as on a domain and a subdomain of it,
+// goes with the longest. Routes on none of them come last, as a group of
+// their own. Empty groups are left out.
+func InDomainOrder(urls []string, order []string) [][]string {
+	groups := make([][]string, len(order)+1)
+	for _, url := range urls {
+		group := len(order)
+		for i, domain := range order {
+			if OnDomain(url, domain) && (group == len(order) || len(domain) > len(order[group])) {
+				group = i
+			}
+		}
+		groups[group] = append(groups[group], url)
+	}
+
+	ordered := [][]string{}
+	for _, group := range groups {
+		if len(group) > 0 {
+			ordered = append(ordered, group)
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,34 @@
+package routes_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/concourse/autopilot/routes"
+)
+
+var _ = Describe("InDomainOrder", func() {
+	It("groups the routes by domain, in the order given, with the rest last", func() {
+		urls := []string{"app.example.com", "app.internal.example.com/api", "app.example.org", "internal.example.com"}
+
+		Expect(InDomainOrder(urls, []string{"internal.example.com", "example.com"})).To(Equal([][]string{
+			{"app.internal.example.com/api", "internal.example.com"},
+			{"app.example.com"},
+			{"app.example.org"},
+		}))
+	})
+
+	It("puts a route on a domain and a subdomain of it with the subdomain", func() {
+		urls := []string{"app.internal.example.com", "app.example.com"}
+
+		Expect(InDomainOrder(urls, []string{"example.com", "internal.example.com"})).To(Equal([][]string{
+			{"app.example.com"},
+			{"app.internal.example.com"},
+		}))
+	})
+
+	It("keeps every route together without an order", func() {
+		Expect(InDomainOrder([]string{"a.example.com", "b.example.org"}, nil)).To(Equal([][]string{{"a.example.com", "b.example.org"}}))
+		Expect(InDomainOrder(nil, []string{"example.com"})).To(BeEmpty())
+	})
+})
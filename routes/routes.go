@@ -0,0 +1,165 @@
+// Package routes holds the parts of moving routes between versions of an app
+// that need no Cloud Controller: which routes to move, what was lost, and
+// running route operations for many hosts at once. Tools that reconcile
+// routes their own way can use it without the rest of autopilot.
+package routes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/concourse/autopilot/repository"
+)
+
+// Route is a set of hosts, and the app's wildcard routes.
+type Route = repository.Route
+
+// WildcardHost is the host of a wildcard route, which answers for every host
+// on its domain that has no route of its own.
+const WildcardHost = "*"
+
+// IsWildcard says whether a "host.domain/path" route is a wildcard route,
+// *.domain.
+func IsWildcard(url string) bool {
+	return strings.HasPrefix(url, WildcardHost+".")
+}
+
+// WildcardURL is the wildcard route on the domain, *.domain.
+func WildcardURL(domain string) string {
+	return WildcardHost + "." + domain
+}
+
+// Hosts lists what moving the route maps or unmaps: its hosts, and its
+// wildcard routes as *.domain. Hosts on a domain other than the route's are
+// given as host.domain, as their domains differ too, and the routes with a
+// path as host/path.
+func Hosts(route Route) []string {
+	hosts := []string{}
+	for i, host := range route.Host {
+		if domain := route.HostDomain(i); domain != route.Domain {
+			host += "." + domain
+		}
+		hosts = append(hosts, host+route.HostPath(i))
+	}
+	for _, domain := range route.Wildcards {
+		hosts = append(hosts, WildcardURL(domain))
+	}
+	return hosts
+}
+
+// HostURL is one of Hosts as "host.domain/path", given the route's domain.
+func HostURL(host, domain string) string {
+	hostname, path := SplitPath(host)
+	if IsWildcard(hostname) || strings.Contains(hostname, ".") {
+		return host
+	}
+	return hostname + "." + domain + path
+}
+
+// SplitPath splits the path, if any, off one of Hosts or a
+// "host.domain/path" route, leaving it as "/path".
+func SplitPath(url string) (string, string) {
+	if i := strings.Index(url, "/"); i != -1 {
+		return url[:i], url[i:]
+	}
+	return url, ""
+}
+
+// Missing lists the routes in oldRoutes that are not in newRoutes.
+func Missing(oldRoutes, newRoutes []string) []string {
+	present := map[string]bool{}
+	for _, route := range newRoutes {
+		present[route] = true
+	}
+
+	missing := []string{}
+	for _, route := range oldRoutes {
+		if !present[route] {
+			missing = append(missing, route)
+		}
+	}
+
+	return missing
+}
+
+// HostError is the failure of a route operation for a single hostname.
+type HostError struct {
+	Host string
+	Err  error
+}
+
+// Errors collects every failed hostname of a route operation.
+type Errors struct {
+	Total    int
+	Failures []HostError
+}
+
+func (errs *Errors) Error() string {
+	failures := []string{}
+	for _, failure := range errs.Failures {
+		failures = append(failures, fmt.Sprintf("%s: %s", failure.Host, failure.Err))
+	}
+
+	return fmt.Sprintf("%d of %d routes failed (%s)", len(errs.Failures), errs.Total, strings.Join(failures, "; "))
+}
+
+// Hosts lists the hostnames that failed.
+func (errs *Errors) Hosts() []string {
+	hosts := []string{}
+	for _, failure := range errs.Failures {
+		hosts = append(hosts, failure.Host)
+	}
+
+	return hosts
+}
+
+// MaxWorkers bounds how many route requests ForEachHost sends at once.
+const MaxWorkers = 4
+
+// ForEachHost runs operation for every host on a bounded pool of workers, so
+// apps with many routes spend less time half way through a cutover. Every
+// host is attempted, and the failures are returned together as Errors.
+func ForEachHost(hosts []string, operation func(host string) error) error {
+	return ForEachRoute(hosts, func(i int) error {
+		return operation(hosts[i])
+	})
+}
+
+// ForEachRoute is ForEachHost, but gives operation the index of the route
+// rather than its url, which names it in the errors. The urls may repeat, as
+// for routes that differ only in their paths.
+func ForEachRoute(urls []string, operation func(i int) error) error {
+	errs := make([]error, len(urls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < MaxWorkers && i < len(urls); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				errs[job] = operation(job)
+			}
+		}()
+	}
+
+	for job := range urls {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	routeErrs := &Errors{Total: len(urls)}
+	for i, err := range errs {
+		if err != nil {
+			routeErrs.Failures = append(routeErrs.Failures, HostError{Host: urls[i], Err: err})
+		}
+	}
+
+	if len(routeErrs.Failures) > 0 {
+		return routeErrs
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package routes_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/concourse/autopilot/routes"
+)
+
+var _ = Describe("Routes", func() {
+	It("lists the hosts of a route, with its wildcard routes", func() {
+		route := Route{Domain: "example.com", Host: []string{"app", "www"}, Wildcards: []string{"example.org"}}
+
+		hosts := Hosts(route)
+		Expect(hosts).To(Equal([]string{"app", "www", "*.example.org"}))
+		Expect(IsWildcard(hosts[2])).To(BeTrue())
+		Expect(IsWildcard("app.example.com")).To(BeFalse())
+		Expect(HostURL(hosts[0], route.Domain)).To(Equal("app.example.com"))
+		Expect(HostURL(hosts[2], route.Domain)).To(Equal("*.example.org"))
+	})
+
+	It("keeps the path of each host's route", func() {
+		route := Route{Domain: "example.com", Host: []string{"www", "www", "api"}, Domains: []string{"", "", "example.org"}, Paths: []string{"", "/api", "/v1"}}
+
+		hosts := Hosts(route)
+		Expect(hosts).To(Equal([]string{"www", "www/api", "api.example.org/v1"}))
+		Expect(HostURL(hosts[1], route.Domain)).To(Equal("www.example.com/api"))
+		Expect(HostURL(hosts[2], route.Domain)).To(Equal("api.example.org/v1"))
+		Expect(route.URLs()).To(Equal([]string{"www.example.com", "www.example.com/api", "api.example.org/v1"}))
+	})
+
+	It("lists the old routes the new ones leave out", func() {
+		Expect(Missing([]string{"a.example.com", "b.example.com"}, []string{"b.example.com", "c.example.com"})).To(Equal([]string{"a.example.com"}))
+		Expect(Missing([]string{"a.example.com"}, []string{"a.example.com"})).To(BeEmpty())
+	})
+
+	Describe("ForEachHost", func() {
+		It("runs the operation for every host", func() {
+			var lock sync.Mutex
+			seen := []string{}
+
+			err := ForEachHost([]string{"a", "b", "c", "d", "e", "f"}, func(host string) error {
+				lock.Lock()
+				defer lock.Unlock()
+				seen = append(seen, host)
+				return nil
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(seen).To(ConsistOf("a", "b", "c", "d", "e", "f"))
+		})
+
+		It("returns every failure together", func() {
+			err := ForEachHost([]string{"a", "b", "c"}, func(host string) error {
+				if host == "b" {
+					return nil
+				}
+				return errors.New("refused")
+			})
+
+			routeErrs, ok := err.(*Errors)
+			Expect(ok).To(BeTrue())
+			Expect(routeErrs.Hosts()).To(Equal([]string{"a", "c"}))
+			Expect(err).To(MatchError("2 of 3 routes failed (a: refused; c: refused)"))
+		})
+	})
+})
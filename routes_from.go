@@ -0,0 +1,64 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where the routes a push maps and verifies come from, as --routes-from
+// says. RoutesFromApp, the default, takes the manifest's routes when it
+// declares them and the live app's otherwise; RoutesFromManifest only ever
+// takes the manifest's, and unmaps any others from the new version.
+const (
+	RoutesFromApp      = "app"
+	RoutesFromManifest = "manifest"
+)
+
+// ParseRoutesFrom checks the value of --routes-from.
+func ParseRoutesFrom(value string) (string, error) {
+	switch value {
+	case "", RoutesFromApp:
+		return RoutesFromApp, nil
+	case RoutesFromManifest:
+		return RoutesFromManifest, nil
+	}
+
+	return "", fmt.Errorf("--routes-from should be %s or %s, not %q", RoutesFromManifest, RoutesFromApp, value)
+}
+
+// checkRoutesFrom makes sure the manifest says which routes the app should
+// have when they are to come from it alone, before anything is changed.
+func checkRoutesFrom(manifestApp ManifestApplication, manifestPath string, options AutopilotOptions) error {
+	if options.RoutesFrom != RoutesFromManifest {
+		return nil
+	}
+
+	if _, known := manifestApp.IntendedRoutes(); !known {
+		return fmt.Errorf("--routes-from manifest needs %s to declare the app's routes, or no-route: true; "+
+			"it leaves them to the foundation's default domain or a random route", manifestPath)
+	}
+
+	return nil
+}
+
+// unmapUndeclaredRoutes corrects drift by unmapping the routes the new
+// version has that the manifest does not declare.
+func (planner *DeploymentPlanner) unmapUndeclaredRoutes(appName string, newRoutes, declared []string, options AutopilotOptions) error {
+	undeclared := options.Domains.Filter(MissingRoutes(newRoutes, declared))
+	if len(undeclared) == 0 {
+		return nil
+	}
+
+	planner.Logger.Printf("Unmapping routes the manifest does not declare from the new version: %s\n", strings.Join(undeclared, ", "))
+	return tolerateRouteErrors(planner.Repo.UnmapRouteURLs(appName, undeclared), options.ContinueOnRouteError)
+}
+
+// describeVerifyRoutes says what the verify routes step checks.
+func describeVerifyRoutes(options AutopilotOptions) string {
+	outcome := describeChoice(options.StrictRoutes, "fail", "warn") + " if not."
+	if options.RoutesFrom == RoutesFromManifest {
+		return "Check the new version has every route the manifest declares, unmap any others it has, and " + outcome
+	}
+
+	return "Check the new version has every route the manifest declares, or the old version had, and " + outcome
+}
@@ -0,0 +1,99 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Taking the routes from the manifest", func() {
+	var (
+		repo         *recordingRepo
+		logger       *recordingLogger
+		planner      *DeploymentPlanner
+		manifestPath string
+	)
+
+	manifest := func(contents string) {
+		Expect(ioutil.WriteFile(manifestPath, []byte(contents), 0644)).To(Succeed())
+	}
+
+	execute := func(options AutopilotOptions) error {
+		return rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+	}
+
+	BeforeEach(func() {
+		file, err := ioutil.TempFile("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		file.Close()
+		manifestPath = file.Name()
+
+		repo = newRecordingRepo()
+		logger = &recordingLogger{}
+		planner = &DeploymentPlanner{
+			Repo:   repo,
+			Naming: SuffixNaming{},
+			Clock:  &fakeClock{},
+			Logger: logger,
+		}
+	})
+
+	AfterEach(func() {
+		os.Remove(manifestPath)
+	})
+
+	It("is chosen with --routes-from", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--routes-from", "manifest"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.RoutesFrom).To(Equal(RoutesFromManifest))
+
+		_, _, _, options, err = ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.RoutesFrom).To(Equal(RoutesFromApp))
+
+		_, _, _, _, err = ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--routes-from", "venerable"})
+		Expect(err).To(MatchError(`--routes-from should be manifest or app, not "venerable"`))
+	})
+
+	It("unmaps the routes the manifest does not declare from the new version", func() {
+		manifest("applications:\n- name: app\n  routes:\n  - route: app.example.com\n")
+		repo.routes["app"] = []string{"app.example.com", "hand-mapped.example.com"}
+
+		Expect(execute(AutopilotOptions{RoutesFrom: RoutesFromManifest})).To(Succeed())
+		Expect(repo.calls).To(ContainElement("UnmapRouteURLs app [hand-mapped.example.com]"))
+		Expect(logger.messages).To(ContainElement("Unmapping routes the manifest does not declare from the new version: hand-mapped.example.com\n"))
+	})
+
+	It("leaves routes the manifest does not declare by default", func() {
+		manifest("applications:\n- name: app\n  routes:\n  - route: app.example.com\n")
+		repo.routes["app"] = []string{"app.example.com", "hand-mapped.example.com"}
+
+		Expect(execute(AutopilotOptions{RoutesFrom: RoutesFromApp})).To(Succeed())
+		Expect(repo.calls).ToNot(ContainElement(HavePrefix("UnmapRouteURLs")))
+	})
+
+	It("fails before changing anything when the manifest leaves the routes to the foundation", func() {
+		manifest("applications:\n- name: app\n")
+		repo.routes["app"] = []string{"app.example.com"}
+
+		err := execute(AutopilotOptions{RoutesFrom: RoutesFromManifest})
+		Expect(err).To(MatchError(ContainSubstring("--routes-from manifest needs " + manifestPath + " to declare the app's routes")))
+		Expect(repo.calls).ToNot(ContainElement(HavePrefix("RenameApplication")))
+		Expect(repo.calls).ToNot(ContainElement(HavePrefix("PushApplication")))
+	})
+
+	It("says so in the plan", func() {
+		actions := planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{RoutesFrom: RoutesFromManifest, StrictRoutes: true})
+		descriptions := []string{}
+		for _, action := range actions {
+			descriptions = append(descriptions, action.Description.Forward)
+		}
+
+		Expect(descriptions).To(ContainElement("Check the new version has every route the manifest declares, unmap any others it has, and fail if not."))
+	})
+})
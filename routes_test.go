@@ -0,0 +1,202 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("CheckRoutesAvailable", func() {
+	var (
+		cliConn   *pluginfakes.FakeCliConnection
+		repo      *ApplicationRepo
+		responses map[string]string
+	)
+
+	BeforeEach(func() {
+		cliConn = &pluginfakes.FakeCliConnection{}
+		repo = NewApplicationRepo(cliConn)
+
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"},
+		}, nil)
+
+		responses = map[string]string{
+			"v2/shared_domains?q=name:app.example.com":                  `{"resources":[]}`,
+			"v2/private_domains?q=name:app.example.com":                 `{"resources":[]}`,
+			"v2/shared_domains?q=name:example.com":                      `{"resources":[{"metadata":{"guid":"domain-guid"}}]}`,
+			"v2/routes?q=host:app&q=domain_guid:domain-guid":            `{"resources":[{"metadata":{"guid":"route-guid"},"entity":{"host":"app","space_guid":"space-guid"}}]}`,
+			"v2/routes/route-guid/apps":                                 `{"resources":[{"entity":{"name":"app-name"}},{"entity":{"name":"app-name-venerable"}}]}`,
+			"v2/routes/reserved/domain/domain-guid?host=app&path=/path": `{"error_code":"CF-NotFound"}`,
+		}
+
+		cliConn.CliCommandWithoutTerminalOutputStub = func(args ...string) ([]string, error) {
+			if response, ok := responses[args[1]]; ok {
+				return []string{response}, nil
+			}
+			return []string{`{"resources":[]}`}, nil
+		}
+	})
+
+	It("succeeds when the routes belong to the app being pushed", func() {
+		Expect(repo.CheckRoutesAvailable("app-name", []string{"app.example.com"})).To(Succeed())
+	})
+
+	It("succeeds when the routes do not exist yet", func() {
+		Expect(repo.CheckRoutesAvailable("app-name", []string{"app.example.com/path"})).To(Succeed())
+	})
+
+	It("fails when a route is mapped to another app", func() {
+		responses["v2/routes/route-guid/apps"] = `{"resources":[{"entity":{"name":"someone-else"}}]}`
+
+		err := repo.CheckRoutesAvailable("app-name", []string{"app.example.com"})
+		Expect(err).To(MatchError(`Route app.example.com is already mapped to app "someone-else".`))
+	})
+
+	It("fails when a route belongs to another space", func() {
+		responses["v2/routes?q=host:app&q=domain_guid:domain-guid"] = `{"resources":[{"metadata":{"guid":"route-guid"},"entity":{"host":"app","space_guid":"other-space"}}]}`
+
+		err := repo.CheckRoutesAvailable("app-name", []string{"app.example.com"})
+		Expect(err).To(MatchError("Route app.example.com is reserved in another space."))
+	})
+
+	It("fails when a route is reserved in a space we cannot see", func() {
+		responses["v2/routes/reserved/domain/domain-guid?host=app&path=/path"] = ""
+
+		err := repo.CheckRoutesAvailable("app-name", []string{"app.example.com/path"})
+		Expect(err).To(MatchError("Route app.example.com/path is reserved in another space."))
+	})
+
+	It("fails when the route's domain does not exist", func() {
+		err := repo.CheckRoutesAvailable("app-name", []string{"app.nowhere.com"})
+		Expect(err).To(MatchError("Could not find a domain for route app.nowhere.com."))
+	})
+
+	Context("with TCP routes", func() {
+		BeforeEach(func() {
+			responses["v2/shared_domains?q=name:tcp.example.com"] = `{"resources":[{"metadata":{"guid":"tcp-domain-guid"}}]}`
+			responses["v2/routes?q=domain_guid:tcp-domain-guid&q=port:1024"] = `{"resources":[{"metadata":{"guid":"tcp-route-guid"},"entity":{"port":1024,"space_guid":"space-guid"}}]}`
+			responses["v2/routes/tcp-route-guid/apps"] = `{"resources":[{"entity":{"name":"app-name"}}]}`
+			responses["v2/routes/reserved/domain/tcp-domain-guid?port=1025"] = `{"error_code":"CF-NotFound"}`
+		})
+
+		It("finds the route by its domain and port", func() {
+			Expect(repo.CheckRoutesAvailable("app-name", []string{"tcp.example.com:1024", "tcp.example.com:1025"})).To(Succeed())
+		})
+
+		It("fails when the port is mapped to another app", func() {
+			responses["v2/routes/tcp-route-guid/apps"] = `{"resources":[{"entity":{"name":"someone-else"}}]}`
+
+			err := repo.CheckRoutesAvailable("app-name", []string{"tcp.example.com:1024"})
+			Expect(err).To(MatchError(`Route tcp.example.com:1024 is already mapped to app "someone-else".`))
+		})
+
+		It("fails on a port that is not a number", func() {
+			err := repo.CheckRoutesAvailable("app-name", []string{"tcp.example.com:http"})
+			Expect(err).To(MatchError("Route tcp.example.com:http has an invalid port."))
+		})
+	})
+})
+
+var _ = Describe("Mapping routes by URL", func() {
+	var (
+		cliConn   *pluginfakes.FakeCliConnection
+		repo      *ApplicationRepo
+		api       *ghttp.Server
+		responses map[string]string
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "4"},
+		}, nil)
+
+		responses = map[string]string{
+			"v2/shared_domains?q=name:example.com":                     `{"resources":[{"metadata":{"guid":"domain-guid"}}]}`,
+			"v2/routes?q=host:app&q=domain_guid:domain-guid":           `{"resources":[{"metadata":{"guid":"api-route-guid"},"entity":{"host":"app","path":"/api"}},{"metadata":{"guid":"route-guid"},"entity":{"host":"app"}}]}`,
+			"v2/routes?q=host:new&q=domain_guid:domain-guid&q=path:/p": `{"resources":[]}`,
+		}
+		cliConn.CliCommandWithoutTerminalOutputStub = func(args ...string) ([]string, error) {
+			if response, ok := responses[args[1]]; ok {
+				return []string{response}, nil
+			}
+			return []string{`{"resources":[]}`}, nil
+		}
+
+		repo = NewApplicationRepo(cliConn)
+
+		api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"app-guid"},"entity":{"name":"app-name","space_guid":"4"}}]}`))
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("maps existing routes and creates missing ones", func() {
+		api.RouteToHandler("PUT", "/v2/routes/route-guid/apps/app-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+		api.RouteToHandler("POST", "/v2/routes", ghttp.CombineHandlers(
+			ghttp.VerifyJSON(`{"host":"new","domain_guid":"domain-guid","space_guid":"4","path":"/p"}`),
+			ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"new-guid"}}`),
+		))
+		api.RouteToHandler("PUT", "/v2/routes/new-guid/apps/app-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+
+		Expect(repo.MapRouteURLs("app-name", []string{"app.example.com", "new.example.com/p"})).To(Succeed())
+		Expect(api.ReceivedRequests()).To(HaveLen(4))
+	})
+
+	It("creates a missing TCP route on its port", func() {
+		responses["v2/shared_domains?q=name:tcp.example.com"] = `{"resources":[{"metadata":{"guid":"tcp-domain-guid"}}]}`
+		api.RouteToHandler("POST", "/v2/routes", ghttp.CombineHandlers(
+			ghttp.VerifyJSON(`{"domain_guid":"tcp-domain-guid","space_guid":"4","port":1024}`),
+			ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"tcp-guid"}}`),
+		))
+		api.RouteToHandler("PUT", "/v2/routes/tcp-guid/apps/app-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+
+		Expect(repo.MapRouteURLs("app-name", []string{"tcp.example.com:1024"})).To(Succeed())
+	})
+
+	It("unmaps routes", func() {
+		api.RouteToHandler("DELETE", "/v2/routes/route-guid/apps/app-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+
+		Expect(repo.UnmapRouteURLs("app-name", []string{"app.example.com"})).To(Succeed())
+	})
+
+	It("deletes the routes no app is mapped to", func() {
+		api.RouteToHandler("DELETE", "/v2/routes/route-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+
+		deleted, err := repo.DeleteOrphanedRoutes([]string{"app.example.com", "new.example.com/p"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal([]string{"app.example.com"}))
+	})
+
+	It("reports routes that do not exist when unmapping", func() {
+		err := repo.UnmapRouteURLs("app-name", []string{"new.example.com/p"})
+		Expect(err).To(MatchError("1 of 1 routes failed (new.example.com/p: Route new.example.com/p does not exist)"))
+	})
+})
+
+var _ = Describe("MissingRoutes", func() {
+	It("lists the old routes the new app does not have", func() {
+		missing := MissingRoutes(
+			[]string{"app.example.com", "app.example.org", "www.example.com"},
+			[]string{"app.example.com", "new.example.com"},
+		)
+		Expect(missing).To(Equal([]string{"app.example.org", "www.example.com"}))
+	})
+
+	It("is empty when every route was taken over", func() {
+		Expect(MissingRoutes([]string{"app.example.com"}, []string{"app.example.com"})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,238 @@
+package autopilot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/autopilot/repository"
+	"github.com/concourse/autopilot/routes"
+)
+
+type ScaleOptions = repository.ScaleOptions
+
+// clonedAppSettings are the parts of an app's configuration a clone keeps.
+var clonedAppSettings = []string{
+	"buildpack",
+	"command",
+	"diego",
+	"disk_quota",
+	"docker_credentials",
+	"docker_image",
+	"enable_ssh",
+	"environment_json",
+	"health_check_http_endpoint",
+	"health_check_timeout",
+	"health_check_type",
+	"instances",
+	"memory",
+	"ports",
+	"stack_guid",
+}
+
+func scaledAppName(appName string) string {
+	return SuffixNaming{}.ScaledName(appName)
+}
+
+func ParseScaleArgs(args []string) (string, ScaleOptions, error) {
+	flags := flag.NewFlagSet("zero-downtime-scale", flag.ContinueOnError)
+	instances := flags.Int("i", 0, "number of instances")
+	memory := flags.String("m", "", "memory limit (e.g. 256M, 1024M, 1G)")
+	diskQuota := flags.String("k", "", "disk limit (e.g. 256M, 1024M, 1G)")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return "", ScaleOptions{}, err
+	}
+
+	options, err := parseScaleOptions(*instances, *memory, *diskQuota)
+	if err != nil {
+		return "", ScaleOptions{}, err
+	}
+
+	if options == (ScaleOptions{}) {
+		return "", ScaleOptions{}, ErrNothingToScale
+	}
+
+	return args[1], options, nil
+}
+
+var ErrNothingToScale = errors.New("at least one of -i, -m or -k is required to scale this application")
+
+// parseScaleOptions reads instances, memory and disk as given on the command
+// line, with sizes the way cf scale takes them. Those left out are 0.
+func parseScaleOptions(instances int, memory, diskQuota string) (ScaleOptions, error) {
+	if instances < 0 {
+		return ScaleOptions{}, fmt.Errorf("invalid number of instances %d", instances)
+	}
+
+	options := ScaleOptions{Instances: instances}
+
+	var err error
+	if memory != "" {
+		options.Memory, err = parseMegabytes(memory)
+		if err != nil {
+			return ScaleOptions{}, err
+		}
+	}
+
+	if diskQuota != "" {
+		options.DiskQuota, err = parseMegabytes(diskQuota)
+		if err != nil {
+			return ScaleOptions{}, err
+		}
+	}
+
+	return options, nil
+}
+
+// parseMegabytes reads sizes the way cf scale does: a number followed by M,
+// MB, G or GB.
+func parseMegabytes(size string) (int64, error) {
+	upper := strings.TrimSuffix(strings.ToUpper(size), "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(upper, "G"):
+		multiplier = 1024
+		upper = strings.TrimSuffix(upper, "G")
+	case strings.HasSuffix(upper, "M"):
+		upper = strings.TrimSuffix(upper, "M")
+	default:
+		return 0, fmt.Errorf("invalid size %q, use a unit of M or G", size)
+	}
+
+	value, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %q, use a unit of M or G", size)
+	}
+
+	return value * multiplier, nil
+}
+
+// ScaleApplication scales the app with cf scale, changing only what options
+// sets. It is run on apps that are not started yet, so nothing restarts.
+func (repo *ApplicationRepo) ScaleApplication(appName string, options ScaleOptions) error {
+	args := []string{"scale", appName}
+	if options.Instances > 0 {
+		args = append(args, "-i", strconv.Itoa(options.Instances))
+	}
+	if options.Memory > 0 {
+		args = append(args, "-m", fmt.Sprintf("%dM", options.Memory))
+	}
+	if options.DiskQuota > 0 {
+		args = append(args, "-k", fmt.Sprintf("%dM", options.DiskQuota))
+	}
+
+	// -f skips the question of whether to restart the app
+	return repo.cliCommand(append(args, "-f")...)
+}
+
+// CloneApplication creates a stopped copy of the app, scaled as given, with
+// the same configuration, service bindings and bits.
+func (repo *ApplicationRepo) CloneApplication(appName, cloneName string, options ScaleOptions) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	entity, err := repo.api.GetAppEntity(app.Metadata.Guid)
+	if err != nil {
+		return err
+	}
+
+	clone := map[string]interface{}{
+		"name":       cloneName,
+		"space_guid": app.Entity.SpaceGuid,
+		"state":      "STOPPED",
+	}
+
+	for _, setting := range clonedAppSettings {
+		if value, ok := entity[setting]; ok && value != nil {
+			clone[setting] = value
+		}
+	}
+
+	if options.Instances > 0 {
+		clone["instances"] = options.Instances
+	}
+	if options.Memory > 0 {
+		clone["memory"] = options.Memory
+	}
+	if options.DiskQuota > 0 {
+		clone["disk_quota"] = options.DiskQuota
+	}
+
+	fmt.Printf("Creating app %s as a scaled copy of %s\n", cloneName, appName)
+	cloned, err := repo.api.CreateApp(clone)
+	if err != nil {
+		return err
+	}
+
+	model, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range model.Services {
+		err = repo.api.BindService(cloned.Metadata.Guid, service.Guid)
+		if err != nil {
+			return fmt.Errorf("Could not bind service %s to %s: %s", service.Name, cloneName, err)
+		}
+	}
+
+	err = repo.cliCommand("copy-source", appName, cloneName, "--no-restart")
+	return err
+}
+
+// CopyRoutes maps every route of one app to another.
+func (repo *ApplicationRepo) CopyRoutes(fromApp, toApp string) error {
+	return repo.forEachAppRoute(fromApp, toApp, func(routeGuid, appGuid string) error {
+		return repo.api.MapRoute(routeGuid, appGuid)
+	})
+}
+
+// RemoveRoutes unmaps every route from the app.
+func (repo *ApplicationRepo) RemoveRoutes(appName string) error {
+	return repo.forEachAppRoute(appName, appName, func(routeGuid, appGuid string) error {
+		return repo.api.UnmapRoute(routeGuid, appGuid)
+	})
+}
+
+// forEachAppRoute runs operation with each of routesApp's routes and the
+// guid of targetApp.
+func (repo *ApplicationRepo) forEachAppRoute(routesApp, targetApp string, operation func(routeGuid, appGuid string) error) error {
+	model, err := repo.conn.GetApp(routesApp)
+	if err != nil {
+		return err
+	}
+
+	target, found, err := repo.findApp(targetApp)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("App %s not found", targetApp)
+	}
+
+	urls := []string{}
+	for _, route := range model.Routes {
+		url := route.Domain.Name
+		if route.Host != "" {
+			url = route.Host + "." + url
+		}
+
+		urls = append(urls, url)
+	}
+
+	return routes.ForEachRoute(urls, func(i int) error {
+		return operation(model.Routes[i].Guid, target.Metadata.Guid)
+	})
+}
@@ -0,0 +1,184 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("ParseScaleArgs", func() {
+	It("parses instances, memory and disk", func() {
+		appName, options, err := ParseScaleArgs([]string{"zero-downtime-scale", "appname", "-i", "4", "-m", "512M", "-k", "2G"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(appName).To(Equal("appname"))
+		Expect(options).To(Equal(ScaleOptions{Instances: 4, Memory: 512, DiskQuota: 2048}))
+	})
+
+	It("accepts sizes in MB and GB", func() {
+		_, options, err := ParseScaleArgs([]string{"zero-downtime-scale", "appname", "-m", "1gb"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Memory).To(Equal(int64(1024)))
+	})
+
+	It("rejects sizes without a unit", func() {
+		_, _, err := ParseScaleArgs([]string{"zero-downtime-scale", "appname", "-m", "512"})
+		Expect(err).To(MatchError(`invalid size "512", use a unit of M or G`))
+	})
+
+	It("rejects a negative number of instances", func() {
+		_, _, err := ParseScaleArgs([]string{"zero-downtime-scale", "appname", "-i", "-1"})
+		Expect(err).To(MatchError("invalid number of instances -1"))
+	})
+
+	It("requires something to scale", func() {
+		_, _, err := ParseScaleArgs([]string{"zero-downtime-scale", "appname"})
+		Expect(err).To(Equal(ErrNothingToScale))
+	})
+})
+
+var _ = Describe("Scaling", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewServer()
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "4"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	findsApp := func(name, guid string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3A"+name+"&q=space_guid%3A4"),
+			ghttp.RespondWith(http.StatusOK, `{"resources":[{"metadata":{"guid":"`+guid+`"},"entity":{"name":"`+name+`","space_guid":"4"}}]}`),
+		)
+	}
+
+	Describe("ScaleApplication", func() {
+		It("scales only what is given, without asking to restart", func() {
+			Expect(repo.ScaleApplication("app-name", ScaleOptions{Instances: 3, DiskQuota: 2048})).To(Succeed())
+
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"scale", "app-name", "-i", "3", "-k", "2048M", "-f"}))
+		})
+	})
+
+	Describe("CloneApplication", func() {
+		It("creates a scaled copy with the same settings, services and bits", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{
+				Services: []plugin_models.GetApp_ServiceSummary{{Guid: "db-guid", Name: "db"}},
+			}, nil)
+
+			api.AppendHandlers(
+				findsApp("app-name", "app-guid"),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/apps/app-guid"),
+					ghttp.RespondWith(http.StatusOK, `{"entity":{"name":"app-name","instances":2,"memory":256,"disk_quota":1024,"command":null,"environment_json":{"A":"b"},"stack_guid":"stack-guid","package_state":"STAGED"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/apps"),
+					ghttp.VerifyJSON(`{"name":"app-name-scaled","space_guid":"4","state":"STOPPED","instances":4,"memory":256,"disk_quota":1024,"environment_json":{"A":"b"},"stack_guid":"stack-guid"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"metadata":{"guid":"clone-guid"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/service_bindings"),
+					ghttp.VerifyJSON(`{"app_guid":"clone-guid","service_instance_guid":"db-guid"}`),
+					ghttp.RespondWith(http.StatusCreated, `{}`),
+				),
+			)
+
+			err := repo.CloneApplication("app-name", "app-name-scaled", ScaleOptions{Instances: 4})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"copy-source", "app-name", "app-name-scaled", "--no-restart"}))
+		})
+
+		It("returns an error if the app does not exist", func() {
+			api.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"resources":[]}`))
+
+			err := repo.CloneApplication("app-name", "app-name-scaled", ScaleOptions{Instances: 4})
+			Expect(err).To(MatchError("App app-name not found"))
+		})
+	})
+
+	Describe("moving routes", func() {
+		BeforeEach(func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{
+				Routes: []plugin_models.GetApp_RouteSummary{
+					{Guid: "route-guid", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+					{Guid: "apex-guid", Domain: plugin_models.GetApp_DomainFields{Name: "example.org"}},
+				},
+			}, nil)
+		})
+
+		It("maps every route of one app to another", func() {
+			api.AppendHandlers(findsApp("app-name-scaled", "clone-guid"))
+			api.RouteToHandler("PUT", "/v2/routes/route-guid/apps/clone-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+			api.RouteToHandler("PUT", "/v2/routes/apex-guid/apps/clone-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+
+			Expect(repo.CopyRoutes("app-name", "app-name-scaled")).To(Succeed())
+			Expect(cliConn.GetAppArgsForCall(0)).To(Equal("app-name"))
+			Expect(api.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("unmaps every route from an app", func() {
+			api.AppendHandlers(findsApp("app-name", "app-guid"))
+			api.RouteToHandler("DELETE", "/v2/routes/route-guid/apps/app-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+			api.RouteToHandler("DELETE", "/v2/routes/apex-guid/apps/app-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+
+			Expect(repo.RemoveRoutes("app-name")).To(Succeed())
+			Expect(api.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("moves routes that differ only in their paths", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{
+				Routes: []plugin_models.GetApp_RouteSummary{
+					{Guid: "api-guid", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+					{Guid: "web-guid", Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+				},
+			}, nil)
+			api.AppendHandlers(findsApp("app-name-scaled", "clone-guid"))
+			api.RouteToHandler("PUT", "/v2/routes/api-guid/apps/clone-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+			api.RouteToHandler("PUT", "/v2/routes/web-guid/apps/clone-guid", ghttp.RespondWith(http.StatusCreated, `{}`))
+
+			Expect(repo.CopyRoutes("app-name", "app-name-scaled")).To(Succeed())
+			paths := []string{}
+			for _, request := range api.ReceivedRequests()[1:] {
+				paths = append(paths, request.URL.Path)
+			}
+			Expect(paths).To(ConsistOf("/v2/routes/api-guid/apps/clone-guid", "/v2/routes/web-guid/apps/clone-guid"))
+		})
+
+		It("reports the routes that failed", func() {
+			api.AppendHandlers(findsApp("app-name", "app-guid"))
+			api.RouteToHandler("DELETE", "/v2/routes/route-guid/apps/app-guid", ghttp.RespondWith(http.StatusNoContent, ""))
+			api.RouteToHandler("DELETE", "/v2/routes/apex-guid/apps/app-guid", ghttp.RespondWith(http.StatusInternalServerError, `{"description":"boom","error_code":"CF-Boom"}`))
+
+			err := repo.RemoveRoutes("app-name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*RouteErrors).Hosts()).To(Equal([]string{"example.org"}))
+		})
+	})
+})
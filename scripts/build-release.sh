@@ -4,14 +4,19 @@ set -e
 
 SANDBOX=$(mktemp -d)
 
+# the version cf plugins lists, from the latest tag, e.g. v1.4.2 or v1.4.2-3-gabc1234
+VERSION=$(git describe --tags --always --dirty | sed 's/^v//')
+LDFLAGS="-X github.com/concourse/autopilot.Version=$VERSION"
+echo "building autopilot $VERSION..."
+
 echo "building linux..."
-GOOS=linux go build -o $SANDBOX/autopilot-linux github.com/concourse/autopilot
+GOOS=linux go build -ldflags "$LDFLAGS" -o $SANDBOX/autopilot-linux github.com/concourse/autopilot/cmd/autopilot
 
 echo "building os x..."
-GOOS=darwin go build -o $SANDBOX/autopilot-darwin github.com/concourse/autopilot
+GOOS=darwin go build -ldflags "$LDFLAGS" -o $SANDBOX/autopilot-darwin github.com/concourse/autopilot/cmd/autopilot
 
 echo "building windows..."
-GOOS=windows go build -o $SANDBOX/autopilot.exe github.com/concourse/autopilot
+GOOS=windows go build -ldflags "$LDFLAGS" -o $SANDBOX/autopilot.exe github.com/concourse/autopilot/cmd/autopilot
 
 echo
 
@@ -1,7 +1,7 @@
 #!/bin/bash
 
 echo 'Building new `autopilot` binary...'
-go install
+go install -ldflags "-X github.com/concourse/autopilot.Version=$(git describe --tags --always --dirty | sed 's/^v//')" ./cmd/autopilot
 
 echo 'Installing the plugin...'
 cf uninstall-plugin Autopilot
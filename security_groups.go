@@ -0,0 +1,247 @@
+package autopilot
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/autopilot/capi"
+)
+
+type SecurityGroupRule = capi.SecurityGroupRule
+
+// Egress is a service the app connects to, found in a URL in its manifest's
+// environment, such as DATABASE_URL=postgres://db.internal:5432/orders.
+type Egress struct {
+	Variable string
+	Host     string
+	Port     int
+	Protocol string
+}
+
+func (egress Egress) String() string {
+	return fmt.Sprintf("%s:%d (%s)", egress.Host, egress.Port, egress.Variable)
+}
+
+// defaultPorts are the ports of URL schemes that usually leave them out.
+var defaultPorts = map[string]int{
+	"http":       80,
+	"https":      443,
+	"postgres":   5432,
+	"postgresql": 5432,
+	"mysql":      3306,
+	"redis":      6379,
+	"rediss":     6380,
+	"amqp":       5672,
+	"amqps":      5671,
+	"mongodb":    27017,
+	"ldap":       389,
+	"ldaps":      636,
+	"smtp":       25,
+}
+
+// EgressDestinations lists the services the app's environment points it at.
+// Values that are not URLs with a host, or whose port cannot be known, are
+// left out.
+func (app ManifestApplication) EgressDestinations() []Egress {
+	names := []string{}
+	for name := range app.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	destinations := []Egress{}
+	for _, name := range names {
+		value, ok := app.Env[name].(string)
+		if !ok || !strings.Contains(value, "://") {
+			continue
+		}
+
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+
+		port, known := defaultPorts[strings.ToLower(parsed.Scheme)]
+		if parsed.Port() != "" {
+			port, err = strconv.Atoi(parsed.Port())
+			known = err == nil
+		}
+		if !known {
+			continue
+		}
+
+		destinations = append(destinations, Egress{
+			Variable: name,
+			Host:     parsed.Hostname(),
+			Port:     port,
+			Protocol: "tcp",
+		})
+	}
+
+	return destinations
+}
+
+// SecurityGroupsAllow reports whether any of the rules lets the app connect to
+// the address and port.
+func SecurityGroupsAllow(rules []SecurityGroupRule, ip net.IP, port int, protocol string) bool {
+	for _, rule := range rules {
+		if rule.Protocol != "all" && rule.Protocol != protocol {
+			continue
+		}
+
+		if destinationIncludes(rule.Destination, ip) && (rule.Protocol == "all" || portsInclude(rule.Ports, port)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func destinationIncludes(destination string, ip net.IP) bool {
+	for _, entry := range strings.Split(destination, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err == nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if bounds := strings.SplitN(entry, "-", 2); len(bounds) == 2 {
+			first := net.ParseIP(strings.TrimSpace(bounds[0]))
+			last := net.ParseIP(strings.TrimSpace(bounds[1]))
+			if first != nil && last != nil && ipBetween(ip, first, last) {
+				return true
+			}
+			continue
+		}
+
+		if address := net.ParseIP(entry); address != nil && address.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ipBetween(ip, first, last net.IP) bool {
+	ip, first, last = ip.To16(), first.To16(), last.To16()
+	return bytes.Compare(ip, first) >= 0 && bytes.Compare(ip, last) <= 0
+}
+
+func portsInclude(ports string, port int) bool {
+	for _, entry := range strings.Split(ports, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(entry), "-", 2)
+		low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			continue
+		}
+
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				continue
+			}
+		}
+
+		if port >= low && port <= high {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckEgress warns about each destination that none of the rules allow. A
+// host that does not resolve here cannot be checked, and is warned about as
+// such.
+func CheckEgress(destinations []Egress, rules []SecurityGroupRule, lookup func(string) ([]net.IP, error)) []string {
+	warnings := []string{}
+	for _, egress := range destinations {
+		ips := []net.IP{net.ParseIP(egress.Host)}
+		if ips[0] == nil {
+			var err error
+			ips, err = lookup(egress.Host)
+			if err != nil || len(ips) == 0 {
+				warnings = append(warnings, fmt.Sprintf("Could not resolve %s to check it against the security groups.", egress))
+				continue
+			}
+		}
+
+		blocked := []string{}
+		for _, ip := range ips {
+			if !SecurityGroupsAllow(rules, ip, egress.Port, egress.Protocol) {
+				blocked = append(blocked, ip.String())
+			}
+		}
+		sort.Strings(blocked)
+
+		if len(blocked) > 0 {
+			warnings = append(warnings, fmt.Sprintf("No running security group allows %s to %s port %d, used by %s.",
+				egress.Protocol, strings.Join(blocked, ", "), egress.Port, egress.Variable))
+		}
+	}
+
+	return warnings
+}
+
+// RunningSecurityGroupRules lists the egress rules for running apps in the
+// current space. The bool is false when the platform wide groups could not
+// be listed.
+func (repo *ApplicationRepo) RunningSecurityGroupRules() ([]SecurityGroupRule, bool, error) {
+	api, err := repo.client()
+	if err != nil {
+		return nil, false, err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return api.RunningSecurityGroupRules(space.Guid)
+}
+
+// checkSecurityGroups warns, before anything is pushed, about services in the
+// manifest's environment that the space's security groups will not let the
+// new version reach.
+func checkSecurityGroups(repo *ApplicationRepo, appName, manifestPath string) error {
+	manifest, err := ParseManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	app, found := manifest.Application(appName)
+	if !found {
+		return nil
+	}
+
+	destinations := app.EgressDestinations()
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	rules, complete, err := repo.RunningSecurityGroupRules()
+	if err != nil {
+		return err
+	}
+
+	warnings := CheckEgress(destinations, rules, net.LookupIP)
+	if len(warnings) > 0 && !complete {
+		warnings = append(warnings, "The platform wide security groups could not be listed, so they may allow some of these.")
+	}
+
+	for _, warning := range warnings {
+		warnf("%s\n", warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/concourse/autopilot/capi"
+	"github.com/concourse/autopilot/rewind"
+)
+
+// serviceInstance finds the service instance's GUID in the current space.
+func (repo *ApplicationRepo) serviceInstance(serviceName string) (*capi.Client, string, error) {
+	api, err := repo.client()
+	if err != nil {
+		return nil, "", err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	guid, found, err := api.FindServiceInstance(space.Guid, serviceName)
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		return nil, "", fmt.Errorf("Service %s not found", serviceName)
+	}
+
+	return api, guid, nil
+}
+
+// CreateServiceKey creates a key for the service, and returns its
+// credentials as JSON.
+func (repo *ApplicationRepo) CreateServiceKey(serviceName, keyName string) (string, error) {
+	api, guid, err := repo.serviceInstance(serviceName)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := api.CreateServiceKey(guid, keyName)
+	if err != nil {
+		return "", err
+	}
+
+	credentials, err := json.Marshal(key.Entity.Credentials)
+	return string(credentials), err
+}
+
+// ServiceKeys lists the names of the service's keys.
+func (repo *ApplicationRepo) ServiceKeys(serviceName string) ([]string, error) {
+	api, guid, err := repo.serviceInstance(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := api.ServiceKeys(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, key := range keys {
+		names = append(names, key.Entity.Name)
+	}
+
+	return names, nil
+}
+
+// DeleteServiceKey deletes the service's key, if it still exists.
+func (repo *ApplicationRepo) DeleteServiceKey(serviceName, keyName string) error {
+	api, guid, err := repo.serviceInstance(serviceName)
+	if err != nil {
+		return err
+	}
+
+	keys, err := api.ServiceKeys(guid)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Entity.Name == keyName {
+			return api.DeleteServiceKey(key.Metadata.Guid)
+		}
+	}
+
+	return nil
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// ServiceKeyVariable is the environment variable the new app finds a rotated
+// service key's credentials in, e.g. ORDERS_DB_CREDENTIALS for orders-db.
+func ServiceKeyVariable(serviceName string) string {
+	return strings.Trim(nonIdentifierChars.ReplaceAllString(strings.ToUpper(serviceName), "_"), "_") + "_CREDENTIALS"
+}
+
+// serviceKeyRotation gives each new version of an app fresh keys for its
+// services, named <app>-key-<time>, and deletes the keys older versions had
+// once they are no longer needed.
+type serviceKeyRotation struct {
+	planner  *DeploymentPlanner
+	appName  string
+	services []string
+
+	keyName string
+	created []string
+	env     EnvVars
+}
+
+func (planner *DeploymentPlanner) newServiceKeyRotation(appName string, services []string) *serviceKeyRotation {
+	return &serviceKeyRotation{
+		planner:  planner,
+		appName:  appName,
+		services: services,
+		env:      EnvVars{},
+	}
+}
+
+// serviceNames names the services for the plan.
+func (rotation *serviceKeyRotation) serviceNames() string {
+	if len(rotation.services) == 0 {
+		return "the --rotate-service-keys services"
+	}
+
+	return strings.Join(rotation.services, ", ")
+}
+
+func (rotation *serviceKeyRotation) keyPrefix() string {
+	return rotation.appName + "-key-"
+}
+
+// create makes a new key for each service and keeps its credentials for the
+// new app's environment.
+func (rotation *serviceKeyRotation) create() error {
+	if len(rotation.services) == 0 {
+		return nil
+	}
+
+	rotation.keyName = rotation.keyPrefix() + rotation.planner.Clock.Now().UTC().Format("20060102-150405")
+	for _, service := range rotation.services {
+		rotation.planner.Logger.Printf("Creating service key %s for %s.\n", rotation.keyName, service)
+		credentials, err := rotation.planner.Repo.CreateServiceKey(service, rotation.keyName)
+		if err != nil {
+			return err
+		}
+
+		rotation.created = append(rotation.created, service)
+		rotation.env[ServiceKeyVariable(service)] = credentials
+	}
+
+	return nil
+}
+
+// deleteCreated deletes the keys made for a new version that is being
+// rolled back.
+func (rotation *serviceKeyRotation) deleteCreated() error {
+	for _, service := range rotation.created {
+		err := rotation.planner.Repo.DeleteServiceKey(service, rotation.keyName)
+		if err != nil {
+			return err
+		}
+	}
+
+	rotation.created = nil
+	return nil
+}
+
+// deleteOld deletes the keys earlier versions of the app were given. It only
+// warns on failure, since the new version is live by then.
+func (rotation *serviceKeyRotation) deleteOld() error {
+	for _, service := range rotation.created {
+		keys, err := rotation.planner.Repo.ServiceKeys(service)
+		if err != nil {
+			rotation.planner.Logger.Printf("Warning: could not list the keys of %s to delete the old ones: %s\n", service, err)
+			continue
+		}
+
+		for _, key := range keys {
+			if !strings.HasPrefix(key, rotation.keyPrefix()) || key == rotation.keyName {
+				continue
+			}
+
+			rotation.planner.Logger.Printf("Deleting old service key %s of %s.\n", key, service)
+			err = rotation.planner.Repo.DeleteServiceKey(service, key)
+			if err != nil {
+				rotation.planner.Logger.Printf("Warning: could not delete service key %s of %s: %s\n", key, service, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pushOptions adds the new keys' credentials to the environment the new app
+// is pushed with.
+func (rotation *serviceKeyRotation) pushOptions(options AutopilotOptions) AutopilotOptions {
+	if len(rotation.env) == 0 {
+		return options
+	}
+
+	env := EnvVars{}
+	for name, value := range options.Env {
+		env[name] = value
+	}
+	for name, value := range rotation.env {
+		env[name] = value
+	}
+
+	options.Env = env
+	return options
+}
+
+// createAction is the action that makes the new keys, before the push.
+func (rotation *serviceKeyRotation) createAction() rewind.Action {
+	return rewind.Action{
+		Name:            "create service keys",
+		Forward:         rotation.create,
+		ReversePrevious: rotation.deleteCreated,
+		Undo:            rotation.deleteCreated,
+		Description: rewind.Description{
+			Forward:         fmt.Sprintf("Create a new key for each of %s, for the new version's environment.", rotation.serviceNames()),
+			When:            onlyWith("--rotate-service-keys", len(rotation.services) > 0),
+			ReversePrevious: "Delete the keys that were created.",
+			Undo:            "Delete the new keys.",
+		},
+	}
+}
+
+// deleteOldAction is the action that deletes the old keys once the new
+// version is live. They are kept while the old version may still run.
+func (rotation *serviceKeyRotation) deleteOldAction(keepOld bool) rewind.Action {
+	return rewind.Action{
+		Name: "delete old service keys",
+		Forward: func() error {
+			if keepOld {
+				if len(rotation.created) > 0 {
+					rotation.planner.Logger.Printf("Keeping the old service keys, which the old version of the app still uses.\n")
+				}
+				return nil
+			}
+
+			return rotation.deleteOld()
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Delete the keys of %s that earlier versions of %s were given.", rotation.serviceNames(), rotation.appName),
+			When:    onlyWith("--rotate-service-keys", len(rotation.services) > 0 && !keepOld),
+		},
+	}
+}
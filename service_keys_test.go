@@ -0,0 +1,25 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Service keys", func() {
+	It("names the variable holding a service's credentials", func() {
+		Expect(ServiceKeyVariable("orders-db")).To(Equal("ORDERS_DB_CREDENTIALS"))
+		Expect(ServiceKeyVariable("my.queue")).To(Equal("MY_QUEUE_CREDENTIALS"))
+		Expect(ServiceKeyVariable("-cache-")).To(Equal("CACHE_CREDENTIALS"))
+	})
+
+	It("collects comma separated services", func() {
+		services := ServiceList{}
+		Expect(services.Set("orders-db, cache")).To(Succeed())
+		Expect(services.Set("queue")).To(Succeed())
+		Expect(services).To(Equal(ServiceList{"orders-db", "cache", "queue"}))
+
+		Expect(services.Set("orders-db,,cache")).To(MatchError(`"orders-db,,cache" should be a comma separated list of services`))
+	})
+})
@@ -0,0 +1,75 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// CheckServicesBindable makes sure every service the manifest binds exists
+// in the space and can be bound. Otherwise the push would fail after the
+// live app has been renamed, and so would every retry.
+func (repo *ApplicationRepo) CheckServicesBindable(serviceNames []string) error {
+	if len(serviceNames) == 0 {
+		return nil
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return err
+	}
+
+	problems := []string{}
+	for _, name := range serviceNames {
+		instance, found, err := api.FindAnyServiceInstance(space.Guid, name)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s does not exist", name))
+			continue
+		}
+
+		if bindable, reason := instance.Bindable(); !bindable {
+			problems = append(problems, fmt.Sprintf("%s cannot be bound yet (%s)", name, reason))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("The manifest binds services that are not ready in space %s: %s. Create them, or wait for them, and deploy again.",
+			space.Name, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// checkServicesAction fails the deploy before anything is renamed when a
+// service the manifest binds is missing or not ready.
+func (planner *DeploymentPlanner) checkServicesAction(appName, manifestPath string) rewind.Action {
+	return rewind.Action{
+		Name: "check services",
+		Forward: func() error {
+			manifest, err := ParseManifest(manifestPath)
+			if err != nil {
+				return err
+			}
+
+			manifestApp, _ := manifest.Application(appName)
+			if len(manifestApp.Services) == 0 {
+				return nil
+			}
+
+			return planner.Repo.CheckServicesBindable(manifestApp.Services)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Check every service %s binds exists in the space and can be bound.", manifestPath),
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Checking the manifest's services", func() {
+	Describe("CheckServicesBindable", func() {
+		var (
+			api  *ghttp.Server
+			repo *ApplicationRepo
+		)
+
+		instances := func(name, body string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/spaces/4/service_instances", "return_user_provided_service_instances=true&q=name%3A"+name),
+				ghttp.RespondWith(http.StatusOK, body),
+			)
+		}
+
+		BeforeEach(func() {
+			api = ghttp.NewServer()
+
+			cliConn := &pluginfakes.FakeCliConnection{}
+			cliConn.ApiEndpointReturns(api.URL(), nil)
+			cliConn.AccessTokenReturns("bearer some-token", nil)
+			cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+				SpaceFields: plugin_models.SpaceFields{Guid: "4", Name: "production"},
+			}, nil)
+
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		AfterEach(func() {
+			api.Close()
+		})
+
+		It("passes services that exist and are ready, managed or user-provided", func() {
+			api.AppendHandlers(
+				instances("orders-db", `{"resources":[{"metadata":{"guid":"db-guid"},"entity":{"name":"orders-db","last_operation":{"type":"update","state":"failed"}}}]}`),
+				instances("logs", `{"resources":[{"metadata":{"guid":"logs-guid"},"entity":{"name":"logs"}}]}`),
+			)
+
+			Expect(repo.CheckServicesBindable([]string{"orders-db", "logs"})).To(Succeed())
+		})
+
+		It("lists every service that is missing or not ready", func() {
+			api.AppendHandlers(
+				instances("orders-db", `{"resources":[]}`),
+				instances("cache", `{"resources":[{"metadata":{"guid":"cache-guid"},"entity":{"name":"cache","last_operation":{"type":"create","state":"in progress"}}}]}`),
+				instances("queue", `{"resources":[{"metadata":{"guid":"queue-guid"},"entity":{"name":"queue","last_operation":{"type":"create","state":"failed"}}}]}`),
+			)
+
+			Expect(repo.CheckServicesBindable([]string{"orders-db", "cache", "queue"})).To(MatchError(
+				"The manifest binds services that are not ready in space production: orders-db does not exist, " +
+					"cache cannot be bound yet (create in progress), queue cannot be bound yet (create failed). " +
+					"Create them, or wait for them, and deploy again."))
+		})
+
+		It("asks nothing when there are no services", func() {
+			Expect(repo.CheckServicesBindable(nil)).To(Succeed())
+			Expect(api.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("before a push over a live app", func() {
+		var (
+			repo         *recordingRepo
+			planner      *DeploymentPlanner
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n  services:\n  - orders-db\n  - name: cache\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: discardLogger{},
+			}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("fails before anything is renamed when a service is not ready", func() {
+			repo.failures["CheckServicesBindable [orders-db cache]"] = errors.New("orders-db does not exist")
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).To(MatchError("orders-db does not exist"))
+			Expect(repo.calls).ToNot(ContainElement(HavePrefix("RenameApplication")))
+			Expect(repo.calls).ToNot(ContainElement(HavePrefix("PushApplication")))
+		})
+	})
+})
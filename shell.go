@@ -0,0 +1,44 @@
+package autopilot
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ShellArgs is how a command given as one string, such as a hook, is run on
+// the OS: with sh on *nix, and with cmd on Windows, or PowerShell for a .ps1
+// script, since build agents there have no sh.
+func ShellArgs(goos, command string) []string {
+	if goos != "windows" {
+		return []string{"sh", "-c", command}
+	}
+
+	fields := strings.Fields(command)
+	if len(fields) > 0 && strings.EqualFold(filepathExt(fields[0]), ".ps1") {
+		return append([]string{"powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File"}, fields...)
+	}
+
+	return []string{"cmd", "/C", command}
+}
+
+// ShellCommand runs the command through the shell of the OS autopilot runs
+// on, passing on its output.
+func ShellCommand(command string) *exec.Cmd {
+	args := ShellArgs(runtime.GOOS, command)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// filepathExt is filepath.Ext for either OS's separators, as ShellArgs is
+// given the OS rather than running on it.
+func filepathExt(name string) string {
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	if i := strings.LastIndex(name, "."); i != -1 {
+		return name[i:]
+	}
+	return ""
+}
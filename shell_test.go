@@ -0,0 +1,41 @@
+package autopilot_test
+
+import (
+	"bytes"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ShellArgs", func() {
+	It("runs commands with sh on *nix", func() {
+		Expect(ShellArgs("linux", "./notify.sh app")).To(Equal([]string{"sh", "-c", "./notify.sh app"}))
+		Expect(ShellArgs("darwin", "echo hi")).To(Equal([]string{"sh", "-c", "echo hi"}))
+	})
+
+	It("runs commands with cmd on Windows", func() {
+		Expect(ShellArgs("windows", `scripts\notify.bat app`)).To(Equal([]string{"cmd", "/C", `scripts\notify.bat app`}))
+	})
+
+	It("runs PowerShell scripts with PowerShell on Windows", func() {
+		Expect(ShellArgs("windows", `scripts\Notify.PS1 -App app`)).To(Equal([]string{
+			"powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", `scripts\Notify.PS1`, "-App", "app",
+		}))
+	})
+
+	It("runs the command in the shell of this OS", func() {
+		// cmd has no arithmetic to check with
+		if runtime.GOOS == "windows" {
+			return
+		}
+
+		var output bytes.Buffer
+		command := ShellCommand("echo $((1 + 2))")
+		command.Stdout = &output
+		Expect(command.Run()).To(Succeed())
+		Expect(output.String()).To(Equal("3\n"))
+	})
+})
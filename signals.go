@@ -0,0 +1,278 @@
+package autopilot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+type AppStatus struct {
+	Name   string
+	Exists bool
+	State  string
+}
+
+// CommandProgress is how far an interrupted command got, which decides what
+// putting the apps back takes: apps named like the command's may have been
+// there before it started, such as an old version kept by
+// --keep-existing-app.
+type CommandProgress struct {
+	// Done are the steps that ran and have not been undone since.
+	Done []string
+	// Running is the step whose forward step was running, if any.
+	Running string
+}
+
+// Completed says whether the step ran and has not been undone.
+func (progress CommandProgress) Completed(step string) bool {
+	return containsString(progress.Done, step)
+}
+
+// StepTracker follows the steps of a command as they run, for the recovery
+// plan should it be abandoned. It is a rewind.Observer, and its StepStarting
+// goes in rewind.Actions' Starting.
+type StepTracker struct {
+	mutex   sync.Mutex
+	done    []string
+	running string
+}
+
+// NewStepTracker tracks a command that runs the actions. The actions before
+// resumeFrom, if given, were carried out by the deploy being resumed.
+func NewStepTracker(actions []rewind.Action, resumeFrom string) *StepTracker {
+	tracker := &StepTracker{}
+	if resumeFrom == "" {
+		return tracker
+	}
+
+	for _, action := range actions {
+		if action.Name == resumeFrom {
+			break
+		}
+		tracker.done = append(tracker.done, action.Name)
+	}
+	return tracker
+}
+
+// StepStarting notes the step that is running.
+func (tracker *StepTracker) StepStarting(name, phase string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracker.running = ""
+	if phase == rewind.PhaseForward {
+		tracker.running = name
+	}
+}
+
+// ObserveStep notes the steps that took effect, and those undone since.
+func (tracker *StepTracker) ObserveStep(name, phase string, start time.Time, err error) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracker.running = ""
+	switch {
+	case phase == rewind.PhaseForward && err == nil:
+		tracker.done = append(tracker.done, name)
+	case phase == rewind.PhaseUndo && err == nil:
+		for i := len(tracker.done) - 1; i >= 0; i-- {
+			if tracker.done[i] == name {
+				tracker.done = append(tracker.done[:i:i], tracker.done[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Progress is how far the command has got.
+func (tracker *StepTracker) Progress() CommandProgress {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	return CommandProgress{Done: append([]string{}, tracker.done...), Running: tracker.running}
+}
+
+// interruptContext returns a context that is cancelled when the user chooses
+// to roll back after a SIGINT or SIGTERM, so the deploy can finish its current
+// step and rewind. On a terminal the user is asked whether to continue, roll
+// back or abandon the deploy; otherwise the deploy is rolled back. Abandoning,
+// or a signal once the rollback has started, prints a recovery plan and exits.
+// previous is the copy a rollback goes back to, or empty for the naming's
+// venerable app.
+func interruptContext(appRepo *ApplicationRepo, naming NamingStrategy, command, appName, previous string, tracker *StepTracker) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		for range signals {
+			if ctx.Err() != nil {
+				abandon(appRepo, naming, command, appName, previous, tracker)
+			}
+
+			fmt.Println()
+			switch promptForInterrupt() {
+			case "c":
+				fmt.Println("Continuing the deploy.")
+			case "a":
+				abandon(appRepo, naming, command, appName, previous, tracker)
+			default:
+				fmt.Println("Finishing the current step and rolling back; interrupt again to quit immediately.")
+				cancel()
+			}
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(signals)
+		cancel()
+	}
+}
+
+// promptForInterrupt asks what to do about an interrupt. Without a terminal
+// to ask on, the answer is always to roll back.
+func promptForInterrupt() string {
+	if !isTerminal() {
+		return "r"
+	}
+
+	fmt.Print("Interrupted. [c]ontinue, [r]oll back now, or [a]bandon with a recovery plan? ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer))
+}
+
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func abandon(appRepo *ApplicationRepo, naming NamingStrategy, command, appName, previous string, tracker *StepTracker) {
+	// a scale leaves a clone behind where a push leaves a venerable app
+	if command == "zero-downtime-scale" {
+		previous = naming.ScaledName(appName)
+	} else if previous == "" {
+		previous = naming.VenerableName(appName)
+	}
+
+	statuses := []AppStatus{}
+	for _, name := range []string{appName, previous, naming.RollbackName(appName)} {
+		statuses = append(statuses, appRepo.appStatus(name))
+	}
+
+	fmt.Println()
+	fmt.Println("Abandoning the deploy. Current state of the apps:")
+	for _, status := range statuses {
+		if status.State == "" && !status.Exists {
+			fmt.Printf("  %s: does not exist\n", status.Name)
+		} else {
+			fmt.Printf("  %s: %s\n", status.Name, status.State)
+		}
+	}
+
+	plan := RecoveryPlan(command, appName, tracker.Progress(), statuses[0], statuses[1], statuses[2])
+	if len(plan) == 0 {
+		fmt.Println("Nothing needs to be done to recover.")
+	} else {
+		fmt.Println("To go back to where you started, run:")
+		for _, step := range plan {
+			fmt.Println("  " + step)
+		}
+	}
+
+	fmt.Printf("The deploy lock is left in place. Once the apps are recovered, remove it with: cf delete %s -f\n", lockAppName(appName))
+	fmt.Printf("Or have cf zero-downtime-abort %s do both.\n", appName)
+
+	// so zero-downtime-abort knows not to wait for this deploy to roll back
+	err := appRepo.markLockAbandoned(appName)
+	if err != nil {
+		warnf("could not mark the deploy lock as abandoned: %s\n", err)
+	}
+
+	os.Exit(130)
+}
+
+func (repo *ApplicationRepo) appStatus(appName string) AppStatus {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return AppStatus{Name: appName, State: fmt.Sprintf("unknown (%s)", err)}
+	}
+
+	return AppStatus{Name: appName, Exists: found, State: app.Entity.State}
+}
+
+// RecoveryPlan lists the cf commands that put the apps back the way they
+// were before the interrupted command started. For a scale, venerable is the
+// status of the scaled clone, and for a rollback that of the copy rolled
+// back to.
+func RecoveryPlan(command, appName string, progress CommandProgress, live, venerable, rollback AppStatus) []string {
+	plan := []string{}
+
+	// the live app only lost its name if the command renamed it; a rename
+	// that was running took effect if the name is free
+	renamed := progress.Completed("rename live app") || (progress.Running == "rename live app" && !live.Exists)
+
+	switch command {
+	case "zero-downtime-push":
+		if !renamed || !venerable.Exists {
+			return plan
+		}
+
+		if live.Exists {
+			plan = append(plan, fmt.Sprintf("cf delete %s -f", appName))
+		}
+		plan = append(plan, fmt.Sprintf("cf rename %s %s", venerable.Name, appName))
+		if venerable.State == "STOPPED" {
+			plan = append(plan, fmt.Sprintf("cf start %s", appName))
+		}
+
+	case "zero-downtime-rollback":
+		if !renamed || !rollback.Exists {
+			return plan
+		}
+
+		if live.Exists {
+			plan = append(plan, fmt.Sprintf("cf rename %s %s", appName, venerable.Name))
+		}
+		plan = append(plan, fmt.Sprintf("cf rename %s %s", rollback.Name, appName))
+		if rollback.State == "STOPPED" {
+			plan = append(plan, fmt.Sprintf("cf start %s", appName))
+		}
+		plan = append(plan, fmt.Sprintf("cf app %s # check its routes, they may still be on %s", appName, venerable.Name))
+
+	case "zero-downtime-scale":
+		if !venerable.Exists {
+			return plan
+		}
+
+		if !live.Exists {
+			plan = append(plan, fmt.Sprintf("cf rename %s %s", venerable.Name, appName))
+			return plan
+		}
+
+		plan = append(plan, fmt.Sprintf("cf app %s # check its routes, some may only be on %s", appName, venerable.Name))
+		plan = append(plan, fmt.Sprintf("cf delete %s -f", venerable.Name))
+
+	case "zero-downtime-delete":
+		// once the app is deleted there is nothing to go back to
+		if !live.Exists {
+			return plan
+		}
+
+		if live.State == "STOPPED" {
+			plan = append(plan, fmt.Sprintf("cf start %s", appName))
+		}
+		plan = append(plan, fmt.Sprintf("cf app %s # map back any routes that were unmapped", appName))
+	}
+
+	return plan
+}
@@ -0,0 +1,176 @@
+package autopilot_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("RecoveryPlan", func() {
+	var live, venerable, rollback AppStatus
+	var progress CommandProgress
+
+	BeforeEach(func() {
+		progress = CommandProgress{Done: []string{"check quota", "rename live app"}}
+		live = AppStatus{Name: "app", Exists: true, State: "STARTED"}
+		venerable = AppStatus{Name: "app-venerable", Exists: true, State: "STARTED"}
+		rollback = AppStatus{Name: "app-rollback"}
+	})
+
+	Context("during a push", func() {
+		It("replaces the new app with the venerable one", func() {
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf delete app -f",
+				"cf rename app-venerable app",
+			}))
+		})
+
+		It("renames and starts a stopped venerable app when nothing was pushed yet", func() {
+			live.Exists = false
+			venerable.State = "STOPPED"
+
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf rename app-venerable app",
+				"cf start app",
+			}))
+		})
+
+		It("has nothing to do before the live app is renamed", func() {
+			venerable.Exists = false
+
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+
+		It("leaves a kept venerable app alone when interrupted before the rename", func() {
+			progress = CommandProgress{Done: []string{"check quota"}, Running: "consult policy before cutover"}
+
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+
+		It("renames the venerable app back when interrupted once the rename freed the name", func() {
+			progress = CommandProgress{Done: []string{"check quota"}, Running: "rename live app"}
+			live.Exists = false
+
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf rename app-venerable app",
+			}))
+		})
+
+		It("leaves the apps alone when interrupted before the rename took effect", func() {
+			progress = CommandProgress{Done: []string{"check quota"}, Running: "rename live app"}
+
+			Expect(RecoveryPlan("zero-downtime-push", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+	})
+
+	Context("during a rollback", func() {
+		It("puts the rolled back app live again", func() {
+			rollback = AppStatus{Name: "app-rollback", Exists: true, State: "STARTED"}
+			venerable.Exists = false
+
+			Expect(RecoveryPlan("zero-downtime-rollback", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf rename app app-venerable",
+				"cf rename app-rollback app",
+				"cf app app # check its routes, they may still be on app-venerable",
+			}))
+		})
+
+		It("renames the app back to the copy it was rolled back to", func() {
+			rollback = AppStatus{Name: "app-rollback", Exists: true, State: "STARTED"}
+			venerable = AppStatus{Name: "app-20260101T000000Z"}
+
+			Expect(RecoveryPlan("zero-downtime-rollback", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf rename app app-20260101T000000Z",
+				"cf rename app-rollback app",
+				"cf app app # check its routes, they may still be on app-20260101T000000Z",
+			}))
+		})
+
+		It("has nothing to do before the live app is renamed", func() {
+			Expect(RecoveryPlan("zero-downtime-rollback", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+
+		It("leaves an earlier rollback's app alone before the live app is renamed", func() {
+			rollback = AppStatus{Name: "app-rollback", Exists: true, State: "STOPPED"}
+			progress = CommandProgress{Running: "start copy before rollback"}
+
+			Expect(RecoveryPlan("zero-downtime-rollback", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+	})
+
+	Context("during a scale", func() {
+		BeforeEach(func() {
+			venerable = AppStatus{Name: "app-scaled", Exists: true, State: "STARTED"}
+		})
+
+		It("deletes the clone once the routes are checked", func() {
+			Expect(RecoveryPlan("zero-downtime-scale", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf app app # check its routes, some may only be on app-scaled",
+				"cf delete app-scaled -f",
+			}))
+		})
+
+		It("renames the clone once the original is gone", func() {
+			live.Exists = false
+
+			Expect(RecoveryPlan("zero-downtime-scale", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf rename app-scaled app",
+			}))
+		})
+	})
+
+	Context("during a delete", func() {
+		It("starts the app again so its routes can be mapped back", func() {
+			live.State = "STOPPED"
+
+			Expect(RecoveryPlan("zero-downtime-delete", "app", progress, live, venerable, rollback)).To(Equal([]string{
+				"cf start app",
+				"cf app app # map back any routes that were unmapped",
+			}))
+		})
+
+		It("has nothing to do once the app is deleted", func() {
+			live.Exists = false
+
+			Expect(RecoveryPlan("zero-downtime-delete", "app", progress, live, venerable, rollback)).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("StepTracker", func() {
+	var tracker *StepTracker
+
+	BeforeEach(func() {
+		tracker = NewStepTracker([]rewind.Action{{Name: "lookup"}, {Name: "rename"}, {Name: "push"}}, "")
+	})
+
+	It("notes the steps that ran and the one running", func() {
+		tracker.StepStarting("lookup", rewind.PhaseForward)
+		tracker.ObserveStep("lookup", rewind.PhaseForward, time.Now(), nil)
+		tracker.StepStarting("rename", rewind.PhaseForward)
+
+		Expect(tracker.Progress()).To(Equal(CommandProgress{Done: []string{"lookup"}, Running: "rename"}))
+	})
+
+	It("forgets the steps that failed or were undone", func() {
+		tracker.ObserveStep("lookup", rewind.PhaseForward, time.Now(), nil)
+		tracker.ObserveStep("rename", rewind.PhaseForward, time.Now(), nil)
+		tracker.ObserveStep("push", rewind.PhaseForward, time.Now(), errors.New("push failed"))
+		tracker.StepStarting("rename", rewind.PhaseUndo)
+		tracker.ObserveStep("rename", rewind.PhaseUndo, time.Now(), nil)
+
+		Expect(tracker.Progress()).To(Equal(CommandProgress{Done: []string{"lookup"}}))
+	})
+
+	It("takes the steps a resumed deploy skips as done", func() {
+		tracker = NewStepTracker([]rewind.Action{{Name: "lookup"}, {Name: "rename"}, {Name: "push"}}, "push")
+
+		Expect(tracker.Progress().Completed("rename")).To(BeTrue())
+		Expect(tracker.Progress().Completed("push")).To(BeFalse())
+	})
+})
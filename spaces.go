@@ -0,0 +1,219 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// confirmGate is the --pause-between that asks before each promotion.
+const confirmGate = "confirm"
+
+// Promotion deploys the same app to several spaces of the current org, one
+// after the other, with a gate before each space after the first.
+type Promotion struct {
+	Spaces []string
+	// Pause is how long to wait before promoting to the next space.
+	Pause time.Duration
+	// Confirm asks on the terminal before promoting to the next space.
+	Confirm bool
+}
+
+// ParsePromotion takes the --spaces and --pause-between flags out of args.
+// The promotion has no spaces if --spaces is not given.
+func ParsePromotion(args []string) (Promotion, []string, error) {
+	spaces, args := takeStringFlag(args, "spaces")
+	pause, args := takeStringFlag(args, "pause-between")
+
+	promotion := Promotion{}
+	for _, space := range strings.Split(spaces, ",") {
+		if space = strings.TrimSpace(space); space != "" {
+			promotion.Spaces = append(promotion.Spaces, space)
+		}
+	}
+
+	if spaces != "" && len(promotion.Spaces) == 0 {
+		return Promotion{}, nil, errors.New("--spaces lists no spaces")
+	}
+	if pause != "" && len(promotion.Spaces) == 0 {
+		return Promotion{}, nil, errors.New("--pause-between needs --spaces")
+	}
+
+	if pause == confirmGate {
+		promotion.Confirm = true
+	} else if pause != "" {
+		duration, err := time.ParseDuration(pause)
+		if err != nil || duration <= 0 {
+			return Promotion{}, nil, fmt.Errorf("--pause-between takes a duration, e.g. 30m, or confirm, not %q", pause)
+		}
+		promotion.Pause = duration
+	}
+
+	return promotion, args, nil
+}
+
+// Run deploys to each space in turn, passing the gate before every space
+// after the first, and stops at the first space that fails.
+func (promotion Promotion) Run(deploy func(space string) error, gate func(from, to string) error) error {
+	for i, space := range promotion.Spaces {
+		if i > 0 {
+			err := gate(promotion.Spaces[i-1], space)
+			if err != nil {
+				return promotion.stoppedAt(i, err)
+			}
+		}
+
+		err := deploy(space)
+		if err != nil {
+			return promotion.stoppedAt(i, fmt.Errorf("the push to %s failed: %s", space, err))
+		}
+	}
+
+	return nil
+}
+
+// stoppedAt says which spaces were deployed to and which were not.
+func (promotion Promotion) stoppedAt(i int, err error) error {
+	if i == 0 {
+		return fmt.Errorf("Deployed to no space, as %s", err)
+	}
+
+	return fmt.Errorf("Deployed to %s, but not to %s, as %s",
+		strings.Join(promotion.Spaces[:i], ", "), strings.Join(promotion.Spaces[i:], ", "), err)
+}
+
+// PromotionArgs are the arguments each space's push is run with: the
+// packaged app, if there is one, instead of the app's directory.
+func PromotionArgs(args []string, artifact string) []string {
+	if artifact == "" {
+		return args
+	}
+
+	_, args = takeStringFlag(args, "p")
+	_, args = takeBoolFlag(args, "package")
+	return insertBeforeDashes(args, "-p", artifact)
+}
+
+func insertBeforeDashes(args []string, extra ...string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return append(append(append([]string{}, args[:i]...), extra...), args[i:]...)
+		}
+	}
+	return append(append([]string{}, args...), extra...)
+}
+
+// cfConfigPath is where the cf CLI keeps the login and target it runs with.
+func cfConfigPath(getenv func(string) string) string {
+	home := getenv("CF_HOME")
+	if home == "" {
+		home = getenv("HOME")
+	}
+	if home == "" {
+		home = getenv("USERPROFILE")
+	}
+
+	return filepath.Join(home, ".cf", "config.json")
+}
+
+// SpaceHome copies the cf CLI config at configPath into a new directory to
+// use as CF_HOME, so a space can be targeted there without retargeting the
+// cf CLI the user is logged in with. Remove the directory when done.
+func SpaceHome(configPath string) (string, error) {
+	config, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read the cf CLI config to copy for each space: %s", err)
+	}
+
+	home, err := ioutil.TempDir("", "autopilot-space")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Join(home, ".cf"), 0700)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(home, ".cf", "config.json"), config, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(home)
+		return "", err
+	}
+
+	return home, nil
+}
+
+// promote pushes the app to each space of the promotion. A directory given
+// with -p is packaged once, and the same archive pushed to every space.
+func promote(promotion Promotion, args []string) error {
+	if args[0] != "zero-downtime-push" {
+		return fmt.Errorf("--spaces only works with zero-downtime-push, not %s", args[0])
+	}
+	if promotion.Confirm && !isTerminal() {
+		return errors.New("--pause-between confirm needs a terminal to ask on; give a duration instead")
+	}
+
+	// the flags are passed on as they are, but the colors apply here too
+	colors, _ = ParseColor(args, os.Getenv, stdoutIsTerminal())
+
+	artifact := ""
+	appPath, _ := takeStringFlag(args, "p")
+	if info, err := os.Stat(appPath); appPath != "" && err == nil && info.IsDir() {
+		artifact, err = packageAppPath(appPath)
+		if err != nil {
+			return err
+		}
+	}
+	spaceArgs := PromotionArgs(args, artifact)
+
+	fmt.Printf("Deploying to %s in turn.\n", strings.Join(promotion.Spaces, ", "))
+
+	err := promotion.Run(func(space string) error {
+		fmt.Println()
+		fmt.Println(colors.Success("Deploying to " + space))
+
+		home, err := SpaceHome(cfConfigPath(os.Getenv))
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(home)
+
+		target, err := CFHomeCommand(home, []string{"target", "-s", space}, os.Environ())
+		if err != nil {
+			return err
+		}
+		err = target.Run()
+		if err != nil {
+			return fmt.Errorf("cf target -s %s: %s", space, err)
+		}
+
+		push, err := CFHomeCommand(home, spaceArgs, os.Environ())
+		if err != nil {
+			return err
+		}
+		return push.Run()
+	}, func(from, to string) error {
+		if promotion.Confirm {
+			if !confirm(fmt.Sprintf("Deployed to %s. Promote to %s?", from, to)) {
+				return fmt.Errorf("the promotion to %s was declined", to)
+			}
+			return nil
+		}
+
+		if promotion.Pause > 0 {
+			fmt.Printf("Deployed to %s. Promoting to %s in %s; interrupt to stop here.\n", from, to, promotion.Pause)
+			time.Sleep(promotion.Pause)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(colors.Success("Deployed to " + strings.Join(promotion.Spaces, ", ")))
+	return nil
+}
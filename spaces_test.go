@@ -0,0 +1,110 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Promoting through spaces", func() {
+	Describe("ParsePromotion", func() {
+		It("takes the spaces and the gate between them", func() {
+			promotion, rest, err := ParsePromotion([]string{"zero-downtime-push", "app", "--spaces", "dev, staging,prod", "--pause-between", "30m", "-f", "manifest.yml"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotion).To(Equal(Promotion{Spaces: []string{"dev", "staging", "prod"}, Pause: 30 * time.Minute}))
+			Expect(rest).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+
+			promotion, _, err = ParsePromotion([]string{"zero-downtime-push", "app", "--spaces", "dev,prod", "--pause-between", "confirm"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotion.Confirm).To(BeTrue())
+		})
+
+		It("has no spaces without --spaces", func() {
+			promotion, _, err := ParsePromotion([]string{"zero-downtime-push", "app"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotion.Spaces).To(BeEmpty())
+		})
+
+		It("rejects a gate it cannot read, or without spaces", func() {
+			_, _, err := ParsePromotion([]string{"zero-downtime-push", "app", "--spaces", "dev,prod", "--pause-between", "later"})
+			Expect(err).To(MatchError(`--pause-between takes a duration, e.g. 30m, or confirm, not "later"`))
+
+			_, _, err = ParsePromotion([]string{"zero-downtime-push", "app", "--pause-between", "5m"})
+			Expect(err).To(MatchError("--pause-between needs --spaces"))
+
+			_, _, err = ParsePromotion([]string{"zero-downtime-push", "app", "--spaces", ","})
+			Expect(err).To(MatchError("--spaces lists no spaces"))
+		})
+	})
+
+	Describe("Run", func() {
+		promotion := Promotion{Spaces: []string{"dev", "staging", "prod"}}
+
+		It("deploys to each space in turn, through the gate", func() {
+			steps := []string{}
+			err := promotion.Run(func(space string) error {
+				steps = append(steps, "deploy "+space)
+				return nil
+			}, func(from, to string) error {
+				steps = append(steps, "gate "+from+" -> "+to)
+				return nil
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(steps).To(Equal([]string{"deploy dev", "gate dev -> staging", "deploy staging", "gate staging -> prod", "deploy prod"}))
+		})
+
+		It("stops at the first space that fails", func() {
+			deployed := []string{}
+			err := promotion.Run(func(space string) error {
+				deployed = append(deployed, space)
+				if space == "staging" {
+					return errors.New("exit status 1")
+				}
+				return nil
+			}, func(from, to string) error { return nil })
+
+			Expect(deployed).To(Equal([]string{"dev", "staging"}))
+			Expect(err).To(MatchError("Deployed to dev, but not to staging, prod, as the push to staging failed: exit status 1"))
+		})
+
+		It("stops where the gate is not passed", func() {
+			err := promotion.Run(func(space string) error { return nil }, func(from, to string) error {
+				if to == "prod" {
+					return errors.New("the promotion to prod was declined")
+				}
+				return nil
+			})
+
+			Expect(err).To(MatchError("Deployed to dev, staging, but not to prod, as the promotion to prod was declined"))
+		})
+	})
+
+	It("pushes the packaged app to every space instead of its directory", func() {
+		args := []string{"zero-downtime-push", "app", "-f", "manifest.yml", "-p", "build", "--package", "--", "-s", "cflinuxfs4"}
+
+		Expect(PromotionArgs(args, "/cache/app.zip")).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "-p", "/cache/app.zip", "--", "-s", "cflinuxfs4"}))
+		Expect(PromotionArgs(args, "")).To(Equal(args))
+	})
+
+	It("copies the cf CLI config for each space, leaving the user's target alone", func() {
+		dir, err := ioutil.TempDir("", "cf-home")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		configPath := filepath.Join(dir, "config.json")
+		Expect(ioutil.WriteFile(configPath, []byte(`{"SpaceFields":{"Name":"dev"}}`), 0600)).To(Succeed())
+
+		home, err := SpaceHome(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(home)
+
+		Expect(ioutil.ReadFile(filepath.Join(home, ".cf", "config.json"))).To(Equal([]byte(`{"SpaceFields":{"Name":"dev"}}`)))
+	})
+})
@@ -0,0 +1,58 @@
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// sshOutputLines is how much of a failed --verify-ssh command's output is
+// shown in the error.
+const sshOutputLines = 10
+
+// RunSSH runs the command in the app's first instance with cf ssh, and
+// returns its output. It fails when the command exits non-zero, or SSH is
+// disabled for the app or its space.
+func (repo *ApplicationRepo) RunSSH(appName, command string) ([]string, error) {
+	return repo.conn.CliCommandWithoutTerminalOutput("ssh", appName, "-i", "0", "-c", command)
+}
+
+// verifySSH runs the --verify-ssh command inside the new version, from
+// where it can reach what only the container network can.
+func (planner *DeploymentPlanner) verifySSH(appName, command string) error {
+	planner.Logger.Printf("Running %q in the new version of %s\n", command, appName)
+	output, err := planner.Repo.RunSSH(appName, command)
+	if err == nil {
+		return nil
+	}
+
+	if len(output) > sshOutputLines {
+		output = output[len(output)-sshOutputLines:]
+	}
+	err = fmt.Errorf("the --verify-ssh command %q failed in the new version of %s: %s. Check SSH is enabled, with cf enable-ssh %s", command, appName, err, appName)
+	if len(output) > 0 {
+		err = fmt.Errorf("%s. It printed:\n  %s", err, strings.Join(output, "\n  "))
+	}
+	return err
+}
+
+// verifySSHAction holds the cutover until the --verify-ssh command succeeds
+// inside the new version.
+func (planner *DeploymentPlanner) verifySSHAction(appName, command string) rewind.Action {
+	return rewind.Action{
+		Name: "verify over ssh",
+		Forward: func() error {
+			if command == "" {
+				return nil
+			}
+
+			return planner.verifySSH(appName, command)
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Run %s in the first instance of the new version of %s with cf ssh, and fail if it exits non-zero.",
+				describeChoice(command != "", fmt.Sprintf("%q", command), "the --verify-ssh command"), appName),
+			When: onlyWith("--verify-ssh", command != ""),
+		},
+	}
+}
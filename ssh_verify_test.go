@@ -0,0 +1,96 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Verifying over ssh", func() {
+	It("is read from the command line", func() {
+		_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--verify-ssh", "curl -f localhost:8080/health"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.VerifySSH).To(Equal("curl -f localhost:8080/health"))
+	})
+
+	It("cannot be combined with --premap-routes", func() {
+		_, _, _, _, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--verify-ssh", "true", "--premap-routes"})
+		Expect(err).To(MatchError(ContainSubstring("pass --verify-ssh first")))
+	})
+
+	It("runs the command in the app's first instance", func() {
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{}, nil)
+		cliConn.CliCommandWithoutTerminalOutputReturns([]string{"ok"}, nil)
+
+		output, err := NewApplicationRepo(cliConn).RunSSH("app", "curl -f localhost:8080/health")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal([]string{"ok"}))
+		Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"ssh", "app", "-i", "0", "-c", "curl -f localhost:8080/health"}))
+	})
+
+	Describe("before the cutover", func() {
+		var (
+			repo         *recordingRepo
+			planner      *DeploymentPlanner
+			options      AutopilotOptions
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			repo.routes["app"] = []string{"app.example.com"}
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: discardLogger{},
+			}
+			options = AutopilotOptions{VerifySSH: "curl -f localhost:8080/health"}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("moves the routes once the command succeeds in the new version", func() {
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  [--no-route]"))
+
+			verified := indexOf(repo.calls, "RunSSH app curl -f localhost:8080/health")
+			Expect(verified).To(BeNumerically(">", indexOf(repo.calls, "PushApplication app "+manifestPath+"  [--no-route]")))
+			Expect(verified).To(BeNumerically("<", indexOf(repo.calls, "MapRouteURLs app [app.example.com]")))
+		})
+
+		It("rolls the deploy back when the command fails, showing what it printed", func() {
+			repo.sshOutput = []string{"curl: (7) Failed to connect to localhost port 8080"}
+			repo.failures["RunSSH app curl -f localhost:8080/health"] = errors.New("exit status 7")
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", options)}.Execute()
+			Expect(err).To(MatchError(ContainSubstring(`the --verify-ssh command "curl -f localhost:8080/health" failed in the new version of app: exit status 7`)))
+			Expect(err).To(MatchError(ContainSubstring("cf enable-ssh app")))
+			Expect(err).To(MatchError(ContainSubstring("Failed to connect to localhost port 8080")))
+			Expect(repo.calls).ToNot(ContainElement("MapRouteURLs app [app.example.com]"))
+			Expect(repo.calls).To(ContainElement("DeleteApplication app"))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package autopilot
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ParseSkipSSLValidation takes the --skip-ssl-validation flag, which every
+// command accepts, out of args. Arguments after -- are left alone.
+func ParseSkipSSLValidation(args []string) (bool, []string) {
+	return takeBoolFlag(args, "skip-ssl-validation")
+}
+
+// SkipSSLValidation stops autopilot's own API calls checking certificates,
+// for lab foundations with self-signed ones.
+func (repo *ApplicationRepo) SkipSSLValidation() {
+	repo.skipSSLValidation = true
+}
+
+// sslWarning is shown whenever certificates will not be checked.
+func sslWarning(endpoint string) string {
+	return fmt.Sprintf("Warning: SSL validation is off, so the certificate of %s is not checked and the connection "+
+		"could be intercepted. Only skip it for lab foundations with self-signed certificates.", endpoint)
+}
+
+// proxyFor names the proxy requests to the endpoint go through, taken from
+// HTTPS_PROXY and NO_PROXY the same way the cf CLI does, or "" for none.
+func proxyFor(endpoint string) string {
+	request, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return ""
+	}
+
+	proxy, err := http.ProxyFromEnvironment(request)
+	if err != nil || proxy == nil {
+		return ""
+	}
+
+	return proxy.Host
+}
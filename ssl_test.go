@@ -0,0 +1,74 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("ParseSkipSSLValidation", func() {
+	It("takes the flag out of the args", func() {
+		skip, args := ParseSkipSSLValidation([]string{"zero-downtime-push", "app", "--skip-ssl-validation", "-f", "manifest.yml"})
+		Expect(skip).To(BeTrue())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("leaves arguments after -- alone", func() {
+		skip, args := ParseSkipSSLValidation([]string{"zero-downtime-push", "app", "--", "--skip-ssl-validation"})
+		Expect(skip).To(BeFalse())
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "--", "--skip-ssl-validation"}))
+	})
+})
+
+var _ = Describe("Skipping SSL validation", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+		api     *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		api = ghttp.NewTLSServer()
+		api.AllowUnhandledRequests = true
+		api.UnhandledRequestStatusCode = http.StatusOK
+
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(api.URL(), nil)
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{
+			SpaceFields: plugin_models.SpaceFields{Guid: "4"},
+		}, nil)
+
+		repo = NewApplicationRepo(cliConn)
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("checks the API's certificate by default", func() {
+		_, err := repo.DoesAppExist("app-name")
+		Expect(err).To(MatchError(ContainSubstring("certificate")))
+	})
+
+	It("does not check it with --skip-ssl-validation", func() {
+		repo.SkipSSLValidation()
+
+		_, err := repo.DoesAppExist("app-name")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("does not check it when the cf session skips validation", func() {
+		cliConn.IsSSLDisabledReturns(true, nil)
+
+		_, err := repo.DoesAppExist("app-name")
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,97 @@
+package autopilot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseStagedStart reads the --staged-start percentage, given as "25%" or
+// "25". An empty value turns staged starts off.
+func ParseStagedStart(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil || percent < 1 || percent > 100 {
+		return 0, fmt.Errorf("--staged-start %q should be a percentage between 1%% and 100%%", value)
+	}
+
+	return percent, nil
+}
+
+// StagedInstances lists the instance counts the new app is scaled through to
+// reach target, in steps of percent of it, always at least one instance.
+func StagedInstances(target, percent int) []int {
+	if target < 1 {
+		return []int{target}
+	}
+
+	step := (target*percent + 99) / 100
+	if step < 1 {
+		step = 1
+	}
+
+	counts := []int{}
+	for count := step; count < target; count += step {
+		counts = append(counts, count)
+	}
+
+	return append(counts, target)
+}
+
+// remainingInstances is what the old version is scaled down to while the new
+// one runs count of target instances. It keeps one instance until the old
+// version is retired.
+func remainingInstances(oldInstances, count, target int) int {
+	remaining := (oldInstances*(target-count) + target - 1) / target
+	if remaining < 1 {
+		return 1
+	}
+
+	return remaining
+}
+
+// ScaleInstances changes how many instances of the app run, without
+// restarting the ones already running.
+func (repo *ApplicationRepo) ScaleInstances(appName string, instances int) error {
+	return repo.cliCommand("scale", appName, "-i", strconv.Itoa(instances))
+}
+
+// stagedStart scales the new version up through the staged instance counts,
+// checking it is healthy at each step, and scales the old version down to
+// match, so both are never fully running at once.
+func (planner *DeploymentPlanner) stagedStart(appName, venerable string, oldInstances int, counts []int) error {
+	target := counts[len(counts)-1]
+	current := oldInstances
+
+	for i, count := range counts {
+		if i > 0 {
+			planner.Logger.Printf("Scaling the new version of the app to %d instances.\n", count)
+			err := planner.Repo.ScaleInstances(appName, count)
+			if err != nil {
+				return err
+			}
+
+			err = planner.Repo.CheckAppHealthy(appName)
+			if err != nil {
+				return err
+			}
+		}
+
+		remaining := remainingInstances(oldInstances, count, target)
+		if remaining == current {
+			continue
+		}
+
+		planner.Logger.Printf("Scaling the old version of the app to %d instances.\n", remaining)
+		err := planner.Repo.ScaleInstances(venerable, remaining)
+		if err != nil {
+			return err
+		}
+		current = remaining
+	}
+
+	return nil
+}
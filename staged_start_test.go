@@ -0,0 +1,29 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Staged starts", func() {
+	It("parses percentages", func() {
+		Expect(ParseStagedStart("25%")).To(Equal(25))
+		Expect(ParseStagedStart("50")).To(Equal(50))
+		Expect(ParseStagedStart("")).To(Equal(0))
+
+		_, err := ParseStagedStart("0%")
+		Expect(err).To(MatchError(`--staged-start "0%" should be a percentage between 1% and 100%`))
+
+		_, err = ParseStagedStart("a quarter")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("steps through the instance counts", func() {
+		Expect(StagedInstances(8, 25)).To(Equal([]int{2, 4, 6, 8}))
+		Expect(StagedInstances(10, 25)).To(Equal([]int{3, 6, 9, 10}))
+		Expect(StagedInstances(3, 10)).To(Equal([]int{1, 2, 3}))
+		Expect(StagedInstances(4, 100)).To(Equal([]int{4}))
+	})
+})
@@ -0,0 +1,198 @@
+package autopilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// DeployState is what a failed push leaves behind, so that once the cause is
+// fixed it can be resumed with --resume-from rather than started again.
+type DeployState struct {
+	App string `json:"app"`
+	// Steps are the names of the deploy's actions, in order.
+	Steps []string `json:"steps"`
+	// FailedAt is the step that failed, or empty if the deploy was
+	// interrupted.
+	FailedAt string `json:"failed_at,omitempty"`
+	Error    string `json:"error"`
+	// Undone are the steps that were undone after the failure.
+	Undone []string `json:"undone,omitempty"`
+	// RolledBack is true when the failure was rolled back in full, which
+	// leaves nothing to resume.
+	RolledBack bool      `json:"rolled_back"`
+	At         time.Time `json:"at"`
+
+	rollbackFailed bool
+}
+
+// NewDeployState starts the state of a push of the app with these actions.
+func NewDeployState(appName string, actions []rewind.Action) *DeployState {
+	steps := []string{}
+	for _, action := range actions {
+		steps = append(steps, action.Name)
+	}
+
+	return &DeployState{App: appName, Steps: steps}
+}
+
+// ObserveStep notes the step that failed and what was undone after it. It
+// does nothing on a nil state.
+func (state *DeployState) ObserveStep(name, phase string, start time.Time, err error) {
+	if state == nil {
+		return
+	}
+
+	switch {
+	case err != nil && phase == rewind.PhaseForward:
+		if state.FailedAt == "" {
+			state.FailedAt = name
+		}
+	case err != nil:
+		state.rollbackFailed = true
+	case phase == rewind.PhaseUndo:
+		state.Undone = append(state.Undone, name)
+	}
+}
+
+// Finish records how the deploy ended.
+func (state *DeployState) Finish(deployErr error, now time.Time) {
+	state.Error = deployErr.Error()
+	state.RolledBack = !state.rollbackFailed
+	state.At = now.UTC()
+}
+
+// DeployStateDir is where the state of failed deploys is kept.
+func DeployStateDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "autopilot", "state"), nil
+}
+
+// DeployStatePath is the state file of the app in the space the cf CLI
+// targets, as apps of the same name in other spaces are other apps.
+func (repo *ApplicationRepo) DeployStatePath(appName string) (string, error) {
+	dir, err := DeployStateDir()
+	if err != nil {
+		return "", err
+	}
+
+	space, err := repo.conn.GetCurrentSpace()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, space.Guid+"-"+appName+".json"), nil
+}
+
+// SaveDeployState writes the state, readable only by the user.
+func SaveDeployState(path string, state *DeployState) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, contents, 0600)
+}
+
+// LoadDeployState reads the state of the last failed deploy. The bool is
+// false if there is none.
+func LoadDeployState(path string) (DeployState, bool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DeployState{}, false, nil
+	}
+	if err != nil {
+		return DeployState{}, false, err
+	}
+
+	var state DeployState
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		return DeployState{}, false, fmt.Errorf("could not read the deploy state in %s: %s", path, err)
+	}
+
+	return state, true, nil
+}
+
+// CheckResume makes sure the deploy can resume from the step: the failed
+// deploy was not rolled back, and every step before it is still in effect.
+func CheckResume(state DeployState, step string) error {
+	if state.RolledBack {
+		return fmt.Errorf("The last deploy of %s failed at %q and was rolled back, so there is nothing to resume. Deploy it again instead.", state.App, state.FailedAt)
+	}
+
+	index := stepIndex(state.Steps, step)
+	if index < 0 {
+		return fmt.Errorf("--resume-from %q does not name a step of the failed deploy, which are: %s", step, strings.Join(state.Steps, ", "))
+	}
+
+	if state.FailedAt != "" && index > stepIndex(state.Steps, state.FailedAt) {
+		return fmt.Errorf("--resume-from %q is after %q, where the failed deploy stopped", step, state.FailedAt)
+	}
+
+	for _, undone := range state.Undone {
+		if stepIndex(state.Steps, undone) < index {
+			return fmt.Errorf("--resume-from %q would skip %q, which was undone after the failure. Resume from it, or an earlier step.", step, undone)
+		}
+	}
+
+	return nil
+}
+
+// stepIndex is the index of the named step, or -1.
+func stepIndex(names []string, name string) int {
+	for i, candidate := range names {
+		if candidate == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResumeActions plans the push again as the failed deploy planned it, which
+// the apps left behind would no longer tell, so that it can be resumed.
+func (planner *DeploymentPlanner) ResumeActions(appName, manifestPath, appPath string, options AutopilotOptions, state DeployState) ([]rewind.Action, error) {
+	planner.ResumeFrom = options.ResumeFrom
+
+	plans := [][]rewind.Action{
+		planner.ExistingAppActions(appName, manifestPath, appPath, options),
+		planner.StoppedAppActions(appName, manifestPath, appPath, options),
+		planner.NewAppActions(appName, manifestPath, appPath, options),
+	}
+	for _, actions := range plans {
+		if sameSteps(actions, state.Steps) {
+			return actions, nil
+		}
+	}
+
+	return nil, errors.New("The steps of this deploy differ from those of the failed one. Resume it with the options it was given.")
+}
+
+func sameSteps(actions []rewind.Action, steps []string) bool {
+	if len(actions) != len(steps) {
+		return false
+	}
+
+	for i, action := range actions {
+		if action.Name != steps[i] {
+			return false
+		}
+	}
+	return true
+}
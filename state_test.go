@@ -0,0 +1,108 @@
+package autopilot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Deploy state", func() {
+	failedState := func() DeployState {
+		return DeployState{
+			App:      "app",
+			Steps:    []string{"check quota", "remember routes", "rename live app", "push", "check test route", "retire old version"},
+			FailedAt: "check test route",
+			Undone:   []string{"push"},
+		}
+	}
+
+	Describe("ObserveStep", func() {
+		It("notes the step that failed and what was undone", func() {
+			state := NewDeployState("app", []rewind.Action{{Name: "rename live app"}, {Name: "push"}, {Name: "check test route"}})
+			Expect(state.Steps).To(Equal([]string{"rename live app", "push", "check test route"}))
+
+			state.ObserveStep("rename live app", rewind.PhaseForward, time.Now(), nil)
+			state.ObserveStep("push", rewind.PhaseForward, time.Now(), nil)
+			state.ObserveStep("check test route", rewind.PhaseForward, time.Now(), errors.New("404"))
+			state.ObserveStep("push", rewind.PhaseUndo, time.Now(), nil)
+			state.ObserveStep("rename live app", rewind.PhaseUndo, time.Now(), errors.New("name taken"))
+			state.Finish(errors.New("404"), time.Now())
+
+			Expect(state.FailedAt).To(Equal("check test route"))
+			Expect(state.Undone).To(Equal([]string{"push"}))
+			Expect(state.Error).To(Equal("404"))
+			Expect(state.RolledBack).To(BeFalse())
+		})
+
+		It("says a deploy whose rollback went through was rolled back", func() {
+			state := NewDeployState("app", []rewind.Action{{Name: "push"}})
+			state.ObserveStep("push", rewind.PhaseForward, time.Now(), errors.New("staging failed"))
+			state.Finish(errors.New("staging failed"), time.Now())
+
+			Expect(state.RolledBack).To(BeTrue())
+		})
+
+		It("does nothing without a state", func() {
+			var state *DeployState
+			state.ObserveStep("push", rewind.PhaseForward, time.Now(), errors.New("staging failed"))
+		})
+	})
+
+	Describe("CheckResume", func() {
+		It("resumes from a step whose predecessors are still in effect", func() {
+			Expect(CheckResume(failedState(), "push")).To(Succeed())
+			Expect(CheckResume(failedState(), "rename live app")).To(Succeed())
+		})
+
+		It("refuses a deploy that was rolled back", func() {
+			state := failedState()
+			state.RolledBack = true
+			Expect(CheckResume(state, "push")).To(MatchError(ContainSubstring("was rolled back, so there is nothing to resume")))
+		})
+
+		It("refuses a step the deploy does not have", func() {
+			Expect(CheckResume(failedState(), "deploy")).To(MatchError(HavePrefix(`--resume-from "deploy" does not name a step of the failed deploy, which are: check quota, remember routes`)))
+		})
+
+		It("refuses a step after the one that failed", func() {
+			Expect(CheckResume(failedState(), "retire old version")).To(MatchError(`--resume-from "retire old version" is after "check test route", where the failed deploy stopped`))
+		})
+
+		It("refuses to skip a step that was undone", func() {
+			Expect(CheckResume(failedState(), "check test route")).To(MatchError(HavePrefix(`--resume-from "check test route" would skip "push", which was undone after the failure.`)))
+		})
+	})
+
+	It("saves and loads the state", func() {
+		dir, err := ioutil.TempDir("", "autopilot-state")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "state", "space-guid-app.json")
+
+		_, found, err := LoadDeployState(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		state := failedState()
+		Expect(SaveDeployState(path, &state)).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		loaded, found, err := LoadDeployState(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(loaded.Steps).To(Equal(state.Steps))
+		Expect(loaded.FailedAt).To(Equal("check test route"))
+		Expect(loaded.Undone).To(Equal([]string{"push"}))
+	})
+})
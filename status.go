@@ -0,0 +1,118 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status describes the app and what it can be rolled back to: the old
+// versions kept beside it, and the revisions the Cloud Controller recorded.
+func (planner *DeploymentPlanner) Status(appName string) ([]string, error) {
+	appRepo := planner.Repo
+
+	exists, err := appRepo.DoesAppExist(appName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("App %s not found", appName)
+	}
+
+	lines := []string{fmt.Sprintf("App %s: %s", appName, planner.describeState(appName))}
+
+	names, err := appRepo.AppNames()
+	if err != nil {
+		return nil, err
+	}
+
+	lines = append(lines, "Old versions:")
+	copies := 0
+	for _, name := range names {
+		if !isCopyName(appName, name) {
+			continue
+		}
+		copies++
+
+		marker, managed, err := appRepo.ManagedMarker(name)
+		if err != nil {
+			return nil, err
+		}
+
+		made := "not marked as made by autopilot"
+		if managed && !marker.CreatedAt.IsZero() {
+			made = "made by autopilot at " + marker.CreatedAt.UTC().Format("2006-01-02 15:04 MST")
+		} else if managed {
+			made = "made by autopilot"
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s, %s", name, planner.describeState(name), made))
+	}
+	if copies == 0 {
+		lines = append(lines, "  none")
+	}
+
+	enabled, err := appRepo.RevisionsEnabled(appName)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return append(lines, "Revisions: not enabled"), nil
+	}
+
+	revisions, err := appRepo.Revisions(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	lines = append(lines, "Revisions:")
+	if len(revisions) == 0 {
+		lines = append(lines, "  none")
+	}
+	// newest first
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		notes := []string{}
+		if revision.Deployed {
+			notes = append(notes, "deployed")
+		}
+		if !revision.Deployable {
+			notes = append(notes, "droplet deleted")
+		}
+		if revision.Description != "" {
+			notes = append(notes, revision.Description)
+		}
+		if revision.CreatedAt != "" {
+			notes = append(notes, "created "+revision.CreatedAt)
+		}
+		lines = append(lines, fmt.Sprintf("  %d: %s", revision.Version, strings.Join(notes, ", ")))
+	}
+
+	return lines, nil
+}
+
+func (planner *DeploymentPlanner) describeState(appName string) string {
+	stopped, err := planner.Repo.IsAppStopped(appName)
+	if err != nil {
+		return fmt.Sprintf("unknown (%s)", err)
+	}
+	if stopped {
+		return "stopped"
+	}
+	return "running"
+}
+
+func showStatus(planner *DeploymentPlanner, args []string) error {
+	if len(args) < 2 {
+		return errors.New("zero-downtime-status needs the name of an app")
+	}
+
+	lines, err := planner.Status(args[1])
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
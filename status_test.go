@@ -0,0 +1,62 @@
+package autopilot_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Status", func() {
+	var (
+		repo    *recordingRepo
+		planner *DeploymentPlanner
+	)
+
+	BeforeEach(func() {
+		repo = newRecordingRepo()
+		planner = &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: &fakeClock{}, Logger: discardLogger{}}
+	})
+
+	It("lists the old versions and revisions the app can be rolled back to", func() {
+		for _, name := range []string{"app", "app-venerable", "app-20240607T1212", "other"} {
+			repo.existing[name] = true
+		}
+		repo.stopped["app-venerable"] = true
+		repo.stopped["app-20240607T1212"] = true
+		repo.unmanaged["app-venerable"] = true
+		repo.markers["app-20240607T1212"] = ManagedMarker{OriginalName: "app", CreatedAt: time.Date(2024, 6, 7, 12, 12, 0, 0, time.UTC)}
+		repo.revisions["app"] = []Revision{
+			{Version: 1, Description: "Initial revision."},
+			{Version: 2, Description: "New droplet deployed.", Deployable: true, Deployed: true, CreatedAt: "2024-06-07T12:12:00Z"},
+		}
+
+		Expect(planner.Status("app")).To(Equal([]string{
+			"App app: running",
+			"Old versions:",
+			"  app-20240607T1212: stopped, made by autopilot at 2024-06-07 12:12 UTC",
+			"  app-venerable: stopped, not marked as made by autopilot",
+			"Revisions:",
+			"  2: deployed, New droplet deployed., created 2024-06-07T12:12:00Z",
+			"  1: droplet deleted, Initial revision.",
+		}))
+	})
+
+	It("says when there is nothing to roll back to", func() {
+		repo.existing["app"] = true
+
+		Expect(planner.Status("app")).To(Equal([]string{
+			"App app: running",
+			"Old versions:",
+			"  none",
+			"Revisions: not enabled",
+		}))
+	})
+
+	It("needs the app to exist", func() {
+		_, err := planner.Status("app")
+		Expect(err).To(MatchError("App app not found"))
+	})
+})
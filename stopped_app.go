@@ -0,0 +1,152 @@
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// StoppedAppActions replaces a stopped app. Nothing is being served, so the
+// new version is simply pushed and verified before the old one is deleted,
+// without a test route, draining or moving routes between the two.
+func (planner *DeploymentPlanner) StoppedAppActions(appName, manifestPath, appPath string, options AutopilotOptions) []rewind.Action {
+	appRepo := planner.Repo
+	venerable := planner.Naming.VenerableName(appName)
+	rotation := planner.newServiceKeyRotation(appName, options.RotateServiceKeys)
+
+	// the stopped app's routes, which the new app should have too
+	var liveRoutes []string
+	// the app's entry in the manifest
+	var manifestApp ManifestApplication
+
+	return []rewind.Action{
+		// make sure no other app holds the manifest's routes
+		{
+			Name: "check routes available",
+			Forward: func() error {
+				manifest, err := ParseManifest(manifestPath)
+				if err != nil {
+					return err
+				}
+
+				manifestApp, _ = manifest.Application(appName)
+				err = checkRoutesFrom(manifestApp, manifestPath, options)
+				if err != nil {
+					return err
+				}
+
+				err = appRepo.CheckRoutesAvailable(appName, manifestApp.RouteURLs())
+				if err != nil {
+					return err
+				}
+
+				liveRoutes, err = appRepo.AppRoutes(appName)
+				return err
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Check no other app holds the routes declared in %s, and remember the routes of %s.", manifestPath, appName),
+			},
+		},
+		// and that the services it binds are there to bind
+		planner.checkServicesAction(appName, manifestPath),
+		// delete old version if it still exists
+		{
+			Name: "delete old venerable app",
+			Forward: func() error {
+				appExists, err := appRepo.DoesAppExist(venerable)
+				if err != nil || !appExists {
+					return err
+				}
+
+				err = planner.verifyManaged(appName, venerable, options.ForceNameCollision)
+				if err != nil {
+					return err
+				}
+
+				planner.Logger.Printf("Found old version of app, deleting.\n")
+				return appRepo.DeleteApplication(venerable)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Delete %s.", venerable),
+				When:    "only if it is left over from an earlier deploy, and marked as made by autopilot",
+			},
+		},
+		// fresh credentials for the new version
+		rotation.createAction(),
+		// rename, to free the name for the new version
+		{
+			Name: "rename stopped app",
+			Forward: func() error {
+				err := appRepo.RenameApplication(appName, venerable)
+				if err != nil {
+					return err
+				}
+
+				planner.markManaged(venerable, appName)
+				return nil
+			},
+			Undo: func() error {
+				return appRepo.RenameApplication(venerable, appName)
+			},
+			Description: rewind.Description{
+				Forward: fmt.Sprintf("Rename %s to %s.", appName, venerable),
+				Undo:    fmt.Sprintf("Rename %s back to %s.", venerable, appName),
+			},
+		},
+		// push
+		{
+			Name: "push",
+			Forward: func() error {
+				return planner.push(appName, manifestPath, appPath, rotation.pushOptions(options))
+			},
+			ReversePrevious: func() error {
+				appRepo.DeleteApplication(appName)
+				return nil
+			},
+			Undo: func() error {
+				return appRepo.DeleteApplication(appName)
+			},
+			Description: rewind.Description{
+				Forward:         fmt.Sprintf("Push the new version of %s with %s%s.", appName, manifestPath, describeLifecycle(options)),
+				ReversePrevious: "Delete the new version, if it was created.",
+				Undo:            "Delete the new version.",
+			},
+		},
+		// make sure the new app has its routes
+		{
+			Name: "verify routes",
+			Forward: func() error {
+				return planner.verifyRoutes(appName, liveRoutes, manifestApp, options)
+			},
+			Description: rewind.Description{
+				Forward: describeVerifyRoutes(options),
+			},
+		},
+		// retire the stopped app
+		{
+			Name: "retire old version",
+			Forward: func() error {
+				if options.KeepExisting {
+					planner.Logger.Printf("Keeping the stopped old version of the app. Remove the --keep-existing-app flag to delete it automatically.\n")
+					return nil
+				} else if options.UnmapRoute {
+					planner.Logger.Printf("Unmapping routes for the venerable app. Remove the --unmap-routes flag to delete the old version.\n")
+					return appRepo.UnmapRouteURLs(venerable, liveRoutes)
+				} else if options.KeepRunning {
+					planner.Logger.Printf("Keeping the stopped old version of the app, as the config file asks.\n")
+					return nil
+				}
+
+				planner.Logger.Printf("Deleting old version of app. Use the --keep-existing-app flag to preserve it.\n")
+				return appRepo.DeleteApplication(venerable)
+			},
+			Description: rewind.Description{
+				Forward: describeChoice(options.KeepExisting || options.KeepRunning, fmt.Sprintf("Keep %s, stopped, for a rollback.", venerable), describeRetirement(venerable, options)),
+			},
+		},
+		// the old version is gone, so its credentials can go too
+		rotation.deleteOldAction(options.keepsVenerable()),
+		// and it should hold nothing any more
+		planner.verifyRetiredAction(venerable, options.keepsVenerable()),
+	}
+}
@@ -0,0 +1,84 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Target is the org and space given with the app as org/space/app, to run
+// the command there rather than in the space the cf CLI targets.
+type Target struct {
+	Org   string
+	Space string
+}
+
+// targetCommands are the commands whose first argument is an app, so it
+// can be given as org/space/app.
+var targetCommands = map[string]bool{
+	"zero-downtime-push":     true,
+	"zero-downtime-plan":     true,
+	"zero-downtime-status":   true,
+	"zero-downtime-history":  true,
+	"zero-downtime-abort":    true,
+	"zero-downtime-rollback": true,
+	"zero-downtime-scale":    true,
+	"zero-downtime-delete":   true,
+	"zero-downtime-copy":     true,
+}
+
+// ParseTarget splits an app given as org/space/app, leaving the app name
+// in args. The Target is empty when the app is given on its own.
+func ParseTarget(args []string) (Target, []string, error) {
+	if len(args) < 2 || !targetCommands[args[0]] || !strings.Contains(args[1], "/") {
+		return Target{}, args, nil
+	}
+
+	parts := strings.Split(args[1], "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return Target{}, nil, fmt.Errorf("%q should be an app name, or org/space/app", args[1])
+	}
+
+	rest := append([]string{args[0], parts[2]}, args[2:]...)
+	return Target{Org: parts[0], Space: parts[1]}, rest, nil
+}
+
+// TargetArgs are the arguments of cf target for the org and space.
+func (target Target) TargetArgs() []string {
+	return []string{"target", "-o", target.Org, "-s", target.Space}
+}
+
+// runInTarget runs the command in a cf CLI targeting the org and space,
+// through a copy of the cf CLI config so the user's own target is left
+// alone, and exits with its status.
+func runInTarget(target Target, args []string) {
+	if spaces, _ := takeStringFlag(args, "spaces"); spaces != "" {
+		fatalIf(errors.New("--spaces promotes through the spaces of the org the cf CLI targets; give the app as org/space/app or use --spaces, not both"))
+	}
+
+	home, err := SpaceHome(cfConfigPath(os.Getenv))
+	fatalIf(err)
+	atExit(func() { os.RemoveAll(home) })
+
+	command, err := CFHomeCommand(home, target.TargetArgs(), os.Environ())
+	fatalIf(err)
+	command.Stdout, command.Stderr = nil, nil
+	output, err := command.CombinedOutput()
+	if err != nil {
+		fatalIf(fmt.Errorf("could not target org %s and space %s: %s", target.Org, target.Space, strings.TrimSpace(string(output))))
+	}
+
+	command, err = CFHomeCommand(home, args, os.Environ())
+	fatalIf(err)
+	err = command.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		cleanUp()
+		os.Exit(exitErr.ExitCode())
+	}
+	fatalIf(err)
+
+	cleanUp()
+	os.Exit(0)
+}
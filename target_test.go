@@ -0,0 +1,40 @@
+package autopilot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ParseTarget", func() {
+	It("takes the org and space from an app given as org/space/app", func() {
+		target, args, err := ParseTarget([]string{"zero-downtime-push", "payments/production/orders", "-f", "manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal(Target{Org: "payments", Space: "production"}))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "orders", "-f", "manifest.yml"}))
+		Expect(target.TargetArgs()).To(Equal([]string{"target", "-o", "payments", "-s", "production"}))
+	})
+
+	It("leaves an app given on its own alone", func() {
+		target, args, err := ParseTarget([]string{"zero-downtime-rollback", "orders"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal(Target{}))
+		Expect(args).To(Equal([]string{"zero-downtime-rollback", "orders"}))
+	})
+
+	It("leaves commands that do not take an app alone", func() {
+		target, args, err := ParseTarget([]string{"zero-downtime-push-batch", "-f", "deploys/apps.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal(Target{}))
+		Expect(args).To(Equal([]string{"zero-downtime-push-batch", "-f", "deploys/apps.yml"}))
+	})
+
+	It("rejects a target that is not org/space/app", func() {
+		_, _, err := ParseTarget([]string{"zero-downtime-push", "production/orders"})
+		Expect(err).To(MatchError(`"production/orders" should be an app name, or org/space/app`))
+
+		_, _, err = ParseTarget([]string{"zero-downtime-push", "payments//orders"})
+		Expect(err).To(MatchError(`"payments//orders" should be an app name, or org/space/app`))
+	})
+})
@@ -0,0 +1,314 @@
+package autopilot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telemetry records a span for every step of a deploy, and counts and times
+// the steps, then exports them with OTLP over HTTP (as JSON) when the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT variable is set.
+type Telemetry struct {
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	Client      *http.Client
+	// Redactor masks sensitive values in the errors exported.
+	Redactor *Redactor
+
+	command string
+	appName string
+	traceID string
+	rootID  string
+	start   time.Time
+
+	mutex sync.Mutex
+	spans []otlpSpan
+	steps map[stepKey]*stepStats
+}
+
+type stepKey struct {
+	name    string
+	phase   string
+	outcome string
+}
+
+type stepStats struct {
+	count    int
+	duration time.Duration
+}
+
+// NewTelemetryFromEnv returns nil, which records nothing, unless
+// OTEL_EXPORTER_OTLP_ENDPOINT is set. OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_SERVICE_NAME are honoured as well.
+func NewTelemetryFromEnv(command, appName string) *Telemetry {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return nil
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "autopilot"
+	}
+
+	return NewTelemetry(endpoint, parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")), serviceName, command, appName)
+}
+
+func NewTelemetry(endpoint string, headers map[string]string, serviceName, command, appName string) *Telemetry {
+	return &Telemetry{
+		Endpoint:    strings.TrimSuffix(endpoint, "/"),
+		Headers:     headers,
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		command:     command,
+		appName:     appName,
+		traceID:     randomID(16),
+		rootID:      randomID(8),
+		start:       time.Now(),
+		steps:       map[stepKey]*stepStats{},
+	}
+}
+
+// parseOTLPHeaders reads the "key=value,key=value" form of the OTLP headers
+// variable.
+func parseOTLPHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) != "" {
+			headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return headers
+}
+
+func randomID(bytes int) string {
+	id := make([]byte, bytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// ObserveStep is a rewind.Observer.
+func (telemetry *Telemetry) ObserveStep(name, phase string, start time.Time, err error) {
+	if telemetry == nil {
+		return
+	}
+
+	end := time.Now()
+	outcome := "success"
+	status := otlpStatus{Code: 1}
+	if err != nil {
+		outcome = "failure"
+		status = otlpStatus{Code: 2, Message: telemetry.Redactor.Redact(err.Error())}
+	}
+
+	telemetry.mutex.Lock()
+	defer telemetry.mutex.Unlock()
+
+	telemetry.spans = append(telemetry.spans, otlpSpan{
+		TraceID:           telemetry.traceID,
+		SpanID:            randomID(8),
+		ParentSpanID:      telemetry.rootID,
+		Name:              name,
+		Kind:              1,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []otlpAttribute{
+			stringAttribute("autopilot.step", name),
+			stringAttribute("autopilot.phase", phase),
+		},
+		Status: status,
+	})
+
+	key := stepKey{name: name, phase: phase, outcome: outcome}
+	if telemetry.steps[key] == nil {
+		telemetry.steps[key] = &stepStats{}
+	}
+	telemetry.steps[key].count++
+	telemetry.steps[key].duration += end.Sub(start)
+}
+
+// Export sends the deploy's trace, with a root span covering the whole
+// command, and its step metrics to the collector.
+func (telemetry *Telemetry) Export(deployErr error) error {
+	if telemetry == nil {
+		return nil
+	}
+
+	telemetry.mutex.Lock()
+	defer telemetry.mutex.Unlock()
+
+	end := time.Now()
+	status := otlpStatus{Code: 1}
+	if deployErr != nil {
+		status = otlpStatus{Code: 2, Message: telemetry.Redactor.Redact(deployErr.Error())}
+	}
+
+	root := otlpSpan{
+		TraceID:           telemetry.traceID,
+		SpanID:            telemetry.rootID,
+		Name:              telemetry.command,
+		Kind:              1,
+		StartTimeUnixNano: unixNano(telemetry.start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes:        []otlpAttribute{stringAttribute("cf.app.name", telemetry.appName)},
+		Status:            status,
+	}
+
+	traces := map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": telemetry.resource(),
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": otlpScope,
+				"spans": append([]otlpSpan{root}, telemetry.spans...),
+			}},
+		}},
+	}
+
+	err := telemetry.post("/v1/traces", traces)
+	if err != nil {
+		return err
+	}
+
+	return telemetry.post("/v1/metrics", telemetry.metrics(end))
+}
+
+func (telemetry *Telemetry) metrics(end time.Time) map[string]interface{} {
+	counts := []interface{}{}
+	durations := []interface{}{}
+	for key, stats := range telemetry.steps {
+		attributes := []otlpAttribute{
+			stringAttribute("cf.app.name", telemetry.appName),
+			stringAttribute("autopilot.command", telemetry.command),
+			stringAttribute("autopilot.step", key.name),
+			stringAttribute("autopilot.phase", key.phase),
+			stringAttribute("autopilot.outcome", key.outcome),
+		}
+
+		counts = append(counts, map[string]interface{}{
+			"attributes":        attributes,
+			"startTimeUnixNano": unixNano(telemetry.start),
+			"timeUnixNano":      unixNano(end),
+			"asInt":             strconv.Itoa(stats.count),
+		})
+		durations = append(durations, map[string]interface{}{
+			"attributes":        attributes,
+			"startTimeUnixNano": unixNano(telemetry.start),
+			"timeUnixNano":      unixNano(end),
+			"count":             strconv.Itoa(stats.count),
+			"sum":               stats.duration.Seconds() * 1000,
+		})
+	}
+
+	// each run reports only its own steps
+	const deltaTemporality = 1
+
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": telemetry.resource(),
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope": otlpScope,
+				"metrics": []interface{}{
+					map[string]interface{}{
+						"name":        "autopilot.steps",
+						"description": "Deploy steps run, by outcome",
+						"unit":        "1",
+						"sum": map[string]interface{}{
+							"aggregationTemporality": deltaTemporality,
+							"isMonotonic":            true,
+							"dataPoints":             counts,
+						},
+					},
+					map[string]interface{}{
+						"name":        "autopilot.step.duration",
+						"description": "Time taken by deploy steps",
+						"unit":        "ms",
+						"histogram": map[string]interface{}{
+							"aggregationTemporality": deltaTemporality,
+							"dataPoints":             durations,
+						},
+					},
+				},
+			}},
+		}},
+	}
+}
+
+func (telemetry *Telemetry) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"attributes": []otlpAttribute{stringAttribute("service.name", telemetry.ServiceName)},
+	}
+}
+
+func (telemetry *Telemetry) post(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", telemetry.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range telemetry.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := telemetry.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("OTLP export to %s failed: %s", telemetry.Endpoint+path, response.Status)
+	}
+
+	return nil
+}
+
+var otlpScope = map[string]string{"name": "autopilot"}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+// otlpStatus codes are 1 for ok and 2 for an error.
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]string{"stringValue": value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,108 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Telemetry", func() {
+	var (
+		collector *ghttp.Server
+		traces    map[string]interface{}
+		metrics   map[string]interface{}
+	)
+
+	decode := func(into *map[string]interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(json.Unmarshal(body, into)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		collector = ghttp.NewServer()
+		collector.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/traces"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer secret"),
+				ghttp.VerifyContentType("application/json"),
+				decode(&traces),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/metrics"),
+				decode(&metrics),
+			),
+		)
+	})
+
+	AfterEach(func() {
+		collector.Close()
+	})
+
+	It("exports a span per step under a span for the command", func() {
+		telemetry := NewTelemetry(collector.URL()+"/", map[string]string{"Authorization": "Bearer secret"}, "autopilot", "zero-downtime-push", "app")
+		telemetry.ObserveStep("push", "forward", time.Now(), nil)
+		telemetry.ObserveStep("verify routes", "forward", time.Now(), errors.New("missing routes"))
+
+		Expect(telemetry.Export(errors.New("missing routes"))).To(Succeed())
+
+		scopeSpans := traces["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})
+		spans := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+		Expect(spans).To(HaveLen(3))
+
+		root := spans[0].(map[string]interface{})
+		Expect(root["name"]).To(Equal("zero-downtime-push"))
+		Expect(root["status"]).To(Equal(map[string]interface{}{"code": 2.0, "message": "missing routes"}))
+
+		step := spans[1].(map[string]interface{})
+		Expect(step["name"]).To(Equal("push"))
+		Expect(step["parentSpanId"]).To(Equal(root["spanId"]))
+		Expect(step["traceId"]).To(Equal(root["traceId"]))
+
+		scopeMetrics := metrics["resourceMetrics"].([]interface{})[0].(map[string]interface{})["scopeMetrics"].([]interface{})
+		exported := scopeMetrics[0].(map[string]interface{})["metrics"].([]interface{})
+		Expect(exported).To(HaveLen(2))
+		Expect(exported[0].(map[string]interface{})["name"]).To(Equal("autopilot.steps"))
+		Expect(exported[1].(map[string]interface{})["name"]).To(Equal("autopilot.step.duration"))
+	})
+
+	It("masks sensitive values in the errors it exports", func() {
+		redactor, err := NewRedactor(nil)
+		Expect(err).ToNot(HaveOccurred())
+		redactor.Add("API_TOKEN", "t0ken-value")
+
+		telemetry := NewTelemetry(collector.URL(), map[string]string{"Authorization": "Bearer secret"}, "autopilot", "zero-downtime-push", "app")
+		telemetry.Redactor = redactor
+		telemetry.ObserveStep("push", "forward", time.Now(), errors.New("rejected t0ken-value"))
+		Expect(telemetry.Export(errors.New("rejected t0ken-value"))).To(Succeed())
+
+		scopeSpans := traces["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})
+		spans := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+		for _, span := range spans {
+			Expect(span.(map[string]interface{})["status"]).To(HaveKeyWithValue("message", "rejected [REDACTED]"))
+		}
+	})
+
+	It("reports collectors that refuse the export", func() {
+		collector.SetHandler(0, ghttp.RespondWith(http.StatusServiceUnavailable, ""))
+
+		telemetry := NewTelemetry(collector.URL(), nil, "autopilot", "zero-downtime-push", "app")
+		Expect(telemetry.Export(nil)).To(MatchError(ContainSubstring("503")))
+	})
+
+	It("does nothing without an endpoint", func() {
+		var telemetry *Telemetry
+		telemetry.ObserveStep("push", "forward", time.Now(), nil)
+		Expect(telemetry.Export(nil)).To(Succeed())
+	})
+})
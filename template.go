@@ -0,0 +1,75 @@
+package autopilot
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what settings given as Go templates can refer to, so that
+// one config works across many apps: {{.AppName}}, {{.Routes}} (the routes
+// the manifest declares, e.g. {{join .Routes ","}}), {{.Revision}} and the
+// --var values, e.g. {{.Vars.team}}.
+type TemplateData struct {
+	AppName  string
+	Routes   []string
+	Revision string
+	Vars     map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// TemplateData gathers what templates can refer to for a push of the app.
+func (options AutopilotOptions) TemplateData(appName string, app ManifestApplication) TemplateData {
+	vars := map[string]string{}
+	for name, value := range options.Vars {
+		vars[name] = value
+	}
+
+	return TemplateData{
+		AppName:  appName,
+		Routes:   app.RouteURLs(),
+		Revision: options.Revision,
+		Vars:     vars,
+	}
+}
+
+// ExpandTemplate fills in text, which is returned unchanged unless it holds
+// a template action. Referring to a --var that was not given is an error.
+func ExpandTemplate(name, text string, data TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %s", name, err)
+	}
+
+	var expanded bytes.Buffer
+	err = tmpl.Execute(&expanded, data)
+	if err != nil {
+		return "", fmt.Errorf("could not fill in the %s template: %s", name, err)
+	}
+
+	return expanded.String(), nil
+}
+
+// GitRevision is the short commit of the git checkout holding dir, or empty
+// if it is not in one, for {{.Revision}} when --revision is not given.
+func GitRevision(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,60 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ExpandTemplate", func() {
+	data := TemplateData{
+		AppName:  "orders",
+		Routes:   []string{"orders.example.com", "orders.example.org"},
+		Revision: "abc1234",
+		Vars:     map[string]string{"team": "payments"},
+	}
+
+	It("fills in the app name, routes, revision and vars", func() {
+		expanded, err := ExpandTemplate("test", `{{.AppName}} {{.Revision}} {{.Vars.team}} {{join .Routes ","}} {{.Routes}}`, data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expanded).To(Equal("orders abc1234 payments orders.example.com,orders.example.org [orders.example.com orders.example.org]"))
+	})
+
+	It("leaves text without template actions alone", func() {
+		Expect(ExpandTemplate("test", "orders-verify.example.com", data)).To(Equal("orders-verify.example.com"))
+	})
+
+	It("fails on missing vars and invalid templates", func() {
+		_, err := ExpandTemplate("test route", "{{.Vars.stage}}", data)
+		Expect(err).To(MatchError(ContainSubstring("could not fill in the test route template")))
+
+		_, err = ExpandTemplate("test route", "{{.AppName", data)
+		Expect(err).To(MatchError(ContainSubstring("invalid test route template")))
+	})
+
+	It("gathers the data from the push options and manifest", func() {
+		options := AutopilotOptions{Revision: "r1", Vars: EnvVars{"team": "payments"}}
+		app := ManifestApplication{Name: "orders", Routes: []ManifestRoute{{Route: "orders.example.com"}}}
+
+		Expect(options.TemplateData("orders", app)).To(Equal(TemplateData{
+			AppName:  "orders",
+			Routes:   []string{"orders.example.com"},
+			Revision: "r1",
+			Vars:     map[string]string{"team": "payments"},
+		}))
+	})
+})
+
+var _ = Describe("GitRevision", func() {
+	It("is empty outside a git checkout", func() {
+		dir, err := ioutil.TempDir("", "revision")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(GitRevision(dir)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,137 @@
+package autopilot
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// autoTestRoute is the --test-route value that picks the route itself.
+const autoTestRoute = "auto"
+
+var (
+	testRouteAttempts = 10
+	testRouteInterval = 3 * time.Second
+
+	// plainHTTPClient reports a redirect, to https or anywhere else, rather
+	// than following it.
+	plainHTTPClient = &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// ResolveTestRoute returns the temporary route to verify the new app on. For
+// "auto" it is autoHost, e.g. <app>-verify, on the domain of the app's first
+// route.
+func (repo *ApplicationRepo) ResolveTestRoute(appName, testRoute, autoHost string) (string, error) {
+	if testRoute != autoTestRoute {
+		return testRoute, nil
+	}
+
+	app, err := repo.conn.GetApp(appName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(app.Routes) == 0 {
+		return "", fmt.Errorf("App %s has no routes to pick a test route domain from, use --test-route host.domain instead", appName)
+	}
+
+	return fmt.Sprintf("%s.%s", autoHost, app.Routes[0].Domain.Name), nil
+}
+
+// ProbeURL requests url until it answers with a success or redirect status,
+// giving the router time to pick up a freshly mapped route.
+func ProbeURL(url string, attempts int, interval time.Duration) error {
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+
+		resp, err := probeClient.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 400 {
+			return nil
+		}
+		lastErr = fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("%s did not become healthy after %d attempts: %s", url, attempts, lastErr)
+}
+
+// CheckHTTPSOnly fails if the route answers a plain http request rather than
+// redirecting or refusing it, as the production routes' middleware would.
+func CheckHTTPSOnly(route string) error {
+	url := "http://" + route
+	resp, err := plainHTTPClient.Get(url)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return fmt.Errorf("%s answered plain http with status %d, but --test-route-https-only requires it to redirect or refuse it", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// checkTestRoute maps the new app to the test route, with the
+// --test-route-service bound to it, and checks the app answers there before
+// the route is unmapped again.
+func (planner *DeploymentPlanner) checkTestRoute(appName, testRoute string, options AutopilotOptions) (err error) {
+	appRepo := planner.Repo
+
+	planner.Logger.Printf("Checking the new version of the app on %s\n", testRoute)
+	err = appRepo.MapRouteURLs(appName, []string{testRoute})
+	if err != nil {
+		return err
+	}
+
+	if options.TestRouteService != "" {
+		err = appRepo.BindRouteServiceToURL(testRoute, options.TestRouteService)
+		if err != nil {
+			return err
+		}
+
+		// the test route outlives the deploy, so it does not keep the
+		// route service whether or not the check passes
+		defer func() {
+			unbindErr := appRepo.UnbindRouteServiceFromURL(testRoute, options.TestRouteService)
+			if err == nil {
+				err = unbindErr
+			}
+		}()
+	}
+
+	err = planner.probe("https://"+testRoute, options)
+	if err != nil {
+		return err
+	}
+
+	if options.TestRouteHTTPSOnly {
+		err = CheckHTTPSOnly(testRoute)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Warmup > 0 || options.WarmupRequests > 0 {
+		planner.Logger.Printf("Warming up the new version of the app on %s\n", testRoute)
+		result, err := WarmUp(planner.context(), "https://"+testRoute, options.Warmup, options.WarmupRequests, planner.Clock)
+		planner.Logger.Printf("Sent %d warm-up requests, %d succeeded.\n", result.Sent, result.Succeeded)
+		if err != nil {
+			return err
+		}
+	}
+
+	return appRepo.UnmapRouteURLs(appName, []string{testRoute})
+}
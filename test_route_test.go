@@ -0,0 +1,157 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+var _ = Describe("Test routes", func() {
+	Describe("ResolveTestRoute", func() {
+		var (
+			cliConn *pluginfakes.FakeCliConnection
+			repo    *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			cliConn = &pluginfakes.FakeCliConnection{}
+			repo = NewApplicationRepo(cliConn)
+		})
+
+		It("uses the given route", func() {
+			route, err := repo.ResolveTestRoute("app-name", "check.example.com", "app-name-verify")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route).To(Equal("check.example.com"))
+			Expect(cliConn.GetAppCallCount()).To(Equal(0))
+		})
+
+		It("picks a route on the app's domain for auto", func() {
+			cliConn.GetAppReturns(plugin_models.GetAppModel{
+				Routes: []plugin_models.GetApp_RouteSummary{
+					{Host: "app", Domain: plugin_models.GetApp_DomainFields{Name: "example.com"}},
+				},
+			}, nil)
+
+			route, err := repo.ResolveTestRoute("app-name", "auto", "app-name-verify")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(route).To(Equal("app-name-verify.example.com"))
+		})
+
+		It("cannot pick a route for an app without routes", func() {
+			_, err := repo.ResolveTestRoute("app-name", "auto", "app-name-verify")
+			Expect(err).To(MatchError("App app-name has no routes to pick a test route domain from, use --test-route host.domain instead"))
+		})
+	})
+
+	Describe("ProbeURL", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("retries until the app answers", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusNotFound, ""),
+				ghttp.RespondWith(http.StatusOK, "ok"),
+			)
+
+			Expect(ProbeURL(server.URL(), 3, time.Millisecond)).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("fails when the app never answers", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusBadGateway, ""),
+				ghttp.RespondWith(http.StatusBadGateway, ""),
+			)
+
+			err := ProbeURL(server.URL(), 2, time.Millisecond)
+			Expect(err).To(MatchError(server.URL() + " did not become healthy after 2 attempts: status 502"))
+		})
+	})
+
+	Describe("CheckHTTPSOnly", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("accepts a route that redirects plain http", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusMovedPermanently, "", http.Header{"Location": {"https://app.example.com/"}}))
+
+			Expect(CheckHTTPSOnly(strings.TrimPrefix(server.URL(), "http://"))).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("accepts a route that refuses plain http", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+
+			Expect(CheckHTTPSOnly(strings.TrimPrefix(server.URL(), "http://"))).To(Succeed())
+		})
+
+		It("fails when plain http is served", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "ok"))
+
+			err := CheckHTTPSOnly(strings.TrimPrefix(server.URL(), "http://"))
+			Expect(err).To(MatchError(server.URL() + " answered plain http with status 200, but --test-route-https-only requires it to redirect or refuse it"))
+		})
+	})
+
+	Describe("options", func() {
+		It("pick a test route when none is given", func() {
+			_, _, _, options, err := ParseArgs([]string{"zero-downtime-push", "app", "-f", "manifest.yml", "--test-route-service", "limiter", "--test-route-https-only"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.TestRoute).To(Equal("auto"))
+			Expect(options.TestRouteService).To(Equal("limiter"))
+			Expect(options.TestRouteHTTPSOnly).To(BeTrue())
+		})
+
+		It("bind the route service while the new app is checked, and unbind it though the check fails", func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			defer os.Remove(manifest.Name())
+
+			repo := newRecordingRepo()
+			repo.existing["app"] = true
+			planner := &DeploymentPlanner{Repo: repo, Naming: SuffixNaming{}, Clock: &fakeClock{}, Logger: discardLogger{}}
+
+			// localhost refuses https, so the check fails
+			options := AutopilotOptions{TestRoute: "localhost", TestRouteService: "limiter", Probe: Prober{Path: "/healthz", Count: 1}}
+			err = rewind.Actions{Actions: planner.ExistingAppActions("app", manifest.Name(), "", options)}.Execute()
+			Expect(err).To(HaveOccurred())
+
+			mapped := indexOf(repo.calls, "MapRouteURLs app [localhost]")
+			bound := indexOf(repo.calls, "BindRouteServiceToURL localhost limiter")
+			unbound := indexOf(repo.calls, "UnbindRouteServiceFromURL localhost limiter")
+			Expect(mapped).To(BeNumerically(">=", 0))
+			Expect(bound).To(BeNumerically(">", mapped))
+			Expect(unbound).To(BeNumerically(">", bound))
+			Expect(repo.calls).To(ContainElement("RenameApplication app-venerable app"))
+		})
+	})
+})
@@ -0,0 +1,122 @@
+package autopilot
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// The variables the cf CLI reads its staging and start timeouts from, in
+// minutes.
+const (
+	stagingTimeoutVar = "CF_STAGING_TIMEOUT"
+	startupTimeoutVar = "CF_STARTUP_TIMEOUT"
+)
+
+// Timeouts are how long the cf CLI waits for the new app to stage, and then
+// for its instances to start. Zero leaves the cf CLI's own.
+type Timeouts struct {
+	Staging time.Duration
+	Startup time.Duration
+}
+
+// ParseTimeouts takes the --staging-timeout and --app-start-timeout flags,
+// which every command accepts, out of args.
+func ParseTimeouts(args []string) (Timeouts, []string, error) {
+	timeouts := Timeouts{}
+	var err error
+
+	for _, flag := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"staging-timeout", &timeouts.Staging},
+		{"app-start-timeout", &timeouts.Startup},
+	} {
+		var value string
+		value, args = takeStringFlag(args, flag.name)
+		if value == "" {
+			continue
+		}
+
+		*flag.value, err = time.ParseDuration(value)
+		if err != nil || *flag.value <= 0 {
+			return Timeouts{}, nil, fmt.Errorf("--%s takes a duration, e.g. 15m, not %q", flag.name, value)
+		}
+	}
+
+	return timeouts, args, nil
+}
+
+// Env is the cf CLI's environment for the timeouts, rounded up to minutes.
+func (timeouts Timeouts) Env() map[string]string {
+	env := map[string]string{}
+	if timeouts.Staging > 0 {
+		env[stagingTimeoutVar] = strconv.Itoa(minutes(timeouts.Staging))
+	}
+	if timeouts.Startup > 0 {
+		env[startupTimeoutVar] = strconv.Itoa(minutes(timeouts.Startup))
+	}
+	return env
+}
+
+func minutes(d time.Duration) int {
+	return int((d + time.Minute - 1) / time.Minute)
+}
+
+// InEffect says whether the cf CLI running the plugin already has the
+// timeouts in its environment.
+func (timeouts Timeouts) InEffect(getenv func(string) string) bool {
+	for name, value := range timeouts.Env() {
+		if getenv(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// pushArgs is cf push's -t for the start timeout, in seconds. The cf start
+// after a push with --no-start goes by CF_STARTUP_TIMEOUT instead.
+func (timeouts Timeouts) pushArgs() []string {
+	if timeouts.Startup <= 0 {
+		return nil
+	}
+
+	seconds := int((timeouts.Startup + time.Second - 1) / time.Second)
+	return []string{"-t", strconv.Itoa(seconds)}
+}
+
+// TimeoutsCommand runs the same autopilot command in a cf CLI with the
+// timeouts in its environment. The cf CLI that started the plugin read its
+// timeouts when it started, so, as with --cf-home, only a separate cf
+// process picks them up.
+func TimeoutsCommand(timeouts Timeouts, args []string, environ []string) *exec.Cmd {
+	command := exec.Command("cf", args...)
+	command.Env = append([]string{}, environ...)
+	env := timeouts.Env()
+	for _, name := range []string{stagingTimeoutVar, startupTimeoutVar} {
+		// later entries win
+		if value, set := env[name]; set {
+			command.Env = append(command.Env, name+"="+value)
+		}
+	}
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	return command
+}
+
+// runWithTimeouts runs the command with the timeouts and exits with its
+// status.
+func runWithTimeouts(timeouts Timeouts, args []string) {
+	err := TimeoutsCommand(timeouts, args, os.Environ()).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	}
+	fatalIf(err)
+
+	os.Exit(0)
+}
@@ -0,0 +1,53 @@
+package autopilot_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Timeouts", func() {
+	It("takes the timeout flags out of the args", func() {
+		timeouts, args, err := ParseTimeouts([]string{"zero-downtime-push", "app", "--staging-timeout", "30m", "--app-start-timeout=90s", "-f", "manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeouts).To(Equal(Timeouts{Staging: 30 * time.Minute, Startup: 90 * time.Second}))
+		Expect(args).To(Equal([]string{"zero-downtime-push", "app", "-f", "manifest.yml"}))
+	})
+
+	It("rejects timeouts that are not durations", func() {
+		_, _, err := ParseTimeouts([]string{"zero-downtime-push", "app", "--staging-timeout", "30"})
+		Expect(err).To(MatchError(`--staging-timeout takes a duration, e.g. 15m, not "30"`))
+
+		_, _, err = ParseTimeouts([]string{"zero-downtime-push", "app", "--app-start-timeout", "-5m"})
+		Expect(err).To(MatchError(`--app-start-timeout takes a duration, e.g. 15m, not "-5m"`))
+	})
+
+	It("gives the cf CLI the timeouts in minutes, rounded up", func() {
+		Expect(Timeouts{Staging: 30 * time.Minute, Startup: 90 * time.Second}.Env()).To(Equal(map[string]string{
+			"CF_STAGING_TIMEOUT": "30",
+			"CF_STARTUP_TIMEOUT": "2",
+		}))
+		Expect(Timeouts{}.Env()).To(BeEmpty())
+	})
+
+	It("knows when the cf CLI already has the timeouts", func() {
+		env := map[string]string{"CF_STAGING_TIMEOUT": "30"}
+		getenv := func(name string) string { return env[name] }
+
+		Expect(Timeouts{}.InEffect(getenv)).To(BeTrue())
+		Expect(Timeouts{Staging: 30 * time.Minute}.InEffect(getenv)).To(BeTrue())
+		Expect(Timeouts{Staging: 45 * time.Minute}.InEffect(getenv)).To(BeFalse())
+		Expect(Timeouts{Staging: 30 * time.Minute, Startup: time.Minute}.InEffect(getenv)).To(BeFalse())
+	})
+
+	It("runs the command again in a cf CLI with the timeouts", func() {
+		timeouts := Timeouts{Staging: 30 * time.Minute, Startup: 5 * time.Minute}
+		command := TimeoutsCommand(timeouts, []string{"zero-downtime-push", "app", "--staging-timeout", "30m"}, []string{"HOME=/home/ci", "CF_STAGING_TIMEOUT=15"})
+
+		Expect(command.Args).To(Equal([]string{"cf", "zero-downtime-push", "app", "--staging-timeout", "30m"}))
+		Expect(command.Env).To(Equal([]string{"HOME=/home/ci", "CF_STAGING_TIMEOUT=15", "CF_STAGING_TIMEOUT=30", "CF_STARTUP_TIMEOUT=5"}))
+	})
+})
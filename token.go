@@ -0,0 +1,89 @@
+package autopilot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Without CF_STAGING_TIMEOUT and CF_STARTUP_TIMEOUT, the cf CLI waits this
+// long for a pushed app to stage, and then for its instances to start.
+const (
+	defaultStagingTimeout = 15 * time.Minute
+	defaultStartupTimeout = 5 * time.Minute
+)
+
+// longestCommand is how long a cf command can run at most: a push that waits
+// for the app to stage and then to start, going by the timeouts in the cf
+// CLI's environment.
+func longestCommand(getenv func(string) string) time.Duration {
+	longest := time.Duration(0)
+	for _, timeout := range []struct {
+		name         string
+		defaultValue time.Duration
+	}{
+		{stagingTimeoutVar, defaultStagingTimeout},
+		{startupTimeoutVar, defaultStartupTimeout},
+	} {
+		minutes, err := strconv.Atoi(getenv(timeout.name))
+		if err != nil || minutes <= 0 {
+			longest += timeout.defaultValue
+			continue
+		}
+		longest += time.Duration(minutes) * time.Minute
+	}
+
+	return longest
+}
+
+// tokenExpiry reads when a "bearer <jwt>" access token expires from its exp
+// claim. The bool is false if the token has none that can be read.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(token, "bearer "), "Bearer ")), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Exp, 0), true
+}
+
+// freshenToken makes sure the session's access token outlasts the cf command
+// about to run. A command turned away halfway through cannot be told apart
+// from any other failure, as the plugin connection only says the command
+// failed, so the token is refreshed beforehand instead: the first time, to
+// learn when it expires, and then whenever it would expire before the longest
+// command could finish. The cf CLI refreshes the token it keeps whenever a
+// plugin asks for it, as cf oauth-token does.
+func (repo *ApplicationRepo) freshenToken(command string) error {
+	if repo.tokenFetched {
+		if repo.tokenExpiry.IsZero() || time.Now().Add(longestCommand(os.Getenv)).Before(repo.tokenExpiry) {
+			return nil
+		}
+	}
+
+	token, err := repo.conn.AccessToken()
+	if err != nil {
+		return fmt.Errorf("The access token could not be refreshed before cf %s: %s", command, err)
+	}
+
+	// a token whose expiry can't be read is not refreshed again
+	repo.tokenExpiry, _ = tokenExpiry(token)
+	repo.tokenFetched = true
+	return nil
+}
@@ -0,0 +1,115 @@
+package autopilot_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+
+	"github.com/cloudfoundry/cli/plugin/pluginfakes"
+)
+
+// tokenExpiringIn is an access token as the cf CLI gives plugins, a JWT whose
+// exp claim is the given time from now.
+func tokenExpiringIn(d time.Duration) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	claims := fmt.Sprintf(`{"user_name":"ci","exp":%d}`, time.Now().Add(d).Unix())
+	return "bearer " + encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+var _ = Describe("Expiring tokens", func() {
+	var (
+		cliConn *pluginfakes.FakeCliConnection
+		repo    *ApplicationRepo
+	)
+
+	// the cf CLI hands out the tokens in turn, then the last one again
+	tokensInTurn := func(tokens ...string) {
+		cliConn.AccessTokenStub = func() (string, error) {
+			token := tokens[0]
+			if len(tokens) > 1 {
+				tokens = tokens[1:]
+			}
+			return token, nil
+		}
+	}
+
+	BeforeEach(func() {
+		cliConn = &pluginfakes.FakeCliConnection{}
+		repo = NewApplicationRepo(cliConn)
+		repo.SetVerbosity(QuietVerbosity)
+	})
+
+	It("fetches the token before the first command to learn when it expires", func() {
+		cliConn.AccessTokenReturns(tokenExpiringIn(time.Hour), nil)
+
+		Expect(repo.StopApplication("app")).To(Succeed())
+		Expect(repo.StartApplication("app")).To(Succeed())
+
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(1))
+		Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(2))
+	})
+
+	It("refreshes the token before a command it would expire during", func() {
+		tokensInTurn(tokenExpiringIn(10*time.Minute), tokenExpiringIn(time.Hour))
+
+		Expect(repo.StopApplication("app")).To(Succeed())
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(1))
+
+		Expect(repo.StartApplication("app")).To(Succeed())
+		Expect(repo.DeleteApplication("app-venerable")).To(Succeed())
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(2))
+	})
+
+	It("allows for the staging and start timeouts of the cf CLI", func() {
+		os.Setenv("CF_STAGING_TIMEOUT", "60")
+		defer os.Unsetenv("CF_STAGING_TIMEOUT")
+		cliConn.AccessTokenReturns(tokenExpiringIn(time.Hour), nil)
+
+		Expect(repo.StopApplication("app")).To(Succeed())
+		Expect(repo.StartApplication("app")).To(Succeed())
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(2))
+	})
+
+	It("does not refresh a token whose expiry can't be read again", func() {
+		cliConn.AccessTokenReturns("bearer some-token", nil)
+
+		Expect(repo.StopApplication("app")).To(Succeed())
+		Expect(repo.StartApplication("app")).To(Succeed())
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(1))
+	})
+
+	It("fails the command when the token can't be refreshed", func() {
+		cliConn.AccessTokenReturns("", errors.New("refresh token expired"))
+
+		Expect(repo.StopApplication("app")).To(MatchError("The access token could not be refreshed before cf stop: refresh token expired"))
+		Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+	})
+
+	It("does not run a failed command again", func() {
+		cliConn.AccessTokenReturns(tokenExpiringIn(time.Hour), nil)
+		// all the plugin connection gives back when a core command fails
+		cliConn.CliCommandWithoutTerminalOutputReturns([]string{}, errors.New("Error executing cli core command"))
+
+		Expect(repo.StopApplication("app")).To(MatchError("Error executing cli core command"))
+		Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(1))
+	})
+
+	It("refreshes the token before setting env variables", func() {
+		tokensInTurn(tokenExpiringIn(time.Minute), tokenExpiringIn(time.Hour))
+
+		captureStdout(func() {
+			Expect(repo.StopApplication("app")).To(Succeed())
+			Expect(repo.SetEnv("app", "DB_PASSWORD", "hunter22")).To(Succeed())
+		})
+		Expect(cliConn.AccessTokenCallCount()).To(Equal(2))
+		Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(1)).To(Equal([]string{"set-env", "app", "DB_PASSWORD", "hunter22"}))
+	})
+})
@@ -0,0 +1,164 @@
+package autopilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// digestAnnotation is set on the app by --skip-if-unchanged to the digest of
+// what was deployed. Renames keep annotations, so a rolled back app still
+// carries the digest of its own deploy.
+const digestAnnotation = "autopilot/deploy-digest"
+
+// deployInputs are what, besides the app's files, shape the new app, so a
+// push with a different --env or --memory is not taken for a repeat.
+type deployInputs struct {
+	Bits         string            `json:"bits"`
+	Manifest     string            `json:"manifest"`
+	Env          map[string]string `json:"env"`
+	Vars         map[string]string `json:"vars"`
+	Revision     string            `json:"revision"`
+	Scale        ScaleOptions      `json:"scale"`
+	Buildpacks   []string          `json:"buildpacks"`
+	Lifecycle    string            `json:"lifecycle"`
+	StartCommand string            `json:"start_command"`
+	HealthCheck  HealthCheck       `json:"health_check"`
+	PushArgs     []string          `json:"push_args"`
+}
+
+// DeployDigest is the SHA-256 of the app's files, as they would be pushed,
+// the manifest and the options that change the new app. Two pushes with the
+// same digest deploy the same thing.
+func DeployDigest(appName, manifestPath, appPath string, options AutopilotOptions) (string, error) {
+	manifest, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	bitsPath, err := appBitsPath(appName, manifestPath, appPath)
+	if err != nil {
+		return "", err
+	}
+
+	bits, err := bitsDigest(bitsPath)
+	if err != nil {
+		return "", fmt.Errorf("could not digest %s: %s", bitsPath, err)
+	}
+
+	manifestSum := sha256.Sum256(manifest)
+	inputs, err := json.Marshal(deployInputs{
+		Bits:         bits,
+		Manifest:     hex.EncodeToString(manifestSum[:]),
+		Env:          options.Env,
+		Vars:         options.Vars,
+		Revision:     options.Revision,
+		Scale:        options.Scale,
+		Buildpacks:   options.Buildpacks,
+		Lifecycle:    options.Lifecycle,
+		StartCommand: options.StartCommand,
+		HealthCheck:  options.HealthCheck,
+		PushArgs:     options.PushArgs,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(inputs)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// appBitsPath is where cf push takes the app's files from: -p, else the
+// manifest's path, else the working directory.
+func appBitsPath(appName, manifestPath, appPath string) (string, error) {
+	if appPath != "" {
+		return appPath, nil
+	}
+
+	manifest, err := ParseManifest(manifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	entry, _ := manifest.Application(appName)
+	if entry.Path == "" {
+		return ".", nil
+	}
+	if filepath.IsAbs(entry.Path) {
+		return entry.Path, nil
+	}
+	return filepath.Join(filepath.Dir(manifestPath), entry.Path), nil
+}
+
+// bitsDigest digests a directory as --package does, leaving out what its
+// .cfignore does, or an archive such as a jar as it is.
+func bitsDigest(bitsPath string) (string, error) {
+	info, err := os.Stat(bitsPath)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		hash := sha256.New()
+		err = copyFile(hash, bitsPath)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	ignore, err := loadCFIgnore(bitsPath)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := packageFiles(bitsPath, ignore)
+	if err != nil {
+		return "", err
+	}
+
+	return digestFiles(bitsPath, files)
+}
+
+// DeployedDigest is the digest --skip-if-unchanged noted on the app when it
+// was last deployed, or empty if there is none or no such app.
+func (repo *ApplicationRepo) DeployedDigest(appName string) (string, error) {
+	app, found, err := repo.findApp(appName)
+	if err != nil || !found {
+		return "", err
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return "", err
+	}
+
+	annotations, err := api.AppAnnotations(app.Metadata.Guid)
+	if err != nil {
+		return "", err
+	}
+
+	return annotations[digestAnnotation], nil
+}
+
+// RecordDeployDigest notes on the app the digest of what was deployed.
+func (repo *ApplicationRepo) RecordDeployDigest(appName, digest string) error {
+	app, found, err := repo.findApp(appName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("App %s not found", appName)
+	}
+
+	api, err := repo.client()
+	if err != nil {
+		return err
+	}
+
+	return api.UpdateAppAnnotations(app.Metadata.Guid, map[string]string{digestAnnotation: digest})
+}
@@ -0,0 +1,132 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/fakecc"
+)
+
+var _ = Describe("DeployDigest", func() {
+	var (
+		dir          string
+		manifestPath string
+	)
+
+	write := func(rel, content string) {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		Expect(os.MkdirAll(filepath.Dir(file), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).To(Succeed())
+	}
+
+	digest := func(appPath string, options AutopilotOptions) string {
+		digest, err := DeployDigest("app", manifestPath, appPath, options)
+		Expect(err).ToNot(HaveOccurred())
+		return digest
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "deploy")
+		Expect(err).ToNot(HaveOccurred())
+
+		manifestPath = filepath.Join(dir, "manifest.yml")
+		write("manifest.yml", "applications:\n- name: app\n  path: src\n")
+		write("src/app.rb", "v1")
+		write("src/.cfignore", "*.log\n")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("is the same for the same files, manifest and flags", func() {
+		options := AutopilotOptions{Env: EnvVars{"A": "1", "B": "2"}}
+		Expect(digest("", options)).To(Equal(digest(filepath.Join(dir, "src"), options)))
+		Expect(digest("", options)).To(HaveLen(64))
+	})
+
+	It("changes with the app's files, but not the ones .cfignore leaves out", func() {
+		before := digest("", AutopilotOptions{})
+
+		write("src/debug.log", "noise")
+		Expect(digest("", AutopilotOptions{})).To(Equal(before))
+
+		write("src/app.rb", "v2")
+		Expect(digest("", AutopilotOptions{})).ToNot(Equal(before))
+	})
+
+	It("changes with the manifest", func() {
+		before := digest("", AutopilotOptions{})
+
+		write("manifest.yml", "applications:\n- name: app\n  path: src\n  memory: 1G\n")
+		Expect(digest("", AutopilotOptions{})).ToNot(Equal(before))
+	})
+
+	It("changes with the flags that change the new app", func() {
+		before := digest("", AutopilotOptions{})
+
+		Expect(digest("", AutopilotOptions{Env: EnvVars{"A": "1"}})).ToNot(Equal(before))
+		Expect(digest("", AutopilotOptions{Scale: ScaleOptions{Memory: 1024}})).ToNot(Equal(before))
+		Expect(digest("", AutopilotOptions{PushArgs: []string{"--no-route"}})).ToNot(Equal(before))
+		Expect(digest("", AutopilotOptions{KeepExisting: true})).To(Equal(before))
+	})
+
+	It("digests an archive as it is", func() {
+		write("app.jar", "jar")
+		before := digest(filepath.Join(dir, "app.jar"), AutopilotOptions{})
+
+		write("app.jar", "new jar")
+		Expect(digest(filepath.Join(dir, "app.jar"), AutopilotOptions{})).ToNot(Equal(before))
+	})
+
+	It("fails when the app's files are missing", func() {
+		_, err := DeployDigest("app", manifestPath, filepath.Join(dir, "missing"), AutopilotOptions{})
+		Expect(err).To(MatchError(ContainSubstring("could not digest " + filepath.Join(dir, "missing"))))
+	})
+
+	Describe("against a Cloud Controller", func() {
+		var (
+			server  *fakecc.Server
+			appRepo *ApplicationRepo
+		)
+
+		BeforeEach(func() {
+			server = fakecc.NewServer()
+			appRepo = NewApplicationRepo(server.CLI())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("notes the digest on the app", func() {
+			server.AddApp("app")
+
+			deployed, err := appRepo.DeployedDigest("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployed).To(BeEmpty())
+
+			Expect(appRepo.RecordDeployDigest("app", "abc123")).To(Succeed())
+			app, _ := server.App("app")
+			Expect(app.Annotations).To(HaveKeyWithValue("autopilot/deploy-digest", "abc123"))
+
+			deployed, err = appRepo.DeployedDigest("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployed).To(Equal("abc123"))
+		})
+
+		It("has no digest for an app that does not exist", func() {
+			deployed, err := appRepo.DeployedDigest("app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployed).To(BeEmpty())
+
+			Expect(appRepo.RecordDeployDigest("app", "abc123")).To(MatchError("App app not found"))
+		})
+	})
+})
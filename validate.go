@@ -0,0 +1,104 @@
+package autopilot
+
+import (
+	"errors"
+	"strings"
+)
+
+// flagConflict is a combination of push flags that cannot all be honoured.
+// It is rejected before anything is changed, rather than the deploy
+// following one of the flags and quietly ignoring the others.
+type flagConflict struct {
+	given func(options AutopilotOptions, appPath string) bool
+	// message says why the flags conflict and what to give instead.
+	message string
+}
+
+var pushFlagConflicts = []flagConflict{
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.KeepExisting && options.UnmapRoute
+		},
+		message: "--keep-existing-app stops the old version, while --unmap-routes leaves it running without routes. Give only one of them.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.DeleteOrphanedRoutes && (options.KeepExisting || options.UnmapRoute)
+		},
+		message: "--delete-orphaned-routes deletes the old version's routes once it is deleted, which --keep-existing-app and --unmap-routes keep it from being. Leave out one or the other.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.DrainWait > 0 && options.UnmapRoute && !options.KeepExisting
+		},
+		message: "--drain-wait waits before the old version is stopped or deleted, while --unmap-routes leaves it running to finish its requests anyway. Leave out --drain-wait.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return appPath != "" && (pushArgGiven(options.PushArgs, "--docker-image", "-o") || options.Lifecycle == "docker")
+		},
+		message: "-p pushes the app's files, while a docker app, from --docker-image or --lifecycle docker, runs an image, which has none. Leave out -p, or push the files without the image.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return pushArgGiven(options.PushArgs, "--strategy")
+		},
+		message: "--strategy makes cf push replace the live app in place, while autopilot pushes the new version beside it and moves the routes over. Leave --strategy out of the cf push arguments.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.ProbeWatch.Enabled() && options.UnmapRoute
+		},
+		message: "--auto-rollback-on-probe-failure stops the old version to roll back to, while --unmap-routes leaves it running without routes. Give only one of them.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.PremapRoutes && (options.TestRoute != "" || options.ReadyLog.Enabled() || options.VerifySSH != "")
+		},
+		message: "--premap-routes gives the new app the production routes before it starts, so it cannot be checked on a test route, wait for --ready-log-pattern or pass --verify-ssh first. Leave out one or the other.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.PremapRoutes && options.CutoverOrder.Enabled()
+		},
+		message: "--premap-routes maps every production route to the new app before it starts, so they cannot be moved a domain at a time with --cutover-order. Leave out one or the other.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.ResumeFrom != "" && len(options.RotateServiceKeys) > 0
+		},
+		message: "--resume-from cannot resume a deploy with --rotate-service-keys, whose new keys only the failed deploy knew. Deploy again instead.",
+	},
+	{
+		given: func(options AutopilotOptions, appPath string) bool {
+			return options.ResumeFrom != "" && options.SkipIfUnchanged
+		},
+		message: "--skip-if-unchanged would skip the deploy --resume-from is to finish, as the failed deploy pushed the same files. Leave out --skip-if-unchanged.",
+	},
+}
+
+// ValidatePushOptions rejects push flags that contradict each other, saying
+// which and what to do about it.
+func ValidatePushOptions(options AutopilotOptions, appPath string) error {
+	for _, conflict := range pushFlagConflicts {
+		if conflict.given(options, appPath) {
+			return errors.New(conflict.message)
+		}
+	}
+
+	return nil
+}
+
+// pushArgGiven says whether the arguments passed on to cf push include any
+// of the flags, as "--flag value" or "--flag=value".
+func pushArgGiven(args []string, names ...string) bool {
+	for _, arg := range args {
+		for _, name := range names {
+			if arg == name || strings.HasPrefix(arg, name+"=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
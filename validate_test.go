@@ -0,0 +1,50 @@
+package autopilot_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("ValidatePushOptions", func() {
+	It("accepts flags that work together", func() {
+		Expect(ValidatePushOptions(AutopilotOptions{KeepExisting: true, DrainWait: time.Minute}, "app")).To(Succeed())
+		Expect(ValidatePushOptions(AutopilotOptions{PushArgs: []string{"--docker-image", "repo/app"}}, "")).To(Succeed())
+	})
+
+	It("rejects keeping the old version both stopped and running", func() {
+		err := ValidatePushOptions(AutopilotOptions{KeepExisting: true, UnmapRoute: true}, "")
+		Expect(err).To(MatchError(HavePrefix("--keep-existing-app stops the old version, while --unmap-routes leaves it running")))
+	})
+
+	It("rejects deleting the routes of an old version that is kept", func() {
+		err := ValidatePushOptions(AutopilotOptions{UnmapRoute: true, DeleteOrphanedRoutes: true}, "")
+		Expect(err).To(MatchError(HavePrefix("--delete-orphaned-routes deletes the old version's routes once it is deleted")))
+	})
+
+	It("rejects waiting for an old version that is never stopped", func() {
+		err := ValidatePushOptions(AutopilotOptions{UnmapRoute: true, DrainWait: time.Minute}, "")
+		Expect(err).To(MatchError(HavePrefix("--drain-wait waits before the old version is stopped or deleted")))
+	})
+
+	It("rejects app files for a docker image", func() {
+		err := ValidatePushOptions(AutopilotOptions{PushArgs: []string{"--docker-image=repo/app"}}, "app")
+		Expect(err).To(MatchError(HavePrefix("-p pushes the app's files, while a docker app")))
+
+		err = ValidatePushOptions(AutopilotOptions{Lifecycle: "docker"}, "app")
+		Expect(err).To(MatchError(HavePrefix("-p pushes the app's files, while a docker app")))
+	})
+
+	It("rejects cf push's own deployment strategy", func() {
+		err := ValidatePushOptions(AutopilotOptions{PushArgs: []string{"--strategy", "rolling"}}, "")
+		Expect(err).To(MatchError(HavePrefix("--strategy makes cf push replace the live app in place")))
+	})
+
+	It("rejects skipping a deploy that is resumed", func() {
+		err := ValidatePushOptions(AutopilotOptions{ResumeFrom: "push", SkipIfUnchanged: true}, "")
+		Expect(err).To(MatchError(HavePrefix("--skip-if-unchanged would skip the deploy --resume-from is to finish")))
+	})
+})
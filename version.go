@@ -0,0 +1,109 @@
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// Version is the version of the plugin, set when it is built from a git tag
+// with -ldflags "-X github.com/concourse/autopilot.Version=$(git describe
+// --tags)". Development builds keep the default.
+var Version = "0.0.3"
+
+// releasesURL is where --check-update looks for the latest release.
+var releasesURL = "https://api.github.com/repos/concourse/autopilot/releases/latest"
+
+// ParsePluginVersion turns a version such as "v1.4.2", or "1.4.2-3-gabc1234"
+// as git describe gives it for a commit after a tag, into the version the
+// cf CLI lists for the plugin. Parts it can't read are 0.
+func ParsePluginVersion(version string) plugin.VersionType {
+	parts := versionParts(version)
+	return plugin.VersionType{Major: parts[0], Minor: parts[1], Build: parts[2]}
+}
+
+func versionParts(version string) [3]int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := [3]int{}
+	for i, part := range strings.SplitN(version, ".", 3) {
+		parts[i], _ = strconv.Atoi(part)
+	}
+	return parts
+}
+
+// newerVersion says whether latest is a later release than current.
+func newerVersion(latest, current string) bool {
+	latestParts, currentParts := versionParts(latest), versionParts(current)
+	for i := range latestParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+	return false
+}
+
+// Release is a published release of the plugin.
+type Release struct {
+	Tag string `json:"tag_name"`
+	URL string `json:"html_url"`
+}
+
+// LatestRelease asks GitHub for the latest release of the plugin.
+func LatestRelease(url string) (Release, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	response, err := client.Get(url)
+	if err != nil {
+		return Release{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("%s answered %s", url, response.Status)
+	}
+
+	var release Release
+	err = json.NewDecoder(response.Body).Decode(&release)
+	return release, err
+}
+
+// DescribeVersion says which build of the plugin this is and, if latest is
+// known, whether there is a newer one.
+func DescribeVersion(version string, latest *Release) string {
+	description := fmt.Sprintf("autopilot %s\n", version)
+	if latest == nil {
+		return description
+	}
+
+	if newerVersion(latest.Tag, version) {
+		return description + fmt.Sprintf("A newer release, %s, is out: %s\n", latest.Tag, latest.URL)
+	}
+
+	return description + "This is the latest release.\n"
+}
+
+// showVersion prints the plugin's version and, with --check-update, whether
+// there is a newer release. A failed check is only a warning.
+func showVersion(checkUpdate bool) {
+	if !checkUpdate {
+		fmt.Print(DescribeVersion(Version, nil))
+		return
+	}
+
+	latest, err := LatestRelease(releasesURL)
+	if err != nil {
+		fmt.Print(DescribeVersion(Version, nil))
+		warnf("could not check for a newer release: %s\n", err)
+		return
+	}
+
+	fmt.Print(DescribeVersion(Version, &latest))
+}
@@ -0,0 +1,117 @@
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/concourse/autopilot/rewind"
+)
+
+// ConfigChange is a difference in environment or bound services between the
+// old and new versions of an app. Configuration set on the old version with
+// cf set-env or cf bind-service, and missing from the manifest, is lost when
+// the new version is pushed.
+type ConfigChange struct {
+	// Category is "env" or "services".
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	// Change is "removed", "added" or "changed".
+	Change string `json:"change"`
+	// Old and New are the variable's values, masked as the report is
+	// written when they are sensitive.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+func (change ConfigChange) String() string {
+	if change.Category == "services" {
+		switch change.Change {
+		case "removed":
+			return fmt.Sprintf("service %s is bound to the old version but not the new one", change.Name)
+		default:
+			return fmt.Sprintf("service %s is bound to the new version but not the old one", change.Name)
+		}
+	}
+
+	switch change.Change {
+	case "removed":
+		return fmt.Sprintf("%s is set on the old version but not the new one", change.Name)
+	case "added":
+		return fmt.Sprintf("%s is set on the new version but not the old one", change.Name)
+	default:
+		return fmt.Sprintf("%s has a different value on the new version", change.Name)
+	}
+}
+
+// CompareVersions lists how the new version's environment variables and
+// bound services differ from the old version's.
+func CompareVersions(before, after AppConfig) []ConfigChange {
+	changes := []ConfigChange{}
+
+	for _, name := range sortedKeys(before.Env) {
+		value, set := after.Env[name]
+		if !set {
+			changes = append(changes, ConfigChange{Category: "env", Name: name, Change: "removed", Old: before.Env[name]})
+		} else if value != before.Env[name] {
+			changes = append(changes, ConfigChange{Category: "env", Name: name, Change: "changed", Old: before.Env[name], New: value})
+		}
+	}
+	for _, name := range sortedKeys(after.Env) {
+		if _, set := before.Env[name]; !set {
+			changes = append(changes, ConfigChange{Category: "env", Name: name, Change: "added", New: after.Env[name]})
+		}
+	}
+
+	for _, service := range MissingRoutes(before.Services, after.Services) {
+		changes = append(changes, ConfigChange{Category: "services", Name: service, Change: "removed"})
+	}
+	for _, service := range MissingRoutes(after.Services, before.Services) {
+		changes = append(changes, ConfigChange{Category: "services", Name: service, Change: "added"})
+	}
+
+	return changes
+}
+
+// ConfigChanges lists how the new version's configuration differed from the
+// old version's, when CompareVersions was set.
+func (planner *DeploymentPlanner) ConfigChanges() []ConfigChange {
+	return planner.configChanges
+}
+
+// compareVersionsAction compares the configuration of the new version with
+// the old one's once it is pushed, while both still exist. Variables and
+// services the new version lacks are warned about, as the deploy goes on
+// regardless.
+func (planner *DeploymentPlanner) compareVersionsAction(appName, venerable string) rewind.Action {
+	return rewind.Action{
+		Name: "compare versions",
+		Forward: func() error {
+			planner.configChanges = nil
+			if !planner.CompareVersions {
+				return nil
+			}
+
+			before, err := planner.Repo.AppConfig(venerable)
+			if err != nil {
+				planner.Logger.Printf("Warning: could not read the configuration of %s to compare: %s\n", venerable, err)
+				return nil
+			}
+			after, err := planner.Repo.AppConfig(appName)
+			if err != nil {
+				planner.Logger.Printf("Warning: could not read the configuration of %s to compare: %s\n", appName, err)
+				return nil
+			}
+
+			planner.configChanges = CompareVersions(before, after)
+			for _, change := range planner.configChanges {
+				if change.Change == "removed" {
+					planner.Logger.Printf("Warning: %s.\n", change)
+				}
+			}
+			return nil
+		},
+		Description: rewind.Description{
+			Forward: fmt.Sprintf("Compare the environment variables and services of the new version of %s with those of %s, and warn about any the new version lacks.", appName, venerable),
+			When:    onlyWith("--report", planner.CompareVersions),
+		},
+	}
+}
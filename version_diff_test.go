@@ -0,0 +1,131 @@
+package autopilot_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+	"github.com/concourse/autopilot/rewind"
+)
+
+var _ = Describe("Comparing versions", func() {
+	It("lists the variables and services the new version lost, gained or changed", func() {
+		changes := CompareVersions(
+			AppConfig{
+				Env:      map[string]string{"FEATURE_X": "on", "LOG_LEVEL": "info", "DB_PASSWORD": "hunter22"},
+				Services: []string{"db", "cache"},
+			},
+			AppConfig{
+				Env:      map[string]string{"LOG_LEVEL": "debug", "DB_PASSWORD": "hunter22", "REGION": "eu"},
+				Services: []string{"db", "queue"},
+			},
+		)
+
+		Expect(changes).To(Equal([]ConfigChange{
+			{Category: "env", Name: "FEATURE_X", Change: "removed", Old: "on"},
+			{Category: "env", Name: "LOG_LEVEL", Change: "changed", Old: "info", New: "debug"},
+			{Category: "env", Name: "REGION", Change: "added", New: "eu"},
+			{Category: "services", Name: "cache", Change: "removed"},
+			{Category: "services", Name: "queue", Change: "added"},
+		}))
+		Expect(changes[0].String()).To(Equal("FEATURE_X is set on the old version but not the new one"))
+		Expect(changes[3].String()).To(Equal("service cache is bound to the old version but not the new one"))
+	})
+
+	It("finds nothing when the versions are configured alike", func() {
+		config := AppConfig{Env: map[string]string{"A": "1"}, Services: []string{"db"}}
+		Expect(CompareVersions(config, config)).To(BeEmpty())
+	})
+
+	Describe("during a push", func() {
+		var (
+			repo         *recordingRepo
+			logger       *recordingLogger
+			planner      *DeploymentPlanner
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			manifest, err := ioutil.TempFile("", "manifest")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manifest.WriteString("applications:\n- name: app\n")
+			Expect(err).ToNot(HaveOccurred())
+			manifest.Close()
+			manifestPath = manifest.Name()
+
+			repo = newRecordingRepo()
+			repo.existing["app"] = true
+			repo.configs["app-venerable"] = AppConfig{Env: map[string]string{"FEATURE_X": "on"}, Services: []string{"db"}}
+			repo.configs["app"] = AppConfig{Env: map[string]string{}, Services: []string{"db"}}
+			logger = &recordingLogger{}
+			planner = &DeploymentPlanner{
+				Repo:   repo,
+				Naming: SuffixNaming{},
+				Clock:  &fakeClock{},
+				Logger: logger,
+			}
+		})
+
+		AfterEach(func() {
+			os.Remove(manifestPath)
+		})
+
+		It("compares the new version with the old one while both exist, and warns about what it lost", func() {
+			planner.CompareVersions = true
+
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(planner.ConfigChanges()).To(Equal([]ConfigChange{{Category: "env", Name: "FEATURE_X", Change: "removed", Old: "on"}}))
+			Expect(logger.messages).To(ContainElement("Warning: FEATURE_X is set on the old version but not the new one.\n"))
+			Expect(repo.calls).To(ContainElement("PushApplication app " + manifestPath + "  []"))
+			Expect(indexOf(repo.calls, "AppConfig app-venerable")).To(BeNumerically(">", indexOf(repo.calls, "PushApplication app "+manifestPath+"  []")))
+			Expect(indexOf(repo.calls, "AppConfig app-venerable")).To(BeNumerically("<", indexOf(repo.calls, "DeleteApplication app-venerable")))
+		})
+
+		It("compares nothing without a report to record it in", func() {
+			err := rewind.Actions{Actions: planner.ExistingAppActions("app", manifestPath, "", AutopilotOptions{})}.Execute()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(planner.ConfigChanges()).To(BeEmpty())
+			Expect(repo.calls).ToNot(ContainElement("AppConfig app-venerable"))
+		})
+	})
+
+	It("masks sensitive values in the report", func() {
+		dir, err := ioutil.TempDir("", "report")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		redactor, err := NewRedactor(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		report := NewDeploymentReport("zero-downtime-push", "app", "guid", []string{})
+		report.Redactor = redactor
+		report.AddConfigChanges([]ConfigChange{
+			{Category: "env", Name: "API_TOKEN", Change: "changed", Old: "abcd1234", New: "efgh5678"},
+			{Category: "env", Name: "LOG_LEVEL", Change: "removed", Old: "info"},
+		})
+		report.Finish(nil, "new-guid", []string{})
+
+		path := filepath.Join(dir, "deployment-report.json")
+		Expect(report.Write(path)).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).ToNot(ContainSubstring("abcd1234"))
+		Expect(string(contents)).ToNot(ContainSubstring("efgh5678"))
+
+		var written struct {
+			ConfigChanges []ConfigChange `json:"config_changes"`
+		}
+		Expect(json.Unmarshal(contents, &written)).To(Succeed())
+		Expect(written.ConfigChanges).To(Equal([]ConfigChange{
+			{Category: "env", Name: "API_TOKEN", Change: "changed", Old: "[REDACTED]", New: "[REDACTED]"},
+			{Category: "env", Name: "LOG_LEVEL", Change: "removed", Old: "info"},
+		}))
+	})
+})
@@ -0,0 +1,59 @@
+package autopilot_test
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry/cli/plugin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Version", func() {
+	It("lists the plugin with the version it was built with", func() {
+		Expect(ParsePluginVersion("v1.4.2")).To(Equal(plugin.VersionType{Major: 1, Minor: 4, Build: 2}))
+		Expect(ParsePluginVersion("1.4.2-3-gabc1234-dirty")).To(Equal(plugin.VersionType{Major: 1, Minor: 4, Build: 2}))
+		Expect(ParsePluginVersion("2.1")).To(Equal(plugin.VersionType{Major: 2, Minor: 1}))
+		Expect(ParsePluginVersion("abc1234")).To(Equal(plugin.VersionType{}))
+		Expect(AutopilotPlugin{}.GetMetadata().Version).To(Equal(ParsePluginVersion(Version)))
+	})
+
+	It("says whether there is a newer release", func() {
+		Expect(DescribeVersion("1.4.2", nil)).To(Equal("autopilot 1.4.2\n"))
+		Expect(DescribeVersion("1.4.2", &Release{Tag: "v1.10.0", URL: "https://example.com/v1.10.0"})).To(Equal(
+			"autopilot 1.4.2\nA newer release, v1.10.0, is out: https://example.com/v1.10.0\n"))
+		Expect(DescribeVersion("1.4.2-3-gabc1234", &Release{Tag: "v1.4.2"})).To(Equal("autopilot 1.4.2-3-gabc1234\nThis is the latest release.\n"))
+	})
+
+	Describe("LatestRelease", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reads the latest release from GitHub", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/concourse/autopilot/releases/latest"),
+				ghttp.RespondWith(http.StatusOK, `{"tag_name":"v1.5.0","html_url":"https://github.com/concourse/autopilot/releases/tag/v1.5.0"}`),
+			))
+
+			release, err := LatestRelease(server.URL() + "/repos/concourse/autopilot/releases/latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release).To(Equal(Release{Tag: "v1.5.0", URL: "https://github.com/concourse/autopilot/releases/tag/v1.5.0"}))
+		})
+
+		It("fails when GitHub does not answer with a release", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, `{"message":"API rate limit exceeded"}`))
+
+			_, err := LatestRelease(server.URL())
+			Expect(err).To(MatchError(server.URL() + " answered 403 Forbidden"))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// warmupInterval is the pause between warm-up requests.
+	warmupInterval = 100 * time.Millisecond
+	// warmupLimit is how long --warmup-requests may take when no --warmup
+	// period is given.
+	warmupLimit = 5 * time.Minute
+)
+
+var probeClient = &http.Client{Timeout: 10 * time.Second}
+
+// WarmUpResult counts the requests sent while warming an app up.
+type WarmUpResult struct {
+	Sent      int
+	Succeeded int
+}
+
+// WarmUp sends requests to url one after another, so caches and JIT
+// compilers are warm before the app gets production traffic. With a number
+// of requests it stops once that many have succeeded, failing if that takes
+// longer than period (or warmupLimit, without a period). Otherwise it keeps
+// going for the whole period. It stops early, with ctx's error, once ctx is
+// done.
+func WarmUp(ctx context.Context, url string, period time.Duration, requests int, clock Clock) (WarmUpResult, error) {
+	result := WarmUpResult{}
+
+	limit := period
+	if requests > 0 && limit == 0 {
+		limit = warmupLimit
+	}
+
+	deadline := clock.Now().Add(limit)
+	for clock.Now().Before(deadline) {
+		result.Sent++
+		resp, err := probeClient.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 400 {
+				result.Succeeded++
+			}
+		}
+
+		if requests > 0 && result.Succeeded >= requests {
+			return result, nil
+		}
+
+		err = sleepContext(ctx, clock, warmupInterval)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if requests > 0 {
+		return result, fmt.Errorf("%s answered %d of %d warm-up requests within %s", url, result.Succeeded, requests, limit)
+	}
+
+	return result, nil
+}
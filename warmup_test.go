@@ -0,0 +1,64 @@
+package autopilot_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("WarmUp", func() {
+	var (
+		server *ghttp.Server
+		clock  *fakeClock
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.AllowUnhandledRequests = true
+		server.UnhandledRequestStatusCode = http.StatusOK
+		clock = &fakeClock{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("keeps sending requests for the whole period", func() {
+		result, err := WarmUp(context.Background(), server.URL(), time.Second, 0, clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(WarmUpResult{Sent: 10, Succeeded: 10}))
+		Expect(server.ReceivedRequests()).To(HaveLen(10))
+	})
+
+	It("stops once enough requests have succeeded", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, ""))
+
+		result, err := WarmUp(context.Background(), server.URL(), 0, 3, clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(WarmUpResult{Sent: 4, Succeeded: 3}))
+	})
+
+	It("fails when too few requests succeed within the period", func() {
+		server.UnhandledRequestStatusCode = http.StatusBadGateway
+
+		result, err := WarmUp(context.Background(), server.URL(), 300*time.Millisecond, 5, clock)
+		Expect(err).To(MatchError(server.URL() + " answered 0 of 5 warm-up requests within 300ms"))
+		Expect(result.Sent).To(Equal(3))
+	})
+
+	It("stops as soon as the deploy is interrupted", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := WarmUp(ctx, server.URL(), time.Second, 0, clock)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(result.Sent).To(Equal(1))
+		Expect(clock.slept).To(BeEmpty())
+	})
+})
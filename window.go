@@ -0,0 +1,165 @@
+package autopilot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // so time zones work on workers without a zoneinfo database
+)
+
+// DeployWindow is when deploys may change apps, such as
+// "Mon-Fri 09:00-17:00 Europe/Berlin". Several windows can be given,
+// separated by ";".
+type DeployWindow struct {
+	spec    string
+	windows []window
+}
+
+type window struct {
+	days     [7]bool
+	start    int // minutes after midnight
+	end      int
+	location *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseDeployWindow reads windows of the form "DAYS HH:MM-HH:MM [ZONE]".
+// Days are a list or range of weekdays, like "Mon-Fri" or "Mon,Wed", or "*"
+// for every day. The zone is an IANA name and defaults to UTC. A window that
+// ends before it starts runs past midnight.
+func ParseDeployWindow(spec string) (*DeployWindow, error) {
+	deployWindow := &DeployWindow{spec: spec}
+
+	for _, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid deploy window %q, use a form like \"Mon-Fri 09:00-17:00 Europe/Berlin\"", strings.TrimSpace(part))
+		}
+
+		w := window{location: time.UTC}
+
+		err := w.parseDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		times := strings.SplitN(fields[1], "-", 2)
+		if len(times) != 2 {
+			return nil, fmt.Errorf("invalid deploy window times %q, use HH:MM-HH:MM", fields[1])
+		}
+		if w.start, err = parseClock(times[0]); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseClock(times[1]); err != nil {
+			return nil, err
+		}
+
+		if len(fields) == 3 {
+			w.location, err = time.LoadLocation(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unknown time zone %q in deploy window", fields[2])
+			}
+		}
+
+		deployWindow.windows = append(deployWindow.windows, w)
+	}
+
+	return deployWindow, nil
+}
+
+func (w *window) parseDays(days string) error {
+	if days == "*" {
+		for i := range w.days {
+			w.days[i] = true
+		}
+		return nil
+	}
+
+	for _, item := range strings.Split(strings.ToLower(days), ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		first, ok := weekdays[bounds[0]]
+		if !ok {
+			return fmt.Errorf("invalid day %q in deploy window, use Mon, Tue, ...", bounds[0])
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, ok = weekdays[bounds[1]]
+			if !ok {
+				return fmt.Errorf("invalid day %q in deploy window, use Mon, Tue, ...", bounds[1])
+			}
+		}
+
+		// ranges like Fri-Mon wrap around the weekend
+		for day := first; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseClock(value string) (int, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) == 2 {
+		hours, hoursErr := strconv.Atoi(parts[0])
+		minutes, minutesErr := strconv.Atoi(parts[1])
+		if hoursErr == nil && minutesErr == nil && hours >= 0 && hours <= 24 && minutes >= 0 && minutes < 60 && hours*60+minutes <= 24*60 {
+			return hours*60 + minutes, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid time %q in deploy window, use HH:MM", value)
+}
+
+// Allows says whether a deploy may run at t.
+func (deployWindow *DeployWindow) Allows(t time.Time) bool {
+	for _, w := range deployWindow.windows {
+		if w.allows(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w window) allows(t time.Time) bool {
+	local := t.In(w.location)
+	minute := local.Hour()*60 + local.Minute()
+
+	if w.start < w.end {
+		return w.days[local.Weekday()] && minute >= w.start && minute < w.end
+	}
+
+	// past midnight, the window belongs to the day it started on
+	if minute >= w.start {
+		return w.days[local.Weekday()]
+	}
+	return minute < w.end && w.days[(local.Weekday()+6)%7]
+}
+
+func (deployWindow *DeployWindow) String() string {
+	return deployWindow.spec
+}
+
+// CheckDeployWindow fails outside the window, unless it is overridden.
+func CheckDeployWindow(deployWindow *DeployWindow, now time.Time, override bool) error {
+	if deployWindow == nil || deployWindow.Allows(now) {
+		return nil
+	}
+
+	if override {
+		warnf("deploying outside the deploy window (%s) because of --override-window.\n", deployWindow)
+		return nil
+	}
+
+	return fmt.Errorf("It is outside the deploy window (%s). Use --override-window to deploy anyway.", deployWindow)
+}
@@ -0,0 +1,62 @@
+package autopilot_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("DeployWindow", func() {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	It("allows deploys on the listed days and times in the zone", func() {
+		window, err := ParseDeployWindow("Mon-Fri 09:00-17:00 Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(window.Allows(time.Date(2024, 6, 7, 9, 0, 0, 0, berlin))).To(BeTrue())    // Friday
+		Expect(window.Allows(time.Date(2024, 6, 7, 7, 30, 0, 0, time.UTC))).To(BeTrue()) // 09:30 in Berlin
+		Expect(window.Allows(time.Date(2024, 6, 7, 17, 0, 0, 0, berlin))).To(BeFalse())
+		Expect(window.Allows(time.Date(2024, 6, 8, 10, 0, 0, 0, berlin))).To(BeFalse()) // Saturday
+	})
+
+	It("handles windows past midnight and several windows", func() {
+		window, err := ParseDeployWindow("Sat 22:00-02:00; Tue,Thu 12:00-13:00")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(window.Allows(time.Date(2024, 6, 8, 23, 0, 0, 0, time.UTC))).To(BeTrue())  // Saturday night
+		Expect(window.Allows(time.Date(2024, 6, 9, 1, 0, 0, 0, time.UTC))).To(BeTrue())   // Sunday morning
+		Expect(window.Allows(time.Date(2024, 6, 8, 1, 0, 0, 0, time.UTC))).To(BeFalse())  // Saturday morning
+		Expect(window.Allows(time.Date(2024, 6, 6, 12, 30, 0, 0, time.UTC))).To(BeTrue()) // Thursday
+	})
+
+	It("wraps day ranges around the weekend", func() {
+		window, err := ParseDeployWindow("Fri-Mon 00:00-24:00")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(window.Allows(time.Date(2024, 6, 9, 12, 0, 0, 0, time.UTC))).To(BeTrue())   // Sunday
+		Expect(window.Allows(time.Date(2024, 6, 11, 12, 0, 0, 0, time.UTC))).To(BeFalse()) // Tuesday
+	})
+
+	It("rejects invalid windows", func() {
+		_, err := ParseDeployWindow("weekdays")
+		Expect(err).To(MatchError(`invalid deploy window "weekdays", use a form like "Mon-Fri 09:00-17:00 Europe/Berlin"`))
+
+		_, err = ParseDeployWindow("Mon-Fri 9-17")
+		Expect(err).To(MatchError(`invalid time "9" in deploy window, use HH:MM`))
+
+		_, err = ParseDeployWindow("Mon-Fri 09:00-17:00 Mars/Olympus")
+		Expect(err).To(MatchError(`unknown time zone "Mars/Olympus" in deploy window`))
+	})
+
+	It("refuses to deploy outside the window unless overridden", func() {
+		window, _ := ParseDeployWindow("Mon-Fri 09:00-17:00 Europe/Berlin")
+		saturday := time.Date(2024, 6, 8, 10, 0, 0, 0, berlin)
+
+		Expect(CheckDeployWindow(window, saturday, false)).To(MatchError(
+			"It is outside the deploy window (Mon-Fri 09:00-17:00 Europe/Berlin). Use --override-window to deploy anyway."))
+		Expect(CheckDeployWindow(window, saturday, true)).To(Succeed())
+		Expect(CheckDeployWindow(nil, saturday, false)).To(Succeed())
+	})
+})
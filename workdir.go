@@ -0,0 +1,72 @@
+package autopilot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ResolveWorkdir makes relative manifest and app paths relative to workdir,
+// as given with --workdir, rather than to the directory autopilot runs in.
+// A manifest read from stdin and absolute paths are left alone.
+func ResolveWorkdir(workdir, manifestPath, appPath string) (string, string) {
+	if workdir == "" {
+		return manifestPath, appPath
+	}
+
+	resolve := func(path string) string {
+		if path == "" || path == StdinManifest || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(workdir, path)
+	}
+
+	return resolve(manifestPath), resolve(appPath)
+}
+
+// CheckDeployPaths makes sure the manifest and the app path, if given, can
+// be read, so that a mistyped path fails the deploy before the live app is
+// touched rather than after it has been renamed.
+func CheckDeployPaths(manifestPath, appPath string) error {
+	err := checkReadable("manifest", manifestPath, false)
+	if err != nil {
+		return err
+	}
+
+	if appPath == "" {
+		return nil
+	}
+	return checkReadable("app path", appPath, true)
+}
+
+// checkReadable opens path, and lists it if it is a directory, which only
+// dirOK allows.
+func checkReadable(what, path string, dirOK bool) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("the %s %s does not exist", what, path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the %s %s: %s", what, path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot read the %s %s: %s", what, path, err)
+	}
+
+	if !info.IsDir() {
+		return nil
+	}
+	if !dirOK {
+		return fmt.Errorf("the %s %s is a directory", what, path)
+	}
+
+	_, err = file.Readdirnames(1)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("cannot read the %s %s: %s", what, path, err)
+	}
+	return nil
+}
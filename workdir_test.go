@@ -0,0 +1,67 @@
+package autopilot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/MerrillCorporation/autopilot"
+)
+
+var _ = Describe("Working directory", func() {
+	It("resolves relative paths from the working directory", func() {
+		manifestPath, appPath := ResolveWorkdir("/src/orders", "manifest.yml", "build/libs/orders.jar")
+		Expect(manifestPath).To(Equal("/src/orders/manifest.yml"))
+		Expect(appPath).To(Equal("/src/orders/build/libs/orders.jar"))
+
+		manifestPath, appPath = ResolveWorkdir("/src/orders", "/etc/manifests/orders.yml", "")
+		Expect(manifestPath).To(Equal("/etc/manifests/orders.yml"))
+		Expect(appPath).To(BeEmpty())
+
+		manifestPath, _ = ResolveWorkdir("/src/orders", "-", "")
+		Expect(manifestPath).To(Equal("-"))
+	})
+
+	It("is given with --workdir", func() {
+		_, manifestPath, appPath, _, err := ParseArgs([]string{"zero-downtime-push", "appname", "-f", "manifest.yml", "-p", ".", "--workdir", "services/orders"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifestPath).To(Equal("services/orders/manifest.yml"))
+		Expect(appPath).To(Equal("services/orders"))
+	})
+
+	Describe("CheckDeployPaths", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "workdir")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "manifest.yml"), []byte("applications: []\n"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("accepts a manifest and an app directory or file that can be read", func() {
+			Expect(CheckDeployPaths(filepath.Join(dir, "manifest.yml"), dir)).To(Succeed())
+			Expect(CheckDeployPaths(filepath.Join(dir, "manifest.yml"), filepath.Join(dir, "manifest.yml"))).To(Succeed())
+			Expect(CheckDeployPaths(filepath.Join(dir, "manifest.yml"), "")).To(Succeed())
+		})
+
+		It("names the path that does not exist", func() {
+			err := CheckDeployPaths(filepath.Join(dir, "manfest.yml"), dir)
+			Expect(err).To(MatchError("the manifest " + filepath.Join(dir, "manfest.yml") + " does not exist"))
+
+			err = CheckDeployPaths(filepath.Join(dir, "manifest.yml"), filepath.Join(dir, "biuld"))
+			Expect(err).To(MatchError("the app path " + filepath.Join(dir, "biuld") + " does not exist"))
+		})
+
+		It("rejects a directory given as the manifest", func() {
+			Expect(CheckDeployPaths(dir, "")).To(MatchError("the manifest " + dir + " is a directory"))
+		})
+	})
+})